@@ -0,0 +1,113 @@
+package accesstokens
+
+import (
+	"crypto/sha256"
+	"database/sql/driver"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Prefix is the prefix of all personal access tokens, it makes it possible to tell them apart from other tokens.
+const Prefix = "sturdy_pat_"
+
+// IsAccessToken returns true if the plaintext token looks like a personal access token.
+func IsAccessToken(plaintext string) bool {
+	return strings.HasPrefix(plaintext, Prefix)
+}
+
+type Scope string
+
+const (
+	// ScopeCodebasesRead allows reading the codebases that the user has access to.
+	ScopeCodebasesRead Scope = "codebases:read"
+	// ScopeWorkspacesWrite allows making changes to workspaces, and everything that ScopeCodebasesRead allows.
+	ScopeWorkspacesWrite Scope = "workspaces:write"
+	// ScopeStatusesWrite allows reporting statuses on changes, and everything that ScopeCodebasesRead allows.
+	ScopeStatusesWrite Scope = "statuses:write"
+	// ScopeAdmin allows everything that the user can do, including managing members and tokens.
+	ScopeAdmin Scope = "admin"
+)
+
+var AllScopes = []Scope{ScopeCodebasesRead, ScopeWorkspacesWrite, ScopeStatusesWrite, ScopeAdmin}
+
+func (s Scope) Valid() bool {
+	for _, scope := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type Scopes []Scope
+
+// Has returns true if the scopes allow what the given scope allows.
+func (ss Scopes) Has(scope Scope) bool {
+	for _, s := range ss {
+		switch {
+		case s == scope, s == ScopeAdmin:
+			return true
+		case scope == ScopeCodebasesRead && (s == ScopeWorkspacesWrite || s == ScopeStatusesWrite):
+			return true
+		}
+	}
+	return false
+}
+
+func (ss *Scopes) Scan(src interface{}) error {
+	var arr pq.StringArray
+	if err := arr.Scan(src); err != nil {
+		return err
+	}
+	res := make(Scopes, 0, len(arr))
+	for _, s := range arr {
+		res = append(res, Scope(s))
+	}
+	*ss = res
+	return nil
+}
+
+func (ss Scopes) Value() (driver.Value, error) {
+	arr := make(pq.StringArray, 0, len(ss))
+	for _, s := range ss {
+		arr = append(arr, string(s))
+	}
+	return arr.Value()
+}
+
+// Token is a personal access token. It authenticates its user, with the permissions of the user limited to the
+// scopes of the token.
+type Token struct {
+	ID     string `db:"id"`
+	UserID string `db:"user_id"`
+	Name   string `db:"name"`
+	// Hash is the bcrypt hash of the secret of tokens from before SecretHash was used, it's nil for newer tokens.
+	Hash []byte `db:"hash"`
+	// SecretHash is the SHA-256 of the secret. The secret is random, so a fast hash is enough, and tokens are looked
+	// up by it.
+	SecretHash []byte     `db:"secret_hash"`
+	Scopes     Scopes     `db:"scopes"`
+	CreatedAt  time.Time  `db:"created_at"`
+	ExpiresAt  *time.Time `db:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+// HashSecret returns the SHA-256 of the secret of a token.
+func HashSecret(secret string) []byte {
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// VerifyLegacy verifies the secret of a token that only has a bcrypt hash.
+func (t *Token) VerifyLegacy(secret string) error {
+	return bcrypt.CompareHashAndPassword(t.Hash, []byte(secret))
+}
+
+// IsExpired returns true if the token has an expiry that has passed.
+func (t *Token) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"getsturdy.com/api/pkg/accesstokens"
+)
+
+var _ Repository = &memory{}
+
+type memory struct {
+	mu   sync.Mutex
+	byID map[string]accesstokens.Token
+}
+
+func NewMemory() Repository {
+	return &memory{byID: make(map[string]accesstokens.Token)}
+}
+
+func (m *memory) Create(_ context.Context, token *accesstokens.Token) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byID[token.ID] = *token
+	return nil
+}
+
+func (m *memory) Get(_ context.Context, id string) (*accesstokens.Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token, ok := m.byID[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &token, nil
+}
+
+func (m *memory) GetBySecretHash(_ context.Context, secretHash []byte) (*accesstokens.Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, token := range m.byID {
+		token := token
+		if token.SecretHash != nil && bytes.Equal(token.SecretHash, secretHash) {
+			return &token, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *memory) ListByUserID(_ context.Context, userID string) ([]*accesstokens.Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*accesstokens.Token
+	for _, token := range m.byID {
+		token := token
+		if token.UserID == userID && token.RevokedAt == nil {
+			res = append(res, &token)
+		}
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].CreatedAt.Before(res[b].CreatedAt)
+	})
+	return res, nil
+}
+
+func (m *memory) Update(_ context.Context, token *accesstokens.Token) error {
+	return m.update(token.ID, func(stored *accesstokens.Token) {
+		stored.RevokedAt = token.RevokedAt
+	})
+}
+
+func (m *memory) SetSecretHash(_ context.Context, id string, secretHash []byte) error {
+	return m.update(id, func(stored *accesstokens.Token) {
+		stored.SecretHash = secretHash
+	})
+}
+
+func (m *memory) SetLastUsedAt(_ context.Context, id string, lastUsedAt time.Time) error {
+	return m.update(id, func(stored *accesstokens.Token) {
+		stored.LastUsedAt = &lastUsedAt
+	})
+}
+
+func (m *memory) update(id string, fn func(*accesstokens.Token)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token, ok := m.byID[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	fn(&token)
+	m.byID[id] = token
+	return nil
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/accesstokens"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Create(context.Context, *accesstokens.Token) error
+	Get(ctx context.Context, id string) (*accesstokens.Token, error)
+	GetBySecretHash(ctx context.Context, secretHash []byte) (*accesstokens.Token, error)
+	ListByUserID(ctx context.Context, userID string) ([]*accesstokens.Token, error)
+	// Update updates when the token was revoked.
+	Update(context.Context, *accesstokens.Token) error
+	SetSecretHash(ctx context.Context, id string, secretHash []byte) error
+	SetLastUsedAt(ctx context.Context, id string, lastUsedAt time.Time) error
+}
+
+type database struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) Repository {
+	return &database{db: db}
+}
+
+func (r *database) Create(ctx context.Context, token *accesstokens.Token) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO personal_access_tokens
+			(id, user_id, name, hash, secret_hash, scopes, created_at, expires_at, last_used_at, revoked_at)
+		VALUES
+			(:id, :user_id, :name, :hash, :secret_hash, :scopes, :created_at, :expires_at, :last_used_at, :revoked_at)
+	`, token); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (r *database) Get(ctx context.Context, id string) (*accesstokens.Token, error) {
+	var res accesstokens.Token
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, user_id, name, hash, secret_hash, scopes, created_at, expires_at, last_used_at, revoked_at
+		FROM
+			personal_access_tokens
+		WHERE
+			id = $1
+	`, id); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *database) GetBySecretHash(ctx context.Context, secretHash []byte) (*accesstokens.Token, error) {
+	var res accesstokens.Token
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, user_id, name, hash, secret_hash, scopes, created_at, expires_at, last_used_at, revoked_at
+		FROM
+			personal_access_tokens
+		WHERE
+			secret_hash = $1
+	`, secretHash); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *database) ListByUserID(ctx context.Context, userID string) ([]*accesstokens.Token, error) {
+	var res []*accesstokens.Token
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, user_id, name, hash, secret_hash, scopes, created_at, expires_at, last_used_at, revoked_at
+		FROM
+			personal_access_tokens
+		WHERE
+			user_id = $1
+			AND revoked_at IS NULL
+		ORDER BY
+			created_at
+	`, userID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *database) Update(ctx context.Context, token *accesstokens.Token) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		UPDATE
+			personal_access_tokens
+		SET
+			revoked_at = :revoked_at
+		WHERE
+			id = :id
+	`, token); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (r *database) SetSecretHash(ctx context.Context, id string, secretHash []byte) error {
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE
+			personal_access_tokens
+		SET
+			secret_hash = $2
+		WHERE
+			id = $1
+	`, id, secretHash); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (r *database) SetLastUsedAt(ctx context.Context, id string, lastUsedAt time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE
+			personal_access_tokens
+		SET
+			last_used_at = $2
+		WHERE
+			id = $1
+	`, id, lastUsedAt); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
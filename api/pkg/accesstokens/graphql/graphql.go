@@ -0,0 +1,184 @@
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/accesstokens"
+	service_accesstokens "getsturdy.com/api/pkg/accesstokens/service"
+	"getsturdy.com/api/pkg/auth"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// scopes maps the values of the PersonalAccessTokenScope enum to scopes.
+var scopes = map[string]accesstokens.Scope{
+	"CodebasesRead":   accesstokens.ScopeCodebasesRead,
+	"WorkspacesWrite": accesstokens.ScopeWorkspacesWrite,
+	"StatusesWrite":   accesstokens.ScopeStatusesWrite,
+	"Admin":           accesstokens.ScopeAdmin,
+}
+
+type rootResolver struct {
+	accessTokensService *service_accesstokens.Service
+}
+
+func New(
+	accessTokensService *service_accesstokens.Service,
+) resolvers.AccessTokensRootResolver {
+	return &rootResolver{
+		accessTokensService: accessTokensService,
+	}
+}
+
+// authenticatedUserID returns the ID of the authenticated user. Tokens can only be managed with a session, or with
+// a token that has the admin scope.
+func authenticatedUserID(ctx context.Context) (string, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := auth.RequireScope(ctx, accesstokens.ScopeAdmin); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+func (r *rootResolver) PersonalAccessTokens(ctx context.Context) ([]resolvers.PersonalAccessTokenResolver, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	tokens, err := r.accessTokensService.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	res := make([]resolvers.PersonalAccessTokenResolver, 0, len(tokens))
+	for _, token := range tokens {
+		res = append(res, &resolver{token: token})
+	}
+	return res, nil
+}
+
+func (r *rootResolver) CreatePersonalAccessToken(ctx context.Context, args resolvers.CreatePersonalAccessTokenArgs) (resolvers.PersonalAccessTokenResolver, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	tokenScopes := make(accesstokens.Scopes, 0, len(args.Input.Scopes))
+	for _, s := range args.Input.Scopes {
+		scope, ok := scopes[s]
+		if !ok {
+			return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "scopes", fmt.Sprintf("unknown scope %q", s))
+		}
+		tokenScopes = append(tokenScopes, scope)
+	}
+
+	var expiresAt *time.Time
+	if args.Input.ExpiresAt != nil {
+		t := time.Unix(int64(*args.Input.ExpiresAt), 0)
+		expiresAt = &t
+	}
+
+	plainTextToken, token, err := r.accessTokensService.Create(ctx, userID, args.Input.Name, tokenScopes, expiresAt)
+	switch {
+	case err == nil:
+	case errors.Is(err, service_accesstokens.ErrInvalidScope):
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "scopes", err.Error())
+	case errors.Is(err, service_accesstokens.ErrInvalidExpiry):
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "expiresAt", err.Error())
+	default:
+		return nil, gqlerrors.Error(fmt.Errorf("failed to create token: %w", err))
+	}
+
+	return &resolver{token: token, plainTextToken: &plainTextToken}, nil
+}
+
+func (r *rootResolver) RevokePersonalAccessToken(ctx context.Context, args resolvers.RevokePersonalAccessTokenArgs) (resolvers.PersonalAccessTokenResolver, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	token, err := r.accessTokensService.Get(ctx, string(args.Input.ID))
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, gqlerrors.Error(gqlerrors.ErrNotFound)
+	default:
+		return nil, gqlerrors.Error(err)
+	}
+
+	if token.UserID != userID {
+		return nil, gqlerrors.Error(gqlerrors.ErrNotFound)
+	}
+
+	if token.RevokedAt == nil {
+		if err := r.accessTokensService.Revoke(ctx, token); err != nil {
+			return nil, gqlerrors.Error(err)
+		}
+	}
+
+	return &resolver{token: token}, nil
+}
+
+type resolver struct {
+	plainTextToken *string
+	token          *accesstokens.Token
+}
+
+func (r *resolver) ID() graphql.ID {
+	return graphql.ID(r.token.ID)
+}
+
+func (r *resolver) Name() string {
+	return r.token.Name
+}
+
+func (r *resolver) Scopes() []string {
+	res := make([]string, 0, len(r.token.Scopes))
+	for _, scope := range r.token.Scopes {
+		for name, s := range scopes {
+			if s == scope {
+				res = append(res, name)
+			}
+		}
+	}
+	return res
+}
+
+func (r *resolver) CreatedAt() int32 {
+	return int32(r.token.CreatedAt.Unix())
+}
+
+func (r *resolver) ExpiresAt() *int32 {
+	return unix(r.token.ExpiresAt)
+}
+
+func (r *resolver) LastUsedAt() *int32 {
+	return unix(r.token.LastUsedAt)
+}
+
+func (r *resolver) RevokedAt() *int32 {
+	return unix(r.token.RevokedAt)
+}
+
+func (r *resolver) Token() *string {
+	return r.plainTextToken
+}
+
+func unix(t *time.Time) *int32 {
+	if t == nil {
+		return nil
+	}
+	u := int32(t.Unix())
+	return &u
+}
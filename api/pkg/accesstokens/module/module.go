@@ -0,0 +1,14 @@
+package module
+
+import (
+	"getsturdy.com/api/pkg/accesstokens/db"
+	"getsturdy.com/api/pkg/accesstokens/graphql"
+	"getsturdy.com/api/pkg/accesstokens/service"
+	"getsturdy.com/api/pkg/di"
+)
+
+func Module(c *di.Container) {
+	c.Import(db.Module)
+	c.Import(graphql.Module)
+	c.Import(service.Module)
+}
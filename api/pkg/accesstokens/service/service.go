@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"getsturdy.com/api/pkg/accesstokens"
+	db_accesstokens "getsturdy.com/api/pkg/accesstokens/db"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// lastUsedInterval is how often the use of a token is written to the database.
+const lastUsedInterval = time.Minute
+
+var (
+	ErrUnauthenticated = errors.New("invalid token")
+	ErrInvalidScope    = errors.New("invalid scope")
+	ErrInvalidExpiry   = errors.New("expiry must be in the future")
+)
+
+type Service struct {
+	logger *zap.Logger
+	repo   db_accesstokens.Repository
+
+	lastUsedGuard sync.Mutex
+	lastUsed      map[string]time.Time
+	lastPruned    time.Time
+}
+
+func New(
+	logger *zap.Logger,
+	repo db_accesstokens.Repository,
+) *Service {
+	return &Service{
+		logger: logger.Named("accesstokens"),
+		repo:   repo,
+
+		lastUsed: make(map[string]time.Time),
+	}
+}
+
+// Create creates a personal access token for the user. Tokens without an expiry are valid until they are revoked.
+// It returns the token in plaintext, which is not stored.
+func (s *Service) Create(ctx context.Context, userID, name string, scopes accesstokens.Scopes, expiresAt *time.Time) (string, *accesstokens.Token, error) {
+	if len(scopes) == 0 {
+		return "", nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidScope)
+	}
+	for _, scope := range scopes {
+		if !scope.Valid() {
+			return "", nil, fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+	}
+
+	now := time.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return "", nil, ErrInvalidExpiry
+	}
+
+	secret := uuid.NewString()
+
+	token := &accesstokens.Token{
+		ID:         uuid.NewString(),
+		UserID:     userID,
+		Name:       name,
+		SecretHash: accesstokens.HashSecret(secret),
+		Scopes:     scopes,
+		CreatedAt:  now,
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.repo.Create(ctx, token); err != nil {
+		return "", nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return accesstokens.Prefix + token.ID + "_" + secret, token, nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*accesstokens.Token, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// ListByUserID returns the tokens of the user that are not revoked.
+func (s *Service) ListByUserID(ctx context.Context, userID string) ([]*accesstokens.Token, error) {
+	return s.repo.ListByUserID(ctx, userID)
+}
+
+func (s *Service) Revoke(ctx context.Context, token *accesstokens.Token) error {
+	now := time.Now()
+	token.RevokedAt = &now
+	if err := s.repo.Update(ctx, token); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// Authenticate returns the token that the plaintext token refers to, if it's neither revoked nor expired. Tokens are
+// looked up by the SHA-256 of their secret, so that authenticating doesn't need a bcrypt comparison on every request.
+func (s *Service) Authenticate(ctx context.Context, plaintext string) (*accesstokens.Token, error) {
+	if !accesstokens.IsAccessToken(plaintext) {
+		return nil, ErrUnauthenticated
+	}
+	parts := strings.SplitN(strings.TrimPrefix(plaintext, accesstokens.Prefix), "_", 2)
+	if len(parts) != 2 {
+		return nil, ErrUnauthenticated
+	}
+	id, secret := parts[0], parts[1]
+
+	secretHash := accesstokens.HashSecret(secret)
+	token, err := s.repo.GetBySecretHash(ctx, secretHash)
+	switch {
+	case err == nil:
+		if subtle.ConstantTimeCompare([]byte(token.ID), []byte(id)) != 1 {
+			return nil, ErrUnauthenticated
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		if token, err = s.authenticateLegacy(ctx, id, secret, secretHash); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	now := time.Now()
+	if token.RevokedAt != nil || token.IsExpired(now) {
+		return nil, ErrUnauthenticated
+	}
+
+	if s.shouldRecordUse(token.ID, now) {
+		if err := s.repo.SetLastUsedAt(ctx, token.ID, now); err != nil {
+			s.logger.Error("failed to update token", zap.Error(err))
+		} else {
+			token.LastUsedAt = &now
+		}
+	}
+
+	return token, nil
+}
+
+// authenticateLegacy authenticates tokens that were created before the SHA-256 of the secret was stored, and stores it
+// for them, so that the bcrypt comparison is only needed once per token.
+func (s *Service) authenticateLegacy(ctx context.Context, id, secret string, secretHash []byte) (*accesstokens.Token, error) {
+	token, err := s.repo.Get(ctx, id)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrUnauthenticated
+	default:
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	if token.SecretHash != nil || token.Hash == nil {
+		return nil, ErrUnauthenticated
+	}
+	if err := token.VerifyLegacy(secret); err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	if err := s.repo.SetSecretHash(ctx, token.ID, secretHash); err != nil {
+		s.logger.Error("failed to set the secret hash of the token", zap.Error(err))
+	} else {
+		token.SecretHash = secretHash
+	}
+	return token, nil
+}
+
+// shouldRecordUse returns true if the use of the token should be written to the database. To not write on every
+// request, it is only written once every lastUsedInterval. Entries that are older than that are not needed anymore,
+// and are pruned so that the map only holds the tokens that have been used recently.
+func (s *Service) shouldRecordUse(tokenID string, now time.Time) bool {
+	s.lastUsedGuard.Lock()
+	defer s.lastUsedGuard.Unlock()
+
+	if now.Sub(s.lastPruned) >= lastUsedInterval {
+		for id, lastUsed := range s.lastUsed {
+			if now.Sub(lastUsed) >= lastUsedInterval {
+				delete(s.lastUsed, id)
+			}
+		}
+		s.lastPruned = now
+	}
+
+	if lastUsed, ok := s.lastUsed[tokenID]; ok && now.Sub(lastUsed) < lastUsedInterval {
+		return false
+	}
+	s.lastUsed[tokenID] = now
+	return true
+}
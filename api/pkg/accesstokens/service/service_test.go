@@ -0,0 +1,140 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/accesstokens"
+	db_accesstokens "getsturdy.com/api/pkg/accesstokens/db"
+	"getsturdy.com/api/pkg/accesstokens/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	repo := db_accesstokens.NewMemory()
+	svc := service.New(zap.NewNop(), repo)
+
+	plaintext, token, err := svc.Create(ctx, "user-id", "ci", accesstokens.Scopes{accesstokens.ScopeCodebasesRead}, nil)
+	assert.NoError(t, err)
+	assert.True(t, accesstokens.IsAccessToken(plaintext))
+
+	authenticated, err := svc.Authenticate(ctx, plaintext)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "user-id", authenticated.UserID)
+		assert.NotNil(t, authenticated.LastUsedAt)
+	}
+
+	_, err = svc.Authenticate(ctx, plaintext+"x")
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+
+	_, err = svc.Authenticate(ctx, accesstokens.Prefix+"unknown_secret")
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+
+	assert.NoError(t, svc.Revoke(ctx, token))
+	_, err = svc.Authenticate(ctx, plaintext)
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+}
+
+func TestAuthenticate_expired(t *testing.T) {
+	ctx := context.Background()
+	repo := db_accesstokens.NewMemory()
+	svc := service.New(zap.NewNop(), repo)
+
+	expiresAt := time.Now().Add(time.Hour)
+	plaintext, token, err := svc.Create(ctx, "user-id", "ci", accesstokens.Scopes{accesstokens.ScopeAdmin}, &expiresAt)
+	assert.NoError(t, err)
+
+	_, err = svc.Authenticate(ctx, plaintext)
+	assert.NoError(t, err)
+
+	expired := time.Now().Add(-time.Minute)
+	token.ExpiresAt = &expired
+	assert.NoError(t, repo.Create(ctx, token))
+
+	_, err = svc.Authenticate(ctx, plaintext)
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+}
+
+func TestAuthenticate_legacy(t *testing.T) {
+	ctx := context.Background()
+	repo := db_accesstokens.NewMemory()
+	svc := service.New(zap.NewNop(), repo)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, &accesstokens.Token{
+		ID:        "token-id",
+		UserID:    "user-id",
+		Hash:      hash,
+		Scopes:    accesstokens.Scopes{accesstokens.ScopeCodebasesRead},
+		CreatedAt: time.Now(),
+	}))
+
+	_, err = svc.Authenticate(ctx, accesstokens.Prefix+"token-id_wrong")
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+
+	authenticated, err := svc.Authenticate(ctx, accesstokens.Prefix+"token-id_secret")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "user-id", authenticated.UserID)
+	}
+
+	// the token is looked up by the hash of the secret from now on
+	stored, err := repo.GetBySecretHash(ctx, accesstokens.HashSecret("secret"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "token-id", stored.ID)
+	}
+	_, err = svc.Authenticate(ctx, accesstokens.Prefix+"token-id_secret")
+	assert.NoError(t, err)
+}
+
+func TestAuthenticate_wrongID(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(zap.NewNop(), db_accesstokens.NewMemory())
+
+	plaintext, _, err := svc.Create(ctx, "user-id", "ci", accesstokens.Scopes{accesstokens.ScopeCodebasesRead}, nil)
+	require.NoError(t, err)
+	_, other, err := svc.Create(ctx, "other-user-id", "ci", accesstokens.Scopes{accesstokens.ScopeAdmin}, nil)
+	require.NoError(t, err)
+
+	parts := strings.SplitN(strings.TrimPrefix(plaintext, accesstokens.Prefix), "_", 2)
+	_, err = svc.Authenticate(ctx, accesstokens.Prefix+other.ID+"_"+parts[1])
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+}
+
+func TestCreate_invalid(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(zap.NewNop(), db_accesstokens.NewMemory())
+
+	_, _, err := svc.Create(ctx, "user-id", "ci", nil, nil)
+	assert.ErrorIs(t, err, service.ErrInvalidScope)
+
+	_, _, err = svc.Create(ctx, "user-id", "ci", accesstokens.Scopes{"everything"}, nil)
+	assert.ErrorIs(t, err, service.ErrInvalidScope)
+
+	past := time.Now().Add(-time.Hour)
+	_, _, err = svc.Create(ctx, "user-id", "ci", accesstokens.Scopes{accesstokens.ScopeAdmin}, &past)
+	assert.ErrorIs(t, err, service.ErrInvalidExpiry)
+}
+
+func TestScopes_Has(t *testing.T) {
+	read := accesstokens.Scopes{accesstokens.ScopeCodebasesRead}
+	assert.True(t, read.Has(accesstokens.ScopeCodebasesRead))
+	assert.False(t, read.Has(accesstokens.ScopeWorkspacesWrite))
+
+	write := accesstokens.Scopes{accesstokens.ScopeWorkspacesWrite}
+	assert.True(t, write.Has(accesstokens.ScopeCodebasesRead))
+	assert.False(t, write.Has(accesstokens.ScopeStatusesWrite))
+	assert.False(t, write.Has(accesstokens.ScopeAdmin))
+
+	admin := accesstokens.Scopes{accesstokens.ScopeAdmin}
+	for _, scope := range accesstokens.AllScopes {
+		assert.True(t, admin.Has(scope))
+	}
+}
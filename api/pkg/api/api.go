@@ -4,13 +4,22 @@ import (
 	"context"
 	"fmt"
 
+	worker_autorevert "getsturdy.com/api/pkg/autorevert/worker"
+	worker_change "getsturdy.com/api/pkg/change/worker"
 	workers_ci "getsturdy.com/api/pkg/ci/workers"
 	worker_gc "getsturdy.com/api/pkg/gc/worker"
 	"getsturdy.com/api/pkg/gitserver"
 	httpx "getsturdy.com/api/pkg/http"
+	worker_jwt "getsturdy.com/api/pkg/jwt/worker"
+	worker_maintenance "getsturdy.com/api/pkg/maintenance/worker"
 	"getsturdy.com/api/pkg/metrics"
+	worker_chat "getsturdy.com/api/pkg/notification/chat/worker"
+	worker_digest "getsturdy.com/api/pkg/notification/digest/worker"
 	"getsturdy.com/api/pkg/pprof"
+	worker_owners "getsturdy.com/api/pkg/review/owners/worker"
+	worker_review "getsturdy.com/api/pkg/review/worker"
 	worker_snapshots "getsturdy.com/api/pkg/snapshots/worker"
+	worker_webhooks "getsturdy.com/api/pkg/webhooks/worker"
 
 	"golang.org/x/sync/errgroup"
 )
@@ -24,6 +33,19 @@ type API struct {
 	snapshotterQueue worker_snapshots.Queue
 	ciBuildQueue     *workers_ci.BuildQueue
 	gcQueue          *worker_gc.Queue
+	changelogQueue   *worker_change.Queue
+	maintenanceQueue *worker_maintenance.Queue
+	maintenanceSched *worker_maintenance.Scheduler
+	autoRevertQueue  *worker_autorevert.Queue
+	autoRevertSched  *worker_autorevert.Scheduler
+	ownersQueue      *worker_owners.Queue
+	reviewStaleQueue *worker_review.Queue
+	webhooksEvents   *worker_webhooks.EventsQueue
+	webhooksDeliver  *worker_webhooks.DeliveriesQueue
+	webhooksSched    *worker_webhooks.Scheduler
+	digestSched      *worker_digest.Scheduler
+	chatQueue        *worker_chat.Queue
+	jwtKeysSched     *worker_jwt.Scheduler
 	gitsrv           *gitserver.Server
 	pprof            *pprof.Server
 	metrics          *metrics.Server
@@ -34,6 +56,19 @@ func ProvideAPI(
 	snapshotterQueue worker_snapshots.Queue,
 	ciBuildQueue *workers_ci.BuildQueue,
 	gcQueue *worker_gc.Queue,
+	changelogQueue *worker_change.Queue,
+	maintenanceQueue *worker_maintenance.Queue,
+	maintenanceSched *worker_maintenance.Scheduler,
+	autoRevertQueue *worker_autorevert.Queue,
+	autoRevertSched *worker_autorevert.Scheduler,
+	ownersQueue *worker_owners.Queue,
+	reviewStaleQueue *worker_review.Queue,
+	webhooksEvents *worker_webhooks.EventsQueue,
+	webhooksDeliver *worker_webhooks.DeliveriesQueue,
+	webhooksSched *worker_webhooks.Scheduler,
+	digestSched *worker_digest.Scheduler,
+	chatQueue *worker_chat.Queue,
+	jwtKeysSched *worker_jwt.Scheduler,
 	gitsrv *gitserver.Server,
 	pprof *pprof.Server,
 	metrics *metrics.Server,
@@ -43,6 +78,19 @@ func ProvideAPI(
 		snapshotterQueue: snapshotterQueue,
 		ciBuildQueue:     ciBuildQueue,
 		gcQueue:          gcQueue,
+		changelogQueue:   changelogQueue,
+		maintenanceQueue: maintenanceQueue,
+		maintenanceSched: maintenanceSched,
+		autoRevertQueue:  autoRevertQueue,
+		autoRevertSched:  autoRevertSched,
+		ownersQueue:      ownersQueue,
+		reviewStaleQueue: reviewStaleQueue,
+		webhooksEvents:   webhooksEvents,
+		webhooksDeliver:  webhooksDeliver,
+		webhooksSched:    webhooksSched,
+		digestSched:      digestSched,
+		chatQueue:        chatQueue,
+		jwtKeysSched:     jwtKeysSched,
 		gitsrv:           gitsrv,
 		pprof:            pprof,
 		metrics:          metrics,
@@ -72,6 +120,97 @@ func (a *API) Start(ctx context.Context) error {
 		}
 		return nil
 	})
+	// changelog queue
+	wg.Go(func() error {
+		if err := a.changelogQueue.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start changelog queue: %w", err)
+		}
+		return nil
+	})
+	// maintenance queue
+	wg.Go(func() error {
+		if err := a.maintenanceQueue.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start maintenance queue: %w", err)
+		}
+		return nil
+	})
+	// maintenance scheduler
+	wg.Go(func() error {
+		if err := a.maintenanceSched.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start maintenance scheduler: %w", err)
+		}
+		return nil
+	})
+	// auto revert queue
+	wg.Go(func() error {
+		if err := a.autoRevertQueue.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start auto revert queue: %w", err)
+		}
+		return nil
+	})
+	// auto revert scheduler
+	wg.Go(func() error {
+		if err := a.autoRevertSched.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start auto revert scheduler: %w", err)
+		}
+		return nil
+	})
+	// review owners queue
+	wg.Go(func() error {
+		if err := a.ownersQueue.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start review owners queue: %w", err)
+		}
+		return nil
+	})
+	// stale reviews queue
+	wg.Go(func() error {
+		if err := a.reviewStaleQueue.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start stale reviews queue: %w", err)
+		}
+		return nil
+	})
+	// webhooks events queue
+	wg.Go(func() error {
+		if err := a.webhooksEvents.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start webhooks events queue: %w", err)
+		}
+		return nil
+	})
+	// webhooks deliveries queue
+	wg.Go(func() error {
+		if err := a.webhooksDeliver.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start webhooks deliveries queue: %w", err)
+		}
+		return nil
+	})
+	// webhooks retry scheduler
+	wg.Go(func() error {
+		if err := a.webhooksSched.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start webhooks scheduler: %w", err)
+		}
+		return nil
+	})
+	// notification digest scheduler
+	wg.Go(func() error {
+		if err := a.digestSched.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start notification digest scheduler: %w", err)
+		}
+		return nil
+	})
+	// chat notifications queue
+	wg.Go(func() error {
+		if err := a.chatQueue.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start chat notifications queue: %w", err)
+		}
+		return nil
+	})
+	// jwt keys rotation scheduler
+	wg.Go(func() error {
+		if err := a.jwtKeysSched.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start jwt keys scheduler: %w", err)
+		}
+		return nil
+	})
 	// Start the git HTTP server
 	wg.Go(func() error {
 		if err := a.gitsrv.Start(); err != nil {
@@ -16,6 +16,7 @@ type API struct {
 	githubClonerQueue   *workers_github.ClonerQueue
 	githubImporterQueue workers_github.ImporterQueue
 	githubWebhooksQueue *workers_github.WebhooksQueue
+	githubTagsQueue     *workers_github.TagsQueue
 }
 
 func ProvideAPI(
@@ -24,12 +25,14 @@ func ProvideAPI(
 	githubClonerQueue *workers_github.ClonerQueue,
 	githubImporterQueue workers_github.ImporterQueue,
 	githubWebhooksQueue *workers_github.WebhooksQueue,
+	githubTagsQueue *workers_github.TagsQueue,
 ) *API {
 	return &API{
 		ossAPI:              ossAPI,
 		githubClonerQueue:   githubClonerQueue,
 		githubImporterQueue: githubImporterQueue,
 		githubWebhooksQueue: githubWebhooksQueue,
+		githubTagsQueue:     githubTagsQueue,
 	}
 }
 
@@ -61,5 +64,12 @@ func (a *API) Start(ctx context.Context) error {
 		return nil
 	})
 
+	wg.Go(func() error {
+		if err := a.githubTagsQueue.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start github tags queue: %w", err)
+		}
+		return nil
+	})
+
 	return wg.Wait()
 }
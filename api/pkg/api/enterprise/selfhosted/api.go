@@ -8,6 +8,7 @@ import (
 	workers_github "getsturdy.com/api/pkg/github/enterprise/workers"
 	workers_license "getsturdy.com/api/pkg/installations/enterprise/selfhosted/worker"
 	worker_installation_statistics "getsturdy.com/api/pkg/installations/statistics/enterprise/selfhosted/worker"
+	worker_ldap "getsturdy.com/api/pkg/ldap/enterprise/selfhosted/worker"
 
 	"golang.org/x/sync/errgroup"
 )
@@ -20,6 +21,8 @@ type API struct {
 	licenseWorker                *workers_license.Worker
 	installationStatisticsWorker *worker_installation_statistics.Worker
 	githubWebhooksQueue          *workers_github.WebhooksQueue
+	githubTagsQueue              *workers_github.TagsQueue
+	ldapWorker                   *worker_ldap.Worker
 }
 
 func ProvideAPI(
@@ -30,6 +33,8 @@ func ProvideAPI(
 	licenseWorker *workers_license.Worker,
 	installationStatisticsWorker *worker_installation_statistics.Worker,
 	githubWebhooksQueue *workers_github.WebhooksQueue,
+	githubTagsQueue *workers_github.TagsQueue,
+	ldapWorker *worker_ldap.Worker,
 ) *API {
 	return &API{
 		ossAPI:                       ossAPI,
@@ -38,6 +43,8 @@ func ProvideAPI(
 		licenseWorker:                licenseWorker,
 		installationStatisticsWorker: installationStatisticsWorker,
 		githubWebhooksQueue:          githubWebhooksQueue,
+		githubTagsQueue:              githubTagsQueue,
+		ldapWorker:                   ldapWorker,
 	}
 }
 
@@ -83,5 +90,19 @@ func (a *API) Start(ctx context.Context) error {
 		return nil
 	})
 
+	wg.Go(func() error {
+		if err := a.githubTagsQueue.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start github tags queue: %w", err)
+		}
+		return nil
+	})
+
+	wg.Go(func() error {
+		if err := a.ldapWorker.Start(ctx); err != nil {
+			return fmt.Errorf("failed to start ldap worker: %w", err)
+		}
+		return nil
+	})
+
 	return wg.Wait()
 }
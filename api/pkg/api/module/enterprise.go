@@ -7,6 +7,8 @@ import (
 	"getsturdy.com/api/pkg/api"
 	"getsturdy.com/api/pkg/api/enterprise/selfhosted"
 	"getsturdy.com/api/pkg/di"
+	module_ldap "getsturdy.com/api/pkg/ldap/enterprise/selfhosted"
+	module_oidc "getsturdy.com/api/pkg/oidc/enterprise/selfhosted"
 	module_queue "getsturdy.com/api/pkg/queue/module"
 )
 
@@ -16,4 +18,6 @@ func Module(c *di.Container) {
 
 	c.Register(api.ProvideAPI)
 	c.Import(selfhosted.Module)
+	c.Import(module_oidc.Module)
+	c.Import(module_ldap.Module)
 }
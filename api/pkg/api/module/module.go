@@ -1,9 +1,11 @@
 package module
 
 import (
+	module_accesstokens "getsturdy.com/api/pkg/accesstokens/module"
 	module_analytics "getsturdy.com/api/pkg/analytics/module"
 	module_auth "getsturdy.com/api/pkg/auth/module"
 	module_author "getsturdy.com/api/pkg/author/module"
+	module_autorevert "getsturdy.com/api/pkg/autorevert/module"
 	module_aws "getsturdy.com/api/pkg/aws/module"
 	module_blobs "getsturdy.com/api/pkg/blobs/module"
 	module_change "getsturdy.com/api/pkg/change/module"
@@ -21,6 +23,7 @@ import (
 	module_gc "getsturdy.com/api/pkg/gc/module"
 	module_gitserver "getsturdy.com/api/pkg/gitserver"
 	module_graphql "getsturdy.com/api/pkg/graphql"
+	module_guests "getsturdy.com/api/pkg/guests/module"
 	module_http "getsturdy.com/api/pkg/http/module"
 	module_installations "getsturdy.com/api/pkg/installations/module"
 	module_installations_statistics "getsturdy.com/api/pkg/installations/statistics/module"
@@ -28,6 +31,7 @@ import (
 	module_jwt "getsturdy.com/api/pkg/jwt/module"
 	module_license "getsturdy.com/api/pkg/licenses/module"
 	module_logger "getsturdy.com/api/pkg/logger/module"
+	module_maintenance "getsturdy.com/api/pkg/maintenance/module"
 	"getsturdy.com/api/pkg/metrics"
 	module_mutagen "getsturdy.com/api/pkg/mutagen/module"
 	module_newsletter "getsturdy.com/api/pkg/newsletter/module"
@@ -38,14 +42,19 @@ import (
 	module_pki "getsturdy.com/api/pkg/pki/module"
 	"getsturdy.com/api/pkg/pprof"
 	module_presence "getsturdy.com/api/pkg/presence/module"
+	module_releases "getsturdy.com/api/pkg/releases/module"
 	module_review "getsturdy.com/api/pkg/review/module"
+	module_scim "getsturdy.com/api/pkg/scim/module"
 	module_servicetokens "getsturdy.com/api/pkg/servicetokens/module"
+	module_sessions "getsturdy.com/api/pkg/sessions/module"
 	module_statuses "getsturdy.com/api/pkg/statuses/module"
 	module_suggestions "getsturdy.com/api/pkg/suggestions/module"
 	module_sync "getsturdy.com/api/pkg/sync/module"
+	module_twofactor "getsturdy.com/api/pkg/twofactor/module"
 	module_user "getsturdy.com/api/pkg/users/module"
 	module_view "getsturdy.com/api/pkg/view/module"
 	module_waitinglist "getsturdy.com/api/pkg/waitinglist"
+	module_webhooks "getsturdy.com/api/pkg/webhooks/module"
 	module_workspace_activity "getsturdy.com/api/pkg/workspaces/activity/module"
 	module_workspace "getsturdy.com/api/pkg/workspaces/module"
 	module_workspace_watchers "getsturdy.com/api/pkg/workspaces/watchers/module"
@@ -57,11 +66,13 @@ func common(c *di.Container) {
 	c.Import(metrics.Module)
 	c.Import(pprof.Module)
 
+	c.Import(module_accesstokens.Module)
 	c.Import(module_aws.Module)
 	c.Import(module_blobs.Module)
 	c.Import(module_analytics.Module)
 	c.Import(module_auth.Module)
 	c.Import(module_author.Module)
+	c.Import(module_autorevert.Module)
 	c.Import(module_change.Module)
 	c.Import(module_ci.Module)
 	c.Import(module_codebase.Module)
@@ -75,6 +86,7 @@ func common(c *di.Container) {
 	c.Import(module_gc.Module)
 	c.Import(module_gitserver.Module)
 	c.Import(module_graphql.Module)
+	c.Import(module_guests.Module)
 	c.Import(module_http.Module)
 	c.Import(module_installations.Module)
 	c.Import(module_installations_statistics.Module)
@@ -82,6 +94,7 @@ func common(c *di.Container) {
 	c.Import(module_jwt.Module)
 	c.Import(module_logger.Module)
 	c.Import(module_license.Module)
+	c.Import(module_maintenance.Module)
 	c.Import(module_mutagen.Module)
 	c.Import(module_newsletter.Module)
 	c.Import(module_notification.Module)
@@ -90,14 +103,19 @@ func common(c *di.Container) {
 	c.Import(module_organization.Module)
 	c.Import(module_pki.Module)
 	c.Import(module_presence.Module)
+	c.Import(module_releases.Module)
 	c.Import(module_review.Module)
+	c.Import(module_scim.Module)
 	c.Import(module_servicetokens.Module)
+	c.Import(module_sessions.Module)
 	c.Import(module_statuses.Module)
 	c.Import(module_suggestions.Module)
 	c.Import(module_sync.Module)
+	c.Import(module_twofactor.Module)
 	c.Import(module_user.Module)
 	c.Import(module_view.Module)
 	c.Import(module_waitinglist.Module)
+	c.Import(module_webhooks.Module)
 	c.Import(module_workspace.Module)
 	c.Import(module_workspace_activity.Module)
 	c.Import(module_workspace_watchers.Module)
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"net/http"
 
+	service_accesstokens "getsturdy.com/api/pkg/accesstokens/service"
 	"getsturdy.com/api/pkg/ctxlog"
 	"getsturdy.com/api/pkg/jwt"
 	service_jwt "getsturdy.com/api/pkg/jwt/service"
+	service_servicetokens "getsturdy.com/api/pkg/servicetokens/service"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -17,8 +19,35 @@ const (
 	ginContextKey = "auth.subject"
 )
 
-func GinMiddleware(logger *zap.Logger, jwtService *service_jwt.Service) gin.HandlerFunc {
+func GinMiddleware(logger *zap.Logger, jwtService *service_jwt.Service, accessTokensService *service_accesstokens.Service, serviceTokensService *service_servicetokens.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		subject, ok, err := subjectFromAccessToken(c.Request, accessTokensService)
+		if !ok {
+			subject, ok, err = subjectFromServiceToken(c.Request, serviceTokensService)
+		}
+		if ok {
+			switch {
+			case err == nil:
+			case errors.Is(err, ErrUnauthenticated):
+				subject = &Subject{Type: SubjectAnonymous}
+			default:
+				ctxlog.ErrorOrWarn(logger, "failed to authenticate user", err)
+				c.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+
+			if !subject.HasScope(routeScope(c.Request.Method, c.FullPath())) {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+
+			c.Set(ginContextKey, subject)
+			c.Request = c.Request.WithContext(NewContext(c.Request.Context(), subject))
+
+			c.Next()
+			return
+		}
+
 		token, shouldRefresh, err := jwtFromRequest(c.Request, jwtService)
 		if err != nil && !errors.Is(err, ErrUnauthenticated) {
 			ctxlog.ErrorOrWarn(logger, "failed to authenticate user", err)
@@ -32,7 +61,7 @@ func GinMiddleware(logger *zap.Logger, jwtService *service_jwt.Service) gin.Hand
 			}
 		}
 
-		subject := subjectFromToken(token)
+		subject = subjectFromToken(token)
 
 		c.Set(ginContextKey, subject)
 		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), subject))
@@ -42,13 +71,12 @@ func GinMiddleware(logger *zap.Logger, jwtService *service_jwt.Service) gin.Hand
 }
 
 func refreshToken(c *gin.Context, token *jwt.Token, jwtService *service_jwt.Service) error {
-	token, err := jwtService.IssueToken(c.Request.Context(), token.Subject, oneMonth, token.Type)
+	token, err := jwtService.RenewToken(c.Request.Context(), token, oneMonth)
 	if err != nil {
-		return fmt.Errorf("failed to issue new token: %w", err)
+		return fmt.Errorf("failed to renew token: %w", err)
 	}
 
-	isSecure := c.Request.URL.Scheme == "https"
-	setAuthCookie(c.Writer, isSecure, token.Token)
+	SetAuthCookie(c, token)
 	return nil
 }
 
@@ -58,11 +86,29 @@ func SetAuthCookieForUser(c *gin.Context, userID string, jwtService *service_jwt
 		return fmt.Errorf("failed to issue new token: %w", err)
 	}
 
-	isSecure := c.Request.URL.Scheme == "https"
-	setAuthCookie(c.Writer, isSecure, token.Token)
+	SetAuthCookie(c, token)
 	return nil
 }
 
+// SetAuthCookie sets the auth cookie to an auth token that has already been issued.
+func SetAuthCookie(c *gin.Context, token *jwt.Token) {
+	setAuthCookie(c.Writer, IsSecure(c.Request), token.Token)
+}
+
+// RenewAuthCookie renews the auth token of the session that the request is authenticated with, and sets it as the
+// auth cookie. It does nothing if the request is not authenticated with a session.
+func RenewAuthCookie(c *gin.Context, jwtService *service_jwt.Service) error {
+	subject, ok := FromContext(c.Request.Context())
+	if !ok || subject.Type != SubjectUser || subject.SessionID == "" {
+		return nil
+	}
+	return refreshToken(c, &jwt.Token{
+		ID:      subject.SessionID,
+		Subject: subject.ID,
+		Type:    jwt.TokenTypeAuth,
+	}, jwtService)
+}
+
 func SubjectFromGinContext(c *gin.Context) (*Subject, bool) {
 	v, found := c.Get(ginContextKey)
 	if !found {
@@ -3,16 +3,27 @@ package auth_test
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"getsturdy.com/api/pkg/accesstokens"
+	db_accesstokens "getsturdy.com/api/pkg/accesstokens/db"
+	service_accesstokens "getsturdy.com/api/pkg/accesstokens/service"
 	"getsturdy.com/api/pkg/auth"
+	"getsturdy.com/api/pkg/ip"
 	"getsturdy.com/api/pkg/jwt"
 	db_jwt_keys "getsturdy.com/api/pkg/jwt/keys/db"
 	service_jwt "getsturdy.com/api/pkg/jwt/service"
+	"getsturdy.com/api/pkg/servicetokens"
+	db_servicetokens "getsturdy.com/api/pkg/servicetokens/db"
+	service_servicetokens "getsturdy.com/api/pkg/servicetokens/service"
+	db_sessions "getsturdy.com/api/pkg/sessions/db"
+	service_sessions "getsturdy.com/api/pkg/sessions/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -25,13 +36,15 @@ var (
 )
 
 func TestGinMiddleware__shouldAllowCIAuthInHeader(t *testing.T) {
-	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory())
+	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
+	accessTokensService := service_accesstokens.New(zap.NewNop(), db_accesstokens.NewMemory())
+	serviceTokensService := service_servicetokens.New(zap.NewNop(), db_servicetokens.NewMemory())
 
 	token, err := jwtTokenService.IssueToken(context.Background(), "id", oneMonth, jwt.TokenTypeCI)
 	assert.NoError(t, err)
 
 	router := gin.New()
-	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService))
+	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService, accessTokensService, serviceTokensService))
 	router.GET("/ping", func(c *gin.Context) {
 		subject, found := auth.SubjectFromGinContext(c)
 		if assert.True(t, found) {
@@ -58,13 +71,15 @@ func TestGinMiddleware__shouldAllowCIAuthInHeader(t *testing.T) {
 }
 
 func TestGinMiddleware__shouldAllowUserAuthInHeader(t *testing.T) {
-	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory())
+	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
+	accessTokensService := service_accesstokens.New(zap.NewNop(), db_accesstokens.NewMemory())
+	serviceTokensService := service_servicetokens.New(zap.NewNop(), db_servicetokens.NewMemory())
 
 	token, err := jwtTokenService.IssueToken(context.Background(), "id", oneMonth, jwt.TokenTypeAuth)
 	assert.NoError(t, err)
 
 	router := gin.New()
-	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService))
+	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService, accessTokensService, serviceTokensService))
 	router.GET("/ping", func(c *gin.Context) {
 		subject, found := auth.SubjectFromGinContext(c)
 		if assert.True(t, found) {
@@ -91,13 +106,15 @@ func TestGinMiddleware__shouldAllowUserAuthInHeader(t *testing.T) {
 }
 
 func TestGinMiddleware__shouldNotRefreshExpiringHeaderToken(t *testing.T) {
-	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory())
+	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
+	accessTokensService := service_accesstokens.New(zap.NewNop(), db_accesstokens.NewMemory())
+	serviceTokensService := service_servicetokens.New(zap.NewNop(), db_servicetokens.NewMemory())
 
 	token, err := jwtTokenService.IssueToken(context.Background(), "id", time.Hour, jwt.TokenTypeAuth)
 	assert.NoError(t, err)
 
 	router := gin.New()
-	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService))
+	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService, accessTokensService, serviceTokensService))
 	router.GET("/ping", func(c *gin.Context) {
 		subject, found := auth.SubjectFromGinContext(c)
 		if assert.True(t, found) {
@@ -124,13 +141,15 @@ func TestGinMiddleware__shouldNotRefreshExpiringHeaderToken(t *testing.T) {
 }
 
 func TestGinMiddleware__shouldRefreshExpiringCookie(t *testing.T) {
-	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory())
+	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
+	accessTokensService := service_accesstokens.New(zap.NewNop(), db_accesstokens.NewMemory())
+	serviceTokensService := service_servicetokens.New(zap.NewNop(), db_servicetokens.NewMemory())
 
 	token, err := jwtTokenService.IssueToken(context.Background(), "id", time.Hour, jwt.TokenTypeAuth)
 	assert.NoError(t, err)
 
 	router := gin.New()
-	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService))
+	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService, accessTokensService, serviceTokensService))
 	router.GET("/ping", func(c *gin.Context) {
 		subject, found := auth.SubjectFromGinContext(c)
 		if assert.True(t, found) {
@@ -163,14 +182,58 @@ func TestGinMiddleware__shouldRefreshExpiringCookie(t *testing.T) {
 	}
 }
 
+func TestSetAuthCookie__secure(t *testing.T) {
+	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
+
+	token, err := jwtTokenService.IssueToken(context.Background(), "id", time.Hour, jwt.TokenTypeAuth)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.GET("/login", func(c *gin.Context) {
+		auth.SetAuthCookie(c, token)
+	})
+
+	for name, tc := range map[string]struct {
+		tls    bool
+		proto  string
+		secure bool
+	}{
+		"http":           {secure: false},
+		"https":          {tls: true, secure: true},
+		"https to proxy": {proto: "https", secure: true},
+		"http to proxy":  {proto: "http", secure: false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, err := http.NewRequest("GET", "/login", nil)
+			assert.NoError(t, err)
+			if tc.tls {
+				req.TLS = &tls.ConnectionState{}
+			}
+			if tc.proto != "" {
+				req.Header.Set("X-Forwarded-Proto", tc.proto)
+			}
+
+			router.ServeHTTP(w, req)
+
+			if cookies := w.Result().Cookies(); assert.Len(t, cookies, 1) {
+				assert.Equal(t, "auth", cookies[0].Name)
+				assert.Equal(t, tc.secure, cookies[0].Secure)
+			}
+		})
+	}
+}
+
 func TestGinMiddleware__shouldAllowUserAuthInCookie(t *testing.T) {
-	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory())
+	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
+	accessTokensService := service_accesstokens.New(zap.NewNop(), db_accesstokens.NewMemory())
+	serviceTokensService := service_servicetokens.New(zap.NewNop(), db_servicetokens.NewMemory())
 
 	token, err := jwtTokenService.IssueToken(context.Background(), "id", oneMonth, jwt.TokenTypeAuth)
 	assert.NoError(t, err)
 
 	router := gin.New()
-	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService))
+	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService, accessTokensService, serviceTokensService))
 	router.GET("/ping", func(c *gin.Context) {
 		subject, found := auth.SubjectFromGinContext(c)
 		if assert.True(t, found) {
@@ -200,10 +263,12 @@ func TestGinMiddleware__shouldAllowUserAuthInCookie(t *testing.T) {
 }
 
 func TestGinMiddleware__shouldAllowNoAuth(t *testing.T) {
-	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory())
+	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
+	accessTokensService := service_accesstokens.New(zap.NewNop(), db_accesstokens.NewMemory())
+	serviceTokensService := service_servicetokens.New(zap.NewNop(), db_servicetokens.NewMemory())
 
 	router := gin.New()
-	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService))
+	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService, accessTokensService, serviceTokensService))
 	router.GET("/ping", func(c *gin.Context) {
 		c.String(http.StatusOK, "pong")
 	})
@@ -218,3 +283,141 @@ func TestGinMiddleware__shouldAllowNoAuth(t *testing.T) {
 	assert.Len(t, w.Result().Cookies(), 0)
 	assert.Equal(t, "pong", w.Body.String())
 }
+
+func TestGinMiddleware__shouldAllowAccessTokenInHeader(t *testing.T) {
+	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
+	accessTokensService := service_accesstokens.New(zap.NewNop(), db_accesstokens.NewMemory())
+	serviceTokensService := service_servicetokens.New(zap.NewNop(), db_servicetokens.NewMemory())
+
+	token, _, err := accessTokensService.Create(context.Background(), "id", "ci", accesstokens.Scopes{accesstokens.ScopeCodebasesRead}, nil)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService, accessTokensService, serviceTokensService))
+	router.GET("/v3/user", func(c *gin.Context) {
+		subject, found := auth.SubjectFromGinContext(c)
+		if assert.True(t, found) {
+			assert.Equal(t, "id", subject.ID)
+			assert.Equal(t, auth.SubjectUser, subject.Type)
+			assert.True(t, subject.HasScope(accesstokens.ScopeCodebasesRead))
+			assert.False(t, subject.HasScope(accesstokens.ScopeWorkspacesWrite))
+		}
+		c.String(http.StatusOK, "pong")
+	})
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/v3/user", nil)
+	assert.NoError(t, err)
+	req.Header.Add("Authorization", fmt.Sprintf("bearer %s", token))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Len(t, w.Result().Cookies(), 0)
+}
+
+func TestGinMiddleware__shouldRequireAccessTokenScopeForRoute(t *testing.T) {
+	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
+	accessTokensService := service_accesstokens.New(zap.NewNop(), db_accesstokens.NewMemory())
+	serviceTokensService := service_servicetokens.New(zap.NewNop(), db_servicetokens.NewMemory())
+
+	readToken, _, err := accessTokensService.Create(context.Background(), "id", "read", accesstokens.Scopes{accesstokens.ScopeCodebasesRead}, nil)
+	assert.NoError(t, err)
+	writeToken, _, err := accessTokensService.Create(context.Background(), "id", "write", accesstokens.Scopes{accesstokens.ScopeWorkspacesWrite}, nil)
+	assert.NoError(t, err)
+	adminToken, _, err := accessTokensService.Create(context.Background(), "id", "admin", accesstokens.Scopes{accesstokens.ScopeAdmin}, nil)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService, accessTokensService, serviceTokensService))
+	pong := func(c *gin.Context) { c.String(http.StatusOK, "pong") }
+	router.GET("/v3/user", pong)
+	router.POST("/v3/workspaces", pong)
+	router.POST("/v3/pki/add-public-key", pong)
+
+	cases := []struct {
+		token  string
+		method string
+		path   string
+		code   int
+	}{
+		{readToken, "GET", "/v3/user", http.StatusOK},
+		{readToken, "POST", "/v3/workspaces", http.StatusForbidden},
+		{writeToken, "POST", "/v3/workspaces", http.StatusOK},
+		{writeToken, "POST", "/v3/pki/add-public-key", http.StatusForbidden},
+		{adminToken, "POST", "/v3/pki/add-public-key", http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest(tc.method, tc.path, nil)
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", fmt.Sprintf("bearer %s", tc.token))
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, tc.code, w.Code, "%s %s", tc.method, tc.path)
+	}
+}
+
+func TestGinMiddleware__shouldNotAllowInvalidAccessToken(t *testing.T) {
+	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
+	accessTokensService := service_accesstokens.New(zap.NewNop(), db_accesstokens.NewMemory())
+	serviceTokensService := service_servicetokens.New(zap.NewNop(), db_servicetokens.NewMemory())
+
+	router := gin.New()
+	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService, accessTokensService, serviceTokensService))
+	router.GET("/ping", func(c *gin.Context) {
+		subject, found := auth.SubjectFromGinContext(c)
+		if assert.True(t, found) {
+			assert.Equal(t, auth.SubjectAnonymous, subject.Type)
+		}
+		c.String(http.StatusOK, "pong")
+	})
+
+	w := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/ping", nil)
+	assert.NoError(t, err)
+	req.Header.Add("Authorization", fmt.Sprintf("bearer %s", accesstokens.Prefix+"id_secret"))
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestGinMiddleware__shouldAllowServiceTokenInHeader(t *testing.T) {
+	jwtTokenService := service_jwt.NewService(zap.NewNop(), db_jwt_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
+	accessTokensService := service_accesstokens.New(zap.NewNop(), db_accesstokens.NewMemory())
+	serviceTokensService := service_servicetokens.New(zap.NewNop(), db_servicetokens.NewMemory())
+
+	secret, token, err := serviceTokensService.Create(context.Background(), "codebase-id", "user-id", "ci", servicetokens.DefaultCapabilities, servicetokens.IPAllowList{"10.0.0.1"}, nil)
+	assert.NoError(t, err)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(ip.NewContext(c.Request.Context(), net.ParseIP(c.GetHeader("X-Test-IP"))))
+	})
+	router.Use(auth.GinMiddleware(zap.NewNop(), jwtTokenService, accessTokensService, serviceTokensService))
+	router.GET("/ping", func(c *gin.Context) {
+		subject, found := auth.SubjectFromGinContext(c)
+		if assert.True(t, found) {
+			c.String(http.StatusOK, string(subject.Type))
+		}
+	})
+
+	for addr, expected := range map[string]auth.SubjectType{
+		"10.0.0.1": auth.SubjectServiceToken,
+		"10.0.0.2": auth.SubjectAnonymous,
+	} {
+		w := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/ping", nil)
+		assert.NoError(t, err)
+		req.Header.Add("Authorization", fmt.Sprintf("bearer %s%s_%s", servicetokens.Prefix, token.ID, secret))
+		req.Header.Add("X-Test-IP", addr)
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, string(expected), w.Body.String(), addr)
+	}
+}
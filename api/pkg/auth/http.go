@@ -8,8 +8,12 @@ import (
 	"strings"
 	"time"
 
+	"getsturdy.com/api/pkg/accesstokens"
+	service_accesstokens "getsturdy.com/api/pkg/accesstokens/service"
 	"getsturdy.com/api/pkg/jwt"
 	service_jwt "getsturdy.com/api/pkg/jwt/service"
+	"getsturdy.com/api/pkg/servicetokens"
+	service_servicetokens "getsturdy.com/api/pkg/servicetokens/service"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
@@ -40,7 +44,14 @@ var (
 	})
 )
 
-func SubjectFromRequest(r *http.Request, jwtService *service_jwt.Service) (*Subject, error) {
+func SubjectFromRequest(r *http.Request, jwtService *service_jwt.Service, accessTokensService *service_accesstokens.Service, serviceTokensService *service_servicetokens.Service) (*Subject, error) {
+	if subject, ok, err := subjectFromAccessToken(r, accessTokensService); ok {
+		return subject, err
+	}
+	if subject, ok, err := subjectFromServiceToken(r, serviceTokensService); ok {
+		return subject, err
+	}
+
 	jwt, _, err := jwtFromRequest(r, jwtService)
 	if err != nil {
 		return nil, err
@@ -48,6 +59,50 @@ func SubjectFromRequest(r *http.Request, jwtService *service_jwt.Service) (*Subj
 	return subjectFromToken(jwt), nil
 }
 
+// subjectFromAccessToken authenticates requests that use a personal access token as their bearer token. The second
+// return value is false if the request doesn't use a personal access token.
+func subjectFromAccessToken(r *http.Request, accessTokensService *service_accesstokens.Service) (*Subject, bool, error) {
+	token, fromHeader := tokenFromHeaders(r.Header)
+	if !fromHeader || !accesstokens.IsAccessToken(token) {
+		return nil, false, nil
+	}
+
+	accessToken, err := accessTokensService.Authenticate(r.Context(), token)
+	if errors.Is(err, service_accesstokens.ErrUnauthenticated) {
+		return nil, true, ErrUnauthenticated
+	} else if err != nil {
+		return nil, true, fmt.Errorf("failed to authenticate access token: %w", err)
+	}
+
+	return &Subject{
+		ID:     accessToken.UserID,
+		Type:   SubjectUser,
+		Scopes: accessToken.Scopes,
+	}, true, nil
+}
+
+// subjectFromServiceToken authenticates requests that use a service token as their bearer token. The second return
+// value is false if the request doesn't use a service token.
+func subjectFromServiceToken(r *http.Request, serviceTokensService *service_servicetokens.Service) (*Subject, bool, error) {
+	token, fromHeader := tokenFromHeaders(r.Header)
+	if !fromHeader || !servicetokens.IsServiceToken(token) {
+		return nil, false, nil
+	}
+
+	serviceToken, err := serviceTokensService.AuthenticateBearer(r.Context(), token)
+	if errors.Is(err, service_servicetokens.ErrUnauthenticated) || errors.Is(err, service_servicetokens.ErrForbidden) {
+		return nil, true, ErrUnauthenticated
+	} else if err != nil {
+		return nil, true, fmt.Errorf("failed to authenticate service token: %w", err)
+	}
+
+	return &Subject{
+		ID:           serviceToken.ID,
+		Type:         SubjectServiceToken,
+		ServiceToken: serviceToken,
+	}, true, nil
+}
+
 func jwtFromRequest(r *http.Request, jwtService *service_jwt.Service) (*jwt.Token, bool, error) {
 	token, fromHeader := tokenFromHeaders(r.Header)
 	var fromCookies bool
@@ -105,6 +160,15 @@ func tokenFromCookies(cookies []*http.Cookie) (string, bool) {
 	return "", false
 }
 
+// IsSecure returns true if the request was made over https, either to this server or to a proxy in front of it.
+// Cookies that are set in responses to secure requests should be secure as well.
+//
+// The X-Forwarded-Proto header doesn't need to come from a trusted proxy, as it can only be used to make cookies
+// more secure.
+func IsSecure(r *http.Request) bool {
+	return r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https")
+}
+
 func setAuthCookie(w http.ResponseWriter, secure bool, value string) {
 	http.SetCookie(w, &http.Cookie{
 		Name:     authCookieName,
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"getsturdy.com/api/pkg/accesstokens"
+)
+
+// routeScopes is the scope that a personal access token needs to call a route, keyed by the method and the full path
+// of the route. Routes that are not listed require accesstokens.ScopeAdmin.
+var routeScopes = map[string]accesstokens.Scope{
+	// the scopes of mutations are checked by the graphql tracer
+	"POST /graphql":    accesstokens.ScopeCodebasesRead,
+	"GET /graphql/ws":  accesstokens.ScopeCodebasesRead,
+	"POST /graphql/ws": accesstokens.ScopeCodebasesRead,
+
+	"GET /v3/user":                       accesstokens.ScopeCodebasesRead,
+	"GET /v3/codebases/:id":              accesstokens.ScopeCodebasesRead,
+	"GET /v3/views/:viewID":              accesstokens.ScopeCodebasesRead,
+	"GET /v3/views/:viewID/ignores":      accesstokens.ScopeCodebasesRead,
+	"GET /v3/stream":                     accesstokens.ScopeCodebasesRead,
+	"GET /v3/rebase/:viewID":             accesstokens.ScopeCodebasesRead,
+	"GET /v3/mutagen/get-view/:id":       accesstokens.ScopeCodebasesRead,
+	"POST /v3/views":                     accesstokens.ScopeWorkspacesWrite,
+	"POST /v3/views/:viewID/ignore-file": accesstokens.ScopeWorkspacesWrite,
+	"POST /v3/rebase/:viewID/start":      accesstokens.ScopeWorkspacesWrite,
+	"POST /v3/rebase/:viewID/resolve":    accesstokens.ScopeWorkspacesWrite,
+	"POST /v3/changes/:id/update":        accesstokens.ScopeWorkspacesWrite,
+	"POST /v3/workspaces":                accesstokens.ScopeWorkspacesWrite,
+}
+
+func routeScope(method, fullPath string) accesstokens.Scope {
+	if scope, ok := routeScopes[method+" "+fullPath]; ok {
+		return scope
+	}
+	return accesstokens.ScopeAdmin
+}
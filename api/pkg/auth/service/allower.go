@@ -10,6 +10,8 @@ import (
 	"getsturdy.com/api/pkg/change"
 	"getsturdy.com/api/pkg/codebase"
 	"getsturdy.com/api/pkg/codebase/acl"
+	"getsturdy.com/api/pkg/guests"
+	service_guests "getsturdy.com/api/pkg/guests/service"
 	"getsturdy.com/api/pkg/suggestions"
 	"getsturdy.com/api/pkg/unidiff"
 	"getsturdy.com/api/pkg/workspaces"
@@ -68,6 +70,11 @@ func (s *Service) GetAllower(ctx context.Context, obj interface{}) (*unidiff.All
 			return s.getCIChangeAllower(ctx, subject.ID, &object)
 		}
 
+	case auth.SubjectServiceToken:
+		if err := s.canServiceTokenAccess(ctx, subject.ServiceToken, accessTypeRead, obj); err == nil {
+			return allAllowed, nil
+		}
+
 	case auth.SubjectAnonymous:
 		switch object := obj.(type) {
 		case *change.Change:
@@ -101,6 +108,22 @@ func (s *Service) getUserWorkspaceAllower(ctx context.Context, userID string, wo
 	if err != nil {
 		return nil, fmt.Errorf("failed to get codebase: %w", err)
 	}
+
+	// guests see the files that the member who invited them can see
+	if err := s.canUserAccessCodebase(ctx, userID, accessTypeRead, cb); errors.Is(err, auth.ErrForbidden) {
+		invite, err := s.getGuestInvite(ctx, userID, workspace.ID, guests.CapabilityView)
+		switch {
+		case err == nil:
+			return s.getUserCodebaseAllower(ctx, invite.CreatedBy, cb)
+		case errors.Is(err, service_guests.ErrForbidden):
+			return noneAllowed, nil
+		default:
+			return nil, fmt.Errorf("failed to get guest invite: %w", err)
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
 	return s.getUserCodebaseAllower(ctx, userID, cb)
 }
 
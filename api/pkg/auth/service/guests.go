@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"getsturdy.com/api/pkg/auth"
+	"getsturdy.com/api/pkg/guests"
+	service_guests "getsturdy.com/api/pkg/guests/service"
+	"getsturdy.com/api/pkg/workspaces"
+)
+
+// CanComment checks if the subject can comment on the workspace. Guests can if their invite to the workspace has the
+// comment capability, everyone else needs the write permission on the workspace.
+func (s *Service) CanComment(ctx context.Context, ws *workspaces.Workspace) error {
+	err := s.CanWrite(ctx, ws)
+	if !errors.Is(err, auth.ErrForbidden) {
+		return err
+	}
+
+	subject, found := auth.FromContext(ctx)
+	if !found || subject.Type != auth.SubjectUser {
+		return err
+	}
+	return s.canGuestAccessWorkspace(ctx, subject.ID, ws.ID, guests.CapabilityComment, err)
+}
+
+// canGuestAccessWorkspace checks if the user has accepted an active invite to the workspace with the capability. If
+// not, forbidden is returned, which is the error of the check that the guest access is a fallback for.
+func (s *Service) canGuestAccessWorkspace(ctx context.Context, userID, workspaceID string, capability guests.Capability, forbidden error) error {
+	_, err := s.getGuestInvite(ctx, userID, workspaceID, capability)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service_guests.ErrForbidden):
+		return forbidden
+	default:
+		return fmt.Errorf("failed to check guest access: %w", err)
+	}
+}
+
+// getGuestInvite returns the active invite that gives the user access to the workspace with the capability. Invites
+// only give access for as long as the user that created them can write to the codebase, so guests lose their access
+// when the member that invited them leaves the codebase, or loses their role in it.
+func (s *Service) getGuestInvite(ctx context.Context, userID, workspaceID string, capability guests.Capability) (*guests.Invite, error) {
+	invites, err := s.guestsService.ListActive(ctx, userID, workspaceID, capability)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, invite := range invites {
+		cb, err := s.codebaseService.GetByID(ctx, invite.CodebaseID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get codebase: %w", err)
+		}
+
+		switch err := s.canUserAccessCodebase(ctx, invite.CreatedBy, accessTypeWrite, cb); {
+		case err == nil:
+			return invite, nil
+		case errors.Is(err, auth.ErrForbidden):
+			continue
+		default:
+			return nil, fmt.Errorf("failed to check access of the invite creator: %w", err)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: no active invite with %q to the workspace", service_guests.ErrForbidden, capability)
+}
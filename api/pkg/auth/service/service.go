@@ -2,8 +2,11 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 
+	"getsturdy.com/api/pkg/accesstokens"
 	"getsturdy.com/api/pkg/auth"
 	"getsturdy.com/api/pkg/change"
 	"getsturdy.com/api/pkg/codebase"
@@ -11,10 +14,14 @@ import (
 	service_codebase "getsturdy.com/api/pkg/codebase/service"
 	"getsturdy.com/api/pkg/comments"
 	"getsturdy.com/api/pkg/github"
+	"getsturdy.com/api/pkg/guests"
+	service_guests "getsturdy.com/api/pkg/guests/service"
 	"getsturdy.com/api/pkg/organization"
 	service_organization "getsturdy.com/api/pkg/organization/service"
 	"getsturdy.com/api/pkg/review"
+	service_servicetokens "getsturdy.com/api/pkg/servicetokens/service"
 	"getsturdy.com/api/pkg/suggestions"
+	service_twofactor "getsturdy.com/api/pkg/twofactor/service"
 	service_user "getsturdy.com/api/pkg/users/service"
 	"getsturdy.com/api/pkg/view"
 	"getsturdy.com/api/pkg/workspaces"
@@ -28,6 +35,9 @@ type Service struct {
 	workspaceService    service_workspace.Service
 	aclProvider         *provider_acl.Provider
 	organizationService *service_organization.Service
+	twoFactorService    *service_twofactor.Service
+	serviceTokenService *service_servicetokens.Service
+	guestsService       *service_guests.Service
 }
 
 func New(
@@ -36,6 +46,9 @@ func New(
 	workspaceService service_workspace.Service,
 	aclProvider *provider_acl.Provider,
 	organizationService *service_organization.Service,
+	twoFactorService *service_twofactor.Service,
+	serviceTokenService *service_servicetokens.Service,
+	guestsService *service_guests.Service,
 ) *Service {
 	return &Service{
 		codebaseService:     codebaseService,
@@ -43,6 +56,9 @@ func New(
 		workspaceService:    workspaceService,
 		aclProvider:         aclProvider,
 		organizationService: organizationService,
+		twoFactorService:    twoFactorService,
+		serviceTokenService: serviceTokenService,
+		guestsService:       guestsService,
 	}
 }
 
@@ -53,6 +69,7 @@ const (
 	accessTypeUnknown accessType = iota
 	accessTypeRead
 	accessTypeWrite
+	accessTypeAdmin
 )
 
 // CanRead checks if the user has the read permission on the given object.
@@ -65,6 +82,11 @@ func (s *Service) CanWrite(ctx context.Context, obj interface{}) error {
 	return s.hasAccess(ctx, accessTypeWrite, obj)
 }
 
+// CanAdmin checks if the user can manage the given object, such as its members and integrations.
+func (s *Service) CanAdmin(ctx context.Context, obj interface{}) error {
+	return s.hasAccess(ctx, accessTypeAdmin, obj)
+}
+
 // hasAccess checks if the user has the given permission on the given object.
 //nolint:cyclop
 func (s *Service) hasAccess(ctx context.Context, at accessType, obj interface{}) error {
@@ -79,6 +101,9 @@ func (s *Service) hasAccess(ctx context.Context, at accessType, obj interface{})
 
 	switch subject.Type {
 	case auth.SubjectUser:
+		if err := scopesAllow(subject, at, obj); err != nil {
+			return err
+		}
 		switch object := obj.(type) {
 		case review.Review:
 			return s.canUserAccessReview(ctx, subject.ID, at, &object)
@@ -136,6 +161,8 @@ func (s *Service) hasAccess(ctx context.Context, at accessType, obj interface{})
 		default:
 			return fmt.Errorf("unsupported object type '%T' for ci: %w", obj, auth.ErrForbidden)
 		}
+	case auth.SubjectServiceToken:
+		return s.canServiceTokenAccess(ctx, subject.ServiceToken, at, obj)
 	case auth.SubjectAnonymous:
 		switch object := obj.(type) {
 		case review.Review:
@@ -186,6 +213,32 @@ func (s *Service) hasAccess(ctx context.Context, at accessType, obj interface{})
 	}
 }
 
+// scopesAllow checks that the scopes of a user that is authenticated with a personal access token allow the access.
+func scopesAllow(subject *auth.Subject, at accessType, obj interface{}) error {
+	var scope accesstokens.Scope
+	switch at {
+	case accessTypeRead:
+		scope = accesstokens.ScopeCodebasesRead
+	case accessTypeWrite:
+		scope = accesstokens.ScopeWorkspacesWrite
+		switch obj.(type) {
+		case change.Change, *change.Change:
+			// statuses are reported on changes
+			if subject.HasScope(accesstokens.ScopeStatusesWrite) {
+				return nil
+			}
+		}
+	case accessTypeAdmin:
+		scope = accesstokens.ScopeAdmin
+	default:
+		return fmt.Errorf("unknown access type: %w", auth.ErrForbidden)
+	}
+	if !subject.HasScope(scope) {
+		return fmt.Errorf("token is missing the %s scope: %w", scope, auth.ErrForbidden)
+	}
+	return nil
+}
+
 func (s *Service) canCIAccessChange(ctx context.Context, changeID string, change *change.Change) error {
 	if changeID != string(change.ID) {
 		return fmt.Errorf("ci doesn't have access to the change: %w", auth.ErrForbidden)
@@ -202,20 +255,30 @@ func (s *Service) canUserAccessChange(ctx context.Context, userID string, at acc
 }
 
 func (s *Service) canUserAccessComment(ctx context.Context, userID string, at accessType, comment *comments.Comment) error {
-	// user can access the comment if he is the author or if he has the read permission on the codebase
-	if comment.UserID == userID {
-		return nil
-	}
-
-	if at == accessTypeWrite && comment.UserID != userID {
+	if at != accessTypeRead && comment.UserID != userID {
 		return fmt.Errorf("only owners can update comments: %w", auth.ErrForbidden)
 	}
 
+	// user can access the comment if they can access the codebase
 	cb, err := s.codebaseService.GetByID(ctx, comment.CodebaseID)
 	if err != nil {
 		return fmt.Errorf("failed to get codebase by id: %w", err)
 	}
-	return s.canUserAccessCodebase(ctx, userID, at, cb)
+	err = s.canUserAccessCodebase(ctx, userID, at, cb)
+	if !errors.Is(err, auth.ErrForbidden) || comment.WorkspaceID == nil {
+		return err
+	}
+
+	// guests can read the comments on the workspaces they are invited to, and update their own comments if they can
+	// still comment on the workspace
+	switch at {
+	case accessTypeRead:
+		return s.canGuestAccessWorkspace(ctx, userID, *comment.WorkspaceID, guests.CapabilityView, err)
+	case accessTypeWrite:
+		return s.canGuestAccessWorkspace(ctx, userID, *comment.WorkspaceID, guests.CapabilityComment, err)
+	default:
+		return err
+	}
 }
 
 func (s *Service) canUserAccessCodebase(ctx context.Context, userID string, at accessType, codebase *codebase.Codebase) error {
@@ -224,24 +287,41 @@ func (s *Service) canUserAccessCodebase(ctx context.Context, userID string, at a
 		return nil
 	}
 
-	accessAllowed, err := s.codebaseService.CanAccess(ctx, userID, codebase.ID)
-	if err != nil {
-		return fmt.Errorf("failed to check if user can access codebase: %w", err)
-	}
-
-	if accessAllowed {
-		return nil
-	}
-
 	if codebase.OrganizationID != nil {
 		org, err := s.organizationService.GetByID(ctx, *codebase.OrganizationID)
 		if err != nil {
-			return fmt.Errorf("failed to check if user can access codebase: %w", err)
+			return fmt.Errorf("failed to get organization: %w", err)
 		}
+		if err := s.checkTwoFactor(ctx, userID, org); err != nil {
+			return err
+		}
+	}
 
-		if err := s.canUserAccessOrganization(ctx, userID, accessTypeWrite, org); err == nil {
+	// user can access the codebase according to their role in it
+	member, err := s.codebaseService.GetMember(ctx, codebase.ID, userID)
+	switch {
+	case err == nil:
+		if roleAllows(member.Role, at) {
 			return nil
 		}
+	case errors.Is(err, sql.ErrNoRows):
+	default:
+		return fmt.Errorf("failed to check if user can access codebase: %w", err)
+	}
+
+	// user can access the codebase according to their role in its organization, guests can only access the codebases
+	// they've been added to
+	if codebase.OrganizationID != nil {
+		member, err := s.organizationService.GetMemberByUserIDAndOrganizationID(ctx, userID, *codebase.OrganizationID)
+		switch {
+		case err == nil:
+			if member.Role != organization.RoleGuest && roleAllows(member.Role, at) {
+				return nil
+			}
+		case errors.Is(err, sql.ErrNoRows):
+		default:
+			return fmt.Errorf("failed to check if user can access codebase: %w", err)
+		}
 	}
 
 	return fmt.Errorf("user doesn't have acces to the codebase: %w", auth.ErrForbidden)
@@ -269,7 +349,12 @@ func (s *Service) canUserAccessWorkspace(ctx context.Context, userID string, at
 		return fmt.Errorf("failed to get codebase: %w", err)
 	}
 
-	return s.canUserAccessCodebase(ctx, userID, at, cb)
+	err = s.canUserAccessCodebase(ctx, userID, at, cb)
+	// guests can read the workspaces they are invited to, without being members of the codebase
+	if errors.Is(err, auth.ErrForbidden) && at == accessTypeRead {
+		return s.canGuestAccessWorkspace(ctx, userID, workspace.ID, guests.CapabilityView, err)
+	}
+	return err
 }
 
 func (s *Service) canAnonymousAccessSuggestion(ctx context.Context, at accessType, suggestion *suggestions.Suggestion) error {
@@ -382,7 +467,7 @@ func (s *Service) canAnonymousAccessGitHubRepo(ctx context.Context, at accessTyp
 }
 
 func (s *Service) canAnonymousAccessView(ctx context.Context, at accessType, v *view.View) error {
-	if at == accessTypeWrite {
+	if at != accessTypeRead {
 		return fmt.Errorf("anonymous users can only read views: %w", auth.ErrForbidden)
 	}
 	// user can access a view if they can access the codebase it's in
@@ -394,7 +479,7 @@ func (s *Service) canAnonymousAccessView(ctx context.Context, at accessType, v *
 }
 
 func (s *Service) canUserAccessView(ctx context.Context, userID string, at accessType, v *view.View) error {
-	if at == accessTypeWrite && v.UserID != userID {
+	if at != accessTypeRead && v.UserID != userID {
 		return fmt.Errorf("only owner can write to a view: %w", auth.ErrForbidden)
 	}
 	// user can access a view if they can access the codebase it's in
@@ -428,10 +513,21 @@ func (s *Service) canAnonymousAccessReview(ctx context.Context, at accessType, r
 }
 
 func (s *Service) canUserAccessOrganization(ctx context.Context, userID string, at accessType, org *organization.Organization) error {
-	// user can access a organization if they are a member of it
-	_, err := s.organizationService.GetMemberByUserIDAndOrganizationID(ctx, userID, org.ID)
-	if err == nil {
-		return nil
+	if err := s.checkTwoFactor(ctx, userID, org); err != nil {
+		return err
+	}
+
+	// user can access a organization according to their role in it
+	member, err := s.organizationService.GetMemberByUserIDAndOrganizationID(ctx, userID, org.ID)
+	switch {
+	case err == nil:
+		if roleAllows(member.Role, at) {
+			return nil
+		}
+		return fmt.Errorf("%s can not access organization: %w", member.Role, auth.ErrForbidden)
+	case errors.Is(err, sql.ErrNoRows):
+	default:
+		return fmt.Errorf("failed to get member: %w", err)
 	}
 
 	// user can read (but not write) a organization if they are a member of any of it's codebases
@@ -448,6 +544,41 @@ func (s *Service) canUserAccessOrganization(ctx context.Context, userID string,
 	return fmt.Errorf("user does not have access to organization: %w", auth.ErrForbidden)
 }
 
+// checkTwoFactor returns an error if the organization requires two-factor authentication, and the user has not
+// enabled it, or has not entered their second factor in the session that they are authenticated with.
+func (s *Service) checkTwoFactor(ctx context.Context, userID string, org *organization.Organization) error {
+	if !org.RequireTwoFactor {
+		return nil
+	}
+	var sessionID string
+	if subject, ok := auth.FromContext(ctx); ok && subject.ID == userID {
+		sessionID = subject.SessionID
+	}
+	verified, err := s.twoFactorService.IsVerified(ctx, userID, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to check two-factor authentication: %w", err)
+	}
+	if !verified {
+		return fmt.Errorf("organization requires two-factor authentication: %w", auth.ErrForbidden)
+	}
+	return nil
+}
+
+// roleAllows returns true if a member with the role has the given access. Guests can only read, members can also
+// write, and owners and admins can also manage.
+func roleAllows(role organization.Role, at accessType) bool {
+	switch at {
+	case accessTypeRead:
+		return true
+	case accessTypeWrite:
+		return role != organization.RoleGuest
+	case accessTypeAdmin:
+		return role.CanManage()
+	default:
+		return false
+	}
+}
+
 func (s *Service) canAnonymousAccessOrganization(ctx context.Context, at accessType, org *organization.Organization) error {
 	return fmt.Errorf("anonymous users can't access organizations: %w", auth.ErrForbidden)
 }
@@ -3,16 +3,33 @@ package service_test
 import (
 	"context"
 	"testing"
+	"time"
 
+	"getsturdy.com/api/pkg/accesstokens"
 	"getsturdy.com/api/pkg/analytics/disabled"
 	service_analytics "getsturdy.com/api/pkg/analytics/service"
 	"getsturdy.com/api/pkg/auth"
 	service_auth "getsturdy.com/api/pkg/auth/service"
 	"getsturdy.com/api/pkg/codebase"
 	service_codebase "getsturdy.com/api/pkg/codebase/service"
+	"getsturdy.com/api/pkg/comments"
+	"getsturdy.com/api/pkg/guests"
+	db_guests "getsturdy.com/api/pkg/guests/db"
+	service_guests "getsturdy.com/api/pkg/guests/service"
 	"getsturdy.com/api/pkg/internal/inmemory"
+	db_onetime "getsturdy.com/api/pkg/onetime/db"
+	service_onetime "getsturdy.com/api/pkg/onetime/service"
 	"getsturdy.com/api/pkg/organization"
 	service_organization "getsturdy.com/api/pkg/organization/service"
+	"getsturdy.com/api/pkg/servicetokens"
+	db_servicetokens "getsturdy.com/api/pkg/servicetokens/db"
+	service_servicetokens "getsturdy.com/api/pkg/servicetokens/service"
+	db_sessions "getsturdy.com/api/pkg/sessions/db"
+	service_sessions "getsturdy.com/api/pkg/sessions/service"
+	"getsturdy.com/api/pkg/twofactor"
+	db_twofactor "getsturdy.com/api/pkg/twofactor/db"
+	service_twofactor "getsturdy.com/api/pkg/twofactor/service"
+	"getsturdy.com/api/pkg/workspaces"
 	"go.uber.org/zap"
 
 	"github.com/google/uuid"
@@ -71,6 +88,9 @@ func TestCanWrite_codebase(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
+		nil,
+		nil,
 	)
 
 	for _, tc := range cases {
@@ -170,6 +190,9 @@ func TestCanRead_codebase(t *testing.T) {
 		nil,
 		nil,
 		organizationService,
+		nil,
+		nil,
+		nil,
 	)
 
 	for _, tc := range cases {
@@ -273,6 +296,9 @@ func TestCanReadWrite_organization(t *testing.T) {
 		nil,
 		nil,
 		organizationService,
+		nil,
+		nil,
+		nil,
 	)
 
 	for _, tc := range cases {
@@ -321,3 +347,474 @@ func TestCanReadWrite_organization(t *testing.T) {
 		})
 	}
 }
+
+func TestRoles_organization(t *testing.T) {
+	cases := []struct {
+		role organization.Role
+
+		expectedCanRead  bool
+		expectedCanWrite bool
+		expectedCanAdmin bool
+	}{
+		{role: organization.RoleOwner, expectedCanRead: true, expectedCanWrite: true, expectedCanAdmin: true},
+		{role: organization.RoleAdmin, expectedCanRead: true, expectedCanWrite: true, expectedCanAdmin: true},
+		{role: organization.RoleMember, expectedCanRead: true, expectedCanWrite: true, expectedCanAdmin: false},
+		{role: organization.RoleGuest, expectedCanRead: true, expectedCanWrite: false, expectedCanAdmin: false},
+	}
+
+	codebaseRepo := inmemory.NewInMemoryCodebaseRepo()
+	codebaseUserRepo := inmemory.NewInMemoryCodebaseUserRepo()
+	analyticsService := service_analytics.New(zap.NewNop(), disabled.NewClient(zap.NewNop()))
+	codebaseService := service_codebase.New(codebaseRepo, codebaseUserRepo, nil, nil, nil, nil, nil, analyticsService)
+
+	organizationRepo := inmemory.NewInMemoryOrganizationRepo()
+	organizationMemberRepo := inmemory.NewInMemoryOrganizationMemberRepository()
+	organizationService := service_organization.New(organizationRepo, organizationMemberRepo, analyticsService)
+
+	authService := service_auth.New(
+		codebaseService,
+		nil,
+		nil,
+		nil,
+		organizationService,
+		nil,
+		nil,
+		nil,
+	)
+
+	for _, tc := range cases {
+		t.Run(string(tc.role), func(t *testing.T) {
+			bgCtx := context.Background()
+
+			org := organization.Organization{ID: uuid.NewString()}
+			assert.NoError(t, organizationRepo.Create(bgCtx, org))
+
+			userID := uuid.NewString()
+			orgmember := organization.Member{ID: uuid.NewString(), OrganizationID: org.ID, UserID: userID, Role: tc.role}
+			assert.NoError(t, organizationMemberRepo.Create(bgCtx, orgmember))
+
+			ctx := auth.NewContext(bgCtx, &auth.Subject{ID: userID, Type: auth.SubjectUser})
+
+			for _, check := range []struct {
+				expected bool
+				err      error
+			}{
+				{tc.expectedCanRead, authService.CanRead(ctx, org)},
+				{tc.expectedCanWrite, authService.CanWrite(ctx, org)},
+				{tc.expectedCanAdmin, authService.CanAdmin(ctx, org)},
+			} {
+				if check.expected {
+					assert.NoError(t, check.err)
+				} else {
+					assert.Error(t, check.err)
+				}
+			}
+		})
+	}
+}
+
+func TestRoles_codebase(t *testing.T) {
+	cases := []struct {
+		name string
+
+		organizationRole *organization.Role
+		codebaseRole     *organization.Role
+
+		expectedCanRead  bool
+		expectedCanWrite bool
+		expectedCanAdmin bool
+	}{
+		{
+			name:             "organization-admin",
+			organizationRole: roleRef(organization.RoleAdmin),
+			expectedCanRead:  true, expectedCanWrite: true, expectedCanAdmin: true,
+		},
+		{
+			name:             "organization-member",
+			organizationRole: roleRef(organization.RoleMember),
+			expectedCanRead:  true, expectedCanWrite: true, expectedCanAdmin: false,
+		},
+		{
+			name:             "organization-guest-not-added-to-codebase",
+			organizationRole: roleRef(organization.RoleGuest),
+			expectedCanRead:  false, expectedCanWrite: false, expectedCanAdmin: false,
+		},
+		{
+			name:             "organization-guest-added-to-codebase",
+			organizationRole: roleRef(organization.RoleGuest),
+			codebaseRole:     roleRef(organization.RoleMember),
+			expectedCanRead:  true, expectedCanWrite: true, expectedCanAdmin: false,
+		},
+		{
+			name:             "organization-member-codebase-admin",
+			organizationRole: roleRef(organization.RoleMember),
+			codebaseRole:     roleRef(organization.RoleAdmin),
+			expectedCanRead:  true, expectedCanWrite: true, expectedCanAdmin: true,
+		},
+		{
+			name:            "codebase-guest",
+			codebaseRole:    roleRef(organization.RoleGuest),
+			expectedCanRead: true, expectedCanWrite: false, expectedCanAdmin: false,
+		},
+	}
+
+	codebaseRepo := inmemory.NewInMemoryCodebaseRepo()
+	codebaseUserRepo := inmemory.NewInMemoryCodebaseUserRepo()
+	analyticsService := service_analytics.New(zap.NewNop(), disabled.NewClient(zap.NewNop()))
+	codebaseService := service_codebase.New(codebaseRepo, codebaseUserRepo, nil, nil, nil, nil, nil, analyticsService)
+
+	organizationRepo := inmemory.NewInMemoryOrganizationRepo()
+	organizationMemberRepo := inmemory.NewInMemoryOrganizationMemberRepository()
+	organizationService := service_organization.New(organizationRepo, organizationMemberRepo, analyticsService)
+
+	authService := service_auth.New(
+		codebaseService,
+		nil,
+		nil,
+		nil,
+		organizationService,
+		nil,
+		nil,
+		nil,
+	)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bgCtx := context.Background()
+
+			org := organization.Organization{ID: uuid.NewString()}
+			assert.NoError(t, organizationRepo.Create(bgCtx, org))
+
+			cb := codebase.Codebase{ID: uuid.NewString(), OrganizationID: &org.ID}
+			assert.NoError(t, codebaseRepo.Create(cb))
+
+			userID := uuid.NewString()
+
+			if tc.organizationRole != nil {
+				orgmember := organization.Member{ID: uuid.NewString(), OrganizationID: org.ID, UserID: userID, Role: *tc.organizationRole}
+				assert.NoError(t, organizationMemberRepo.Create(bgCtx, orgmember))
+			}
+
+			if tc.codebaseRole != nil {
+				cbu := codebase.CodebaseUser{ID: uuid.NewString(), CodebaseID: cb.ID, UserID: userID, Role: *tc.codebaseRole}
+				assert.NoError(t, codebaseUserRepo.Create(cbu))
+			}
+
+			ctx := auth.NewContext(bgCtx, &auth.Subject{ID: userID, Type: auth.SubjectUser})
+
+			for _, check := range []struct {
+				expected bool
+				err      error
+			}{
+				{tc.expectedCanRead, authService.CanRead(ctx, cb)},
+				{tc.expectedCanWrite, authService.CanWrite(ctx, cb)},
+				{tc.expectedCanAdmin, authService.CanAdmin(ctx, cb)},
+			} {
+				if check.expected {
+					assert.NoError(t, check.err)
+				} else {
+					assert.Error(t, check.err)
+				}
+			}
+		})
+	}
+}
+
+func TestScopes_codebase(t *testing.T) {
+	cases := []struct {
+		name   string
+		scopes accesstokens.Scopes
+
+		expectedCanRead  bool
+		expectedCanWrite bool
+		expectedCanAdmin bool
+	}{
+		{
+			name:            "session",
+			expectedCanRead: true, expectedCanWrite: true, expectedCanAdmin: true,
+		},
+		{
+			name:            "codebases-read",
+			scopes:          accesstokens.Scopes{accesstokens.ScopeCodebasesRead},
+			expectedCanRead: true, expectedCanWrite: false, expectedCanAdmin: false,
+		},
+		{
+			name:            "workspaces-write",
+			scopes:          accesstokens.Scopes{accesstokens.ScopeWorkspacesWrite},
+			expectedCanRead: true, expectedCanWrite: true, expectedCanAdmin: false,
+		},
+		{
+			name:            "statuses-write",
+			scopes:          accesstokens.Scopes{accesstokens.ScopeStatusesWrite},
+			expectedCanRead: true, expectedCanWrite: false, expectedCanAdmin: false,
+		},
+		{
+			name:            "admin",
+			scopes:          accesstokens.Scopes{accesstokens.ScopeAdmin},
+			expectedCanRead: true, expectedCanWrite: true, expectedCanAdmin: true,
+		},
+	}
+
+	codebaseRepo := inmemory.NewInMemoryCodebaseRepo()
+	codebaseUserRepo := inmemory.NewInMemoryCodebaseUserRepo()
+	analyticsService := service_analytics.New(zap.NewNop(), disabled.NewClient(zap.NewNop()))
+	codebaseService := service_codebase.New(codebaseRepo, codebaseUserRepo, nil, nil, nil, nil, nil, analyticsService)
+
+	authService := service_auth.New(
+		codebaseService,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+	)
+
+	bgCtx := context.Background()
+
+	cb := codebase.Codebase{ID: uuid.NewString()}
+	assert.NoError(t, codebaseRepo.Create(cb))
+
+	userID := uuid.NewString()
+	cbu := codebase.CodebaseUser{ID: uuid.NewString(), CodebaseID: cb.ID, UserID: userID, Role: organization.RoleAdmin}
+	assert.NoError(t, codebaseUserRepo.Create(cbu))
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := auth.NewContext(bgCtx, &auth.Subject{ID: userID, Type: auth.SubjectUser, Scopes: tc.scopes})
+
+			for _, check := range []struct {
+				expected bool
+				err      error
+			}{
+				{tc.expectedCanRead, authService.CanRead(ctx, cb)},
+				{tc.expectedCanWrite, authService.CanWrite(ctx, cb)},
+				{tc.expectedCanAdmin, authService.CanAdmin(ctx, cb)},
+			} {
+				if check.expected {
+					assert.NoError(t, check.err)
+				} else {
+					assert.Error(t, check.err)
+				}
+			}
+		})
+	}
+}
+
+func TestTwoFactor_organization(t *testing.T) {
+	cases := []struct {
+		name              string
+		requireTwoFactor  bool
+		twoFactorEnabled  bool
+		withSession       bool
+		twoFactorVerified bool
+		expected          bool
+	}{
+		{name: "not-required", requireTwoFactor: false, twoFactorEnabled: false, expected: true},
+		{name: "required-enabled", requireTwoFactor: true, twoFactorEnabled: true, expected: true},
+		{name: "required-not-enabled", requireTwoFactor: true, twoFactorEnabled: false, expected: false},
+		{name: "required-enabled-session-verified", requireTwoFactor: true, twoFactorEnabled: true, withSession: true, twoFactorVerified: true, expected: true},
+		{name: "required-enabled-session-not-verified", requireTwoFactor: true, twoFactorEnabled: true, withSession: true, twoFactorVerified: false, expected: false},
+	}
+
+	codebaseRepo := inmemory.NewInMemoryCodebaseRepo()
+	codebaseUserRepo := inmemory.NewInMemoryCodebaseUserRepo()
+	analyticsService := service_analytics.New(zap.NewNop(), disabled.NewClient(zap.NewNop()))
+	codebaseService := service_codebase.New(codebaseRepo, codebaseUserRepo, nil, nil, nil, nil, nil, analyticsService)
+
+	organizationRepo := inmemory.NewInMemoryOrganizationRepo()
+	organizationMemberRepo := inmemory.NewInMemoryOrganizationMemberRepository()
+	organizationService := service_organization.New(organizationRepo, organizationMemberRepo, analyticsService)
+
+	totpRepo := db_twofactor.NewTOTPMemory()
+	sessionsService := service_sessions.New(db_sessions.NewMemory())
+	twoFactorService := service_twofactor.New(zap.NewNop(), totpRepo, db_twofactor.NewRecoveryCodesMemory(), db_twofactor.NewChallengesMemory(), organizationService, sessionsService)
+
+	authService := service_auth.New(
+		codebaseService,
+		nil,
+		nil,
+		nil,
+		organizationService,
+		twoFactorService,
+		nil,
+		nil,
+	)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			bgCtx := context.Background()
+
+			org := organization.Organization{ID: uuid.NewString(), RequireTwoFactor: tc.requireTwoFactor}
+			assert.NoError(t, organizationRepo.Create(bgCtx, org))
+
+			cb := codebase.Codebase{ID: uuid.NewString(), OrganizationID: &org.ID}
+			assert.NoError(t, codebaseRepo.Create(cb))
+
+			userID := uuid.NewString()
+			orgmember := organization.Member{ID: uuid.NewString(), OrganizationID: org.ID, UserID: userID, Role: organization.RoleOwner}
+			assert.NoError(t, organizationMemberRepo.Create(bgCtx, orgmember))
+
+			if tc.twoFactorEnabled {
+				now := time.Now()
+				assert.NoError(t, totpRepo.Set(bgCtx, &twofactor.TOTP{UserID: userID, CreatedAt: now, ConfirmedAt: &now}))
+			}
+
+			subject := &auth.Subject{ID: userID, Type: auth.SubjectUser}
+			if tc.withSession {
+				subject.SessionID = uuid.NewString()
+				assert.NoError(t, sessionsService.Issued(bgCtx, subject.SessionID, userID, time.Now().Add(time.Hour)))
+				if tc.twoFactorVerified {
+					assert.NoError(t, sessionsService.TwoFactorVerified(bgCtx, subject.SessionID))
+				}
+			}
+			ctx := auth.NewContext(bgCtx, subject)
+
+			for _, err := range []error{
+				authService.CanRead(ctx, org),
+				authService.CanRead(ctx, cb),
+			} {
+				if tc.expected {
+					assert.NoError(t, err)
+				} else {
+					assert.ErrorIs(t, err, auth.ErrForbidden)
+				}
+			}
+		})
+	}
+}
+
+func TestGuests_workspace(t *testing.T) {
+	codebaseRepo := inmemory.NewInMemoryCodebaseRepo()
+	codebaseUserRepo := inmemory.NewInMemoryCodebaseUserRepo()
+	analyticsService := service_analytics.New(zap.NewNop(), disabled.NewClient(zap.NewNop()))
+	codebaseService := service_codebase.New(codebaseRepo, codebaseUserRepo, nil, nil, nil, nil, nil, analyticsService)
+
+	guestsService := service_guests.New(zap.NewNop(), db_guests.NewMemory(), service_onetime.New(db_onetime.NewMemory()))
+
+	authService := service_auth.New(
+		codebaseService,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		guestsService,
+	)
+
+	bgCtx := context.Background()
+
+	cb := codebase.Codebase{ID: uuid.NewString()}
+	assert.NoError(t, codebaseRepo.Create(cb))
+
+	ws := &workspaces.Workspace{ID: uuid.NewString(), CodebaseID: cb.ID}
+	otherWs := &workspaces.Workspace{ID: uuid.NewString(), CodebaseID: cb.ID}
+
+	inviterID := uuid.NewString()
+	inviter := codebase.CodebaseUser{ID: uuid.NewString(), CodebaseID: cb.ID, UserID: inviterID}
+	assert.NoError(t, codebaseUserRepo.Create(inviter))
+
+	viewerID, commenterID := uuid.NewString(), uuid.NewString()
+	for userID, capability := range map[string]guests.Capability{
+		viewerID:    guests.CapabilityView,
+		commenterID: guests.CapabilityComment,
+	} {
+		code, _, err := guestsService.Create(bgCtx, cb.ID, ws.ID, inviterID, guests.Capabilities{capability}, nil)
+		assert.NoError(t, err)
+		_, err = guestsService.Accept(bgCtx, userID, code)
+		assert.NoError(t, err)
+	}
+
+	for userID, canComment := range map[string]bool{viewerID: false, commenterID: true} {
+		ctx := auth.NewContext(bgCtx, &auth.Subject{ID: userID, Type: auth.SubjectUser})
+
+		assert.NoError(t, authService.CanRead(ctx, ws))
+		assert.ErrorIs(t, authService.CanWrite(ctx, ws), auth.ErrForbidden)
+		assert.ErrorIs(t, authService.CanRead(ctx, otherWs), auth.ErrForbidden)
+		assert.ErrorIs(t, authService.CanRead(ctx, cb), auth.ErrForbidden)
+		if canComment {
+			assert.NoError(t, authService.CanComment(ctx, ws))
+		} else {
+			assert.ErrorIs(t, authService.CanComment(ctx, ws), auth.ErrForbidden)
+		}
+
+		// guests can only update their own comments if they can comment on the workspace
+		ownComment := &comments.Comment{UserID: userID, CodebaseID: cb.ID, WorkspaceID: &ws.ID}
+		inviterComment := &comments.Comment{UserID: inviterID, CodebaseID: cb.ID, WorkspaceID: &ws.ID}
+		assert.NoError(t, authService.CanRead(ctx, ownComment))
+		assert.NoError(t, authService.CanRead(ctx, inviterComment))
+		assert.ErrorIs(t, authService.CanWrite(ctx, inviterComment), auth.ErrForbidden)
+		if canComment {
+			assert.NoError(t, authService.CanWrite(ctx, ownComment))
+		} else {
+			assert.ErrorIs(t, authService.CanWrite(ctx, ownComment), auth.ErrForbidden)
+		}
+	}
+
+	// the invites stop working when the inviter leaves the codebase
+	assert.NoError(t, codebaseUserRepo.DeleteByID(bgCtx, inviter.ID))
+
+	for _, userID := range []string{viewerID, commenterID} {
+		ctx := auth.NewContext(bgCtx, &auth.Subject{ID: userID, Type: auth.SubjectUser})
+
+		assert.ErrorIs(t, authService.CanRead(ctx, ws), auth.ErrForbidden)
+		assert.ErrorIs(t, authService.CanComment(ctx, ws), auth.ErrForbidden)
+	}
+}
+
+func TestServiceTokens_workspace(t *testing.T) {
+	codebaseRepo := inmemory.NewInMemoryCodebaseRepo()
+	codebaseUserRepo := inmemory.NewInMemoryCodebaseUserRepo()
+	analyticsService := service_analytics.New(zap.NewNop(), disabled.NewClient(zap.NewNop()))
+	codebaseService := service_codebase.New(codebaseRepo, codebaseUserRepo, nil, nil, nil, nil, nil, analyticsService)
+
+	serviceTokenService := service_servicetokens.New(zap.NewNop(), db_servicetokens.NewMemory())
+
+	authService := service_auth.New(
+		codebaseService,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		serviceTokenService,
+		nil,
+	)
+
+	bgCtx := context.Background()
+
+	cb := codebase.Codebase{ID: uuid.NewString()}
+	assert.NoError(t, codebaseRepo.Create(cb))
+
+	userID := uuid.NewString()
+	cbu := codebase.CodebaseUser{ID: uuid.NewString(), CodebaseID: cb.ID, UserID: userID}
+	assert.NoError(t, codebaseUserRepo.Create(cbu))
+
+	_, token, err := serviceTokenService.Create(bgCtx, cb.ID, userID, "ci", servicetokens.Capabilities{servicetokens.CapabilityWorkspacesWrite}, nil, nil)
+	assert.NoError(t, err)
+	ctx := auth.NewContext(bgCtx, &auth.Subject{ID: token.ID, Type: auth.SubjectServiceToken, ServiceToken: token})
+
+	assert.NoError(t, authService.CanCreateWorkspace(ctx, &cb))
+
+	created := &workspaces.Workspace{ID: uuid.NewString(), CodebaseID: cb.ID, UserID: userID}
+	assert.NoError(t, authService.WorkspaceCreated(ctx, created))
+	assert.NoError(t, authService.CanRead(ctx, created))
+	assert.NoError(t, authService.CanWrite(ctx, created))
+	assert.ErrorIs(t, authService.CanAdmin(ctx, created), auth.ErrForbidden)
+
+	// other workspaces of the user that created the token
+	other := &workspaces.Workspace{ID: uuid.NewString(), CodebaseID: cb.ID, UserID: userID}
+	assert.ErrorIs(t, authService.CanRead(ctx, other), auth.ErrForbidden)
+	assert.ErrorIs(t, authService.CanWrite(ctx, other), auth.ErrForbidden)
+
+	// the user that created the token leaves the codebase
+	assert.NoError(t, codebaseUserRepo.DeleteByID(bgCtx, cbu.ID))
+	assert.ErrorIs(t, authService.CanCreateWorkspace(ctx, &cb), auth.ErrForbidden)
+	assert.ErrorIs(t, authService.CanWrite(ctx, created), auth.ErrForbidden)
+}
+
+func roleRef(role organization.Role) *organization.Role {
+	return &role
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"getsturdy.com/api/pkg/auth"
+	"getsturdy.com/api/pkg/change"
+	"getsturdy.com/api/pkg/codebase"
+	"getsturdy.com/api/pkg/servicetokens"
+	service_servicetokens "getsturdy.com/api/pkg/servicetokens/service"
+	"getsturdy.com/api/pkg/workspaces"
+)
+
+// CanCreateWorkspace checks if the subject can create workspaces in the codebase. Service tokens can if they have the
+// workspaces:write capability, and the user that created the token can still write to the codebase. Everyone else
+// needs the write permission on the codebase.
+func (s *Service) CanCreateWorkspace(ctx context.Context, cb *codebase.Codebase) error {
+	subject, found := auth.FromContext(ctx)
+	if !found {
+		return fmt.Errorf("subject is not found in the context: %w", auth.ErrUnauthenticated)
+	}
+	if subject.Type != auth.SubjectServiceToken {
+		return s.CanWrite(ctx, cb)
+	}
+
+	token := subject.ServiceToken
+	if token.CodebaseID != cb.ID {
+		return fmt.Errorf("the token doesn't have access to the codebase: %w", auth.ErrForbidden)
+	}
+	if token.CreatedBy == nil {
+		return fmt.Errorf("the token doesn't belong to a user: %w", auth.ErrForbidden)
+	}
+	if err := s.canServiceTokenCreatorAccess(ctx, token, accessTypeWrite); err != nil {
+		return err
+	}
+	return s.authorizeServiceToken(ctx, token, servicetokens.CapabilityWorkspacesWrite)
+}
+
+// WorkspaceCreated records that the workspace was created by the subject. Service tokens can only access the workspaces
+// that they created, for other subjects this is a no-op.
+func (s *Service) WorkspaceCreated(ctx context.Context, ws *workspaces.Workspace) error {
+	subject, found := auth.FromContext(ctx)
+	if !found || subject.Type != auth.SubjectServiceToken {
+		return nil
+	}
+	if err := s.serviceTokenService.AddWorkspace(ctx, subject.ServiceToken, ws.ID); err != nil {
+		return fmt.Errorf("failed to add workspace to token: %w", err)
+	}
+	return nil
+}
+
+// canServiceTokenAccess checks that the capabilities of the token allow the access. Tokens can only access objects in
+// their own codebase, and only as long as the user that created the token has the same access to the codebase:
+//
+//   - codebases can be read with changes:read or workspaces:write
+//   - changes can be read with changes:read, and written (have statuses reported on them) with statuses:write
+//   - workspaces that were created with the token can be read and written with workspaces:write
+func (s *Service) canServiceTokenAccess(ctx context.Context, token *servicetokens.Token, at accessType, obj interface{}) error {
+	var codebaseID string
+	var capability servicetokens.Capability
+
+	switch object := obj.(type) {
+	case codebase.Codebase:
+		return s.canServiceTokenAccess(ctx, token, at, &object)
+	case *codebase.Codebase:
+		codebaseID = object.ID
+		if at != accessTypeRead {
+			return fmt.Errorf("service tokens can only read codebases: %w", auth.ErrForbidden)
+		}
+		capability = servicetokens.CapabilityChangesRead
+		if !token.Capabilities.Has(capability) {
+			capability = servicetokens.CapabilityWorkspacesWrite
+		}
+	case change.Change:
+		return s.canServiceTokenAccess(ctx, token, at, &object)
+	case *change.Change:
+		codebaseID = object.CodebaseID
+		switch at {
+		case accessTypeRead:
+			capability = servicetokens.CapabilityChangesRead
+		case accessTypeWrite:
+			capability = servicetokens.CapabilityStatusesWrite
+		default:
+			return fmt.Errorf("service tokens can't administer changes: %w", auth.ErrForbidden)
+		}
+	case workspaces.Workspace:
+		return s.canServiceTokenAccess(ctx, token, at, &object)
+	case *workspaces.Workspace:
+		codebaseID = object.CodebaseID
+		if at == accessTypeAdmin {
+			return fmt.Errorf("service tokens can't administer workspaces: %w", auth.ErrForbidden)
+		}
+		if token.CreatedBy == nil {
+			return fmt.Errorf("the token doesn't belong to a user: %w", auth.ErrForbidden)
+		}
+		if codebaseID != token.CodebaseID {
+			return fmt.Errorf("the token doesn't have access to the codebase: %w", auth.ErrForbidden)
+		}
+		if err := s.canServiceTokenCreatorAccess(ctx, token, at); err != nil {
+			return err
+		}
+		return serviceTokenError(s.serviceTokenService.AuthorizeWorkspace(ctx, token, object.ID, servicetokens.CapabilityWorkspacesWrite))
+	default:
+		return fmt.Errorf("unsupported object type '%T' for service token: %w", obj, auth.ErrForbidden)
+	}
+
+	if codebaseID != token.CodebaseID {
+		return fmt.Errorf("the token doesn't have access to the codebase: %w", auth.ErrForbidden)
+	}
+	if err := s.canServiceTokenCreatorAccess(ctx, token, at); err != nil {
+		return err
+	}
+	return s.authorizeServiceToken(ctx, token, capability)
+}
+
+// canServiceTokenCreatorAccess checks that the user that created the token still has the access to the codebase of the
+// token, so that tokens stop working when their creator leaves the codebase. Tokens from before tokens were created by
+// users don't belong to anyone, and only have the access of their capabilities.
+func (s *Service) canServiceTokenCreatorAccess(ctx context.Context, token *servicetokens.Token, at accessType) error {
+	if token.CreatedBy == nil {
+		return nil
+	}
+	cb, err := s.codebaseService.GetByID(ctx, token.CodebaseID)
+	if err != nil {
+		return fmt.Errorf("failed to get codebase: %w", err)
+	}
+	if err := s.canUserAccessCodebase(ctx, *token.CreatedBy, at, cb); err != nil {
+		if errors.Is(err, auth.ErrForbidden) {
+			return fmt.Errorf("the user that created the token doesn't have access to the codebase: %w", auth.ErrForbidden)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *Service) authorizeServiceToken(ctx context.Context, token *servicetokens.Token, capability servicetokens.Capability) error {
+	return serviceTokenError(s.serviceTokenService.Authorize(ctx, token, capability))
+}
+
+// serviceTokenError translates the errors of the service tokens service to the errors of the auth package.
+func serviceTokenError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service_servicetokens.ErrForbidden):
+		return fmt.Errorf("%s: %w", err.Error(), auth.ErrForbidden)
+	default:
+		return err
+	}
+}
@@ -2,7 +2,11 @@ package auth
 
 import (
 	"context"
+	"fmt"
+
+	"getsturdy.com/api/pkg/accesstokens"
 	"getsturdy.com/api/pkg/jwt"
+	"getsturdy.com/api/pkg/servicetokens"
 )
 
 type SubjectType string
@@ -13,6 +17,8 @@ const (
 	SubjectCI        SubjectType = "ci"
 	SubjectMutagen   SubjectType = "mutagen"
 	SubjectAnonymous SubjectType = "anonymous"
+	// SubjectServiceToken is CI or other automation, that is authenticated with a service token.
+	SubjectServiceToken SubjectType = "service_token"
 )
 
 func (st SubjectType) String() string {
@@ -22,6 +28,21 @@ func (st SubjectType) String() string {
 type Subject struct {
 	ID   string
 	Type SubjectType
+
+	// SessionID is the ID of the session of a user that is authenticated with a session.
+	SessionID string
+
+	// Scopes limit what a user that is authenticated with a personal access token can do. Users that are
+	// authenticated with a session have no scopes, and are not limited.
+	Scopes accesstokens.Scopes
+
+	// ServiceToken is the token that a SubjectServiceToken is authenticated with.
+	ServiceToken *servicetokens.Token
+}
+
+// HasScope returns true if the subject is allowed to do what the scope allows.
+func (s *Subject) HasScope(scope accesstokens.Scope) bool {
+	return s.Scopes == nil || s.Scopes.Has(scope)
 }
 
 var (
@@ -36,10 +57,14 @@ func subjectFromToken(token *jwt.Token) *Subject {
 		return &Subject{Type: SubjectAnonymous}
 	}
 
-	return &Subject{
+	subject := &Subject{
 		ID:   token.Subject,
 		Type: convertType[token.Type],
 	}
+	if token.Type == jwt.TokenTypeAuth {
+		subject.SessionID = token.ID
+	}
+	return subject
 }
 
 type subjectKeyType struct{}
@@ -68,3 +93,32 @@ func UserID(ctx context.Context) (string, error) {
 	}
 	return s.ID, nil
 }
+
+// ActingUserID returns the ID of the user that the authenticated subject acts for. That is the user itself, or the user
+// that created the service token that the subject is authenticated with.
+func ActingUserID(ctx context.Context) (string, error) {
+	s, ok := FromContext(ctx)
+	if !ok {
+		return "", ErrUnauthenticated
+	}
+	switch {
+	case s.Type == SubjectUser:
+		return s.ID, nil
+	case s.Type == SubjectServiceToken && s.ServiceToken.CreatedBy != nil:
+		return *s.ServiceToken.CreatedBy, nil
+	default:
+		return "", ErrUnauthenticated
+	}
+}
+
+// RequireScope returns ErrForbidden if the authenticated subject is not allowed to do what the scope allows.
+func RequireScope(ctx context.Context, scope accesstokens.Scope) error {
+	s, ok := FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !s.HasScope(scope) {
+		return fmt.Errorf("token is missing the %s scope: %w", scope, ErrForbidden)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package autorevert
+
+import (
+	"time"
+
+	"getsturdy.com/api/pkg/change"
+
+	"github.com/lib/pq"
+)
+
+// Config controls if changes on trunk are reverted automatically when a required check fails.
+type Config struct {
+	CodebaseID string `db:"codebase_id"`
+	Enabled    bool   `db:"enabled"`
+	// RequiredChecks are the titles of the statuses that must not fail
+	RequiredChecks pq.StringArray `db:"required_checks"`
+	// OnCallGroupID is the ID of a group in the codebase ACL. Members of the group are notified of reverts.
+	OnCallGroupID *string `db:"on_call_group_id"`
+	// AutoLand lands the revert workspace directly, instead of leaving it for the author to land
+	AutoLand  bool       `db:"auto_land"`
+	UpdatedBy *string    `db:"updated_by"`
+	UpdatedAt *time.Time `db:"updated_at"`
+}
+
+// DefaultConfig is used for codebases that have not configured automatic reverts.
+func DefaultConfig(codebaseID string) *Config {
+	return &Config{
+		CodebaseID:     codebaseID,
+		RequiredChecks: pq.StringArray{},
+	}
+}
+
+// IsRequired returns true if the status with the title is a required check.
+func (c *Config) IsRequired(title string) bool {
+	for _, check := range c.RequiredChecks {
+		if check == title {
+			return true
+		}
+	}
+	return false
+}
+
+// Revert is a change that has been reverted automatically.
+type Revert struct {
+	ID         string    `db:"id"`
+	CodebaseID string    `db:"codebase_id"`
+	ChangeID   change.ID `db:"change_id"`
+	// StatusID is the failing status that triggered the revert
+	StatusID string `db:"status_id"`
+	// WorkspaceID is the revert workspace, it's set once the workspace has been created
+	WorkspaceID *string `db:"workspace_id"`
+	// LandedChangeID is set if the revert workspace was landed automatically
+	LandedChangeID *change.ID `db:"landed_change_id"`
+	// LandAttempts is the number of times that landing the revert workspace has failed
+	LandAttempts int `db:"land_attempts"`
+	// NextLandAttemptAt is set if landing the revert workspace failed, and is waiting to be retried
+	NextLandAttemptAt *time.Time `db:"next_land_attempt_at"`
+	CreatedAt         time.Time  `db:"created_at"`
+}
@@ -0,0 +1,20 @@
+package autorevert_test
+
+import (
+	"testing"
+
+	"getsturdy.com/api/pkg/autorevert"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigIsRequired(t *testing.T) {
+	config := autorevert.DefaultConfig("codebase")
+	assert.False(t, config.IsRequired("ci/build"))
+
+	config.RequiredChecks = append(config.RequiredChecks, "ci/build", "ci/test")
+	assert.True(t, config.IsRequired("ci/build"))
+	assert.True(t, config.IsRequired("ci/test"))
+	assert.False(t, config.IsRequired("ci/lint"))
+	assert.False(t, config.IsRequired(""))
+}
@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"getsturdy.com/api/pkg/autorevert"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ConfigRepository interface {
+	Get(ctx context.Context, codebaseID string) (*autorevert.Config, error)
+	Upsert(context.Context, *autorevert.Config) error
+}
+
+type configRepo struct {
+	db *sqlx.DB
+}
+
+func NewConfigRepository(db *sqlx.DB) ConfigRepository {
+	return &configRepo{db: db}
+}
+
+func (r *configRepo) Get(ctx context.Context, codebaseID string) (*autorevert.Config, error) {
+	var res autorevert.Config
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			codebase_id, enabled, required_checks, on_call_group_id, auto_land, updated_by, updated_at
+		FROM
+			auto_revert_configs
+		WHERE
+			codebase_id = $1
+	`, codebaseID); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *configRepo) Upsert(ctx context.Context, config *autorevert.Config) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO auto_revert_configs
+			(codebase_id, enabled, required_checks, on_call_group_id, auto_land, updated_by, updated_at)
+		VALUES
+			(:codebase_id, :enabled, :required_checks, :on_call_group_id, :auto_land, :updated_by, :updated_at)
+		ON CONFLICT (codebase_id) DO UPDATE SET
+			enabled = :enabled,
+			required_checks = :required_checks,
+			on_call_group_id = :on_call_group_id,
+			auto_land = :auto_land,
+			updated_by = :updated_by,
+			updated_at = :updated_at
+	`, config); err != nil {
+		return fmt.Errorf("failed to upsert: %w", err)
+	}
+	return nil
+}
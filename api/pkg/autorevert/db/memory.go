@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"getsturdy.com/api/pkg/autorevert"
+)
+
+var (
+	_ ConfigRepository = &inMemoryConfigs{}
+	_ RevertRepository = &inMemoryReverts{}
+)
+
+type inMemoryConfigs struct {
+	mu           sync.Mutex
+	byCodebaseID map[string]autorevert.Config
+}
+
+func NewInMemoryConfigRepository() *inMemoryConfigs {
+	return &inMemoryConfigs{byCodebaseID: make(map[string]autorevert.Config)}
+}
+
+func (i *inMemoryConfigs) Get(_ context.Context, codebaseID string) (*autorevert.Config, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	config, ok := i.byCodebaseID[codebaseID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &config, nil
+}
+
+func (i *inMemoryConfigs) Upsert(_ context.Context, config *autorevert.Config) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.byCodebaseID[config.CodebaseID] = *config
+	return nil
+}
+
+type inMemoryReverts struct {
+	mu   sync.Mutex
+	byID map[string]autorevert.Revert
+}
+
+func NewInMemoryRevertRepository() *inMemoryReverts {
+	return &inMemoryReverts{byID: make(map[string]autorevert.Revert)}
+}
+
+func (i *inMemoryReverts) Create(_ context.Context, revert *autorevert.Revert) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, existing := range i.byID {
+		if existing.ChangeID == revert.ChangeID {
+			return ErrAlreadyExists
+		}
+	}
+	i.byID[revert.ID] = *revert
+	return nil
+}
+
+func (i *inMemoryReverts) Get(_ context.Context, id string) (*autorevert.Revert, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	revert, ok := i.byID[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &revert, nil
+}
+
+func (i *inMemoryReverts) GetByChangeID(_ context.Context, changeID string) (*autorevert.Revert, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, revert := range i.byID {
+		if string(revert.ChangeID) == changeID {
+			return &revert, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (i *inMemoryReverts) Update(_ context.Context, revert *autorevert.Revert) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	existing, ok := i.byID[revert.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	existing.LandedChangeID = revert.LandedChangeID
+	existing.LandAttempts = revert.LandAttempts
+	existing.NextLandAttemptAt = revert.NextLandAttemptAt
+	i.byID[revert.ID] = existing
+	return nil
+}
+
+func (i *inMemoryReverts) ClaimWorkspace(_ context.Context, id, workspaceID string) (bool, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	revert, ok := i.byID[id]
+	if !ok || revert.WorkspaceID != nil {
+		return false, nil
+	}
+	revert.WorkspaceID = &workspaceID
+	i.byID[id] = revert
+	return true, nil
+}
+
+func (i *inMemoryReverts) ClaimDueLands(_ context.Context, now time.Time) ([]string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var ids []string
+	for id, revert := range i.byID {
+		if revert.NextLandAttemptAt == nil || revert.NextLandAttemptAt.After(now) {
+			continue
+		}
+		revert.NextLandAttemptAt = nil
+		i.byID[id] = revert
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,8 @@
+package db
+
+import "getsturdy.com/api/pkg/di"
+
+func Module(c *di.Container) {
+	c.Register(NewConfigRepository)
+	c.Register(NewRevertRepository)
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/autorevert"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var ErrAlreadyExists = errors.New("already exists")
+
+type RevertRepository interface {
+	// Create returns ErrAlreadyExists if the change has already been reverted.
+	Create(context.Context, *autorevert.Revert) error
+	Get(ctx context.Context, id string) (*autorevert.Revert, error)
+	GetByChangeID(ctx context.Context, changeID string) (*autorevert.Revert, error)
+	// Update updates the landing of the revert, the workspace is set with ClaimWorkspace.
+	Update(context.Context, *autorevert.Revert) error
+	// ClaimWorkspace sets the revert workspace of the revert, and returns false if it already has one.
+	ClaimWorkspace(ctx context.Context, id, workspaceID string) (bool, error)
+	// ClaimDueLands clears next_land_attempt_at of the reverts that are due to be landed again at the given
+	// time, and returns their IDs.
+	ClaimDueLands(ctx context.Context, now time.Time) ([]string, error)
+}
+
+type revertRepo struct {
+	db *sqlx.DB
+}
+
+func NewRevertRepository(db *sqlx.DB) RevertRepository {
+	return &revertRepo{db: db}
+}
+
+func (r *revertRepo) Create(ctx context.Context, revert *autorevert.Revert) error {
+	res, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO auto_reverts
+			(id, codebase_id, change_id, status_id, workspace_id, landed_change_id, land_attempts, next_land_attempt_at,
+			 created_at)
+		VALUES
+			(:id, :codebase_id, :change_id, :status_id, :workspace_id, :landed_change_id, :land_attempts, :next_land_attempt_at,
+			 :created_at)
+		ON CONFLICT (change_id) DO NOTHING
+	`, revert)
+	if err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return ErrAlreadyExists
+	}
+	return nil
+}
+
+func (r *revertRepo) Get(ctx context.Context, id string) (*autorevert.Revert, error) {
+	var res autorevert.Revert
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, codebase_id, change_id, status_id, workspace_id, landed_change_id, land_attempts, next_land_attempt_at,
+			created_at
+		FROM
+			auto_reverts
+		WHERE
+			id = $1
+	`, id); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *revertRepo) GetByChangeID(ctx context.Context, changeID string) (*autorevert.Revert, error) {
+	var res autorevert.Revert
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, codebase_id, change_id, status_id, workspace_id, landed_change_id, land_attempts, next_land_attempt_at,
+			created_at
+		FROM
+			auto_reverts
+		WHERE
+			change_id = $1
+	`, changeID); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *revertRepo) Update(ctx context.Context, revert *autorevert.Revert) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		UPDATE
+			auto_reverts
+		SET
+			landed_change_id = :landed_change_id,
+			land_attempts = :land_attempts,
+			next_land_attempt_at = :next_land_attempt_at
+		WHERE
+			id = :id
+	`, revert); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (r *revertRepo) ClaimWorkspace(ctx context.Context, id, workspaceID string) (bool, error) {
+	var claimedID string
+	err := r.db.GetContext(ctx, &claimedID, `
+		UPDATE
+			auto_reverts
+		SET
+			workspace_id = $1
+		WHERE
+			id = $2
+			AND workspace_id IS NULL
+		RETURNING
+			id
+	`, workspaceID, id)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to claim workspace: %w", err)
+	}
+}
+
+func (r *revertRepo) ClaimDueLands(ctx context.Context, now time.Time) ([]string, error) {
+	var ids []string
+	if err := r.db.SelectContext(ctx, &ids, `
+		UPDATE
+			auto_reverts
+		SET
+			next_land_attempt_at = NULL
+		WHERE
+			next_land_attempt_at <= $1
+		RETURNING
+			id
+	`, now); err != nil {
+		return nil, fmt.Errorf("failed to claim: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,207 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"getsturdy.com/api/pkg/auth"
+	service_auth "getsturdy.com/api/pkg/auth/service"
+	"getsturdy.com/api/pkg/autorevert"
+	service_autorevert "getsturdy.com/api/pkg/autorevert/service"
+	service_change "getsturdy.com/api/pkg/change/service"
+	service_codebase "getsturdy.com/api/pkg/codebase/service"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	service_statuses "getsturdy.com/api/pkg/statuses/service"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/lib/pq"
+)
+
+type rootResolver struct {
+	authService       *service_auth.Service
+	codebaseService   *service_codebase.Service
+	changeService     *service_change.Service
+	statusService     *service_statuses.Service
+	autoRevertService *service_autorevert.Service
+
+	changeRootResolver    resolvers.ChangeRootResolver
+	statusesRootResolver  resolvers.StatusesRootResolver
+	workspaceRootResolver *resolvers.WorkspaceRootResolver
+}
+
+func New(
+	authService *service_auth.Service,
+	codebaseService *service_codebase.Service,
+	changeService *service_change.Service,
+	statusService *service_statuses.Service,
+	autoRevertService *service_autorevert.Service,
+	changeRootResolver resolvers.ChangeRootResolver,
+	statusesRootResolver resolvers.StatusesRootResolver,
+	workspaceRootResolver *resolvers.WorkspaceRootResolver,
+) resolvers.AutoRevertRootResolver {
+	return &rootResolver{
+		authService:           authService,
+		codebaseService:       codebaseService,
+		changeService:         changeService,
+		statusService:         statusService,
+		autoRevertService:     autoRevertService,
+		changeRootResolver:    changeRootResolver,
+		statusesRootResolver:  statusesRootResolver,
+		workspaceRootResolver: workspaceRootResolver,
+	}
+}
+
+func (r *rootResolver) InternalConfigByCodebaseID(ctx context.Context, codebaseID string) (resolvers.AutoRevertConfigResolver, error) {
+	config, err := r.autoRevertService.GetConfig(ctx, codebaseID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return &configResolver{config: config}, nil
+}
+
+func (r *rootResolver) InternalRevertByID(ctx context.Context, id string) (resolvers.AutoRevertResolver, error) {
+	revert, err := r.autoRevertService.GetRevert(ctx, id)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return &revertResolver{root: r, revert: revert}, nil
+}
+
+func (r *rootResolver) UpdateAutoRevertConfig(ctx context.Context, args resolvers.UpdateAutoRevertConfigArgs) (resolvers.AutoRevertConfigResolver, error) {
+	cb, err := r.codebaseService.GetByID(ctx, string(args.Input.CodebaseID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.authService.CanAdmin(ctx, cb); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	config, err := r.autoRevertService.GetConfig(ctx, cb.ID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if args.Input.Enabled != nil {
+		config.Enabled = *args.Input.Enabled
+	}
+	if args.Input.RequiredChecks != nil {
+		config.RequiredChecks = pq.StringArray(*args.Input.RequiredChecks)
+	}
+	if args.Input.OnCallGroupID != nil {
+		if *args.Input.OnCallGroupID == "" {
+			config.OnCallGroupID = nil
+		} else {
+			config.OnCallGroupID = args.Input.OnCallGroupID
+		}
+	}
+	if args.Input.AutoLand != nil {
+		config.AutoLand = *args.Input.AutoLand
+	}
+
+	if err := r.autoRevertService.UpdateConfig(ctx, config, userID); errors.Is(err, service_autorevert.ErrInvalidConfig) {
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "required checks must be unique and set when enabled, and the on-call group must exist in the ACL")
+	} else if err != nil {
+		return nil, gqlerrors.Error(fmt.Errorf("failed to update auto revert config: %w", err))
+	}
+
+	return &configResolver{config: config}, nil
+}
+
+type configResolver struct {
+	config *autorevert.Config
+}
+
+func (r *configResolver) ID() graphql.ID {
+	return graphql.ID(r.config.CodebaseID)
+}
+
+func (r *configResolver) Enabled() bool {
+	return r.config.Enabled
+}
+
+func (r *configResolver) RequiredChecks() []string {
+	return r.config.RequiredChecks
+}
+
+func (r *configResolver) OnCallGroupID() *string {
+	return r.config.OnCallGroupID
+}
+
+func (r *configResolver) AutoLand() bool {
+	return r.config.AutoLand
+}
+
+func (r *configResolver) UpdatedAt() *int32 {
+	if r.config.UpdatedAt == nil {
+		return nil
+	}
+	t := int32(r.config.UpdatedAt.Unix())
+	return &t
+}
+
+type revertResolver struct {
+	root   *rootResolver
+	revert *autorevert.Revert
+}
+
+func (r *revertResolver) ID() graphql.ID {
+	return graphql.ID(r.revert.ID)
+}
+
+func (r *revertResolver) Change(ctx context.Context) (resolvers.ChangeResolver, error) {
+	ch, err := r.root.changeService.GetChangeByID(ctx, r.revert.ChangeID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return r.root.changeRootResolver.InternalChange(ch), nil
+}
+
+func (r *revertResolver) Status(ctx context.Context) (resolvers.StatusResolver, error) {
+	status, err := r.root.statusService.Get(ctx, r.revert.StatusID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return r.root.statusesRootResolver.InternalStatus(status), nil
+}
+
+func (r *revertResolver) Workspace(ctx context.Context) (resolvers.WorkspaceResolver, error) {
+	if r.revert.WorkspaceID == nil {
+		return nil, nil
+	}
+	allowArchived := true
+	ws, err := (*r.root.workspaceRootResolver).Workspace(ctx, resolvers.WorkspaceArgs{
+		ID:            graphql.ID(*r.revert.WorkspaceID),
+		AllowArchived: &allowArchived,
+	})
+	switch {
+	case err == nil:
+		return ws, nil
+	case errors.Is(err, gqlerrors.ErrNotFound):
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (r *revertResolver) LandedChange(ctx context.Context) (resolvers.ChangeResolver, error) {
+	if r.revert.LandedChangeID == nil {
+		return nil, nil
+	}
+	ch, err := r.root.changeService.GetChangeByID(ctx, *r.revert.LandedChangeID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return r.root.changeRootResolver.InternalChange(ch), nil
+}
+
+func (r *revertResolver) CreatedAt() int32 {
+	return int32(r.revert.CreatedAt.Unix())
+}
@@ -0,0 +1,16 @@
+package module
+
+import (
+	"getsturdy.com/api/pkg/autorevert/db"
+	"getsturdy.com/api/pkg/autorevert/graphql"
+	"getsturdy.com/api/pkg/autorevert/service"
+	"getsturdy.com/api/pkg/autorevert/worker"
+	"getsturdy.com/api/pkg/di"
+)
+
+func Module(c *di.Container) {
+	c.Import(db.Module)
+	c.Import(graphql.Module)
+	c.Import(service.Module)
+	c.Import(worker.Module)
+}
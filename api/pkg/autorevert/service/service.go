@@ -0,0 +1,440 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"time"
+
+	"getsturdy.com/api/pkg/autorevert"
+	db_autorevert "getsturdy.com/api/pkg/autorevert/db"
+	"getsturdy.com/api/pkg/change"
+	service_change "getsturdy.com/api/pkg/change/service"
+	"getsturdy.com/api/pkg/codebase/acl"
+	provider_acl "getsturdy.com/api/pkg/codebase/acl/provider"
+	db_codebase "getsturdy.com/api/pkg/codebase/db"
+	"getsturdy.com/api/pkg/notification"
+	sender_notification "getsturdy.com/api/pkg/notification/sender"
+	"getsturdy.com/api/pkg/statuses"
+	service_statuses "getsturdy.com/api/pkg/statuses/service"
+	db_user "getsturdy.com/api/pkg/users/db"
+	service_workspace "getsturdy.com/api/pkg/workspaces/service"
+	"getsturdy.com/api/vcs"
+	"getsturdy.com/api/vcs/executor"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrInvalidConfig = errors.New("invalid config")
+
+	errWorkspaceArchived = errors.New("the revert workspace is archived")
+)
+
+const (
+	// maxLandAttempts is the number of times landing a revert workspace is attempted
+	maxLandAttempts = 5
+)
+
+type Service struct {
+	logger             *zap.Logger
+	configRepo         db_autorevert.ConfigRepository
+	revertRepo         db_autorevert.RevertRepository
+	codebaseUserRepo   db_codebase.CodebaseUserRepository
+	userRepo           db_user.Repository
+	statusService      *service_statuses.Service
+	changeService      *service_change.Service
+	workspaceService   service_workspace.Service
+	aclProvider        *provider_acl.Provider
+	notificationSender sender_notification.NotificationSender
+	executorProvider   executor.Provider
+}
+
+func New(
+	logger *zap.Logger,
+	configRepo db_autorevert.ConfigRepository,
+	revertRepo db_autorevert.RevertRepository,
+	codebaseUserRepo db_codebase.CodebaseUserRepository,
+	userRepo db_user.Repository,
+	statusService *service_statuses.Service,
+	changeService *service_change.Service,
+	workspaceService service_workspace.Service,
+	aclProvider *provider_acl.Provider,
+	notificationSender sender_notification.NotificationSender,
+	executorProvider executor.Provider,
+) *Service {
+	return &Service{
+		logger:             logger.Named("autoRevertService"),
+		configRepo:         configRepo,
+		revertRepo:         revertRepo,
+		codebaseUserRepo:   codebaseUserRepo,
+		userRepo:           userRepo,
+		statusService:      statusService,
+		changeService:      changeService,
+		workspaceService:   workspaceService,
+		aclProvider:        aclProvider,
+		notificationSender: notificationSender,
+		executorProvider:   executorProvider,
+	}
+}
+
+// GetConfig returns the auto revert config of the codebase, or the default config if the codebase has none.
+func (s *Service) GetConfig(ctx context.Context, codebaseID string) (*autorevert.Config, error) {
+	config, err := s.configRepo.Get(ctx, codebaseID)
+	switch {
+	case err == nil:
+		return config, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return autorevert.DefaultConfig(codebaseID), nil
+	default:
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+}
+
+func (s *Service) UpdateConfig(ctx context.Context, config *autorevert.Config, userID string) error {
+	seen := make(map[string]bool, len(config.RequiredChecks))
+	for _, check := range config.RequiredChecks {
+		if check == "" || seen[check] {
+			return ErrInvalidConfig
+		}
+		seen[check] = true
+	}
+	if config.Enabled && len(config.RequiredChecks) == 0 {
+		return ErrInvalidConfig
+	}
+
+	if config.OnCallGroupID != nil {
+		codebaseACL, err := s.aclProvider.GetByCodebaseID(ctx, config.CodebaseID)
+		if err != nil {
+			return fmt.Errorf("failed to get acl: %w", err)
+		}
+		if !hasGroup(codebaseACL.Policy, *config.OnCallGroupID) {
+			return ErrInvalidConfig
+		}
+	}
+
+	now := time.Now()
+	config.UpdatedAt = &now
+	config.UpdatedBy = &userID
+	if err := s.configRepo.Upsert(ctx, config); err != nil {
+		return fmt.Errorf("failed to update config: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) GetRevert(ctx context.Context, id string) (*autorevert.Revert, error) {
+	return s.revertRepo.Get(ctx, id)
+}
+
+// OnStatusUpdated reverts the change that the status belongs to, if the status is a failing required check.
+//
+// A revert workspace is created on top of the change, and optionally landed. The change author and the
+// on-call group of the codebase are notified. Each change is reverted at most once, but if creating the revert
+// workspace failed, the revert is resumed when the next failing status of the change is handled (or when the
+// status update is retried). Landing is retried by RetryLands if it fails.
+func (s *Service) OnStatusUpdated(ctx context.Context, statusID string) error {
+	status, err := s.statusService.Get(ctx, statusID)
+	if err != nil {
+		return fmt.Errorf("failed to get status: %w", err)
+	}
+
+	if status.Type != statuses.TypeFailing {
+		return nil
+	}
+
+	config, err := s.GetConfig(ctx, status.CodebaseID)
+	if err != nil {
+		return err
+	}
+	if !config.Enabled || !config.IsRequired(status.Title) {
+		return nil
+	}
+
+	logger := s.logger.With(
+		zap.String("codebase_id", status.CodebaseID),
+		zap.String("commit_id", status.CommitID),
+		zap.String("status_id", status.ID),
+	)
+
+	// statuses can be reported for commits that are not on trunk, such as pull requests
+	var onTrunk bool
+	if err := s.executorProvider.New().GitRead(func(repo vcs.RepoGitReader) error {
+		var err error
+		onTrunk, err = repo.BranchHasCommit("sturdytrunk", status.CommitID)
+		return err
+	}).ExecTrunk(status.CodebaseID, "autoRevertOnTrunk"); err != nil {
+		return fmt.Errorf("failed to check if commit is on trunk: %w", err)
+	}
+	if !onTrunk {
+		return nil
+	}
+
+	ch, err := s.changeService.GetByCommitAndCodebase(ctx, status.CommitID, status.CodebaseID)
+	if errors.Is(err, service_change.ErrNotFound) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get change: %w", err)
+	}
+
+	ownerID := ch.UserID
+	if ownerID == nil {
+		// changes created outside of Sturdy have no author, the revert is owned by whoever enabled auto reverts
+		ownerID = config.UpdatedBy
+	}
+	if ownerID == nil {
+		logger.Warn("no user to own the revert workspace")
+		return nil
+	}
+
+	return s.revertChange(ctx, logger, config, ch, status, *ownerID)
+}
+
+// revertChange creates the revert workspace of the change owned by ownerID, optionally lands it, and notifies the
+// author and the on-call group. If the change is reverted concurrently, only one revert workspace is kept.
+func (s *Service) revertChange(ctx context.Context, logger *zap.Logger, config *autorevert.Config, ch *change.Change, status *statuses.Status, ownerID string) error {
+	revert, err := s.getOrCreateRevert(ctx, ch, status)
+	if err != nil {
+		return err
+	}
+	if revert.WorkspaceID != nil {
+		// already reverted
+		return nil
+	}
+
+	title := "Untitled"
+	if ch.Title != nil {
+		title = *ch.Title
+	}
+
+	ws, err := s.workspaceService.Create(ctx, service_workspace.CreateWorkspaceRequest{
+		UserID:     ownerID,
+		CodebaseID: ch.CodebaseID,
+		Name:       "Revert " + title,
+		DraftDescription: fmt.Sprintf(
+			"<p>Revert %s</p><p>Reverted automatically, the required check %s is failing.</p>",
+			html.EscapeString(title),
+			html.EscapeString(status.Title),
+		),
+		BaseChangeID: &ch.ID,
+		Revert:       true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create revert workspace: %w", err)
+	}
+
+	claimed, err := s.revertRepo.ClaimWorkspace(ctx, revert.ID, ws.ID)
+	if err != nil {
+		return fmt.Errorf("failed to claim revert: %w", err)
+	}
+	if !claimed {
+		// another status update of the change created a revert workspace first
+		if err := s.workspaceService.Archive(ctx, ws); err != nil {
+			return fmt.Errorf("failed to archive duplicate revert workspace: %w", err)
+		}
+		return nil
+	}
+	revert.WorkspaceID = &ws.ID
+
+	logger.Info("created revert workspace", zap.String("workspace_id", ws.ID))
+
+	if config.AutoLand {
+		if err := s.tryLand(ctx, logger, revert); err != nil {
+			return err
+		}
+	}
+
+	recipients, err := s.recipients(ctx, config, ch)
+	if err != nil {
+		return fmt.Errorf("failed to list recipients: %w", err)
+	}
+	for _, userID := range recipients {
+		if err := s.notificationSender.User(ctx, userID, ch.CodebaseID, notification.AutoRevertNotificationType, revert.ID); err != nil {
+			logger.Error("failed to send notification", zap.String("user_id", userID), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+// RetryLands lands the revert workspaces that failed to land, and are due to be retried. It's called by the
+// scheduler.
+func (s *Service) RetryLands(ctx context.Context) error {
+	ids, err := s.revertRepo.ClaimDueLands(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to claim due lands: %w", err)
+	}
+
+	for _, id := range ids {
+		logger := s.logger.With(zap.String("revert_id", id))
+
+		revert, err := s.revertRepo.Get(ctx, id)
+		if err != nil {
+			logger.Error("failed to get revert", zap.Error(err))
+			continue
+		}
+		if revert.WorkspaceID == nil || revert.LandedChangeID != nil {
+			continue
+		}
+
+		config, err := s.GetConfig(ctx, revert.CodebaseID)
+		if err != nil {
+			logger.Error("failed to get config", zap.Error(err))
+			continue
+		}
+		if !config.Enabled || !config.AutoLand {
+			continue
+		}
+
+		if err := s.tryLand(ctx, logger, revert); err != nil {
+			logger.Error("failed to land revert", zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// tryLand lands the revert workspace of the revert. If landing fails, it's retried later with a backoff, until
+// maxLandAttempts is reached, and the workspace is left for someone to land manually.
+func (s *Service) tryLand(ctx context.Context, logger *zap.Logger, revert *autorevert.Revert) error {
+	logger = logger.With(zap.String("workspace_id", *revert.WorkspaceID))
+
+	landedChange, err := s.land(ctx, *revert.WorkspaceID)
+	switch {
+	case err == nil:
+		revert.LandedChangeID = &landedChange.ID
+		revert.NextLandAttemptAt = nil
+		logger.Info("landed revert", zap.Stringer("landed_change_id", landedChange.ID))
+	case errors.Is(err, errWorkspaceArchived):
+		// the workspace has been landed or discarded by someone else
+		revert.NextLandAttemptAt = nil
+	default:
+		revert.LandAttempts++
+		if revert.LandAttempts < maxLandAttempts {
+			next := time.Now().Add(landRetryDelay(revert.LandAttempts))
+			revert.NextLandAttemptAt = &next
+			logger.Warn("failed to land revert, retrying", zap.Int("attempts", revert.LandAttempts), zap.Time("next_attempt_at", next), zap.Error(err))
+		} else {
+			revert.NextLandAttemptAt = nil
+			logger.Error("failed to land revert, the workspace is kept for someone to land manually", zap.Int("attempts", revert.LandAttempts), zap.Error(err))
+		}
+	}
+
+	if err := s.revertRepo.Update(ctx, revert); err != nil {
+		return fmt.Errorf("failed to update revert: %w", err)
+	}
+	return nil
+}
+
+// landRetryDelay returns how long to wait before landing again, after the given number of failed attempts.
+func landRetryDelay(attempts int) time.Duration {
+	return time.Minute << (attempts - 1)
+}
+
+// getOrCreateRevert returns the revert of the change, and creates it if the change has not been reverted before.
+func (s *Service) getOrCreateRevert(ctx context.Context, ch *change.Change, status *statuses.Status) (*autorevert.Revert, error) {
+	revert := &autorevert.Revert{
+		ID:         uuid.NewString(),
+		CodebaseID: ch.CodebaseID,
+		ChangeID:   ch.ID,
+		StatusID:   status.ID,
+		CreatedAt:  time.Now(),
+	}
+	err := s.revertRepo.Create(ctx, revert)
+	switch {
+	case err == nil:
+		return revert, nil
+	case errors.Is(err, db_autorevert.ErrAlreadyExists):
+		existing, err := s.revertRepo.GetByChangeID(ctx, string(ch.ID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get revert: %w", err)
+		}
+		return existing, nil
+	default:
+		return nil, fmt.Errorf("failed to create revert: %w", err)
+	}
+}
+
+func (s *Service) land(ctx context.Context, workspaceID string) (*change.Change, error) {
+	ws, err := s.workspaceService.GetByID(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	if ws.ArchivedAt != nil {
+		return nil, errWorkspaceArchived
+	}
+
+	diffs, _, err := s.workspaceService.Diffs(ctx, ws.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diffs: %w", err)
+	}
+
+	var patchIDs []string
+	for _, diff := range diffs {
+		for _, hunk := range diff.Hunks {
+			patchIDs = append(patchIDs, hunk.ID)
+		}
+	}
+	if len(patchIDs) == 0 {
+		return nil, fmt.Errorf("the revert workspace has no changes")
+	}
+
+	return s.workspaceService.LandChange(ctx, ws, patchIDs)
+}
+
+// recipients returns the IDs of the users that should be notified about a revert of the change: the author
+// of the change, and the members of the on-call group.
+func (s *Service) recipients(ctx context.Context, config *autorevert.Config, ch *change.Change) ([]string, error) {
+	var res []string
+	added := map[string]bool{}
+	add := func(userID string) {
+		if !added[userID] {
+			added[userID] = true
+			res = append(res, userID)
+		}
+	}
+
+	if ch.UserID != nil {
+		add(*ch.UserID)
+	}
+
+	if config.OnCallGroupID == nil {
+		return res, nil
+	}
+
+	codebaseACL, err := s.aclProvider.GetByCodebaseID(ctx, ch.CodebaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get acl: %w", err)
+	}
+
+	members, err := s.codebaseUserRepo.GetByCodebase(ch.CodebaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list codebase members: %w", err)
+	}
+	userIDs := make([]string, 0, len(members))
+	for _, member := range members {
+		userIDs = append(userIDs, member.UserID)
+	}
+	users, err := s.userRepo.GetByIDs(ctx, userIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	for _, user := range users {
+		if codebaseACL.Policy.IsGroupMember(*config.OnCallGroupID, acl.Identity{Type: acl.Users, ID: user.Email}) {
+			add(user.ID)
+		}
+	}
+
+	return res, nil
+}
+
+func hasGroup(policy acl.Policy, groupID string) bool {
+	for _, group := range policy.Groups {
+		if group.ID == groupID {
+			return true
+		}
+	}
+	return false
+}
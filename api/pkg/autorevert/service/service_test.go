@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/autorevert"
+	db_autorevert "getsturdy.com/api/pkg/autorevert/db"
+	"getsturdy.com/api/pkg/change"
+	"getsturdy.com/api/pkg/notification"
+	"getsturdy.com/api/pkg/statuses"
+	"getsturdy.com/api/pkg/unidiff"
+	"getsturdy.com/api/pkg/workspaces"
+	service_workspace "getsturdy.com/api/pkg/workspaces/service"
+	"getsturdy.com/api/vcs"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeWorkspaceService creates workspaces in memory, and lands them unless landErr is set.
+type fakeWorkspaceService struct {
+	service_workspace.Service
+
+	mu         sync.Mutex
+	workspaces map[string]*workspaces.Workspace
+	landed     []string
+	landErr    error
+}
+
+func (f *fakeWorkspaceService) Create(_ context.Context, req service_workspace.CreateWorkspaceRequest) (*workspaces.Workspace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ws := &workspaces.Workspace{ID: uuid.NewString(), UserID: req.UserID, CodebaseID: req.CodebaseID, Name: &req.Name}
+	f.workspaces[ws.ID] = ws
+	return ws, nil
+}
+
+func (f *fakeWorkspaceService) GetByID(_ context.Context, id string) (*workspaces.Workspace, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.workspaces[id], nil
+}
+
+func (f *fakeWorkspaceService) Diffs(context.Context, string, ...service_workspace.DiffsOption) ([]unidiff.FileDiff, bool, error) {
+	return []unidiff.FileDiff{{Hunks: []unidiff.Hunk{{ID: "hunk"}}}}, false, nil
+}
+
+func (f *fakeWorkspaceService) LandChange(_ context.Context, ws *workspaces.Workspace, _ []string, _ ...vcs.DiffOption) (*change.Change, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.landErr != nil {
+		return nil, f.landErr
+	}
+	f.landed = append(f.landed, ws.ID)
+	return &change.Change{ID: change.ID(uuid.NewString()), CodebaseID: ws.CodebaseID}, nil
+}
+
+func (f *fakeWorkspaceService) Archive(_ context.Context, ws *workspaces.Workspace) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	f.workspaces[ws.ID].ArchivedAt = &now
+	return nil
+}
+
+func (f *fakeWorkspaceService) active() []*workspaces.Workspace {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var res []*workspaces.Workspace
+	for _, ws := range f.workspaces {
+		if ws.ArchivedAt == nil {
+			res = append(res, ws)
+		}
+	}
+	return res
+}
+
+// fakeNotificationSender records the users that are notified.
+type fakeNotificationSender struct {
+	mu      sync.Mutex
+	userIDs []string
+}
+
+func (f *fakeNotificationSender) Codebase(context.Context, string, notification.NotificationType, string, string) error {
+	return nil
+}
+
+func (f *fakeNotificationSender) User(_ context.Context, userID, _ string, _ notification.NotificationType, _ string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.userIDs = append(f.userIDs, userID)
+	return nil
+}
+
+type testCollaborators struct {
+	service            *Service
+	revertRepo         db_autorevert.RevertRepository
+	workspaceService   *fakeWorkspaceService
+	notificationSender *fakeNotificationSender
+}
+
+func setup(t *testing.T) *testCollaborators {
+	revertRepo := db_autorevert.NewInMemoryRevertRepository()
+	workspaceService := &fakeWorkspaceService{workspaces: make(map[string]*workspaces.Workspace)}
+	notificationSender := &fakeNotificationSender{}
+
+	service := New(
+		zap.NewNop(),
+		db_autorevert.NewInMemoryConfigRepository(),
+		revertRepo,
+		nil, // codebaseUserRepo
+		nil, // userRepo
+		nil, // statusService
+		nil, // changeService
+		workspaceService,
+		nil, // aclProvider
+		notificationSender,
+		nil, // executorProvider
+	)
+
+	return &testCollaborators{
+		service:            service,
+		revertRepo:         revertRepo,
+		workspaceService:   workspaceService,
+		notificationSender: notificationSender,
+	}
+}
+
+func fixtures(t *testing.T, c *testCollaborators) (*autorevert.Config, *change.Change, *statuses.Status) {
+	codebaseID := uuid.NewString()
+	authorID := uuid.NewString()
+	title := "Fix login"
+
+	config := &autorevert.Config{
+		CodebaseID:     codebaseID,
+		Enabled:        true,
+		RequiredChecks: []string{"ci/test"},
+		AutoLand:       true,
+	}
+	if !assert.NoError(t, c.service.UpdateConfig(context.Background(), config, authorID)) {
+		t.FailNow()
+	}
+
+	ch := &change.Change{ID: change.ID(uuid.NewString()), CodebaseID: codebaseID, UserID: &authorID, Title: &title}
+	status := &statuses.Status{ID: uuid.NewString(), CodebaseID: codebaseID, Type: statuses.TypeFailing, Title: "ci/test"}
+	return config, ch, status
+}
+
+func TestRevertChange(t *testing.T) {
+	c := setup(t)
+	ctx := context.Background()
+	config, ch, status := fixtures(t, c)
+
+	assert.NoError(t, c.service.revertChange(ctx, zap.NewNop(), config, ch, status, *ch.UserID))
+
+	revert, err := c.revertRepo.GetByChangeID(ctx, string(ch.ID))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	if assert.NotNil(t, revert.WorkspaceID) {
+		assert.Equal(t, []string{*revert.WorkspaceID}, c.workspaceService.landed)
+	}
+	assert.NotNil(t, revert.LandedChangeID)
+	assert.Nil(t, revert.NextLandAttemptAt)
+	assert.Equal(t, []string{*ch.UserID}, c.notificationSender.userIDs)
+
+	// the change is only reverted once
+	assert.NoError(t, c.service.revertChange(ctx, zap.NewNop(), config, ch, status, *ch.UserID))
+	assert.Len(t, c.workspaceService.active(), 1)
+	assert.Len(t, c.workspaceService.landed, 1)
+	assert.Len(t, c.notificationSender.userIDs, 1)
+}
+
+func TestRevertChange_concurrent(t *testing.T) {
+	c := setup(t)
+	ctx := context.Background()
+	config, ch, status := fixtures(t, c)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, c.service.revertChange(ctx, zap.NewNop(), config, ch, status, *ch.UserID))
+		}()
+	}
+	wg.Wait()
+
+	revert, err := c.revertRepo.GetByChangeID(ctx, string(ch.ID))
+	if !assert.NoError(t, err) || !assert.NotNil(t, revert.WorkspaceID) {
+		t.FailNow()
+	}
+
+	active := c.workspaceService.active()
+	if assert.Len(t, active, 1, "duplicate revert workspaces are archived") {
+		assert.Equal(t, *revert.WorkspaceID, active[0].ID)
+	}
+	assert.Equal(t, []string{*revert.WorkspaceID}, c.workspaceService.landed)
+	assert.Len(t, c.notificationSender.userIDs, 1)
+}
+
+func TestRevertChange_retryLand(t *testing.T) {
+	c := setup(t)
+	ctx := context.Background()
+	config, ch, status := fixtures(t, c)
+
+	c.workspaceService.landErr = errors.New("conflicts")
+	assert.NoError(t, c.service.revertChange(ctx, zap.NewNop(), config, ch, status, *ch.UserID))
+
+	revert, err := c.revertRepo.GetByChangeID(ctx, string(ch.ID))
+	if !assert.NoError(t, err) || !assert.NotNil(t, revert.NextLandAttemptAt) {
+		t.FailNow()
+	}
+	assert.Nil(t, revert.LandedChangeID)
+	assert.Equal(t, 1, revert.LandAttempts)
+	assert.Len(t, c.notificationSender.userIDs, 1, "the revert is notified even if it's not landed")
+
+	// not due yet
+	assert.NoError(t, c.service.RetryLands(ctx))
+	assert.Empty(t, c.workspaceService.landed)
+
+	// make the retry due
+	due := time.Now().Add(-time.Second)
+	revert.NextLandAttemptAt = &due
+	assert.NoError(t, c.revertRepo.Update(ctx, revert))
+
+	c.workspaceService.landErr = nil
+	assert.NoError(t, c.service.RetryLands(ctx))
+	assert.Equal(t, []string{*revert.WorkspaceID}, c.workspaceService.landed)
+
+	revert, err = c.revertRepo.Get(ctx, revert.ID)
+	if assert.NoError(t, err) {
+		assert.NotNil(t, revert.LandedChangeID)
+		assert.Nil(t, revert.NextLandAttemptAt)
+	}
+}
+
+func TestRevertChange_giveUpLanding(t *testing.T) {
+	c := setup(t)
+	ctx := context.Background()
+	config, ch, status := fixtures(t, c)
+
+	c.workspaceService.landErr = errors.New("conflicts")
+	assert.NoError(t, c.service.revertChange(ctx, zap.NewNop(), config, ch, status, *ch.UserID))
+
+	for attempt := 2; attempt <= maxLandAttempts; attempt++ {
+		revert, err := c.revertRepo.GetByChangeID(ctx, string(ch.ID))
+		if !assert.NoError(t, err) || !assert.NotNil(t, revert.NextLandAttemptAt) {
+			t.FailNow()
+		}
+		due := time.Now().Add(-time.Second)
+		revert.NextLandAttemptAt = &due
+		assert.NoError(t, c.revertRepo.Update(ctx, revert))
+		assert.NoError(t, c.service.RetryLands(ctx))
+	}
+
+	revert, err := c.revertRepo.GetByChangeID(ctx, string(ch.ID))
+	if assert.NoError(t, err) {
+		assert.Equal(t, maxLandAttempts, revert.LandAttempts)
+		assert.Nil(t, revert.NextLandAttemptAt, "not retried anymore")
+		assert.Nil(t, revert.LandedChangeID)
+	}
+	assert.Len(t, c.workspaceService.active(), 1, "the workspace is kept to land manually")
+}
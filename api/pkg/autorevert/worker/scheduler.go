@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"getsturdy.com/api/pkg/autorevert/service"
+
+	"go.uber.org/zap"
+)
+
+var (
+	retryLandsEvery = 30 * time.Second
+)
+
+// Scheduler periodically lands the revert workspaces that failed to land, and are due to be retried.
+type Scheduler struct {
+	logger  *zap.Logger
+	service *service.Service
+}
+
+func NewScheduler(
+	logger *zap.Logger,
+	service *service.Service,
+) *Scheduler {
+	return &Scheduler{
+		logger:  logger.Named("autoRevertScheduler"),
+		service: service,
+	}
+}
+
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.logger.Info("starting")
+
+	ticker := time.NewTicker(retryLandsEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.service.RetryLands(ctx); err != nil {
+				s.logger.Error("failed to retry lands", zap.Error(err))
+			}
+		case <-ctx.Done():
+			s.logger.Info("stopping")
+			return nil
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"getsturdy.com/api/pkg/autorevert/service"
+	"getsturdy.com/api/pkg/events"
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/eventqueue"
+	"getsturdy.com/api/pkg/queue/names"
+
+	"go.uber.org/zap"
+)
+
+// Queue handles updated statuses, so that changes with failing required checks can be reverted.
+type Queue struct {
+	*eventqueue.Queue
+}
+
+func New(
+	logger *zap.Logger,
+	queue queue.Queue,
+	eventsReader events.EventReader,
+	service *service.Service,
+) *Queue {
+	return &Queue{eventqueue.New(
+		logger.Named("autoRevertQueue"),
+		queue,
+		eventsReader,
+		names.StatusAutoRevert,
+		events.StatusUpdated,
+		service.OnStatusUpdated,
+	)}
+}
@@ -32,3 +32,40 @@ type Change struct {
 	// Is null for the first change in a codebase, or if the changes parent hasn't been imported to Sturdy yet.
 	ParentChangeID *ID `db:"parent_change_id"`
 }
+
+// SortTime is the time that changes are ordered by when listed.
+func (c *Change) SortTime() time.Time {
+	switch {
+	case c.CreatedAt != nil:
+		return *c.CreatedAt
+	case c.GitCreatedAt != nil:
+		return *c.GitCreatedAt
+	default:
+		return time.Unix(0, 0)
+	}
+}
+
+// Filter narrows down a list of changes. All fields that are set must match.
+type Filter struct {
+	// AuthorID is the ID of the Sturdy user that created the change.
+	AuthorID *string
+	// AuthorEmail is the email of the git author, for changes created outside of Sturdy.
+	AuthorEmail *string
+	// PathPrefix limits the list to changes that modify a file in this path.
+	PathPrefix *string
+	Since      *time.Time
+	Until      *time.Time
+	// Query is searched for in the title and description.
+	Query *string
+}
+
+// ChangelogMaterialization keeps track of how much of the trunk history of a codebase that has been
+// imported as changes.
+type ChangelogMaterialization struct {
+	CodebaseID   string `db:"codebase_id"`
+	HeadCommitID string `db:"head_commit_id"`
+	// TailCommitID is the oldest imported commit. It's nil if history has been imported all the way
+	// to the root commit.
+	TailCommitID *string   `db:"tail_commit_id"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
@@ -0,0 +1,42 @@
+package change
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor is a position in a list of changes.
+type Cursor struct {
+	Time time.Time
+	ID   ID
+}
+
+func (c *Change) Cursor() *Cursor {
+	return &Cursor{Time: c.SortTime(), ID: c.ID}
+}
+
+func (c *Cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", c.Time.UnixNano(), c.ID)))
+}
+
+func ParseCursor(s string) (*Cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return nil, ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &Cursor{Time: time.Unix(0, nanos), ID: ID(parts[1])}, nil
+}
@@ -0,0 +1,39 @@
+package change
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	createdAt := time.Date(2022, 2, 17, 13, 29, 40, 123000, time.UTC)
+	gitCreatedAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		change   *Change
+		expected time.Time
+	}{
+		{name: "created", change: &Change{ID: "a", CreatedAt: &createdAt, GitCreatedAt: &gitCreatedAt}, expected: createdAt},
+		{name: "imported", change: &Change{ID: "b:c", GitCreatedAt: &gitCreatedAt}, expected: gitCreatedAt},
+		{name: "no time", change: &Change{ID: "d"}, expected: time.Unix(0, 0)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := ParseCursor(tc.change.Cursor().String())
+			assert.NoError(t, err)
+			assert.True(t, tc.expected.Equal(parsed.Time))
+			assert.Equal(t, tc.change.ID, parsed.ID)
+		})
+	}
+}
+
+func TestParseCursorInvalid(t *testing.T) {
+	for _, s := range []string{"", "!!!", "MTIz", "YWJjOmRlZg"} {
+		_, err := ParseCursor(s)
+		assert.ErrorIs(t, err, ErrInvalidCursor, s)
+	}
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/change"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type MaterializationRepository interface {
+	Get(ctx context.Context, codebaseID string) (*change.ChangelogMaterialization, error)
+	Upsert(context.Context, *change.ChangelogMaterialization) error
+	// MarkQueued marks the codebase as queued for materialization at now. It returns false if the codebase is
+	// already queued, and was queued after staleBefore.
+	MarkQueued(ctx context.Context, codebaseID string, now, staleBefore time.Time) (bool, error)
+	// ClearQueued marks the codebase as not queued for materialization.
+	ClearQueued(ctx context.Context, codebaseID string) error
+}
+
+func NewMaterializationRepo(db *sqlx.DB) MaterializationRepository {
+	return &materializationRepo{db: db}
+}
+
+type materializationRepo struct {
+	db *sqlx.DB
+}
+
+func (r *materializationRepo) Get(ctx context.Context, codebaseID string) (*change.ChangelogMaterialization, error) {
+	var res change.ChangelogMaterialization
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			codebase_id, head_commit_id, tail_commit_id, updated_at
+		FROM
+			changelog_materializations
+		WHERE
+			codebase_id = $1
+	`, codebaseID); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *materializationRepo) Upsert(ctx context.Context, m *change.ChangelogMaterialization) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO changelog_materializations
+			(codebase_id, head_commit_id, tail_commit_id, updated_at)
+		VALUES
+			(:codebase_id, :head_commit_id, :tail_commit_id, :updated_at)
+		ON CONFLICT (codebase_id) DO UPDATE SET
+			head_commit_id = :head_commit_id,
+			tail_commit_id = :tail_commit_id,
+			updated_at = :updated_at
+	`, m); err != nil {
+		return fmt.Errorf("failed to upsert: %w", err)
+	}
+	return nil
+}
+
+func (r *materializationRepo) MarkQueued(ctx context.Context, codebaseID string, now, staleBefore time.Time) (bool, error) {
+	var id string
+	err := r.db.GetContext(ctx, &id, `
+		INSERT INTO changelog_materialization_queue
+			(codebase_id, queued_at)
+		VALUES
+			($1, $2)
+		ON CONFLICT (codebase_id) DO UPDATE SET
+			queued_at = $2
+		WHERE
+			changelog_materialization_queue.queued_at < $3
+		RETURNING
+			codebase_id
+	`, codebaseID, now, staleBefore)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to mark as queued: %w", err)
+	}
+}
+
+func (r *materializationRepo) ClearQueued(ctx context.Context, codebaseID string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		DELETE FROM
+			changelog_materialization_queue
+		WHERE
+			codebase_id = $1
+	`, codebaseID); err != nil {
+		return fmt.Errorf("failed to clear queued: %w", err)
+	}
+	return nil
+}
@@ -4,4 +4,5 @@ import "getsturdy.com/api/pkg/di"
 
 func Module(c *di.Container) {
 	c.Register(NewRepo)
+	c.Register(NewMaterializationRepo)
 }
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/lib/pq"
 
@@ -15,10 +16,27 @@ type Repository interface {
 	Get(ctx context.Context, id change.ID) (*change.Change, error)
 	ListByIDs(ctx context.Context, ids ...change.ID) ([]*change.Change, error)
 	GetByCommitID(ctx context.Context, commitID, codebaseID string) (*change.Change, error)
+	ListByCommitIDs(ctx context.Context, codebaseID string, commitIDs []string) ([]*change.Change, error)
+	ListByCodebaseID(ctx context.Context, codebaseID string, opts ListOptions) ([]*change.Change, error)
+	// Insert creates the change. If the commit already has a change in the codebase, nothing is inserted.
 	Insert(ctx context.Context, ch change.Change) error
 	Update(ctx context.Context, ch change.Change) error
 }
 
+// ListOptions filters and paginates changes. Changes are listed newest first.
+type ListOptions struct {
+	Limit int
+	// After is the cursor of the last change on the previous page.
+	After           *change.Cursor
+	UserID          *string
+	GitCreatorEmail *string
+	// CommitIDs limits the list to changes of these commits, if not nil.
+	CommitIDs []string
+	Since     *time.Time
+	Until     *time.Time
+	Query     *string
+}
+
 func NewRepo(db *sqlx.DB) Repository {
 	return &repo{db: db}
 }
@@ -53,6 +71,7 @@ func (r *repo) Insert(ctx context.Context, ch change.Change) error {
 	_, err := r.db.NamedExecContext(ctx, `INSERT INTO changes
 		(id, codebase_id, title, updated_description, user_id, git_creator_name, git_creator_email, created_at, git_created_at, commit_id, parent_change_id)
 		VALUES(:id, :codebase_id, :title, :updated_description, :user_id, :git_creator_name, :git_creator_email, :created_at, :git_created_at, :commit_id, :parent_change_id)
+		ON CONFLICT (codebase_id, commit_id) DO NOTHING
     	`, &ch)
 	if err != nil {
 		return fmt.Errorf("failed to insert: %w", err)
@@ -93,3 +112,54 @@ func (r *repo) ListByIDs(ctx context.Context, ids ...change.ID) ([]*change.Chang
 	}
 	return res, nil
 }
+
+func (r *repo) ListByCommitIDs(ctx context.Context, codebaseID string, commitIDs []string) ([]*change.Change, error) {
+	var res []*change.Change
+	err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, codebase_id, title, updated_description, user_id, git_creator_name, git_creator_email, created_at, git_created_at, commit_id, parent_change_id
+		FROM
+			changes
+		WHERE
+			codebase_id = $1
+			AND commit_id = ANY($2)
+	`, codebaseID, pq.Array(commitIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *repo) ListByCodebaseID(ctx context.Context, codebaseID string, opts ListOptions) ([]*change.Change, error) {
+	var afterTime *time.Time
+	var afterID *change.ID
+	if opts.After != nil {
+		afterTime = &opts.After.Time
+		afterID = &opts.After.ID
+	}
+
+	// the sort expression must match the changes_codebase_id_sort_time_idx index
+	var res []*change.Change
+	err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, codebase_id, title, updated_description, user_id, git_creator_name, git_creator_email, created_at, git_created_at, commit_id, parent_change_id
+		FROM
+			changes
+		WHERE
+			codebase_id = $1
+			AND ($2::TIMESTAMPTZ IS NULL OR (COALESCE(created_at, git_created_at, '1970-01-01 00:00:00+00'::TIMESTAMPTZ), id) < ($2::TIMESTAMPTZ, $3::TEXT))
+			AND ($4::TEXT IS NULL OR user_id = $4)
+			AND ($5::TEXT IS NULL OR git_creator_email = $5)
+			AND ($6::TEXT[] IS NULL OR commit_id = ANY($6))
+			AND ($7::TIMESTAMPTZ IS NULL OR COALESCE(created_at, git_created_at, '1970-01-01 00:00:00+00'::TIMESTAMPTZ) >= $7)
+			AND ($8::TIMESTAMPTZ IS NULL OR COALESCE(created_at, git_created_at, '1970-01-01 00:00:00+00'::TIMESTAMPTZ) < $8)
+			AND ($9::TEXT IS NULL OR to_tsvector('simple', COALESCE(title, '') || ' ' || COALESCE(updated_description, '')) @@ plainto_tsquery('simple', $9))
+		ORDER BY
+			COALESCE(created_at, git_created_at, '1970-01-01 00:00:00+00'::TIMESTAMPTZ) DESC, id DESC
+		LIMIT $10
+	`, codebaseID, afterTime, afterID, opts.UserID, opts.GitCreatorEmail, pq.Array(opts.CommitIDs), opts.Since, opts.Until, opts.Query, opts.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
@@ -1,24 +1,94 @@
 package graphql
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/auth"
+	service_auth "getsturdy.com/api/pkg/auth/service"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
 	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/review/viewed"
+	db_viewed "getsturdy.com/api/pkg/review/viewed/db"
 	"getsturdy.com/api/pkg/unidiff"
+	service_workspace "getsturdy.com/api/pkg/workspaces/service"
 
 	"github.com/graph-gophers/graphql-go"
 )
 
-type FileDiffRootResolver struct{}
-
-func NewFileDiffRootResolver() resolvers.FileDiffRootResolver {
-	return &FileDiffRootResolver{}
+type FileDiffRootResolver struct {
+	viewedRepo       db_viewed.Repository
+	workspaceService service_workspace.Service
+	authService      *service_auth.Service
+}
+
+func NewFileDiffRootResolver(
+	viewedRepo db_viewed.Repository,
+	workspaceService service_workspace.Service,
+	authService *service_auth.Service,
+) resolvers.FileDiffRootResolver {
+	return &FileDiffRootResolver{
+		viewedRepo:       viewedRepo,
+		workspaceService: workspaceService,
+		authService:      authService,
+	}
 }
 
 func (r *FileDiffRootResolver) InternalFileDiff(diff *unidiff.FileDiff) resolvers.FileDiffResolver {
-	return &fileDiffResolver{diff: *diff}
+	return &fileDiffResolver{diff: *diff, root: r}
+}
+
+func (r *FileDiffRootResolver) InternalWorkspaceFileDiff(workspaceID string, diff *unidiff.FileDiff) resolvers.FileDiffResolver {
+	return &fileDiffResolver{diff: *diff, workspaceID: &workspaceID, root: r}
+}
+
+func (r *FileDiffRootResolver) SetFileDiffViewed(ctx context.Context, args resolvers.SetFileDiffViewedArgs) (resolvers.ViewedFileResolver, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if args.Input.Path == "" || args.Input.ContentHash == "" {
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "path and contentHash are required")
+	}
+
+	ws, err := r.workspaceService.GetByID(ctx, string(args.Input.WorkspaceID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	if err := r.authService.CanRead(ctx, ws); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	file := &viewed.File{
+		UserID:      userID,
+		WorkspaceID: ws.ID,
+		Path:        args.Input.Path,
+		ContentHash: args.Input.ContentHash,
+		ViewedAt:    time.Now(),
+	}
+
+	if args.Input.Viewed {
+		if err := r.viewedRepo.Create(ctx, file); err != nil {
+			return nil, gqlerrors.Error(fmt.Errorf("failed to mark file as viewed: %w", err))
+		}
+	} else {
+		if err := r.viewedRepo.Delete(ctx, userID, file.WorkspaceID, file.Path, file.ContentHash); err != nil {
+			return nil, gqlerrors.Error(fmt.Errorf("failed to unmark file as viewed: %w", err))
+		}
+	}
+
+	return &viewedFileResolver{file: file, isViewed: args.Input.Viewed}, nil
 }
 
 type fileDiffResolver struct {
 	diff unidiff.FileDiff
+	// workspaceID is the workspace that the diff is in, if any
+	workspaceID *string
+	root        *FileDiffRootResolver
 }
 
 func (f *fileDiffResolver) ID() graphql.ID {
@@ -75,6 +145,31 @@ func (f *fileDiffResolver) Hunks() ([]resolvers.HunkResolver, error) {
 	return res, nil
 }
 
+func (f *fileDiffResolver) ContentHash() string {
+	return f.diff.ContentHash()
+}
+
+func (f *fileDiffResolver) ViewedByMe(ctx context.Context) (bool, error) {
+	if f.workspaceID == nil {
+		// only diffs in workspaces are reviewed
+		return false, nil
+	}
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		// anonymous users can view public codebases, but can't mark files as viewed
+		return false, nil
+	}
+	_, err = f.root.viewedRepo.Get(ctx, userID, *f.workspaceID, f.diff.PreferredName, f.diff.ContentHash())
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	default:
+		return false, gqlerrors.Error(err)
+	}
+}
+
 type hunkResolver struct {
 	hunk unidiff.Hunk
 }
@@ -111,3 +206,24 @@ func (l *largeFileInfoResolver) ID() graphql.ID {
 func (l *largeFileInfoResolver) Size() int32 {
 	return int32(l.info.Size)
 }
+
+type viewedFileResolver struct {
+	file     *viewed.File
+	isViewed bool
+}
+
+func (r *viewedFileResolver) ID() graphql.ID {
+	return graphql.ID(r.file.WorkspaceID + ":" + r.file.Path + ":" + r.file.ContentHash)
+}
+
+func (r *viewedFileResolver) Path() string {
+	return r.file.Path
+}
+
+func (r *viewedFileResolver) ContentHash() string {
+	return r.file.ContentHash
+}
+
+func (r *viewedFileResolver) IsViewed() bool {
+	return r.isViewed
+}
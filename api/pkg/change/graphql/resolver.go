@@ -27,6 +27,7 @@ type ChangeRootResolver struct {
 	authorResolver    resolvers.AuthorRootResolver
 	statusResovler    *resolvers.StatusesRootResolver
 	downloadsResovler resolvers.ContentsDownloadUrlRootResolver
+	fileDiffResolver  resolvers.FileDiffRootResolver
 
 	executorProvider executor.Provider
 
@@ -44,6 +45,7 @@ func NewResolver(
 	authorResolver resolvers.AuthorRootResolver,
 	statusResovler *resolvers.StatusesRootResolver,
 	downloadsResovler resolvers.ContentsDownloadUrlRootResolver,
+	fileDiffResolver resolvers.FileDiffRootResolver,
 
 	executorProvider executor.Provider,
 
@@ -60,6 +62,7 @@ func NewResolver(
 		authorResolver:    authorResolver,
 		statusResovler:    statusResovler,
 		downloadsResovler: downloadsResovler,
+		fileDiffResolver:  fileDiffResolver,
 
 		executorProvider: executorProvider,
 
@@ -94,6 +97,11 @@ func (r *ChangeRootResolver) Change(ctx context.Context, args resolvers.ChangeAr
 	return &ChangeResolver{root: r, ch: ch}, nil
 }
 
+// InternalChange returns a resolver for a change that the caller has already checked access to.
+func (r *ChangeRootResolver) InternalChange(ch *change.Change) resolvers.ChangeResolver {
+	return &ChangeResolver{root: r, ch: ch}
+}
+
 type ChangeResolver struct {
 	ch   *change.Change
 	root *ChangeRootResolver
@@ -180,8 +188,8 @@ func (r *ChangeResolver) Diffs(ctx context.Context) ([]resolvers.FileDiffResolve
 	}
 
 	res := make([]resolvers.FileDiffResolver, len(diffs))
-	for k, v := range diffs {
-		res[k] = &fileDiffResolver{diff: v}
+	for k := range diffs {
+		res[k] = r.root.fileDiffResolver.InternalFileDiff(&diffs[k])
 	}
 	return res, nil
 }
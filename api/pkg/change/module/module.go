@@ -5,6 +5,7 @@ import (
 	module_downloads "getsturdy.com/api/pkg/change/downloads/module"
 	"getsturdy.com/api/pkg/change/graphql"
 	"getsturdy.com/api/pkg/change/service"
+	"getsturdy.com/api/pkg/change/worker"
 	"getsturdy.com/api/pkg/di"
 )
 
@@ -13,4 +14,5 @@ func Module(c *di.Container) {
 	c.Import(service.Module)
 	c.Import(graphql.Module)
 	c.Import(module_downloads.Module)
+	c.Import(worker.Module)
 }
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"getsturdy.com/api/pkg/change"
+	db_change "getsturdy.com/api/pkg/change/db"
+	"getsturdy.com/api/pkg/queue/names"
+	"getsturdy.com/api/vcs"
+)
+
+var (
+	// materializeBatchSize is the number of commits that are read from git at a time
+	materializeBatchSize = 500
+	// materializeBudget is the max number of commits that are walked for each materialization
+	materializeBudget = 2000
+	// maxListFallbackRounds is the max number of batches that are imported from git to fill a single page
+	maxListFallbackRounds = 2
+	// materializeQueuedTTL is how long a codebase is considered queued for materialization, if the
+	// materialization doesn't finish
+	materializeQueuedTTL = 15 * time.Minute
+)
+
+// ChangelogQueueEntry is published to the changelog queue for each codebase that has history that should be
+// imported as changes.
+type ChangelogQueueEntry struct {
+	CodebaseID string `json:"codebase_id"`
+}
+
+// List returns up to limit changes on trunk of the codebase, newest first, starting after the cursor.
+//
+// Changes are listed from the database. If commits on trunk have not been imported as changes yet, the codebase is
+// queued to have its history imported in the background (see Materialize), and the git log is walked to import
+// enough history to fill the page in the meantime. hasNextPage is true if the page is full, and there are more
+// changes in the database or history that has not been imported yet.
+func (svc *Service) List(ctx context.Context, codebaseID string, limit int, after *change.Cursor, filter change.Filter) (changes []*change.Change, hasNextPage bool, err error) {
+	complete, err := svc.isMaterialized(ctx, codebaseID)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return nil, false, nil
+	case err != nil:
+		return nil, false, fmt.Errorf("failed to get materialization status: %w", err)
+	}
+
+	if !complete {
+		if err := svc.enqueueMaterialization(ctx, codebaseID); err != nil {
+			return nil, false, err
+		}
+	}
+
+	opts := db_change.ListOptions{
+		Limit:           limit + 1,
+		After:           after,
+		UserID:          filter.AuthorID,
+		GitCreatorEmail: filter.AuthorEmail,
+		Since:           filter.Since,
+		Until:           filter.Until,
+		Query:           filter.Query,
+	}
+
+	if filter.PathPrefix != nil {
+		var commitIDs []string
+		if err := svc.executorProvider.New().GitRead(func(repo vcs.RepoGitReader) error {
+			var err error
+			commitIDs, err = repo.LogFirstParentPath(*filter.PathPrefix)
+			return err
+		}).ExecTrunk(codebaseID, "changeServiceListPath"); err != nil {
+			return nil, false, fmt.Errorf("failed to list commits by path: %w", err)
+		}
+		if commitIDs == nil {
+			commitIDs = []string{}
+		}
+		opts.CommitIDs = commitIDs
+	}
+
+	for round := 1; ; round++ {
+		changes, err = svc.changeRepo.ListByCodebaseID(ctx, codebaseID, opts)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to list changes: %w", err)
+		}
+		if len(changes) > limit {
+			return changes[:limit], true, nil
+		}
+		if complete || round > maxListFallbackRounds {
+			return changes, !complete && limit > 0 && len(changes) == limit, nil
+		}
+
+		// not enough changes in the database, import more history from the git log and try again
+		complete, err = svc.materialize(ctx, codebaseID, materializeBatchSize)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to materialize changelog: %w", err)
+		}
+	}
+}
+
+// enqueueMaterialization queues the codebase to have its history imported, unless it's already queued.
+func (svc *Service) enqueueMaterialization(ctx context.Context, codebaseID string) error {
+	now := time.Now()
+	queued, err := svc.materializationRepo.MarkQueued(ctx, codebaseID, now, now.Add(-materializeQueuedTTL))
+	if err != nil {
+		return fmt.Errorf("failed to mark materialization as queued: %w", err)
+	}
+	if !queued {
+		return nil
+	}
+	if err := svc.queue.Publish(ctx, names.ChangelogMaterialize, &ChangelogQueueEntry{CodebaseID: codebaseID}); err != nil {
+		return fmt.Errorf("failed to enqueue materialization: %w", err)
+	}
+	return nil
+}
+
+// Materialize imports all commits on the first parent history of trunk that have not been imported yet as changes.
+// It's called by the changelog queue.
+func (svc *Service) Materialize(ctx context.Context, codebaseID string) error {
+	for {
+		complete, err := svc.materialize(ctx, codebaseID, materializeBudget)
+		switch {
+		case errors.Is(err, ErrNotFound), err == nil && complete:
+			if err := svc.materializationRepo.ClearQueued(ctx, codebaseID); err != nil {
+				return fmt.Errorf("failed to clear queued materialization: %w", err)
+			}
+			return nil
+		case err != nil:
+			return fmt.Errorf("failed to materialize changelog: %w", err)
+		}
+	}
+}
+
+// isMaterialized returns true if all of the history of trunk has been imported as changes.
+func (svc *Service) isMaterialized(ctx context.Context, codebaseID string) (bool, error) {
+	headCommitID, err := svc.headCommitID(codebaseID)
+	if err != nil {
+		return false, err
+	}
+
+	status, err := svc.materializationRepo.Get(ctx, codebaseID)
+	switch {
+	case err == nil:
+		return status.HeadCommitID == headCommitID && status.TailCommitID == nil, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to get materialization status: %w", err)
+	}
+}
+
+// ListBetween returns the changes on the first parent history of toCommitID, newest first, until
+// fromCommitID (exclusive) is reached. If fromCommitID is nil, history is listed until the root commit.
+// At most limit commits are walked.
+func (svc *Service) ListBetween(ctx context.Context, codebaseID string, fromCommitID *string, toCommitID string, limit int) ([]*change.Change, error) {
+	_, res, err := svc.walkFirstParent(ctx, codebaseID, toCommitID, fromCommitID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.commitIDs) == 0 {
+		return nil, nil
+	}
+
+	changes, err := svc.changeRepo.ListByCommitIDs(ctx, codebaseID, res.commitIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+
+	byCommitID := make(map[string]*change.Change, len(changes))
+	for _, ch := range changes {
+		if ch.CommitID != nil {
+			byCommitID[*ch.CommitID] = ch
+		}
+	}
+
+	ordered := make([]*change.Change, 0, len(changes))
+	for _, commitID := range res.commitIDs {
+		if ch, ok := byCommitID[commitID]; ok {
+			ordered = append(ordered, ch)
+		}
+	}
+	return ordered, nil
+}
+
+// materialize imports commits on the first parent history of trunk as changes, so that they can be listed
+// from the database. New commits on top of trunk are imported first, and then older history. At most budget
+// commits are walked.
+//
+// Returns true if all history of trunk has been imported.
+func (svc *Service) materialize(ctx context.Context, codebaseID string, budget int) (bool, error) {
+	headCommitID, err := svc.headCommitID(codebaseID)
+	if err != nil {
+		return false, err
+	}
+
+	status, err := svc.materializationRepo.Get(ctx, codebaseID)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		status = nil
+	default:
+		return false, fmt.Errorf("failed to get materialization status: %w", err)
+	}
+
+	var walked int
+	var tail *string
+	if status != nil && status.HeadCommitID == headCommitID {
+		// no new commits
+		tail = status.TailCommitID
+	} else {
+		var stopAt *string
+		if status != nil {
+			stopAt = &status.HeadCommitID
+		}
+
+		// import new commits on top of trunk
+		var res walkResult
+		walked, res, err = svc.walkFirstParent(ctx, codebaseID, headCommitID, stopAt, budget)
+		if err != nil {
+			return false, err
+		}
+
+		switch {
+		case res.stopped:
+			tail = status.TailCommitID
+		case res.root:
+			tail = nil
+		default:
+			tail = &res.last
+		}
+	}
+
+	if status != nil && status.HeadCommitID == headCommitID && tail == nil {
+		return true, nil
+	}
+
+	// continue with older history
+	if tail != nil && budget-walked > 0 {
+		_, res, err := svc.walkFirstParent(ctx, codebaseID, *tail, nil, budget-walked)
+		if err != nil {
+			return false, err
+		}
+		if res.root {
+			tail = nil
+		} else {
+			tail = &res.last
+		}
+	}
+
+	if err := svc.materializationRepo.Upsert(ctx, &change.ChangelogMaterialization{
+		CodebaseID:   codebaseID,
+		HeadCommitID: headCommitID,
+		TailCommitID: tail,
+		UpdatedAt:    time.Now(),
+	}); err != nil {
+		return false, fmt.Errorf("failed to save materialization status: %w", err)
+	}
+
+	return tail == nil, nil
+}
+
+type walkResult struct {
+	// last is the last commit that was walked
+	last string
+	// stopped is true if the walk reached the stop commit
+	stopped bool
+	// root is true if the walk reached the root commit
+	root bool
+	// commitIDs are the walked commits, in walk order
+	commitIDs []string
+}
+
+// walkFirstParent imports the commits on the first parent history of fromCommitID, until the stopAt commit
+// (exclusive) or the root commit is reached, or budget commits have been walked.
+func (svc *Service) walkFirstParent(ctx context.Context, codebaseID, fromCommitID string, stopAt *string, budget int) (int, walkResult, error) {
+	var res walkResult
+	var walked int
+	next := fromCommitID
+	for walked < budget {
+		batchSize := materializeBatchSize
+		if budget-walked < batchSize {
+			batchSize = budget - walked
+		}
+
+		var commits []*vcs.CommitDetails
+		if err := svc.executorProvider.New().GitRead(func(repo vcs.RepoGitReader) error {
+			var err error
+			commits, err = repo.LogFirstParent(next, batchSize)
+			return err
+		}).ExecTrunk(codebaseID, "changeServiceMaterialize"); err != nil {
+			return walked, res, fmt.Errorf("failed to read log: %w", err)
+		}
+
+		for i, c := range commits {
+			if stopAt != nil && c.CommitID == *stopAt {
+				commits = commits[:i]
+				res.stopped = true
+				break
+			}
+		}
+
+		if err := svc.importCommits(ctx, codebaseID, commits); err != nil {
+			return walked, res, err
+		}
+		walked += len(commits)
+		for _, c := range commits {
+			res.commitIDs = append(res.commitIDs, c.CommitID)
+		}
+
+		if res.stopped {
+			return walked, res, nil
+		}
+		if len(commits) == 0 {
+			res.root = true
+			return walked, res, nil
+		}
+
+		last := commits[len(commits)-1]
+		res.last = last.CommitID
+		if len(last.Parents) == 0 {
+			res.root = true
+			return walked, res, nil
+		}
+		next = last.Parents[0]
+	}
+	return walked, res, nil
+}
+
+// importCommits creates changes for the commits that have not been imported yet. Commits that are imported
+// concurrently are only inserted once.
+func (svc *Service) importCommits(ctx context.Context, codebaseID string, commits []*vcs.CommitDetails) error {
+	if len(commits) == 0 {
+		return nil
+	}
+
+	commitIDs := make([]string, 0, len(commits))
+	for _, c := range commits {
+		commitIDs = append(commitIDs, c.CommitID)
+	}
+
+	existing, err := svc.changeRepo.ListByCommitIDs(ctx, codebaseID, commitIDs)
+	if err != nil {
+		return fmt.Errorf("failed to list existing changes: %w", err)
+	}
+	imported := make(map[string]bool, len(existing))
+	for _, ch := range existing {
+		if ch.CommitID != nil {
+			imported[*ch.CommitID] = true
+		}
+	}
+
+	for _, c := range commits {
+		if imported[c.CommitID] || isSturdyRootCommit(c) {
+			continue
+		}
+		if err := svc.changeRepo.Insert(ctx, changeFromCommit(codebaseID, c)); err != nil {
+			return fmt.Errorf("could not write new change to db: %w", err)
+		}
+	}
+
+	return nil
+}
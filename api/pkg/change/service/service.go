@@ -14,6 +14,7 @@ import (
 	"getsturdy.com/api/pkg/change"
 	db_change "getsturdy.com/api/pkg/change/db"
 	"getsturdy.com/api/pkg/change/message"
+	"getsturdy.com/api/pkg/queue"
 	"getsturdy.com/api/pkg/unidiff"
 	"getsturdy.com/api/pkg/workspaces"
 	"getsturdy.com/api/vcs"
@@ -23,20 +24,26 @@ import (
 )
 
 type Service struct {
-	changeRepo       db_change.Repository
-	logger           *zap.Logger
-	executorProvider executor.Provider
+	changeRepo          db_change.Repository
+	materializationRepo db_change.MaterializationRepository
+	logger              *zap.Logger
+	executorProvider    executor.Provider
+	queue               queue.Queue
 }
 
 func New(
 	changeRepo db_change.Repository,
+	materializationRepo db_change.MaterializationRepository,
 	logger *zap.Logger,
 	executorProvider executor.Provider,
+	queue queue.Queue,
 ) *Service {
 	return &Service{
-		changeRepo:       changeRepo,
-		logger:           logger.Named("changeService"),
-		executorProvider: executorProvider,
+		changeRepo:          changeRepo,
+		materializationRepo: materializationRepo,
+		logger:              logger.Named("changeService"),
+		executorProvider:    executorProvider,
+		queue:               queue,
 	}
 }
 
@@ -115,10 +122,30 @@ func (svc *Service) CreateWithChangeAsParent(ctx context.Context, ws *workspaces
 		return nil, fmt.Errorf("failed to insert change: %w", err)
 	}
 
+	stored, err := svc.changeRepo.GetByCommitID(ctx, commitID, ws.CodebaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get change: %w", err)
+	}
+	if stored.ID != changeChange.ID {
+		// the commit has already been imported from the changelog, add the details from the workspace to it
+		changeChange.ID = stored.ID
+		if err := svc.changeRepo.Update(ctx, changeChange); err != nil {
+			return nil, fmt.Errorf("failed to update change: %w", err)
+		}
+	}
+
 	return &changeChange, nil
 }
 
 func (svc *Service) head(ctx context.Context, codebaseID string) (*change.Change, error) {
+	headCommitID, err := svc.headCommitID(codebaseID)
+	if err != nil {
+		return nil, err
+	}
+	return svc.getChangeFromCommit(ctx, codebaseID, headCommitID)
+}
+
+func (svc *Service) headCommitID(codebaseID string) (string, error) {
 	// To find the root commit, peek into git
 	var headCommitID string
 
@@ -134,11 +161,11 @@ func (svc *Service) head(ctx context.Context, codebaseID string) (*change.Change
 	err := svc.executorProvider.New().GitRead(getHeadCommit).ExecTrunk(codebaseID, "changeServiceChangelog")
 	switch {
 	case errors.Is(err, vcs.ErrNotFound):
-		return nil, ErrNotFound
+		return "", ErrNotFound
 	case err != nil:
-		return nil, fmt.Errorf("could not get head commit: %w", err)
+		return "", fmt.Errorf("could not get head commit: %w", err)
 	default:
-		return svc.getChangeFromCommit(ctx, codebaseID, headCommitID)
+		return headCommitID, nil
 	}
 }
 
@@ -267,18 +294,33 @@ func (svc *Service) importCommitToChange(ctx context.Context, codebaseID, commit
 	}
 
 	// don't import Sturdy-style root commits
-	if len(details.Parents) == 0 && details.Message == "Root Commit" {
+	if isSturdyRootCommit(details) {
 		return nil, ErrNotFound
 	}
 
+	if err := svc.changeRepo.Insert(ctx, changeFromCommit(codebaseID, details)); err != nil {
+		return nil, fmt.Errorf("could not write new change to db: %w", err)
+	}
+
+	// the commit could have been imported concurrently, return the change that was stored
+	return svc.changeRepo.GetByCommitID(ctx, commitID, codebaseID)
+}
+
+func isSturdyRootCommit(details *vcs.CommitDetails) bool {
+	return len(details.Parents) == 0 && details.Message == "Root Commit"
+}
+
+func changeFromCommit(codebaseID string, details *vcs.CommitDetails) change.Change {
 	meta := change.ParseCommitMessage(details.Message)
 	title := firstLine(meta.Description)
 
 	desc := meta.Description
 	desc = strings.ReplaceAll(desc, "\n", "<br>")
 
+	commitID := details.CommitID
+
 	// CreateWithCommitAsParent change!
-	ch := change.Change{
+	return change.Change{
 		ID:                 change.ID(uuid.NewString()),
 		CodebaseID:         codebaseID,
 		Title:              &title,
@@ -291,12 +333,6 @@ func (svc *Service) importCommitToChange(ctx context.Context, codebaseID, commit
 		CommitID:           &commitID,
 		ParentChangeID:     nil, // Parent is starts out as nil. If/when the parent commit is imported, this value will be set.
 	}
-
-	if err := svc.changeRepo.Insert(ctx, ch); err != nil {
-		return nil, fmt.Errorf("could not write new change to db: %w", err)
-	}
-
-	return &ch, nil
 }
 
 func firstLine(in string) string {
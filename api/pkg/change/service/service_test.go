@@ -4,18 +4,22 @@ import (
 	"context"
 	"os/exec"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/dig"
 	"go.uber.org/zap"
 
+	"getsturdy.com/api/pkg/change"
 	"getsturdy.com/api/pkg/change/service"
 	module_configuration "getsturdy.com/api/pkg/configuration/module"
 	"getsturdy.com/api/pkg/di"
 	"getsturdy.com/api/pkg/installations"
 	"getsturdy.com/api/pkg/internal/inmemory"
 	module_logger "getsturdy.com/api/pkg/logger/module"
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/names"
 	"getsturdy.com/api/vcs"
 	"getsturdy.com/api/vcs/executor"
 	module_vcs "getsturdy.com/api/vcs/module"
@@ -54,7 +58,7 @@ func TestChangelog(t *testing.T) {
 
 	codebaseID := uuid.NewString()
 
-	svc := service.New(changeRepo, d.Logger, d.ExecutorProvider)
+	svc := service.New(changeRepo, inmemory.NewInMemoryChangelogMaterializationRepo(), d.Logger, d.ExecutorProvider, queue.NewNoop())
 
 	d.ExecutorProvider.New()
 
@@ -108,3 +112,176 @@ func TestChangelog(t *testing.T) {
 		}
 	}
 }
+
+// countingQueue counts the messages that are published.
+type countingQueue struct {
+	queue.Queue
+	published int
+}
+
+func (q *countingQueue) Publish(context.Context, names.IncompleteQueueName, interface{}) error {
+	q.published++
+	return nil
+}
+
+func TestList(t *testing.T) {
+	changeRepo := inmemory.NewInMemoryChangeRepo()
+	materializationRepo := inmemory.NewInMemoryChangelogMaterializationRepo()
+	q := &countingQueue{Queue: queue.NewNoop()}
+
+	type deps struct {
+		dig.In
+		ExecutorProvider executor.Provider
+		RepoProvider     provider.RepoProvider
+		Logger           *zap.Logger
+	}
+
+	var d deps
+	if !assert.NoError(t, di.Init(&d, module)) {
+		t.FailNow()
+	}
+
+	codebaseID := uuid.NewString()
+
+	svc := service.New(changeRepo, materializationRepo, d.Logger, d.ExecutorProvider, q)
+
+	barePath := d.RepoProvider.TrunkPath(codebaseID)
+	_, err := vcs.CreateBareRepoWithRootCommit(barePath)
+	assert.NoError(t, err)
+
+	viewID := uuid.NewString()
+	viewPath := d.RepoProvider.ViewPath(codebaseID, viewID)
+
+	_, err = vcs.CloneRepo(barePath, viewPath)
+	assert.NoError(t, err)
+
+	output, err := exec.Command("bash", "testdata/generate-linear-repo.sh", viewPath).CombinedOutput()
+	if !assert.NoError(t, err) {
+		t.Logf("output: %s", string(output))
+	}
+
+	err = d.ExecutorProvider.New().Write(func(writer vcs.RepoWriter) error {
+		err = writer.ForcePush(d.Logger, "sturdytrunk")
+		assert.NoError(t, err)
+		return nil
+	}).AllowRebasingState().ExecView(codebaseID, viewID, "test")
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	titles := func(changes []*change.Change) []string {
+		var res []string
+		for _, ch := range changes {
+			res = append(res, *ch.Title)
+		}
+		return res
+	}
+
+	t.Run("not materialized", func(t *testing.T) {
+		// the page is filled from the git log, and the codebase is queued to be materialized once
+		page, hasNextPage, err := svc.List(ctx, codebaseID, 2, nil, change.Filter{})
+		assert.NoError(t, err)
+		assert.True(t, hasNextPage)
+		assert.Equal(t, []string{"Fix api bug", "Add web client"}, titles(page))
+		assert.Equal(t, 1, q.published)
+
+		queued, err := materializationRepo.MarkQueued(ctx, codebaseID, time.Now(), time.Now().Add(-time.Minute))
+		assert.NoError(t, err)
+		assert.False(t, queued, "already queued")
+	})
+
+	if !assert.NoError(t, svc.Materialize(ctx, codebaseID)) {
+		t.FailNow()
+	}
+
+	t.Run("materialized", func(t *testing.T) {
+		queued, err := materializationRepo.MarkQueued(ctx, codebaseID, time.Now(), time.Now().Add(-time.Minute))
+		assert.NoError(t, err)
+		assert.True(t, queued, "queued is cleared by materialize")
+		assert.NoError(t, materializationRepo.ClearQueued(ctx, codebaseID))
+
+		_, _, err = svc.List(ctx, codebaseID, 2, nil, change.Filter{})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, q.published)
+	})
+
+	t.Run("pagination", func(t *testing.T) {
+		page, hasNextPage, err := svc.List(ctx, codebaseID, 2, nil, change.Filter{})
+		assert.NoError(t, err)
+		assert.True(t, hasNextPage)
+		assert.Equal(t, []string{"Fix api bug", "Add web client"}, titles(page))
+
+		page, hasNextPage, err = svc.List(ctx, codebaseID, 2, page[1].Cursor(), change.Filter{})
+		assert.NoError(t, err)
+		assert.True(t, hasNextPage)
+		assert.Equal(t, []string{"Fix readme typo", "Add api server"}, titles(page))
+
+		page, hasNextPage, err = svc.List(ctx, codebaseID, 2, page[1].Cursor(), change.Filter{})
+		assert.NoError(t, err)
+		assert.False(t, hasNextPage)
+		assert.Equal(t, []string{"Add readme"}, titles(page))
+	})
+
+	t.Run("between", func(t *testing.T) {
+		all, _, err := svc.List(ctx, codebaseID, 10, nil, change.Filter{})
+		assert.NoError(t, err)
+		if !assert.Len(t, all, 5) {
+			t.FailNow()
+		}
+
+		between, err := svc.ListBetween(ctx, codebaseID, all[3].CommitID, *all[0].CommitID, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Fix api bug", "Add web client", "Fix readme typo"}, titles(between))
+
+		between, err = svc.ListBetween(ctx, codebaseID, nil, *all[1].CommitID, 100)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Add web client", "Fix readme typo", "Add api server", "Add readme"}, titles(between))
+	})
+
+	str := func(s string) *string { return &s }
+	day := func(d int) *time.Time {
+		ts := time.Date(2022, 2, d, 0, 0, 0, 0, time.UTC)
+		return &ts
+	}
+
+	cases := []struct {
+		name     string
+		filter   change.Filter
+		expected []string
+	}{
+		{
+			name:     "author",
+			filter:   change.Filter{AuthorEmail: str("alice@getsturdy.com")},
+			expected: []string{"Fix api bug", "Fix readme typo", "Add readme"},
+		},
+		{
+			name:     "path",
+			filter:   change.Filter{PathPrefix: str("api")},
+			expected: []string{"Fix api bug", "Add api server"},
+		},
+		{
+			name:     "query",
+			filter:   change.Filter{Query: str("readme")},
+			expected: []string{"Fix readme typo", "Add readme"},
+		},
+		{
+			name:     "date range",
+			filter:   change.Filter{Since: day(3), Until: day(5)},
+			expected: []string{"Add web client", "Fix readme typo"},
+		},
+		{
+			name:     "combined",
+			filter:   change.Filter{AuthorEmail: str("bob@getsturdy.com"), PathPrefix: str("web")},
+			expected: []string{"Add web client"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, hasNextPage, err := svc.List(ctx, codebaseID, 10, nil, tc.filter)
+			assert.NoError(t, err)
+			assert.False(t, hasNextPage)
+			assert.Equal(t, tc.expected, titles(page))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+#!/bin/bash
+
+set -euo pipefail
+set -x
+
+# generate-linear-repo.sh generates a small git repository with linear history, where all commits
+# have different authors and dates.
+#
+# 5 Fix api bug (alice, 2022-02-05)
+# 4 Add web client (bob, 2022-02-04)
+# 3 Fix readme typo (alice, 2022-02-03)
+# 2 Add api server (bob, 2022-02-02)
+# 1 Add readme (alice, 2022-02-01)
+
+pushd "$1"
+
+git config user.email "support@getsturdy.com"
+git config user.name "Sturdy Testdata"
+git config commit.gpgsign false
+
+# This script is expected to be executed in a repository initialized from CreateBareRepoWithRootCommit and CloneRepo
+git checkout -b tmp
+git branch -D sturdytrunk
+git checkout --orphan sturdytrunk
+git branch -D tmp
+
+write_and_commit() {
+	file=$1
+	author=$2
+	day=$3
+	message=$4
+	mkdir -p "$(dirname "$file")"
+	echo "$message" >>"$file"
+	git add "$file"
+	ts="2022-02-0${day}T12:00:00Z"
+	GIT_AUTHOR_DATE=$ts \
+		GIT_COMMITTER_DATE=$ts \
+		GIT_AUTHOR_NAME="$author" \
+		GIT_COMMITTER_NAME="$author" \
+		GIT_AUTHOR_EMAIL="$author@getsturdy.com" \
+		GIT_COMMITTER_EMAIL="$author@getsturdy.com" \
+		git commit -m "$message"
+}
+
+write_and_commit README.md alice 1 "Add readme"
+write_and_commit api/main.go bob 2 "Add api server"
+write_and_commit README.md alice 3 "Fix readme typo"
+write_and_commit web/index.html bob 4 "Add web client"
+write_and_commit api/main.go alice 5 "Fix api bug"
+
+git log
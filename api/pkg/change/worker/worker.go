@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"getsturdy.com/api/pkg/change/service"
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/names"
+)
+
+// Queue imports the history of the codebases that are enqueued by the change service as changes.
+type Queue struct {
+	logger *zap.Logger
+	queue  queue.Queue
+	name   names.IncompleteQueueName
+
+	service *service.Service
+}
+
+func New(
+	logger *zap.Logger,
+	queue queue.Queue,
+	service *service.Service,
+) *Queue {
+	return &Queue{
+		logger:  logger.Named("changelogQueue"),
+		queue:   queue,
+		name:    names.ChangelogMaterialize,
+		service: service,
+	}
+}
+
+func (q *Queue) Start(ctx context.Context) error {
+	messages := make(chan queue.Message)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				q.logger.Error("panic in runner", zap.String("panic", fmt.Sprintf("%v", rec)))
+			}
+		}()
+
+		for msg := range messages {
+			t0 := time.Now()
+
+			m := &service.ChangelogQueueEntry{}
+			if err := msg.As(m); err != nil {
+				q.logger.Error("failed to decode message", zap.Error(err))
+				continue
+			}
+			logger := q.logger.With(zap.String("codebase_id", m.CodebaseID))
+
+			if err := q.service.Materialize(context.Background(), m.CodebaseID); err != nil {
+				logger.Error("failed to materialize changelog", zap.Error(err))
+				continue
+			}
+
+			if err := msg.Ack(); err != nil {
+				logger.Error("failed to ack message", zap.Error(err))
+				continue
+			}
+
+			logger.Info("changelog materialized", zap.Duration("duration", time.Since(t0)))
+		}
+	}()
+
+	q.logger.Info("starting queue", zap.Stringer("queue_name", q.name))
+	if err := q.queue.Subscribe(ctx, q.name, messages); err != nil {
+		return fmt.Errorf("could not subscribe to queue: %w", err)
+	}
+	q.logger.Info("queue stoped", zap.Stringer("queue_name", q.name))
+
+	return nil
+}
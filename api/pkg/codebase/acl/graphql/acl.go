@@ -87,6 +87,11 @@ func (r *ACLRootResolver) UpdateACL(ctx context.Context, args resolvers.UpdateAC
 		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "policy", "failed to decode as json")
 	}
 
+	// tests can refer to the groups of the identity provider
+	if err := r.aclProvider.AddOrganizationGroups(ctx, a.CodebaseID, &policy); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
 	if errs := policy.Errors(string(a.ID)); len(errs) > 0 {
 		msgs := make([]string, 0, len(errs)*2)
 		for k, v := range errs {
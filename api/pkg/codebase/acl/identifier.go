@@ -11,6 +11,10 @@ import (
 type Identifier struct {
 	Type    identityType `json:"type,omitempty"`
 	Pattern string       `json:"pattern,omitempty"`
+	// Exact identifiers only match the identity with the ID that is the same as the pattern, wildcards in the
+	// pattern are not expanded. It's used for identifiers that are not written by users, such as the emails of the
+	// members of groups from an identity provider.
+	Exact bool `json:"-"`
 }
 
 // MarshalJSON implements encoding/json.Marshaller to override resulting format.
@@ -56,5 +60,8 @@ func (i *Identifier) Matches(identity Identity) bool {
 	if i.Type != identity.Type {
 		return false
 	}
+	if i.Exact {
+		return identity.ID == i.Pattern
+	}
 	return match.Match(identity.ID, i.Pattern)
 }
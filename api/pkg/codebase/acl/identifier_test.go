@@ -27,3 +27,12 @@ func Test_Identifier_json(t *testing.T) {
 		})
 	}
 }
+
+func Test_Identifier_exact(t *testing.T) {
+	exact := Identifier{Type: Users, Pattern: "*@example.org", Exact: true}
+	assert.True(t, exact.Matches(Identity{Type: Users, ID: "*@example.org"}))
+	assert.False(t, exact.Matches(Identity{Type: Users, ID: "user@example.org"}))
+
+	pattern := Identifier{Type: Users, Pattern: "*@example.org"}
+	assert.True(t, pattern.Matches(Identity{Type: Users, ID: "user@example.org"}))
+}
@@ -11,6 +11,7 @@ type identityType string
 var supportedIdentityTypes = map[identityType]bool{
 	Users:     true,
 	Groups:    true,
+	Roles:     true,
 	Codebases: true,
 	ACLs:      true,
 	Files:     true,
@@ -24,6 +25,7 @@ const (
 	Users     identityType = "users"
 	Codebases identityType = "codebases"
 	Groups    identityType = "groups"
+	Roles     identityType = "roles"
 	ACLs      identityType = "acls"
 	Files     identityType = "files"
 )
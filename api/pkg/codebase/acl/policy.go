@@ -8,6 +8,7 @@ type Policy struct {
 	Rules  []*Rule  `json:"rules,omitempty"`
 	Groups []*Group `json:"groups,omitempty"`
 	Tests  []*Test  `json:"tests,omitempty"`
+	Owners []*Owner `json:"owners,omitempty"`
 }
 
 // List return a list of _typ_ resources that _principal_ can _action_ on.
@@ -102,7 +103,7 @@ func (p Policy) Errors(aclID string) map[string]error {
 	// groups can't contain other groups
 	for _, group := range p.Groups {
 		for _, member := range group.Members {
-			if member.Type == Groups {
+			if member.Type == Groups || member.Type == Roles {
 				errs[fmt.Sprintf("groups[\"%s\"]", group.ID)] = ErrSubgroupsForbidden
 			}
 
@@ -133,12 +134,101 @@ func (p Policy) Errors(aclID string) map[string]error {
 		}
 	}
 
+	for _, owner := range p.Owners {
+		for _, p := range owner.Principals {
+			if p.Type != Users && p.Type != Groups && p.Type != Roles {
+				bytes, _ := p.MarshalJSON()
+				errs[fmt.Sprintf("owners[\"%s\"].principals[%s]", owner.ID, string(bytes))] = ErrUnsupportedIdentityType
+			}
+		}
+
+		for _, r := range owner.Resources {
+			if r.Type != Files && r.Type != Groups {
+				bytes, _ := r.MarshalJSON()
+				errs[fmt.Sprintf("owners[\"%s\"].resources[%s]", owner.ID, string(bytes))] = ErrUnsupportedIdentityType
+			}
+		}
+	}
+
 	return errs
 }
 
 type Group struct {
 	ID      string        `json:"id,omitempty"`
 	Members []*Identifier `json:"members,omitempty"`
+
+	// Type is Roles for the groups of the users with a role in the codebase, and empty for all other groups.
+	Type identityType `json:"-"`
+}
+
+func (g *Group) identity() Identity {
+	if g.Type == "" {
+		return Identity{ID: g.ID, Type: Groups}
+	}
+	return Identity{ID: g.ID, Type: g.Type}
+}
+
+// AddGroups adds groups that are defined outside of the policy, such as the groups of an identity provider. Groups
+// that are defined in the policy take precedence over added groups with the same id.
+func (p *Policy) AddGroups(groups ...*Group) {
+	defined := make(map[Identity]bool, len(p.Groups))
+	for _, group := range p.Groups {
+		defined[group.identity()] = true
+	}
+	for _, group := range groups {
+		if defined[group.identity()] {
+			continue
+		}
+		defined[group.identity()] = true
+		p.Groups = append(p.Groups, group)
+	}
+}
+
+// IsGroupMember returns true if principal is a member of the group with id groupID.
+func (p Policy) IsGroupMember(groupID string, principal Identity) bool {
+	for _, group := range p.Groups {
+		if group.identity() != (Identity{ID: groupID, Type: Groups}) {
+			continue
+		}
+		for _, member := range group.Members {
+			if member.Matches(principal) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Owner is a set of principals that own a set of files. Changes to the files request reviews from the owners, and
+// need to be approved by one of them.
+type Owner struct {
+	ID         string        `json:"id,omitempty"`
+	Principals []*Identifier `json:"principals,omitempty"`
+	Resources  []*Identifier `json:"resources,omitempty"`
+}
+
+// OwnersOf returns the owners of the resource.
+func (p Policy) OwnersOf(resource Identity) []*Owner {
+	var res []*Owner
+	for _, owner := range p.Owners {
+		for _, r := range resolveGroups(owner.Resources, p.Groups) {
+			if r.Matches(resource) {
+				res = append(res, owner)
+				break
+			}
+		}
+	}
+	return res
+}
+
+// IsOwner returns true if principal is one of the owners.
+func (p Policy) IsOwner(owner *Owner, principal Identity) bool {
+	for _, o := range resolveGroups(owner.Principals, p.Groups) {
+		if o.Matches(principal) {
+			return true
+		}
+	}
+	return false
 }
 
 type Test struct {
@@ -154,9 +244,9 @@ func resolveGroups(identifiers []*Identifier, groups []*Group) []*Identifier {
 	for _, i := range identifiers {
 		resolved = append(resolved, i)
 
-		if i.Type == Groups {
+		if i.Type == Groups || i.Type == Roles {
 			for _, group := range groups {
-				if i.Matches(Identity{ID: group.ID, Type: Groups}) {
+				if i.Matches(group.identity()) {
 					resolved = append(resolved, group.Members...)
 				}
 			}
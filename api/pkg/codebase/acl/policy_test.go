@@ -203,6 +203,132 @@ func Test_Policy_group_match(t *testing.T) {
 	assert.True(t, isAllowed)
 }
 
+func Test_Policy_IsGroupMember(t *testing.T) {
+	p := Policy{
+		Groups: []*Group{
+			{
+				ID: "oncall",
+				Members: []*Identifier{
+					{Type: Users, Pattern: "*@getsturdy.com"},
+				},
+			},
+			{
+				ID: "admins",
+				Members: []*Identifier{
+					{Type: Users, Pattern: "admin@example.com"},
+				},
+			},
+		},
+	}
+
+	assert.True(t, p.IsGroupMember("oncall", Identity{Type: Users, ID: "alice@getsturdy.com"}))
+	assert.False(t, p.IsGroupMember("oncall", Identity{Type: Users, ID: "admin@example.com"}))
+	assert.False(t, p.IsGroupMember("missing", Identity{Type: Users, ID: "alice@getsturdy.com"}))
+}
+
+func Test_Policy_AddGroups(t *testing.T) {
+	p := Policy{
+		Groups: []*Group{
+			{
+				ID:      "admins",
+				Members: []*Identifier{{Type: Users, Pattern: "admin@example.com"}},
+			},
+		},
+		Rules: []*Rule{
+			{
+				Action:     ActionWrite,
+				Principals: []*Identifier{{Type: Groups, Pattern: "engineering"}},
+				Resources:  []*Identifier{{Type: Files, Pattern: "*"}},
+			},
+		},
+	}
+
+	p.AddGroups(
+		&Group{ID: "admins", Members: []*Identifier{{Type: Users, Pattern: "mallory@example.com"}}},
+		&Group{ID: "engineering", Members: []*Identifier{{Type: Users, Pattern: "alice@example.com"}}},
+	)
+
+	assert.True(t, p.IsGroupMember("admins", Identity{Type: Users, ID: "admin@example.com"}))
+	assert.False(t, p.IsGroupMember("admins", Identity{Type: Users, ID: "mallory@example.com"}))
+	assert.True(t, p.Assert(Identity{Type: Users, ID: "alice@example.com"}, ActionWrite, Identity{Type: Files, ID: "README.md"}))
+	assert.False(t, p.Assert(Identity{Type: Users, ID: "bob@example.com"}, ActionWrite, Identity{Type: Files, ID: "README.md"}))
+}
+
+func Test_Policy_roles(t *testing.T) {
+	p := Policy{
+		Groups: []*Group{
+			{
+				ID:      "admin",
+				Members: []*Identifier{{Type: Users, Pattern: "mallory@example.com"}},
+			},
+		},
+		Rules: []*Rule{
+			{
+				Action:     ActionWrite,
+				Principals: []*Identifier{{Type: Roles, Pattern: "admin"}},
+				Resources:  []*Identifier{{Type: Files, Pattern: "*"}},
+			},
+		},
+	}
+
+	p.AddGroups(
+		&Group{ID: "admin", Type: Roles, Members: []*Identifier{{Type: Users, Pattern: "alice@example.com"}}},
+		&Group{ID: "member", Type: Roles, Members: []*Identifier{{Type: Users, Pattern: "bob@example.com"}}},
+	)
+
+	assert.True(t, p.Assert(Identity{Type: Users, ID: "alice@example.com"}, ActionWrite, Identity{Type: Files, ID: "README.md"}))
+	assert.False(t, p.Assert(Identity{Type: Users, ID: "bob@example.com"}, ActionWrite, Identity{Type: Files, ID: "README.md"}))
+	// groups::admin is not the same as roles::admin
+	assert.False(t, p.Assert(Identity{Type: Users, ID: "mallory@example.com"}, ActionWrite, Identity{Type: Files, ID: "README.md"}))
+	assert.False(t, p.IsGroupMember("admin", Identity{Type: Users, ID: "alice@example.com"}))
+}
+
+func Test_Policy_Owners(t *testing.T) {
+	p := Policy{
+		Groups: []*Group{
+			{
+				ID: "frontend",
+				Members: []*Identifier{
+					{Type: Users, Pattern: "*@frontend.example.com"},
+				},
+			},
+			{
+				ID: "web",
+				Members: []*Identifier{
+					{Type: Files, Pattern: "web/*"},
+				},
+			},
+		},
+		Owners: []*Owner{
+			{
+				ID:         "web",
+				Principals: []*Identifier{{Type: Groups, Pattern: "frontend"}},
+				Resources:  []*Identifier{{Type: Groups, Pattern: "web"}},
+			},
+			{
+				ID:         "api",
+				Principals: []*Identifier{{Type: Users, Pattern: "alice@example.com"}},
+				Resources:  []*Identifier{{Type: Files, Pattern: "api/*"}, {Type: Files, Pattern: "go.mod"}},
+			},
+		},
+	}
+
+	owners := p.OwnersOf(Identity{Type: Files, ID: "web/src/index.ts"})
+	if assert.Len(t, owners, 1) {
+		assert.Equal(t, "web", owners[0].ID)
+		assert.True(t, p.IsOwner(owners[0], Identity{Type: Users, ID: "bob@frontend.example.com"}))
+		assert.False(t, p.IsOwner(owners[0], Identity{Type: Users, ID: "alice@example.com"}))
+	}
+
+	owners = p.OwnersOf(Identity{Type: Files, ID: "go.mod"})
+	if assert.Len(t, owners, 1) {
+		assert.Equal(t, "api", owners[0].ID)
+		assert.True(t, p.IsOwner(owners[0], Identity{Type: Users, ID: "alice@example.com"}))
+	}
+
+	assert.Empty(t, p.OwnersOf(Identity{Type: Files, ID: "README.md"}))
+}
+
 func Test_Policy_Errors_all_good(t *testing.T) {
 	actionWrite := ActionWrite
 	p := Policy{
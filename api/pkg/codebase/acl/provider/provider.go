@@ -10,6 +10,10 @@ import (
 	"getsturdy.com/api/pkg/codebase/acl"
 	db_acl "getsturdy.com/api/pkg/codebase/acl/db"
 	db_codebase "getsturdy.com/api/pkg/codebase/db"
+	"getsturdy.com/api/pkg/organization"
+	db_organization "getsturdy.com/api/pkg/organization/db"
+	"getsturdy.com/api/pkg/scim"
+	db_scim "getsturdy.com/api/pkg/scim/db"
 	db_user "getsturdy.com/api/pkg/users/db"
 
 	"github.com/google/uuid"
@@ -17,20 +21,29 @@ import (
 )
 
 type Provider struct {
-	aclDB          db_acl.ACLRepository
-	usersDB        db_user.Repository
-	codebaseUserDB db_codebase.CodebaseUserRepository
+	aclDB                db_acl.ACLRepository
+	usersDB              db_user.Repository
+	codebaseUserDB       db_codebase.CodebaseUserRepository
+	codebaseDB           db_codebase.CodebaseRepository
+	scimGroupsDB         db_scim.GroupRepository
+	organizationMemberDB db_organization.MemberRepository
 }
 
 func New(
 	aclRepo db_acl.ACLRepository,
 	codebaseUserDB db_codebase.CodebaseUserRepository,
 	usersDB db_user.Repository,
+	codebaseDB db_codebase.CodebaseRepository,
+	scimGroupsDB db_scim.GroupRepository,
+	organizationMemberDB db_organization.MemberRepository,
 ) *Provider {
 	return &Provider{
-		aclDB:          aclRepo,
-		codebaseUserDB: codebaseUserDB,
-		usersDB:        usersDB,
+		aclDB:                aclRepo,
+		codebaseUserDB:       codebaseUserDB,
+		usersDB:              usersDB,
+		codebaseDB:           codebaseDB,
+		scimGroupsDB:         scimGroupsDB,
+		organizationMemberDB: organizationMemberDB,
 	}
 }
 
@@ -51,9 +64,92 @@ func (p *Provider) GetByCodebaseID(ctx context.Context, codebaseID string) (acl.
 		return acl.ACL{}, fmt.Errorf("failed to unmarshal policy: %w", err)
 	}
 
+	if err := p.AddOrganizationGroups(ctx, codebaseID, &entity.Policy); err != nil {
+		return acl.ACL{}, err
+	}
+
 	return entity, nil
 }
 
+// AddOrganizationGroups adds the groups that the identity provider of the codebase's organization has provisioned,
+// and the groups of users with each role in the codebase, to the policy. Rules can refer to them as "groups::<name>"
+// and "roles::<role>".
+func (p *Provider) AddOrganizationGroups(ctx context.Context, codebaseID string, policy *acl.Policy) error {
+	cb, err := p.codebaseDB.GetAllowArchived(codebaseID)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return nil
+	default:
+		return fmt.Errorf("failed to get codebase: %w", err)
+	}
+
+	// users have a role in the codebase if they are a member of it, or of its organization
+	roleMembers := make(map[organization.Role][]string, len(organization.Roles))
+	codebaseUsers, err := p.codebaseUserDB.GetByCodebase(codebaseID)
+	if err != nil {
+		return fmt.Errorf("failed to query codebase users: %w", err)
+	}
+	for _, u := range codebaseUsers {
+		roleMembers[u.Role] = append(roleMembers[u.Role], u.UserID)
+	}
+
+	var groups []*scim.Group
+	if cb.OrganizationID != nil {
+		members, err := p.organizationMemberDB.ListByOrganizationID(ctx, *cb.OrganizationID)
+		if err != nil {
+			return fmt.Errorf("failed to list organization members: %w", err)
+		}
+		for _, m := range members {
+			roleMembers[m.Role] = append(roleMembers[m.Role], m.UserID)
+		}
+
+		groups, err = p.scimGroupsDB.ListByOrganizationID(ctx, *cb.OrganizationID)
+		if err != nil {
+			return fmt.Errorf("failed to list organization groups: %w", err)
+		}
+	}
+
+	var userIDs []string
+	for _, ids := range roleMembers {
+		userIDs = append(userIDs, ids...)
+	}
+	for _, group := range groups {
+		userIDs = append(userIDs, group.MemberIDs...)
+	}
+	emails := make(map[string]string, len(userIDs))
+	if len(userIDs) > 0 {
+		users, err := p.usersDB.GetByIDs(ctx, userIDs...)
+		if err != nil {
+			return fmt.Errorf("failed to query users: %w", err)
+		}
+		for _, user := range users {
+			emails[user.ID] = user.Email
+		}
+	}
+
+	members := func(userIDs []string) []*acl.Identifier {
+		res := []*acl.Identifier{}
+		for _, userID := range userIDs {
+			if email, ok := emails[userID]; ok {
+				res = append(res, &acl.Identifier{Type: acl.Users, Pattern: email, Exact: true})
+			}
+		}
+		return res
+	}
+
+	aclGroups := make([]*acl.Group, 0, len(groups)+len(organization.Roles))
+	for _, group := range groups {
+		aclGroups = append(aclGroups, &acl.Group{ID: group.DisplayName, Members: members(group.MemberIDs)})
+	}
+	for _, role := range organization.Roles {
+		aclGroups = append(aclGroups, &acl.Group{ID: string(role), Type: acl.Roles, Members: members(roleMembers[role])})
+	}
+	policy.AddGroups(aclGroups...)
+
+	return nil
+}
+
 func (p *Provider) createDefaultPolicy(ctx context.Context, codebaseID string) (acl.ACL, error) {
 	emails, err := p.getUserEmailsForCodebase(ctx, codebaseID)
 	if err != nil {
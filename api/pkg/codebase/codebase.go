@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"getsturdy.com/api/pkg/author"
+	"getsturdy.com/api/pkg/organization"
 
 	"github.com/gosimple/slug"
 )
@@ -23,13 +24,17 @@ type Codebase struct {
 
 	IsReady  bool `json:"is_ready" db:"is_ready"`
 	IsPublic bool `json:"is_public" db:"is_public"`
+
+	// DismissStaleApprovals dismisses approvals automatically when the reviewed files change
+	DismissStaleApprovals bool `json:"dismiss_stale_approvals" db:"dismiss_stale_approvals"`
 }
 
 type CodebaseUser struct {
-	ID         string     `db:"id"`
-	UserID     string     `db:"user_id"`
-	CodebaseID string     `db:"codebase_id"`
-	CreatedAt  *time.Time `db:"created_at" json:"created_at"`
+	ID         string            `db:"id"`
+	UserID     string            `db:"user_id"`
+	CodebaseID string            `db:"codebase_id"`
+	CreatedAt  *time.Time        `db:"created_at" json:"created_at"`
+	Role       organization.Role `db:"role"`
 }
 
 type CodebaseWithMetadata struct {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"getsturdy.com/api/pkg/codebase"
+	"getsturdy.com/api/pkg/organization"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -14,6 +15,7 @@ type CodebaseUserRepository interface {
 	GetByUser(userID string) ([]*codebase.CodebaseUser, error)
 	GetByCodebase(codebaseID string) ([]*codebase.CodebaseUser, error)
 	GetByUserAndCodebase(userID, codebaseID string) (*codebase.CodebaseUser, error)
+	Update(ctx context.Context, entity *codebase.CodebaseUser) error
 	DeleteByID(ctx context.Context, id string) error
 }
 
@@ -26,8 +28,11 @@ func NewCodebaseUserRepo(db *sqlx.DB) CodebaseUserRepository {
 }
 
 func (r *codebaseUserRepo) Create(entity codebase.CodebaseUser) error {
-	_, err := r.db.NamedExec(`INSERT INTO codebase_users (id, user_id, codebase_id, created_at)
-		VALUES (:id, :user_id, :codebase_id, :created_at)`, &entity)
+	if entity.Role == "" {
+		entity.Role = organization.RoleMember
+	}
+	_, err := r.db.NamedExec(`INSERT INTO codebase_users (id, user_id, codebase_id, created_at, role)
+		VALUES (:id, :user_id, :codebase_id, :created_at, :role)`, &entity)
 	if err != nil {
 		return fmt.Errorf("failed to perform insert: %w", err)
 	}
@@ -61,6 +66,14 @@ func (r *codebaseUserRepo) GetByUserAndCodebase(userID, codebaseID string) (*cod
 	return &cb, nil
 }
 
+func (r *codebaseUserRepo) Update(ctx context.Context, entity *codebase.CodebaseUser) error {
+	_, err := r.db.NamedExecContext(ctx, `UPDATE codebase_users SET role = :role WHERE id = :id`, entity)
+	if err != nil {
+		return fmt.Errorf("failed to update codebase_users: %w", err)
+	}
+	return nil
+}
+
 func (r *codebaseUserRepo) DeleteByID(ctx context.Context, id string) error {
 	_, err := r.db.ExecContext(ctx, `DELETE FROM codebase_users WHERE id = $1`, id)
 	if err != nil {
@@ -20,8 +20,8 @@ func NewRepo(db *sqlx.DB) CodebaseRepository {
 }
 
 func (r *Repo) Create(entity codebase.Codebase) error {
-	_, err := r.db.NamedExec(`INSERT INTO codebases (id, short_id, name, description, emoji, created_at, invite_code, is_ready, is_public, organization_id)
-		VALUES (:id, :short_id, :name, :description, :emoji, :created_at, :invite_code, :is_ready, :is_public, :organization_id)`, &entity)
+	_, err := r.db.NamedExec(`INSERT INTO codebases (id, short_id, name, description, emoji, created_at, invite_code, is_ready, is_public, organization_id, dismiss_stale_approvals)
+		VALUES (:id, :short_id, :name, :description, :emoji, :created_at, :invite_code, :is_ready, :is_public, :organization_id, :dismiss_stale_approvals)`, &entity)
 	if err != nil {
 		return fmt.Errorf("failed to create codebase: %w", err)
 	}
@@ -30,7 +30,7 @@ func (r *Repo) Create(entity codebase.Codebase) error {
 
 func (r *Repo) Get(id string) (*codebase.Codebase, error) {
 	entity := &codebase.Codebase{}
-	err := r.db.Get(entity, `SELECT id, short_id, name, description, emoji, created_at, invite_code, is_ready, archived_at, is_public, organization_id
+	err := r.db.Get(entity, `SELECT id, short_id, name, description, emoji, created_at, invite_code, is_ready, archived_at, is_public, organization_id, dismiss_stale_approvals
 		FROM codebases
 		WHERE id = $1
 		AND archived_at IS NULL`, id)
@@ -42,7 +42,7 @@ func (r *Repo) Get(id string) (*codebase.Codebase, error) {
 
 func (r *Repo) GetAllowArchived(id string) (*codebase.Codebase, error) {
 	entity := &codebase.Codebase{}
-	err := r.db.Get(entity, `SELECT id, short_id, name, description, emoji, created_at, invite_code, is_ready, archived_at, is_public, organization_id
+	err := r.db.Get(entity, `SELECT id, short_id, name, description, emoji, created_at, invite_code, is_ready, archived_at, is_public, organization_id, dismiss_stale_approvals
 		FROM codebases
 		WHERE id = $1`, id)
 	if err != nil {
@@ -53,7 +53,7 @@ func (r *Repo) GetAllowArchived(id string) (*codebase.Codebase, error) {
 
 func (r *Repo) GetByInviteCode(inviteCode string) (*codebase.Codebase, error) {
 	entity := &codebase.Codebase{}
-	err := r.db.Get(entity, `SELECT id, short_id, name, description, emoji, created_at, invite_code, is_ready, archived_at, is_public, organization_id
+	err := r.db.Get(entity, `SELECT id, short_id, name, description, emoji, created_at, invite_code, is_ready, archived_at, is_public, organization_id, dismiss_stale_approvals
 		FROM codebases
 		WHERE invite_code = $1
 	    AND archived_at IS NULL`, inviteCode)
@@ -65,7 +65,7 @@ func (r *Repo) GetByInviteCode(inviteCode string) (*codebase.Codebase, error) {
 
 func (r *Repo) GetByShortID(shortID string) (*codebase.Codebase, error) {
 	entity := &codebase.Codebase{}
-	err := r.db.Get(entity, `SELECT id, short_id, name, description, emoji, created_at, invite_code, is_ready, archived_at, is_public, organization_id
+	err := r.db.Get(entity, `SELECT id, short_id, name, description, emoji, created_at, invite_code, is_ready, archived_at, is_public, organization_id, dismiss_stale_approvals
 		FROM codebases
 		WHERE short_id = $1
 	    AND archived_at IS NULL`, shortID)
@@ -85,7 +85,8 @@ func (r *Repo) Update(entity *codebase.Codebase) error {
 		    short_id = :short_id,
 		    is_ready = :is_ready,
 		    is_public = :is_public,
-		    organization_id = :organization_id
+		    organization_id = :organization_id,
+		    dismiss_stale_approvals = :dismiss_stale_approvals
 		WHERE id = :id`, &entity)
 	if err != nil {
 		return fmt.Errorf("failed to perform update: %w", err)
@@ -96,7 +97,7 @@ func (r *Repo) Update(entity *codebase.Codebase) error {
 func (r *Repo) ListByOrganization(ctx context.Context, organizationID string) ([]*codebase.Codebase, error) {
 	var res []*codebase.Codebase
 	err := r.db.SelectContext(ctx, &res, `
-		SELECT id, short_id, name, description, emoji, created_at, invite_code, is_ready, archived_at, is_public, organization_id
+		SELECT id, short_id, name, description, emoji, created_at, invite_code, is_ready, archived_at, is_public, organization_id, dismiss_stale_approvals
 		FROM codebases
 		WHERE organization_id = $1
 	    AND archived_at IS NULL`, organizationID)
@@ -106,6 +107,18 @@ func (r *Repo) ListByOrganization(ctx context.Context, organizationID string) ([
 	return res, nil
 }
 
+func (r *Repo) List(ctx context.Context) ([]*codebase.Codebase, error) {
+	var res []*codebase.Codebase
+	err := r.db.SelectContext(ctx, &res, `
+		SELECT id, short_id, name, description, emoji, created_at, invite_code, is_ready, archived_at, is_public, organization_id, dismiss_stale_approvals
+		FROM codebases
+		WHERE archived_at IS NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list codebases: %w", err)
+	}
+	return res, nil
+}
+
 func (r *Repo) Count(ctx context.Context) (uint64, error) {
 	var res struct {
 		Count uint64
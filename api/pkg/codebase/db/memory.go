@@ -41,7 +41,7 @@ func (m *memory) Get(id string) (*codebase.Codebase, error) {
 }
 
 func (m *memory) GetAllowArchived(id string) (*codebase.Codebase, error) {
-	found, ok := m.byInviteCode[id]
+	found, ok := m.byID[id]
 	if !ok {
 		return nil, sql.ErrNoRows
 	}
@@ -78,6 +78,16 @@ func (r *memory) ListByOrganization(_ context.Context, id string) ([]*codebase.C
 	return res, nil
 }
 
+func (r *memory) List(context.Context) ([]*codebase.Codebase, error) {
+	var res []*codebase.Codebase
+	for _, cb := range r.byID {
+		if cb.ArchivedAt == nil {
+			res = append(res, cb)
+		}
+	}
+	return res, nil
+}
+
 func (r *memory) Count(context.Context) (uint64, error) {
 	return uint64(len(r.byID)), nil
 }
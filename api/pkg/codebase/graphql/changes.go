@@ -0,0 +1,115 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/change"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+)
+
+const (
+	defaultChangesPageSize = 50
+	maxChangesPageSize     = 100
+)
+
+func (r *CodebaseResolver) ChangesConnection(ctx context.Context, args resolvers.CodebaseChangesConnectionArgs) (resolvers.ChangeConnectionResolver, error) {
+	limit := defaultChangesPageSize
+	if args.First != nil {
+		if *args.First < 0 || *args.First > maxChangesPageSize {
+			return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", fmt.Sprintf("first must be between 0 and %d", maxChangesPageSize))
+		}
+		limit = int(*args.First)
+	}
+
+	var after *change.Cursor
+	if args.After != nil {
+		cursor, err := change.ParseCursor(*args.After)
+		if errors.Is(err, change.ErrInvalidCursor) {
+			return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "invalid cursor")
+		} else if err != nil {
+			return nil, gqlerrors.Error(err)
+		}
+		after = cursor
+	}
+
+	var filter change.Filter
+	if args.Filter != nil {
+		if args.Filter.AuthorID != nil {
+			authorID := string(*args.Filter.AuthorID)
+			filter.AuthorID = &authorID
+		}
+		filter.AuthorEmail = args.Filter.AuthorEmail
+		if args.Filter.PathPrefix != nil && *args.Filter.PathPrefix != "" {
+			filter.PathPrefix = args.Filter.PathPrefix
+		}
+		if args.Filter.Since != nil {
+			since := time.Unix(int64(*args.Filter.Since), 0)
+			filter.Since = &since
+		}
+		if args.Filter.Until != nil {
+			until := time.Unix(int64(*args.Filter.Until), 0)
+			filter.Until = &until
+		}
+		if args.Filter.Query != nil && *args.Filter.Query != "" {
+			filter.Query = args.Filter.Query
+		}
+	}
+
+	changes, hasNextPage, err := r.root.changeService.List(ctx, r.c.ID, limit, after, filter)
+	if err != nil {
+		return nil, gqlerrors.Error(fmt.Errorf("failed to list changes: %w", err))
+	}
+
+	edges := make([]resolvers.ChangeEdgeResolver, 0, len(changes))
+	for _, ch := range changes {
+		edges = append(edges, &changeEdgeResolver{
+			cursor: ch.Cursor().String(),
+			node:   r.root.changeRootResolver.InternalChange(ch),
+		})
+	}
+
+	// there is no cursor to continue from without edges
+	return &changeConnectionResolver{edges: edges, hasNextPage: hasNextPage && len(edges) > 0}, nil
+}
+
+type changeConnectionResolver struct {
+	edges       []resolvers.ChangeEdgeResolver
+	hasNextPage bool
+}
+
+func (r *changeConnectionResolver) Edges() []resolvers.ChangeEdgeResolver {
+	return r.edges
+}
+
+func (r *changeConnectionResolver) PageInfo() resolvers.PageInfoResolver {
+	return r
+}
+
+func (r *changeConnectionResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+func (r *changeConnectionResolver) EndCursor() *string {
+	if len(r.edges) == 0 {
+		return nil
+	}
+	cursor := r.edges[len(r.edges)-1].Cursor()
+	return &cursor
+}
+
+type changeEdgeResolver struct {
+	cursor string
+	node   resolvers.ChangeResolver
+}
+
+func (r *changeEdgeResolver) Cursor() string {
+	return r.cursor
+}
+
+func (r *changeEdgeResolver) Node() resolvers.ChangeResolver {
+	return r.node
+}
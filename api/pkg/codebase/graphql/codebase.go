@@ -23,6 +23,7 @@ import (
 	"getsturdy.com/api/pkg/events"
 	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
 	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/organization"
 	service_organization "getsturdy.com/api/pkg/organization/service"
 	db_user "getsturdy.com/api/pkg/users/db"
 	"getsturdy.com/api/pkg/view"
@@ -52,6 +53,12 @@ type CodebaseRootResolver struct {
 	instantIntegrationRootResolver    resolvers.IntegrationRootResolver
 	codebaseGitHubIntegrationResolver resolvers.CodebaseGitHubIntegrationRootResolver
 	organizationRootResolver          *resolvers.OrganizationRootResolver
+	snapshotRetentionPolicyResolver   resolvers.SnapshotRetentionPolicyRootResolver
+	releaseRootResolver               resolvers.ReleaseRootResolver
+	autoRevertRootResolver            resolvers.AutoRevertRootResolver
+	webhooksRootResolver              resolvers.WebhooksRootResolver
+	chatWebhooksRootResolver          resolvers.ChatWebhooksRootResolver
+	serviceTokensRootResolver         resolvers.ServiceTokensRootResolver
 
 	logger           *zap.Logger
 	viewEvents       events.EventReader
@@ -81,6 +88,12 @@ func NewCodebaseRootResolver(
 	instantIntegrationRootResolver resolvers.IntegrationRootResolver,
 	codebaseGitHubIntegrationResolver resolvers.CodebaseGitHubIntegrationRootResolver,
 	organizationRootResolver *resolvers.OrganizationRootResolver,
+	snapshotRetentionPolicyResolver resolvers.SnapshotRetentionPolicyRootResolver,
+	releaseRootResolver resolvers.ReleaseRootResolver,
+	autoRevertRootResolver resolvers.AutoRevertRootResolver,
+	webhooksRootResolver resolvers.WebhooksRootResolver,
+	chatWebhooksRootResolver resolvers.ChatWebhooksRootResolver,
+	serviceTokensRootResolver resolvers.ServiceTokensRootResolver,
 
 	logger *zap.Logger,
 	viewEvents events.EventReader,
@@ -109,6 +122,12 @@ func NewCodebaseRootResolver(
 		instantIntegrationRootResolver:    instantIntegrationRootResolver,
 		codebaseGitHubIntegrationResolver: codebaseGitHubIntegrationResolver,
 		organizationRootResolver:          organizationRootResolver,
+		snapshotRetentionPolicyResolver:   snapshotRetentionPolicyResolver,
+		releaseRootResolver:               releaseRootResolver,
+		autoRevertRootResolver:            autoRevertRootResolver,
+		webhooksRootResolver:              webhooksRootResolver,
+		chatWebhooksRootResolver:          chatWebhooksRootResolver,
+		serviceTokensRootResolver:         serviceTokensRootResolver,
 
 		logger:           logger.Named("CodebaseRootResolver"),
 		viewEvents:       viewEvents,
@@ -186,12 +205,12 @@ func (r *CodebaseRootResolver) CreateCodebase(ctx context.Context, args resolver
 		o := string(*args.Input.OrganizationID)
 		orgID = &o
 
-		// Verify access to organization
+		// Only owners and admins of the organization can create codebases in it
 		org, err := r.organizationService.GetByID(ctx, o)
 		if err != nil {
 			return nil, gqlerrors.Error(err)
 		}
-		if err := r.authService.CanWrite(ctx, org); err != nil {
+		if err := r.authService.CanAdmin(ctx, org); err != nil {
 			return nil, gqlerrors.Error(err)
 		}
 	}
@@ -261,6 +280,14 @@ func (r *CodebaseRootResolver) UpdateCodebase(ctx context.Context, args resolver
 		return nil, gqlerrors.Error(err)
 	}
 
+	// the visibility, invite code and review settings can only be changed by owners and admins
+	if args.Input.IsPublic != nil || args.Input.DismissStaleApprovals != nil ||
+		args.Input.DisableInviteCode != nil || args.Input.GenerateInviteCode != nil {
+		if err := r.authService.CanAdmin(ctx, cb); err != nil {
+			return nil, gqlerrors.Error(err)
+		}
+	}
+
 	if args.Input.Name != nil && len(*args.Input.Name) > 0 {
 		cb.Name = *args.Input.Name
 	}
@@ -286,6 +313,9 @@ func (r *CodebaseRootResolver) UpdateCodebase(ctx context.Context, args resolver
 		// track, will be used to review malicious activity and the codebases that are made public
 		r.analyticsService.Capture(ctx, "ser codebase is_public", analytics.CodebaseID(cb.ID))
 	}
+	if args.Input.DismissStaleApprovals != nil {
+		cb.DismissStaleApprovals = *args.Input.DismissStaleApprovals
+	}
 
 	if err := r.codebaseService.Update(ctx, cb); err != nil {
 		return nil, gqlerrors.Error(fmt.Errorf("failed to update codebase: %w", err))
@@ -300,11 +330,16 @@ func (r *CodebaseRootResolver) AddUserToCodebase(ctx context.Context, args resol
 	}
 
 	// Auth
-	if err := r.authService.CanWrite(ctx, cb); err != nil {
+	if err := r.authService.CanAdmin(ctx, cb); err != nil {
 		return nil, gqlerrors.Error(err)
 	}
 
-	if _, err := r.codebaseService.AddUserByEmail(ctx, string(args.Input.CodebaseID), args.Input.Email); err != nil {
+	role := organization.RoleMember
+	if args.Input.Role != nil {
+		role = parseRole(*args.Input.Role)
+	}
+
+	if _, err := r.codebaseService.AddUserByEmail(ctx, string(args.Input.CodebaseID), args.Input.Email, role); err != nil {
 		return nil, gqlerrors.Error(err)
 	}
 
@@ -318,7 +353,7 @@ func (r *CodebaseRootResolver) RemoveUserFromCodebase(ctx context.Context, args
 	}
 
 	// Auth
-	if err := r.authService.CanWrite(ctx, cb); err != nil {
+	if err := r.authService.CanAdmin(ctx, cb); err != nil {
 		return nil, gqlerrors.Error(err)
 	}
 
@@ -329,6 +364,29 @@ func (r *CodebaseRootResolver) RemoveUserFromCodebase(ctx context.Context, args
 	return &CodebaseResolver{c: cb, root: r}, nil
 }
 
+func (r *CodebaseRootResolver) UpdateCodebaseMemberRole(ctx context.Context, args resolvers.UpdateCodebaseMemberRoleArgs) (resolvers.CodebaseResolver, error) {
+	cb, err := r.codebaseService.GetByID(ctx, string(args.Input.CodebaseID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	// Auth
+	if err := r.authService.CanAdmin(ctx, cb); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if _, err := r.codebaseService.SetMemberRole(ctx, cb.ID, string(args.Input.UserID), parseRole(args.Input.Role)); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	return &CodebaseResolver{c: cb, root: r}, nil
+}
+
+// parseRole converts a MemberRole enum value to a role.
+func parseRole(role string) organization.Role {
+	return organization.Role(strings.ToLower(role))
+}
+
 type CodebaseResolver struct {
 	c    *codebase.Codebase
 	root *CodebaseRootResolver
@@ -633,6 +691,10 @@ func (r *CodebaseResolver) IsPublic() bool {
 	return r.c.IsPublic
 }
 
+func (r *CodebaseResolver) DismissStaleApprovals() bool {
+	return r.c.DismissStaleApprovals
+}
+
 func (r *CodebaseResolver) Organization(ctx context.Context) (resolvers.OrganizationResolver, error) {
 	if r.c.OrganizationID == nil {
 		return nil, nil
@@ -652,6 +714,10 @@ func (r *CodebaseResolver) Writeable(ctx context.Context) bool {
 	return false
 }
 
+func (r *CodebaseResolver) SnapshotRetentionPolicy(ctx context.Context) (resolvers.SnapshotRetentionPolicyResolver, error) {
+	return r.root.snapshotRetentionPolicyResolver.InternalByCodebaseID(ctx, r.c.ID)
+}
+
 func (r *CodebaseRootResolver) resolveCodebase(ctx context.Context, id graphql.ID) (*CodebaseResolver, error) {
 	c, err := r.codebaseRepo.Get(string(id))
 	if err != nil {
@@ -682,3 +748,27 @@ func (r *CodebaseRootResolver) resolveCodebaseByShort(ctx context.Context, short
 
 	return &CodebaseResolver{c: c, root: r}, nil
 }
+
+func (r *CodebaseResolver) Tags(ctx context.Context) ([]resolvers.TagResolver, error) {
+	return r.root.releaseRootResolver.InternalTagsByCodebaseID(ctx, r.c.ID)
+}
+
+func (r *CodebaseResolver) Releases(ctx context.Context) ([]resolvers.ReleaseResolver, error) {
+	return r.root.releaseRootResolver.InternalReleasesByCodebaseID(ctx, r.c.ID)
+}
+
+func (r *CodebaseResolver) AutoRevertConfig(ctx context.Context) (resolvers.AutoRevertConfigResolver, error) {
+	return r.root.autoRevertRootResolver.InternalConfigByCodebaseID(ctx, r.c.ID)
+}
+
+func (r *CodebaseResolver) Webhooks(ctx context.Context) ([]resolvers.WebhookResolver, error) {
+	return r.root.webhooksRootResolver.InternalListByCodebaseID(ctx, r.c.ID)
+}
+
+func (r *CodebaseResolver) ChatWebhooks(ctx context.Context) ([]resolvers.ChatWebhookResolver, error) {
+	return r.root.chatWebhooksRootResolver.InternalListByCodebaseID(ctx, r.c.ID)
+}
+
+func (r *CodebaseResolver) ServiceTokens(ctx context.Context) ([]resolvers.ServiceTokenResovler, error) {
+	return r.root.serviceTokensRootResolver.InternalListByCodebaseID(ctx, r.c.ID)
+}
@@ -10,6 +10,7 @@ import (
 	service_codebase "getsturdy.com/api/pkg/codebase/service"
 	"getsturdy.com/api/pkg/graphql/resolvers"
 	"getsturdy.com/api/pkg/internal/inmemory"
+	"getsturdy.com/api/pkg/organization"
 
 	"github.com/google/uuid"
 	"github.com/graph-gophers/graphql-go"
@@ -21,7 +22,7 @@ func TestCodebaseAccess(t *testing.T) {
 	codebaseRepo := inmemory.NewInMemoryCodebaseRepo()
 	codebaseUserRepo := inmemory.NewInMemoryCodebaseUserRepo()
 	codebaseService := service_codebase.New(codebaseRepo, codebaseUserRepo, nil, nil, nil, nil, nil, nil)
-	authService := service_auth.New(codebaseService, nil, nil, nil, nil)
+	authService := service_auth.New(codebaseService, nil, nil, nil, nil, nil, nil, nil)
 	resolver := NewCodebaseRootResolver(
 		codebaseRepo,
 		codebaseUserRepo,
@@ -37,6 +38,12 @@ func TestCodebaseAccess(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
 		zap.NewNop(),
 		nil,
 		nil,
@@ -120,3 +127,36 @@ func TestCodebaseAccess(t *testing.T) {
 
 	}
 }
+
+func TestUpdateCodebase_roles(t *testing.T) {
+	codebaseRepo := inmemory.NewInMemoryCodebaseRepo()
+	codebaseUserRepo := inmemory.NewInMemoryCodebaseUserRepo()
+	codebaseService := service_codebase.New(codebaseRepo, codebaseUserRepo, nil, nil, nil, nil, nil, nil)
+	resolver := &CodebaseRootResolver{
+		authService:     service_auth.New(codebaseService, nil, nil, nil, nil, nil, nil, nil),
+		codebaseService: codebaseService,
+	}
+
+	cb := codebase.Codebase{ID: uuid.NewString()}
+	assert.NoError(t, codebaseRepo.Create(cb))
+
+	userID := uuid.NewString()
+	assert.NoError(t, codebaseUserRepo.Create(codebase.CodebaseUser{ID: uuid.NewString(), CodebaseID: cb.ID, UserID: userID, Role: organization.RoleMember}))
+	ctx := auth.NewContext(context.Background(), &auth.Subject{Type: auth.SubjectUser, ID: userID})
+
+	yes := true
+	for name, input := range map[string]resolvers.UpdateCodebaseInput{
+		"is-public":               {ID: graphql.ID(cb.ID), IsPublic: &yes},
+		"dismiss-stale-approvals": {ID: graphql.ID(cb.ID), DismissStaleApprovals: &yes},
+		"generate-invite-code":    {ID: graphql.ID(cb.ID), GenerateInviteCode: &yes},
+		"disable-invite-code":     {ID: graphql.ID(cb.ID), DisableInviteCode: &yes},
+	} {
+		_, err := resolver.UpdateCodebase(ctx, resolvers.UpdateCodebaseArgs{Input: input})
+		assert.ErrorIs(t, err, auth.ErrForbidden, name)
+	}
+
+	stored, err := codebaseRepo.Get(cb.ID)
+	assert.NoError(t, err)
+	assert.False(t, stored.IsPublic)
+	assert.False(t, stored.DismissStaleApprovals)
+}
@@ -9,6 +9,7 @@ import (
 
 	service_auth "getsturdy.com/api/pkg/auth/service"
 	service_codebase "getsturdy.com/api/pkg/codebase/service"
+	"getsturdy.com/api/pkg/organization"
 )
 
 type InviteUserRequest struct {
@@ -33,7 +34,7 @@ func Invite(
 
 		ctx := c.Request.Context()
 
-		if err := authService.CanWrite(ctx, cb); err != nil {
+		if err := authService.CanAdmin(ctx, cb); err != nil {
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
@@ -44,7 +45,7 @@ func Invite(
 			return
 		}
 
-		member, err := codebaseService.AddUserByEmail(ctx, cb.ID, request.UserEmail)
+		member, err := codebaseService.AddUserByEmail(ctx, cb.ID, request.UserEmail, organization.RoleMember)
 		if err != nil {
 			c.AbortWithStatus(http.StatusBadRequest)
 			return
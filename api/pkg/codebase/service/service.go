@@ -17,6 +17,7 @@ import (
 	db_codebase "getsturdy.com/api/pkg/codebase/db"
 	"getsturdy.com/api/pkg/codebase/vcs"
 	"getsturdy.com/api/pkg/events"
+	"getsturdy.com/api/pkg/organization"
 	"getsturdy.com/api/pkg/shortid"
 	service_user "getsturdy.com/api/pkg/users/service"
 	service_workspace "getsturdy.com/api/pkg/workspaces/service"
@@ -24,6 +25,8 @@ import (
 	"getsturdy.com/api/vcs/provider"
 )
 
+var ErrInvalidRole = errors.New("invalid role")
+
 type Service struct {
 	repo             db_codebase.CodebaseRepository
 	codebaseUserRepo db_codebase.CodebaseUserRepository
@@ -91,6 +94,14 @@ func (svc *Service) CanAccess(ctx context.Context, userID string, codebaseID str
 	}
 }
 
+func (svc *Service) GetMember(ctx context.Context, codebaseID, userID string) (*codebase.CodebaseUser, error) {
+	member, err := svc.codebaseUserRepo.GetByUserAndCodebase(userID, codebaseID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get member: %w", err)
+	}
+	return member, nil
+}
+
 func (svc *Service) ListByOrganization(ctx context.Context, organizationID string) ([]*codebase.Codebase, error) {
 	res, err := svc.repo.ListByOrganization(ctx, organizationID)
 	if err != nil {
@@ -99,6 +110,11 @@ func (svc *Service) ListByOrganization(ctx context.Context, organizationID strin
 	return res, nil
 }
 
+// List returns all codebases that are not archived.
+func (svc *Service) List(ctx context.Context) ([]*codebase.Codebase, error) {
+	return svc.repo.List(ctx)
+}
+
 func (svc *Service) ListByOrganizationAndUser(ctx context.Context, organizationID, userID string) ([]*codebase.Codebase, error) {
 	codebases, err := svc.repo.ListByOrganization(ctx, organizationID)
 	if err != nil {
@@ -223,6 +239,7 @@ func (svc *Service) Create(ctx context.Context, name string, organizationID *str
 		UserID:     userID,
 		CodebaseID: cb.ID,
 		CreatedAt:  &t,
+		Role:       organization.RoleAdmin,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to add creator as member: %w", err)
@@ -255,7 +272,11 @@ func (svc *Service) CodebaseCount(ctx context.Context) (uint64, error) {
 	return svc.repo.Count(ctx)
 }
 
-func (svc *Service) AddUserByEmail(ctx context.Context, codebaseID, email string) (*codebase.CodebaseUser, error) {
+func (svc *Service) AddUserByEmail(ctx context.Context, codebaseID, email string, role organization.Role) (*codebase.CodebaseUser, error) {
+	if !role.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+
 	inviteUser, err := svc.userService.GetByEmail(ctx, email)
 	if err != nil {
 		return nil, fmt.Errorf("could not get user: %w", err)
@@ -273,6 +294,7 @@ func (svc *Service) AddUserByEmail(ctx context.Context, codebaseID, email string
 		UserID:     inviteUser.ID,
 		CodebaseID: codebaseID,
 		CreatedAt:  &t,
+		Role:       role,
 	}
 
 	err = svc.codebaseUserRepo.Create(member)
@@ -293,6 +315,35 @@ func (svc *Service) AddUserByEmail(ctx context.Context, codebaseID, email string
 	return &member, nil
 }
 
+func (svc *Service) SetMemberRole(ctx context.Context, codebaseID, userID string, role organization.Role) (*codebase.CodebaseUser, error) {
+	if !role.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+
+	member, err := svc.codebaseUserRepo.GetByUserAndCodebase(userID, codebaseID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get member: %w", err)
+	}
+
+	member.Role = role
+	if err := svc.codebaseUserRepo.Update(ctx, member); err != nil {
+		return nil, fmt.Errorf("could not update member: %w", err)
+	}
+
+	// Send events
+	if err := svc.eventsSender.Codebase(codebaseID, events.CodebaseUpdated, codebaseID); err != nil {
+		svc.logger.Error("failed to send events", zap.Error(err))
+	}
+
+	svc.analyticsService.Capture(ctx, "update codebase member role",
+		analytics.CodebaseID(codebaseID),
+		analytics.Property("user_id", userID),
+		analytics.Property("role", role),
+	)
+
+	return member, nil
+}
+
 func (svc *Service) RemoveUser(ctx context.Context, codebaseID, userID string) error {
 	member, err := svc.codebaseUserRepo.GetByUserAndCodebase(userID, codebaseID)
 	switch {
@@ -38,4 +38,23 @@ type Comment struct {
 	Context             *string `db:"context"`
 
 	ParentComment *ID `db:"parent_comment_id"`
+
+	// ResolvedAt and ResolvedBy are set when the thread is resolved, only top comments can be resolved
+	ResolvedAt *time.Time `db:"resolved_at"`
+	ResolvedBy *string    `db:"resolved_by"`
+
+	// Suggestion is the text that is suggested to replace the commented lines with, if set.
+	// SuggestionOriginal is the text of the commented lines when the suggestion was made.
+	Suggestion          *string    `db:"suggestion"`
+	SuggestionOriginal  *string    `db:"suggestion_original"`
+	SuggestionAppliedAt *time.Time `db:"suggestion_applied_at"`
+	SuggestionAppliedBy *string    `db:"suggestion_applied_by"`
+
+	// IsOutdated is set by live.GetWorkspaceComments if the commented lines have been changed or removed since
+	// the comment was made. It's not stored.
+	IsOutdated bool `db:"-"`
+}
+
+func (c *Comment) IsResolved() bool {
+	return c.ResolvedAt != nil
 }
@@ -18,6 +18,8 @@ type Repository interface {
 	GetByWorkspace(workspaceID string) ([]comments.Comment, error)
 	GetByParent(id comments.ID) ([]comments.Comment, error)
 	CountByWorkspaceID(context.Context, string) (int32, error)
+	// CountUnresolvedByWorkspaceID returns the number of unresolved top comments in the workspace
+	CountUnresolvedByWorkspaceID(context.Context, string) (int32, error)
 }
 
 type repo struct {
@@ -38,8 +40,8 @@ func (r *repo) Get(id comments.ID) (comments.Comment, error) {
 }
 
 func (r *repo) Create(comment comments.Comment) error {
-	_, err := r.db.NamedExec(`INSERT INTO comments (id, codebase_id, change_id, user_id, created_at, message, path, old_path, line_start, line_end, line_is_new, workspace_id, context, context_starts_at_line, parent_comment_id)
-		VALUES (:id, :codebase_id, :change_id, :user_id, :created_at, :message, :path, :old_path, :line_start, :line_end, :line_is_new, :workspace_id, :context, :context_starts_at_line, :parent_comment_id)`, &comment)
+	_, err := r.db.NamedExec(`INSERT INTO comments (id, codebase_id, change_id, user_id, created_at, message, path, old_path, line_start, line_end, line_is_new, workspace_id, context, context_starts_at_line, parent_comment_id, suggestion, suggestion_original)
+		VALUES (:id, :codebase_id, :change_id, :user_id, :created_at, :message, :path, :old_path, :line_start, :line_end, :line_is_new, :workspace_id, :context, :context_starts_at_line, :parent_comment_id, :suggestion, :suggestion_original)`, &comment)
 	if err != nil {
 		return fmt.Errorf("failed to perform insert: %w", err)
 	}
@@ -51,7 +53,14 @@ func (r *repo) Update(comment comments.Comment) error {
     	SET deleted_at = :deleted_at,
 			message = :message,
     	    workspace_id = :workspace_id,
-    	    change_id = :change_id
+    	    change_id = :change_id,
+    	    line_start = :line_start,
+    	    line_end = :line_end,
+    	    context_starts_at_line = :context_starts_at_line,
+    	    resolved_at = :resolved_at,
+    	    resolved_by = :resolved_by,
+    	    suggestion_applied_at = :suggestion_applied_at,
+    	    suggestion_applied_by = :suggestion_applied_by
     	WHERE id = :id`, &comment)
 	if err != nil {
 		return fmt.Errorf("failed to update change: %w", err)
@@ -61,7 +70,7 @@ func (r *repo) Update(comment comments.Comment) error {
 
 func (r *repo) GetByCodebaseAndChange(codebaseID string, changeID change.ID) ([]comments.Comment, error) {
 	var res []comments.Comment
-	err := r.db.Select(&res, `SELECT id, codebase_id, change_id, user_id, created_at, message, path, old_path, line_start, line_end, line_is_new, workspace_id, context, context_starts_at_line, parent_comment_id
+	err := r.db.Select(&res, `SELECT id, codebase_id, change_id, user_id, created_at, message, path, old_path, line_start, line_end, line_is_new, workspace_id, context, context_starts_at_line, parent_comment_id, resolved_at, resolved_by, suggestion, suggestion_original, suggestion_applied_at, suggestion_applied_by
 		FROM comments
 		WHERE codebase_id = $1
 		  AND change_id = $2
@@ -76,9 +85,10 @@ func (r *repo) GetByCodebaseAndChange(codebaseID string, changeID change.ID) ([]
 
 func (r *repo) GetByWorkspace(workspaceID string) ([]comments.Comment, error) {
 	var res []comments.Comment
-	err := r.db.Select(&res, `SELECT id, codebase_id, change_id, user_id, created_at, message, path, old_path, line_start, line_end, line_is_new, workspace_id, context, context_starts_at_line, parent_comment_id
+	err := r.db.Select(&res, `SELECT id, codebase_id, change_id, user_id, created_at, message, path, old_path, line_start, line_end, line_is_new, workspace_id, context, context_starts_at_line, parent_comment_id, resolved_at, resolved_by, suggestion, suggestion_original, suggestion_applied_at, suggestion_applied_by
 		FROM comments
 		WHERE workspace_id = $1
+		  AND change_id IS NULL
 		  AND deleted_at IS NULL
 		  AND parent_comment_id IS NULL
 	  ORDER BY created_at DESC`, workspaceID)
@@ -90,7 +100,7 @@ func (r *repo) GetByWorkspace(workspaceID string) ([]comments.Comment, error) {
 
 func (r *repo) GetByParent(id comments.ID) ([]comments.Comment, error) {
 	var res []comments.Comment
-	err := r.db.Select(&res, `SELECT id, codebase_id, change_id, user_id, created_at, message, path, old_path, line_start, line_end, line_is_new, workspace_id, context, context_starts_at_line, parent_comment_id
+	err := r.db.Select(&res, `SELECT id, codebase_id, change_id, user_id, created_at, message, path, old_path, line_start, line_end, line_is_new, workspace_id, context, context_starts_at_line, parent_comment_id, resolved_at, resolved_by, suggestion, suggestion_original, suggestion_applied_at, suggestion_applied_by
 		FROM comments
 		WHERE parent_comment_id = $1
 		  AND deleted_at IS NULL
@@ -103,7 +113,21 @@ func (r *repo) GetByParent(id comments.ID) ([]comments.Comment, error) {
 
 func (r *repo) CountByWorkspaceID(ctx context.Context, workspaceID string) (int32, error) {
 	var res int32
-	if err := r.db.GetContext(ctx, &res, `SELECT COUNT(*) FROM comments WHERE workspace_id = $1 AND deleted_at IS NULL`, workspaceID); err != nil {
+	if err := r.db.GetContext(ctx, &res, `SELECT COUNT(*) FROM comments WHERE workspace_id = $1 AND change_id IS NULL AND deleted_at IS NULL`, workspaceID); err != nil {
+		return 0, fmt.Errorf("failed to query table: %w", err)
+	}
+	return res, nil
+}
+
+func (r *repo) CountUnresolvedByWorkspaceID(ctx context.Context, workspaceID string) (int32, error) {
+	var res int32
+	if err := r.db.GetContext(ctx, &res, `SELECT COUNT(*)
+		FROM comments
+		WHERE workspace_id = $1
+		  AND change_id IS NULL
+		  AND deleted_at IS NULL
+		  AND parent_comment_id IS NULL
+		  AND resolved_at IS NULL`, workspaceID); err != nil {
 		return 0, fmt.Errorf("failed to query table: %w", err)
 	}
 	return res, nil
@@ -0,0 +1,167 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/analytics"
+	"getsturdy.com/api/pkg/auth"
+	"getsturdy.com/api/pkg/comments"
+	"getsturdy.com/api/pkg/events"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+
+	"go.uber.org/zap"
+)
+
+func (r *CommentRootResolver) ResolveComment(ctx context.Context, args resolvers.ResolveCommentArgs) (resolvers.CommentResolver, error) {
+	return r.setResolved(ctx, comments.ID(args.Input.ID), true)
+}
+
+func (r *CommentRootResolver) UnresolveComment(ctx context.Context, args resolvers.ResolveCommentArgs) (resolvers.CommentResolver, error) {
+	return r.setResolved(ctx, comments.ID(args.Input.ID), false)
+}
+
+func (r *CommentRootResolver) setResolved(ctx context.Context, id comments.ID, resolved bool) (resolvers.CommentResolver, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	comment, err := r.commentsRepo.Get(id)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if comment.DeletedAt != nil {
+		return nil, gqlerrors.Error(gqlerrors.ErrNotFound)
+	}
+
+	if comment.ParentComment != nil {
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "only top comments can be resolved")
+	}
+
+	// anyone that can comment on the workspace or the change can resolve the thread
+	if err := r.canWriteTopComment(ctx, &comment); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if comment.IsResolved() == resolved {
+		return &CommentResolver{root: r, comment: comment}, nil
+	}
+
+	if resolved {
+		now := time.Now()
+		comment.ResolvedAt = &now
+		comment.ResolvedBy = &userID
+	} else {
+		comment.ResolvedAt = nil
+		comment.ResolvedBy = nil
+	}
+
+	if err := r.commentsRepo.Update(comment); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.eventsSender.Codebase(comment.CodebaseID, events.CommentResolutionUpdated, string(comment.ID)); err != nil {
+		r.logger.Error("failed to send comment resolution updated event", zap.Error(err))
+		// do not fail
+	}
+
+	if comment.WorkspaceID != nil {
+		if err := r.eventsSender.Codebase(comment.CodebaseID, events.WorkspaceUpdatedComments, *comment.WorkspaceID); err != nil {
+			r.logger.Error("failed to send workspace updated comments event", zap.Error(err))
+			// do not fail
+		}
+	}
+
+	event := "resolved comment"
+	if !resolved {
+		event = "unresolved comment"
+	}
+	r.analyticsService.Capture(ctx, event,
+		analytics.CodebaseID(comment.CodebaseID),
+		analytics.Property("comment_id", comment.ID),
+	)
+
+	return &CommentResolver{root: r, comment: comment}, nil
+}
+
+func (r *CommentRootResolver) canWriteTopComment(ctx context.Context, comment *comments.Comment) error {
+	switch {
+	case comment.WorkspaceID != nil:
+		ws, err := r.workspaceReader.Get(*comment.WorkspaceID)
+		if err != nil {
+			return err
+		}
+		return r.authService.CanComment(ctx, ws)
+	case comment.ChangeID != nil:
+		ch, err := r.changeService.GetChangeByID(ctx, *comment.ChangeID)
+		if err != nil {
+			return err
+		}
+		return r.authService.CanWrite(ctx, ch)
+	default:
+		return fmt.Errorf("comment has neither a workspace nor a change")
+	}
+}
+
+func (r *CommentRootResolver) InternalCountUnresolvedByWorkspaceID(ctx context.Context, workspaceID string) (int32, error) {
+	return r.commentsRepo.CountUnresolvedByWorkspaceID(ctx, workspaceID)
+}
+
+func (r *CommentRootResolver) UpdatedCommentResolution(ctx context.Context, args resolvers.UpdatedCommentResolutionArgs) (<-chan resolvers.TopCommentResolver, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	c := make(chan resolvers.TopCommentResolver, 100)
+
+	cancelFunc := r.eventsReader.SubscribeUser(userID, func(eventType events.EventType, commentID string) error {
+		if eventType != events.CommentResolutionUpdated {
+			return nil
+		}
+
+		comment, err := r.commentsRepo.Get(comments.ID(commentID))
+		if err != nil {
+			return fmt.Errorf("failed to get comment: %w", err)
+		}
+
+		if args.CodebaseID != nil && comment.CodebaseID != string(*args.CodebaseID) {
+			return nil
+		}
+
+		if err := r.authService.CanRead(ctx, comment); err != nil {
+			return nil
+		}
+
+		resolver, ok := (&CommentResolver{root: r, comment: comment}).ToTopComment()
+		if !ok {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case c <- resolver:
+		default:
+			r.logger.Named("updatedCommentResolution").Error("dropped event",
+				zap.String("user_id", userID),
+				zap.Stringer("event_type", eventType),
+				zap.Int("channel_size", len(c)),
+			)
+		}
+
+		return nil
+	})
+
+	go func() {
+		<-ctx.Done()
+		cancelFunc()
+		close(c)
+	}()
+
+	return c, nil
+}
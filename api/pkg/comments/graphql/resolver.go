@@ -25,9 +25,12 @@ import (
 	"getsturdy.com/api/pkg/notification"
 	notification_sender "getsturdy.com/api/pkg/notification/sender"
 	db_snapshots "getsturdy.com/api/pkg/snapshots/db"
+	service_suggestions "getsturdy.com/api/pkg/suggestions/service"
 	"getsturdy.com/api/pkg/users"
 	db_user "getsturdy.com/api/pkg/users/db"
 	db_view "getsturdy.com/api/pkg/view/db"
+	"getsturdy.com/api/pkg/webhooks"
+	sender_webhooks "getsturdy.com/api/pkg/webhooks/sender"
 	"getsturdy.com/api/pkg/workspaces"
 	"getsturdy.com/api/pkg/workspaces/activity"
 	sender_workspace_activity "getsturdy.com/api/pkg/workspaces/activity/sender"
@@ -61,11 +64,13 @@ type CommentRootResolver struct {
 	workspaceWatchersService *service_workspace_watchers.Service
 	authService              *service_auth.Service
 	changeService            *service_change.Service
+	suggestionsService       *service_suggestions.Service
 
 	eventsReader       events.EventReader
 	eventsSender       events.EventSender
 	notificationSender notification_sender.NotificationSender
 	activitySender     sender_workspace_activity.ActivitySender
+	webhooksSender     sender_webhooks.Sender
 
 	authorResolver    resolvers.AuthorRootResolver
 	workspaceResolver *resolvers.WorkspaceRootResolver
@@ -85,11 +90,13 @@ func NewResolver(
 	workspaceWatchersService *service_workspace_watchers.Service,
 	authService *service_auth.Service,
 	changeService *service_change.Service,
+	suggestionsService *service_suggestions.Service,
 
 	eventsSender events.EventSender,
 	eventsReader events.EventReader,
 	notificationSender notification_sender.NotificationSender,
 	activitySender sender_workspace_activity.ActivitySender,
+	webhooksSender sender_webhooks.Sender,
 
 	authorResolver resolvers.AuthorRootResolver,
 	workspaceResolver *resolvers.WorkspaceRootResolver,
@@ -111,11 +118,13 @@ func NewResolver(
 		workspaceWatchersService: workspaceWatchersService,
 		authService:              authService,
 		changeService:            changeService,
+		suggestionsService:       suggestionsService,
 
 		eventsSender:       eventsSender,
 		eventsReader:       eventsReader,
 		notificationSender: notificationSender,
 		activitySender:     activitySender,
+		webhooksSender:     webhooksSender,
 
 		authorResolver:    authorResolver,
 		workspaceResolver: workspaceResolver,
@@ -152,7 +161,7 @@ func (r *CommentRootResolver) InternalWorkspaceComments(workspace *workspaces.Wo
 	var res []resolvers.CommentResolver
 	for _, c := range comms {
 		_ = c
-		res = append(res, &CommentResolver{comment: c, root: r})
+		res = append(res, &CommentResolver{comment: c, root: r, relocated: true})
 	}
 
 	return res, nil
@@ -353,6 +362,11 @@ func (r *CommentRootResolver) CreateComment(ctx context.Context, args resolvers.
 		return nil, gqlerrors.Error(err)
 	}
 
+	if err := r.webhooksSender.Codebase(ctx, comment.CodebaseID, webhooks.EventCommentCreated, string(comment.ID)); err != nil {
+		r.logger.Error("failed to send webhook event", zap.Error(err))
+		// do not fail
+	}
+
 	if comment.WorkspaceID == nil {
 		return &CommentResolver{root: r, comment: *comment}, nil
 	}
@@ -421,12 +435,18 @@ func (r *CommentRootResolver) prepareTopComment(ctx context.Context, args resolv
 		return nil, fmt.Errorf("path, lineIsNew, lineStart or lineEnd is not set")
 	}
 
+	// Suggestions replace new lines in a workspace
+	if args.Input.Suggestion != nil && (args.Input.WorkspaceID == nil || args.Input.Path == nil || !*args.Input.LineIsNew) {
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "suggestions can only be made on new lines in a workspace")
+	}
+
 	var codebaseID string
 	var workspaceID *string
 	var changeID *change.ID
 
 	var optionalContext *string
 	var optionalContextStartsAt *int
+	var optionalSuggestionOriginal *string
 
 	// Comment in a workspace
 	if args.Input.WorkspaceID != nil {
@@ -438,7 +458,7 @@ func (r *CommentRootResolver) prepareTopComment(ctx context.Context, args resolv
 			return nil, err
 		}
 
-		if err := r.authService.CanWrite(ctx, ws); err != nil {
+		if err := r.authService.CanComment(ctx, ws); err != nil {
 			return nil, err
 		}
 
@@ -453,6 +473,16 @@ func (r *CommentRootResolver) prepareTopComment(ctx context.Context, args resolv
 			}
 			optionalContext = &context
 			optionalContextStartsAt = &contextStartsAt
+
+			if args.Input.Suggestion != nil {
+				original, err := vcs.GetLines(int(*args.Input.LineStart), int(*args.Input.LineEnd), *args.Input.Path, ws, r.executorProvider, r.snapshotRepo)
+				if errors.Is(err, vcs.ErrLinesNotFound) {
+					return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "the suggested lines do not exist")
+				} else if err != nil {
+					return nil, fmt.Errorf("failed to get suggested lines: %w", err)
+				}
+				optionalSuggestionOriginal = &original
+			}
 		}
 	} else {
 		// Comment on a change
@@ -490,6 +520,8 @@ func (r *CommentRootResolver) prepareTopComment(ctx context.Context, args resolv
 		ChangeID:            changeID,
 		Context:             optionalContext,
 		ContextStartsAtLine: optionalContextStartsAt,
+		Suggestion:          args.Input.Suggestion,
+		SuggestionOriginal:  optionalSuggestionOriginal,
 	}
 
 	// Comment on code
@@ -509,6 +541,9 @@ func (r *CommentRootResolver) prepareReplyComment(ctx context.Context, args reso
 	if err != nil {
 		return nil, err
 	}
+	if args.Input.Suggestion != nil {
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "suggestions can not be made in replies")
+	}
 	parentID := comments.ID(*args.Input.InReplyTo)
 
 	// Get more meta from parent comment
@@ -522,6 +557,17 @@ func (r *CommentRootResolver) prepareReplyComment(ctx context.Context, args reso
 		return nil, errors.New("can not reply to another reply")
 	}
 
+	// Replies in workspaces are limited in the same way as new comments, so that guests can't comment through them
+	if parent.WorkspaceID != nil && parent.ChangeID == nil {
+		ws, err := r.workspaceReader.Get(*parent.WorkspaceID)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.authService.CanComment(ctx, ws); err != nil {
+			return nil, err
+		}
+	}
+
 	id := comments.ID(uuid.NewString())
 	comment := &comments.Comment{
 		ID:            id,
@@ -531,6 +577,7 @@ func (r *CommentRootResolver) prepareReplyComment(ctx context.Context, args reso
 		ParentComment: &parentID,
 		CodebaseID:    parent.CodebaseID,  // Not exposed on the API for reply comments
 		WorkspaceID:   parent.WorkspaceID, // Not exposed on the API for reply comments, but is used to generate/route events
+		ChangeID:      parent.ChangeID,    // Set if the parent has been moved to a change, see MoveCommentsFromWorkspaceToChange
 	}
 
 	if err := r.authService.CanWrite(ctx, comment); err != nil {
@@ -551,6 +598,8 @@ func (r *CommentRootResolver) prepareReplyComment(ctx context.Context, args reso
 type CommentResolver struct {
 	root    *CommentRootResolver
 	comment comments.Comment
+	// relocated is true if the comment has been relocated in the latest snapshot of the workspace
+	relocated bool
 }
 
 func (r *CommentResolver) ToReplyComment() (resolvers.ReplyCommentResolver, bool) {
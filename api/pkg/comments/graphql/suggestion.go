@@ -0,0 +1,111 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"getsturdy.com/api/pkg/auth"
+	"getsturdy.com/api/pkg/comments"
+	"getsturdy.com/api/pkg/events"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+
+	"github.com/graph-gophers/graphql-go"
+	"go.uber.org/zap"
+)
+
+// ApplyCommentSuggestion applies the suggestion of the comment to the workspace, and resolves the comment. Only the
+// owner of the workspace can apply suggestions.
+func (r *CommentRootResolver) ApplyCommentSuggestion(ctx context.Context, args resolvers.ApplyCommentSuggestionArgs) (resolvers.CommentResolver, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	comment, err := r.commentsRepo.Get(comments.ID(args.Input.ID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if comment.DeletedAt != nil {
+		return nil, gqlerrors.Error(gqlerrors.ErrNotFound)
+	}
+
+	if !comment.HasSuggestion() || comment.WorkspaceID == nil || comment.ChangeID != nil {
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "comment has no suggestion that can be applied")
+	}
+
+	if comment.SuggestionAppliedAt != nil {
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "suggestion has already been applied")
+	}
+
+	ws, err := r.workspaceReader.Get(*comment.WorkspaceID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.authService.CanWrite(ctx, ws); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if ws.UserID != userID {
+		return nil, gqlerrors.Error(gqlerrors.ErrForbidden, "message", "only the owner of the workspace can apply suggestions")
+	}
+
+	if err := r.suggestionsService.ApplyComment(ctx, &comment); errors.Is(err, comments.ErrSuggestionConflict) {
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "the suggested lines have been changed", "reason", "conflict")
+	} else if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	now := time.Now()
+	comment.SuggestionAppliedAt = &now
+	comment.SuggestionAppliedBy = &userID
+	if !comment.IsResolved() {
+		comment.ResolvedAt = &now
+		comment.ResolvedBy = &userID
+	}
+	if err := r.commentsRepo.Update(comment); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.eventsSender.Codebase(comment.CodebaseID, events.CommentResolutionUpdated, string(comment.ID)); err != nil {
+		r.logger.Error("failed to send comment resolution updated event", zap.Error(err))
+		// do not fail
+	}
+
+	if err := r.eventsSender.Codebase(comment.CodebaseID, events.WorkspaceUpdatedComments, *comment.WorkspaceID); err != nil {
+		r.logger.Error("failed to send workspace updated comments event", zap.Error(err))
+		// do not fail
+	}
+
+	return &CommentResolver{root: r, comment: comment}, nil
+}
+
+type CommentSuggestionResolver struct {
+	*CommentResolver
+}
+
+func (r *CommentSuggestionResolver) Replacement() string {
+	return *r.comment.Suggestion
+}
+
+func (r *CommentSuggestionResolver) IsApplied() bool {
+	return r.comment.SuggestionAppliedAt != nil
+}
+
+func (r *CommentSuggestionResolver) AppliedAt() *int32 {
+	if r.comment.SuggestionAppliedAt == nil {
+		return nil
+	}
+	t := int32(r.comment.SuggestionAppliedAt.Unix())
+	return &t
+}
+
+func (r *CommentSuggestionResolver) AppliedBy(ctx context.Context) (resolvers.AuthorResolver, error) {
+	if r.comment.SuggestionAppliedBy == nil {
+		return nil, nil
+	}
+	return r.root.authorResolver.Author(ctx, graphql.ID(*r.comment.SuggestionAppliedBy))
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 
+	"getsturdy.com/api/pkg/comments/live"
 	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
 	"getsturdy.com/api/pkg/graphql/resolvers"
 
@@ -67,3 +68,55 @@ func (r *TopCommentResolver) CodeContext() resolvers.CommentCodeContext {
 	}
 	return &CodeCommentContextResolver{r.CommentResolver}
 }
+
+func (r *TopCommentResolver) IsResolved() bool {
+	return r.comment.IsResolved()
+}
+
+func (r *TopCommentResolver) ResolvedAt() *int32 {
+	if r.comment.ResolvedAt == nil {
+		return nil
+	}
+	t := int32(r.comment.ResolvedAt.Unix())
+	return &t
+}
+
+func (r *TopCommentResolver) ResolvedBy(ctx context.Context) (resolvers.AuthorResolver, error) {
+	if r.comment.ResolvedBy == nil {
+		return nil, nil
+	}
+	return r.root.authorResolver.Author(ctx, graphql.ID(*r.comment.ResolvedBy))
+}
+
+// IsOutdated returns true if the commented lines have been changed or removed in the latest snapshot of the
+// workspace. Comments on changes, including comments that have been moved from the workspace when it was landed, are
+// never outdated.
+func (r *TopCommentResolver) IsOutdated(ctx context.Context) (bool, error) {
+	if r.comment.WorkspaceID == nil || r.comment.ChangeID != nil || r.comment.Path == "" {
+		return false, nil
+	}
+	if r.relocated {
+		return r.comment.IsOutdated, nil
+	}
+
+	ws, err := r.root.workspaceReader.Get(*r.comment.WorkspaceID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The workspace has been archived since the comment was created
+		return false, nil
+	} else if err != nil {
+		return false, gqlerrors.Error(err)
+	}
+
+	if err := live.GetWorkspaceComment(&r.comment, ws, r.root.executorProvider, r.root.snapshotRepo); err != nil {
+		return false, gqlerrors.Error(err)
+	}
+	r.relocated = true
+	return r.comment.IsOutdated, nil
+}
+
+func (r *TopCommentResolver) Suggestion() resolvers.CommentSuggestionResolver {
+	if !r.comment.HasSuggestion() {
+		return nil
+	}
+	return &CommentSuggestionResolver{r.CommentResolver}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"getsturdy.com/api/pkg/change"
 	"getsturdy.com/api/pkg/snapshots"
 	db_snapshots "getsturdy.com/api/pkg/snapshots/db"
 	"getsturdy.com/api/pkg/workspaces"
@@ -78,6 +79,57 @@ func (snapshotsFS *SnapshotsFS) Open(path string) (fs.File, error) {
 	}).ExecTrunk(snapshotsFS.snapshot.CodebaseID, fmt.Sprintf("open %s", path))
 }
 
+type ChangesFS struct {
+	executorProvider executor.Provider
+	change           *change.Change
+	// newLines directly links to comment.LineIsNew
+	newLines bool
+}
+
+// ChangeFS returns the files of the change, or the files of the parent of the change if newLines is false.
+func ChangeFS(
+	executorProvider executor.Provider,
+	change *change.Change,
+	newLines bool,
+) fs.FS {
+	return &ChangesFS{
+		executorProvider: executorProvider,
+		change:           change,
+		newLines:         newLines,
+	}
+}
+
+func (changesFS *ChangesFS) Open(path string) (fs.File, error) {
+	if changesFS.change.CommitID == nil {
+		return nil, fs.ErrNotExist
+	}
+	commitID := *changesFS.change.CommitID
+
+	var file fs.File
+	return file, changesFS.executorProvider.New().GitRead(func(repo vcs.RepoGitReader) error {
+		if changesFS.newLines {
+			var err error
+			file, err = fileFromCommit(repo, commitID, path)
+			return err
+		}
+
+		parents, err := repo.GetCommitParents(commitID)
+		if err != nil {
+			return fmt.Errorf("failed to get commit parents: %w", err)
+		}
+		switch len(parents) {
+		case 0:
+			// the first change in the codebase, there are no old files
+			return fs.ErrNotExist
+		case 1:
+		default:
+			return fmt.Errorf("expected 1 parent, got %d", len(parents))
+		}
+		file, err = fileFromCommit(repo, parents[0], path)
+		return err
+	}).ExecTrunk(changesFS.change.CodebaseID, fmt.Sprintf("open %s", path))
+}
+
 type ViewFS struct {
 	executorProvider executor.Provider
 	viewID           string
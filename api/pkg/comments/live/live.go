@@ -144,49 +144,129 @@ func GetWorkspaceComments(
 	}
 
 	// fuzzily update line numbers
-	for i, c := range comms {
-		if c.Context == nil || c.ContextStartsAtLine == nil {
-			comms[i].LineStart = -1
-			comms[i].LineEnd = -1
-			continue
+	for i := range comms {
+		if err := relocate(&comms[i], newFilesFS, oldFilesFS); err != nil {
+			return nil, err
 		}
+	}
+
+	return comms, nil
+}
+
+// GetWorkspaceComment updates the line numbers of a single comment on the workspace, see GetWorkspaceComments.
+func GetWorkspaceComment(
+	c *comments.Comment,
+	ws *workspaces.Workspace,
+	executorProvider executor.Provider,
+	snapshotRepo db_snapshots.Repository,
+) error {
+	newFilesFS, err := WorkspaceFS(executorProvider, snapshotRepo, ws, true)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrNoFiles):
+		return nil
+	default:
+		return fmt.Errorf("could not prepare workspace filesystem: %w", err)
+	}
+
+	oldFilesFS, err := WorkspaceFS(executorProvider, snapshotRepo, ws, false)
+	if err != nil {
+		return fmt.Errorf("could not prepare workspace filesystem: %w", err)
+	}
+
+	return relocate(c, newFilesFS, oldFilesFS)
+}
+
+// Relocate fuzzily finds the commented lines in the files, such as the files of a change (see ChangeFS), and moves
+// the line numbers and the context of the comment to them. It returns false, and leaves the comment untouched, if the lines
+// can't be found.
+func Relocate(c *comments.Comment, newFilesFS, oldFilesFS fs.FS) (bool, error) {
+	relocated := *c
+	if err := relocate(&relocated, newFilesFS, oldFilesFS); err != nil {
+		return false, err
+	}
+	if relocated.LineStart < 1 {
+		return false, nil
+	}
+
+	delta := relocated.LineStart - c.LineStart
+	c.LineStart += delta
+	c.LineEnd += delta
+	contextStartsAtLine := *c.ContextStartsAtLine + delta
+	c.ContextStartsAtLine = &contextStartsAtLine
+	return true, nil
+}
+
+// relocate fuzzily finds the new location of the comment in the files, and marks it as outdated if the commented
+// line has been changed or removed.
+func relocate(c *comments.Comment, newFilesFS, oldFilesFS fs.FS) error {
+	if c.Context == nil || c.ContextStartsAtLine == nil {
+		c.LineStart = -1
+		c.LineEnd = -1
+		return nil
+	}
 
-		var (
-			file fs.File
-			err  error
-		)
-		if c.LineIsNew {
-			file, err = newFilesFS.Open(c.Path)
+	var (
+		file fs.File
+		err  error
+	)
+	if c.LineIsNew {
+		file, err = newFilesFS.Open(c.Path)
+	} else {
+		if c.OldPath != nil {
+			file, err = oldFilesFS.Open(*c.OldPath)
 		} else {
-			if c.OldPath != nil {
-				file, err = oldFilesFS.Open(*c.OldPath)
-			} else {
-				file, err = oldFilesFS.Open(c.Path)
-			}
-		}
-		switch {
-		case err == nil:
-		case errors.Is(err, fs.ErrNotExist), errors.Is(err, executor.ErrIsRebasing):
-			comms[i].LineStart = -1
-			comms[i].LineEnd = -1
-			continue
-		default:
-			return nil, fmt.Errorf("could not open file %s: %w", c.Path, err)
+			file, err = oldFilesFS.Open(c.Path)
 		}
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, fs.ErrNotExist):
+		c.LineStart = -1
+		c.LineEnd = -1
+		c.IsOutdated = true
+		return nil
+	case errors.Is(err, executor.ErrIsRebasing):
+		c.LineStart = -1
+		c.LineEnd = -1
+		return nil
+	default:
+		return fmt.Errorf("could not open file %s: %w", c.Path, err)
+	}
 
-		contents, err := ioutil.ReadAll(file)
-		if err != nil {
-			return nil, fmt.Errorf("could not read file contents: %w", err)
-		}
+	contents, err := ioutil.ReadAll(file)
+	if err != nil {
+		return fmt.Errorf("could not read file contents: %w", err)
+	}
 
-		newLoc := fuzzyNewLocation(c, string(contents))
-		comms[i].LineStart = newLoc
-		comms[i].LineEnd = newLoc
+	newLoc := fuzzyNewLocation(*c, string(contents))
+	c.IsOutdated = isOutdated(*c, string(contents), newLoc)
+	c.LineStart = newLoc
+	c.LineEnd = newLoc
 
-		if err := file.Close(); err != nil {
-			return nil, fmt.Errorf("could not close file: %w", err)
-		}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("could not close file: %w", err)
+	}
+	return nil
+}
+
+// isOutdated returns true if the commented line can't be found at newLoc, ignoring whitespace changes.
+func isOutdated(c comments.Comment, fileContents string, newLoc int) bool {
+	if newLoc < 1 {
+		return true
 	}
 
-	return comms, nil
+	context := strings.Split(*c.Context, "\n")
+	commentedLine := c.LineStart - *c.ContextStartsAtLine
+	if commentedLine < 0 || commentedLine >= len(context) {
+		// the context does not contain the commented line, there is nothing to compare with
+		return false
+	}
+
+	rows := strings.Split(fileContents, "\n")
+	if newLoc > len(rows) {
+		return true
+	}
+
+	return normalizeWhitespace(rows[newLoc-1]) != normalizeWhitespace(context[commentedLine])
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"getsturdy.com/api/pkg/comments"
 	"io/ioutil"
+	"os"
 	"strings"
 	"testing"
 
@@ -219,3 +220,113 @@ i`, "\n")
 		})
 	}
 }
+
+func TestIsOutdated(t *testing.T) {
+	cases := []struct {
+		name             string
+		line             int
+		context          string
+		contextStartsAt  int
+		fileContents     string
+		expectedOutdated bool
+	}{
+		{
+			name:             "not-changed",
+			line:             3,
+			context:          "a\nb\nc\nd\ne\n",
+			contextStartsAt:  1,
+			fileContents:     "a\nb\nc\nd\ne\n",
+			expectedOutdated: false,
+		},
+		{
+			name:             "moved",
+			line:             3,
+			context:          "a\nb\nc\nd\ne\n",
+			contextStartsAt:  1,
+			fileContents:     "x\ny\na\nb\nc\nd\ne\n",
+			expectedOutdated: false,
+		},
+		{
+			name:             "indentation-changed",
+			line:             3,
+			context:          "a\nb\nc\nd\ne\n",
+			contextStartsAt:  1,
+			fileContents:     "a\nb\n\t\tc\nd\ne\n",
+			expectedOutdated: false,
+		},
+		{
+			name:             "line-changed",
+			line:             3,
+			context:          "a\nb\nc\nd\ne\n",
+			contextStartsAt:  1,
+			fileContents:     "a\nb\nchanged\nd\ne\n",
+			expectedOutdated: true,
+		},
+		{
+			name:             "surrounding-line-changed",
+			line:             3,
+			context:          "a\nb\nc\nd\ne\n",
+			contextStartsAt:  1,
+			fileContents:     "a\nchanged\nc\nd\ne\n",
+			expectedOutdated: false,
+		},
+		{
+			name:             "removed",
+			line:             3,
+			context:          "a\nb\nc\nd\ne\n",
+			contextStartsAt:  1,
+			fileContents:     "something\nelse\nentirely\n",
+			expectedOutdated: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			comment := comments.Comment{
+				LineStart:           tc.line,
+				LineEnd:             tc.line,
+				LineIsNew:           true,
+				Context:             &tc.context,
+				ContextStartsAtLine: &tc.contextStartsAt,
+			}
+
+			newLocation := fuzzyNewLocation(comment, tc.fileContents)
+			assert.Equal(t, tc.expectedOutdated, isOutdated(comment, tc.fileContents, newLocation))
+		})
+	}
+}
+
+func TestRelocate(t *testing.T) {
+	context := "\t\tfmt.Println(i, fizz)\n\t} else if i%5 == 0 {\n\t\tfmt.Println(i, buzz)\n\t} else {\n\t\tfmt.Println(i)\n"
+	newComment := func(path string) *comments.Comment {
+		contextStartsAt := 12
+		return &comments.Comment{
+			Path:                path,
+			LineStart:           14,
+			LineEnd:             14,
+			LineIsNew:           true,
+			Context:             &context,
+			ContextStartsAtLine: &contextStartsAt,
+		}
+	}
+
+	files := os.DirFS("testdata")
+
+	t.Run("moved", func(t *testing.T) {
+		c := newComment("fizzbuzz_1.go")
+		ok, err := Relocate(c, files, files)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, 23, c.LineStart)
+		assert.Equal(t, 23, c.LineEnd)
+		assert.Equal(t, 21, *c.ContextStartsAtLine)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		c := newComment("does-not-exist.go")
+		ok, err := Relocate(c, files, files)
+		assert.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, newComment("does-not-exist.go"), c)
+	})
+}
@@ -5,30 +5,105 @@ import (
 	"fmt"
 
 	"getsturdy.com/api/pkg/change"
+	service_change "getsturdy.com/api/pkg/change/service"
+	"getsturdy.com/api/pkg/comments"
 	db_comments "getsturdy.com/api/pkg/comments/db"
+	"getsturdy.com/api/pkg/comments/live"
+	"getsturdy.com/api/vcs/executor"
+
+	"go.uber.org/zap"
 )
 
 type Service struct {
-	commentRepo db_comments.Repository
+	commentRepo      db_comments.Repository
+	changeService    *service_change.Service
+	executorProvider executor.Provider
+	logger           *zap.Logger
 }
 
-func New(commentRepo db_comments.Repository) *Service {
+func New(
+	commentRepo db_comments.Repository,
+	changeService *service_change.Service,
+	executorProvider executor.Provider,
+	logger *zap.Logger,
+) *Service {
 	return &Service{
-		commentRepo: commentRepo,
+		commentRepo:      commentRepo,
+		changeService:    changeService,
+		executorProvider: executorProvider,
+		logger:           logger.Named("comments"),
 	}
 }
 
+// MoveCommentsFromWorkspaceToChange links the live comments of the workspace to a change that has been landed from it.
+//
+// Comments on code are only moved if the commented lines are part of the change, their line numbers are remapped to
+// the files of the change. Comments on code that is not landed stay in the workspace. The moved comments keep their
+// workspace id, so that existing links to them keep working.
 func (s *Service) MoveCommentsFromWorkspaceToChange(ctx context.Context, workspaceID string, changeID change.ID) error {
-	// Move all live comments on this workspace to the new change
-	comments, err := s.commentRepo.GetByWorkspace(workspaceID)
+	ch, err := s.changeService.GetChangeByID(ctx, changeID)
+	if err != nil {
+		return fmt.Errorf("failed to get change: %w", err)
+	}
+
+	diffs, err := s.changeService.Diffs(ctx, ch, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get change diffs: %w", err)
+	}
+	changedPaths := make(map[string]bool, len(diffs))
+	for _, diff := range diffs {
+		changedPaths[diff.OrigName] = true
+		changedPaths[diff.NewName] = true
+	}
+
+	newFilesFS := live.ChangeFS(s.executorProvider, ch, true)
+	oldFilesFS := live.ChangeFS(s.executorProvider, ch, false)
+
+	comms, err := s.commentRepo.GetByWorkspace(workspaceID)
 	if err != nil {
 		return fmt.Errorf("failed to get comments in workspace: %w", err)
 	}
-	for _, comment := range comments {
-		comment.WorkspaceID = nil
-		comment.ChangeID = &changeID
-		if err := s.commentRepo.Update(comment); err != nil {
-			return fmt.Errorf("failed to update comment: %w", err)
+	for _, comment := range comms {
+		if comment.Path != "" {
+			if !changedPaths[comment.Path] && (comment.OldPath == nil || !changedPaths[*comment.OldPath]) {
+				continue
+			}
+			if comment.Context != nil && comment.ContextStartsAtLine != nil {
+				found, err := live.Relocate(&comment, newFilesFS, oldFilesFS)
+				if err != nil {
+					return fmt.Errorf("failed to relocate comment: %w", err)
+				}
+				if !found {
+					s.logger.Info("commented lines are not part of the change, keeping comment in workspace",
+						zap.String("comment_id", string(comment.ID)),
+						zap.Stringer("change_id", changeID),
+					)
+					continue
+				}
+			}
+		}
+
+		if err := s.moveToChange(comment, changeID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) moveToChange(comment comments.Comment, changeID change.ID) error {
+	comment.ChangeID = &changeID
+	if err := s.commentRepo.Update(comment); err != nil {
+		return fmt.Errorf("failed to update comment: %w", err)
+	}
+
+	replies, err := s.commentRepo.GetByParent(comment.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get replies: %w", err)
+	}
+	for _, reply := range replies {
+		reply.ChangeID = &changeID
+		if err := s.commentRepo.Update(reply); err != nil {
+			return fmt.Errorf("failed to update reply: %w", err)
 		}
 	}
 	return nil
@@ -0,0 +1,108 @@
+package comments
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// suggestionContextLines is the number of unchanged lines to include before and after the suggested lines in a patch
+const suggestionContextLines = 3
+
+var (
+	ErrNoSuggestion       = errors.New("comment has no suggestion")
+	ErrSuggestionConflict = errors.New("the suggested lines have been changed")
+)
+
+// HasSuggestion returns true if the comment suggests replacement text for the commented lines.
+func (c *Comment) HasSuggestion() bool {
+	return c.Suggestion != nil
+}
+
+// SuggestionPatch returns a patch that replaces the lines LineStart..LineEnd of the file with the suggestion of the
+// comment, in a format that can be applied with "git apply".
+//
+// If the lines in fileContents are not the same as when the suggestion was made, ErrSuggestionConflict is returned.
+func (c *Comment) SuggestionPatch(fileContents string) ([]byte, error) {
+	if c.Suggestion == nil || c.SuggestionOriginal == nil {
+		return nil, ErrNoSuggestion
+	}
+
+	lines := splitLines(fileContents)
+	if c.LineStart < 1 || c.LineEnd < c.LineStart || c.LineEnd > len(lines) {
+		return nil, ErrSuggestionConflict
+	}
+
+	removed := lines[c.LineStart-1 : c.LineEnd]
+	if strings.Join(trimNewlines(removed), "\n") != strings.TrimSuffix(*c.SuggestionOriginal, "\n") {
+		return nil, ErrSuggestionConflict
+	}
+
+	var added []string
+	if *c.Suggestion != "" {
+		added = splitLines(strings.TrimSuffix(*c.Suggestion, "\n") + "\n")
+		// if the suggestion replaces the last line of the file, keep the file ending as it was
+		if c.LineEnd == len(lines) && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+			added[len(added)-1] = strings.TrimSuffix(added[len(added)-1], "\n")
+		}
+	}
+
+	contextStart := c.LineStart - suggestionContextLines
+	if contextStart < 1 {
+		contextStart = 1
+	}
+	contextEnd := c.LineEnd + suggestionContextLines
+	if contextEnd > len(lines) {
+		contextEnd = len(lines)
+	}
+	before := lines[contextStart-1 : c.LineStart-1]
+	after := lines[c.LineEnd:contextEnd]
+
+	oldCount := len(before) + len(removed) + len(after)
+	newCount := len(before) + len(added) + len(after)
+	newStart := contextStart
+	if newCount == 0 {
+		newStart = contextStart - 1
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n", c.Path, c.Path)
+	fmt.Fprintf(&b, "--- a/%s\n", c.Path)
+	fmt.Fprintf(&b, "+++ b/%s\n", c.Path)
+	fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", contextStart, oldCount, newStart, newCount)
+	writePatchLines(&b, " ", before)
+	writePatchLines(&b, "-", removed)
+	writePatchLines(&b, "+", added)
+	writePatchLines(&b, " ", after)
+	return []byte(b.String()), nil
+}
+
+// splitLines splits s into lines, keeping the line endings
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func trimNewlines(lines []string) []string {
+	res := make([]string, 0, len(lines))
+	for _, line := range lines {
+		res = append(res, strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+	}
+	return res
+}
+
+func writePatchLines(b *strings.Builder, prefix string, lines []string) {
+	for _, line := range lines {
+		b.WriteString(prefix)
+		b.WriteString(line)
+		if !strings.HasSuffix(line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package comments
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestionPatch(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	file := "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\n"
+
+	cases := []struct {
+		name        string
+		file        string
+		lineStart   int
+		lineEnd     int
+		original    string
+		suggestion  string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:       "replace-line",
+			file:       file,
+			lineStart:  5,
+			lineEnd:    5,
+			original:   "five",
+			suggestion: "FIVE",
+			expected: "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -2,7 +2,7 @@\n" +
+				" two\n three\n four\n-five\n+FIVE\n six\n seven\n eight\n",
+		},
+		{
+			name:       "replace-lines-at-start",
+			file:       file,
+			lineStart:  1,
+			lineEnd:    2,
+			original:   "one\ntwo",
+			suggestion: "1\n2\n2.5\n",
+			expected: "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1,5 +1,6 @@\n" +
+				"-one\n-two\n+1\n+2\n+2.5\n three\n four\n five\n",
+		},
+		{
+			name:       "remove-line",
+			file:       file,
+			lineStart:  8,
+			lineEnd:    8,
+			original:   "eight",
+			suggestion: "",
+			expected: "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -5,4 +5,3 @@\n" +
+				" five\n six\n seven\n-eight\n",
+		},
+		{
+			name:       "no-newline-at-end",
+			file:       "one\ntwo",
+			lineStart:  2,
+			lineEnd:    2,
+			original:   "two",
+			suggestion: "TWO",
+			expected: "diff --git a/a.txt b/a.txt\n--- a/a.txt\n+++ b/a.txt\n@@ -1,2 +1,2 @@\n" +
+				" one\n-two\n\\ No newline at end of file\n+TWO\n\\ No newline at end of file\n",
+		},
+		{
+			name:        "changed",
+			file:        file,
+			lineStart:   5,
+			lineEnd:     5,
+			original:    "5",
+			suggestion:  "FIVE",
+			expectedErr: ErrSuggestionConflict,
+		},
+		{
+			name:        "removed",
+			file:        "one\n",
+			lineStart:   5,
+			lineEnd:     5,
+			original:    "five",
+			suggestion:  "FIVE",
+			expectedErr: ErrSuggestionConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &Comment{
+				Path:               "a.txt",
+				LineStart:          tc.lineStart,
+				LineEnd:            tc.lineEnd,
+				LineIsNew:          true,
+				Suggestion:         str(tc.suggestion),
+				SuggestionOriginal: str(tc.original),
+			}
+			patch, err := c.SuggestionPatch(tc.file)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, string(patch))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package vcs
 
 import (
 	"bufio"
+	"errors"
 	"io/fs"
 	"strings"
 
@@ -11,6 +12,8 @@ import (
 	"getsturdy.com/api/vcs/executor"
 )
 
+var ErrLinesNotFound = errors.New("lines not found")
+
 func GetContext(lineNumber int, lineIsNew bool, filePath string, oldFilePath *string, ws *workspaces.Workspace, executorProvider executor.Provider, snapshotRepo db_snapshots.Repository) (context string, startsAtLine int, err error) {
 	workspaceFS, err := live.WorkspaceFS(executorProvider, snapshotRepo, ws, lineIsNew)
 	if err != nil {
@@ -55,3 +58,33 @@ func GetContext(lineNumber int, lineIsNew bool, filePath string, oldFilePath *st
 	}
 	return res.String(), startsAtLine, nil
 }
+
+// GetLines returns the new lines lineStart..lineEnd (1-indexed, inclusive) of a file in the workspace, without the
+// trailing newline.
+func GetLines(lineStart, lineEnd int, filePath string, ws *workspaces.Workspace, executorProvider executor.Provider, snapshotRepo db_snapshots.Repository) (string, error) {
+	workspaceFS, err := live.WorkspaceFS(executorProvider, snapshotRepo, ws, true)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := workspaceFS.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	s := bufio.NewScanner(file)
+	var lines []string
+	for l := 1; s.Scan() && l <= lineEnd; l++ {
+		if l >= lineStart {
+			lines = append(lines, s.Text())
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	if len(lines) != lineEnd-lineStart+1 {
+		return "", ErrLinesNotFound
+	}
+	return strings.Join(lines, "\n"), nil
+}
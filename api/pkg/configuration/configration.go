@@ -5,12 +5,14 @@ import (
 	service_ci "getsturdy.com/api/pkg/ci/service"
 	"getsturdy.com/api/pkg/db"
 	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/emails/smtp"
 	"getsturdy.com/api/pkg/gitserver"
 	"getsturdy.com/api/pkg/http"
 	"getsturdy.com/api/pkg/logger"
 	"getsturdy.com/api/pkg/metrics"
 	"getsturdy.com/api/pkg/pprof"
 	"getsturdy.com/api/pkg/users/avatars/uploader"
+	"getsturdy.com/api/pkg/web"
 	"getsturdy.com/api/vcs/provider"
 
 	"github.com/jessevdk/go-flags"
@@ -27,6 +29,7 @@ type Base struct {
 	Pprof    *pprof.Configuration      `flags-group:"pprof" namespace:"pprof"`
 	Metrics  *metrics.Configuration    `flags-group:"metrics" namespace:"metrics"`
 	Logger   *logger.Configuration     `flags-group:"logger" namespace:"logger"`
+	Web      *web.Configuration        `flags-group:"web" namespace:"web" env-namespace:"STURDY_WEB"`
 }
 
 type Configuration struct {
@@ -34,6 +37,7 @@ type Configuration struct {
 
 	Analytics *proxy.Configuration    `flags-group:"analytics" namespace:"analytics"`
 	Avatars   *uploader.Configuration `flags-group:"avatars" namespace:"users.avatars"`
+	Emails    *smtp.Configuration     `flags-group:"emails" namespace:"emails" env-namespace:"STURDY_EMAILS"`
 }
 
 func New() (Configuration, error) {
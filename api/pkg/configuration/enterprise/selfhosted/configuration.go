@@ -3,7 +3,10 @@ package selfhosted
 import (
 	"getsturdy.com/api/pkg/analytics/proxy"
 	"getsturdy.com/api/pkg/configuration"
+	"getsturdy.com/api/pkg/emails/smtp"
 	"getsturdy.com/api/pkg/github/enterprise/config"
+	config_ldap "getsturdy.com/api/pkg/ldap/enterprise/selfhosted/config"
+	config_oidc "getsturdy.com/api/pkg/oidc/enterprise/selfhosted/config"
 	"getsturdy.com/api/pkg/users/avatars/uploader"
 
 	"github.com/jessevdk/go-flags"
@@ -12,9 +15,12 @@ import (
 type Configuration struct {
 	configuration.Base
 
-	GitHub    *config.GitHubAppConfig `flags-group:"github-app" namespace:"github-app" env-namespace:"STURDY_GITHUB_APP"`
-	Analytics *proxy.Configuration    `flags-group:"analytics" namespace:"analytics"`
-	Avatars   *uploader.Configuration `flags-group:"avatars" namespace:"users.avatars"`
+	GitHub    *config.GitHubAppConfig    `flags-group:"github-app" namespace:"github-app" env-namespace:"STURDY_GITHUB_APP"`
+	Analytics *proxy.Configuration       `flags-group:"analytics" namespace:"analytics"`
+	Avatars   *uploader.Configuration    `flags-group:"avatars" namespace:"users.avatars"`
+	Emails    *smtp.Configuration        `flags-group:"emails" namespace:"emails" env-namespace:"STURDY_EMAILS"`
+	OIDC      *config_oidc.Configuration `flags-group:"oidc" namespace:"auth.oidc" env-namespace:"STURDY_OIDC"`
+	LDAP      *config_ldap.Configuration `flags-group:"ldap" namespace:"auth.ldap" env-namespace:"STURDY_LDAP"`
 }
 
 func New() (Configuration, error) {
@@ -10,6 +10,7 @@ import (
 	"getsturdy.com/api/pkg/configuration/flags"
 	"getsturdy.com/api/pkg/db"
 	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/emails/smtp"
 	"getsturdy.com/api/pkg/gitserver"
 	"getsturdy.com/api/pkg/http"
 	"getsturdy.com/api/pkg/internal/sturdytest"
@@ -17,6 +18,7 @@ import (
 	"getsturdy.com/api/pkg/metrics"
 	"getsturdy.com/api/pkg/pprof"
 	"getsturdy.com/api/pkg/users/avatars/uploader"
+	"getsturdy.com/api/pkg/web"
 	"getsturdy.com/api/vcs/provider"
 )
 
@@ -67,10 +69,12 @@ func TestingModule(c *di.Container) {
 				Pprof:   &pprof.Configuration{Addr: pprofAddr},
 				Metrics: &metrics.Configuration{Addr: metricsAddr},
 				Logger:  &logger.Configuration{},
+				Web:     &web.Configuration{URL: "http://localhost:8080"},
 			},
 
 			Analytics: &proxy.Configuration{Disable: true},
 			Avatars:   &uploader.Configuration{},
+			Emails:    &smtp.Configuration{},
 		}
 	})
 }
@@ -0,0 +1,3 @@
+DROP INDEX snapshots_codebase_id_workspace_id_created_at_idx;
+
+DROP TABLE snapshot_retention_policies;
@@ -0,0 +1,10 @@
+CREATE TABLE snapshot_retention_policies (
+    codebase_id              TEXT                     NOT NULL PRIMARY KEY,
+    keep_last_per_workspace  INTEGER                  NOT NULL,
+    keep_newer_than_seconds  BIGINT                   NOT NULL,
+    keep_daily_for_days      INTEGER                  NOT NULL,
+    updated_at               TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_by               TEXT                     NOT NULL
+);
+
+CREATE INDEX snapshots_codebase_id_workspace_id_created_at_idx ON snapshots (codebase_id, workspace_id, created_at DESC) WHERE deleted_at IS NULL;
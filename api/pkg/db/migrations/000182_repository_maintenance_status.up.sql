@@ -0,0 +1,13 @@
+CREATE TABLE repository_maintenance_status (
+    id                  TEXT                     NOT NULL PRIMARY KEY,
+    codebase_id         TEXT                     NOT NULL,
+    view_id             TEXT,
+    size_bytes          BIGINT                   NOT NULL,
+    objects_count       BIGINT                   NOT NULL,
+    packs_count         BIGINT                   NOT NULL,
+    last_maintenance_at TIMESTAMP WITH TIME ZONE,
+    quarantined_at      TIMESTAMP WITH TIME ZONE,
+    fsck_error          TEXT
+);
+
+CREATE INDEX repository_maintenance_status_codebase_id_idx ON repository_maintenance_status (codebase_id);
@@ -0,0 +1,9 @@
+DROP TABLE changelog_materializations;
+
+DROP INDEX changes_search_idx;
+
+DROP INDEX changes_codebase_id_git_creator_email_idx;
+
+DROP INDEX changes_codebase_id_user_id_idx;
+
+DROP INDEX changes_codebase_id_sort_time_idx;
@@ -0,0 +1,22 @@
+CREATE INDEX changes_codebase_id_sort_time_idx
+    ON changes (codebase_id, (COALESCE(created_at, git_created_at, '1970-01-01 00:00:00+00'::TIMESTAMPTZ)) DESC, id DESC);
+
+CREATE INDEX changes_codebase_id_user_id_idx
+    ON changes (codebase_id, user_id);
+
+CREATE INDEX changes_codebase_id_git_creator_email_idx
+    ON changes (codebase_id, git_creator_email);
+
+CREATE INDEX changes_search_idx
+    ON changes USING GIN (to_tsvector('simple', COALESCE(title, '') || ' ' || COALESCE(updated_description, '')));
+
+CREATE TABLE changelog_materializations
+(
+    codebase_id    TEXT                     NOT NULL PRIMARY KEY,
+    -- head_commit_id is the trunk commit that history has been materialized from
+    head_commit_id TEXT                     NOT NULL,
+    -- tail_commit_id is the oldest commit that has been materialized, or null if history has been
+    -- materialized all the way to the root commit
+    tail_commit_id TEXT,
+    updated_at     TIMESTAMP WITH TIME ZONE NOT NULL
+);
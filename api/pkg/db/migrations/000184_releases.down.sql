@@ -0,0 +1,2 @@
+DROP TABLE releases;
+DROP TABLE tags;
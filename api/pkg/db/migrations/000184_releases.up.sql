@@ -0,0 +1,27 @@
+CREATE TABLE tags (
+    id          TEXT                     NOT NULL PRIMARY KEY,
+    codebase_id TEXT                     NOT NULL,
+    name        TEXT                     NOT NULL,
+    change_id   TEXT                     NOT NULL,
+    commit_id   TEXT                     NOT NULL,
+    message     TEXT                     NOT NULL,
+    created_by  TEXT                     NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE UNIQUE INDEX tags_codebase_id_name_idx ON tags (codebase_id, name);
+
+CREATE TABLE releases (
+    id              TEXT                     NOT NULL PRIMARY KEY,
+    codebase_id     TEXT                     NOT NULL,
+    tag_id          TEXT                     NOT NULL REFERENCES tags (id),
+    previous_tag_id TEXT REFERENCES tags (id),
+    title           TEXT                     NOT NULL,
+    notes           TEXT                     NOT NULL,
+    created_by      TEXT                     NOT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at      TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX releases_tag_id_idx ON releases (tag_id);
+CREATE INDEX releases_codebase_id_idx ON releases (codebase_id);
@@ -0,0 +1,2 @@
+DROP TABLE auto_reverts;
+DROP TABLE auto_revert_configs;
@@ -0,0 +1,21 @@
+CREATE TABLE auto_revert_configs (
+    codebase_id      TEXT                     NOT NULL PRIMARY KEY,
+    enabled          BOOLEAN                  NOT NULL,
+    required_checks  TEXT[]                   NOT NULL,
+    on_call_group_id TEXT,
+    auto_land        BOOLEAN                  NOT NULL,
+    updated_by       TEXT,
+    updated_at       TIMESTAMP WITH TIME ZONE
+);
+
+CREATE TABLE auto_reverts (
+    id               TEXT                     NOT NULL PRIMARY KEY,
+    codebase_id      TEXT                     NOT NULL,
+    change_id        TEXT                     NOT NULL,
+    status_id        TEXT                     NOT NULL,
+    workspace_id     TEXT,
+    landed_change_id TEXT,
+    created_at       TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE UNIQUE INDEX auto_reverts_change_id_idx ON auto_reverts (change_id);
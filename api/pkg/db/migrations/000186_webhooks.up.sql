@@ -0,0 +1,37 @@
+CREATE TABLE webhooks (
+    id          TEXT                     NOT NULL PRIMARY KEY,
+    codebase_id TEXT                     NOT NULL,
+    url         TEXT                     NOT NULL,
+    secret      TEXT                     NOT NULL,
+    events      TEXT[]                   NOT NULL,
+    version     INTEGER                  NOT NULL,
+    active      BOOLEAN                  NOT NULL,
+    created_by  TEXT                     NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at  TIMESTAMP WITH TIME ZONE,
+    deleted_at  TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX webhooks_codebase_id_idx ON webhooks (codebase_id);
+
+CREATE TABLE webhook_deliveries (
+    id                   TEXT                     NOT NULL PRIMARY KEY,
+    webhook_id           TEXT                     NOT NULL,
+    codebase_id          TEXT                     NOT NULL,
+    event_id             TEXT                     NOT NULL,
+    event_type           TEXT                     NOT NULL,
+    payload              TEXT                     NOT NULL,
+    status               TEXT                     NOT NULL,
+    attempts             INTEGER                  NOT NULL,
+    response_status_code INTEGER,
+    response_body        TEXT,
+    error                TEXT,
+    duration_ms          INTEGER,
+    redelivery_of        TEXT,
+    created_at           TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_attempt_at      TIMESTAMP WITH TIME ZONE,
+    next_attempt_at      TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX webhook_deliveries_webhook_id_created_at_idx ON webhook_deliveries (webhook_id, created_at DESC);
+CREATE INDEX webhook_deliveries_next_attempt_at_idx ON webhook_deliveries (next_attempt_at) WHERE next_attempt_at IS NOT NULL;
@@ -0,0 +1,2 @@
+DROP TABLE chat_webhook_posts;
+DROP TABLE chat_webhooks;
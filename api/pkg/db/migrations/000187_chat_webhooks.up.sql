@@ -0,0 +1,23 @@
+CREATE TABLE chat_webhooks (
+    id          TEXT                     NOT NULL PRIMARY KEY,
+    user_id     TEXT,
+    codebase_id TEXT,
+    provider    TEXT                     NOT NULL,
+    url         TEXT                     NOT NULL,
+    created_by  TEXT                     NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL,
+    deleted_at  TIMESTAMP WITH TIME ZONE,
+    CHECK ((user_id IS NULL) <> (codebase_id IS NULL))
+);
+
+CREATE INDEX chat_webhooks_user_id_idx ON chat_webhooks (user_id) WHERE user_id IS NOT NULL;
+CREATE INDEX chat_webhooks_codebase_id_idx ON chat_webhooks (codebase_id) WHERE codebase_id IS NOT NULL;
+
+-- chat_webhook_posts is used to post each notification to a codebase webhook only once, even if it has many recipients
+CREATE TABLE chat_webhook_posts (
+    webhook_id        TEXT                     NOT NULL,
+    notification_type TEXT                     NOT NULL,
+    reference_id      TEXT                     NOT NULL,
+    created_at        TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (webhook_id, notification_type, reference_id)
+);
@@ -0,0 +1,5 @@
+DROP INDEX notifications_pending_digest_idx;
+ALTER TABLE notifications
+    DROP COLUMN digest_id;
+DROP TABLE notification_digests;
+DROP TABLE notification_digest_settings;
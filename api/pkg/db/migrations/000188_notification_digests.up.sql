@@ -0,0 +1,20 @@
+CREATE TABLE notification_digest_settings (
+    user_id      TEXT                     NOT NULL PRIMARY KEY,
+    frequency    TEXT                     NOT NULL,
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_sent_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX notification_digest_settings_frequency_idx ON notification_digest_settings (frequency);
+
+CREATE TABLE notification_digests (
+    id         TEXT                     NOT NULL PRIMARY KEY,
+    user_id    TEXT                     NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- digest_id is set on the notifications that were included in a digest email
+ALTER TABLE notifications
+    ADD COLUMN digest_id TEXT;
+
+CREATE INDEX notifications_pending_digest_idx ON notifications (user_id, created_at) WHERE digest_id IS NULL AND archived_at IS NULL;
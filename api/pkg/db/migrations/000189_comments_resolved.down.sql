@@ -0,0 +1,3 @@
+ALTER TABLE comments
+    DROP COLUMN resolved_at,
+    DROP COLUMN resolved_by;
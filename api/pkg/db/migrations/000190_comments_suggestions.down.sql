@@ -0,0 +1,5 @@
+ALTER TABLE comments
+    DROP COLUMN suggestion,
+    DROP COLUMN suggestion_original,
+    DROP COLUMN suggestion_applied_at,
+    DROP COLUMN suggestion_applied_by;
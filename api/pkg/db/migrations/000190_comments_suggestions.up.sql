@@ -0,0 +1,5 @@
+ALTER TABLE comments
+    ADD COLUMN suggestion TEXT,
+    ADD COLUMN suggestion_original TEXT,
+    ADD COLUMN suggestion_applied_at TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN suggestion_applied_by TEXT;
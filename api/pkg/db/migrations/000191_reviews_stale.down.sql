@@ -0,0 +1,5 @@
+ALTER TABLE workspace_reviews
+    DROP COLUMN snapshot_id;
+
+ALTER TABLE codebases
+    DROP COLUMN dismiss_stale_approvals;
@@ -0,0 +1,5 @@
+ALTER TABLE workspace_reviews
+    ADD COLUMN snapshot_id TEXT;
+
+ALTER TABLE codebases
+    ADD COLUMN dismiss_stale_approvals BOOLEAN NOT NULL DEFAULT FALSE;
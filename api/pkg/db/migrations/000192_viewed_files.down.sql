@@ -0,0 +1 @@
+DROP TABLE viewed_files;
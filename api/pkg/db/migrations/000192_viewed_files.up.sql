@@ -0,0 +1,7 @@
+CREATE TABLE viewed_files (
+    user_id      TEXT                     NOT NULL,
+    path         TEXT                     NOT NULL,
+    content_hash TEXT                     NOT NULL,
+    viewed_at    TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, path, content_hash)
+);
@@ -0,0 +1,40 @@
+CREATE TABLE scim_tokens (
+    id              TEXT                     NOT NULL PRIMARY KEY,
+    organization_id TEXT                     NOT NULL,
+    name            TEXT                     NOT NULL,
+    hash            BYTEA                    NOT NULL,
+    created_by      TEXT                     NOT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at    TIMESTAMP WITH TIME ZONE,
+    revoked_at      TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX scim_tokens_organization_id_idx ON scim_tokens (organization_id);
+
+-- scim_users are the users that are provisioned to an organization by its identity provider
+CREATE TABLE scim_users (
+    organization_id TEXT                     NOT NULL,
+    user_id         TEXT                     NOT NULL,
+    external_id     TEXT,
+    active          BOOLEAN                  NOT NULL,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE TABLE scim_groups (
+    id              TEXT                     NOT NULL PRIMARY KEY,
+    organization_id TEXT                     NOT NULL,
+    display_name    TEXT                     NOT NULL,
+    external_id     TEXT,
+    created_at      TIMESTAMP WITH TIME ZONE NOT NULL,
+    updated_at      TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE UNIQUE INDEX scim_groups_organization_id_display_name_idx ON scim_groups (organization_id, display_name);
+
+CREATE TABLE scim_group_members (
+    group_id TEXT NOT NULL,
+    user_id  TEXT NOT NULL,
+    PRIMARY KEY (group_id, user_id)
+);
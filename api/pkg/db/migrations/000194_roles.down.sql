@@ -0,0 +1,5 @@
+ALTER TABLE codebase_users
+    DROP COLUMN role;
+
+ALTER TABLE organization_members
+    DROP COLUMN role;
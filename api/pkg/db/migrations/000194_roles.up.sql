@@ -0,0 +1,19 @@
+ALTER TABLE organization_members
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'member';
+
+-- the creators of organizations are their owners
+UPDATE organization_members
+SET role = 'owner'
+FROM organizations
+WHERE organizations.id = organization_members.organization_id
+  AND organizations.created_by = organization_members.user_id;
+
+ALTER TABLE codebase_users
+    ADD COLUMN role TEXT NOT NULL DEFAULT 'member';
+
+-- codebases that are not in an organization have no one else to manage them
+UPDATE codebase_users
+SET role = 'admin'
+FROM codebases
+WHERE codebases.id = codebase_users.codebase_id
+  AND codebases.organization_id IS NULL;
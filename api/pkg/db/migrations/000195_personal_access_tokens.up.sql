@@ -0,0 +1,15 @@
+-- personal_access_tokens authenticate a user to the API, with the permissions of the user limited to the scopes of
+-- the token
+CREATE TABLE personal_access_tokens (
+    id           TEXT                     NOT NULL PRIMARY KEY,
+    user_id      TEXT                     NOT NULL,
+    name         TEXT                     NOT NULL,
+    hash         BYTEA                    NOT NULL,
+    scopes       TEXT[]                   NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at   TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    revoked_at   TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX personal_access_tokens_user_id_idx ON personal_access_tokens (user_id);
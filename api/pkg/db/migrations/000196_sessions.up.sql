@@ -0,0 +1,14 @@
+-- sessions are the signed in sessions of users, they're keyed by the id of the session's auth token
+CREATE TABLE sessions (
+    id           TEXT                     NOT NULL PRIMARY KEY,
+    user_id      TEXT                     NOT NULL,
+    device       TEXT                     NOT NULL,
+    ip           TEXT                     NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at   TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+CREATE INDEX sessions_revoked_at_idx ON sessions (revoked_at) WHERE revoked_at IS NOT NULL;
@@ -0,0 +1,5 @@
+DROP TABLE two_factor_recovery_codes;
+DROP TABLE two_factor_totp;
+
+ALTER TABLE organizations
+    DROP COLUMN require_two_factor;
@@ -0,0 +1,22 @@
+ALTER TABLE organizations
+    ADD COLUMN require_two_factor BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- two_factor_totp are the authenticator apps that users have enrolled as their second factor
+CREATE TABLE two_factor_totp (
+    user_id        TEXT                     NOT NULL PRIMARY KEY,
+    secret         TEXT                     NOT NULL,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+    confirmed_at   TIMESTAMP WITH TIME ZONE,
+    last_used_step BIGINT                   NOT NULL DEFAULT 0
+);
+
+-- two_factor_recovery_codes are single use codes that users can sign in with if they lose their authenticator
+CREATE TABLE two_factor_recovery_codes (
+    id         TEXT                     NOT NULL PRIMARY KEY,
+    user_id    TEXT                     NOT NULL,
+    hash       BYTEA                    NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at    TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX two_factor_recovery_codes_user_id_idx ON two_factor_recovery_codes (user_id);
@@ -0,0 +1,4 @@
+DROP INDEX jwt_keys_expires_at_idx;
+
+ALTER TABLE jwt_keys
+    DROP COLUMN expires_at;
@@ -0,0 +1,11 @@
+-- expires_at is when all tokens signed with the key have expired, and the key can be deleted
+ALTER TABLE jwt_keys
+    ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+
+-- keys from before rotation have signed tokens until now, that are valid for at most 31 days
+UPDATE jwt_keys SET expires_at = NOW() + INTERVAL '31 days';
+
+ALTER TABLE jwt_keys
+    ALTER COLUMN expires_at SET NOT NULL;
+
+CREATE INDEX jwt_keys_expires_at_idx ON jwt_keys (expires_at);
@@ -0,0 +1,11 @@
+DROP TABLE servicetoken_usages;
+
+DROP INDEX servicetokens_codebase_id_idx;
+
+ALTER TABLE servicetokens
+    DROP COLUMN capabilities,
+    DROP COLUMN allowed_ips,
+    DROP COLUMN created_by,
+    DROP COLUMN expires_at,
+    DROP COLUMN previous_hash,
+    DROP COLUMN previous_hash_expires_at;
@@ -0,0 +1,22 @@
+-- capabilities limit what a service token can be used for, tokens from before capabilities were used to fetch the ci
+-- repository and to post statuses
+ALTER TABLE servicetokens
+    ADD COLUMN capabilities             TEXT[]                   NOT NULL DEFAULT '{ci:fetch,statuses:write}',
+    ADD COLUMN allowed_ips              TEXT[]                   NOT NULL DEFAULT '{}',
+    ADD COLUMN created_by               TEXT,
+    ADD COLUMN expires_at               TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN previous_hash            BYTEA,
+    ADD COLUMN previous_hash_expires_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE servicetokens
+    ALTER COLUMN capabilities DROP DEFAULT;
+
+CREATE INDEX servicetokens_codebase_id_idx ON servicetokens (codebase_id);
+
+-- servicetoken_usages is when a service token was last used for each of its capabilities
+CREATE TABLE servicetoken_usages (
+    token_id     TEXT                     NOT NULL,
+    capability   TEXT                     NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (token_id, capability)
+);
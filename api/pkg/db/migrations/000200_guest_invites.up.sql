@@ -0,0 +1,16 @@
+CREATE TABLE guest_invites (
+    id           TEXT                     NOT NULL PRIMARY KEY,
+    codebase_id  TEXT                     NOT NULL,
+    workspace_id TEXT                     NOT NULL,
+    hash         BYTEA                    NOT NULL,
+    capabilities TEXT[]                   NOT NULL,
+    created_by   TEXT                     NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_by  TEXT,
+    accepted_at  TIMESTAMP WITH TIME ZONE,
+    revoked_at   TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX guest_invites_workspace_id_idx ON guest_invites (workspace_id);
+CREATE INDEX guest_invites_accepted_by_workspace_id_idx ON guest_invites (accepted_by, workspace_id);
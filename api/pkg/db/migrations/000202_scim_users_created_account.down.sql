@@ -0,0 +1,2 @@
+ALTER TABLE scim_users
+    DROP COLUMN created_account;
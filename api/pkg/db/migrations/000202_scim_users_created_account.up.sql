@@ -0,0 +1,4 @@
+-- created_account is true for the users whose Sturdy account was created by the provisioning, only those accounts can
+-- be updated by the organization
+ALTER TABLE scim_users
+    ADD COLUMN created_account BOOLEAN NOT NULL DEFAULT FALSE;
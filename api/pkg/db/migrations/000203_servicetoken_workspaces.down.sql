@@ -0,0 +1 @@
+DROP TABLE servicetoken_workspaces;
@@ -0,0 +1,8 @@
+-- servicetoken_workspaces are the workspaces that were created with a service token, tokens can only write to the
+-- workspaces that they created
+CREATE TABLE servicetoken_workspaces (
+    token_id     TEXT                     NOT NULL,
+    workspace_id TEXT                     NOT NULL,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (token_id, workspace_id)
+);
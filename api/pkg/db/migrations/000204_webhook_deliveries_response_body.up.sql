@@ -0,0 +1,4 @@
+-- the response bodies of the webhooks are not saved anymore, as they could be used to read any url that the server
+-- can reach
+ALTER TABLE webhook_deliveries
+    DROP COLUMN response_body;
@@ -0,0 +1 @@
+DROP INDEX changes_codebase_id_commit_id_idx;
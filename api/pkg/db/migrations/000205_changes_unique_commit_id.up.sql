@@ -0,0 +1,44 @@
+-- commits could be imported as changes more than once, when the changelog was imported concurrently. Keep the change
+-- that was created by Sturdy if there is one (otherwise the oldest), and point everything at it.
+CREATE TEMPORARY TABLE duplicate_changes AS
+SELECT id, keep_id
+FROM (SELECT id,
+             FIRST_VALUE(id) OVER (
+                 PARTITION BY codebase_id, commit_id
+                 ORDER BY user_id IS NULL, COALESCE(created_at, git_created_at), id
+                 ) AS keep_id
+      FROM changes
+      WHERE commit_id IS NOT NULL) AS ranked
+WHERE id <> keep_id;
+
+UPDATE comments SET change_id = d.keep_id FROM duplicate_changes d WHERE comments.change_id = d.id;
+UPDATE tags SET change_id = d.keep_id FROM duplicate_changes d WHERE tags.change_id = d.id;
+-- a change can only be auto-reverted once, keep the auto revert of the kept change if there is one (otherwise the
+-- oldest) and delete the others before repointing them.
+DELETE
+FROM auto_reverts
+WHERE id IN (SELECT id
+             FROM (SELECT a.id,
+                          ROW_NUMBER() OVER (
+                              PARTITION BY COALESCE(d.keep_id, a.change_id)
+                              ORDER BY d.id IS NOT NULL, a.created_at, a.id
+                              ) AS n
+                   FROM auto_reverts a
+                            LEFT JOIN duplicate_changes d ON a.change_id = d.id
+                   WHERE a.change_id IN (SELECT id FROM duplicate_changes)
+                      OR a.change_id IN (SELECT keep_id FROM duplicate_changes)) AS ranked
+             WHERE n > 1);
+UPDATE auto_reverts SET change_id = d.keep_id FROM duplicate_changes d WHERE auto_reverts.change_id = d.id;
+UPDATE auto_reverts SET landed_change_id = d.keep_id FROM duplicate_changes d WHERE auto_reverts.landed_change_id = d.id;
+UPDATE workspaces SET head_change_id = d.keep_id FROM duplicate_changes d WHERE workspaces.head_change_id = d.id;
+UPDATE changes SET parent_change_id = d.keep_id FROM duplicate_changes d WHERE changes.parent_change_id = d.id;
+
+DELETE FROM changes USING duplicate_changes d WHERE changes.id = d.id;
+
+DROP TABLE duplicate_changes;
+
+-- dropped together with the commit_id column in 000073
+DROP INDEX IF EXISTS changes_codebase_id_commit_id_idx;
+
+CREATE UNIQUE INDEX changes_codebase_id_commit_id_idx
+    ON changes (codebase_id, commit_id);
@@ -0,0 +1,10 @@
+-- oidc_identities link the users at the OpenID Connect provider to their Sturdy users, so that users that signed in with
+-- an email that the provider had not verified can sign in again, without their account being looked up by email
+CREATE TABLE oidc_identities (
+    issuer         TEXT                     NOT NULL,
+    subject        TEXT                     NOT NULL,
+    user_id        TEXT                     NOT NULL,
+    email_verified BOOLEAN                  NOT NULL,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (issuer, subject)
+);
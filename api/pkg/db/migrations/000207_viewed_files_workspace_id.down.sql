@@ -0,0 +1,15 @@
+ALTER TABLE viewed_files
+    DROP CONSTRAINT viewed_files_pkey;
+
+DELETE FROM viewed_files a
+    USING viewed_files b
+WHERE a.user_id = b.user_id
+  AND a.path = b.path
+  AND a.content_hash = b.content_hash
+  AND a.workspace_id > b.workspace_id;
+
+ALTER TABLE viewed_files
+    DROP COLUMN workspace_id;
+
+ALTER TABLE viewed_files
+    ADD PRIMARY KEY (user_id, path, content_hash);
@@ -0,0 +1,12 @@
+-- the same file can be reviewed in many workspaces, marks are per workspace. Existing marks can't be attributed to a
+-- workspace, and are cleared.
+TRUNCATE TABLE viewed_files;
+
+ALTER TABLE viewed_files
+    ADD COLUMN workspace_id TEXT NOT NULL;
+
+ALTER TABLE viewed_files
+    DROP CONSTRAINT viewed_files_pkey;
+
+ALTER TABLE viewed_files
+    ADD PRIMARY KEY (user_id, workspace_id, path, content_hash);
@@ -0,0 +1 @@
+DROP TABLE changelog_materialization_queue;
@@ -0,0 +1,6 @@
+-- codebases that are queued to have their changelog materialized, so that they are only queued once at a time
+CREATE TABLE changelog_materialization_queue
+(
+    codebase_id TEXT                     NOT NULL PRIMARY KEY,
+    queued_at   TIMESTAMP WITH TIME ZONE NOT NULL
+);
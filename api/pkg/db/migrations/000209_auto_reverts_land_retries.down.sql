@@ -0,0 +1,5 @@
+DROP INDEX auto_reverts_next_land_attempt_at_idx;
+
+ALTER TABLE auto_reverts
+    DROP COLUMN land_attempts,
+    DROP COLUMN next_land_attempt_at;
@@ -0,0 +1,5 @@
+ALTER TABLE auto_reverts
+    ADD COLUMN land_attempts        INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN next_land_attempt_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX auto_reverts_next_land_attempt_at_idx ON auto_reverts (next_land_attempt_at) WHERE next_land_attempt_at IS NOT NULL;
@@ -0,0 +1 @@
+DROP TABLE codebase_maintenance_schedule;
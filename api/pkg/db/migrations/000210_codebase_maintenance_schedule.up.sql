@@ -0,0 +1,6 @@
+-- when codebases were last scheduled for maintenance, so that each codebase is only scheduled by one replica
+CREATE TABLE codebase_maintenance_schedule
+(
+    codebase_id  TEXT                     NOT NULL PRIMARY KEY,
+    scheduled_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
@@ -0,0 +1,2 @@
+ALTER TABLE codebases_garbage_collection_status
+    DROP COLUMN storage_used_bytes;
@@ -0,0 +1,8 @@
+DROP INDEX scim_tokens_secret_hash_idx;
+
+-- tokens without a bcrypt hash can't be used without the secret_hash
+DELETE FROM scim_tokens WHERE hash IS NULL;
+
+ALTER TABLE scim_tokens
+    DROP COLUMN secret_hash,
+    ALTER COLUMN hash SET NOT NULL;
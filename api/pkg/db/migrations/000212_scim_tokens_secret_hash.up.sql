@@ -0,0 +1,7 @@
+-- secret_hash is the SHA-256 of the secret of a token, tokens are looked up by it. Tokens from before it was used only
+-- have the bcrypt hash, and get the secret_hash the first time that they are used.
+ALTER TABLE scim_tokens
+    ADD COLUMN secret_hash BYTEA,
+    ALTER COLUMN hash DROP NOT NULL;
+
+CREATE UNIQUE INDEX scim_tokens_secret_hash_idx ON scim_tokens (secret_hash);
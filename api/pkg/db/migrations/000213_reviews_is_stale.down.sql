@@ -0,0 +1,2 @@
+ALTER TABLE workspace_reviews
+    DROP COLUMN is_stale;
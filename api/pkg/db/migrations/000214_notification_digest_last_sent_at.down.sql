@@ -0,0 +1,2 @@
+ALTER TABLE notification_digest_settings
+    ALTER COLUMN last_sent_at DROP NOT NULL;
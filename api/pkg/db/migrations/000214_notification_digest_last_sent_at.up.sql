@@ -0,0 +1,6 @@
+-- the next digest includes the notifications that were created after last_sent_at
+UPDATE notification_digest_settings
+SET last_sent_at = GREATEST(COALESCE(last_sent_at, updated_at), updated_at);
+
+ALTER TABLE notification_digest_settings
+    ALTER COLUMN last_sent_at SET NOT NULL;
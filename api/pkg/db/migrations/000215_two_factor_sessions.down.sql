@@ -0,0 +1,8 @@
+DROP TABLE two_factor_used_challenges;
+
+ALTER TABLE two_factor_totp
+    DROP COLUMN attempts,
+    DROP COLUMN attempts_since;
+
+ALTER TABLE sessions
+    DROP COLUMN two_factor_verified_at;
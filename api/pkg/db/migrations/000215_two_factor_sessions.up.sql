@@ -0,0 +1,14 @@
+-- sessions in which the user has entered their second factor
+ALTER TABLE sessions
+    ADD COLUMN two_factor_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- the attempts to verify a code of the user, in the window that started at attempts_since
+ALTER TABLE two_factor_totp
+    ADD COLUMN attempts       INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN attempts_since TIMESTAMP WITH TIME ZONE;
+
+-- two_factor_used_challenges are the two-factor sign in tokens that have been used, they can only be used once
+CREATE TABLE two_factor_used_challenges (
+    id         TEXT                     NOT NULL PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
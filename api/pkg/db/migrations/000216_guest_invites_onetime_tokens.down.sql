@@ -0,0 +1,5 @@
+ALTER TABLE guest_invites
+    ADD COLUMN hash BYTEA NOT NULL DEFAULT '';
+
+ALTER TABLE onetime_tokens
+    DROP COLUMN expires_at;
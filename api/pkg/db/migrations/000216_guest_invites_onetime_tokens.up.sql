@@ -0,0 +1,7 @@
+-- tokens that are valid for longer than ten minutes
+ALTER TABLE onetime_tokens
+    ADD COLUMN expires_at TIMESTAMP WITH TIME ZONE;
+
+-- the secrets of guest invites are one-time tokens, invites that have not been accepted yet can no longer be accepted
+ALTER TABLE guest_invites
+    DROP COLUMN hash;
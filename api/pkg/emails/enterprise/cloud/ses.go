@@ -24,6 +24,19 @@ func NewSES(s *session.Session) *sesClient {
 }
 
 func (s *sesClient) Send(ctx context.Context, msg *emails.Email) error {
+	body := &ses.Body{
+		Html: &ses.Content{
+			Charset: aws.String("UTF-8"),
+			Data:    aws.String(msg.Html),
+		},
+	}
+	if msg.Text != "" {
+		body.Text = &ses.Content{
+			Charset: aws.String("UTF-8"),
+			Data:    aws.String(msg.Text),
+		}
+	}
+
 	if _, err := s.sesClient.SendEmailWithContext(ctx, &ses.SendEmailInput{
 		Destination: &ses.Destination{ToAddresses: []*string{aws.String(msg.To)}},
 		Source:      aws.String("Sturdy <no-reply@getsturdy.com>"),
@@ -32,12 +45,7 @@ func (s *sesClient) Send(ctx context.Context, msg *emails.Email) error {
 				Charset: aws.String("UTF-8"),
 				Data:    &msg.Subject,
 			},
-			Body: &ses.Body{
-				Html: &ses.Content{
-					Charset: aws.String("UTF-8"),
-					Data:    aws.String(msg.Html),
-				},
-			},
+			Body: body,
 		},
 	}); err != nil {
 		return fmt.Errorf("failed to send email to ses: %w", err)
@@ -5,9 +5,9 @@ package module
 
 import (
 	"getsturdy.com/api/pkg/di"
-	"getsturdy.com/api/pkg/emails"
+	"getsturdy.com/api/pkg/emails/smtp"
 )
 
 func Module(c *di.Container) {
-	c.Register(emails.NewDisabled)
+	c.Register(smtp.New)
 }
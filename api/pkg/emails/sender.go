@@ -8,6 +8,8 @@ type Email struct {
 	To      string
 	Subject string
 	Html    string
+	// Text is the plain text alternative of Html. It's optional.
+	Text string
 }
 
 type Sender interface {
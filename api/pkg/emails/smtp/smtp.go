@@ -0,0 +1,212 @@
+package smtp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"getsturdy.com/api/pkg/emails"
+
+	"github.com/cenkalti/backoff/v4"
+	"go.uber.org/zap"
+)
+
+const (
+	TLSNone     = "none"
+	TLSStartTLS = "starttls"
+	TLSImplicit = "tls"
+)
+
+var (
+	errNoStartTLS  = errors.New("smtp server does not support STARTTLS")
+	errAuthRefused = errors.New("refused to authenticate")
+)
+
+var (
+	// initialRetryInterval is the time to wait before the first retry of a failed delivery
+	initialRetryInterval = time.Second
+	// dialTimeout is the max time to wait for a connection to the smtp server
+	dialTimeout = 30 * time.Second
+)
+
+type Configuration struct {
+	Addr               string `long:"smtp-addr" description:"Address (host:port) of the SMTP server to send emails with, emails are not sent if empty" env:"SMTP_ADDR"`
+	Username           string `long:"smtp-username" description:"Username to authenticate to the SMTP server with" env:"SMTP_USERNAME"`
+	Password           string `long:"smtp-password" description:"Password to authenticate to the SMTP server with, prefer the environment variable to keep it out of the process arguments" env:"SMTP_PASSWORD"`
+	TLS                string `long:"smtp-tls" description:"How to secure the connection to the SMTP server" choice:"none" choice:"starttls" choice:"tls" default:"starttls" env:"SMTP_TLS"`
+	InsecureSkipVerify bool   `long:"smtp-insecure-skip-verify" description:"Do not verify the certificate of the SMTP server" env:"SMTP_INSECURE_SKIP_VERIFY"`
+	MaxRetries         uint64 `long:"smtp-max-retries" description:"Number of times to retry sending an email if the SMTP server fails temporarily" default:"3" env:"SMTP_MAX_RETRIES"`
+	From               string `long:"smtp-from" description:"Address to send emails from" default:"Sturdy <no-reply@getsturdy.com>" env:"SMTP_FROM"`
+}
+
+func New(cfg *Configuration, logger *zap.Logger) (emails.Sender, error) {
+	if cfg.Addr == "" {
+		return emails.NewDisabled(), nil
+	}
+
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp address: %w", err)
+	}
+
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+
+	switch cfg.TLS {
+	case TLSNone, TLSStartTLS, TLSImplicit:
+	case "":
+		cfg.TLS = TLSStartTLS
+	default:
+		return nil, fmt.Errorf("invalid smtp tls mode: %q", cfg.TLS)
+	}
+
+	return &client{
+		cfg:    cfg,
+		host:   host,
+		from:   from,
+		logger: logger.Named("smtp"),
+	}, nil
+}
+
+var _ emails.Sender = &client{}
+
+type client struct {
+	cfg    *Configuration
+	host   string
+	from   *mail.Address
+	logger *zap.Logger
+}
+
+func (c *client) Send(ctx context.Context, msg *emails.Email) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	data, err := buildMessage(c.from, to, msg, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to build message: %w", err)
+	}
+
+	exp := backoff.NewExponentialBackOff()
+	exp.InitialInterval = initialRetryInterval
+	b := backoff.WithContext(backoff.WithMaxRetries(exp, c.cfg.MaxRetries), ctx)
+
+	if err := backoff.Retry(func() error {
+		err := c.send(ctx, to.Address, data)
+		switch {
+		case err == nil:
+			return nil
+		case isPermanent(err):
+			return backoff.Permanent(err)
+		default:
+			c.logger.Warn("failed to send email, retrying", zap.Error(err))
+			return err
+		}
+	}, b); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+func (c *client) send(ctx context.Context, to string, data []byte) error {
+	tlsConfig := &tls.Config{
+		ServerName:         c.host,
+		InsecureSkipVerify: c.cfg.InsecureSkipVerify,
+	}
+
+	var dialer interface {
+		DialContext(context.Context, string, string) (net.Conn, error)
+	} = &net.Dialer{Timeout: dialTimeout}
+	if c.cfg.TLS == TLSImplicit {
+		dialer = &tls.Dialer{
+			NetDialer: &net.Dialer{Timeout: dialTimeout},
+			Config:    tlsConfig,
+		}
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", c.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed to set deadline: %w", err)
+		}
+	}
+
+	sc, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	defer sc.Close()
+
+	if c.cfg.TLS == TLSStartTLS {
+		if ok, _ := sc.Extension("STARTTLS"); !ok {
+			return errNoStartTLS
+		}
+		if err := sc.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+
+	if c.cfg.Username != "" {
+		if err := sc.Auth(&refusableAuth{smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.host)}); err != nil {
+			return fmt.Errorf("failed to authenticate: %w", err)
+		}
+	}
+
+	if err := sc.Mail(c.from.Address); err != nil {
+		return fmt.Errorf("MAIL FROM failed: %w", err)
+	}
+	if err := sc.Rcpt(to); err != nil {
+		return fmt.Errorf("RCPT TO failed: %w", err)
+	}
+
+	w, err := sc.Data()
+	if err != nil {
+		return fmt.Errorf("DATA failed: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return sc.Quit()
+}
+
+// refusableAuth wraps the errors of auths that refuse to start with errAuthRefused. PlainAuth refuses to send the
+// password over an unencrypted connection to anything but localhost, which won't change by retrying.
+type refusableAuth struct {
+	smtp.Auth
+}
+
+func (a *refusableAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	proto, toServer, err := a.Auth.Start(server)
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %s", errAuthRefused, err)
+	}
+	return proto, toServer, nil
+}
+
+// isPermanent returns true if the server rejected the email with a permanent (5xx) error, in which
+// case there is no point in retrying.
+func isPermanent(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 500
+	}
+	return errors.Is(err, errNoStartTLS) || errors.Is(err, errAuthRefused)
+}
@@ -0,0 +1,325 @@
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/emails"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func init() {
+	initialRetryInterval = time.Millisecond
+}
+
+type receivedMessage struct {
+	Auth string
+	From string
+	To   string
+	TLS  bool
+	Data []byte
+}
+
+// testServer is a minimal in-process smtp server that records received messages.
+type testServer struct {
+	t         *testing.T
+	ln        net.Listener
+	tlsConfig *tls.Config
+	implicit  bool
+	// reply, if set, can override the reply to a command on the n:th connection
+	reply func(n int, cmd string) string
+
+	mu          sync.Mutex
+	connections int
+	messages    []*receivedMessage
+}
+
+func newTestServer(t *testing.T, tlsMode string) *testServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &testServer{t: t}
+	if tlsMode != TLSNone {
+		s.tlsConfig = &tls.Config{Certificates: []tls.Certificate{selfSignedCertificate(t)}}
+	}
+	if tlsMode == TLSImplicit {
+		s.implicit = true
+		ln = tls.NewListener(ln, s.tlsConfig)
+	}
+	s.ln = ln
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.connections++
+			n := s.connections
+			s.mu.Unlock()
+			go s.serve(conn, n)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *testServer) serve(conn net.Conn, n int) {
+	defer func() { conn.Close() }()
+
+	tp := textproto.NewConn(conn)
+	msg := &receivedMessage{TLS: s.implicit}
+	_ = tp.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			_ = tp.PrintfLine("500 empty command")
+			continue
+		}
+		cmd := strings.ToUpper(fields[0])
+
+		if s.reply != nil {
+			if reply := s.reply(n, cmd); reply != "" {
+				_ = tp.PrintfLine("%s", reply)
+				continue
+			}
+		}
+
+		switch cmd {
+		case "EHLO", "HELO":
+			_ = tp.PrintfLine("250-localhost")
+			if s.tlsConfig != nil && !msg.TLS {
+				_ = tp.PrintfLine("250-STARTTLS")
+			}
+			_ = tp.PrintfLine("250 AUTH PLAIN")
+		case "STARTTLS":
+			_ = tp.PrintfLine("220 ready to start tls")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+			tp = textproto.NewConn(conn)
+			msg.TLS = true
+		case "AUTH":
+			if len(fields) != 3 {
+				_ = tp.PrintfLine("501 expected initial response")
+				continue
+			}
+			auth, err := base64.StdEncoding.DecodeString(fields[2])
+			if err != nil {
+				_ = tp.PrintfLine("501 invalid initial response")
+				continue
+			}
+			msg.Auth = string(auth)
+			_ = tp.PrintfLine("235 authenticated")
+		case "MAIL":
+			msg.From = line
+			_ = tp.PrintfLine("250 ok")
+		case "RCPT":
+			msg.To = line
+			_ = tp.PrintfLine("250 ok")
+		case "DATA":
+			_ = tp.PrintfLine("354 go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			_ = tp.PrintfLine("250 queued")
+		case "QUIT":
+			_ = tp.PrintfLine("221 bye")
+			return
+		default:
+			_ = tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func (s *testServer) received() []*receivedMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.messages
+}
+
+func (s *testServer) connectionsCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
+}
+
+func selfSignedCertificate(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func newTestSender(t *testing.T, s *testServer, tlsMode string) emails.Sender {
+	sender, err := New(&Configuration{
+		Addr:               s.ln.Addr().String(),
+		Username:           "user",
+		Password:           "secret",
+		TLS:                tlsMode,
+		InsecureSkipVerify: true,
+		MaxRetries:         2,
+		From:               "Sturdy <no-reply@example.com>",
+	}, zap.NewNop())
+	require.NoError(t, err)
+	return sender
+}
+
+func TestSend(t *testing.T) {
+	for _, tlsMode := range []string{TLSNone, TLSStartTLS, TLSImplicit} {
+		t.Run(tlsMode, func(t *testing.T) {
+			server := newTestServer(t, tlsMode)
+			sender := newTestSender(t, server, tlsMode)
+
+			err := sender.Send(context.Background(), &emails.Email{
+				To:      "nikita@example.com",
+				Subject: "Welcome to Sturdy! 🐣",
+				Html:    "<p>Hello, world!</p>",
+				Text:    "Hello, world!\n",
+			})
+			require.NoError(t, err)
+
+			received := server.received()
+			require.Len(t, received, 1)
+			assert.Equal(t, tlsMode != TLSNone, received[0].TLS)
+			assert.Equal(t, "\x00user\x00secret", received[0].Auth)
+			assert.Equal(t, "MAIL FROM:<no-reply@example.com>", received[0].From)
+			assert.Equal(t, "RCPT TO:<nikita@example.com>", received[0].To)
+
+			msg, err := mail.ReadMessage(bytes.NewReader(received[0].Data))
+			require.NoError(t, err)
+			assert.Equal(t, `"Sturdy" <no-reply@example.com>`, msg.Header.Get("From"))
+			assert.Equal(t, "<nikita@example.com>", msg.Header.Get("To"))
+
+			subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+			require.NoError(t, err)
+			assert.Equal(t, "Welcome to Sturdy! 🐣", subject)
+
+			mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+			require.NoError(t, err)
+			assert.Equal(t, "multipart/alternative", mediaType)
+
+			parts := map[string]string{}
+			mr := multipart.NewReader(msg.Body, params["boundary"])
+			for {
+				part, err := mr.NextPart()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				body, err := io.ReadAll(part)
+				require.NoError(t, err)
+				parts[part.Header.Get("Content-Type")] = string(body)
+			}
+			assert.Equal(t, map[string]string{
+				"text/plain; charset=UTF-8": "Hello, world!\n",
+				"text/html; charset=UTF-8":  "<p>Hello, world!</p>",
+			}, parts)
+		})
+	}
+}
+
+func TestSend_retriesTemporaryFailures(t *testing.T) {
+	server := newTestServer(t, TLSNone)
+	server.reply = func(n int, cmd string) string {
+		if n == 1 && cmd == "MAIL" {
+			return "421 try again later"
+		}
+		return ""
+	}
+	sender := newTestSender(t, server, TLSNone)
+
+	err := sender.Send(context.Background(), &emails.Email{
+		To:      "nikita@example.com",
+		Subject: "Hello",
+		Html:    "<p>Hello</p>",
+	})
+	require.NoError(t, err)
+	assert.Len(t, server.received(), 1)
+	assert.Equal(t, 2, server.connectionsCount())
+}
+
+func TestSend_doesNotRetryPermanentFailures(t *testing.T) {
+	server := newTestServer(t, TLSNone)
+	server.reply = func(n int, cmd string) string {
+		if cmd == "RCPT" {
+			return "550 no such user"
+		}
+		return ""
+	}
+	sender := newTestSender(t, server, TLSNone)
+
+	err := sender.Send(context.Background(), &emails.Email{
+		To:      "nikita@example.com",
+		Subject: "Hello",
+		Html:    "<p>Hello</p>",
+	})
+	assert.Error(t, err)
+	assert.Empty(t, server.received())
+	assert.Equal(t, 1, server.connectionsCount())
+}
+
+func TestSend_doesNotRetryRefusedAuth(t *testing.T) {
+	server := newTestServer(t, TLSNone)
+	sender := newTestSender(t, server, TLSNone)
+	// PlainAuth only sends the password over unencrypted connections to localhost
+	sender.(*client).host = "smtp.example.com"
+
+	err := sender.Send(context.Background(), &emails.Email{
+		To:      "nikita@example.com",
+		Subject: "Hello",
+		Html:    "<p>Hello</p>",
+	})
+	assert.ErrorIs(t, err, errAuthRefused)
+	assert.Empty(t, server.received())
+	assert.Equal(t, 1, server.connectionsCount())
+}
+
+func TestNew_disabled(t *testing.T) {
+	sender, err := New(&Configuration{}, zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, emails.NewDisabled(), sender)
+}
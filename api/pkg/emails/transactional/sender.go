@@ -161,51 +161,127 @@ func (e *Sender) SendNotification(ctx context.Context, usr *users.User, notif *n
 		return nil
 	}
 
+	content, err := e.NotificationContent(ctx, usr, notif)
+	if err != nil {
+		return err
+	}
+
+	return e.Send(ctx, usr, content.Subject, content.Template, content.Data)
+}
+
+// SendNotificationDigest sends a single email with the notifications that the user wants to receive via email,
+// grouped by codebase and workspace. It returns the notifications that were included in the email, nothing is sent
+// if there are none.
+func (e *Sender) SendNotificationDigest(ctx context.Context, usr *users.User, notifs []*notification.Notification) ([]*notification.Notification, error) {
+	shouldSendByType := make(map[notification.NotificationType]bool)
+	data := &templates.NotificationDigestTemplateData{User: usr}
+	included := make([]*notification.Notification, 0, len(notifs))
+	for _, notif := range notifs {
+		shouldSend, ok := shouldSendByType[notif.NotificationType]
+		if !ok {
+			var err error
+			shouldSend, err = e.shouldSendNotification(ctx, usr, notif.NotificationType)
+			if err != nil {
+				return nil, err
+			}
+			shouldSendByType[notif.NotificationType] = shouldSend
+		}
+		if !shouldSend {
+			continue
+		}
+
+		content, err := e.NotificationContent(ctx, usr, notif)
+		if errors.Is(err, ErrNotSupported) {
+			continue
+		} else if err != nil {
+			// the referenced object might have been deleted since, skip it instead of blocking the digest
+			e.logger.Warn("failed to get notification content", zap.String("notification_id", notif.ID), zap.Error(err))
+			continue
+		}
+
+		if err := data.Add(content.Data); err != nil {
+			return nil, fmt.Errorf("failed to add notification to digest: %w", err)
+		}
+		included = append(included, notif)
+	}
+
+	if len(included) == 0 {
+		return nil, nil
+	}
+
+	subject := fmt.Sprintf("[Sturdy] You have %d new notifications", len(included))
+	if len(included) == 1 {
+		subject = "[Sturdy] You have a new notification"
+	}
+	if err := e.Send(ctx, usr, subject, templates.NotificationDigestTemplate, data); err != nil {
+		return nil, err
+	}
+	return included, nil
+}
+
+// NotificationContent is the data that a notification is rendered from.
+type NotificationContent struct {
+	Subject  string
+	Template templates.Template
+	// Data is one of the templates.Notification*TemplateData types
+	Data interface{}
+}
+
+// NotificationContent gathers the data needed to render the notification to the user. ErrNotSupported is
+// returned for notification types that can't be rendered.
+//
+// usr can be nil, to only gather the data that is the same for all recipients, for channels that are shared by the
+// members of the codebase.
+func (e *Sender) NotificationContent(ctx context.Context, usr *users.User, notif *notification.Notification) (*NotificationContent, error) {
 	switch notif.NotificationType {
 	case notification.CommentNotificationType:
-		if err := e.sendCommentNotification(ctx, usr, comments.ID(notif.ReferenceID)); err != nil {
-			return fmt.Errorf("failed to send comment notification: %w", err)
+		content, err := e.commentNotification(ctx, usr, comments.ID(notif.ReferenceID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get comment notification: %w", err)
 		}
-		return nil
+		return content, nil
 	case notification.NewSuggestionNotificationType:
-		if err := e.sendNewSuggestionNotification(ctx, usr, suggestions.ID(notif.ReferenceID)); err != nil {
-			return fmt.Errorf("failed to send new suggestion notification: %w", err)
+		content, err := e.newSuggestionNotification(ctx, usr, suggestions.ID(notif.ReferenceID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get new suggestion notification: %w", err)
 		}
-		return nil
+		return content, nil
 	case notification.RequestedReviewNotificationType:
-		if err := e.sendRequestedReviewNotification(ctx, usr, notif.ReferenceID); err != nil {
-			return fmt.Errorf("failed to send requested review notification: %w", err)
+		content, err := e.requestedReviewNotification(ctx, usr, notif.ReferenceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get requested review notification: %w", err)
 		}
-		return nil
+		return content, nil
 	case notification.ReviewNotificationType:
-		if err := e.sendReviewNotification(ctx, usr, notif.ReferenceID); err != nil {
-			return fmt.Errorf("failed to send review notification: %w", err)
+		content, err := e.reviewNotification(ctx, usr, notif.ReferenceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get review notification: %w", err)
 		}
-		return nil
+		return content, nil
 	default:
-		return ErrNotSupported
+		return nil, ErrNotSupported
 	}
 }
 
-func (e *Sender) sendReviewNotification(ctx context.Context, usr *users.User, reviewID string) error {
+func (e *Sender) reviewNotification(ctx context.Context, usr *users.User, reviewID string) (*NotificationContent, error) {
 	r, err := e.reviewRepo.Get(ctx, reviewID)
 	if err != nil {
-		return fmt.Errorf("failed to find review: %w", err)
+		return nil, fmt.Errorf("failed to find review: %w", err)
 	}
 
 	author, err := e.userRepo.Get(r.UserID)
 	if err != nil {
-		return fmt.Errorf("failed to find author: %w", err)
+		return nil, fmt.Errorf("failed to find author: %w", err)
 	}
 
 	w, err := e.workspaceRepo.Get(r.WorkspaceID)
 	if err != nil {
-		return fmt.Errorf("failed to find workspace: %w", err)
+		return nil, fmt.Errorf("failed to find workspace: %w", err)
 	}
 
 	c, err := e.codebaseRepo.Get(r.CodebaseID)
 	if err != nil {
-		return fmt.Errorf("failed to find codebase: %w", err)
+		return nil, fmt.Errorf("failed to find codebase: %w", err)
 	}
 
 	title := fmt.Sprintf("[Sturdy] %s sent you a review", author.Name)
@@ -217,28 +293,33 @@ func (e *Sender) sendReviewNotification(ctx context.Context, usr *users.User, re
 		Workspace: w,
 		Codebase:  c,
 	}
-	return e.Send(ctx, usr, title, templates.NotificationReviewTemplate, data)
+	return &NotificationContent{Subject: title, Template: templates.NotificationReviewTemplate, Data: data}, nil
 }
 
-func (e *Sender) sendRequestedReviewNotification(ctx context.Context, usr *users.User, reviewID string) error {
+func (e *Sender) requestedReviewNotification(ctx context.Context, usr *users.User, reviewID string) (*NotificationContent, error) {
 	r, err := e.reviewRepo.Get(ctx, reviewID)
 	if err != nil {
-		return fmt.Errorf("failed to find review: %w", err)
+		return nil, fmt.Errorf("failed to find review: %w", err)
 	}
 
 	requestedBy, err := e.userRepo.Get(*r.RequestedBy)
 	if err != nil {
-		return fmt.Errorf("failed to find author: %w", err)
+		return nil, fmt.Errorf("failed to find author: %w", err)
+	}
+
+	reviewer, err := e.userRepo.Get(r.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find reviewer: %w", err)
 	}
 
 	w, err := e.workspaceRepo.Get(r.WorkspaceID)
 	if err != nil {
-		return fmt.Errorf("failed to find workspace: %w", err)
+		return nil, fmt.Errorf("failed to find workspace: %w", err)
 	}
 
 	c, err := e.codebaseRepo.Get(r.CodebaseID)
 	if err != nil {
-		return fmt.Errorf("failed to find codebase: %w", err)
+		return nil, fmt.Errorf("failed to find codebase: %w", err)
 	}
 
 	title := fmt.Sprintf("[Sturdy] %s asked for your feedback", requestedBy.Name)
@@ -246,31 +327,32 @@ func (e *Sender) sendRequestedReviewNotification(ctx context.Context, usr *users
 		User: usr,
 
 		RequestedBy: requestedBy,
+		Reviewer:    reviewer,
 		Workspace:   w,
 		Codebase:    c,
 	}
-	return e.Send(ctx, usr, title, templates.NotificationRequestedReviewTemplate, data)
+	return &NotificationContent{Subject: title, Template: templates.NotificationRequestedReviewTemplate, Data: data}, nil
 }
 
-func (e *Sender) sendNewSuggestionNotification(ctx context.Context, usr *users.User, suggestionID suggestions.ID) error {
+func (e *Sender) newSuggestionNotification(ctx context.Context, usr *users.User, suggestionID suggestions.ID) (*NotificationContent, error) {
 	s, err := e.suggestionRepo.GetByID(ctx, suggestionID)
 	if err != nil {
-		return fmt.Errorf("failed to find suggestion: %w", err)
+		return nil, fmt.Errorf("failed to find suggestion: %w", err)
 	}
 
 	author, err := e.userRepo.Get(s.UserID)
 	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	workspace, err := e.workspaceRepo.Get(s.ForWorkspaceID)
 	if err != nil {
-		return fmt.Errorf("failed to get workspace: %w", err)
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
 	}
 
 	codebase, err := e.codebaseRepo.Get(workspace.CodebaseID)
 	if err != nil {
-		return fmt.Errorf("failed to get codebase: %w", err)
+		return nil, fmt.Errorf("failed to get codebase: %w", err)
 	}
 
 	title := fmt.Sprintf("[Sturdy] New suggestion on %s", workspace.NameOrFallback())
@@ -280,7 +362,7 @@ func (e *Sender) sendNewSuggestionNotification(ctx context.Context, usr *users.U
 		Workspace: workspace,
 		Codebase:  codebase,
 	}
-	return e.Send(ctx, usr, title, templates.NotificationNewSuggestionTemplate, data)
+	return &NotificationContent{Subject: title, Template: templates.NotificationNewSuggestionTemplate, Data: data}, nil
 }
 
 func (e *Sender) getUsersByCodebaseID(ctx context.Context, codebaseID string) ([]*users.User, error) {
@@ -301,19 +383,19 @@ func (e *Sender) getUsersByCodebaseID(ctx context.Context, codebaseID string) ([
 	return users, nil
 }
 
-func (e *Sender) sendCommentNotification(ctx context.Context, usr *users.User, commentID comments.ID) error {
+func (e *Sender) commentNotification(ctx context.Context, usr *users.User, commentID comments.ID) (*NotificationContent, error) {
 	comment, err := e.commentsRepo.Get(commentID)
 	if err != nil {
-		return fmt.Errorf("failed to find comment: %w", err)
+		return nil, fmt.Errorf("failed to find comment: %w", err)
 	}
 	author, err := e.userRepo.Get(comment.UserID)
 	if err != nil {
-		return fmt.Errorf("failed to get user: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
 	codebaseUsers, err := e.getUsersByCodebaseID(ctx, comment.CodebaseID)
 	if err != nil {
-		return fmt.Errorf("failed to get users: %w", err)
+		return nil, fmt.Errorf("failed to get users: %w", err)
 	}
 
 	mentions := decorate_comments.ExtractIDMentions(comment.Message, codebaseUsers)
@@ -324,7 +406,7 @@ func (e *Sender) sendCommentNotification(ctx context.Context, usr *users.User, c
 
 	codebase, err := e.codebaseRepo.Get(comment.CodebaseID)
 	if err != nil {
-		return fmt.Errorf("failed to get codebase: %w", err)
+		return nil, fmt.Errorf("failed to get codebase: %w", err)
 	}
 
 	data := &templates.NotificationCommentTemplateData{
@@ -339,12 +421,12 @@ func (e *Sender) sendCommentNotification(ctx context.Context, usr *users.User, c
 	case comment.ParentComment != nil: // replied to
 		parentComment, err := e.commentsRepo.Get(*comment.ParentComment)
 		if err != nil {
-			return fmt.Errorf("failed to get parent comment: %w", err)
+			return nil, fmt.Errorf("failed to get parent comment: %w", err)
 		}
 
 		parentAuthor, err := e.userRepo.Get(parentComment.UserID)
 		if err != nil {
-			return fmt.Errorf("failed to get parent author: %w", err)
+			return nil, fmt.Errorf("failed to get parent author: %w", err)
 		}
 
 		data.Parent = &templates.NotificationCommentTemplateData{
@@ -357,46 +439,46 @@ func (e *Sender) sendCommentNotification(ctx context.Context, usr *users.User, c
 		case parentComment.ChangeID != nil:
 			change, err := e.changeService.GetChangeByID(ctx, *parentComment.ChangeID)
 			if err != nil {
-				return fmt.Errorf("failed to get parent change: %w", err)
+				return nil, fmt.Errorf("failed to get parent change: %w", err)
 			}
 			data.Parent.Change = change
 			title := fmt.Sprintf(
 				"[Strudy] %s repied to %s's comment on %s",
 				author.Name, parentAuthor.Name, *change.Title)
-			return e.Send(ctx, usr, title, templates.NotificationCommentTemplate, data)
+			return &NotificationContent{Subject: title, Template: templates.NotificationCommentTemplate, Data: data}, nil
 		case parentComment.WorkspaceID != nil:
 			workspace, err := e.workspaceRepo.Get(*parentComment.WorkspaceID)
 			if err != nil {
-				return fmt.Errorf("failed to get parent workspace: %w", err)
+				return nil, fmt.Errorf("failed to get parent workspace: %w", err)
 			}
 			data.Parent.Workspace = workspace
 			title := fmt.Sprintf("[Sturdy] %s replied to %s's comment on %s",
 				author.Name, parentAuthor.Name, *workspace.Name)
-			return e.Send(ctx, usr, title, templates.NotificationCommentTemplate, data)
+			return &NotificationContent{Subject: title, Template: templates.NotificationCommentTemplate, Data: data}, nil
 		default:
 			title := fmt.Sprintf("[Sturdy] %s replied to %s's comment",
 				author.Name, parentAuthor.Name)
-			return e.Send(ctx, usr, title, templates.NotificationCommentTemplate, data)
+			return &NotificationContent{Subject: title, Template: templates.NotificationCommentTemplate, Data: data}, nil
 		}
 	case comment.ChangeID != nil:
 		change, err := e.changeService.GetChangeByID(ctx, *comment.ChangeID)
 		if err != nil {
-			return fmt.Errorf("failed to get change: %w", err)
+			return nil, fmt.Errorf("failed to get change: %w", err)
 		}
 		data.Change = change
 		title := fmt.Sprintf("[Sturdy] %s commented on %s", author.Name, *change.Title)
-		return e.Send(ctx, usr, title, templates.NotificationCommentTemplate, data)
+		return &NotificationContent{Subject: title, Template: templates.NotificationCommentTemplate, Data: data}, nil
 	case comment.WorkspaceID != nil:
 		workspace, err := e.workspaceRepo.Get(*comment.WorkspaceID)
 		if err != nil {
-			return fmt.Errorf("failed to get comment workspace: %w", err)
+			return nil, fmt.Errorf("failed to get comment workspace: %w", err)
 		}
 		data.Workspace = workspace
 		title := fmt.Sprintf("[Sturdy] %s commented on %s", author.Name, workspace.NameOrFallback())
-		return e.Send(ctx, usr, title, templates.NotificationCommentTemplate, data)
+		return &NotificationContent{Subject: title, Template: templates.NotificationCommentTemplate, Data: data}, nil
 	default:
 		title := fmt.Sprintf("[Sturdy] %s commented", author.Name)
-		return e.Send(ctx, usr, title, templates.NotificationCommentTemplate, data)
+		return &NotificationContent{Subject: title, Template: templates.NotificationCommentTemplate, Data: data}, nil
 	}
 }
 
@@ -423,6 +505,11 @@ func (e *Sender) Send(
 		return fmt.Errorf("failed to render email: %w", err)
 	}
 
+	text, err := templates.HTMLToText(content)
+	if err != nil {
+		return fmt.Errorf("failed to render plain text email: %w", err)
+	}
+
 	e.logger.Info(
 		"sending email",
 		zap.String("user_id", u.ID),
@@ -433,6 +520,7 @@ func (e *Sender) Send(
 		To:      u.Email,
 		Subject: subject,
 		Html:    content,
+		Text:    text,
 	}); err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
@@ -9,5 +9,6 @@ yarn run mjml ./templates/notification/comment.template.mjml -o ./templates/outp
 yarn run mjml ./templates/notification/new_suggestion.template.mjml -o ./templates/output/notification/new_suggestion.template.html
 yarn run mjml ./templates/notification/requested_review.template.mjml -o ./templates/output/notification/requested_review.template.html
 yarn run mjml ./templates/notification/review.template.mjml -o ./templates/output/notification/review.template.html
+yarn run mjml ./templates/notification/digest.template.mjml -o ./templates/output/notification/digest.template.html
 yarn run mjml ./templates/verify_email.template.mjml -o ./templates/output/verify_email.template.html
 yarn run mjml ./templates/magic_link.template.mjml -o ./templates/output/magic_link.template.html
@@ -0,0 +1,91 @@
+package templates
+
+import (
+	"fmt"
+
+	"getsturdy.com/api/pkg/change"
+	"getsturdy.com/api/pkg/codebase"
+	"getsturdy.com/api/pkg/review"
+	"getsturdy.com/api/pkg/workspaces"
+)
+
+// Add adds a notification to the digest. Data is one of the Notification*TemplateData types that the single
+// notification emails are rendered from.
+func (d *NotificationDigestTemplateData) Add(data interface{}) error {
+	switch data := data.(type) {
+	case *NotificationCommentTemplateData:
+		item := &NotificationDigestItem{Quote: data.Comment.Message}
+		target := data
+		if data.Parent != nil {
+			authorReference := fmt.Sprintf("%s's", data.Parent.Author.Name)
+			if d.User != nil && data.Parent.Author.ID == d.User.ID {
+				authorReference = "your"
+			}
+			item.Text = fmt.Sprintf("%s replied to %s comment", data.Author.Name, authorReference)
+			target = data.Parent
+		} else {
+			item.Text = fmt.Sprintf("%s commented", data.Author.Name)
+		}
+		d.add(data.Codebase, target.Workspace, target.Change, item)
+	case *NotificationReviewTemplateData:
+		var text string
+		switch data.Review.Grade {
+		case review.ReviewGradeApprove:
+			text = fmt.Sprintf("%s approved", data.Author.Name)
+		case review.ReviewGradeReject:
+			text = fmt.Sprintf("%s has some feedback", data.Author.Name)
+		default:
+			text = fmt.Sprintf("%s reviewed", data.Author.Name)
+		}
+		d.add(data.Codebase, data.Workspace, nil, &NotificationDigestItem{Text: text})
+	case *NotificationRequestedReviewTemplateData:
+		d.add(data.Codebase, data.Workspace, nil, &NotificationDigestItem{
+			Text: fmt.Sprintf("%s asked you for feedback", data.RequestedBy.Name),
+		})
+	case *NotificationNewSuggestionTemplateData:
+		d.add(data.Codebase, data.Workspace, nil, &NotificationDigestItem{
+			Text: fmt.Sprintf("%s made a suggestion", data.Author.Name),
+		})
+	default:
+		return fmt.Errorf("unsupported notification data: %T", data)
+	}
+	d.Count++
+	return nil
+}
+
+func (d *NotificationDigestTemplateData) add(cb *codebase.Codebase, ws *workspaces.Workspace, ch *change.Change, item *NotificationDigestItem) {
+	var digestCodebase *NotificationDigestCodebase
+	for _, c := range d.Codebases {
+		if c.Codebase.ID == cb.ID {
+			digestCodebase = c
+			break
+		}
+	}
+	if digestCodebase == nil {
+		digestCodebase = &NotificationDigestCodebase{Codebase: cb}
+		d.Codebases = append(d.Codebases, digestCodebase)
+	}
+
+	for _, g := range digestCodebase.Groups {
+		if g.matches(ws, ch) {
+			g.Items = append(g.Items, item)
+			return
+		}
+	}
+	digestCodebase.Groups = append(digestCodebase.Groups, &NotificationDigestGroup{
+		Workspace: ws,
+		Change:    ch,
+		Items:     []*NotificationDigestItem{item},
+	})
+}
+
+func (g *NotificationDigestGroup) matches(ws *workspaces.Workspace, ch *change.Change) bool {
+	switch {
+	case ws != nil:
+		return g.Workspace != nil && g.Workspace.ID == ws.ID
+	case ch != nil:
+		return g.Change != nil && g.Change.ID == ch.ID
+	default:
+		return g.Workspace == nil && g.Change == nil
+	}
+}
@@ -48,6 +48,7 @@ const (
 	NotificationNewSuggestionTemplate            Template = "new_suggestion.template.html"
 	NotificationRequestedReviewTemplate          Template = "requested_review.template.html"
 	NotificationReviewTemplate                   Template = "review.template.html"
+	NotificationDigestTemplate                   Template = "digest.template.html"
 	VerifyEmailTemplate                          Template = "verify_email.template.html"
 	MagicLinkTemplate                            Template = "magic_link.template.html"
 )
@@ -86,8 +87,10 @@ type NotificationRequestedReviewTemplateData struct {
 	User *users.User
 
 	RequestedBy *users.User
-	Workspace   *workspaces.Workspace
-	Codebase    *codebase.Codebase
+	// Reviewer is the user that was asked for feedback, the same as User when the data is rendered for a recipient
+	Reviewer  *users.User
+	Workspace *workspaces.Workspace
+	Codebase  *codebase.Codebase
 }
 
 type NotificationReviewTemplateData struct {
@@ -99,6 +102,34 @@ type NotificationReviewTemplateData struct {
 	Codebase  *codebase.Codebase
 }
 
+// NotificationDigestTemplateData is a batch of notifications, grouped by codebase, and by workspace or change
+// within the codebase. Use Add to add notifications to it.
+type NotificationDigestTemplateData struct {
+	User *users.User
+
+	Count     int
+	Codebases []*NotificationDigestCodebase
+}
+
+type NotificationDigestCodebase struct {
+	Codebase *codebase.Codebase
+	Groups   []*NotificationDigestGroup
+}
+
+// NotificationDigestGroup contains the notifications of a workspace or a change. Notifications that belong to neither
+// are in a group where both are nil.
+type NotificationDigestGroup struct {
+	Workspace *workspaces.Workspace
+	Change    *change.Change
+	Items     []*NotificationDigestItem
+}
+
+type NotificationDigestItem struct {
+	Text string
+	// Quote is the comment message, if any
+	Quote string
+}
+
 type MagicLinkTemplateData struct {
 	User *users.User
 	Code string
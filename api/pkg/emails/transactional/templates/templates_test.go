@@ -463,6 +463,60 @@ func TestRenderNotificationReview_rejected(t *testing.T) {
 	assert.Equal(t, mustReadFile(t, "testdata/notification/review_rejected.html"), output)
 }
 
+func TestRenderNotificationDigest(t *testing.T) {
+	usr := &users.User{
+		Name:  "me",
+		ID:    "0",
+		Email: "me@test.com",
+	}
+	author := &users.User{
+		ID:   "1",
+		Name: "User One",
+	}
+	cb := &codebase.Codebase{
+		ID:              "codebase-id",
+		ShortCodebaseID: "short-id",
+		Name:            "codebase",
+	}
+	ws := &workspaces.Workspace{
+		ID:   "workspace-id",
+		Name: strPointer("Workspace"),
+	}
+
+	data := &NotificationDigestTemplateData{User: usr}
+	assert.NoError(t, data.Add(&NotificationReviewTemplateData{
+		User:      usr,
+		Author:    author,
+		Review:    &review.Review{Grade: review.ReviewGradeApprove},
+		Codebase:  cb,
+		Workspace: ws,
+	}))
+	assert.NoError(t, data.Add(&NotificationCommentTemplateData{
+		User:     usr,
+		Comment:  &comments.Comment{Message: "This is my comment message"},
+		Author:   author,
+		Codebase: cb,
+		Change: &change.Change{
+			ID:    "change-id",
+			Title: strPointer("Change"),
+		},
+	}))
+	assert.NoError(t, data.Add(&NotificationNewSuggestionTemplateData{
+		User:      usr,
+		Author:    author,
+		Codebase:  cb,
+		Workspace: ws,
+	}))
+
+	output, err := Render(NotificationDigestTemplate, data)
+
+	// uncomment to make a snapshot
+	// os.WriteFile("testdata/notification/digest.html", []byte(output), 0666)
+
+	assert.NoError(t, err)
+	assert.Equal(t, mustReadFile(t, "testdata/notification/digest.html"), output)
+}
+
 func TestRenderVerifyEmail(t *testing.T) {
 	usr := &users.User{
 		Name:  "me",
@@ -0,0 +1,116 @@
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	skippedElements = map[atom.Atom]bool{
+		atom.Head:   true,
+		atom.Style:  true,
+		atom.Script: true,
+		atom.Title:  true,
+	}
+
+	blockElements = map[atom.Atom]bool{
+		atom.Br:    true,
+		atom.Div:   true,
+		atom.P:     true,
+		atom.Tr:    true,
+		atom.Table: true,
+		atom.Li:    true,
+		atom.Ul:    true,
+		atom.Ol:    true,
+		atom.H1:    true,
+		atom.H2:    true,
+		atom.H3:    true,
+		atom.H4:    true,
+		atom.H5:    true,
+		atom.H6:    true,
+		atom.Hr:    true,
+		atom.Pre:   true,
+	}
+
+	spaces     = regexp.MustCompile(`[ \t\r\n\f\v\x{00a0}]+`)
+	emptyLines = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText converts an html document to plain text. Headers, styles and scripts are dropped,
+// block elements are separated by new lines, and links are followed by their targets.
+func HTMLToText(in string) (string, error) {
+	doc, err := html.Parse(strings.NewReader(in))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse html: %w", err)
+	}
+
+	b := &strings.Builder{}
+	writeText(b, doc)
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+	text := emptyLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text) + "\n", nil
+}
+
+func writeText(b *strings.Builder, n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		b.WriteString(spaces.ReplaceAllString(n.Data, " "))
+		return
+	case html.ElementNode:
+		if skippedElements[n.DataAtom] || isHidden(n) {
+			return
+		}
+	}
+
+	block := n.Type == html.ElementNode && blockElements[n.DataAtom]
+	if block {
+		b.WriteString("\n")
+	}
+
+	linkStart := b.Len()
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		writeText(b, c)
+	}
+
+	if n.Type == html.ElementNode && n.DataAtom == atom.A {
+		href := strings.TrimSpace(attr(n, "href"))
+		text := strings.TrimSpace(b.String()[linkStart:])
+		if href != "" && href != text && !strings.HasPrefix(href, "#") && !strings.HasPrefix(href, "mailto:") {
+			switch {
+			case text == "":
+				b.WriteString(href)
+			case strings.HasSuffix(b.String(), " "):
+				b.WriteString("(" + href + ")")
+			default:
+				b.WriteString(" (" + href + ")")
+			}
+		}
+	}
+
+	if block {
+		b.WriteString("\n")
+	}
+}
+
+// isHidden returns true for elements that are not displayed, such as the preview text of the templates.
+func isHidden(n *html.Node) bool {
+	style := strings.ReplaceAll(attr(n, "style"), " ", "")
+	return strings.Contains(style, "display:none")
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
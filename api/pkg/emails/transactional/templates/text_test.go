@@ -0,0 +1,64 @@
+package templates
+
+import (
+	"testing"
+
+	"getsturdy.com/api/pkg/users"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLToText(t *testing.T) {
+	cases := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name:     "head is skipped",
+			html:     `<html><head><title>title</title><style>p { color: red; }</style></head><body><p>hello</p></body></html>`,
+			expected: "hello\n",
+		},
+		{
+			name:     "blocks are separated",
+			html:     `<div>first</div><div>second<br>third</div>`,
+			expected: "first\n\nsecond\n\nthird\n",
+		},
+		{
+			name:     "whitespace is collapsed",
+			html:     "<p>  hello \n\n   world  </p>\n\n\n\n<p>again</p>",
+			expected: "hello world\n\nagain\n",
+		},
+		{
+			name:     "links",
+			html:     `<p><a href="https://getsturdy.com/"> Sturdy </a> <a href="https://getsturdy.com/">https://getsturdy.com/</a> <a href="https://getsturdy.com/logo"><img src="logo.png"></a></p>`,
+			expected: "Sturdy (https://getsturdy.com/) https://getsturdy.com/ https://getsturdy.com/logo\n",
+		},
+		{
+			name:     "hidden elements are skipped",
+			html:     `<div style="display: none;">preview</div><p>content</p>`,
+			expected: "content\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			text, err := HTMLToText(tc.html)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, text)
+		})
+	}
+}
+
+func TestHTMLToText_magicLink(t *testing.T) {
+	html, err := Render(MagicLinkTemplate, MagicLinkTemplateData{
+		User: &users.User{Name: "Nikita", Email: "test@email.com"},
+		Code: "123456",
+	})
+	assert.NoError(t, err)
+	text, err := HTMLToText(html)
+	assert.NoError(t, err)
+	assert.Contains(t, text, "Hi Nikita,\n")
+	assert.Contains(t, text, "\n123456\n")
+	assert.NotContains(t, text, "<")
+}
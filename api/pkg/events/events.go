@@ -13,11 +13,15 @@ type EventReader interface {
 	// Introduce reference filtering in the call to SubscribeUser(uesrID string, cb CallbackFunc, map[EventType][]string) CancelFunc
 	SubscribeUser(userID string, cb CallbackFunc) CancelFunc
 	SubscribeWorkspace(workspaceID string, cb CallbackFunc) CancelFunc
+	// SubscribeCodebases subscribes to events that are sent to any codebase, such as StatusUpdated.
+	// The events are received once, and not once per codebase member.
+	SubscribeCodebases(cb CallbackFunc) CancelFunc
 }
 
 type eventWriter interface {
 	UserEvent(userID string, eventType EventType, reference string)
 	WorkspaceEvent(workspaceID string, eventType EventType, reference string)
+	CodebaseEvent(codebaseID string, eventType EventType, reference string)
 }
 
 type EventReadWriter interface {
@@ -49,6 +53,7 @@ const (
 	StatusUpdated
 	CompletedOnboardingStep
 	WorkspaceWatchingStatusUpdated
+	CommentResolutionUpdated
 )
 
 var eventTypeString = map[EventType]string{
@@ -68,6 +73,7 @@ var eventTypeString = map[EventType]string{
 	StatusUpdated:                  "StatusUpdated",
 	CompletedOnboardingStep:        "CompletedOnboardingStep",
 	WorkspaceWatchingStatusUpdated: "WorkspaceWatchingStatusUpdated",
+	CommentResolutionUpdated:       "CommentResolutionUpdated",
 }
 
 type CallbackFunc func(eventType EventType, reference string) error
@@ -102,8 +108,15 @@ func (i *inMemory) WorkspaceEvent(workspaceID string, eventType EventType, refer
 	i.event(Topic(workspaceID), eventType, reference)
 }
 
+func (i *inMemory) CodebaseEvent(_ string, eventType EventType, reference string) {
+	i.event(allCodebasesTopic, eventType, reference)
+}
+
 type Topic string
 
+// allCodebasesTopic is the topic of events that are sent to any codebase
+const allCodebasesTopic Topic = "codebases"
+
 type TopicSubscriber struct {
 	Topic         Topic
 	SubscriberKey string
@@ -112,9 +125,8 @@ type TopicSubscriber struct {
 func (i *inMemory) event(topic Topic, eventType EventType, reference string) {
 	sentEventCounterMetric.WithLabelValues(eventTypeString[eventType]).Inc()
 
-	subs := make(map[TopicSubscriber]CallbackFunc, len(i.subscribers[topic]))
-
 	i.mx.RLock()
+	subs := make(map[TopicSubscriber]CallbackFunc, len(i.subscribers[topic]))
 	// Copy over all subscribers
 	for subId, cb := range i.subscribers[topic] {
 		subs[TopicSubscriber{Topic: topic, SubscriberKey: subId}] = cb
@@ -138,40 +150,29 @@ func (i *inMemory) event(topic Topic, eventType EventType, reference string) {
 }
 
 func (i *inMemory) SubscribeWorkspace(workspaceID string, cb CallbackFunc) CancelFunc {
-	workspaceTopic := Topic(workspaceID)
-
-	id := uuid.New().String()
-
-	i.mx.Lock()
-	_, ok := i.subscribers[workspaceTopic]
-	if !ok {
-		i.subscribers[workspaceTopic] = make(map[string]CallbackFunc)
-	}
-	i.subscribers[workspaceTopic][id] = cb
-
-	unregKey := TopicSubscriber{
-		Topic:         workspaceTopic,
-		SubscriberKey: id,
-	}
-	i.mx.Unlock()
-
-	return func() { i.unreg(unregKey) }
+	return i.subscribe(Topic(workspaceID), cb)
 }
 
 func (i *inMemory) SubscribeUser(userID string, cb CallbackFunc) CancelFunc {
-	userTopic := Topic(userID)
+	return i.subscribe(Topic(userID), cb)
+}
+
+func (i *inMemory) SubscribeCodebases(cb CallbackFunc) CancelFunc {
+	return i.subscribe(allCodebasesTopic, cb)
+}
 
+func (i *inMemory) subscribe(topic Topic, cb CallbackFunc) CancelFunc {
 	id := uuid.New().String()
 
 	i.mx.Lock()
-	_, ok := i.subscribers[userTopic]
+	_, ok := i.subscribers[topic]
 	if !ok {
-		i.subscribers[userTopic] = make(map[string]CallbackFunc)
+		i.subscribers[topic] = make(map[string]CallbackFunc)
 	}
-	i.subscribers[userTopic][id] = cb
+	i.subscribers[topic][id] = cb
 
 	unregKey := TopicSubscriber{
-		Topic:         userTopic,
+		Topic:         topic,
 		SubscriberKey: id,
 	}
 	i.mx.Unlock()
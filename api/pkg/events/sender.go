@@ -15,6 +15,9 @@ type EventSender interface {
 
 	// Workspace sends this event to all members of the codebase of this workspace
 	Workspace(id string, eventType EventType, reference string) error
+
+	// Internal sends this event to the subscribers of all codebases only, such as workers. It's not sent to any user.
+	Internal(eventType EventType, reference string)
 }
 
 type eventsSender struct {
@@ -41,6 +44,8 @@ func (s *eventsSender) User(id string, eventType EventType, reference string) {
 }
 
 func (s *eventsSender) Codebase(id string, eventType EventType, reference string) error {
+	s.events.CodebaseEvent(id, eventType, reference)
+
 	members, err := s.codebaseUserRepo.GetByCodebase(id)
 	if err != nil {
 		return err
@@ -62,3 +67,7 @@ func (s *eventsSender) Workspace(id string, eventType EventType, reference strin
 	}
 	return s.Codebase(ws.CodebaseID, eventType, reference)
 }
+
+func (s *eventsSender) Internal(eventType EventType, reference string) {
+	s.events.CodebaseEvent("", eventType, reference)
+}
@@ -16,6 +16,7 @@ import (
 	provider_acl "getsturdy.com/api/pkg/codebase/acl/provider"
 	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
 	"getsturdy.com/api/pkg/internal/inmemory"
+	db_scim "getsturdy.com/api/pkg/scim/db"
 	db_users "getsturdy.com/api/pkg/users/db"
 	service_user "getsturdy.com/api/pkg/users/service"
 	"getsturdy.com/api/vcs"
@@ -54,6 +55,9 @@ func TestFileResolver(t *testing.T) {
 		aclRepo,
 		nil,
 		nil,
+		inmemory.NewInMemoryCodebaseRepo(),
+		db_scim.NewGroupsMemory(),
+		inmemory.NewInMemoryOrganizationMemberRepository(),
 	)
 
 	authService := service_auth.New(
@@ -62,6 +66,9 @@ func TestFileResolver(t *testing.T) {
 		nil,
 		aclProvider,
 		nil,
+		nil,
+		nil,
+		nil,
 	)
 
 	aclID := uuid.NewString()
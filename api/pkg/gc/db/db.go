@@ -12,6 +12,8 @@ import (
 type Repository interface {
 	ListSince(ctx context.Context, codebaseID string, since time.Time) ([]*gc.CodebaseGarbageStatus, error)
 	Create(context.Context, *gc.CodebaseGarbageStatus) error
+	// GetLatestWithStorageUsed returns the latest run that calculated the storage used by the codebase.
+	GetLatestWithStorageUsed(ctx context.Context, codebaseID string) (*gc.CodebaseGarbageStatus, error)
 }
 
 type repo struct {
@@ -28,7 +30,8 @@ func (r *repo) ListSince(ctx context.Context, codebaseID string, since time.Time
 		SELECT
 			codebase_id,
 			completed_at,
-			duration_millis
+			duration_millis,
+			storage_used_bytes
 		FROM
 			codebases_garbage_collection_status
 		WHERE
@@ -43,15 +46,37 @@ func (r *repo) ListSince(ctx context.Context, codebaseID string, since time.Time
 func (r *repo) Create(ctx context.Context, status *gc.CodebaseGarbageStatus) error {
 	if _, err := r.db.NamedExecContext(ctx, `
 		INSERT INTO codebases_garbage_collection_status 
-			(codebase_id, completed_at, duration_millis)
+			(codebase_id, completed_at, duration_millis, storage_used_bytes)
 		VALUES
-			(:codebase_id, :completed_at, :duration_millis)
+			(:codebase_id, :completed_at, :duration_millis, :storage_used_bytes)
 	`, status); err != nil {
 		return fmt.Errorf("failed to insert: %w", err)
 	}
 	return nil
 }
 
+func (r *repo) GetLatestWithStorageUsed(ctx context.Context, codebaseID string) (*gc.CodebaseGarbageStatus, error) {
+	var res gc.CodebaseGarbageStatus
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			codebase_id,
+			completed_at,
+			duration_millis,
+			storage_used_bytes
+		FROM
+			codebases_garbage_collection_status
+		WHERE
+			codebase_id = $1
+			AND storage_used_bytes IS NOT NULL
+		ORDER BY
+			completed_at DESC
+		LIMIT 1
+	`, codebaseID); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
 func (r *repo) GetByCodebaseID(ctx context.Context, codebaseID string) (*gc.CodebaseGarbageStatus, error) {
 	var res gc.CodebaseGarbageStatus
 	if err := r.db.Get(&res, `
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"getsturdy.com/api/pkg/gc"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type RetentionPolicyRepository interface {
+	GetByCodebaseID(ctx context.Context, codebaseID string) (*gc.SnapshotRetentionPolicy, error)
+	Upsert(context.Context, *gc.SnapshotRetentionPolicy) error
+}
+
+type retentionPolicyRepo struct {
+	db *sqlx.DB
+}
+
+func NewRetentionPolicyRepository(db *sqlx.DB) RetentionPolicyRepository {
+	return &retentionPolicyRepo{db: db}
+}
+
+func (r *retentionPolicyRepo) GetByCodebaseID(ctx context.Context, codebaseID string) (*gc.SnapshotRetentionPolicy, error) {
+	var res gc.SnapshotRetentionPolicy
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			codebase_id,
+			keep_last_per_workspace,
+			keep_newer_than_seconds,
+			keep_daily_for_days,
+			updated_at,
+			updated_by
+		FROM
+			snapshot_retention_policies
+		WHERE
+			codebase_id = $1
+	`, codebaseID); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *retentionPolicyRepo) Upsert(ctx context.Context, policy *gc.SnapshotRetentionPolicy) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO snapshot_retention_policies
+			(codebase_id, keep_last_per_workspace, keep_newer_than_seconds, keep_daily_for_days, updated_at, updated_by)
+		VALUES
+			(:codebase_id, :keep_last_per_workspace, :keep_newer_than_seconds, :keep_daily_for_days, :updated_at, :updated_by)
+		ON CONFLICT (codebase_id) DO UPDATE SET
+			keep_last_per_workspace = :keep_last_per_workspace,
+			keep_newer_than_seconds = :keep_newer_than_seconds,
+			keep_daily_for_days = :keep_daily_for_days,
+			updated_at = :updated_at,
+			updated_by = :updated_by
+	`, policy); err != nil {
+		return fmt.Errorf("failed to upsert: %w", err)
+	}
+	return nil
+}
@@ -6,4 +6,40 @@ type CodebaseGarbageStatus struct {
 	CodebaseID     string    `db:"codebase_id"`
 	CompletedAt    time.Time `db:"completed_at"`
 	DurationMillis int64     `db:"duration_millis"`
+	// StorageUsedBytes is the number of bytes used on disk by the trunk and all views of the codebase after the
+	// run, or nil if it could not be calculated.
+	StorageUsedBytes *int64 `db:"storage_used_bytes"`
+}
+
+// SnapshotRetentionPolicy controls which snapshots are kept when a codebase is garbage collected.
+//
+// Snapshots that are used by a non-archived workspace, or by a suggestion, are never collected
+// regardless of the policy.
+type SnapshotRetentionPolicy struct {
+	CodebaseID string `db:"codebase_id"`
+	// KeepLastPerWorkspace is the number of most recent snapshots that are kept for each workspace.
+	KeepLastPerWorkspace int `db:"keep_last_per_workspace"`
+	// KeepNewerThanSeconds is the age in seconds under which all snapshots are kept.
+	KeepNewerThanSeconds int64 `db:"keep_newer_than_seconds"`
+	// KeepDailyForDays is the number of days for which the last snapshot of each day is kept
+	// for each workspace.
+	KeepDailyForDays int       `db:"keep_daily_for_days"`
+	UpdatedAt        time.Time `db:"updated_at"`
+	UpdatedBy        string    `db:"updated_by"`
+}
+
+// DefaultSnapshotRetentionPolicy is used for codebases that have not configured a policy of their own.
+func DefaultSnapshotRetentionPolicy(codebaseID string) *SnapshotRetentionPolicy {
+	return &SnapshotRetentionPolicy{
+		CodebaseID:           codebaseID,
+		KeepNewerThanSeconds: int64((3 * time.Hour).Seconds()),
+	}
+}
+
+func (p *SnapshotRetentionPolicy) KeepNewerThan() time.Duration {
+	return time.Duration(p.KeepNewerThanSeconds) * time.Second
+}
+
+func (p *SnapshotRetentionPolicy) KeepDailyFor() time.Duration {
+	return time.Duration(p.KeepDailyForDays) * 24 * time.Hour
 }
@@ -0,0 +1,126 @@
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"getsturdy.com/api/pkg/auth"
+	service_auth "getsturdy.com/api/pkg/auth/service"
+	service_codebase "getsturdy.com/api/pkg/codebase/service"
+	"getsturdy.com/api/pkg/gc"
+	service_gc "getsturdy.com/api/pkg/gc/service"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type rootResolver struct {
+	authService     *service_auth.Service
+	codebaseService *service_codebase.Service
+	gcService       *service_gc.Service
+}
+
+func New(
+	authService *service_auth.Service,
+	codebaseService *service_codebase.Service,
+	gcService *service_gc.Service,
+) resolvers.SnapshotRetentionPolicyRootResolver {
+	return &rootResolver{
+		authService:     authService,
+		codebaseService: codebaseService,
+		gcService:       gcService,
+	}
+}
+
+func (r *rootResolver) InternalByCodebaseID(ctx context.Context, codebaseID string) (resolvers.SnapshotRetentionPolicyResolver, error) {
+	policy, err := r.gcService.GetRetentionPolicy(ctx, codebaseID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return &resolver{policy: policy, root: r}, nil
+}
+
+func (r *rootResolver) UpdateSnapshotRetentionPolicy(ctx context.Context, args resolvers.UpdateSnapshotRetentionPolicyArgs) (resolvers.SnapshotRetentionPolicyResolver, error) {
+	cb, err := r.codebaseService.GetByID(ctx, string(args.Input.CodebaseID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.authService.CanAdmin(ctx, cb); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	policy, err := r.gcService.GetRetentionPolicy(ctx, cb.ID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if args.Input.KeepLastPerWorkspace != nil {
+		policy.KeepLastPerWorkspace = int(*args.Input.KeepLastPerWorkspace)
+	}
+	if args.Input.KeepNewerThanSeconds != nil {
+		policy.KeepNewerThanSeconds = int64(*args.Input.KeepNewerThanSeconds)
+	}
+	if args.Input.KeepDailyForDays != nil {
+		policy.KeepDailyForDays = int(*args.Input.KeepDailyForDays)
+	}
+	policy.UpdatedBy = userID
+
+	if err := r.gcService.UpdateRetentionPolicy(ctx, policy); errors.Is(err, gc.ErrInvalidPolicy) {
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "retention rules must be between 0 and 2147483647")
+	} else if err != nil {
+		return nil, gqlerrors.Error(fmt.Errorf("failed to update retention policy: %w", err))
+	}
+
+	return &resolver{policy: policy, root: r}, nil
+}
+
+type resolver struct {
+	policy *gc.SnapshotRetentionPolicy
+	root   *rootResolver
+}
+
+func (r *resolver) ID() graphql.ID {
+	return graphql.ID(r.policy.CodebaseID)
+}
+
+func (r *resolver) KeepLastPerWorkspace() int32 {
+	return int32(r.policy.KeepLastPerWorkspace)
+}
+
+func (r *resolver) KeepNewerThanSeconds() int32 {
+	return int32(r.policy.KeepNewerThanSeconds)
+}
+
+func (r *resolver) KeepDailyForDays() int32 {
+	return int32(r.policy.KeepDailyForDays)
+}
+
+func (r *resolver) UpdatedAt() *int32 {
+	if r.policy.UpdatedAt.IsZero() {
+		return nil
+	}
+	t := int32(r.policy.UpdatedAt.Unix())
+	return &t
+}
+
+func (r *resolver) StorageUsedBytes(ctx context.Context) (*float64, error) {
+	size, err := r.root.gcService.StorageUsed(ctx, r.policy.CodebaseID)
+	switch {
+	case err == nil:
+		f := float64(size)
+		return &f, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil
+	default:
+		return nil, gqlerrors.Error(err)
+	}
+}
@@ -0,0 +1,71 @@
+package gc
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"getsturdy.com/api/pkg/snapshots"
+)
+
+var ErrInvalidPolicy = errors.New("invalid retention policy")
+
+// MaxKeepNewerThanSeconds is the largest KeepNewerThanSeconds that can be represented in the API, where it is an Int.
+const MaxKeepNewerThanSeconds = math.MaxInt32
+
+// Validate returns ErrInvalidPolicy if any of the policy rules are out of range.
+func (p *SnapshotRetentionPolicy) Validate() error {
+	if p.KeepLastPerWorkspace < 0 || p.KeepNewerThanSeconds < 0 || p.KeepDailyForDays < 0 {
+		return ErrInvalidPolicy
+	}
+	if p.KeepNewerThanSeconds > MaxKeepNewerThanSeconds {
+		return ErrInvalidPolicy
+	}
+	return nil
+}
+
+// Retained returns the IDs of the candidate snapshots that must be kept according to the policy.
+//
+// latest should contain the KeepLastPerWorkspace most recent snapshots of each workspace. Snapshots
+// newer than KeepNewerThan are expected to already be excluded from candidates.
+func (p *SnapshotRetentionPolicy) Retained(now time.Time, candidates, latest []*snapshots.Snapshot) map[string]bool {
+	retained := make(map[string]bool)
+
+	for _, snapshot := range latest {
+		retained[snapshot.ID] = true
+	}
+
+	if p.KeepDailyForDays == 0 {
+		return retained
+	}
+
+	sorted := make([]*snapshots.Snapshot, len(candidates))
+	copy(sorted, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+
+	// the first (most recent) snapshot seen for every workspace and day is the daily checkpoint
+	dailyFrom := now.Add(-p.KeepDailyFor())
+	checkpoints := make(map[string]bool)
+	for _, snapshot := range sorted {
+		if snapshot.CreatedAt.Before(dailyFrom) {
+			continue
+		}
+
+		var workspaceID string
+		if snapshot.WorkspaceID != nil {
+			workspaceID = *snapshot.WorkspaceID
+		}
+
+		key := workspaceID + "/" + snapshot.CreatedAt.UTC().Format("2006-01-02")
+		if checkpoints[key] {
+			continue
+		}
+		checkpoints[key] = true
+		retained[snapshot.ID] = true
+	}
+
+	return retained
+}
@@ -0,0 +1,79 @@
+package gc
+
+import (
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/snapshots"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetained(t *testing.T) {
+	now := time.Date(2022, 3, 10, 12, 0, 0, 0, time.UTC)
+	ws1, ws2 := "ws-1", "ws-2"
+
+	snapshot := func(id string, workspaceID *string, age time.Duration) *snapshots.Snapshot {
+		return &snapshots.Snapshot{ID: id, WorkspaceID: workspaceID, CreatedAt: now.Add(-age)}
+	}
+
+	candidates := []*snapshots.Snapshot{
+		snapshot("a", &ws1, 4*time.Hour),
+		snapshot("b", &ws1, 5*time.Hour),
+		snapshot("c", &ws2, 5*time.Hour),
+		snapshot("d", &ws1, 26*time.Hour),
+		snapshot("e", &ws1, 27*time.Hour),
+		snapshot("f", &ws1, 10*24*time.Hour),
+	}
+
+	cases := []struct {
+		name     string
+		policy   SnapshotRetentionPolicy
+		latest   []*snapshots.Snapshot
+		expected []string
+	}{
+		{
+			name:     "default",
+			policy:   *DefaultSnapshotRetentionPolicy("cb"),
+			expected: []string{},
+		},
+		{
+			name:     "keep-last",
+			policy:   SnapshotRetentionPolicy{KeepLastPerWorkspace: 1},
+			latest:   []*snapshots.Snapshot{candidates[0], candidates[2]},
+			expected: []string{"a", "c"},
+		},
+		{
+			name:     "keep-daily",
+			policy:   SnapshotRetentionPolicy{KeepDailyForDays: 3},
+			expected: []string{"a", "c", "d"},
+		},
+		{
+			name:     "keep-last-and-daily",
+			policy:   SnapshotRetentionPolicy{KeepLastPerWorkspace: 1, KeepDailyForDays: 30},
+			latest:   []*snapshots.Snapshot{candidates[1]},
+			expected: []string{"a", "b", "c", "d", "f"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			retained := tc.policy.Retained(now, candidates, tc.latest)
+			ids := []string{}
+			for _, c := range candidates {
+				if retained[c.ID] {
+					ids = append(ids, c.ID)
+				}
+			}
+			assert.Equal(t, tc.expected, ids)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, DefaultSnapshotRetentionPolicy("cb").Validate())
+	assert.ErrorIs(t, (&SnapshotRetentionPolicy{KeepLastPerWorkspace: -1}).Validate(), ErrInvalidPolicy)
+	assert.ErrorIs(t, (&SnapshotRetentionPolicy{KeepDailyForDays: -1}).Validate(), ErrInvalidPolicy)
+	assert.NoError(t, (&SnapshotRetentionPolicy{KeepNewerThanSeconds: MaxKeepNewerThanSeconds}).Validate())
+	assert.ErrorIs(t, (&SnapshotRetentionPolicy{KeepNewerThanSeconds: MaxKeepNewerThanSeconds + 1}).Validate(), ErrInvalidPolicy)
+}
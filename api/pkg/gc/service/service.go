@@ -5,6 +5,8 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io/fs"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 
 	"getsturdy.com/api/pkg/gc"
 	"getsturdy.com/api/pkg/gc/db"
+	service_maintenance "getsturdy.com/api/pkg/maintenance/service"
 	"getsturdy.com/api/pkg/snapshots"
 	db_snapshots "getsturdy.com/api/pkg/snapshots/db"
 	service_suggestion "getsturdy.com/api/pkg/suggestions/service"
@@ -23,50 +26,180 @@ import (
 )
 
 type Service struct {
-	logger            *zap.Logger
-	gcRepo            db.Repository
-	viewRepo          db_view.Repository
-	snapshotsRepo     db_snapshots.Repository
-	workspaceReader   db_workspaces.WorkspaceReader
-	suggestionService *service_suggestion.Service
-	executorProvider  executor.Provider
+	logger             *zap.Logger
+	gcRepo             db.Repository
+	retentionRepo      db.RetentionPolicyRepository
+	viewRepo           db_view.Repository
+	snapshotsRepo      db_snapshots.Repository
+	workspaceReader    db_workspaces.WorkspaceReader
+	suggestionService  *service_suggestion.Service
+	maintenanceService *service_maintenance.Service
+	executorProvider   executor.Provider
 }
 
 func New(
 	logger *zap.Logger,
 	gcRepo db.Repository,
+	retentionRepo db.RetentionPolicyRepository,
 	viewRepo db_view.Repository,
 	snapshotsRepo db_snapshots.Repository,
 	workspaceReader db_workspaces.WorkspaceReader,
 	suggestionService *service_suggestion.Service,
+	maintenanceService *service_maintenance.Service,
 	executorProvider executor.Provider,
 ) *Service {
 	return &Service{
-		logger:            logger.Named("gcService"),
-		gcRepo:            gcRepo,
-		viewRepo:          viewRepo,
-		snapshotsRepo:     snapshotsRepo,
-		workspaceReader:   workspaceReader,
-		suggestionService: suggestionService,
-		executorProvider:  executorProvider,
+		logger:             logger.Named("gcService"),
+		gcRepo:             gcRepo,
+		retentionRepo:      retentionRepo,
+		viewRepo:           viewRepo,
+		snapshotsRepo:      snapshotsRepo,
+		workspaceReader:    workspaceReader,
+		suggestionService:  suggestionService,
+		maintenanceService: maintenanceService,
+		executorProvider:   executorProvider,
 	}
 }
 
-func (svc *Service) gcSnapshots(ctx context.Context, codebaseID string, snapshotThreshold time.Duration) error {
+// GetRetentionPolicy returns the snapshot retention policy of the codebase, or the default policy if the
+// codebase has not configured one.
+func (svc *Service) GetRetentionPolicy(ctx context.Context, codebaseID string) (*gc.SnapshotRetentionPolicy, error) {
+	policy, err := svc.retentionRepo.GetByCodebaseID(ctx, codebaseID)
+	switch {
+	case err == nil:
+		return policy, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return gc.DefaultSnapshotRetentionPolicy(codebaseID), nil
+	default:
+		return nil, fmt.Errorf("failed to get retention policy: %w", err)
+	}
+}
+
+func (svc *Service) UpdateRetentionPolicy(ctx context.Context, policy *gc.SnapshotRetentionPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	policy.UpdatedAt = time.Now()
+	if err := svc.retentionRepo.Upsert(ctx, policy); err != nil {
+		return fmt.Errorf("failed to update retention policy: %w", err)
+	}
+	return nil
+}
+
+// StorageUsed returns the number of bytes used on disk by the trunk and all views of the codebase, as of the last
+// time it was garbage collected. It returns sql.ErrNoRows if the codebase has not been garbage collected since the
+// storage used has been recorded.
+func (svc *Service) StorageUsed(ctx context.Context, codebaseID string) (int64, error) {
+	status, err := svc.gcRepo.GetLatestWithStorageUsed(ctx, codebaseID)
+	if err != nil {
+		return 0, err
+	}
+	return *status.StorageUsedBytes, nil
+}
+
+// calculateStorageUsed returns an estimate of the number of bytes used on disk by the trunk and all views of the
+// codebase. It walks all repositories, and is only run after garbage collection.
+func (svc *Service) calculateStorageUsed(codebaseID string) (int64, error) {
+	var total int64
+
+	if err := svc.executorProvider.New().Read(func(repo vcs.RepoReader) error {
+		size, err := dirSize(repo.Path())
+		if err != nil {
+			return err
+		}
+		total += size
+		return nil
+	}).ExecTrunk(codebaseID, "storageUsedTrunk"); err != nil {
+		return 0, fmt.Errorf("failed to calculate trunk size: %w", err)
+	}
+
+	views, err := svc.viewRepo.ListByCodebase(codebaseID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list views: %w", err)
+	}
+
+	for _, view := range views {
+		if err := svc.executorProvider.New().
+			AllowRebasingState().
+			Read(func(repo vcs.RepoReader) error {
+				size, err := dirSize(repo.Path())
+				if err != nil {
+					return err
+				}
+				total += size
+				return nil
+			}).ExecView(codebaseID, view.ID, "storageUsedView"); err != nil {
+			svc.logger.Warn("failed to calculate view size", zap.String("view_id", view.ID), zap.Error(err))
+			// do not fail
+		}
+	}
+
+	return total, nil
+}
+
+func dirSize(path string) (int64, error) {
+	var size int64
+	if err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	}); err != nil {
+		return 0, fmt.Errorf("failed to walk %s: %w", path, err)
+	}
+	return size, nil
+}
+
+func (svc *Service) gcSnapshots(ctx context.Context, codebaseID string, policy *gc.SnapshotRetentionPolicy) error {
+	// Snapshots are deleted from trunk, which is left untouched while it's quarantined.
+	if quarantined, err := svc.maintenanceService.IsQuarantined(ctx, codebaseID); err != nil {
+		return fmt.Errorf("failed to check if trunk is quarantined: %w", err)
+	} else if quarantined {
+		svc.logger.Warn("trunk is quarantined, skipping snapshots", zap.String("codebase_id", codebaseID))
+		return nil
+	}
+
 	// Delete snapshots older than
-	threshold := time.Now().Add(snapshotThreshold)
+	now := time.Now()
+	threshold := now.Add(-policy.KeepNewerThan())
 
 	// GC unused snapshots
-	snapshots, err := svc.snapshotsRepo.ListUndeletedInCodebase(codebaseID, threshold)
+	candidates, err := svc.snapshotsRepo.ListUndeletedInCodebase(codebaseID, threshold)
 	if err != nil && !errors.Is(err, sql.ErrNoRows) {
 		return fmt.Errorf("could not get snapshots: %w", err)
 	}
 
-	svc.logger.Info("cleaning up snapshots", zap.Int("total_snapshots", len(snapshots)))
+	var latest []*snapshots.Snapshot
+	if policy.KeepLastPerWorkspace > 0 {
+		latest, err = svc.snapshotsRepo.ListLatestUndeletedPerWorkspace(codebaseID, policy.KeepLastPerWorkspace)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("could not get latest snapshots: %w", err)
+		}
+	}
+
+	retained := policy.Retained(now, candidates, latest)
 
-	for _, snapshot := range snapshots {
+	svc.logger.Info("cleaning up snapshots",
+		zap.Int("total_snapshots", len(candidates)),
+		zap.Int("retained_by_policy", len(retained)),
+	)
+
+	for _, snapshot := range candidates {
 		logger := svc.logger.With(zap.String("snapshot_id", snapshot.ID))
 
+		if retained[snapshot.ID] {
+			logger.Info("snapshot is retained by policy, skipping")
+			continue
+		}
+
 		if err := svc.gcSnapshot(
 			ctx,
 			snapshot,
@@ -145,6 +278,17 @@ func (svc *Service) gcSnapshot(
 		return nil
 	}
 
+	// The snapshot is kept until the view that created it has been repaired, so that its branch is deleted from
+	// both repositories.
+	if snapshot.ViewID != "" && !strings.HasPrefix(snapshot.ViewID, "tmp-") {
+		if quarantined, err := svc.maintenanceService.IsQuarantined(ctx, snapshot.ViewID); err != nil {
+			return fmt.Errorf("failed to check if view is quarantined: %w", err)
+		} else if quarantined {
+			logger.Warn("view is quarantined, skipping", zap.String("view_id", snapshot.ViewID))
+			return nil
+		}
+	}
+
 	// Throttle heavy operations
 	time.Sleep(time.Second / 2)
 
@@ -213,16 +357,16 @@ func getGCInterval() time.Duration {
 	return time.Hour
 }
 
-func getSnapshotThreshold() time.Duration {
-	return -3 * time.Hour
-}
-
 func (svc *Service) Work(
 	ctx context.Context,
 	logger *zap.Logger,
 	codebaseID string,
 ) error {
-	return svc.WorkWithOptions(ctx, logger, codebaseID, getGCInterval(), getSnapshotThreshold())
+	policy, err := svc.GetRetentionPolicy(ctx, codebaseID)
+	if err != nil {
+		return err
+	}
+	return svc.WorkWithOptions(ctx, logger, codebaseID, getGCInterval(), policy)
 }
 
 func (svc *Service) WorkWithOptions(
@@ -230,7 +374,7 @@ func (svc *Service) WorkWithOptions(
 	logger *zap.Logger,
 	codebaseID string,
 	gcInterval time.Duration,
-	gcSnapshotsThreshold time.Duration,
+	policy *gc.SnapshotRetentionPolicy,
 ) error {
 	t0 := time.Now()
 
@@ -247,12 +391,18 @@ func (svc *Service) WorkWithOptions(
 
 	logger.Info("starting gc")
 
-	if err := svc.gcSnapshots(ctx, codebaseID, gcSnapshotsThreshold); err != nil {
+	if err := svc.gcSnapshots(ctx, codebaseID, policy); err != nil {
 		logger.Error("failed to gc snapshots", zap.Error(err))
 		// do not fail
 	}
 
-	if err := svc.executorProvider.New().GitWrite(func(trunkRepo vcs.RepoGitWriter) error {
+	// Quarantined repositories have failed fsck, and are left untouched until they have been repaired.
+	if quarantined, err := svc.maintenanceService.IsQuarantined(ctx, codebaseID); err != nil {
+		logger.Error("failed to check if trunk is quarantined", zap.Error(err))
+		// don't exit
+	} else if quarantined {
+		logger.Warn("trunk is quarantined, skipping git gc")
+	} else if err := svc.executorProvider.New().GitWrite(func(trunkRepo vcs.RepoGitWriter) error {
 		if err := trunkRepo.GitReflogExpire(); err != nil {
 			logger.Error("failed to run git-reflog expire on trunk", zap.Error(err))
 			// don't exit
@@ -280,6 +430,14 @@ func (svc *Service) WorkWithOptions(
 	for _, view := range views {
 		logger := logger.With(zap.String("view_id", view.ID))
 
+		if quarantined, err := svc.maintenanceService.IsQuarantined(ctx, view.ID); err != nil {
+			logger.Error("failed to check if view is quarantined", zap.Error(err))
+			continue
+		} else if quarantined {
+			logger.Warn("view is quarantined, skipping git gc")
+			continue
+		}
+
 		if err := svc.executorProvider.New().GitWrite(func(viewGitRepo vcs.RepoGitWriter) error {
 			if err := viewGitRepo.GitReflogExpire(); err != nil {
 				logger.Error("failed to run git-reflog expire on trunk", zap.Error(err))
@@ -308,11 +466,20 @@ func (svc *Service) WorkWithOptions(
 		}
 	}
 
+	var storageUsedBytes *int64
+	if size, err := svc.calculateStorageUsed(codebaseID); err != nil {
+		logger.Error("failed to calculate storage used", zap.Error(err))
+		// don't exit
+	} else {
+		storageUsedBytes = &size
+	}
+
 	now := time.Now()
 	if err := svc.gcRepo.Create(ctx, &gc.CodebaseGarbageStatus{
-		CodebaseID:     codebaseID,
-		CompletedAt:    now,
-		DurationMillis: now.Sub(t0).Milliseconds(),
+		CodebaseID:       codebaseID,
+		CompletedAt:      now,
+		DurationMillis:   now.Sub(t0).Milliseconds(),
+		StorageUsedBytes: storageUsedBytes,
 	}); err != nil {
 		return fmt.Errorf("failed to record gc run stats: %w", err)
 	}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"getsturdy.com/api/pkg/github"
+	github_client "getsturdy.com/api/pkg/github/enterprise/client"
+	github_vcs "getsturdy.com/api/pkg/github/enterprise/vcs"
+	"getsturdy.com/api/vcs"
+
+	"go.uber.org/zap"
+)
+
+// PushTag pushes a tag from trunk to the GitHub repository.
+func (s *Service) PushTag(ctx context.Context, gitHubRepository *github.GitHubRepository, tagName string) error {
+	installation, err := s.gitHubInstallationRepo.GetByInstallationID(gitHubRepository.InstallationID)
+	if err != nil {
+		return fmt.Errorf("failed to get github installation: %w", err)
+	}
+
+	logger := s.logger.With(
+		zap.Int64("github_installation_id", gitHubRepository.InstallationID),
+		zap.Int64("github_repository_id", gitHubRepository.GitHubRepositoryID),
+		zap.String("tag", tagName),
+	)
+
+	accessToken, err := github_client.GetAccessToken(
+		ctx,
+		logger,
+		s.gitHubAppConfig,
+		installation,
+		gitHubRepository.GitHubRepositoryID,
+		s.gitHubRepositoryRepo,
+		s.gitHubInstallationClientProvider,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get github access token: %w", err)
+	}
+
+	if err := s.executorProvider.New().GitWrite(func(repo vcs.RepoGitWriter) error {
+		if _, err := github_vcs.PushTagToGitHub(logger, repo, accessToken, tagName); err != nil {
+			return err
+		}
+		return nil
+	}).ExecTrunk(gitHubRepository.CodebaseID, "pushTagToGitHub"); err != nil {
+		return fmt.Errorf("failed to push tag to github: %w", err)
+	}
+
+	logger.Info("pushed tag to github")
+
+	return nil
+}
@@ -44,6 +44,15 @@ func PushTrackedToGitHub(logger *zap.Logger, repo vcs.RepoGitWriter, accessToken
 	return "", nil
 }
 
+func PushTagToGitHub(logger *zap.Logger, repo vcs.RepoGitWriter, accessToken, tagName string) (userError string, err error) {
+	refspec := fmt.Sprintf("refs/tags/%s:refs/tags/%s", tagName, tagName)
+	userError, err = repo.PushNamedRemoteWithRefspec(logger, "origin", newCredentialsCallback(accessToken), []string{refspec})
+	if err != nil {
+		return userError, fmt.Errorf("failed to push %s: %w", refspec, err)
+	}
+	return "", nil
+}
+
 func PushBranchToGithubWithForce(logger *zap.Logger, executorProvider executor.Provider, codebaseID, sturdyBranchName, remoteBranchName, accessToken string) (userError string, err error) {
 	refspec := fmt.Sprintf("+refs/heads/%s:refs/heads/%s", sturdyBranchName, remoteBranchName)
 
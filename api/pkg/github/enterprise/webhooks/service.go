@@ -26,6 +26,8 @@ import (
 	db_review "getsturdy.com/api/pkg/review/db"
 	service_statuses "getsturdy.com/api/pkg/statuses/service"
 	service_sync "getsturdy.com/api/pkg/sync/service"
+	sturdy_webhooks "getsturdy.com/api/pkg/webhooks"
+	sender_webhooks "getsturdy.com/api/pkg/webhooks/sender"
 	"getsturdy.com/api/pkg/workspaces/activity"
 	sender_workspace_activity "getsturdy.com/api/pkg/workspaces/activity/sender"
 	db_workspaces "getsturdy.com/api/pkg/workspaces/db"
@@ -56,6 +58,7 @@ type Service struct {
 	eventsSender     events.EventSender
 	analyticsService *service_analytics.Service
 	activitySender   sender_workspace_activity.ActivitySender
+	webhooksSender   sender_webhooks.Sender
 
 	syncService      *service_sync.Service
 	workspaceService service_workspace.Service
@@ -88,6 +91,7 @@ func New(
 	eventsSender events.EventSender,
 	analyticsService *service_analytics.Service,
 	activitySender sender_workspace_activity.ActivitySender,
+	webhooksSender sender_webhooks.Sender,
 
 	syncService *service_sync.Service,
 	workspaceService service_workspace.Service,
@@ -119,6 +123,7 @@ func New(
 		eventsSender:     eventsSender,
 		analyticsService: analyticsService,
 		activitySender:   activitySender,
+		webhooksSender:   webhooksSender,
 
 		syncService:      syncService,
 		workspaceService: workspaceService,
@@ -246,6 +251,11 @@ func (svc *Service) HandlePullRequestEvent(event *PullRequestEvent) error {
 			// do not fail
 		}
 
+		if err := svc.webhooksSender.Codebase(ctx, ws.CodebaseID, sturdy_webhooks.EventChangeLanded, string(ch.ID)); err != nil {
+			svc.logger.Error("failed to send webhook event", zap.Error(err))
+			// do not fail
+		}
+
 		if err := svc.buildQueue.EnqueueChange(ctx, ch); err != nil {
 			svc.logger.Error("failed to enqueue change", zap.Error(err))
 			// do not fail
@@ -8,4 +8,5 @@ func Module(c *di.Container) {
 	c.Register(NewImporterQueue)
 	c.Register(NewClonerQueue)
 	c.Register(NewWebhooksQueue)
+	c.Register(NewTagsQueue)
 }
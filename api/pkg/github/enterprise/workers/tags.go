@@ -0,0 +1,103 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/github"
+	service_github "getsturdy.com/api/pkg/github/enterprise/service"
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/names"
+
+	"go.uber.org/zap"
+)
+
+// TagsQueue pushes tags that are created in Sturdy to the GitHub repository of the codebase.
+type TagsQueue struct {
+	logger        *zap.Logger
+	queue         queue.Queue
+	name          names.IncompleteQueueName
+	gitHubService *service_github.Service
+}
+
+func NewTagsQueue(
+	logger *zap.Logger,
+	queue queue.Queue,
+	githubService *service_github.Service,
+) *TagsQueue {
+	return &TagsQueue{
+		logger:        logger.Named("GitHubTagsQueue"),
+		queue:         queue,
+		gitHubService: githubService,
+		name:          names.GitHubPushTag,
+	}
+}
+
+func (q *TagsQueue) Enqueue(ctx context.Context, event *github.PushTagEvent) error {
+	if err := q.queue.Publish(ctx, q.name, event); err != nil {
+		return fmt.Errorf("failed to publish event: %w", err)
+	}
+	return nil
+}
+
+func (q *TagsQueue) Start(ctx context.Context) error {
+	messages := make(chan queue.Message, 10)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				q.logger.Error("panic in runner", zap.String("panic", fmt.Sprintf("%v", rec)), zap.Stack("recovered"))
+			}
+		}()
+
+		for msg := range messages {
+			t0 := time.Now()
+
+			event := &github.PushTagEvent{}
+			if err := msg.As(event); err != nil {
+				q.logger.Error("failed to parse tag event in worker", zap.Error(err))
+				continue
+			}
+
+			logger := q.logger.With(zap.String("codebase_id", event.CodebaseID), zap.String("tag", event.TagName))
+
+			if err := q.push(ctx, event); err != nil {
+				logger.Error("failed to push tag", zap.Error(err))
+				continue
+			}
+
+			if err := msg.Ack(); err != nil {
+				logger.Error("failed to ack", zap.Error(err))
+				continue
+			}
+
+			logger.Info("pushed tag", zap.Duration("duration", time.Since(t0)))
+		}
+	}()
+
+	q.logger.Info("starting queue", zap.Stringer("queue_name", q.name))
+	if err := q.queue.Subscribe(ctx, q.name, messages); err != nil {
+		return fmt.Errorf("could not subscribe to queue: %w", err)
+	}
+	q.logger.Info("queue stoped", zap.Stringer("queue_name", q.name))
+
+	return nil
+}
+
+func (q *TagsQueue) push(ctx context.Context, event *github.PushTagEvent) error {
+	gitHubRepository, err := q.gitHubService.GetRepositoryByCodebaseID(ctx, event.CodebaseID)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		// the integration has been removed since the tag was created
+		return nil
+	default:
+		return fmt.Errorf("failed to get github repository: %w", err)
+	}
+	if !gitHubRepository.IntegrationEnabled {
+		return nil
+	}
+	return q.gitHubService.PushTag(ctx, gitHubRepository, event.TagName)
+}
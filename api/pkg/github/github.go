@@ -72,3 +72,9 @@ type CloneRepositoryEvent struct {
 	GitHubRepositoryID int64  `json:"github_repository_id"`
 	SenderUserID       string `json:"sender_user_id"`
 }
+
+// PushTagEvent is a tag that should be pushed from trunk to the GitHub repository of the codebase.
+type PushTagEvent struct {
+	CodebaseID string `json:"codebase_id"`
+	TagName    string `json:"tag_name"`
+}
@@ -148,7 +148,7 @@ func (r *codebaseGitHubIntegrationRootResolver) ImportGitHubPullRequests(ctx con
 		return nil, gqlerrors.Error(err)
 	}
 
-	if err := r.authService.CanWrite(ctx, cb); err != nil {
+	if err := r.authService.CanAdmin(ctx, cb); err != nil {
 		return nil, gqlerrors.Error(err)
 	}
 
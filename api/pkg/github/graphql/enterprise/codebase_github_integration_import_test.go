@@ -0,0 +1,40 @@
+package enterprise
+
+import (
+	"context"
+	"testing"
+
+	"getsturdy.com/api/pkg/auth"
+	service_auth "getsturdy.com/api/pkg/auth/service"
+	"getsturdy.com/api/pkg/codebase"
+	service_codebase "getsturdy.com/api/pkg/codebase/service"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/internal/inmemory"
+	"getsturdy.com/api/pkg/organization"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportGitHubPullRequests_member(t *testing.T) {
+	codebaseRepo := inmemory.NewInMemoryCodebaseRepo()
+	codebaseUserRepo := inmemory.NewInMemoryCodebaseUserRepo()
+	codebaseService := service_codebase.New(codebaseRepo, codebaseUserRepo, nil, nil, nil, nil, nil, nil)
+	resolver := &codebaseGitHubIntegrationRootResolver{
+		authService:     service_auth.New(codebaseService, nil, nil, nil, nil, nil, nil, nil),
+		codebaseService: codebaseService,
+	}
+
+	cb := codebase.Codebase{ID: uuid.NewString()}
+	assert.NoError(t, codebaseRepo.Create(cb))
+
+	userID := uuid.NewString()
+	assert.NoError(t, codebaseUserRepo.Create(codebase.CodebaseUser{ID: uuid.NewString(), CodebaseID: cb.ID, UserID: userID, Role: organization.RoleMember}))
+	ctx := auth.NewContext(context.Background(), &auth.Subject{Type: auth.SubjectUser, ID: userID})
+
+	_, err := resolver.ImportGitHubPullRequests(ctx, resolvers.ImportGitHubPullRequestsInputArgs{
+		Input: resolvers.ImportGitHubPullRequestsInput{CodebaseID: graphql.ID(cb.ID)},
+	})
+	assert.ErrorIs(t, err, auth.ErrForbidden)
+}
@@ -2,18 +2,21 @@ package gitserver
 
 import (
 	"bytes"
-	"database/sql"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os/exec"
 	"strings"
 	"time"
 
+	"getsturdy.com/api/pkg/accesstokens"
+	service_accesstokens "getsturdy.com/api/pkg/accesstokens/service"
 	service_codebase "getsturdy.com/api/pkg/codebase/service"
 	"getsturdy.com/api/pkg/configuration/flags"
 	"getsturdy.com/api/pkg/gitserver/pack"
+	"getsturdy.com/api/pkg/ip"
 	"getsturdy.com/api/pkg/jwt"
 	service_jwt "getsturdy.com/api/pkg/jwt/service"
 	"getsturdy.com/api/pkg/servicetokens"
@@ -28,7 +31,8 @@ import (
 )
 
 type Configuration struct {
-	Addr flags.Addr `long:"addr" description:"listen address" default:"127.0.0.1:3002"`
+	Addr           flags.Addr `long:"addr" description:"listen address" default:"127.0.0.1:3002"`
+	TrustedProxies []string   `long:"trusted-proxy" description:"Address or CIDR of a proxy in front of the server, the ip of the client is read from the X-Forwarded-For header of requests from trusted proxies (can be provided multiple times)"`
 }
 
 type Server struct {
@@ -37,6 +41,7 @@ type Server struct {
 
 	serviceTokensService *service_servicetokens.Service
 	jwtTokensService     *service_jwt.Service
+	accessTokensService  *service_accesstokens.Service
 	codebaseService      *service_codebase.Service
 	executorProvider     executor.Provider
 
@@ -55,18 +60,23 @@ func New(
 	cfg *Configuration,
 	serviceTokensService *service_servicetokens.Service,
 	jwtTokensService *service_jwt.Service,
+	accessTokensService *service_accesstokens.Service,
 	codebaeService *service_codebase.Service,
 	executorProvider executor.Provider,
 ) *Server {
 	gin.SetMode(ginMode())
 	ginRouter := gin.New()
-	ginRouter.SetTrustedProxies(nil)
+	if err := ginRouter.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		logger.Error("invalid trusted proxies", zap.Error(err))
+		_ = ginRouter.SetTrustedProxies(nil)
+	}
 	return &Server{
 		logger: logger,
 		cfg:    cfg,
 
 		serviceTokensService: serviceTokensService,
 		jwtTokensService:     jwtTokensService,
+		accessTokensService:  accessTokensService,
 		codebaseService:      codebaeService,
 		executorProvider:     executorProvider,
 
@@ -82,7 +92,7 @@ func (h *Server) Start() error {
 	ciIntegrationGroup.GET("/info/refs", h.handleInfoRefs)
 	ciIntegrationGroup.POST("/git-upload-pack", h.handleGitUploadPack)
 
-	importGroup := h.router.Group("/:codebaseId").Use(h.jwtTokenAuth)
+	importGroup := h.router.Group("/:codebaseId").Use(h.userTokenAuth)
 	importGroup.GET("/info/refs", h.handleInfoRefs)
 	importGroup.POST("/git-receive-pack", h.handleGitReceivePack)
 
@@ -101,7 +111,9 @@ const (
 	ciRepo    = "ci"
 )
 
-func (h *Server) jwtTokenAuth(c *gin.Context) {
+// userTokenAuth authenticates users with either a session token, or a personal access token with the
+// workspaces:write scope.
+func (h *Server) userTokenAuth(c *gin.Context) {
 	username, password, ok := c.Request.BasicAuth()
 	if !ok {
 		c.Header("WWW-Authenticate", "Basic realm=Authorization Required")
@@ -109,18 +121,43 @@ func (h *Server) jwtTokenAuth(c *gin.Context) {
 		return
 	}
 
-	if username != "import" {
-		c.AbortWithStatus(http.StatusUnauthorized)
-		return
-	}
+	var userID string
+	if accesstokens.IsAccessToken(password) {
+		// personal access tokens can be used with any username
+		token, err := h.accessTokensService.Authenticate(c.Request.Context(), password)
+		switch {
+		case err == nil:
+		case errors.Is(err, service_accesstokens.ErrUnauthenticated):
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		default:
+			h.logger.Error("failed to authenticate access token", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
 
-	userToken, err := h.jwtTokensService.Verify(c.Request.Context(), password, jwt.TokenTypeAuth)
-	if err != nil {
-		c.AbortWithStatus(http.StatusUnauthorized)
-		return
+		if !token.Scopes.Has(accesstokens.ScopeWorkspacesWrite) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		userID = token.UserID
+	} else {
+		if username != "import" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		userToken, err := h.jwtTokensService.Verify(c.Request.Context(), password, jwt.TokenTypeAuth)
+		if err != nil {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+
+		userID = userToken.Subject
 	}
 
-	accessAllowed, err := h.codebaseService.CanAccess(c.Request.Context(), userToken.Subject, c.Param("codebaseId"))
+	accessAllowed, err := h.codebaseService.CanAccess(c.Request.Context(), userID, c.Param("codebaseId"))
 	if err != nil {
 		h.logger.Error("failed to check access", zap.Error(err))
 		c.AbortWithStatus(http.StatusInternalServerError)
@@ -132,9 +169,11 @@ func (h *Server) jwtTokenAuth(c *gin.Context) {
 		return
 	}
 
-	c.Set(userIDKey, userToken.Subject)
+	c.Set(userIDKey, userID)
 }
 
+// serviceTokenAuth authenticates CI with a service token that has the ci:fetch capability. The username is the ID of
+// the token, and the password is its secret.
 func (h *Server) serviceTokenAuth(c *gin.Context) {
 	username, password, ok := c.Request.BasicAuth()
 	if !ok {
@@ -143,17 +182,29 @@ func (h *Server) serviceTokenAuth(c *gin.Context) {
 		return
 	}
 
-	token, err := h.serviceTokensService.Get(c.Request.Context(), username)
-	if errors.Is(err, sql.ErrNoRows) {
+	ctx := c.Request.Context()
+	// the ip of the client is used for the allow-list of the token, which behind a load balancer needs the load balancer
+	// to be a trusted proxy
+	if clientIP := net.ParseIP(c.ClientIP()); clientIP != nil {
+		ctx = ip.NewContext(ctx, clientIP)
+	}
+
+	token, err := h.serviceTokensService.Authenticate(ctx, username, password)
+	switch {
+	case err == nil:
+	case errors.Is(err, service_servicetokens.ErrUnauthenticated):
 		c.AbortWithStatus(http.StatusUnauthorized)
 		return
-	} else if err != nil {
+	case errors.Is(err, service_servicetokens.ErrForbidden):
+		c.AbortWithStatus(http.StatusForbidden)
+		return
+	default:
 		c.AbortWithError(http.StatusInternalServerError, err)
 		return
 	}
 
-	if err := token.Verify(password); err != nil {
-		c.AbortWithStatus(http.StatusUnauthorized)
+	if err := h.serviceTokensService.Authorize(ctx, token, servicetokens.CapabilityFetchCI); err != nil {
+		c.AbortWithStatus(http.StatusForbidden)
 		return
 	}
 
@@ -5,9 +5,11 @@ import (
 	_ "embed"
 	goerrors "errors"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
+	service_accesstokens "getsturdy.com/api/pkg/accesstokens/service"
 	"getsturdy.com/api/pkg/auth"
 	"getsturdy.com/api/pkg/ctxlog"
 	"getsturdy.com/api/pkg/graphql/dataloader"
@@ -16,6 +18,7 @@ import (
 	"getsturdy.com/api/pkg/graphql/schema"
 	"getsturdy.com/api/pkg/ip"
 	service_jwt "getsturdy.com/api/pkg/jwt/service"
+	service_servicetokens "getsturdy.com/api/pkg/servicetokens/service"
 
 	"github.com/gin-gonic/gin"
 	"github.com/graph-gophers/graphql-go"
@@ -31,16 +34,21 @@ import (
 
 type RootResolver struct {
 	resolvers.ACLRootResolver
+	resolvers.AccessTokensRootResolver
 	resolvers.AuthorRootResolver
+	resolvers.AutoRevertRootResolver
 	resolvers.BuildkiteInstantIntegrationRootResolver
 	resolvers.ChangeRootResolver
+	resolvers.ChatWebhooksRootResolver
 	resolvers.CodebaseGitHubIntegrationRootResolver
 	resolvers.CodebaseRootResolver
 	resolvers.CommentRootResolver
 	resolvers.FeaturesRootResolver
+	resolvers.FileDiffRootResolver
 	resolvers.GitHubAppRootResolver
 	resolvers.GitHubPullRequestRootResolver
 	resolvers.GitHubRootResolver
+	resolvers.GuestInvitesRootResolver
 	resolvers.IntegrationRootResolver
 	resolvers.LicenseRootResolver
 	resolvers.NotificationRootResolver
@@ -48,36 +56,52 @@ type RootResolver struct {
 	resolvers.OrganizationRootResolver
 	resolvers.PKIRootResolver
 	resolvers.PresenceRootResolver
+	resolvers.ReleaseRootResolver
 	resolvers.ReviewRootResolver
+	resolvers.SCIMRootResolver
 	resolvers.InstallationsRootResolver
 	resolvers.ServiceTokensRootResolver
+	resolvers.SessionsRootResolver
+	resolvers.SnapshotRetentionPolicyRootResolver
+	resolvers.StorageHealthRootResolver
 	resolvers.StatusesRootResolver
 	resolvers.SuggestionRootResolver
+	resolvers.TwoFactorRootResolver
 	resolvers.UserRootResolver
 	resolvers.ViewRootResolver
+	resolvers.WebhooksRootResolver
 	resolvers.WorkspaceActivityRootResolver
 	resolvers.WorkspaceRootResolver
 	resolvers.WorkspaceWatcherRootResolver
 
-	schema     *graphql.Schema
-	jwtService *service_jwt.Service
-	logger     *zap.Logger
+	schema               *graphql.Schema
+	jwtService           *service_jwt.Service
+	accessTokensService  *service_accesstokens.Service
+	serviceTokensService *service_servicetokens.Service
+	logger               *zap.Logger
 }
 
 func NewRootResolver(
 	logger *zap.Logger,
 	jwtService *service_jwt.Service,
+	accessTokensService *service_accesstokens.Service,
+	serviceTokensService *service_servicetokens.Service,
 
 	aclResovler resolvers.ACLRootResolver,
+	accessTokensRootResolver resolvers.AccessTokensRootResolver,
 	authorResolver resolvers.AuthorRootResolver,
+	autoRevertRootResolver resolvers.AutoRevertRootResolver,
 	buildkiteRootResolver resolvers.BuildkiteInstantIntegrationRootResolver,
 	changeResolver resolvers.ChangeRootResolver,
+	chatWebhooksRootResolver resolvers.ChatWebhooksRootResolver,
 	codebaseGitHubIntegrationResolver resolvers.CodebaseGitHubIntegrationRootResolver,
 	codebaseResolver resolvers.CodebaseRootResolver,
 	commentsResolver resolvers.CommentRootResolver,
 	featuresRootResolver resolvers.FeaturesRootResolver,
+	fileDiffRootResolver resolvers.FileDiffRootResolver,
 	gitHubRootResolver resolvers.GitHubRootResolver,
 	githubAppResolver resolvers.GitHubAppRootResolver,
+	guestInvitesRootResolver resolvers.GuestInvitesRootResolver,
 	instantIntegrationRootResolver resolvers.IntegrationRootResolver,
 	licenseRootResolver resolvers.LicenseRootResolver,
 	notificationResolver resolvers.NotificationRootResolver,
@@ -86,32 +110,46 @@ func NewRootResolver(
 	pkiRootResolver resolvers.PKIRootResolver,
 	prResolver resolvers.GitHubPullRequestRootResolver,
 	presenceRootResolver resolvers.PresenceRootResolver,
+	releaseRootResolver resolvers.ReleaseRootResolver,
 	reviewResolver resolvers.ReviewRootResolver,
+	scimRootResolver resolvers.SCIMRootResolver,
 	serverStatusRootResolver resolvers.InstallationsRootResolver,
 	serviceTokensRootResolver resolvers.ServiceTokensRootResolver,
+	sessionsRootResolver resolvers.SessionsRootResolver,
+	snapshotRetentionPolicyRootResolver resolvers.SnapshotRetentionPolicyRootResolver,
+	storageHealthRootResolver resolvers.StorageHealthRootResolver,
 	statusRootResolver resolvers.StatusesRootResolver,
 	suggestionResolver resolvers.SuggestionRootResolver,
+	twoFactorRootResolver resolvers.TwoFactorRootResolver,
 	userResolver resolvers.UserRootResolver,
 	viewResolver resolvers.ViewRootResolver,
+	webhooksRootResolver resolvers.WebhooksRootResolver,
 	workspaceActivityResolver resolvers.WorkspaceActivityRootResolver,
 	workspaceResolver resolvers.WorkspaceRootResolver,
 	workspaceWatcherRootResolver resolvers.WorkspaceWatcherRootResolver,
 ) *RootResolver {
 	r := &RootResolver{
-		jwtService: jwtService,
-		logger:     logger,
+		jwtService:           jwtService,
+		accessTokensService:  accessTokensService,
+		serviceTokensService: serviceTokensService,
+		logger:               logger,
 
 		ACLRootResolver:                         aclResovler,
+		AccessTokensRootResolver:                accessTokensRootResolver,
 		AuthorRootResolver:                      authorResolver,
+		AutoRevertRootResolver:                  autoRevertRootResolver,
 		BuildkiteInstantIntegrationRootResolver: buildkiteRootResolver,
 		ChangeRootResolver:                      changeResolver,
+		ChatWebhooksRootResolver:                chatWebhooksRootResolver,
 		CodebaseGitHubIntegrationRootResolver:   codebaseGitHubIntegrationResolver,
 		CodebaseRootResolver:                    codebaseResolver,
 		CommentRootResolver:                     commentsResolver,
 		FeaturesRootResolver:                    featuresRootResolver,
+		FileDiffRootResolver:                    fileDiffRootResolver,
 		GitHubAppRootResolver:                   githubAppResolver,
 		GitHubPullRequestRootResolver:           prResolver,
 		GitHubRootResolver:                      gitHubRootResolver,
+		GuestInvitesRootResolver:                guestInvitesRootResolver,
 		IntegrationRootResolver:                 instantIntegrationRootResolver,
 		LicenseRootResolver:                     licenseRootResolver,
 		NotificationRootResolver:                notificationResolver,
@@ -119,20 +157,27 @@ func NewRootResolver(
 		OrganizationRootResolver:                organizationRootResolver,
 		PKIRootResolver:                         pkiRootResolver,
 		PresenceRootResolver:                    presenceRootResolver,
+		ReleaseRootResolver:                     releaseRootResolver,
 		ReviewRootResolver:                      reviewResolver,
+		SCIMRootResolver:                        scimRootResolver,
 		InstallationsRootResolver:               serverStatusRootResolver,
 		ServiceTokensRootResolver:               serviceTokensRootResolver,
+		SessionsRootResolver:                    sessionsRootResolver,
+		SnapshotRetentionPolicyRootResolver:     snapshotRetentionPolicyRootResolver,
+		StorageHealthRootResolver:               storageHealthRootResolver,
 		StatusesRootResolver:                    statusRootResolver,
 		SuggestionRootResolver:                  suggestionResolver,
+		TwoFactorRootResolver:                   twoFactorRootResolver,
 		UserRootResolver:                        userResolver,
 		ViewRootResolver:                        viewResolver,
+		WebhooksRootResolver:                    webhooksRootResolver,
 		WorkspaceActivityRootResolver:           workspaceActivityResolver,
 		WorkspaceRootResolver:                   workspaceResolver,
 		WorkspaceWatcherRootResolver:            workspaceWatcherRootResolver,
 	}
 
 	logger = logger.Named("graphql")
-	tracer := &metricTracer{logger: logger}
+	tracer := &scopeTracer{Tracer: &metricTracer{logger: logger}}
 	r.schema = parseSchema(r, tracer, logger)
 
 	return r
@@ -149,8 +194,8 @@ func (r *RootResolver) HttpHandler() gin.HandlerFunc {
 
 		ctx = dataloader.NewContext(ctx)
 
-		if remoteIP, _ := c.RemoteIP(); remoteIP != nil {
-			ctx = ip.NewContext(ctx, remoteIP)
+		if clientIP := net.ParseIP(c.ClientIP()); clientIP != nil {
+			ctx = ip.NewContext(ctx, clientIP)
 		} else {
 			r.logger.Error("could not find and set remoteIP", zap.String("remote_addr", c.Request.RemoteAddr))
 		}
@@ -161,7 +206,7 @@ func (r *RootResolver) HttpHandler() gin.HandlerFunc {
 
 func (r *RootResolver) UnauthenticatedHttpHandler(logger *zap.Logger) gin.HandlerFunc {
 	// Don't run against the real schema
-	fakeSchema := parseSchema(&RootResolver{}, nil, logger)
+	fakeSchema := parseSchema(&RootResolver{}, trace.NoopTracer{}, logger)
 	h := &relay.Handler{Schema: fakeSchema}
 	return func(c *gin.Context) {
 		h.ServeHTTP(c.Writer, c.Request)
@@ -169,11 +214,13 @@ func (r *RootResolver) UnauthenticatedHttpHandler(logger *zap.Logger) gin.Handle
 }
 
 type websocketContextBuilder struct {
-	jwtService *service_jwt.Service
+	jwtService           *service_jwt.Service
+	accessTokensService  *service_accesstokens.Service
+	serviceTokensService *service_servicetokens.Service
 }
 
 func (c *websocketContextBuilder) BuildContext(ctx context.Context, r *http.Request) (context.Context, error) {
-	subject, err := auth.SubjectFromRequest(r, c.jwtService)
+	subject, err := auth.SubjectFromRequest(r, c.jwtService, c.accessTokensService, c.serviceTokensService)
 	if err != nil {
 		return nil, err
 	}
@@ -188,7 +235,9 @@ func (r *RootResolver) WebsocketHandler() gin.HandlerFunc {
 	h := graphqlws.NewHandlerFunc(r.schema, &relay.Handler{
 		Schema: r.schema,
 	}, graphqlws.WithContextGenerator(&websocketContextBuilder{
-		jwtService: r.jwtService,
+		jwtService:           r.jwtService,
+		accessTokensService:  r.accessTokensService,
+		serviceTokensService: r.serviceTokensService,
 	}))
 
 	return func(c *gin.Context) {
@@ -223,7 +272,7 @@ func CorsMiddleware(allowOrigins []string) func(*gin.Context) {
 
 // Reads and parses the schema from file.
 // Associates root resolver. Panics if can't read.
-func parseSchema(resolver interface{}, tracer *metricTracer, logger *zap.Logger) *graphql.Schema {
+func parseSchema(resolver interface{}, tracer trace.Tracer, logger *zap.Logger) *graphql.Schema {
 	parsedSchema, err := graphql.ParseSchema(
 		schema.String,
 		resolver,
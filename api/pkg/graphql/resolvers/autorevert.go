@@ -0,0 +1,46 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type AutoRevertRootResolver interface {
+	// Internal
+	InternalConfigByCodebaseID(ctx context.Context, codebaseID string) (AutoRevertConfigResolver, error)
+	InternalRevertByID(ctx context.Context, id string) (AutoRevertResolver, error)
+
+	// Mutations
+	UpdateAutoRevertConfig(context.Context, UpdateAutoRevertConfigArgs) (AutoRevertConfigResolver, error)
+}
+
+type UpdateAutoRevertConfigArgs struct {
+	Input UpdateAutoRevertConfigInput
+}
+
+type UpdateAutoRevertConfigInput struct {
+	CodebaseID     graphql.ID
+	Enabled        *bool
+	RequiredChecks *[]string
+	OnCallGroupID  *string
+	AutoLand       *bool
+}
+
+type AutoRevertConfigResolver interface {
+	ID() graphql.ID
+	Enabled() bool
+	RequiredChecks() []string
+	OnCallGroupID() *string
+	AutoLand() bool
+	UpdatedAt() *int32
+}
+
+type AutoRevertResolver interface {
+	ID() graphql.ID
+	Change(context.Context) (ChangeResolver, error)
+	Status(context.Context) (StatusResolver, error)
+	Workspace(context.Context) (WorkspaceResolver, error)
+	LandedChange(context.Context) (ChangeResolver, error)
+	CreatedAt() int32
+}
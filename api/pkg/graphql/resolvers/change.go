@@ -11,6 +11,9 @@ import (
 
 type ChangeRootResolver interface {
 	Change(ctx context.Context, args ChangeArgs) (ChangeResolver, error)
+
+	// Internal
+	InternalChange(*change.Change) ChangeResolver
 }
 
 type ChangeArgs struct {
@@ -34,9 +37,47 @@ type ChangeResolver interface {
 	DownloadZip(context.Context) (ContentsDownloadUrlResolver, error)
 }
 
+type ChangeConnectionResolver interface {
+	Edges() []ChangeEdgeResolver
+	PageInfo() PageInfoResolver
+}
+
+type ChangeEdgeResolver interface {
+	Cursor() string
+	Node() ChangeResolver
+}
+
+type PageInfoResolver interface {
+	HasNextPage() bool
+	EndCursor() *string
+}
+
 type FileDiffRootResolver interface {
+	// Mutations
+	SetFileDiffViewed(context.Context, SetFileDiffViewedArgs) (ViewedFileResolver, error)
+
 	// Internal
 	InternalFileDiff(*unidiff.FileDiff) FileDiffResolver
+	// InternalWorkspaceFileDiff returns a resolver of a diff in the workspace, that can be marked as viewed
+	InternalWorkspaceFileDiff(workspaceID string, diff *unidiff.FileDiff) FileDiffResolver
+}
+
+type SetFileDiffViewedArgs struct {
+	Input SetFileDiffViewedInput
+}
+
+type SetFileDiffViewedInput struct {
+	WorkspaceID graphql.ID
+	Path        string
+	ContentHash string
+	Viewed      bool
+}
+
+type ViewedFileResolver interface {
+	ID() graphql.ID
+	Path() string
+	ContentHash() string
+	IsViewed() bool
 }
 
 type FileDiffResolver interface {
@@ -54,6 +95,9 @@ type FileDiffResolver interface {
 	IsHidden() bool
 
 	Hunks() ([]HunkResolver, error)
+
+	ContentHash() string
+	ViewedByMe(context.Context) (bool, error)
 }
 
 type LargeFileInfoResolver interface {
@@ -0,0 +1,43 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type ChatWebhooksRootResolver interface {
+	// Internal
+	InternalListByUserID(ctx context.Context, userID string) ([]ChatWebhookResolver, error)
+	InternalListByCodebaseID(ctx context.Context, codebaseID string) ([]ChatWebhookResolver, error)
+
+	// Mutations
+	CreateChatWebhook(context.Context, CreateChatWebhookArgs) (ChatWebhookResolver, error)
+	DeleteChatWebhook(context.Context, DeleteChatWebhookArgs) (ChatWebhookResolver, error)
+}
+
+type CreateChatWebhookArgs struct {
+	Input CreateChatWebhookInput
+}
+
+type CreateChatWebhookInput struct {
+	Provider   string
+	URL        string
+	CodebaseID *graphql.ID
+}
+
+type DeleteChatWebhookArgs struct {
+	Input DeleteChatWebhookInput
+}
+
+type DeleteChatWebhookInput struct {
+	ID graphql.ID
+}
+
+type ChatWebhookResolver interface {
+	ID() graphql.ID
+	Provider() string
+	Host() string
+	Codebase(context.Context) (CodebaseResolver, error)
+	CreatedAt() int32
+}
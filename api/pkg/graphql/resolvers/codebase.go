@@ -23,6 +23,7 @@ type CodebaseRootResolver interface {
 	UpdateCodebase(ctx context.Context, args UpdateCodebaseArgs) (CodebaseResolver, error)
 	AddUserToCodebase(ctx context.Context, args AddUserToCodebaseArgs) (CodebaseResolver, error)
 	RemoveUserFromCodebase(ctx context.Context, args RemoveUserFromCodebaseArgs) (CodebaseResolver, error)
+	UpdateCodebaseMemberRole(ctx context.Context, args UpdateCodebaseMemberRoleArgs) (CodebaseResolver, error)
 }
 
 type CodebaseViewsArgs struct {
@@ -43,12 +44,13 @@ type UpdateCodebaseArgs struct {
 }
 
 type UpdateCodebaseInput struct {
-	ID                 graphql.ID
-	Name               *string
-	DisableInviteCode  *bool
-	GenerateInviteCode *bool
-	Archive            *bool
-	IsPublic           *bool
+	ID                    graphql.ID
+	Name                  *string
+	DisableInviteCode     *bool
+	GenerateInviteCode    *bool
+	Archive               *bool
+	IsPublic              *bool
+	DismissStaleApprovals *bool
 }
 
 type CodebaseResolver interface {
@@ -69,13 +71,23 @@ type CodebaseResolver interface {
 	IsReady() bool
 	ACL(context.Context) (ACLResolver, error)
 	Changes(ctx context.Context, args *CodebaseChangesArgs) ([]ChangeResolver, error)
+	ChangesConnection(ctx context.Context, args CodebaseChangesConnectionArgs) (ChangeConnectionResolver, error)
 	Readme(ctx context.Context) (FileResolver, error)
 	File(ctx context.Context, args CodebaseFileArgs) (FileOrDirectoryResolver, error)
 	Integrations(ctx context.Context, args IntegrationsArgs) ([]IntegrationResolver, error)
 	IsPublic() bool
+	DismissStaleApprovals() bool
 	Organization(ctx context.Context) (OrganizationResolver, error)
 
 	Writeable(context.Context) bool
+
+	SnapshotRetentionPolicy(context.Context) (SnapshotRetentionPolicyResolver, error)
+	Tags(context.Context) ([]TagResolver, error)
+	Releases(context.Context) ([]ReleaseResolver, error)
+	AutoRevertConfig(context.Context) (AutoRevertConfigResolver, error)
+	Webhooks(context.Context) ([]WebhookResolver, error)
+	ChatWebhooks(context.Context) ([]ChatWebhookResolver, error)
+	ServiceTokens(context.Context) ([]ServiceTokenResovler, error)
 }
 
 type CodebaseChangesArgs struct {
@@ -86,6 +98,21 @@ type CodebaseChangesInput struct {
 	Limit *int32
 }
 
+type CodebaseChangesConnectionArgs struct {
+	First  *int32
+	After  *string
+	Filter *ChangesFilter
+}
+
+type ChangesFilter struct {
+	AuthorID    *graphql.ID
+	AuthorEmail *string
+	PathPrefix  *string
+	Since       *int32
+	Until       *int32
+	Query       *string
+}
+
 type CodebaseFileArgs struct {
 	Path string
 }
@@ -105,6 +132,7 @@ type AddUserToCodebaseArgs struct {
 type AddUserToCodebaseInput struct {
 	CodebaseID graphql.ID
 	Email      string
+	Role       *string
 }
 
 type RemoveUserFromCodebaseArgs struct {
@@ -115,3 +143,13 @@ type RemoveUserFromCodebaseInput struct {
 	CodebaseID graphql.ID
 	UserID     graphql.ID
 }
+
+type UpdateCodebaseMemberRoleArgs struct {
+	Input UpdateCodebaseMemberRoleInput
+}
+
+type UpdateCodebaseMemberRoleInput struct {
+	CodebaseID graphql.ID
+	UserID     graphql.ID
+	Role       string
+}
@@ -13,14 +13,19 @@ type CommentRootResolver interface {
 	Comment(ctx context.Context, args CommentArgs) (CommentResolver, error)
 	InternalWorkspaceComments(workspace *workspaces.Workspace) ([]CommentResolver, error)
 	InternalCountByWorkspaceID(context.Context, string) (int32, error)
+	InternalCountUnresolvedByWorkspaceID(context.Context, string) (int32, error)
 
 	// Mutations
 	DeleteComment(ctx context.Context, args DeleteCommentArgs) (CommentResolver, error)
 	UpdateComment(ctx context.Context, args UpdateCommentArgs) (CommentResolver, error)
 	CreateComment(ctx context.Context, args CreateCommentArgs) (CommentResolver, error)
+	ResolveComment(ctx context.Context, args ResolveCommentArgs) (CommentResolver, error)
+	UnresolveComment(ctx context.Context, args ResolveCommentArgs) (CommentResolver, error)
+	ApplyCommentSuggestion(ctx context.Context, args ApplyCommentSuggestionArgs) (CommentResolver, error)
 
 	// Subscriptions
 	UpdatedComment(ctx context.Context, args UpdatedCommentArgs) (<-chan CommentResolver, error)
+	UpdatedCommentResolution(ctx context.Context, args UpdatedCommentResolutionArgs) (<-chan TopCommentResolver, error)
 
 	// Internal
 	PreFetchedComment(c comments.Comment) (CommentResolver, error)
@@ -35,6 +40,26 @@ type UpdatedCommentArgs struct {
 	ViewID      *graphql.ID
 }
 
+type UpdatedCommentResolutionArgs struct {
+	CodebaseID *graphql.ID
+}
+
+type ResolveCommentArgs struct {
+	Input ResolveCommentInput
+}
+
+type ResolveCommentInput struct {
+	ID graphql.ID
+}
+
+type ApplyCommentSuggestionArgs struct {
+	Input ApplyCommentSuggestionInput
+}
+
+type ApplyCommentSuggestionInput struct {
+	ID graphql.ID
+}
+
 type DeleteCommentArgs struct {
 	ID graphql.ID
 }
@@ -64,6 +89,7 @@ type CreateCommentInput struct {
 	ChangeID    *graphql.ID
 	WorkspaceID *graphql.ID
 	ViewID      *graphql.ID
+	Suggestion  *string
 }
 
 type CommentResolver interface {
@@ -87,6 +113,11 @@ type TopCommentResolver interface {
 	Change(ctx context.Context) (ChangeResolver, error)
 	Replies() ([]ReplyCommentResolver, error)
 	CodeContext() CommentCodeContext
+	IsResolved() bool
+	ResolvedAt() *int32
+	ResolvedBy(context.Context) (AuthorResolver, error)
+	IsOutdated(context.Context) (bool, error)
+	Suggestion() CommentSuggestionResolver
 }
 
 type ReplyCommentResolver interface {
@@ -98,6 +129,13 @@ type ReplyCommentResolver interface {
 	Parent(context.Context) (TopCommentResolver, error)
 }
 
+type CommentSuggestionResolver interface {
+	Replacement() string
+	IsApplied() bool
+	AppliedAt() *int32
+	AppliedBy(context.Context) (AuthorResolver, error)
+}
+
 type CommentCodeContext interface {
 	ID() graphql.ID
 	Path() string
@@ -0,0 +1,56 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type GuestInvitesRootResolver interface {
+	CreateGuestInvite(context.Context, CreateGuestInviteArgs) (GuestInviteResolver, error)
+	AcceptGuestInvite(context.Context, AcceptGuestInviteArgs) (GuestInviteResolver, error)
+	RevokeGuestInvite(context.Context, RevokeGuestInviteArgs) (GuestInviteResolver, error)
+
+	// Internal
+	InternalListByWorkspaceID(ctx context.Context, workspaceID string) ([]GuestInviteResolver, error)
+}
+
+type CreateGuestInviteArgs struct {
+	Input CreateGuestInviteInput
+}
+
+type CreateGuestInviteInput struct {
+	WorkspaceID  graphql.ID
+	Capabilities []string
+	ExpiresAt    *int32
+}
+
+type AcceptGuestInviteArgs struct {
+	Input AcceptGuestInviteInput
+}
+
+type AcceptGuestInviteInput struct {
+	Code string
+}
+
+type RevokeGuestInviteArgs struct {
+	Input RevokeGuestInviteInput
+}
+
+type RevokeGuestInviteInput struct {
+	ID graphql.ID
+}
+
+type GuestInviteResolver interface {
+	ID() graphql.ID
+	Workspace(context.Context) (WorkspaceResolver, error)
+	Capabilities() []string
+	CreatedBy(context.Context) (AuthorResolver, error)
+	CreatedAt() int32
+	ExpiresAt() int32
+	AcceptedBy(context.Context) (AuthorResolver, error)
+	AcceptedAt() *int32
+	RevokedAt() *int32
+
+	Code() *string
+}
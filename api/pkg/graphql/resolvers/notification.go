@@ -34,6 +34,8 @@ type NotificationResolver interface {
 	ToReviewNotification() (ReviewNotificationResolver, bool)
 	ToNewSuggestionNotification() (NewSuggestionNotificationResolver, bool)
 	ToGitHubRepositoryImported() (GitHubRepositoryImportedNotificationResovler, bool)
+	ToAutoRevertNotification() (AutoRevertNotificationResolver, bool)
+	ToReviewDismissedNotification() (ReviewDismissedNotificationResolver, bool)
 
 	commonNotificationResolver
 }
@@ -63,6 +65,16 @@ type GitHubRepositoryImportedNotificationResovler interface {
 	Repository(context.Context) (CodebaseGitHubIntegrationResolver, error)
 }
 
+type AutoRevertNotificationResolver interface {
+	commonNotificationResolver
+	Revert(context.Context) (AutoRevertResolver, error)
+}
+
+type ReviewDismissedNotificationResolver interface {
+	commonNotificationResolver
+	Review(ctx context.Context) (ReviewResolver, error)
+}
+
 type ArchiveNotificationsArgs struct {
 	Input ArchiveNotificationsInput
 }
@@ -90,6 +102,8 @@ const (
 	NotificationTypeRequestedReview      NotificationType = "RequestedReview"
 	NotificationTypeNewSuggestion        NotificationType = "NewSuggestion"
 	NotificationGitHubRepositoryImported NotificationType = "GitHubRepositoryImported"
+	NotificationTypeAutoRevert           NotificationType = "AutoRevert"
+	NotificationTypeReviewDismissed      NotificationType = "ReviewDismissed"
 )
 
 type NotificationChannel string
@@ -98,6 +112,7 @@ const (
 	NotificationChannelUndefined NotificationChannel = ""
 	NotificationChannelWeb       NotificationChannel = "Web"
 	NotificationChannelEmail     NotificationChannel = "Email"
+	NotificationChannelChat      NotificationChannel = "Chat"
 )
 
 type NotificationPreferenceResolver interface {
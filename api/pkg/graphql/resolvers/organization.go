@@ -14,6 +14,7 @@ type OrganizationRootResolver interface {
 	CreateOrganization(context.Context, CreateOrganizationArgs) (OrganizationResolver, error)
 	AddUserToOrganization(context.Context, AddUserToOrganizationArgs) (OrganizationResolver, error)
 	RemoveUserFromOrganization(context.Context, RemoveUserFromOrganizationArgs) (OrganizationResolver, error)
+	UpdateOrganizationMemberRole(context.Context, UpdateOrganizationMemberRoleArgs) (OrganizationResolver, error)
 }
 
 type OrganizationResolver interface {
@@ -21,13 +22,21 @@ type OrganizationResolver interface {
 	ShortID() graphql.ID
 	Name() string
 	Members(context.Context) ([]AuthorResolver, error)
+	Memberships(context.Context) ([]OrganizationMemberResolver, error)
 	Codebases(context.Context) ([]CodebaseResolver, error)
+	ViewerRole(context.Context) (*string, error)
+	RequireTwoFactor() bool
 
 	Licenses(context.Context) ([]LicenseResolver, error)
 
 	Writeable(context.Context) bool
 }
 
+type OrganizationMemberResolver interface {
+	Author(context.Context) (AuthorResolver, error)
+	Role() string
+}
+
 type CreateOrganizationArgs struct {
 	Input CreateOrganizationInput
 }
@@ -43,6 +52,7 @@ type AddUserToOrganizationArgs struct {
 type AddUserToOrganizationInput struct {
 	OrganizationID graphql.ID
 	Email          string
+	Role           *string
 }
 
 type OrganizationArgs struct {
@@ -58,3 +68,13 @@ type RemoveUserFromOrganizationInput struct {
 	OrganizationID graphql.ID
 	UserID         graphql.ID
 }
+
+type UpdateOrganizationMemberRoleArgs struct {
+	Input UpdateOrganizationMemberRoleInput
+}
+
+type UpdateOrganizationMemberRoleInput struct {
+	OrganizationID graphql.ID
+	UserID         graphql.ID
+	Role           string
+}
@@ -0,0 +1,77 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type ReleaseRootResolver interface {
+	// Internal
+	InternalTagsByCodebaseID(ctx context.Context, codebaseID string) ([]TagResolver, error)
+	InternalReleasesByCodebaseID(ctx context.Context, codebaseID string) ([]ReleaseResolver, error)
+
+	// Queries
+	Release(context.Context, ReleaseArgs) (ReleaseResolver, error)
+
+	// Mutations
+	CreateTag(context.Context, CreateTagArgs) (TagResolver, error)
+	CreateRelease(context.Context, CreateReleaseArgs) (ReleaseResolver, error)
+	UpdateRelease(context.Context, UpdateReleaseArgs) (ReleaseResolver, error)
+}
+
+type ReleaseArgs struct {
+	ID graphql.ID
+}
+
+type CreateTagArgs struct {
+	Input CreateTagInput
+}
+
+type CreateTagInput struct {
+	ChangeID graphql.ID
+	Name     string
+	Message  *string
+}
+
+type CreateReleaseArgs struct {
+	Input CreateReleaseInput
+}
+
+type CreateReleaseInput struct {
+	TagID         graphql.ID
+	PreviousTagID *graphql.ID
+	Title         *string
+}
+
+type UpdateReleaseArgs struct {
+	Input UpdateReleaseInput
+}
+
+type UpdateReleaseInput struct {
+	ID    graphql.ID
+	Title *string
+	Notes *string
+}
+
+type TagResolver interface {
+	ID() graphql.ID
+	Name() string
+	Message() string
+	Change(context.Context) (ChangeResolver, error)
+	Author(context.Context) (AuthorResolver, error)
+	CreatedAt() int32
+	Release(context.Context) (ReleaseResolver, error)
+}
+
+type ReleaseResolver interface {
+	ID() graphql.ID
+	Title() string
+	Notes() string
+	Tag(context.Context) (TagResolver, error)
+	PreviousTag(context.Context) (TagResolver, error)
+	Changes(context.Context) ([]ChangeResolver, error)
+	Author(context.Context) (AuthorResolver, error)
+	CreatedAt() int32
+	UpdatedAt() *int32
+}
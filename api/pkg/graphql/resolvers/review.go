@@ -30,6 +30,7 @@ type ReviewResolver interface {
 	IsReplaced() bool
 	Workspace(context.Context) (WorkspaceResolver, error)
 	RequestedBy(context.Context) (AuthorResolver, error)
+	IsStale() bool
 }
 
 type CreateReviewArgs struct {
@@ -0,0 +1,39 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type SCIMRootResolver interface {
+	CreateSCIMToken(context.Context, CreateSCIMTokenArgs) (SCIMTokenResolver, error)
+	RevokeSCIMToken(context.Context, RevokeSCIMTokenArgs) (SCIMTokenResolver, error)
+}
+
+type CreateSCIMTokenArgs struct {
+	Input CreateSCIMTokenInput
+}
+
+type CreateSCIMTokenInput struct {
+	OrganizationID graphql.ID
+	Name           string
+}
+
+type RevokeSCIMTokenArgs struct {
+	Input RevokeSCIMTokenInput
+}
+
+type RevokeSCIMTokenInput struct {
+	ID graphql.ID
+}
+
+type SCIMTokenResolver interface {
+	ID() graphql.ID
+	Name() string
+	CreatedAt() int32
+	LastUsedAt() *int32
+	RevokedAt() *int32
+
+	Token() *string
+}
@@ -8,6 +8,10 @@ import (
 
 type ServiceTokensRootResolver interface {
 	CreateServiceToken(context.Context, CreateServiceTokenArgs) (ServiceTokenResovler, error)
+	RotateServiceToken(context.Context, RotateServiceTokenArgs) (ServiceTokenResovler, error)
+
+	// Internal
+	InternalListByCodebaseID(ctx context.Context, codebaseID string) ([]ServiceTokenResovler, error)
 }
 
 type CreateServiceTokenArgs struct {
@@ -17,13 +21,35 @@ type CreateServiceTokenArgs struct {
 type CreateServiceTokenInput struct {
 	Name            string
 	ShortCodebaseID string
+	Capabilities    *[]string
+	AllowedIPs      *[]string
+	ExpiresAt       *int32
+}
+
+type RotateServiceTokenArgs struct {
+	Input RotateServiceTokenInput
+}
+
+type RotateServiceTokenInput struct {
+	ID                 graphql.ID
+	GracePeriodSeconds *int32
 }
 
 type ServiceTokenResovler interface {
 	ID() graphql.ID
 	Name() string
+	Capabilities() []string
+	AllowedIPs() []string
 	CreatedAt() int32
+	ExpiresAt() *int32
 	LastUsedAt() *int32
+	Usage(context.Context) ([]ServiceTokenUsageResolver, error)
+	PreviousTokenExpiresAt() *int32
 
 	Token() *string
 }
+
+type ServiceTokenUsageResolver interface {
+	Capability() string
+	LastUsedAt() *int32
+}
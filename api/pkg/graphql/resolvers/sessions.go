@@ -0,0 +1,44 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type SessionsRootResolver interface {
+	// Mutations
+	RevokeSession(context.Context, RevokeSessionArgs) (SessionResolver, error)
+	RevokeAllSessions(context.Context, RevokeAllSessionsArgs) ([]SessionResolver, error)
+
+	// Internal
+	InternalListByUserID(ctx context.Context, userID string) ([]SessionResolver, error)
+}
+
+type RevokeSessionArgs struct {
+	Input RevokeSessionInput
+}
+
+type RevokeSessionInput struct {
+	ID graphql.ID
+}
+
+type RevokeAllSessionsArgs struct {
+	Input RevokeAllSessionsInput
+}
+
+type RevokeAllSessionsInput struct {
+	UserID         *graphql.ID
+	OrganizationID *graphql.ID
+}
+
+type SessionResolver interface {
+	ID() graphql.ID
+	Device() string
+	IP() string
+	CreatedAt() int32
+	LastUsedAt() int32
+	ExpiresAt() int32
+	RevokedAt() *int32
+	Current(context.Context) bool
+}
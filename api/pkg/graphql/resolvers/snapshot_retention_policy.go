@@ -0,0 +1,35 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type SnapshotRetentionPolicyRootResolver interface {
+	// Internal
+	InternalByCodebaseID(ctx context.Context, codebaseID string) (SnapshotRetentionPolicyResolver, error)
+
+	// Mutations
+	UpdateSnapshotRetentionPolicy(context.Context, UpdateSnapshotRetentionPolicyArgs) (SnapshotRetentionPolicyResolver, error)
+}
+
+type UpdateSnapshotRetentionPolicyArgs struct {
+	Input UpdateSnapshotRetentionPolicyInput
+}
+
+type UpdateSnapshotRetentionPolicyInput struct {
+	CodebaseID           graphql.ID
+	KeepLastPerWorkspace *int32
+	KeepNewerThanSeconds *int32
+	KeepDailyForDays     *int32
+}
+
+type SnapshotRetentionPolicyResolver interface {
+	ID() graphql.ID
+	KeepLastPerWorkspace() int32
+	KeepNewerThanSeconds() int32
+	KeepDailyForDays() int32
+	UpdatedAt() *int32
+	StorageUsedBytes(context.Context) (*float64, error)
+}
@@ -0,0 +1,36 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type StorageHealthRootResolver interface {
+	CodebaseStorageHealth(context.Context, CodebaseStorageHealthArgs) (CodebaseStorageHealthResolver, error)
+}
+
+type CodebaseStorageHealthArgs struct {
+	CodebaseID graphql.ID
+}
+
+type CodebaseStorageHealthResolver interface {
+	ID() graphql.ID
+	Codebase(context.Context) (CodebaseResolver, error)
+	SizeBytes() float64
+	ObjectsCount() float64
+	QuarantinedCount() int32
+	Repositories() []RepositoryStorageHealthResolver
+}
+
+type RepositoryStorageHealthResolver interface {
+	ID() graphql.ID
+	IsTrunk() bool
+	View(context.Context) (ViewResolver, error)
+	SizeBytes() float64
+	ObjectsCount() float64
+	PacksCount() int32
+	LastMaintenanceAt() *int32
+	QuarantinedAt() *int32
+	FsckError() *string
+}
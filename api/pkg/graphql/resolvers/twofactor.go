@@ -0,0 +1,61 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type TwoFactorRootResolver interface {
+	// Mutations
+	EnrollTwoFactor(context.Context) (TwoFactorEnrollmentResolver, error)
+	ConfirmTwoFactor(context.Context, ConfirmTwoFactorArgs) (TwoFactorRecoveryCodesResolver, error)
+	DisableTwoFactor(context.Context, DisableTwoFactorArgs) (UserResolver, error)
+	RegenerateTwoFactorRecoveryCodes(context.Context, RegenerateTwoFactorRecoveryCodesArgs) (TwoFactorRecoveryCodesResolver, error)
+	UpdateOrganizationTwoFactorRequirement(context.Context, UpdateOrganizationTwoFactorRequirementArgs) (OrganizationResolver, error)
+
+	// Internal
+	InternalIsEnabled(ctx context.Context, userID string) (bool, error)
+}
+
+type ConfirmTwoFactorArgs struct {
+	Input ConfirmTwoFactorInput
+}
+
+type ConfirmTwoFactorInput struct {
+	Code string
+}
+
+type DisableTwoFactorArgs struct {
+	Input DisableTwoFactorInput
+}
+
+type DisableTwoFactorInput struct {
+	Code string
+}
+
+type RegenerateTwoFactorRecoveryCodesArgs struct {
+	Input RegenerateTwoFactorRecoveryCodesInput
+}
+
+type RegenerateTwoFactorRecoveryCodesInput struct {
+	Code string
+}
+
+type UpdateOrganizationTwoFactorRequirementArgs struct {
+	Input UpdateOrganizationTwoFactorRequirementInput
+}
+
+type UpdateOrganizationTwoFactorRequirementInput struct {
+	OrganizationID graphql.ID
+	Required       bool
+}
+
+type TwoFactorEnrollmentResolver interface {
+	Secret() string
+	URL() string
+}
+
+type TwoFactorRecoveryCodesResolver interface {
+	Codes() []string
+}
@@ -27,6 +27,7 @@ type UpdateUserInput struct {
 	Email                          *string
 	Password                       *string
 	NotificationsReceiveNewsletter *bool
+	NotificationsDigestFrequency   *string
 }
 
 type VerifyEmailArgs struct {
@@ -44,10 +45,14 @@ type UserResolver interface {
 	EmailVerified() bool
 	AvatarUrl() *string
 	NotificationPreferences(context.Context) ([]NotificationPreferenceResolver, error)
+	ChatWebhooks(context.Context) ([]ChatWebhookResolver, error)
 	GitHubAccount(context.Context) (GitHubAccountResolver, error)
 	NotificationsReceiveNewsletter() (bool, error)
+	NotificationsDigestFrequency(context.Context) (string, error)
 	Views() ([]ViewResolver, error)
 	LastUsedView(ctx context.Context, args LastUsedViewArgs) (ViewResolver, error)
+	Sessions(context.Context) ([]SessionResolver, error)
+	TwoFactorEnabled(context.Context) (bool, error)
 }
 
 type LastUsedViewArgs struct {
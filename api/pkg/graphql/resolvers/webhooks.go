@@ -0,0 +1,91 @@
+package resolvers
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type WebhooksRootResolver interface {
+	// Internal
+	InternalListByCodebaseID(ctx context.Context, codebaseID string) ([]WebhookResolver, error)
+
+	// Mutations
+	CreateWebhook(context.Context, CreateWebhookArgs) (WebhookResolver, error)
+	UpdateWebhook(context.Context, UpdateWebhookArgs) (WebhookResolver, error)
+	DeleteWebhook(context.Context, DeleteWebhookArgs) (WebhookResolver, error)
+	RedeliverWebhookDelivery(context.Context, RedeliverWebhookDeliveryArgs) (WebhookDeliveryResolver, error)
+}
+
+type CreateWebhookArgs struct {
+	Input CreateWebhookInput
+}
+
+type CreateWebhookInput struct {
+	CodebaseID graphql.ID
+	URL        string
+	Secret     string
+	Events     []string
+	Active     *bool
+}
+
+type UpdateWebhookArgs struct {
+	Input UpdateWebhookInput
+}
+
+type UpdateWebhookInput struct {
+	ID     graphql.ID
+	URL    *string
+	Secret *string
+	Events *[]string
+	Active *bool
+}
+
+type DeleteWebhookArgs struct {
+	Input DeleteWebhookInput
+}
+
+type DeleteWebhookInput struct {
+	ID graphql.ID
+}
+
+type RedeliverWebhookDeliveryArgs struct {
+	Input RedeliverWebhookDeliveryInput
+}
+
+type RedeliverWebhookDeliveryInput struct {
+	ID graphql.ID
+}
+
+type WebhookResolver interface {
+	ID() graphql.ID
+	Codebase(context.Context) (CodebaseResolver, error)
+	URL() string
+	Events() []string
+	Active() bool
+	Version() int32
+	CreatedAt() int32
+	UpdatedAt() *int32
+	Deliveries(context.Context, WebhookDeliveriesArgs) ([]WebhookDeliveryResolver, error)
+}
+
+type WebhookDeliveriesArgs struct {
+	First *int32
+}
+
+type WebhookDeliveryResolver interface {
+	ID() graphql.ID
+	Webhook(context.Context) (WebhookResolver, error)
+	EventID() graphql.ID
+	Event() string
+	Status() string
+	Payload() string
+	Attempts() int32
+	ResponseStatusCode() *int32
+	Error() *string
+	DurationMs() *int32
+	RedeliveryOf(context.Context) (WebhookDeliveryResolver, error)
+	CreatedAt() int32
+	LastAttemptAt() *int32
+	NextAttemptAt() *int32
+}
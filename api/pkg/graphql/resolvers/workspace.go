@@ -134,12 +134,14 @@ type WorkspaceResolver interface {
 	View(ctx context.Context) (ViewResolver, error)
 	Comments() ([]TopCommentResolver, error)
 	CommentsCount(context.Context) (int32, error)
+	UnresolvedCommentsCount(context.Context) (int32, error)
 	GitHubPullRequest(ctx context.Context) (GitHubPullRequestResolver, error)
 	UpToDateWithTrunk(context.Context) (bool, error)
 	Conflicts(context.Context) (bool, error)
 	HeadChange(ctx context.Context) (ChangeResolver, error)
 	Activity(ctx context.Context, args WorkspaceActivityArgs) ([]WorkspaceActivityResolver, error)
 	Reviews(ctx context.Context) ([]ReviewResolver, error)
+	RequiredOwners(context.Context) ([]OwnerSetResolver, error)
 	Presence(ctx context.Context) ([]PresenceResolver, error)
 	Suggestions(context.Context) ([]SuggestionResolver, error)
 	Statuses(context.Context) ([]StatusResolver, error)
@@ -147,4 +149,11 @@ type WorkspaceResolver interface {
 	Suggestion(context.Context) (SuggestionResolver, error)
 	SuggestingViews() []ViewResolver
 	DiffsCount(context.Context) *int32
+	Diffs(context.Context) ([]FileDiffResolver, error)
+	GuestInvites(context.Context) ([]GuestInviteResolver, error)
+}
+
+type OwnerSetResolver interface {
+	ID() graphql.ID
+	Owners(context.Context) ([]AuthorResolver, error)
 }
@@ -0,0 +1,109 @@
+package graphql
+
+import (
+	"context"
+
+	"getsturdy.com/api/pkg/accesstokens"
+	"getsturdy.com/api/pkg/auth"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+
+	"github.com/graph-gophers/graphql-go/trace"
+)
+
+// mutationScopes is the scope that a personal access token needs to run a mutation. Mutations that are not listed
+// require accesstokens.ScopeAdmin.
+var mutationScopes = map[string]accesstokens.Scope{
+	// workspaces and views
+	"openWorkspaceOnView": accesstokens.ScopeWorkspacesWrite,
+	"copyWorkspaceToView": accesstokens.ScopeWorkspacesWrite,
+	"repairView":          accesstokens.ScopeWorkspacesWrite,
+	"landWorkspaceChange": accesstokens.ScopeWorkspacesWrite,
+	"updateWorkspace":     accesstokens.ScopeWorkspacesWrite,
+	"archiveWorkspace":    accesstokens.ScopeWorkspacesWrite,
+	"unarchiveWorkspace":  accesstokens.ScopeWorkspacesWrite,
+	"createWorkspace":     accesstokens.ScopeWorkspacesWrite,
+	"extractWorkspace":    accesstokens.ScopeWorkspacesWrite,
+	"createView":          accesstokens.ScopeWorkspacesWrite,
+	"watchWorkspace":      accesstokens.ScopeWorkspacesWrite,
+	"unwatchWorkspace":    accesstokens.ScopeWorkspacesWrite,
+
+	// comments, reviews and activity
+	"deleteComment":           accesstokens.ScopeWorkspacesWrite,
+	"updateComment":           accesstokens.ScopeWorkspacesWrite,
+	"createComment":           accesstokens.ScopeWorkspacesWrite,
+	"resolveComment":          accesstokens.ScopeWorkspacesWrite,
+	"unresolveComment":        accesstokens.ScopeWorkspacesWrite,
+	"applyCommentSuggestion":  accesstokens.ScopeWorkspacesWrite,
+	"setFileDiffViewed":       accesstokens.ScopeWorkspacesWrite,
+	"createOrUpdateReview":    accesstokens.ScopeWorkspacesWrite,
+	"dismissReview":           accesstokens.ScopeWorkspacesWrite,
+	"requestReview":           accesstokens.ScopeWorkspacesWrite,
+	"readWorkspaceActivity":   accesstokens.ScopeWorkspacesWrite,
+	"reportWorkspacePresence": accesstokens.ScopeWorkspacesWrite,
+
+	// suggestions
+	"createSuggestion":       accesstokens.ScopeWorkspacesWrite,
+	"dismissSuggestion":      accesstokens.ScopeWorkspacesWrite,
+	"applySuggestionHunks":   accesstokens.ScopeWorkspacesWrite,
+	"dismissSuggestionHunks": accesstokens.ScopeWorkspacesWrite,
+	"removePatches":          accesstokens.ScopeWorkspacesWrite,
+
+	// github and integrations
+	"createWorkspaceFromGitHubBranch": accesstokens.ScopeWorkspacesWrite,
+	"createOrUpdateGitHubPullRequest": accesstokens.ScopeWorkspacesWrite,
+	"mergeGitHubPullRequest":          accesstokens.ScopeWorkspacesWrite,
+	"triggerInstantIntegration":       accesstokens.ScopeWorkspacesWrite,
+
+	// releases
+	"createTag":     accesstokens.ScopeWorkspacesWrite,
+	"createRelease": accesstokens.ScopeWorkspacesWrite,
+	"updateRelease": accesstokens.ScopeWorkspacesWrite,
+
+	// statuses
+	"updateStatus": accesstokens.ScopeStatusesWrite,
+}
+
+func mutationScope(fieldName string) accesstokens.Scope {
+	if scope, ok := mutationScopes[fieldName]; ok {
+		return scope
+	}
+	return accesstokens.ScopeAdmin
+}
+
+// scopeTracer refuses to run the mutations that the authenticated subject doesn't have the scope for.
+//
+// graphql-go doesn't run a resolver if the context that is returned from TraceField is done, and reports the error of
+// the context instead.
+type scopeTracer struct {
+	trace.Tracer
+}
+
+func (s *scopeTracer) TraceField(ctx context.Context, label, typeName, fieldName string, trivial bool, args map[string]interface{}) (context.Context, trace.TraceFieldFinishFunc) {
+	ctx, finish := s.Tracer.TraceField(ctx, label, typeName, fieldName, trivial, args)
+	if typeName != "Mutation" {
+		return ctx, finish
+	}
+	if subject, ok := auth.FromContext(ctx); ok && !subject.HasScope(mutationScope(fieldName)) {
+		return &forbiddenContext{Context: ctx}, finish
+	}
+	return ctx, finish
+}
+
+var closedChan = func() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}()
+
+// forbiddenContext is a context that is done, with gqlerrors.ErrForbidden as its error.
+type forbiddenContext struct {
+	context.Context
+}
+
+func (*forbiddenContext) Done() <-chan struct{} {
+	return closedChan
+}
+
+func (*forbiddenContext) Err() error {
+	return gqlerrors.ErrForbidden
+}
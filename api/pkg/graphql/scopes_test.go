@@ -0,0 +1,53 @@
+package graphql
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"getsturdy.com/api/pkg/accesstokens"
+	"getsturdy.com/api/pkg/auth"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/schema"
+
+	"github.com/graph-gophers/graphql-go/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutationScopes_exist(t *testing.T) {
+	for fieldName := range mutationScopes {
+		assert.Regexp(t, regexp.MustCompile(`\n\s+`+fieldName+`\(`), schema.String, "%s is not in the schema", fieldName)
+	}
+}
+
+func TestScopeTracer(t *testing.T) {
+	tracer := &scopeTracer{Tracer: trace.NoopTracer{}}
+
+	cases := []struct {
+		name      string
+		scopes    accesstokens.Scopes
+		typeName  string
+		fieldName string
+		allowed   bool
+	}{
+		{"session user", nil, "Mutation", "createPersonalAccessToken", true},
+		{"queries are not limited", accesstokens.Scopes{accesstokens.ScopeCodebasesRead}, "Query", "codebases", true},
+		{"listed mutation", accesstokens.Scopes{accesstokens.ScopeWorkspacesWrite}, "Mutation", "createWorkspace", true},
+		{"listed mutation, missing scope", accesstokens.Scopes{accesstokens.ScopeCodebasesRead}, "Mutation", "createWorkspace", false},
+		{"statuses", accesstokens.Scopes{accesstokens.ScopeStatusesWrite}, "Mutation", "updateStatus", true},
+		{"unlisted mutation", accesstokens.Scopes{accesstokens.ScopeWorkspacesWrite}, "Mutation", "createPersonalAccessToken", false},
+		{"unlisted mutation, admin", accesstokens.Scopes{accesstokens.ScopeAdmin}, "Mutation", "createPersonalAccessToken", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := auth.NewContext(context.Background(), &auth.Subject{ID: "user", Type: auth.SubjectUser, Scopes: tc.scopes})
+			ctx, _ = tracer.TraceField(ctx, "", tc.typeName, tc.fieldName, false, nil)
+			if tc.allowed {
+				assert.NoError(t, ctx.Err())
+			} else {
+				assert.ErrorIs(t, ctx.Err(), gqlerrors.ErrForbidden)
+			}
+		})
+	}
+}
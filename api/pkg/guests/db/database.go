@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"getsturdy.com/api/pkg/guests"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var _ Repository = &database{}
+
+type database struct {
+	db *sqlx.DB
+}
+
+func NewDatabase(db *sqlx.DB) Repository {
+	return &database{
+		db: db,
+	}
+}
+
+func (d *database) Create(ctx context.Context, invite *guests.Invite) error {
+	if _, err := d.db.NamedExecContext(ctx, `
+		INSERT INTO guest_invites (
+			id, codebase_id, workspace_id, capabilities, created_by, created_at, expires_at, accepted_by,
+			accepted_at, revoked_at
+		) VALUES (
+			:id, :codebase_id, :workspace_id, :capabilities, :created_by, :created_at, :expires_at, :accepted_by,
+			:accepted_at, :revoked_at
+		)
+	`, invite); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (d *database) GetByID(ctx context.Context, id string) (*guests.Invite, error) {
+	invite := &guests.Invite{}
+	if err := d.db.GetContext(ctx, invite, `
+		SELECT
+			id, codebase_id, workspace_id, capabilities, created_by, created_at, expires_at, accepted_by,
+			accepted_at, revoked_at
+		FROM guest_invites
+		WHERE id = $1
+	`, id); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return invite, nil
+}
+
+func (d *database) Update(ctx context.Context, invite *guests.Invite) error {
+	if _, err := d.db.NamedExecContext(ctx, `
+		UPDATE guest_invites
+		SET
+			accepted_by = :accepted_by,
+			accepted_at = :accepted_at,
+			revoked_at = :revoked_at
+		WHERE id = :id
+	`, invite); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (d *database) ListByWorkspaceID(ctx context.Context, workspaceID string) ([]*guests.Invite, error) {
+	var invites []*guests.Invite
+	if err := d.db.SelectContext(ctx, &invites, `
+		SELECT
+			id, codebase_id, workspace_id, capabilities, created_by, created_at, expires_at, accepted_by,
+			accepted_at, revoked_at
+		FROM guest_invites
+		WHERE workspace_id = $1
+		ORDER BY created_at
+	`, workspaceID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return invites, nil
+}
+
+func (d *database) ListByAcceptedByAndWorkspaceID(ctx context.Context, userID, workspaceID string) ([]*guests.Invite, error) {
+	var invites []*guests.Invite
+	if err := d.db.SelectContext(ctx, &invites, `
+		SELECT
+			id, codebase_id, workspace_id, capabilities, created_by, created_at, expires_at, accepted_by,
+			accepted_at, revoked_at
+		FROM guest_invites
+		WHERE accepted_by = $1
+		  AND workspace_id = $2
+		ORDER BY created_at
+	`, userID, workspaceID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return invites, nil
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+
+	"getsturdy.com/api/pkg/guests"
+)
+
+var _ Repository = &memory{}
+
+type memory struct {
+	mu   sync.Mutex
+	byID map[string]guests.Invite
+}
+
+func NewMemory() Repository {
+	return &memory{
+		byID: map[string]guests.Invite{},
+	}
+}
+
+func (m *memory) Create(_ context.Context, invite *guests.Invite) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byID[invite.ID] = *invite
+	return nil
+}
+
+func (m *memory) GetByID(_ context.Context, id string) (*guests.Invite, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	invite, found := m.byID[id]
+	if !found {
+		return nil, sql.ErrNoRows
+	}
+	return &invite, nil
+}
+
+func (m *memory) Update(_ context.Context, invite *guests.Invite) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, found := m.byID[invite.ID]
+	if !found {
+		return sql.ErrNoRows
+	}
+	stored.AcceptedBy = invite.AcceptedBy
+	stored.AcceptedAt = invite.AcceptedAt
+	stored.RevokedAt = invite.RevokedAt
+	m.byID[invite.ID] = stored
+	return nil
+}
+
+func (m *memory) ListByWorkspaceID(_ context.Context, workspaceID string) ([]*guests.Invite, error) {
+	return m.list(func(invite *guests.Invite) bool {
+		return invite.WorkspaceID == workspaceID
+	}), nil
+}
+
+func (m *memory) ListByAcceptedByAndWorkspaceID(_ context.Context, userID, workspaceID string) ([]*guests.Invite, error) {
+	return m.list(func(invite *guests.Invite) bool {
+		return invite.AcceptedBy != nil && *invite.AcceptedBy == userID && invite.WorkspaceID == workspaceID
+	}), nil
+}
+
+func (m *memory) list(match func(*guests.Invite) bool) []*guests.Invite {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*guests.Invite
+	for _, invite := range m.byID {
+		invite := invite
+		if match(&invite) {
+			res = append(res, &invite)
+		}
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].CreatedAt.Before(res[b].CreatedAt)
+	})
+	return res
+}
@@ -0,0 +1,17 @@
+package db
+
+import (
+	"context"
+
+	"getsturdy.com/api/pkg/guests"
+)
+
+type Repository interface {
+	Create(context.Context, *guests.Invite) error
+	GetByID(context.Context, string) (*guests.Invite, error)
+	// Update updates who accepted the invite, and when it was revoked.
+	Update(context.Context, *guests.Invite) error
+	ListByWorkspaceID(ctx context.Context, workspaceID string) ([]*guests.Invite, error)
+	// ListByAcceptedByAndWorkspaceID lists the invites to the workspace that the user has accepted.
+	ListByAcceptedByAndWorkspaceID(ctx context.Context, userID, workspaceID string) ([]*guests.Invite, error)
+}
@@ -0,0 +1,258 @@
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/accesstokens"
+	"getsturdy.com/api/pkg/auth"
+	service_auth "getsturdy.com/api/pkg/auth/service"
+	gqlerror "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/guests"
+	service_guests "getsturdy.com/api/pkg/guests/service"
+	service_workspace "getsturdy.com/api/pkg/workspaces/service"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// capabilities maps the values of the GuestCapability enum to capabilities.
+var capabilities = map[string]guests.Capability{
+	"View":    guests.CapabilityView,
+	"Comment": guests.CapabilityComment,
+}
+
+func capabilityName(capability guests.Capability) string {
+	for name, c := range capabilities {
+		if c == capability {
+			return name
+		}
+	}
+	return ""
+}
+
+type rootResolver struct {
+	authService      *service_auth.Service
+	guestsService    *service_guests.Service
+	workspaceService service_workspace.Service
+
+	workspaceRootResolver *resolvers.WorkspaceRootResolver
+	authorRootResolver    resolvers.AuthorRootResolver
+}
+
+func New(
+	authService *service_auth.Service,
+	guestsService *service_guests.Service,
+	workspaceService service_workspace.Service,
+
+	workspaceRootResolver *resolvers.WorkspaceRootResolver,
+	authorRootResolver resolvers.AuthorRootResolver,
+) resolvers.GuestInvitesRootResolver {
+	return &rootResolver{
+		authService:      authService,
+		guestsService:    guestsService,
+		workspaceService: workspaceService,
+
+		workspaceRootResolver: workspaceRootResolver,
+		authorRootResolver:    authorRootResolver,
+	}
+}
+
+// InternalListByWorkspaceID lists the invites to the workspace. Only users that can write to the workspace can see
+// them, for everyone else (including the guests) the list is empty.
+func (r *rootResolver) InternalListByWorkspaceID(ctx context.Context, workspaceID string) ([]resolvers.GuestInviteResolver, error) {
+	ws, err := r.workspaceService.GetByID(ctx, workspaceID)
+	if err != nil {
+		return nil, gqlerror.Error(fmt.Errorf("workspace not found: %w", err))
+	}
+
+	switch err := r.authService.CanWrite(ctx, ws); {
+	case err == nil:
+	case errors.Is(err, auth.ErrForbidden):
+		return []resolvers.GuestInviteResolver{}, nil
+	default:
+		return nil, gqlerror.Error(err)
+	}
+
+	invites, err := r.guestsService.ListByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return nil, gqlerror.Error(err)
+	}
+
+	res := make([]resolvers.GuestInviteResolver, 0, len(invites))
+	for _, invite := range invites {
+		res = append(res, &resolver{root: r, invite: invite})
+	}
+	return res, nil
+}
+
+func (r *rootResolver) CreateGuestInvite(ctx context.Context, args resolvers.CreateGuestInviteArgs) (resolvers.GuestInviteResolver, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerror.Error(err)
+	}
+
+	ws, err := r.workspaceService.GetByID(ctx, string(args.Input.WorkspaceID))
+	if err != nil {
+		return nil, gqlerror.Error(fmt.Errorf("workspace not found: %w", err))
+	}
+
+	if err := r.authService.CanWrite(ctx, ws); err != nil {
+		return nil, gqlerror.Error(err)
+	}
+
+	inviteCapabilities := make(guests.Capabilities, 0, len(args.Input.Capabilities))
+	for _, c := range args.Input.Capabilities {
+		capability, ok := capabilities[c]
+		if !ok {
+			return nil, gqlerror.Error(gqlerror.ErrBadRequest, "capabilities", fmt.Sprintf("unknown capability %q", c))
+		}
+		inviteCapabilities = append(inviteCapabilities, capability)
+	}
+
+	var expiresAt *time.Time
+	if args.Input.ExpiresAt != nil {
+		t := time.Unix(int64(*args.Input.ExpiresAt), 0)
+		expiresAt = &t
+	}
+
+	code, invite, err := r.guestsService.Create(ctx, ws.CodebaseID, ws.ID, userID, inviteCapabilities, expiresAt)
+	switch {
+	case err == nil:
+	case errors.Is(err, service_guests.ErrInvalidCapability):
+		return nil, gqlerror.Error(gqlerror.ErrBadRequest, "capabilities", err.Error())
+	case errors.Is(err, service_guests.ErrInvalidExpiry):
+		return nil, gqlerror.Error(gqlerror.ErrBadRequest, "expiresAt", err.Error())
+	default:
+		return nil, gqlerror.Error(fmt.Errorf("failed to create invite: %w", err))
+	}
+
+	return &resolver{
+		root:   r,
+		invite: invite,
+		code:   &code,
+	}, nil
+}
+
+func (r *rootResolver) AcceptGuestInvite(ctx context.Context, args resolvers.AcceptGuestInviteArgs) (resolvers.GuestInviteResolver, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerror.Error(err)
+	}
+
+	if err := auth.RequireScope(ctx, accesstokens.ScopeAdmin); err != nil {
+		return nil, gqlerror.Error(err)
+	}
+
+	invite, err := r.guestsService.Accept(ctx, userID, args.Input.Code)
+	switch {
+	case err == nil:
+	case errors.Is(err, service_guests.ErrInvalid):
+		return nil, gqlerror.Error(gqlerror.ErrNotFound)
+	case errors.Is(err, service_guests.ErrExpired),
+		errors.Is(err, service_guests.ErrRevoked),
+		errors.Is(err, service_guests.ErrAlreadyAccepted):
+		return nil, gqlerror.Error(gqlerror.ErrBadRequest, "code", err.Error())
+	default:
+		return nil, gqlerror.Error(fmt.Errorf("failed to accept invite: %w", err))
+	}
+
+	return &resolver{root: r, invite: invite}, nil
+}
+
+func (r *rootResolver) RevokeGuestInvite(ctx context.Context, args resolvers.RevokeGuestInviteArgs) (resolvers.GuestInviteResolver, error) {
+	invite, err := r.guestsService.Get(ctx, string(args.Input.ID))
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, gqlerror.Error(gqlerror.ErrNotFound)
+	default:
+		return nil, gqlerror.Error(err)
+	}
+
+	ws, err := r.workspaceService.GetByID(ctx, invite.WorkspaceID)
+	if err != nil {
+		return nil, gqlerror.Error(fmt.Errorf("workspace not found: %w", err))
+	}
+
+	if err := r.authService.CanWrite(ctx, ws); err != nil {
+		return nil, gqlerror.Error(err)
+	}
+
+	invite, err = r.guestsService.Revoke(ctx, invite)
+	if err != nil {
+		return nil, gqlerror.Error(fmt.Errorf("failed to revoke invite: %w", err))
+	}
+
+	return &resolver{root: r, invite: invite}, nil
+}
+
+type resolver struct {
+	root   *rootResolver
+	invite *guests.Invite
+	code   *string
+}
+
+func (r *resolver) ID() graphql.ID {
+	return graphql.ID(r.invite.ID)
+}
+
+func (r *resolver) Workspace(ctx context.Context) (resolvers.WorkspaceResolver, error) {
+	t := true
+	return (*r.root.workspaceRootResolver).Workspace(ctx, resolvers.WorkspaceArgs{
+		ID:            graphql.ID(r.invite.WorkspaceID),
+		AllowArchived: &t,
+	})
+}
+
+func (r *resolver) Capabilities() []string {
+	res := make([]string, 0, len(r.invite.Capabilities))
+	for _, capability := range r.invite.Capabilities {
+		if name := capabilityName(capability); name != "" {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+func (r *resolver) CreatedBy(ctx context.Context) (resolvers.AuthorResolver, error) {
+	return r.root.authorRootResolver.Author(ctx, graphql.ID(r.invite.CreatedBy))
+}
+
+func (r *resolver) CreatedAt() int32 {
+	return int32(r.invite.CreatedAt.Unix())
+}
+
+func (r *resolver) ExpiresAt() int32 {
+	return int32(r.invite.ExpiresAt.Unix())
+}
+
+func (r *resolver) AcceptedBy(ctx context.Context) (resolvers.AuthorResolver, error) {
+	if r.invite.AcceptedBy == nil {
+		return nil, nil
+	}
+	return r.root.authorRootResolver.Author(ctx, graphql.ID(*r.invite.AcceptedBy))
+}
+
+func (r *resolver) AcceptedAt() *int32 {
+	return unix(r.invite.AcceptedAt)
+}
+
+func (r *resolver) RevokedAt() *int32 {
+	return unix(r.invite.RevokedAt)
+}
+
+func (r *resolver) Code() *string {
+	return r.code
+}
+
+func unix(t *time.Time) *int32 {
+	if t == nil {
+		return nil
+	}
+	u := int32(t.Unix())
+	return &u
+}
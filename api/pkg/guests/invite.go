@@ -0,0 +1,92 @@
+package guests
+
+import (
+	"database/sql/driver"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type Capability string
+
+const (
+	// CapabilityView allows viewing the workspace, its diffs and comments.
+	CapabilityView Capability = "view"
+	// CapabilityComment allows commenting on the workspace, and implies CapabilityView.
+	CapabilityComment Capability = "comment"
+)
+
+var AllCapabilities = []Capability{CapabilityView, CapabilityComment}
+
+func (c Capability) Valid() bool {
+	for _, capability := range AllCapabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+type Capabilities []Capability
+
+func (cs Capabilities) Has(capability Capability) bool {
+	for _, c := range cs {
+		if c == capability {
+			return true
+		}
+		if c == CapabilityComment && capability == CapabilityView {
+			return true
+		}
+	}
+	return false
+}
+
+func (cs *Capabilities) Scan(src interface{}) error {
+	var arr pq.StringArray
+	if err := arr.Scan(src); err != nil {
+		return err
+	}
+	res := make(Capabilities, 0, len(arr))
+	for _, c := range arr {
+		res = append(res, Capability(c))
+	}
+	*cs = res
+	return nil
+}
+
+func (cs Capabilities) Value() (driver.Value, error) {
+	arr := make(pq.StringArray, 0, len(cs))
+	for _, c := range cs {
+		arr = append(arr, string(c))
+	}
+	return arr.Value()
+}
+
+// Invite gives a user that is not a member of the codebase access to a single workspace, limited to the capabilities
+// of the invite, until it expires. The invite is shared as a link with the code id + "_" + key, where key is the key of
+// a one-time token (see pkg/onetime) of the invite, and it's bound to the first user that accepts it.
+type Invite struct {
+	ID           string       `db:"id"`
+	CodebaseID   string       `db:"codebase_id"`
+	WorkspaceID  string       `db:"workspace_id"`
+	Capabilities Capabilities `db:"capabilities"`
+	CreatedBy    string       `db:"created_by"`
+	CreatedAt    time.Time    `db:"created_at"`
+	ExpiresAt    time.Time    `db:"expires_at"`
+	AcceptedBy   *string      `db:"accepted_by"`
+	AcceptedAt   *time.Time   `db:"accepted_at"`
+	RevokedAt    *time.Time   `db:"revoked_at"`
+}
+
+func (i *Invite) IsExpired(now time.Time) bool {
+	return !now.Before(i.ExpiresAt)
+}
+
+func (i *Invite) IsRevoked() bool {
+	return i.RevokedAt != nil
+}
+
+// IsActive returns true if the invite gives access at the time.
+func (i *Invite) IsActive(now time.Time) bool {
+	return !i.IsExpired(now) && !i.IsRevoked()
+}
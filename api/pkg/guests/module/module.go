@@ -0,0 +1,14 @@
+package module
+
+import (
+	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/guests/db"
+	"getsturdy.com/api/pkg/guests/graphql"
+	"getsturdy.com/api/pkg/guests/service"
+)
+
+func Module(c *di.Container) {
+	c.Import(db.Module)
+	c.Import(graphql.Module)
+	c.Import(service.Module)
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"getsturdy.com/api/pkg/guests"
+	db_guests "getsturdy.com/api/pkg/guests/db"
+	service_onetime "getsturdy.com/api/pkg/onetime/service"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultValidFor is how long invites are valid for, if they are created without an expiry.
+	DefaultValidFor = 7 * 24 * time.Hour
+	// MaxValidFor is how long invites can be valid for at most.
+	MaxValidFor = 30 * 24 * time.Hour
+)
+
+var (
+	ErrForbidden         = errors.New("forbidden")
+	ErrInvalid           = errors.New("invite invalid")
+	ErrExpired           = errors.New("invite expired")
+	ErrRevoked           = errors.New("invite revoked")
+	ErrAlreadyAccepted   = errors.New("invite already accepted")
+	ErrInvalidCapability = errors.New("invalid capability")
+	ErrInvalidExpiry     = errors.New("invalid expiry")
+)
+
+type Service struct {
+	logger         *zap.Logger
+	repo           db_guests.Repository
+	onetimeService *service_onetime.Service
+}
+
+func New(
+	logger *zap.Logger,
+	repo db_guests.Repository,
+	onetimeService *service_onetime.Service,
+) *Service {
+	return &Service{
+		logger:         logger.Named("guests"),
+		repo:           repo,
+		onetimeService: onetimeService,
+	}
+}
+
+// Create creates an invite to the workspace, and returns its code and the invite. The code contains the key of a
+// one-time token that is valid until the invite expires. The token is created for the invite rather than for a user, so
+// it can't be used to sign in. Invites without an expiry are valid for DefaultValidFor.
+func (s *Service) Create(
+	ctx context.Context,
+	codebaseID, workspaceID, createdBy string,
+	capabilities guests.Capabilities,
+	expiresAt *time.Time,
+) (string, *guests.Invite, error) {
+	if len(capabilities) == 0 {
+		return "", nil, fmt.Errorf("%w: at least one capability is required", ErrInvalidCapability)
+	}
+	for _, capability := range capabilities {
+		if !capability.Valid() {
+			return "", nil, fmt.Errorf("%w: %q", ErrInvalidCapability, capability)
+		}
+	}
+
+	now := time.Now()
+	validUntil := now.Add(DefaultValidFor)
+	if expiresAt != nil {
+		if !expiresAt.After(now) || expiresAt.Sub(now) > MaxValidFor {
+			return "", nil, fmt.Errorf("%w: must be in the future, and at most %s from now", ErrInvalidExpiry, MaxValidFor)
+		}
+		validUntil = *expiresAt
+	}
+
+	invite := &guests.Invite{
+		ID:           uuid.NewString(),
+		CodebaseID:   codebaseID,
+		WorkspaceID:  workspaceID,
+		Capabilities: capabilities,
+		CreatedBy:    createdBy,
+		CreatedAt:    now,
+		ExpiresAt:    validUntil,
+	}
+
+	token, err := s.onetimeService.CreateTokenValidUntil(ctx, invite.ID, validUntil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	if err := s.repo.Create(ctx, invite); err != nil {
+		return "", nil, fmt.Errorf("failed to create: %w", err)
+	}
+
+	return invite.ID + "_" + token.Key, invite, nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*guests.Invite, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *Service) ListByWorkspaceID(ctx context.Context, workspaceID string) ([]*guests.Invite, error) {
+	return s.repo.ListByWorkspaceID(ctx, workspaceID)
+}
+
+// Accept binds the invite with the code to the user. The token of the invite can only be resolved once, so an invite
+// can only be used by one user, but accepting an invite again as the same user is a no-op.
+func (s *Service) Accept(ctx context.Context, userID, code string) (*guests.Invite, error) {
+	parts := strings.SplitN(code, "_", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalid
+	}
+
+	invite, err := s.repo.GetByID(ctx, parts[0])
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrInvalid
+	default:
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+
+	_, err = s.onetimeService.ResolveByUserID(ctx, invite.ID, parts[1])
+	switch {
+	case err == nil, errors.Is(err, service_onetime.ErrExpired), errors.Is(err, service_onetime.ErrReused):
+		// the code is the code of the invite
+	case errors.Is(err, service_onetime.ErrInvalid):
+		return nil, ErrInvalid
+	default:
+		return nil, fmt.Errorf("failed to resolve token: %w", err)
+	}
+
+	now := time.Now()
+	switch {
+	case invite.IsRevoked():
+		return nil, ErrRevoked
+	case invite.IsExpired(now), errors.Is(err, service_onetime.ErrExpired):
+		return nil, ErrExpired
+	case invite.AcceptedBy != nil && *invite.AcceptedBy == userID:
+		return invite, nil
+	case invite.AcceptedBy != nil, errors.Is(err, service_onetime.ErrReused):
+		return nil, ErrAlreadyAccepted
+	}
+
+	invite.AcceptedBy = &userID
+	invite.AcceptedAt = &now
+	if err := s.repo.Update(ctx, invite); err != nil {
+		return nil, fmt.Errorf("failed to update invite: %w", err)
+	}
+
+	s.logger.Info("guest invite accepted",
+		zap.String("invite_id", invite.ID),
+		zap.String("workspace_id", invite.WorkspaceID),
+		zap.String("user_id", userID),
+	)
+
+	return invite, nil
+}
+
+// Revoke ends the access given by the invite, and makes it impossible to accept.
+func (s *Service) Revoke(ctx context.Context, invite *guests.Invite) (*guests.Invite, error) {
+	if invite.IsRevoked() {
+		return invite, nil
+	}
+	now := time.Now()
+	invite.RevokedAt = &now
+	if err := s.repo.Update(ctx, invite); err != nil {
+		return nil, fmt.Errorf("failed to update invite: %w", err)
+	}
+	return invite, nil
+}
+
+// Allows returns ErrForbidden if the user doesn't have an accepted invite to the workspace with the capability, that
+// is neither expired nor revoked. As this is checked on every access, the access of guests ends when their invites
+// expire.
+func (s *Service) Allows(ctx context.Context, userID, workspaceID string, capability guests.Capability) error {
+	_, err := s.GetActive(ctx, userID, workspaceID, capability)
+	return err
+}
+
+// GetActive returns the invite that gives the user access to the workspace with the capability, or ErrForbidden.
+func (s *Service) GetActive(ctx context.Context, userID, workspaceID string, capability guests.Capability) (*guests.Invite, error) {
+	invites, err := s.ListActive(ctx, userID, workspaceID, capability)
+	if err != nil {
+		return nil, err
+	}
+	if len(invites) == 0 {
+		return nil, fmt.Errorf("%w: no active invite with %q to the workspace", ErrForbidden, capability)
+	}
+	return invites[0], nil
+}
+
+// ListActive returns the accepted invites of the user to the workspace with the capability, that are neither expired
+// nor revoked.
+func (s *Service) ListActive(ctx context.Context, userID, workspaceID string, capability guests.Capability) ([]*guests.Invite, error) {
+	invites, err := s.repo.ListByAcceptedByAndWorkspaceID(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invites: %w", err)
+	}
+	now := time.Now()
+	res := make([]*guests.Invite, 0, len(invites))
+	for _, invite := range invites {
+		if invite.IsActive(now) && invite.Capabilities.Has(capability) {
+			res = append(res, invite)
+		}
+	}
+	return res, nil
+}
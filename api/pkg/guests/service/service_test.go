@@ -0,0 +1,118 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/guests"
+	db_guests "getsturdy.com/api/pkg/guests/db"
+	"getsturdy.com/api/pkg/guests/service"
+	db_onetime "getsturdy.com/api/pkg/onetime/db"
+	service_onetime "getsturdy.com/api/pkg/onetime/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAccept(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(zap.NewNop(), db_guests.NewMemory(), service_onetime.New(db_onetime.NewMemory()))
+
+	code, invite, err := svc.Create(ctx, "codebase-id", "workspace-id", "member-id", guests.Capabilities{guests.CapabilityView}, nil)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(service.DefaultValidFor), invite.ExpiresAt, time.Minute)
+
+	assert.ErrorIs(t, svc.Allows(ctx, "guest-id", "workspace-id", guests.CapabilityView), service.ErrForbidden)
+
+	_, err = svc.Accept(ctx, "guest-id", invite.ID+"_wrong")
+	assert.ErrorIs(t, err, service.ErrInvalid)
+	_, err = svc.Accept(ctx, "guest-id", "unknown_secret")
+	assert.ErrorIs(t, err, service.ErrInvalid)
+
+	accepted, err := svc.Accept(ctx, "guest-id", code)
+	require.NoError(t, err)
+	assert.Equal(t, "guest-id", *accepted.AcceptedBy)
+
+	_, err = svc.Accept(ctx, "guest-id", code)
+	assert.NoError(t, err, "accepting again as the same user is a no-op")
+	_, err = svc.Accept(ctx, "other-id", code)
+	assert.ErrorIs(t, err, service.ErrAlreadyAccepted)
+
+	assert.NoError(t, svc.Allows(ctx, "guest-id", "workspace-id", guests.CapabilityView))
+	assert.ErrorIs(t, svc.Allows(ctx, "guest-id", "workspace-id", guests.CapabilityComment), service.ErrForbidden)
+	assert.ErrorIs(t, svc.Allows(ctx, "guest-id", "other-workspace-id", guests.CapabilityView), service.ErrForbidden)
+	assert.ErrorIs(t, svc.Allows(ctx, "other-id", "workspace-id", guests.CapabilityView), service.ErrForbidden)
+}
+
+func TestAllows_commentImpliesView(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(zap.NewNop(), db_guests.NewMemory(), service_onetime.New(db_onetime.NewMemory()))
+
+	code, _, err := svc.Create(ctx, "codebase-id", "workspace-id", "member-id", guests.Capabilities{guests.CapabilityComment}, nil)
+	require.NoError(t, err)
+	_, err = svc.Accept(ctx, "guest-id", code)
+	require.NoError(t, err)
+
+	assert.NoError(t, svc.Allows(ctx, "guest-id", "workspace-id", guests.CapabilityView))
+	assert.NoError(t, svc.Allows(ctx, "guest-id", "workspace-id", guests.CapabilityComment))
+}
+
+func TestAllows_expired(t *testing.T) {
+	ctx := context.Background()
+	repo := db_guests.NewMemory()
+	svc := service.New(zap.NewNop(), repo, service_onetime.New(db_onetime.NewMemory()))
+
+	expiresAt := time.Now().Add(time.Hour)
+	code, invite, err := svc.Create(ctx, "codebase-id", "workspace-id", "member-id", guests.Capabilities{guests.CapabilityView}, &expiresAt)
+	require.NoError(t, err)
+	_, err = svc.Accept(ctx, "guest-id", code)
+	require.NoError(t, err)
+	assert.NoError(t, svc.Allows(ctx, "guest-id", "workspace-id", guests.CapabilityView))
+
+	invite, err = repo.GetByID(ctx, invite.ID)
+	require.NoError(t, err)
+	invite.ExpiresAt = time.Now().Add(-time.Minute)
+	require.NoError(t, repo.Create(ctx, invite))
+
+	assert.ErrorIs(t, svc.Allows(ctx, "guest-id", "workspace-id", guests.CapabilityView), service.ErrForbidden)
+	_, err = svc.Accept(ctx, "guest-id", code)
+	assert.ErrorIs(t, err, service.ErrExpired)
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(zap.NewNop(), db_guests.NewMemory(), service_onetime.New(db_onetime.NewMemory()))
+
+	code, invite, err := svc.Create(ctx, "codebase-id", "workspace-id", "member-id", guests.Capabilities{guests.CapabilityView}, nil)
+	require.NoError(t, err)
+	_, err = svc.Accept(ctx, "guest-id", code)
+	require.NoError(t, err)
+
+	_, err = svc.Revoke(ctx, invite)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, svc.Allows(ctx, "guest-id", "workspace-id", guests.CapabilityView), service.ErrForbidden)
+	_, err = svc.Accept(ctx, "guest-id", code)
+	assert.ErrorIs(t, err, service.ErrRevoked)
+}
+
+func TestCreate_invalid(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(zap.NewNop(), db_guests.NewMemory(), service_onetime.New(db_onetime.NewMemory()))
+
+	_, _, err := svc.Create(ctx, "codebase-id", "workspace-id", "member-id", nil, nil)
+	assert.ErrorIs(t, err, service.ErrInvalidCapability)
+
+	_, _, err = svc.Create(ctx, "codebase-id", "workspace-id", "member-id", guests.Capabilities{"admin"}, nil)
+	assert.ErrorIs(t, err, service.ErrInvalidCapability)
+
+	past := time.Now().Add(-time.Hour)
+	_, _, err = svc.Create(ctx, "codebase-id", "workspace-id", "member-id", guests.Capabilities{guests.CapabilityView}, &past)
+	assert.ErrorIs(t, err, service.ErrInvalidExpiry)
+
+	tooLate := time.Now().Add(service.MaxValidFor + time.Hour)
+	_, _, err = svc.Create(ctx, "codebase-id", "workspace-id", "member-id", guests.Capabilities{guests.CapabilityView}, &tooLate)
+	assert.ErrorIs(t, err, service.ErrInvalidExpiry)
+}
@@ -4,6 +4,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
+	service_accesstokens "getsturdy.com/api/pkg/accesstokens/service"
+	service_analytics "getsturdy.com/api/pkg/analytics/service"
 	authz "getsturdy.com/api/pkg/auth"
 	service_ci "getsturdy.com/api/pkg/ci/service"
 	"getsturdy.com/api/pkg/github/enterprise/config"
@@ -14,9 +16,16 @@ import (
 	"getsturdy.com/api/pkg/http"
 	service_buildkite "getsturdy.com/api/pkg/integrations/buildkite/enterprise/service"
 	service_jwt "getsturdy.com/api/pkg/jwt/service"
+	routes_ldap "getsturdy.com/api/pkg/ldap/enterprise/selfhosted/routes"
+	service_ldap "getsturdy.com/api/pkg/ldap/enterprise/selfhosted/service"
+	routes_oidc "getsturdy.com/api/pkg/oidc/enterprise/selfhosted/routes"
+	service_oidc "getsturdy.com/api/pkg/oidc/enterprise/selfhosted/service"
+	routes_scim "getsturdy.com/api/pkg/scim/routes"
+	service_scim "getsturdy.com/api/pkg/scim/service"
 	service_servicetokens "getsturdy.com/api/pkg/servicetokens/service"
 	routes_ci "getsturdy.com/api/pkg/statuses/enterprise/routes"
 	service_statuses "getsturdy.com/api/pkg/statuses/service"
+	service_twofactor "getsturdy.com/api/pkg/twofactor/service"
 	db_user "getsturdy.com/api/pkg/users/db"
 )
 
@@ -29,19 +38,40 @@ func ProvideHandler(
 	gitHubAppConfig *config.GitHubAppConfig,
 	statusesService *service_statuses.Service,
 	jwtService *service_jwt.Service,
+	accessTokensService *service_accesstokens.Service,
 	gitHubService *service_github.Service,
 	ciService *service_ci.Service,
 	serviceTokensService *service_servicetokens.Service,
 	buildkiteService *service_buildkite.Service,
+	scimService *service_scim.Service,
 	ossEngine *http.Engine,
 	gitHubWebhooksQueue *workers_github.WebhooksQueue,
 ) *Engine {
 	auth := ossEngine.Group("")
-	auth.Use(authz.GinMiddleware(logger, jwtService))
+	auth.Use(authz.GinMiddleware(logger, jwtService, accessTokensService, serviceTokensService))
 	auth.POST("/v3/github/oauth", routes_v3_ghapp.Oauth(logger, gitHubAppConfig, userRepo, gitHubUserRepo, gitHubService))
 
 	publ := ossEngine.Group("")
 	publ.POST("/v3/github/webhook", routes_v3_ghapp.Webhook(logger, gitHubWebhooksQueue))
 	publ.POST("/v3/statuses/webhook", routes_ci.WebhookHandler(logger, statusesService, ciService, serviceTokensService, buildkiteService))
+
+	routes_scim.Register(logger, ossEngine.Group("/scim/v2"), scimService)
 	return (*Engine)(ossEngine)
 }
+
+// ProvideSelfHostedHandler adds the routes that are only available in self-hosted installations.
+func ProvideSelfHostedHandler(
+	logger *zap.Logger,
+	enterpriseEngine *Engine,
+	jwtService *service_jwt.Service,
+	analyticsService *service_analytics.Service,
+	oidcService *service_oidc.Service,
+	ldapService *service_ldap.Service,
+	twoFactorService *service_twofactor.Service,
+) *gin.Engine {
+	publ := enterpriseEngine.Group("")
+	publ.GET("/v3/auth/oidc", routes_oidc.Redirect(logger, oidcService))
+	publ.GET("/v3/auth/oidc/callback", routes_oidc.Callback(logger, oidcService, analyticsService, jwtService))
+	publ.POST("/v3/auth/ldap", routes_ldap.Login(logger, ldapService, analyticsService, jwtService, twoFactorService))
+	return (*gin.Engine)(enterpriseEngine)
+}
@@ -1,11 +1,13 @@
 package http
 
 import (
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"strings"
 	"time"
 
+	service_accesstokens "getsturdy.com/api/pkg/accesstokens/service"
 	service_analytics "getsturdy.com/api/pkg/analytics/service"
 	authz "getsturdy.com/api/pkg/auth"
 	service_auth "getsturdy.com/api/pkg/auth/service"
@@ -22,6 +24,7 @@ import (
 	"getsturdy.com/api/pkg/ginzap"
 	sturdygrapql "getsturdy.com/api/pkg/graphql"
 	"getsturdy.com/api/pkg/ip"
+	routes_jwt "getsturdy.com/api/pkg/jwt/routes"
 	service_jwt "getsturdy.com/api/pkg/jwt/service"
 	"getsturdy.com/api/pkg/metrics/ginprometheus"
 	db_mutagen "getsturdy.com/api/pkg/mutagen/db"
@@ -31,6 +34,8 @@ import (
 	db_pki "getsturdy.com/api/pkg/pki/db"
 	routes_v3_pki "getsturdy.com/api/pkg/pki/routes"
 	service_presence "getsturdy.com/api/pkg/presence/service"
+	service_servicetokens "getsturdy.com/api/pkg/servicetokens/service"
+	service_sessions "getsturdy.com/api/pkg/sessions/service"
 	db_snapshots "getsturdy.com/api/pkg/snapshots/db"
 	"getsturdy.com/api/pkg/snapshots/snapshotter"
 	worker_snapshots "getsturdy.com/api/pkg/snapshots/worker"
@@ -38,6 +43,8 @@ import (
 	service_suggestion "getsturdy.com/api/pkg/suggestions/service"
 	routes_v3_sync "getsturdy.com/api/pkg/sync/routes"
 	service_sync "getsturdy.com/api/pkg/sync/service"
+	service_twofactor "getsturdy.com/api/pkg/twofactor/service"
+	"getsturdy.com/api/pkg/useragent"
 	"getsturdy.com/api/pkg/users/avatars/uploader"
 	db_user "getsturdy.com/api/pkg/users/db"
 	routes_v3_user "getsturdy.com/api/pkg/users/routes"
@@ -64,6 +71,7 @@ import (
 type Configuration struct {
 	Addr             flags.Addr `long:"addr" description:"Address to listen on" default:"localhost:3000"`
 	AllowCORSOrigins []string   `long:"allow-cors-origin" description:"Additional origin that is allowed to make CORS requests (can be provided multiple times)"`
+	TrustedProxies   []string   `long:"trusted-proxy" description:"Address or CIDR of a proxy in front of the server, the ip of the client is read from the X-Forwarded-For header of requests from trusted proxies (can be provided multiple times)"`
 }
 
 func ginMode() string {
@@ -106,6 +114,10 @@ func ProvideHandler(
 	userService service_user.Service,
 	syncService *service_sync.Service,
 	jwtService *service_jwt.Service,
+	accessTokensService *service_accesstokens.Service,
+	serviceTokensService *service_servicetokens.Service,
+	sessionsService *service_sessions.Service,
+	twoFactorService *service_twofactor.Service,
 	codebaseService *service_codebase.Service,
 	authService *service_auth.Service,
 	grapqhlResolver *sturdygrapql.RootResolver,
@@ -139,18 +151,22 @@ func ProvideHandler(
 	})
 	gin.SetMode(ginMode())
 	r := gin.New()
-	_ = r.SetTrustedProxies(nil)
+	if err := r.SetTrustedProxies(config.TrustedProxies); err != nil {
+		logger.Error("invalid trusted proxies", zap.Error(err))
+		_ = r.SetTrustedProxies(nil)
+	}
 	r.Use(accessLogger(logger, time.RFC3339, true))
 	r.Use(gzip.Gzip(gzip.DefaultCompression))
 	r.Use(ginzap.RecoveryWithZap(logger, true))
 	r.Use(cors)
 	r.Use(setIp)
+	r.Use(setUserAgent)
 
 	// Setup Prometheus metrics for Gin
 	ginprom := ginprometheus.NewPrometheus("gin", logger)
 	ginprom.ReqCntURLLabelMappingFn = metricsMapper
 	ginprom.Use(r)
-	graphql := r.Group("/graphql", sturdygrapql.CorsMiddleware(allowOrigins), authz.GinMiddleware(logger, jwtService))
+	graphql := r.Group("/graphql", sturdygrapql.CorsMiddleware(allowOrigins), authz.GinMiddleware(logger, jwtService, accessTokensService, serviceTokensService))
 	graphql.OPTIONS("", func(c *gin.Context) { c.Status(http.StatusOK) })
 	graphql.OPTIONS("ws", func(c *gin.Context) { c.Status(http.StatusOK) })
 	graphql.POST("", grapqhlResolver.HttpHandler())
@@ -160,11 +176,12 @@ func ProvideHandler(
 	publ := r.Group("")
 	// Private endpoints, requires a valid auth cookie
 	auth := r.Group("")
-	auth.Use(authz.GinMiddleware(logger, jwtService))
-	publ.POST("/v3/auth", routes_v3_user.Login(logger, userRepo, analyticsService, jwtService))
+	auth.Use(authz.GinMiddleware(logger, jwtService, accessTokensService, serviceTokensService))
+	publ.POST("/v3/auth", routes_v3_user.Login(logger, userRepo, analyticsService, jwtService, twoFactorService))
+	publ.POST("/v3/auth/two-factor", routes_v3_user.LoginTwoFactor(logger, userRepo, analyticsService, jwtService, twoFactorService, sessionsService))
 	publ.POST("/v3/users", routes_v3_user.Signup(logger, userService, jwtService, analyticsService))
-	publ.POST("/v3/auth/destroy", routes_v3_user.AuthDestroy)
-	auth.POST("/v3/auth/client-token", routes_v3_user.ClientToken(userRepo, jwtService))
+	auth.POST("/v3/auth/destroy", routes_v3_user.AuthDestroy(logger, sessionsService))
+	auth.POST("/v3/auth/client-token", routes_v3_user.ClientToken(userRepo, jwtService, twoFactorService, sessionsService))
 	auth.POST("/v3/auth/renew-token", routes_v3_user.RenewToken(logger, userRepo, jwtService))
 	auth.POST("/v3/user/update-avatar", routes_v3_user.UpdateAvatar(logger, userRepo, uploader))                                                                                                       // Used by the web (2021-10-04)
 	auth.GET("/v3/user", routes_v3_user.GetSelf(userRepo, jwtService))                                                                                                                                 // Used by the command line client
@@ -188,6 +205,8 @@ func ProvideHandler(
 	auth.POST("/v3/workspaces", routes_v3_workspace.Create(logger, workspaceService, codebaseUserRepo))                  // Used by the command line client
 	// Used by LBS to check for health
 	publ.GET("/readyz", func(c *gin.Context) { c.Status(http.StatusOK) })
+	// Used to verify tokens offline, by CI jobs and other services
+	publ.GET("/.well-known/jwks.json", routes_jwt.JWKS(logger, jwtService))
 	publ.POST("/v3/waitinglist", waitinglist.Insert(logger, analyticsService, waitingListRepo))                                                                                                               // Used by the web (2021-10-04)
 	publ.POST("/v3/acl-request-enterprise", acl.Insert(logger, analyticsService, aclInterestRepo))                                                                                                            // Used by the web (2021-10-04)
 	publ.POST("/v3/instant-integration", instantintegration.Insert(logger, analyticsService, instantIntegrationInterestRepo))                                                                                 // Used by the web (2021-10-27)
@@ -262,10 +281,15 @@ func metricsMapper(c *gin.Context) string {
 	return url
 }
 
+func setUserAgent(c *gin.Context) {
+	c.Request = c.Request.WithContext(useragent.NewContext(c.Request.Context(), c.Request.UserAgent()))
+	c.Next()
+}
+
+// setIp sets the ip of the client in the context. Behind a load balancer, the ip is only read from X-Forwarded-For if the
+// load balancer is configured as a trusted proxy, otherwise it's the ip of the load balancer.
 func setIp(c *gin.Context) {
-	// This is not checking if the remote IP is "trusted" or not
-	// TODO: Allow configuration for trusted proxies?
-	remoteIp, _ := c.RemoteIP()
-	c.Request = c.Request.WithContext(ip.NewContext(c.Request.Context(), remoteIp))
+	clientIp := net.ParseIP(c.ClientIP())
+	c.Request = c.Request.WithContext(ip.NewContext(c.Request.Context(), clientIp))
 	c.Next()
 }
@@ -7,7 +7,7 @@ import (
 
 	service_auth "getsturdy.com/api/pkg/auth/service"
 	service_ci "getsturdy.com/api/pkg/ci/service"
-	"getsturdy.com/api/pkg/codebase"
+	service_codebase "getsturdy.com/api/pkg/codebase/service"
 	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
 	"getsturdy.com/api/pkg/graphql/resolvers"
 	"getsturdy.com/api/pkg/integrations"
@@ -19,6 +19,7 @@ import (
 
 type rootResolver struct {
 	authService                    *service_auth.Service
+	codebaseService                *service_codebase.Service
 	buildkiteService               *service_buildkite.Service
 	instantIntegrationServcie      *service_ci.Service
 	instantIntegrationRootResolver *resolvers.IntegrationRootResolver
@@ -38,12 +39,14 @@ var seedFiles = []string{
 
 func New(
 	authService *service_auth.Service,
+	codebaseService *service_codebase.Service,
 	buildkiteService *service_buildkite.Service,
 	instantIntegrationServcie *service_ci.Service,
 	instantIntegrationRootResolver *resolvers.IntegrationRootResolver,
 ) resolvers.BuildkiteInstantIntegrationRootResolver {
 	return &rootResolver{
 		authService:                    authService,
+		codebaseService:                codebaseService,
 		buildkiteService:               buildkiteService,
 		instantIntegrationServcie:      instantIntegrationServcie,
 		instantIntegrationRootResolver: instantIntegrationRootResolver,
@@ -125,7 +128,12 @@ func (root *rootResolver) updateConfiguration(ctx context.Context, existingCfg *
 }
 
 func (root *rootResolver) CreateOrUpdateBuildkiteIntegration(ctx context.Context, args resolvers.CreateOrUpdateBuildkiteIntegrationArgs) (resolvers.IntegrationResolver, error) {
-	if err := root.authService.CanWrite(ctx, &codebase.Codebase{ID: string(args.Input.CodebaseID)}); err != nil {
+	cb, err := root.codebaseService.GetByID(ctx, string(args.Input.CodebaseID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := root.authService.CanAdmin(ctx, cb); err != nil {
 		return nil, gqlerrors.Error(err)
 	}
 
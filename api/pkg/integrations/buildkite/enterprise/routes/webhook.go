@@ -18,6 +18,7 @@ import (
 	svc_ci "getsturdy.com/api/pkg/ci/service"
 	"getsturdy.com/api/pkg/integrations/buildkite"
 	service_buildkite "getsturdy.com/api/pkg/integrations/buildkite/enterprise/service"
+	"getsturdy.com/api/pkg/servicetokens"
 	service_servicetokens "getsturdy.com/api/pkg/servicetokens/service"
 	"getsturdy.com/api/pkg/statuses"
 	svc_statuses "getsturdy.com/api/pkg/statuses/service"
@@ -147,6 +148,17 @@ func WebhookHandler(
 			}
 		}
 
+		// the webhook is sent by buildkite, and not by the ci agents that use the secret of the token, so it's
+		// authenticated by its signature, and the allowed ips of the token don't apply
+		if serviceToken.IsExpired(time.Now()) {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		if err := serviceTokensService.Authorize(c.Request.Context(), serviceToken, servicetokens.CapabilityStatusesWrite); err != nil {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
 		statusType, ok := buildkiteStateToType[*payload.Build.State]
 		if !ok {
 			logger.Error("invalid status from buildkite", zap.Stringp("status", payload.Build.State))
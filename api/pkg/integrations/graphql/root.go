@@ -5,7 +5,7 @@ import (
 	"fmt"
 
 	service_change "getsturdy.com/api/pkg/change/service"
-	"getsturdy.com/api/pkg/codebase"
+	service_codebase "getsturdy.com/api/pkg/codebase/service"
 	"getsturdy.com/api/pkg/integrations"
 
 	service_auth "getsturdy.com/api/pkg/auth/service"
@@ -16,9 +16,10 @@ import (
 )
 
 type rootResolver struct {
-	svc           *service.Service
-	changeService *service_change.Service
-	authService   *service_auth.Service
+	svc             *service.Service
+	changeService   *service_change.Service
+	authService     *service_auth.Service
+	codebaseService *service_codebase.Service
 
 	buildkiteRootResolver resolvers.BuildkiteInstantIntegrationRootResolver
 	statusesRootResolver  resolvers.StatusesRootResolver
@@ -28,14 +29,16 @@ func NewRootResolver(
 	svc *service.Service,
 	changeService *service_change.Service,
 	authService *service_auth.Service,
+	codebaseService *service_codebase.Service,
 
 	buildkiteRootResolver resolvers.BuildkiteInstantIntegrationRootResolver,
 	statusesRootResolver resolvers.StatusesRootResolver,
 ) resolvers.IntegrationRootResolver {
 	return &rootResolver{
-		svc:           svc,
-		changeService: changeService,
-		authService:   authService,
+		svc:             svc,
+		changeService:   changeService,
+		authService:     authService,
+		codebaseService: codebaseService,
 
 		buildkiteRootResolver: buildkiteRootResolver,
 		statusesRootResolver:  statusesRootResolver,
@@ -81,7 +84,12 @@ func (r *rootResolver) DeleteIntegration(ctx context.Context, args resolvers.Del
 		return nil, gqlerrors.Error(err)
 	}
 
-	if err := r.authService.CanWrite(ctx, &codebase.Codebase{ID: cfg.CodebaseID}); err != nil {
+	cb, err := r.codebaseService.GetByID(ctx, cfg.CodebaseID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.authService.CanAdmin(ctx, cb); err != nil {
 		return nil, gqlerrors.Error(err)
 	}
 
@@ -3,6 +3,9 @@ package inmemory
 import (
 	"context"
 	"database/sql"
+	"sort"
+	"strings"
+	"time"
 
 	"getsturdy.com/api/pkg/change"
 	db_change "getsturdy.com/api/pkg/change/db"
@@ -37,14 +40,19 @@ func (r *inMemoryChangeRepo) ListByIDs(_ context.Context, ids ...change.ID) ([]*
 
 func (r *inMemoryChangeRepo) GetByCommitID(_ context.Context, commitID, codebaseID string) (*change.Change, error) {
 	for _, c := range r.changes {
-		if c.CodebaseID == codebaseID && c.CommitID == nil && *c.CommitID == commitID {
+		if c.CodebaseID == codebaseID && c.CommitID != nil && *c.CommitID == commitID {
 			return &c, nil
 		}
 	}
 	return nil, sql.ErrNoRows
 }
 
-func (r *inMemoryChangeRepo) Insert(_ context.Context, ch change.Change) error {
+func (r *inMemoryChangeRepo) Insert(ctx context.Context, ch change.Change) error {
+	if ch.CommitID != nil {
+		if _, err := r.GetByCommitID(ctx, *ch.CommitID, ch.CodebaseID); err == nil {
+			return nil
+		}
+	}
 	r.changes[ch.ID] = ch
 	return nil
 }
@@ -53,3 +61,116 @@ func (r *inMemoryChangeRepo) Update(_ context.Context, ch change.Change) error {
 	r.changes[ch.ID] = ch
 	return nil
 }
+
+func (r *inMemoryChangeRepo) ListByCommitIDs(_ context.Context, codebaseID string, commitIDs []string) ([]*change.Change, error) {
+	ids := make(map[string]bool, len(commitIDs))
+	for _, id := range commitIDs {
+		ids[id] = true
+	}
+	var res []*change.Change
+	for _, c := range r.changes {
+		c := c
+		if c.CodebaseID == codebaseID && c.CommitID != nil && ids[*c.CommitID] {
+			res = append(res, &c)
+		}
+	}
+	return res, nil
+}
+
+func (r *inMemoryChangeRepo) ListByCodebaseID(_ context.Context, codebaseID string, opts db_change.ListOptions) ([]*change.Change, error) {
+	var commitIDs map[string]bool
+	if opts.CommitIDs != nil {
+		commitIDs = make(map[string]bool, len(opts.CommitIDs))
+		for _, id := range opts.CommitIDs {
+			commitIDs[id] = true
+		}
+	}
+
+	var res []*change.Change
+	for _, c := range r.changes {
+		c := c
+		t := c.SortTime()
+		switch {
+		case c.CodebaseID != codebaseID:
+			continue
+		case opts.After != nil && !(t.Before(opts.After.Time) || t.Equal(opts.After.Time) && c.ID < opts.After.ID):
+			continue
+		case opts.UserID != nil && (c.UserID == nil || *c.UserID != *opts.UserID):
+			continue
+		case opts.GitCreatorEmail != nil && (c.GitCreatorEmail == nil || *c.GitCreatorEmail != *opts.GitCreatorEmail):
+			continue
+		case commitIDs != nil && (c.CommitID == nil || !commitIDs[*c.CommitID]):
+			continue
+		case opts.Since != nil && t.Before(*opts.Since):
+			continue
+		case opts.Until != nil && !t.Before(*opts.Until):
+			continue
+		case opts.Query != nil && !matchesQuery(&c, *opts.Query):
+			continue
+		}
+		res = append(res, &c)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		ti, tj := res[i].SortTime(), res[j].SortTime()
+		if ti.Equal(tj) {
+			return res[i].ID > res[j].ID
+		}
+		return ti.After(tj)
+	})
+
+	if len(res) > opts.Limit {
+		res = res[:opts.Limit]
+	}
+	return res, nil
+}
+
+func matchesQuery(c *change.Change, query string) bool {
+	text := strings.ToLower(c.UpdatedDescription)
+	if c.Title != nil {
+		text = strings.ToLower(*c.Title) + " " + text
+	}
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+type inMemoryChangelogMaterializationRepo struct {
+	materializations map[string]change.ChangelogMaterialization
+	queuedAt         map[string]time.Time
+}
+
+func NewInMemoryChangelogMaterializationRepo() db_change.MaterializationRepository {
+	return &inMemoryChangelogMaterializationRepo{
+		materializations: make(map[string]change.ChangelogMaterialization),
+		queuedAt:         make(map[string]time.Time),
+	}
+}
+
+func (r *inMemoryChangelogMaterializationRepo) Get(_ context.Context, codebaseID string) (*change.ChangelogMaterialization, error) {
+	if m, ok := r.materializations[codebaseID]; ok {
+		return &m, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *inMemoryChangelogMaterializationRepo) Upsert(_ context.Context, m *change.ChangelogMaterialization) error {
+	r.materializations[m.CodebaseID] = *m
+	return nil
+}
+
+func (r *inMemoryChangelogMaterializationRepo) MarkQueued(_ context.Context, codebaseID string, now, staleBefore time.Time) (bool, error) {
+	if queuedAt, ok := r.queuedAt[codebaseID]; ok && !queuedAt.Before(staleBefore) {
+		return false, nil
+	}
+	r.queuedAt[codebaseID] = now
+	return true, nil
+}
+
+func (r *inMemoryChangelogMaterializationRepo) ClearQueued(_ context.Context, codebaseID string) error {
+	delete(r.queuedAt, codebaseID)
+	return nil
+}
@@ -78,6 +78,17 @@ func (r *inMemoryCodebaseRepository) ListByOrganization(_ context.Context, id st
 	return res, nil
 }
 
+func (r *inMemoryCodebaseRepository) List(_ context.Context) ([]*codebase.Codebase, error) {
+	var res []*codebase.Codebase
+	for _, cb := range r.codebases {
+		if cb.ArchivedAt == nil {
+			c2 := cb
+			res = append(res, &c2)
+		}
+	}
+	return res, nil
+}
+
 func (r *inMemoryCodebaseRepository) Count(_ context.Context) (uint64, error) {
 	return uint64(len(r.codebases)), nil
 }
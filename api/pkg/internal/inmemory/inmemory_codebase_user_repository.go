@@ -6,6 +6,7 @@ import (
 
 	"getsturdy.com/api/pkg/codebase"
 	db_codebase "getsturdy.com/api/pkg/codebase/db"
+	"getsturdy.com/api/pkg/organization"
 )
 
 type inMemoryCodebaseUserRepository struct {
@@ -17,6 +18,9 @@ func NewInMemoryCodebaseUserRepo() db_codebase.CodebaseUserRepository {
 }
 
 func (r *inMemoryCodebaseUserRepository) Create(entity codebase.CodebaseUser) error {
+	if entity.Role == "" {
+		entity.Role = organization.RoleMember
+	}
 	r.users = append(r.users, entity)
 	return nil
 }
@@ -52,6 +56,15 @@ func (r *inMemoryCodebaseUserRepository) GetByUserAndCodebase(userID, codebaseID
 	return nil, sql.ErrNoRows
 }
 
+func (r *inMemoryCodebaseUserRepository) Update(_ context.Context, entity *codebase.CodebaseUser) error {
+	for i, u := range r.users {
+		if u.ID == entity.ID {
+			r.users[i] = *entity
+		}
+	}
+	return nil
+}
+
 func (r *inMemoryCodebaseUserRepository) DeleteByID(_ context.Context, id string) error {
 	for i, u := range r.users {
 		if u.ID == id {
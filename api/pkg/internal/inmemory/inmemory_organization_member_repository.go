@@ -18,7 +18,7 @@ func NewInMemoryOrganizationMemberRepository() db_organization.MemberRepository
 
 func (r *inMemoryOrganizationMemberRepository) GetByUserIDAndOrganizationID(ctx context.Context, userID, organizationID string) (*organization.Member, error) {
 	for _, u := range r.users {
-		if u.UserID == userID && u.OrganizationID == organizationID {
+		if u.UserID == userID && u.OrganizationID == organizationID && u.DeletedAt == nil {
 			return &u, nil
 		}
 	}
@@ -28,7 +28,7 @@ func (r *inMemoryOrganizationMemberRepository) GetByUserIDAndOrganizationID(ctx
 func (r *inMemoryOrganizationMemberRepository) ListByOrganizationID(ctx context.Context, id string) ([]*organization.Member, error) {
 	var res []*organization.Member
 	for _, u := range r.users {
-		if u.OrganizationID == id {
+		if u.OrganizationID == id && u.DeletedAt == nil {
 			u2 := u
 			res = append(res, &u2)
 		}
@@ -39,7 +39,7 @@ func (r *inMemoryOrganizationMemberRepository) ListByOrganizationID(ctx context.
 func (r *inMemoryOrganizationMemberRepository) ListByUserID(ctx context.Context, id string) ([]*organization.Member, error) {
 	var res []*organization.Member
 	for _, u := range r.users {
-		if u.UserID == id {
+		if u.UserID == id && u.DeletedAt == nil {
 			u2 := u
 			res = append(res, &u2)
 		}
@@ -48,6 +48,9 @@ func (r *inMemoryOrganizationMemberRepository) ListByUserID(ctx context.Context,
 }
 
 func (r *inMemoryOrganizationMemberRepository) Create(ctx context.Context, org organization.Member) error {
+	if org.Role == "" {
+		org.Role = organization.RoleMember
+	}
 	r.users = append(r.users, org)
 	return nil
 }
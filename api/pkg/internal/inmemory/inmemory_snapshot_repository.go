@@ -55,6 +55,10 @@ func (f *snapshotRepo) ListUndeletedInCodebase(_ string, _ time.Time) ([]*snapsh
 	panic("not implemented")
 }
 
+func (f *snapshotRepo) ListLatestUndeletedPerWorkspace(_ string, _ int) ([]*snapshots.Snapshot, error) {
+	panic("not implemented")
+}
+
 func (f *snapshotRepo) Update(*snapshots.Snapshot) error {
 	panic("not implemented")
 }
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"getsturdy.com/api/pkg/jwt/keys"
 	"sync"
+	"time"
 )
 
 var _ Repository = &cache{}
@@ -65,3 +66,22 @@ func (c *cache) Get(ctx context.Context, id string) (*keys.Key, error) {
 		}
 	}
 }
+
+func (c *cache) ListUnexpired(ctx context.Context, at time.Time) ([]*keys.Key, error) {
+	return c.db.ListUnexpired(ctx, at)
+}
+
+func (c *cache) DeleteExpired(ctx context.Context, at time.Time) (int64, error) {
+	deleted, err := c.db.DeleteExpired(ctx, at)
+	if err != nil {
+		return 0, err
+	}
+	c.cacheGuard.Lock()
+	for id, key := range c.cache {
+		if key != nil && key.Expired(at) {
+			delete(c.cache, id)
+		}
+	}
+	c.cacheGuard.Unlock()
+	return deleted, nil
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"getsturdy.com/api/pkg/jwt/keys"
 
@@ -25,9 +26,10 @@ func (db *database) Create(ctx context.Context, key *keys.Key) error {
 	if _, err := db.db.NamedExecContext(ctx, `
 	INSERT INTO jwt_keys (
 		id,
-		public_der
+		public_der,
+		expires_at
 	) VALUES (
-		:id, :public_der
+		:id, :public_der, :expires_at
 	)
 	`, key); err != nil {
 		return fmt.Errorf("failed to insert: %w", err)
@@ -39,7 +41,7 @@ func (db *database) Get(ctx context.Context, id string) (*keys.Key, error) {
 	key := &keys.Key{}
 	if err := db.db.GetContext(ctx, key, `
 	SELECT
-		id, public_der
+		id, public_der, expires_at
 	FROM
 		jwt_keys
 	WHERE
@@ -49,3 +51,37 @@ func (db *database) Get(ctx context.Context, id string) (*keys.Key, error) {
 	}
 	return key, nil
 }
+
+func (db *database) ListUnexpired(ctx context.Context, at time.Time) ([]*keys.Key, error) {
+	var res []*keys.Key
+	if err := db.db.SelectContext(ctx, &res, `
+	SELECT
+		id, public_der, expires_at
+	FROM
+		jwt_keys
+	WHERE
+		expires_at > $1
+	ORDER BY
+		expires_at
+	`, at); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (db *database) DeleteExpired(ctx context.Context, at time.Time) (int64, error) {
+	res, err := db.db.ExecContext(ctx, `
+	DELETE FROM
+		jwt_keys
+	WHERE
+		expires_at <= $1
+	`, at)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete: %w", err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return deleted, nil
+}
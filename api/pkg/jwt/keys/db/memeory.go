@@ -3,6 +3,8 @@ package db
 import (
 	"context"
 	"database/sql"
+	"sort"
+	"time"
 
 	"getsturdy.com/api/pkg/jwt/keys"
 )
@@ -31,3 +33,27 @@ func (db *memory) Get(ctx context.Context, id string) (*keys.Key, error) {
 	}
 	return key, nil
 }
+
+func (db *memory) ListUnexpired(ctx context.Context, at time.Time) ([]*keys.Key, error) {
+	var res []*keys.Key
+	for _, key := range db.byID {
+		if !key.Expired(at) {
+			res = append(res, key)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ExpiresAt.Before(res[j].ExpiresAt)
+	})
+	return res, nil
+}
+
+func (db *memory) DeleteExpired(ctx context.Context, at time.Time) (int64, error) {
+	var deleted int64
+	for id, key := range db.byID {
+		if key.Expired(at) {
+			delete(db.byID, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"getsturdy.com/api/pkg/jwt/keys"
 )
@@ -9,4 +10,8 @@ import (
 type Repository interface {
 	Create(context.Context, *keys.Key) error
 	Get(context.Context, string) (*keys.Key, error)
+	// ListUnexpired returns the keys that have not expired at the given time.
+	ListUnexpired(context.Context, time.Time) ([]*keys.Key, error)
+	// DeleteExpired deletes the keys that have expired at the given time, and returns how many were deleted.
+	DeleteExpired(context.Context, time.Time) (int64, error)
 }
@@ -2,6 +2,7 @@ package keys
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -11,10 +12,13 @@ var ErrKeyIsEmpty = fmt.Errorf("key is empty")
 type Key struct {
 	ID        string `db:"id"`
 	PublicDER []byte `db:"public_der"`
+	// ExpiresAt is when all tokens signed with the key have expired. Expired keys are not published, and can be
+	// deleted.
+	ExpiresAt time.Time `db:"expires_at"`
 }
 
 // New creates a new key with a public der payload.
-func New(publicDER []byte) (*Key, error) {
+func New(publicDER []byte, expiresAt time.Time) (*Key, error) {
 	if len(publicDER) == 0 {
 		return nil, ErrKeyIsEmpty
 	}
@@ -22,5 +26,11 @@ func New(publicDER []byte) (*Key, error) {
 	return &Key{
 		ID:        uuid.New().String(),
 		PublicDER: publicDER,
+		ExpiresAt: expiresAt,
 	}, nil
 }
+
+// Expired returns true if all tokens signed with the key have expired at the given time.
+func (k *Key) Expired(at time.Time) bool {
+	return !at.Before(k.ExpiresAt)
+}
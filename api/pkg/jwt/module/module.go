@@ -4,9 +4,11 @@ import (
 	"getsturdy.com/api/pkg/di"
 	keys_db "getsturdy.com/api/pkg/jwt/keys/db"
 	"getsturdy.com/api/pkg/jwt/service"
+	"getsturdy.com/api/pkg/jwt/worker"
 )
 
 func Module(c *di.Container) {
 	c.Import(keys_db.Module)
 	c.Import(service.Module)
+	c.Import(worker.Module)
 }
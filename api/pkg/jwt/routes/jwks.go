@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"net/http"
+
+	service_jwt "getsturdy.com/api/pkg/jwt/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// JWKS returns the public keys that Sturdy tokens are signed with, so that they can be verified without calling the
+// API. Keys that replace other keys are published an hour before they are used, but the first key of a process signs
+// tokens as soon as it's generated, so the keys must be revalidated before they are used from a cache, and fetched
+// again when a token is signed by an unknown key.
+func JWKS(logger *zap.Logger, jwtService *service_jwt.Service) func(*gin.Context) {
+	return func(c *gin.Context) {
+		set, err := jwtService.JWKS(c.Request.Context())
+		if err != nil {
+			logger.Error("failed to get jwks", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		c.Header("Cache-Control", "public, no-cache")
+		c.JSON(http.StatusOK, set)
+	}
+}
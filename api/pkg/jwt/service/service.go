@@ -15,6 +15,7 @@ import (
 	"getsturdy.com/api/pkg/jwt"
 	"getsturdy.com/api/pkg/jwt/keys"
 	db_keys "getsturdy.com/api/pkg/jwt/keys/db"
+	service_sessions "getsturdy.com/api/pkg/sessions/service"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -24,58 +25,110 @@ import (
 
 // Known errors.
 var (
-	ErrInvalidToken = fmt.Errorf("token is invalid")
-	ErrTokenExpired = fmt.Errorf("token is expired")
+	ErrInvalidToken    = fmt.Errorf("token is invalid")
+	ErrTokenExpired    = fmt.Errorf("token is expired")
+	ErrTokenRevoked    = fmt.Errorf("%w: session is revoked", ErrInvalidToken)
+	ErrValidForTooLong = fmt.Errorf("tokens can be valid for at most %s", maxValidFor)
 )
 
 const (
 	defaultIssuer = "https://getsturdy.com"
 )
 
-type Service struct {
-	logger   *zap.Logger
-	keysRepo db_keys.Repository
+var (
+	// rotateEvery is how long each key is used to sign tokens, before it's replaced by a new key.
+	rotateEvery = 24 * time.Hour
+	// publishAhead is how long before a key replaces the current key that it's published in the JWKS, so that anyone
+	// that verifies tokens offline has time to fetch it. The first key of a process has nothing to replace, and signs
+	// tokens as soon as it's generated.
+	publishAhead = time.Hour
+	// maxValidFor is the longest that tokens can be valid for. Keys are kept until all tokens that they have signed
+	// have expired.
+	maxValidFor = 31 * 24 * time.Hour
+)
+
+// signingKey is a key that this process signs tokens with. Only the public keys are stored, every process has its
+// own private keys.
+type signingKey struct {
+	id        string
+	signer    jose.Signer
+	signFrom  time.Time
+	signUntil time.Time
+}
 
-	signerInitOnce *sync.Once
-	signer         jose.Signer
+type Service struct {
+	logger          *zap.Logger
+	keysRepo        db_keys.Repository
+	sessionsService *service_sessions.Service
+
+	signingKeysGuard *sync.Mutex
+	// current is the key that signs tokens, and next is the key that will replace it
+	current *signingKey
+	next    *signingKey
 }
 
-func NewService(logger *zap.Logger, keysRepo db_keys.Repository) *Service {
+func NewService(logger *zap.Logger, keysRepo db_keys.Repository, sessionsService *service_sessions.Service) *Service {
 	return &Service{
-		logger:   logger,
-		keysRepo: db_keys.NewCache(keysRepo),
+		logger:          logger,
+		keysRepo:        db_keys.NewCache(keysRepo),
+		sessionsService: sessionsService,
 
-		signerInitOnce: &sync.Once{},
+		signingKeysGuard: &sync.Mutex{},
 	}
 }
 
-func (s *Service) initOnce(ctx context.Context) error {
-	var err error
-	s.signerInitOnce.Do(func() {
-		err = s.init(ctx)
-	})
-	return err
+// Rotate makes sure that there is a key to sign tokens with at the given time. The next key is generated and
+// published publishAhead before the current key is retired. If there is no current key, for example when the process
+// has just started, one is generated that signs tokens right away.
+func (s *Service) Rotate(ctx context.Context, now time.Time) error {
+	s.signingKeysGuard.Lock()
+	defer s.signingKeysGuard.Unlock()
+	return s.rotate(ctx, now)
 }
 
-// init prepares jwt service for work by generating a jwt signer.
-func (s *Service) init(ctx context.Context) error {
+func (s *Service) rotate(ctx context.Context, now time.Time) error {
+	if s.next != nil && !now.Before(s.next.signFrom) {
+		s.current, s.next = s.next, nil
+	}
+
+	if s.current == nil || !now.Before(s.current.signUntil) {
+		current, err := s.generate(ctx, now, now.Add(rotateEvery))
+		if err != nil {
+			return err
+		}
+		s.current, s.next = current, nil
+	}
+
+	if s.next == nil && !now.Before(s.current.signUntil.Add(-publishAhead)) {
+		next, err := s.generate(ctx, s.current.signUntil, s.current.signUntil.Add(rotateEvery))
+		if err != nil {
+			return err
+		}
+		s.next = next
+	}
+
+	return nil
+}
+
+// generate generates a key that signs tokens between signFrom and signUntil, and stores its public key.
+func (s *Service) generate(ctx context.Context, signFrom, signUntil time.Time) (*signingKey, error) {
 	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
-		return fmt.Errorf("failed to generate encryption key: %w", err)
+		return nil, fmt.Errorf("failed to generate encryption key: %w", err)
 	}
 
 	publicDER, err := x509.MarshalPKIXPublicKey(privateKey.Public())
 	if err != nil {
-		return fmt.Errorf("failed to marshal encryption key: %w", err)
+		return nil, fmt.Errorf("failed to marshal encryption key: %w", err)
 	}
 
-	key, err := keys.New(publicDER)
+	key, err := keys.New(publicDER, signUntil.Add(maxValidFor))
 	if err != nil {
-		return fmt.Errorf("failed to create a key: %w", err)
+		return nil, fmt.Errorf("failed to create a key: %w", err)
 	}
 
 	if err := s.keysRepo.Create(ctx, key); err != nil {
-		return fmt.Errorf("failed to store key in the database: %w", err)
+		return nil, fmt.Errorf("failed to store key in the database: %w", err)
 	}
 
 	options := (&jose.SignerOptions{}).
@@ -87,22 +140,88 @@ func (s *Service) init(ctx context.Context) error {
 		Key:       privateKey,
 	}, options)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	s.signer = signer
+	return &signingKey{
+		id:        key.ID,
+		signer:    signer,
+		signFrom:  signFrom,
+		signUntil: signUntil,
+	}, nil
+}
+
+func (s *Service) signer(ctx context.Context, now time.Time) (jose.Signer, error) {
+	s.signingKeysGuard.Lock()
+	defer s.signingKeysGuard.Unlock()
+	if err := s.rotate(ctx, now); err != nil {
+		return nil, err
+	}
+	return s.current.signer, nil
+}
 
+// Prune deletes the keys that have expired at the given time.
+func (s *Service) Prune(ctx context.Context, now time.Time) error {
+	deleted, err := s.keysRepo.DeleteExpired(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to delete expired keys: %w", err)
+	}
+	if deleted > 0 {
+		s.logger.Info("deleted expired keys", zap.Int64("count", deleted))
+	}
 	return nil
 }
 
+// JWKS returns the public keys that tokens can be verified with, including keys that will be used soon.
+func (s *Service) JWKS(ctx context.Context) (*jose.JSONWebKeySet, error) {
+	kk, err := s.keysRepo.ListUnexpired(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list keys: %w", err)
+	}
+
+	set := &jose.JSONWebKeySet{Keys: make([]jose.JSONWebKey, 0, len(kk))}
+	for _, key := range kk {
+		publicKey, err := parsePublicKey(key)
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, jose.JSONWebKey{
+			Key:       publicKey,
+			KeyID:     key.ID,
+			Algorithm: string(jose.ES256),
+			Use:       "sig",
+		})
+	}
+	return set, nil
+}
+
 func (s *Service) IssueToken(ctx context.Context, subject string, validFor time.Duration, tokenType jwt.TokenType) (*jwt.Token, error) {
-	if err := s.initOnce(ctx); err != nil {
-		return nil, err
+	return s.issue(ctx, uuid.New().String(), subject, validFor, tokenType)
+}
+
+// RenewToken issues a new token with the same ID, subject and type as the given token. Renewed auth tokens belong to
+// the same session as the token they renew.
+func (s *Service) RenewToken(ctx context.Context, token *jwt.Token, validFor time.Duration) (*jwt.Token, error) {
+	id := token.ID
+	if id == "" {
+		id = uuid.New().String()
+	}
+	return s.issue(ctx, id, token.Subject, validFor, token.Type)
+}
+
+func (s *Service) issue(ctx context.Context, id, subject string, validFor time.Duration, tokenType jwt.TokenType) (*jwt.Token, error) {
+	if validFor > maxValidFor {
+		return nil, ErrValidForTooLong
 	}
 
 	now := time.Now()
+	signer, err := s.signer(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+
 	stdClaims := &jose_jwt.Claims{
-		ID:       uuid.New().String(),
+		ID:       id,
 		Issuer:   defaultIssuer,
 		Subject:  subject,
 		IssuedAt: jose_jwt.NewNumericDate(now),
@@ -112,14 +231,22 @@ func (s *Service) IssueToken(ctx context.Context, subject string, validFor time.
 		Type: tokenType,
 	}
 
-	token, err := jose_jwt.Signed(s.signer).
+	token, err := jose_jwt.Signed(signer).
 		Claims(stdClaims).
 		Claims(sturdyClaims).
 		CompactSerialize()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a signed token: %w", err)
 	}
+
+	if tokenType == jwt.TokenTypeAuth {
+		if err := s.sessionsService.Issued(ctx, stdClaims.ID, subject, stdClaims.Expiry.Time()); err != nil {
+			return nil, fmt.Errorf("failed to record session: %w", err)
+		}
+	}
+
 	return &jwt.Token{
+		ID:        stdClaims.ID,
 		Token:     token,
 		Subject:   stdClaims.Subject,
 		ExpiresAt: stdClaims.Expiry.Time(),
@@ -153,6 +280,14 @@ func (s *Service) get(ctx context.Context, id string) (*ecdsa.PublicKey, error)
 		return nil, fmt.Errorf("failed to find key '%s': %w", id, err)
 	}
 
+	if key.Expired(time.Now()) {
+		return nil, fmt.Errorf("key '%s' is expired: %w", id, sql.ErrNoRows)
+	}
+
+	return parsePublicKey(key)
+}
+
+func parsePublicKey(key *keys.Key) (*ecdsa.PublicKey, error) {
 	untypedResult, err := x509.ParsePKIXPublicKey(key.PublicDER)
 	if err != nil {
 		return nil, fmt.Errorf("unable to parse PKIX public key: %w", err)
@@ -203,7 +338,13 @@ func (s *Service) verify(ctx context.Context, rawToken string, jwtoken *jose_jwt
 		if !validType(sturdyClaims.Type, expectedTypes...) {
 			return nil, ErrInvalidToken
 		}
+		if sturdyClaims.Type == jwt.TokenTypeAuth && stdClaims.ID != "" {
+			if err := s.checkSession(ctx, stdClaims); err != nil {
+				return nil, err
+			}
+		}
 		return &jwt.Token{
+			ID:        stdClaims.ID,
 			Token:     rawToken,
 			Subject:   stdClaims.Subject,
 			ExpiresAt: stdClaims.Expiry.Time(),
@@ -215,3 +356,19 @@ func (s *Service) verify(ctx context.Context, rawToken string, jwtoken *jose_jwt
 		return nil, ErrInvalidToken
 	}
 }
+
+// checkSession returns ErrTokenRevoked if the session of the auth token is revoked, and records that it's used.
+func (s *Service) checkSession(ctx context.Context, stdClaims *jose_jwt.Claims) error {
+	revoked, err := s.sessionsService.IsRevoked(ctx, stdClaims.ID)
+	if err != nil {
+		return fmt.Errorf("failed to check if session is revoked: %w", err)
+	}
+	if revoked {
+		return ErrTokenRevoked
+	}
+
+	if err := s.sessionsService.Used(ctx, stdClaims.ID, stdClaims.Subject, stdClaims.Expiry.Time()); err != nil {
+		s.logger.Error("failed to record session use", zap.Error(err))
+	}
+	return nil
+}
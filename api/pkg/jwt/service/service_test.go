@@ -8,13 +8,17 @@ import (
 	"getsturdy.com/api/pkg/jwt"
 	db_keys "getsturdy.com/api/pkg/jwt/keys/db"
 	"getsturdy.com/api/pkg/jwt/service"
+	db_sessions "getsturdy.com/api/pkg/sessions/db"
+	service_sessions "getsturdy.com/api/pkg/sessions/service"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	jose_jwt "gopkg.in/square/go-jose.v2/jwt"
 )
 
 func TestVerify_shouldVerifyIssuedKey(t *testing.T) {
-	svc := service.NewService(zap.NewNop(), db_keys.NewInMemory())
+	svc := service.NewService(zap.NewNop(), db_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
 
 	token, err := svc.IssueToken(context.Background(), "user-id", time.Hour, jwt.TokenTypeAuth)
 	assert.NoError(t, err)
@@ -24,3 +28,118 @@ func TestVerify_shouldVerifyIssuedKey(t *testing.T) {
 		assert.Equal(t, token, verifiedToken)
 	}
 }
+
+func TestVerify_shouldNotVerifyRevokedSession(t *testing.T) {
+	ctx := context.Background()
+	sessionsService := service_sessions.New(db_sessions.NewMemory())
+	svc := service.NewService(zap.NewNop(), db_keys.NewInMemory(), sessionsService)
+
+	token, err := svc.IssueToken(ctx, "user-id", time.Hour, jwt.TokenTypeAuth)
+	assert.NoError(t, err)
+
+	session, err := sessionsService.Get(ctx, token.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "user-id", session.UserID)
+	}
+
+	renewed, err := svc.RenewToken(ctx, token, time.Hour)
+	if assert.NoError(t, err) {
+		assert.Equal(t, token.ID, renewed.ID)
+	}
+
+	assert.NoError(t, sessionsService.Revoke(ctx, session))
+
+	_, err = svc.Verify(ctx, token.Token, jwt.TokenTypeAuth)
+	assert.ErrorIs(t, err, service.ErrTokenRevoked)
+
+	_, err = svc.Verify(ctx, renewed.Token, jwt.TokenTypeAuth)
+	assert.ErrorIs(t, err, service.ErrInvalidToken)
+}
+
+func TestIssueToken_shouldNotIssueLongLivedTokens(t *testing.T) {
+	svc := service.NewService(zap.NewNop(), db_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
+
+	_, err := svc.IssueToken(context.Background(), "user-id", 365*24*time.Hour, jwt.TokenTypeAuth)
+	assert.ErrorIs(t, err, service.ErrValidForTooLong)
+}
+
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	keysRepo := db_keys.NewInMemory()
+	svc := service.NewService(zap.NewNop(), keysRepo, service_sessions.New(db_sessions.NewMemory()))
+
+	now := time.Now()
+	assert.NoError(t, svc.Rotate(ctx, now))
+
+	first, err := svc.IssueToken(ctx, "change-id", time.Hour, jwt.TokenTypeCI)
+	require.NoError(t, err)
+	assert.Len(t, jwks(t, svc), 1)
+
+	// the next key is published before it's used
+	assert.NoError(t, svc.Rotate(ctx, now.Add(23*time.Hour+30*time.Minute)))
+	assert.Len(t, jwks(t, svc), 2)
+	second, err := svc.IssueToken(ctx, "change-id", time.Hour, jwt.TokenTypeCI)
+	require.NoError(t, err)
+	assert.Equal(t, keyID(t, first.Token), keyID(t, second.Token))
+
+	// and replaces the current key when it's retired, tokens signed with the old key are still valid
+	assert.NoError(t, svc.Rotate(ctx, now.Add(24*time.Hour)))
+	third, err := svc.IssueToken(ctx, "change-id", time.Hour, jwt.TokenTypeCI)
+	require.NoError(t, err)
+	assert.NotEqual(t, keyID(t, first.Token), keyID(t, third.Token))
+	for _, token := range []*jwt.Token{first, third} {
+		_, err := svc.Verify(ctx, token.Token, jwt.TokenTypeCI)
+		assert.NoError(t, err)
+	}
+
+	// the old key is deleted when all of its tokens have expired
+	assert.NoError(t, svc.Prune(ctx, now.Add(48*time.Hour)))
+	assert.Len(t, jwks(t, svc), 2)
+	assert.NoError(t, svc.Prune(ctx, now.Add(24*time.Hour+31*24*time.Hour)))
+	keys := jwks(t, svc)
+	if assert.Len(t, keys, 1) {
+		assert.Equal(t, keyID(t, third.Token), keys[0])
+	}
+	_, err = svc.Verify(ctx, first.Token, jwt.TokenTypeCI)
+	assert.ErrorIs(t, err, service.ErrInvalidToken)
+}
+
+func TestJWKS_shouldVerifyTokensOffline(t *testing.T) {
+	ctx := context.Background()
+	svc := service.NewService(zap.NewNop(), db_keys.NewInMemory(), service_sessions.New(db_sessions.NewMemory()))
+
+	token, err := svc.IssueToken(ctx, "change-id", time.Hour, jwt.TokenTypeCI)
+	require.NoError(t, err)
+
+	set, err := svc.JWKS(ctx)
+	require.NoError(t, err)
+
+	parsed, err := jose_jwt.ParseSigned(token.Token)
+	require.NoError(t, err)
+	keys := set.Key(parsed.Headers[0].KeyID)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "ES256", keys[0].Algorithm)
+	assert.Equal(t, "sig", keys[0].Use)
+
+	var claims jose_jwt.Claims
+	require.NoError(t, parsed.Claims(keys[0].Key, &claims))
+	assert.Equal(t, "change-id", claims.Subject)
+	assert.NoError(t, claims.Validate(jose_jwt.Expected{Issuer: "https://getsturdy.com", Time: time.Now()}))
+}
+
+// jwks returns the IDs of the published keys.
+func jwks(t *testing.T, svc *service.Service) []string {
+	set, err := svc.JWKS(context.Background())
+	require.NoError(t, err)
+	ids := make([]string, 0, len(set.Keys))
+	for _, key := range set.Keys {
+		ids = append(ids, key.KeyID)
+	}
+	return ids
+}
+
+func keyID(t *testing.T, token string) string {
+	parsed, err := jose_jwt.ParseSigned(token)
+	require.NoError(t, err)
+	return parsed.Headers[0].KeyID
+}
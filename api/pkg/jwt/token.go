@@ -10,11 +10,18 @@ const (
 	TokenTypeVerifyEmail TokenType = "verify_email"
 	// TokenTypeAuth is the token type for authenticating users. it must have user_id as a subject.
 	TokenTypeAuth TokenType = "auth"
-	// TokenTypeCI is the token type for CI authentication. It must have change_id as a subject.
+	// TokenTypeCI is the token type for CI authentication. It must have change_id as a subject. CI jobs can verify
+	// them offline with the keys published at /.well-known/jwks.json.
 	TokenTypeCI TokenType = "ci"
+	// TokenTypeTwoFactor is the token type for users that have signed in with a password, but not yet with their
+	// second factor. It must have user_id as a subject.
+	TokenTypeTwoFactor TokenType = "two_factor"
 )
 
 type Token struct {
+	// ID is the ID of the token. Renewed tokens keep the ID of the token they renew, auth tokens use it as the ID
+	// of their session.
+	ID        string
 	Token     string
 	Type      TokenType
 	Subject   string
@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	service_jwt "getsturdy.com/api/pkg/jwt/service"
+
+	"go.uber.org/zap"
+)
+
+var (
+	scheduleEvery = 10 * time.Minute
+)
+
+// Scheduler periodically rotates the keys that tokens are signed with, and deletes keys that have expired.
+type Scheduler struct {
+	logger  *zap.Logger
+	service *service_jwt.Service
+}
+
+func NewScheduler(
+	logger *zap.Logger,
+	service *service_jwt.Service,
+) *Scheduler {
+	return &Scheduler{
+		logger:  logger.Named("jwtKeysScheduler"),
+		service: service,
+	}
+}
+
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.logger.Info("starting")
+
+	s.run(ctx)
+
+	ticker := time.NewTicker(scheduleEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.run(ctx)
+		case <-ctx.Done():
+			s.logger.Info("stopping")
+			return nil
+		}
+	}
+}
+
+func (s *Scheduler) run(ctx context.Context) {
+	now := time.Now()
+	if err := s.service.Rotate(ctx, now); err != nil {
+		s.logger.Error("failed to rotate keys", zap.Error(err))
+	}
+	if err := s.service.Prune(ctx, now); err != nil {
+		s.logger.Error("failed to prune keys", zap.Error(err))
+	}
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"getsturdy.com/api/pkg/organization"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+type Configuration struct {
+	URL                string `long:"url" description:"URL of the LDAP server, ldap:// or ldaps://, LDAP authentication is disabled if empty" env:"URL"`
+	StartTLS           bool   `long:"start-tls" description:"Upgrade ldap:// connections to TLS with StartTLS" env:"START_TLS"`
+	CAFile             string `long:"ca-file" description:"Path to PEM encoded certificates to verify the server with, instead of the system roots" env:"CA_FILE"`
+	InsecureSkipVerify bool   `long:"insecure-skip-verify" description:"Don't verify the certificate of the server" env:"INSECURE_SKIP_VERIFY"`
+
+	BindDN       string `long:"bind-dn" description:"DN of the service account that searches for users and groups" env:"BIND_DN"`
+	BindPassword string `long:"bind-password" description:"Password of the service account" env:"BIND_PASSWORD"`
+
+	UserDNTemplate string `long:"user-dn-template" description:"DN of users with %s in place of the username, to bind as users directly instead of searching for them first, for example uid=%s,ou=people,dc=example,dc=com" env:"USER_DN_TEMPLATE"`
+	UserBaseDN     string `long:"user-base-dn" description:"DN to search for users in" env:"USER_BASE_DN"`
+	UserFilter     string `long:"user-filter" description:"Filter to search for users with, with %s in place of the username" default:"(&(objectClass=person)(uid=%s))" env:"USER_FILTER"`
+	EmailAttribute string `long:"email-attribute" description:"Attribute with the email address of users" default:"mail" env:"EMAIL_ATTRIBUTE"`
+	NameAttribute  string `long:"name-attribute" description:"Attribute with the full name of users" default:"cn" env:"NAME_ATTRIBUTE"`
+
+	GroupBaseDN          string `long:"group-base-dn" description:"DN to search for groups in, groups are not synced if empty" env:"GROUP_BASE_DN"`
+	GroupFilter          string `long:"group-filter" description:"Filter to search for groups with" default:"(|(objectClass=groupOfNames)(objectClass=groupOfUniqueNames)(objectClass=group))" env:"GROUP_FILTER"`
+	GroupNameAttribute   string `long:"group-name-attribute" description:"Attribute with the name of groups, groups can be referred to from ACLs as groups::<name>" default:"cn" env:"GROUP_NAME_ATTRIBUTE"`
+	GroupMemberAttribute string `long:"group-member-attribute" description:"Attribute with the DNs of the members of groups" default:"member" env:"GROUP_MEMBER_ATTRIBUTE"`
+
+	OrganizationID string        `long:"organization-id" description:"ID of the organization that users and groups are synced to" env:"ORGANIZATION_ID"`
+	MemberGroups   []string      `long:"member-group" description:"Name of a group whose members are members of the organization, all users can sign in and are members if no member or admin groups are set" env:"MEMBER_GROUPS" env-delim:","`
+	AdminGroups    []string      `long:"admin-group" description:"Name of a group whose members are admins of the organization" env:"ADMIN_GROUPS" env-delim:","`
+	SyncInterval   time.Duration `long:"sync-interval" description:"How often users and groups are synced" default:"15m" env:"SYNC_INTERVAL"`
+}
+
+// Enabled returns true if LDAP authentication is configured.
+func (c *Configuration) Enabled() bool {
+	return c != nil && c.URL != ""
+}
+
+// SearchesUsers returns true if users are found by searching for them, rather than by binding as them directly.
+func (c *Configuration) SearchesUsers() bool {
+	return c.UserDNTemplate == ""
+}
+
+// SyncsGroups returns true if groups are synced to the organization.
+func (c *Configuration) SyncsGroups() bool {
+	return c.OrganizationID != "" && c.GroupBaseDN != ""
+}
+
+// RestrictsMembership returns true if only the members of the member and admin groups are members of the organization.
+func (c *Configuration) RestrictsMembership() bool {
+	return len(c.MemberGroups) > 0 || len(c.AdminGroups) > 0
+}
+
+// UserDN returns the DN to bind as for the username, when binding as users directly.
+func (c *Configuration) UserDN(username string) string {
+	return strings.ReplaceAll(c.UserDNTemplate, "%s", escapeDN(username))
+}
+
+// UserSearchFilter returns the filter to search for the user with the username.
+func (c *Configuration) UserSearchFilter(username string) (string, error) {
+	filter := strings.ReplaceAll(c.UserFilter, "%s", ldap.EscapeFilter(username))
+	if _, err := ldap.CompileFilter(filter); err != nil {
+		return "", err
+	}
+	return filter, nil
+}
+
+// escapeDN escapes the characters of s that have a special meaning in distinguished names, so that it can be used
+// as an attribute value, as in RFC 4514. The version of go-ldap that is used only escapes filters.
+func escapeDN(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ',' || c == '+' || c == '"' || c == '\\' || c == '<' || c == '>' || c == ';' || c == '=':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == 0:
+			b.WriteString("\\00")
+		case (c == ' ' || c == '#') && i == 0, c == ' ' && i == len(s)-1:
+			fmt.Fprintf(&b, "\\%02x", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// UsersBaseDN returns the DN that all users are below.
+func (c *Configuration) UsersBaseDN() string {
+	if c.UserBaseDN != "" || c.SearchesUsers() {
+		return c.UserBaseDN
+	}
+	// the parent of the template, for example ou=people,dc=example,dc=com for uid=%s,ou=people,dc=example,dc=com
+	if i := strings.Index(c.UserDNTemplate, ","); i >= 0 {
+		return c.UserDNTemplate[i+1:]
+	}
+	return ""
+}
+
+// Role returns the role in the organization of members of the groups, and false if they should not be members.
+func (c *Configuration) Role(groupNames []string) (organization.Role, bool) {
+	if !c.RestrictsMembership() {
+		return organization.RoleMember, true
+	}
+	if containsAny(c.AdminGroups, groupNames) {
+		return organization.RoleAdmin, true
+	}
+	if containsAny(c.MemberGroups, groupNames) {
+		return organization.RoleMember, true
+	}
+	return "", false
+}
+
+func containsAny(configured, names []string) bool {
+	for _, c := range configured {
+		for _, name := range names {
+			if strings.EqualFold(strings.TrimSpace(c), name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TLSConfig returns the configuration to verify the server with.
+func (c *Configuration) TLSConfig() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		//nolint:gosec // opt-in, for servers with self-signed certificates
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.CAFile == "" {
+		return cfg, nil
+	}
+
+	pem, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ca file: %w", err)
+	}
+	cfg.RootCAs = x509.NewCertPool()
+	if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in the ca file")
+	}
+	return cfg, nil
+}
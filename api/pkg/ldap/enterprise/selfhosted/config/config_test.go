@@ -0,0 +1,41 @@
+package config
+
+import (
+	"testing"
+
+	"getsturdy.com/api/pkg/organization"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRole(t *testing.T) {
+	cases := []struct {
+		name         string
+		memberGroups []string
+		adminGroups  []string
+		groups       []string
+		expectedRole organization.Role
+		expectedOK   bool
+	}{
+		{name: "no-restriction", expectedRole: organization.RoleMember, expectedOK: true},
+		{name: "member", memberGroups: []string{"developers"}, groups: []string{"Developers"}, expectedRole: organization.RoleMember, expectedOK: true},
+		{name: "admin", memberGroups: []string{"developers"}, adminGroups: []string{"admins"}, groups: []string{"developers", "admins"}, expectedRole: organization.RoleAdmin, expectedOK: true},
+		{name: "not-member", memberGroups: []string{"developers"}, groups: []string{"sales"}, expectedOK: false},
+		{name: "no-groups", adminGroups: []string{"admins"}, expectedOK: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Configuration{MemberGroups: tc.memberGroups, AdminGroups: tc.adminGroups}
+			role, ok := cfg.Role(tc.groups)
+			assert.Equal(t, tc.expectedOK, ok)
+			assert.Equal(t, tc.expectedRole, role)
+		})
+	}
+}
+
+func TestUserDN(t *testing.T) {
+	cfg := &Configuration{UserDNTemplate: "uid=%s,ou=people,dc=example,dc=com"}
+	assert.Equal(t, "uid=john\\,ou\\=admins,ou=people,dc=example,dc=com", cfg.UserDN("john,ou=admins"))
+	assert.Equal(t, "ou=people,dc=example,dc=com", cfg.UsersBaseDN())
+}
@@ -0,0 +1,558 @@
+// Package ldaptest provides an in-process LDAP server for tests, like net/http/httptest does for HTTP.
+package ldaptest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	startTLSOID = "1.3.6.1.4.1.1466.20037"
+
+	// passwordAttribute is the attribute with the password of entries that can be bound as
+	passwordAttribute = "userpassword"
+)
+
+// Server is a directory that serves its entries over LDAP, with the message encoding of go-ldap. It supports simple
+// binds, searches (with paging) and StartTLS. Searches are only allowed after a successful bind.
+//
+// Values are compared case-insensitively, and objectClass values are not expanded to their superclasses.
+type Server struct {
+	// URL is ldap://127.0.0.1:<port>, or ldaps:// for servers started with NewTLSServer.
+	URL string
+
+	listener    net.Listener
+	tlsConfig   *tls.Config
+	certificate *x509.Certificate
+
+	mu      sync.Mutex
+	entries []*ldap.Entry
+	binds   int
+
+	wg     sync.WaitGroup
+	closed chan struct{}
+}
+
+// NewServer starts a server that accepts plain connections, that can be upgraded with StartTLS. The server is
+// closed when the test ends.
+func NewServer(t *testing.T) *Server {
+	return newServer(t, false)
+}
+
+// NewTLSServer starts a server that only accepts TLS connections. The server is closed when the test ends.
+func NewTLSServer(t *testing.T) *Server {
+	return newServer(t, true)
+}
+
+func newServer(t *testing.T, useTLS bool) *Server {
+	certificate, tlsCertificate, err := generateCertificate()
+	if err != nil {
+		t.Fatalf("ldaptest: failed to generate certificate: %v", err)
+	}
+
+	s := &Server{
+		tlsConfig:   &tls.Config{Certificates: []tls.Certificate{tlsCertificate}, MinVersion: tls.VersionTLS12},
+		certificate: certificate,
+		closed:      make(chan struct{}),
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ldaptest: failed to listen: %v", err)
+	}
+
+	if useTLS {
+		s.listener = tls.NewListener(listener, s.tlsConfig)
+		s.URL = "ldaps://" + listener.Addr().String()
+	} else {
+		s.listener = listener
+		s.URL = "ldap://" + listener.Addr().String()
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// CAFile writes the self-signed certificate of the server to a PEM file in a temporary directory of the test, and
+// returns its path.
+func (s *Server) CAFile(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.certificate.Raw}), 0o600); err != nil {
+		t.Fatalf("ldaptest: failed to write ca file: %v", err)
+	}
+	return path
+}
+
+// AddEntry adds an entry to the directory. Entries with a userPassword attribute can be bound as.
+func (s *Server) AddEntry(dn string, attributes map[string][]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, ldap.NewEntry(dn, attributes))
+}
+
+// RemoveEntry removes the entry with the DN from the directory.
+func (s *Server) RemoveEntry(dn string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := s.entries[:0]
+	for _, e := range s.entries {
+		if !equalDN(e.DN, dn) {
+			entries = append(entries, e)
+		}
+	}
+	s.entries = entries
+}
+
+// SetAttribute replaces the values of an attribute of the entry with the DN.
+func (s *Server) SetAttribute(dn, name string, values ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, e := range s.entries {
+		if !equalDN(e.DN, dn) {
+			continue
+		}
+		attributes := make(map[string][]string, len(e.Attributes))
+		for _, a := range e.Attributes {
+			if !strings.EqualFold(a.Name, name) {
+				attributes[a.Name] = a.Values
+			}
+		}
+		attributes[name] = values
+		s.entries[i] = ldap.NewEntry(e.DN, attributes)
+	}
+}
+
+// Binds returns the number of successful binds.
+func (s *Server) Binds() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.binds
+}
+
+func (s *Server) Close() {
+	select {
+	case <-s.closed:
+		return
+	default:
+	}
+	close(s.closed)
+	s.listener.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+		}()
+	}
+}
+
+type session struct {
+	conn  net.Conn
+	bound bool
+}
+
+func (s *Server) handle(conn net.Conn) {
+	sess := &session{conn: conn}
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		sess.conn.Close()
+	}()
+
+	// close the connection when the server is closed, to stop the blocking read
+	go func() {
+		select {
+		case <-s.closed:
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		packet, err := ber.ReadPacket(sess.conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		id, ok := packet.Children[0].Value.(int64)
+		if !ok {
+			return
+		}
+		op := packet.Children[1]
+		var controls []*ber.Packet
+		if len(packet.Children) > 2 {
+			controls = packet.Children[2].Children
+		}
+
+		switch op.Tag {
+		case ldap.ApplicationBindRequest:
+			code, message := s.bind(op)
+			sess.bound = code == ldap.LDAPResultSuccess
+			if err := write(sess.conn, id, result(ldap.ApplicationBindResponse, code, message)); err != nil {
+				return
+			}
+		case ldap.ApplicationSearchRequest:
+			if !sess.bound {
+				if err := write(sess.conn, id, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultInsufficientAccessRights, "bind first")); err != nil {
+					return
+				}
+				continue
+			}
+			if err := s.search(sess.conn, id, op, controls); err != nil {
+				return
+			}
+		case ldap.ApplicationExtendedRequest:
+			if len(op.Children) == 0 || op.Children[0].Data.String() != startTLSOID {
+				if err := write(sess.conn, id, result(ldap.ApplicationExtendedResponse, ldap.LDAPResultProtocolError, "unsupported extended operation")); err != nil {
+					return
+				}
+				continue
+			}
+			if _, isTLS := sess.conn.(*tls.Conn); isTLS {
+				if err := write(sess.conn, id, result(ldap.ApplicationExtendedResponse, ldap.LDAPResultOperationsError, "tls is already started")); err != nil {
+					return
+				}
+				continue
+			}
+			if err := write(sess.conn, id, result(ldap.ApplicationExtendedResponse, ldap.LDAPResultSuccess, "")); err != nil {
+				return
+			}
+			tlsConn := tls.Server(sess.conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			sess.conn = tlsConn
+		default:
+			// unbind, or an unsupported operation
+			return
+		}
+	}
+}
+
+func (s *Server) bind(op *ber.Packet) (uint16, string) {
+	if len(op.Children) < 3 {
+		return ldap.LDAPResultProtocolError, "invalid bind request"
+	}
+	name, _ := op.Children[1].Value.(string)
+	password := op.Children[2].Data.String()
+	if op.Children[2].Tag != 0 {
+		return ldap.LDAPResultAuthMethodNotSupported, "only simple binds are supported"
+	}
+	if password == "" {
+		// like most directories that require authentication
+		return ldap.LDAPResultUnwillingToPerform, "unauthenticated binds are not allowed"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if equalDN(e.DN, name) && contains(e.GetEqualFoldAttributeValues(passwordAttribute), password, false) {
+			s.binds++
+			return ldap.LDAPResultSuccess, ""
+		}
+	}
+	return ldap.LDAPResultInvalidCredentials, ""
+}
+
+// search writes the entries that match the search request, and the result.
+func (s *Server) search(conn net.Conn, id int64, op *ber.Packet, controls []*ber.Packet) error {
+	if len(op.Children) < 8 {
+		return write(conn, id, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultProtocolError, "invalid search request"))
+	}
+	base, _ := op.Children[0].Value.(string)
+	scope, _ := op.Children[1].Value.(int64)
+	sizeLimit, _ := op.Children[3].Value.(int64)
+	filter := op.Children[6]
+	var attributes []string
+	for _, a := range op.Children[7].Children {
+		if name, ok := a.Value.(string); ok {
+			attributes = append(attributes, name)
+		}
+	}
+
+	var paging *ldap.ControlPaging
+	for _, c := range controls {
+		if control, err := ldap.DecodeControl(c); err == nil {
+			if p, ok := control.(*ldap.ControlPaging); ok {
+				paging = p
+			}
+		}
+	}
+
+	s.mu.Lock()
+	var found bool
+	var matches []*ldap.Entry
+	for _, e := range s.entries {
+		if equalDN(e.DN, base) {
+			found = true
+		}
+		if inScope(e.DN, base, int(scope)) && match(filter, e) {
+			matches = append(matches, e)
+		}
+	}
+	s.mu.Unlock()
+
+	if !found && base != "" {
+		return write(conn, id, result(ldap.ApplicationSearchResultDone, ldap.LDAPResultNoSuchObject, ""))
+	}
+
+	code := uint16(ldap.LDAPResultSuccess)
+	if sizeLimit > 0 && len(matches) > int(sizeLimit) {
+		matches = matches[:sizeLimit]
+		code = ldap.LDAPResultSizeLimitExceeded
+	}
+
+	// pages are continued from the offset in the cookie, and a page size of 0 abandons the search
+	var responseControls []ldap.Control
+	if paging != nil {
+		offset, _ := strconv.Atoi(string(paging.Cookie))
+		if offset > len(matches) || paging.PagingSize == 0 {
+			offset = len(matches)
+		}
+		end := len(matches)
+		if paging.PagingSize > 0 && offset+int(paging.PagingSize) < end {
+			end = offset + int(paging.PagingSize)
+		}
+		total := len(matches)
+		matches = matches[offset:end]
+
+		next := ldap.NewControlPaging(0)
+		if end < total {
+			next.SetCookie([]byte(strconv.Itoa(end)))
+		}
+		responseControls = append(responseControls, next)
+	}
+
+	for _, e := range matches {
+		if err := write(conn, id, searchResultEntry(e, attributes)); err != nil {
+			return err
+		}
+	}
+	return write(conn, id, result(ldap.ApplicationSearchResultDone, code, ""), responseControls...)
+}
+
+func write(conn net.Conn, id int64, op *ber.Packet, controls ...ldap.Control) error {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "MessageID"))
+	packet.AppendChild(op)
+	if len(controls) > 0 {
+		packet.AppendChild(encodeControls(controls))
+	}
+	_, err := conn.Write(packet.Bytes())
+	return err
+}
+
+func encodeControls(controls []ldap.Control) *ber.Packet {
+	packet := ber.Encode(ber.ClassContext, ber.TypeConstructed, 0, nil, "Controls")
+	for _, control := range controls {
+		packet.AppendChild(control.Encode())
+	}
+	return packet
+}
+
+func result(tag ber.Tag, code uint16, message string) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "resultCode"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, message, "diagnosticMessage"))
+	return packet
+}
+
+func searchResultEntry(e *ldap.Entry, attributes []string) *ber.Packet {
+	packet := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "Search Result Entry")
+	packet.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, e.DN, "objectName"))
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for _, a := range e.Attributes {
+		if strings.EqualFold(a.Name, passwordAttribute) || !selected(a.Name, attributes) {
+			continue
+		}
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, a.Name, "type"))
+		values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, v := range a.Values {
+			values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, "value"))
+		}
+		attribute.AppendChild(values)
+		list.AppendChild(attribute)
+	}
+	packet.AppendChild(list)
+	return packet
+}
+
+// selected returns true if the attribute is one of the requested attributes. All attributes are returned if none
+// are requested, or if * is requested, and none are returned if only 1.1 is requested.
+func selected(name string, attributes []string) bool {
+	if len(attributes) == 0 {
+		return true
+	}
+	for _, a := range attributes {
+		if a == "*" || strings.EqualFold(a, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// match returns true if the entry matches the filter. Only the and, or, not, equality, substrings and present
+// filters are supported.
+func match(filter *ber.Packet, e *ldap.Entry) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !match(child, e) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if match(child, e) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return len(filter.Children) == 1 && !match(filter.Children[0], e)
+	case ldap.FilterEqualityMatch:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		return contains(e.GetEqualFoldAttributeValues(filter.Children[0].Data.String()), filter.Children[1].Data.String(), true)
+	case ldap.FilterPresent:
+		return len(e.GetEqualFoldAttributeValues(filter.Data.String())) > 0
+	case ldap.FilterSubstrings:
+		if len(filter.Children) != 2 {
+			return false
+		}
+		for _, v := range e.GetEqualFoldAttributeValues(filter.Children[0].Data.String()) {
+			if matchSubstrings(strings.ToLower(v), filter.Children[1].Children) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+func matchSubstrings(value string, parts []*ber.Packet) bool {
+	for _, part := range parts {
+		s := strings.ToLower(part.Data.String())
+		switch part.Tag {
+		case ldap.FilterSubstringsInitial:
+			if !strings.HasPrefix(value, s) {
+				return false
+			}
+			value = value[len(s):]
+		case ldap.FilterSubstringsAny:
+			i := strings.Index(value, s)
+			if i < 0 {
+				return false
+			}
+			value = value[i+len(s):]
+		case ldap.FilterSubstringsFinal:
+			if !strings.HasSuffix(value, s) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func contains(values []string, value string, ignoreCase bool) bool {
+	for _, v := range values {
+		if v == value || ignoreCase && strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalDN(a, b string) bool {
+	parsedA, errA := ldap.ParseDN(a)
+	parsedB, errB := ldap.ParseDN(b)
+	if errA != nil || errB != nil {
+		return strings.EqualFold(a, b)
+	}
+	return parsedA.EqualFold(parsedB)
+}
+
+func inScope(dn, base string, scope int) bool {
+	parsedDN, err := ldap.ParseDN(dn)
+	if err != nil {
+		return false
+	}
+	parsedBase, err := ldap.ParseDN(base)
+	if err != nil {
+		return false
+	}
+
+	switch scope {
+	case ldap.ScopeBaseObject:
+		return parsedBase.EqualFold(parsedDN)
+	case ldap.ScopeSingleLevel:
+		return parsedBase.AncestorOfFold(parsedDN) && len(parsedDN.RDNs) == len(parsedBase.RDNs)+1
+	default:
+		return parsedBase.EqualFold(parsedDN) || parsedBase.AncestorOfFold(parsedDN)
+	}
+}
+
+func generateCertificate() (*x509.Certificate, tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ldaptest"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		IsCA:         true,
+
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, tls.Certificate{}, fmt.Errorf("failed to create certificate: %w", err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, tls.Certificate{}, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+
+	return certificate, tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: certificate}, nil
+}
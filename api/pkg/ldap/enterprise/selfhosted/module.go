@@ -0,0 +1,12 @@
+package selfhosted
+
+import (
+	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/ldap/enterprise/selfhosted/service"
+	"getsturdy.com/api/pkg/ldap/enterprise/selfhosted/worker"
+)
+
+func Module(c *di.Container) {
+	c.Import(service.Module)
+	c.Import(worker.Module)
+}
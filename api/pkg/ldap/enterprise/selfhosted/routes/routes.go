@@ -0,0 +1,106 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"getsturdy.com/api/pkg/analytics"
+	service_analytics "getsturdy.com/api/pkg/analytics/service"
+	"getsturdy.com/api/pkg/auth"
+	"getsturdy.com/api/pkg/jwt"
+	service_jwt "getsturdy.com/api/pkg/jwt/service"
+	service_ldap "getsturdy.com/api/pkg/ldap/enterprise/selfhosted/service"
+	service_twofactor "getsturdy.com/api/pkg/twofactor/service"
+	service_user "getsturdy.com/api/pkg/users/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// twoFactorTokenValidFor is how long users have to enter their second factor after signing in with LDAP.
+const twoFactorTokenValidFor = 5 * time.Minute
+
+// Login signs in the user with their directory username and password. Users with two-factor authentication get a
+// token to complete the sign in with at /v3/auth/two-factor, like password logins.
+func Login(
+	logger *zap.Logger,
+	ldapService *service_ldap.Service,
+	analyticsService *service_analytics.Service,
+	jwtService *service_jwt.Service,
+	twoFactorService *service_twofactor.Service,
+) func(*gin.Context) {
+	type request struct {
+		Username string `json:"username" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		if !ldapService.Enabled() {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Warn("failed to bind input", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Something went wrong, please check the input and try again"})
+			return
+		}
+
+		ctx := c.Request.Context()
+
+		usr, err := ldapService.SignIn(ctx, strings.TrimSpace(req.Username), req.Password)
+		switch {
+		case err == nil:
+		case errors.Is(err, service_ldap.ErrInvalidCredentials):
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid username or password, please check the input and try again"})
+			return
+		case errors.Is(err, service_ldap.ErrNotAllowed):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "You are not a member of any of the groups that are allowed to sign in"})
+			return
+		case errors.Is(err, service_ldap.ErrNoEmail):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Your account has no email address in the directory"})
+			return
+		case errors.Is(err, service_user.ErrExceeded):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Maximum number of users exceeded"})
+			return
+		default:
+			logger.Error("failed to sign in", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		twoFactorEnabled, err := twoFactorService.IsEnabled(ctx, usr.ID)
+		if err != nil {
+			logger.Error("failed to check two-factor authentication", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		if twoFactorEnabled {
+			token, err := jwtService.IssueToken(ctx, usr.ID, twoFactorTokenValidFor, jwt.TokenTypeTwoFactor)
+			if err != nil {
+				logger.Error("failed to issue two-factor token", zap.Error(err))
+				c.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"two_factor_required": true,
+				"two_factor_token":    token.Token,
+			})
+			return
+		}
+
+		if err := auth.SetAuthCookieForUser(c, usr.ID, jwtService); err != nil {
+			logger.Error("failed to set auth cookie", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		analyticsService.IdentifyUser(ctx, usr)
+		analyticsService.Capture(ctx, "logged in", analytics.Property("type", "ldap"))
+
+		c.JSON(http.StatusOK, usr)
+	}
+}
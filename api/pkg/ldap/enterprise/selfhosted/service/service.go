@@ -0,0 +1,577 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"getsturdy.com/api/pkg/ldap/enterprise/selfhosted/config"
+	"getsturdy.com/api/pkg/organization"
+	service_organization "getsturdy.com/api/pkg/organization/service"
+	"getsturdy.com/api/pkg/scim"
+	db_scim "getsturdy.com/api/pkg/scim/db"
+	"getsturdy.com/api/pkg/users"
+	service_user "getsturdy.com/api/pkg/users/service"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrDisabled           = errors.New("ldap is not configured")
+	ErrMisconfigured      = errors.New("ldap is misconfigured")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrNoEmail            = errors.New("user has no email address in the directory")
+	ErrNotAllowed         = errors.New("user is not a member of any of the allowed groups")
+)
+
+const (
+	timeout  = 10 * time.Second
+	pageSize = 500
+)
+
+type Service struct {
+	logger *zap.Logger
+	cfg    *config.Configuration
+
+	scimUserRepo  db_scim.UserRepository
+	scimGroupRepo db_scim.GroupRepository
+
+	userService         service_user.Service
+	organizationService *service_organization.Service
+}
+
+func New(
+	logger *zap.Logger,
+	cfg *config.Configuration,
+	scimUserRepo db_scim.UserRepository,
+	scimGroupRepo db_scim.GroupRepository,
+	userService service_user.Service,
+	organizationService *service_organization.Service,
+) *Service {
+	return &Service{
+		logger: logger.Named("ldap"),
+		cfg:    cfg,
+
+		scimUserRepo:  scimUserRepo,
+		scimGroupRepo: scimGroupRepo,
+
+		userService:         userService,
+		organizationService: organizationService,
+	}
+}
+
+func (s *Service) Enabled() bool {
+	return s.cfg.Enabled()
+}
+
+// SyncInterval returns how often Sync should run, or zero if groups are not synced.
+func (s *Service) SyncInterval() time.Duration {
+	if !s.Enabled() || !s.cfg.SyncsGroups() {
+		return 0
+	}
+	return s.cfg.SyncInterval
+}
+
+// SignIn authenticates the user against the directory, and returns the Sturdy user with the same email. Users that
+// don't have an account yet are created, and all users are added to the configured organization with the role
+// of their groups.
+func (s *Service) SignIn(ctx context.Context, username, password string) (*users.User, error) {
+	if !s.Enabled() {
+		return nil, ErrDisabled
+	}
+	if username == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	entry, err := s.authenticate(conn, username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	email := entry.GetEqualFoldAttributeValue(s.cfg.EmailAttribute)
+	if email == "" {
+		return nil, ErrNoEmail
+	}
+
+	role := organization.RoleMember
+	if s.cfg.RestrictsMembership() {
+		groups, err := s.searchGroups(conn)
+		if err != nil {
+			return nil, err
+		}
+		var ok bool
+		if role, ok = s.cfg.Role(groupNames(s.cfg, groups, entry.DN)); !ok {
+			return nil, ErrNotAllowed
+		}
+	}
+
+	usr, err := s.getOrCreateUser(ctx, email, entry.GetEqualFoldAttributeValue(s.cfg.NameAttribute))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.provision(ctx, usr, entry.DN, role); err != nil {
+		return nil, err
+	}
+
+	return usr, nil
+}
+
+// Sync maps the groups in the directory to groups in the organization, that can be referred to from ACLs as
+// groups::<name>. If member or admin groups are configured, their members are added to the organization, and
+// users that have been removed from them are removed from the organization. Owners are never changed.
+//
+// Groups are not expanded, only users that are direct members of groups are synced.
+func (s *Service) Sync(ctx context.Context) error {
+	if !s.Enabled() {
+		return ErrDisabled
+	}
+	if !s.cfg.SyncsGroups() {
+		return nil
+	}
+
+	conn, err := s.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := s.bindServiceAccount(conn); err != nil {
+		return err
+	}
+
+	groups, err := s.searchGroups(conn)
+	if err != nil {
+		return err
+	}
+	entries, err := s.searchUsers(conn)
+	if err != nil {
+		return err
+	}
+
+	// the Sturdy user IDs of the users in the directory, by their normalized DN
+	userIDs := make(map[string]string, len(entries))
+	// the users that are members of the organization because of their groups
+	active := make(map[string]bool)
+	for _, entry := range entries {
+		email := entry.GetEqualFoldAttributeValue(s.cfg.EmailAttribute)
+		if email == "" {
+			continue
+		}
+
+		role, ok := s.cfg.Role(groupNames(s.cfg, groups, entry.DN))
+		if !s.cfg.RestrictsMembership() || !ok {
+			// users that sign in are added to the organization, only users that already have an account are mapped
+			// to groups
+			usr, err := s.userService.GetByEmail(ctx, email)
+			switch {
+			case err == nil:
+				userIDs[normalizeDN(entry.DN)] = usr.ID
+			case errors.Is(err, sql.ErrNoRows):
+			default:
+				return fmt.Errorf("failed to get user by email: %w", err)
+			}
+			continue
+		}
+
+		usr, err := s.getOrCreateUser(ctx, email, entry.GetEqualFoldAttributeValue(s.cfg.NameAttribute))
+		if err != nil {
+			s.logger.Warn("failed to sync user", zap.String("dn", entry.DN), zap.Error(err))
+			continue
+		}
+		if err := s.provision(ctx, usr, entry.DN, role); err != nil {
+			s.logger.Warn("failed to sync user", zap.String("dn", entry.DN), zap.Error(err))
+			continue
+		}
+		userIDs[normalizeDN(entry.DN)] = usr.ID
+		active[usr.ID] = true
+	}
+
+	if err := s.syncGroups(ctx, groups, userIDs); err != nil {
+		return err
+	}
+
+	if s.cfg.RestrictsMembership() {
+		if err := s.deprovision(ctx, active); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) connect(ctx context.Context) (*ldap.Conn, error) {
+	tlsConfig, err := s.cfg.TLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrMisconfigured, err)
+	}
+	u, err := url.Parse(s.cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid url: %s", ErrMisconfigured, err)
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = u.Hostname()
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+	conn, err := ldap.DialURL(s.cfg.URL, ldap.DialWithDialer(dialer), ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ldap: %w", err)
+	}
+	conn.SetTimeout(timeout)
+
+	if s.cfg.StartTLS && u.Scheme == "ldap" {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to connect to ldap: %w", err)
+		}
+	}
+
+	return conn, nil
+}
+
+func (s *Service) bindServiceAccount(conn *ldap.Conn) error {
+	if s.cfg.BindDN == "" {
+		return fmt.Errorf("%w: the bind dn is required to search the directory", ErrMisconfigured)
+	}
+	if err := conn.Bind(s.cfg.BindDN, s.cfg.BindPassword); err != nil {
+		return fmt.Errorf("failed to bind as the service account: %w", err)
+	}
+	return nil
+}
+
+// authenticate binds as the user, and returns its entry. The connection is bound as the service account
+// afterwards, if there is one.
+func (s *Service) authenticate(conn *ldap.Conn, username, password string) (*ldap.Entry, error) {
+	var dn string
+	if s.cfg.SearchesUsers() {
+		if err := s.bindServiceAccount(conn); err != nil {
+			return nil, err
+		}
+		filter, err := s.cfg.UserSearchFilter(username)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid user filter: %s", ErrMisconfigured, err)
+		}
+		res, err := conn.Search(ldap.NewSearchRequest(
+			s.cfg.UserBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false, filter, nil, nil,
+		))
+		switch {
+		case ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded):
+			s.logger.Warn("more than one user matches the username", zap.String("username", username))
+			return nil, ErrInvalidCredentials
+		case err != nil:
+			return nil, fmt.Errorf("failed to search for user: %w", err)
+		case len(res.Entries) != 1:
+			if len(res.Entries) > 1 {
+				s.logger.Warn("more than one user matches the username", zap.String("username", username))
+			}
+			return nil, ErrInvalidCredentials
+		}
+		dn = res.Entries[0].DN
+	} else {
+		dn = s.cfg.UserDN(username)
+	}
+
+	// binds without a password are unauthenticated binds that servers let succeed, and are refused by the client
+	if err := conn.Bind(dn, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("failed to bind as user: %w", err)
+	}
+
+	if s.cfg.BindDN != "" {
+		if err := s.bindServiceAccount(conn); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := conn.Search(ldap.NewSearchRequest(
+		dn, ldap.ScopeBaseObject, ldap.NeverDerefAliases, 1, 0, false, "(objectClass=*)",
+		[]string{s.cfg.EmailAttribute, s.cfg.NameAttribute}, nil,
+	))
+	switch {
+	case err != nil:
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	case len(res.Entries) == 0:
+		return nil, fmt.Errorf("failed to get user: %q not found", dn)
+	}
+	return res.Entries[0], nil
+}
+
+func (s *Service) searchUsers(conn *ldap.Conn) ([]*ldap.Entry, error) {
+	filter := strings.ReplaceAll(s.cfg.UserFilter, "%s", "*")
+	if _, err := ldap.CompileFilter(filter); err != nil {
+		return nil, fmt.Errorf("%w: invalid user filter: %s", ErrMisconfigured, err)
+	}
+	res, err := conn.SearchWithPaging(ldap.NewSearchRequest(
+		s.cfg.UsersBaseDN(), ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, filter,
+		[]string{s.cfg.EmailAttribute, s.cfg.NameAttribute}, nil,
+	), pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for users: %w", err)
+	}
+	return res.Entries, nil
+}
+
+func (s *Service) searchGroups(conn *ldap.Conn) ([]*ldap.Entry, error) {
+	if s.cfg.GroupBaseDN == "" {
+		return nil, fmt.Errorf("%w: the group base dn is required to use groups", ErrMisconfigured)
+	}
+	if _, err := ldap.CompileFilter(s.cfg.GroupFilter); err != nil {
+		return nil, fmt.Errorf("%w: invalid group filter: %s", ErrMisconfigured, err)
+	}
+	res, err := conn.SearchWithPaging(ldap.NewSearchRequest(
+		s.cfg.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, s.cfg.GroupFilter,
+		[]string{s.cfg.GroupNameAttribute, s.cfg.GroupMemberAttribute}, nil,
+	), pageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for groups: %w", err)
+	}
+	return res.Entries, nil
+}
+
+// groupNames returns the names of the groups that the entry with the DN is a member of.
+func groupNames(cfg *config.Configuration, groups []*ldap.Entry, dn string) []string {
+	dn = normalizeDN(dn)
+	var names []string
+	for _, group := range groups {
+		for _, member := range group.GetEqualFoldAttributeValues(cfg.GroupMemberAttribute) {
+			if normalizeDN(member) == dn {
+				names = append(names, group.GetEqualFoldAttributeValue(cfg.GroupNameAttribute))
+				break
+			}
+		}
+	}
+	return names
+}
+
+// normalizeDN returns the DN in a form that can be compared with other normalized DNs, regardless of case and
+// spacing.
+func normalizeDN(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return strings.ToLower(dn)
+	}
+	return strings.ToLower(parsed.String())
+}
+
+// isDescendant returns true if dn is the same as, or below, base.
+func isDescendant(dn, base string) bool {
+	parsedDN, err := ldap.ParseDN(dn)
+	if err != nil {
+		return false
+	}
+	parsedBase, err := ldap.ParseDN(base)
+	if err != nil {
+		return false
+	}
+	return parsedBase.EqualFold(parsedDN) || parsedBase.AncestorOfFold(parsedDN)
+}
+
+func (s *Service) getOrCreateUser(ctx context.Context, email, name string) (*users.User, error) {
+	usr, err := s.userService.GetByEmail(ctx, email)
+	switch {
+	case err == nil:
+		return usr, nil
+	case errors.Is(err, sql.ErrNoRows):
+		if name == "" {
+			name = email
+		}
+		if usr, err = s.userService.CreateWithVerifiedEmail(ctx, name, email); err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+		s.logger.Info("created user from ldap", zap.String("user_id", usr.ID))
+		return usr, nil
+	default:
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+}
+
+// provision adds the user to the organization with the role. If membership is restricted to groups, the role of
+// existing members is updated, and the user is tracked so that it can be removed when it leaves the groups.
+func (s *Service) provision(ctx context.Context, usr *users.User, dn string, role organization.Role) error {
+	if s.cfg.OrganizationID == "" {
+		return nil
+	}
+
+	member, err := s.organizationService.GetMember(ctx, s.cfg.OrganizationID, usr.ID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if _, err := s.organizationService.AddMember(ctx, s.cfg.OrganizationID, usr.ID, usr.ID, role); err != nil {
+			return fmt.Errorf("failed to add member: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get member: %w", err)
+	case s.cfg.RestrictsMembership() && member.Role != role && member.Role != organization.RoleOwner:
+		if _, err := s.organizationService.SetMemberRole(ctx, s.cfg.OrganizationID, usr.ID, role); err != nil {
+			return fmt.Errorf("failed to set member role: %w", err)
+		}
+	}
+
+	if !s.cfg.RestrictsMembership() {
+		return nil
+	}
+
+	provisioned, err := s.scimUserRepo.Get(ctx, s.cfg.OrganizationID, usr.ID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		provisioned = &scim.User{
+			OrganizationID: s.cfg.OrganizationID,
+			UserID:         usr.ID,
+			CreatedAt:      time.Now(),
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get provisioned user: %w", err)
+	}
+	provisioned.ExternalID = &dn
+	provisioned.Active = true
+	provisioned.UpdatedAt = time.Now()
+	if err := s.scimUserRepo.Upsert(ctx, provisioned); err != nil {
+		return fmt.Errorf("failed to update provisioned user: %w", err)
+	}
+	return nil
+}
+
+// deprovision removes the users that were provisioned from the directory, but are no longer members of the member
+// or admin groups, from the organization.
+func (s *Service) deprovision(ctx context.Context, active map[string]bool) error {
+	base := s.cfg.UsersBaseDN()
+	if base == "" {
+		return nil
+	}
+
+	provisioned, err := s.scimUserRepo.ListByOrganizationID(ctx, s.cfg.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("failed to list provisioned users: %w", err)
+	}
+
+	for _, p := range provisioned {
+		if !p.Active || active[p.UserID] || p.ExternalID == nil || !isDescendant(*p.ExternalID, base) {
+			continue
+		}
+
+		member, err := s.organizationService.GetMember(ctx, s.cfg.OrganizationID, p.UserID)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return fmt.Errorf("failed to get member: %w", err)
+		case member.Role == organization.RoleOwner:
+			continue
+		default:
+			if err := s.organizationService.RemoveMember(ctx, s.cfg.OrganizationID, p.UserID, p.UserID); err != nil {
+				return fmt.Errorf("failed to remove member: %w", err)
+			}
+			s.logger.Info("removed user that left the ldap groups", zap.String("user_id", p.UserID))
+		}
+
+		p.Active = false
+		p.UpdatedAt = time.Now()
+		if err := s.scimUserRepo.Upsert(ctx, p); err != nil {
+			return fmt.Errorf("failed to update provisioned user: %w", err)
+		}
+	}
+	return nil
+}
+
+// syncGroups creates and updates a group in the organization for every group in the directory, and deletes the
+// groups that have been removed from the directory. Groups are identified by their DN.
+func (s *Service) syncGroups(ctx context.Context, entries []*ldap.Entry, userIDs map[string]string) error {
+	existing, err := s.scimGroupRepo.ListByOrganizationID(ctx, s.cfg.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	managed := make(map[string]*scim.Group)
+	for _, group := range existing {
+		if group.ExternalID != nil && isDescendant(*group.ExternalID, s.cfg.GroupBaseDN) {
+			managed[normalizeDN(*group.ExternalID)] = group
+		}
+	}
+
+	for _, entry := range entries {
+		name := entry.GetEqualFoldAttributeValue(s.cfg.GroupNameAttribute)
+		if name == "" {
+			continue
+		}
+
+		var memberIDs []string
+		seen := make(map[string]bool)
+		for _, member := range entry.GetEqualFoldAttributeValues(s.cfg.GroupMemberAttribute) {
+			if id, ok := userIDs[normalizeDN(member)]; ok && !seen[id] {
+				seen[id] = true
+				memberIDs = append(memberIDs, id)
+			}
+		}
+		sort.Strings(memberIDs)
+
+		key := normalizeDN(entry.DN)
+		group, ok := managed[key]
+		delete(managed, key)
+		if !ok {
+			dn := entry.DN
+			now := time.Now()
+			if err := s.scimGroupRepo.Create(ctx, &scim.Group{
+				ID:             uuid.NewString(),
+				OrganizationID: s.cfg.OrganizationID,
+				DisplayName:    name,
+				ExternalID:     &dn,
+				CreatedAt:      now,
+				UpdatedAt:      now,
+				MemberIDs:      memberIDs,
+			}); err != nil {
+				return fmt.Errorf("failed to create group: %w", err)
+			}
+			continue
+		}
+
+		if group.DisplayName == name && equalIDs(group.MemberIDs, memberIDs) {
+			continue
+		}
+		group.DisplayName = name
+		group.MemberIDs = memberIDs
+		group.UpdatedAt = time.Now()
+		if err := s.scimGroupRepo.Update(ctx, group); err != nil {
+			return fmt.Errorf("failed to update group: %w", err)
+		}
+	}
+
+	for _, group := range managed {
+		if err := s.scimGroupRepo.Delete(ctx, s.cfg.OrganizationID, group.ID); err != nil {
+			return fmt.Errorf("failed to delete group: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string{}, a...)
+	sort.Strings(a)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,290 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"getsturdy.com/api/pkg/analytics/disabled"
+	service_analytics "getsturdy.com/api/pkg/analytics/service"
+	"getsturdy.com/api/pkg/internal/inmemory"
+	"getsturdy.com/api/pkg/ldap/enterprise/selfhosted/config"
+	"getsturdy.com/api/pkg/ldap/enterprise/selfhosted/ldaptest"
+	"getsturdy.com/api/pkg/ldap/enterprise/selfhosted/service"
+	"getsturdy.com/api/pkg/organization"
+	service_organization "getsturdy.com/api/pkg/organization/service"
+	"getsturdy.com/api/pkg/scim"
+	db_scim "getsturdy.com/api/pkg/scim/db"
+	db_users "getsturdy.com/api/pkg/users/db"
+	service_user "getsturdy.com/api/pkg/users/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	organizationID = "organization-id"
+
+	rootDN = "dc=example,dc=com"
+)
+
+type testService struct {
+	*service.Service
+
+	groupRepo           db_scim.GroupRepository
+	organizationService *service_organization.Service
+}
+
+// directory is an in-process ldap server with the entries of a test.
+type directory struct {
+	*ldaptest.Server
+}
+
+// dn returns the DN of the entry in the directory, for example uid=alice,ou=people,dc=example,dc=com for
+// uid=alice,ou=people.
+func (d *directory) dn(rdns string) string {
+	return rdns + "," + rootDN
+}
+
+func newDirectory(t *testing.T) *directory {
+	d := &directory{Server: ldaptest.NewServer(t)}
+
+	d.AddEntry(rootDN, map[string][]string{
+		"objectClass": {"domain"},
+		"dc":          {"example"},
+	})
+	for _, ou := range []string{"services", "people", "groups"} {
+		d.AddEntry(d.dn("ou="+ou), map[string][]string{
+			"objectClass": {"organizationalUnit"},
+			"ou":          {ou},
+		})
+	}
+
+	d.AddEntry(d.dn("cn=sturdy,ou=services"), map[string][]string{
+		"objectClass":  {"person"},
+		"cn":           {"sturdy"},
+		"sn":           {"sturdy"},
+		"userPassword": {"service-password"},
+	})
+	for _, name := range []string{"Alice", "Bob", "Carol"} {
+		uid := strings.ToLower(name)
+		d.AddEntry(d.dn("uid="+uid+",ou=people"), map[string][]string{
+			"objectClass":  {"top", "person", "organizationalPerson", "inetOrgPerson"},
+			"uid":          {uid},
+			"cn":           {name},
+			"sn":           {name},
+			"mail":         {uid + "@example.com"},
+			"userPassword": {uid + "-password"},
+		})
+	}
+	d.AddEntry(d.dn("cn=developers,ou=groups"), map[string][]string{
+		"objectClass": {"groupOfNames"},
+		"cn":          {"developers"},
+		"member":      {d.dn("uid=alice,ou=people"), "UID=Bob, OU=People, " + strings.ToUpper(rootDN)},
+	})
+	d.AddEntry(d.dn("cn=admins,ou=groups"), map[string][]string{
+		"objectClass": {"groupOfNames"},
+		"cn":          {"admins"},
+		"member":      {d.dn("uid=alice,ou=people")},
+	})
+	return d
+}
+
+func newService(t *testing.T, d *directory, cfg *config.Configuration) *testService {
+	cfg.URL = d.URL
+	if cfg.UserFilter == "" {
+		cfg.UserFilter = "(&(objectClass=person)(uid=%s))"
+	}
+	cfg.EmailAttribute = "mail"
+	cfg.NameAttribute = "cn"
+	cfg.GroupFilter = "(objectClass=groupOfNames)"
+	cfg.GroupNameAttribute = "cn"
+	cfg.GroupMemberAttribute = "member"
+
+	analyticsService := service_analytics.New(zap.NewNop(), disabled.NewClient(zap.NewNop()))
+	userService := service_user.New(zap.NewNop(), db_users.NewMemory(), analyticsService)
+	organizationService := service_organization.New(inmemory.NewInMemoryOrganizationRepo(), inmemory.NewInMemoryOrganizationMemberRepository(), analyticsService)
+	groupRepo := db_scim.NewGroupsMemory()
+
+	return &testService{
+		Service:             service.New(zap.NewNop(), cfg, db_scim.NewUsersMemory(), groupRepo, userService, organizationService),
+		groupRepo:           groupRepo,
+		organizationService: organizationService,
+	}
+}
+
+func (s *testService) role(t *testing.T, userID string) organization.Role {
+	ok, err := s.organizationService.CanAccess(context.Background(), userID, organizationID)
+	require.NoError(t, err)
+	if !ok {
+		return ""
+	}
+	member, err := s.organizationService.GetMember(context.Background(), organizationID, userID)
+	require.NoError(t, err)
+	return member.Role
+}
+
+func (s *testService) groups(t *testing.T) map[string][]string {
+	groups, err := s.groupRepo.ListByOrganizationID(context.Background(), organizationID)
+	require.NoError(t, err)
+	res := make(map[string][]string, len(groups))
+	for _, group := range groups {
+		if len(group.MemberIDs) > 0 {
+			res[group.DisplayName] = group.MemberIDs
+		} else {
+			res[group.DisplayName] = nil
+		}
+	}
+	return res
+}
+
+func TestSignIn_bindAsUser(t *testing.T) {
+	ctx := context.Background()
+	d := newDirectory(t)
+	svc := newService(t, d, &config.Configuration{
+		UserDNTemplate: d.dn("uid=%s,ou=people"),
+		OrganizationID: organizationID,
+	})
+
+	_, err := svc.SignIn(ctx, "alice", "wrong-password")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	_, err = svc.SignIn(ctx, "alice", "")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	_, err = svc.SignIn(ctx, "nobody", "alice-password")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	_, err = svc.SignIn(ctx, "alice,"+d.dn("ou=people"), "alice-password")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials, "the username is escaped")
+
+	usr, err := svc.SignIn(ctx, "alice", "alice-password")
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", usr.Email)
+	assert.Equal(t, "Alice", usr.Name)
+	assert.Equal(t, organization.RoleMember, svc.role(t, usr.ID))
+
+	again, err := svc.SignIn(ctx, "alice", "alice-password")
+	require.NoError(t, err)
+	assert.Equal(t, usr.ID, again.ID, "the same user signs in again")
+}
+
+func TestSignIn_searchThenBind(t *testing.T) {
+	ctx := context.Background()
+	d := newDirectory(t)
+	svc := newService(t, d, &config.Configuration{
+		StartTLS:       true,
+		CAFile:         d.CAFile(t),
+		BindDN:         d.dn("cn=sturdy,ou=services"),
+		BindPassword:   "service-password",
+		UserBaseDN:     d.dn("ou=people"),
+		GroupBaseDN:    d.dn("ou=groups"),
+		MemberGroups:   []string{"developers"},
+		AdminGroups:    []string{"admins"},
+		OrganizationID: organizationID,
+	})
+
+	_, err := svc.SignIn(ctx, "*", "alice-password")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials, "the username is escaped")
+	_, err = svc.SignIn(ctx, "bob", "alice-password")
+	assert.ErrorIs(t, err, service.ErrInvalidCredentials)
+	_, err = svc.SignIn(ctx, "carol", "carol-password")
+	assert.ErrorIs(t, err, service.ErrNotAllowed)
+
+	alice, err := svc.SignIn(ctx, "alice", "alice-password")
+	require.NoError(t, err)
+	assert.Equal(t, organization.RoleAdmin, svc.role(t, alice.ID))
+
+	bob, err := svc.SignIn(ctx, "bob", "bob-password")
+	require.NoError(t, err)
+	assert.Equal(t, organization.RoleMember, svc.role(t, bob.ID))
+}
+
+func TestSignIn_untrusted(t *testing.T) {
+	d := newDirectory(t)
+	svc := newService(t, d, &config.Configuration{
+		StartTLS:       true,
+		UserDNTemplate: d.dn("uid=%s,ou=people"),
+	})
+	_, err := svc.SignIn(context.Background(), "alice", "alice-password")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, service.ErrInvalidCredentials)
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	d := newDirectory(t)
+	svc := newService(t, d, &config.Configuration{
+		BindDN:         d.dn("cn=sturdy,ou=services"),
+		BindPassword:   "service-password",
+		UserBaseDN:     d.dn("ou=people"),
+		GroupBaseDN:    d.dn("ou=groups"),
+		MemberGroups:   []string{"developers"},
+		AdminGroups:    []string{"admins"},
+		OrganizationID: organizationID,
+	})
+
+	// a group that is managed through scim, and is not touched by the sync
+	externalID := "scim-group"
+	require.NoError(t, svc.groupRepo.Create(ctx, &scim.Group{ID: "scim", OrganizationID: organizationID, DisplayName: "scim", ExternalID: &externalID}))
+
+	require.NoError(t, svc.Sync(ctx))
+
+	alice, err := svc.SignIn(ctx, "alice", "alice-password")
+	require.NoError(t, err)
+	bob, err := svc.SignIn(ctx, "bob", "bob-password")
+	require.NoError(t, err)
+
+	assert.Equal(t, organization.RoleAdmin, svc.role(t, alice.ID))
+	assert.Equal(t, organization.RoleMember, svc.role(t, bob.ID))
+	assert.Equal(t, map[string][]string{
+		"scim":       nil,
+		"developers": sortedIDs(alice.ID, bob.ID),
+		"admins":     {alice.ID},
+	}, svc.groups(t))
+
+	// bob leaves, alice is no longer an admin, and the admins group is removed
+	d.SetAttribute(d.dn("cn=developers,ou=groups"), "member", d.dn("uid=alice,ou=people"))
+	d.RemoveEntry(d.dn("cn=admins,ou=groups"))
+
+	require.NoError(t, svc.Sync(ctx))
+
+	assert.Equal(t, organization.RoleMember, svc.role(t, alice.ID))
+	assert.Equal(t, organization.Role(""), svc.role(t, bob.ID))
+	assert.Equal(t, map[string][]string{
+		"scim":       nil,
+		"developers": {alice.ID},
+	}, svc.groups(t))
+
+	_, err = svc.SignIn(ctx, "bob", "bob-password")
+	assert.ErrorIs(t, err, service.ErrNotAllowed)
+}
+
+func TestSync_owner(t *testing.T) {
+	ctx := context.Background()
+	d := newDirectory(t)
+	svc := newService(t, d, &config.Configuration{
+		BindDN:         d.dn("cn=sturdy,ou=services"),
+		BindPassword:   "service-password",
+		UserBaseDN:     d.dn("ou=people"),
+		GroupBaseDN:    d.dn("ou=groups"),
+		MemberGroups:   []string{"developers"},
+		OrganizationID: organizationID,
+	})
+
+	require.NoError(t, svc.Sync(ctx))
+	alice, err := svc.SignIn(ctx, "alice", "alice-password")
+	require.NoError(t, err)
+	_, err = svc.organizationService.SetMemberRole(ctx, organizationID, alice.ID, organization.RoleOwner)
+	require.NoError(t, err)
+
+	// owners are not demoted or removed
+	d.RemoveEntry(d.dn("cn=developers,ou=groups"))
+	require.NoError(t, svc.Sync(ctx))
+	assert.Equal(t, organization.RoleOwner, svc.role(t, alice.ID))
+}
+
+func sortedIDs(ids ...string) []string {
+	if ids[0] > ids[1] {
+		ids[0], ids[1] = ids[1], ids[0]
+	}
+	return ids
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	service_ldap "getsturdy.com/api/pkg/ldap/enterprise/selfhosted/service"
+
+	"go.uber.org/zap"
+)
+
+// Worker periodically syncs the groups in the directory to the organization.
+type Worker struct {
+	logger      *zap.Logger
+	ldapService *service_ldap.Service
+}
+
+func New(
+	logger *zap.Logger,
+	ldapService *service_ldap.Service,
+) *Worker {
+	return &Worker{
+		logger:      logger.Named("ldap_worker"),
+		ldapService: ldapService,
+	}
+}
+
+func (w *Worker) Start(ctx context.Context) error {
+	interval := w.ldapService.SyncInterval()
+	if interval <= 0 {
+		return nil
+	}
+
+	w.logger.Info("starting")
+
+	w.sync(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.sync(ctx)
+		case <-ctx.Done():
+			w.logger.Info("stopping")
+			return nil
+		}
+	}
+}
+
+func (w *Worker) sync(ctx context.Context) {
+	if err := w.ldapService.Sync(ctx); err != nil {
+		w.logger.Error("failed to sync ldap", zap.Error(err))
+	}
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/maintenance"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Get(ctx context.Context, id string) (*maintenance.RepositoryStatus, error)
+	ListByCodebaseID(ctx context.Context, codebaseID string) ([]*maintenance.RepositoryStatus, error)
+	Upsert(context.Context, *maintenance.RepositoryStatus) error
+	// MarkScheduled marks the codebase as scheduled for maintenance at now. It returns false if the codebase has
+	// already been scheduled after scheduledBefore.
+	MarkScheduled(ctx context.Context, codebaseID string, now, scheduledBefore time.Time) (bool, error)
+}
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+	return &repo{db: db}
+}
+
+func (r *repo) Get(ctx context.Context, id string) (*maintenance.RepositoryStatus, error) {
+	var res maintenance.RepositoryStatus
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id,
+			codebase_id,
+			view_id,
+			size_bytes,
+			objects_count,
+			packs_count,
+			last_maintenance_at,
+			quarantined_at,
+			fsck_error
+		FROM
+			repository_maintenance_status
+		WHERE
+			id = $1
+	`, id); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *repo) ListByCodebaseID(ctx context.Context, codebaseID string) ([]*maintenance.RepositoryStatus, error) {
+	var res []*maintenance.RepositoryStatus
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id,
+			codebase_id,
+			view_id,
+			size_bytes,
+			objects_count,
+			packs_count,
+			last_maintenance_at,
+			quarantined_at,
+			fsck_error
+		FROM
+			repository_maintenance_status
+		WHERE
+			codebase_id = $1
+	`, codebaseID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *repo) Upsert(ctx context.Context, status *maintenance.RepositoryStatus) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO repository_maintenance_status
+			(id, codebase_id, view_id, size_bytes, objects_count, packs_count, last_maintenance_at, quarantined_at, fsck_error)
+		VALUES
+			(:id, :codebase_id, :view_id, :size_bytes, :objects_count, :packs_count, :last_maintenance_at, :quarantined_at, :fsck_error)
+		ON CONFLICT (id) DO UPDATE SET
+			size_bytes = :size_bytes,
+			objects_count = :objects_count,
+			packs_count = :packs_count,
+			last_maintenance_at = :last_maintenance_at,
+			quarantined_at = :quarantined_at,
+			fsck_error = :fsck_error
+	`, status); err != nil {
+		return fmt.Errorf("failed to upsert: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) MarkScheduled(ctx context.Context, codebaseID string, now, scheduledBefore time.Time) (bool, error) {
+	var id string
+	err := r.db.GetContext(ctx, &id, `
+		INSERT INTO codebase_maintenance_schedule
+			(codebase_id, scheduled_at)
+		VALUES
+			($1, $2)
+		ON CONFLICT (codebase_id) DO UPDATE SET
+			scheduled_at = $2
+		WHERE
+			codebase_maintenance_schedule.scheduled_at < $3
+		RETURNING
+			codebase_id
+	`, codebaseID, now, scheduledBefore)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to mark as scheduled: %w", err)
+	}
+}
@@ -0,0 +1,167 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	service_auth "getsturdy.com/api/pkg/auth/service"
+	service_codebase "getsturdy.com/api/pkg/codebase/service"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/maintenance"
+	service_maintenance "getsturdy.com/api/pkg/maintenance/service"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type rootResolver struct {
+	authService        *service_auth.Service
+	codebaseService    *service_codebase.Service
+	maintenanceService *service_maintenance.Service
+
+	codebaseResolver *resolvers.CodebaseRootResolver
+	viewResolver     *resolvers.ViewRootResolver
+}
+
+func New(
+	authService *service_auth.Service,
+	codebaseService *service_codebase.Service,
+	maintenanceService *service_maintenance.Service,
+	codebaseResolver *resolvers.CodebaseRootResolver,
+	viewResolver *resolvers.ViewRootResolver,
+) resolvers.StorageHealthRootResolver {
+	return &rootResolver{
+		authService:        authService,
+		codebaseService:    codebaseService,
+		maintenanceService: maintenanceService,
+		codebaseResolver:   codebaseResolver,
+		viewResolver:       viewResolver,
+	}
+}
+
+func (r *rootResolver) CodebaseStorageHealth(ctx context.Context, args resolvers.CodebaseStorageHealthArgs) (resolvers.CodebaseStorageHealthResolver, error) {
+	cb, err := r.codebaseService.GetByID(ctx, string(args.CodebaseID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.authService.CanAdmin(ctx, cb); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	statuses, err := r.maintenanceService.ListByCodebaseID(ctx, cb.ID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	return &codebaseResolver{root: r, codebaseID: cb.ID, statuses: statuses}, nil
+}
+
+type codebaseResolver struct {
+	root       *rootResolver
+	codebaseID string
+	statuses   []*maintenance.RepositoryStatus
+}
+
+func (r *codebaseResolver) ID() graphql.ID {
+	return graphql.ID(r.codebaseID)
+}
+
+func (r *codebaseResolver) Codebase(ctx context.Context) (resolvers.CodebaseResolver, error) {
+	id := graphql.ID(r.codebaseID)
+	return (*r.root.codebaseResolver).Codebase(ctx, resolvers.CodebaseArgs{ID: &id})
+}
+
+func (r *codebaseResolver) SizeBytes() float64 {
+	var size int64
+	for _, status := range r.statuses {
+		size += status.SizeBytes
+	}
+	return float64(size)
+}
+
+func (r *codebaseResolver) ObjectsCount() float64 {
+	var count int64
+	for _, status := range r.statuses {
+		count += status.ObjectsCount
+	}
+	return float64(count)
+}
+
+func (r *codebaseResolver) QuarantinedCount() int32 {
+	var count int32
+	for _, status := range r.statuses {
+		if status.IsQuarantined() {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *codebaseResolver) Repositories() []resolvers.RepositoryStorageHealthResolver {
+	res := make([]resolvers.RepositoryStorageHealthResolver, 0, len(r.statuses))
+	for _, status := range r.statuses {
+		res = append(res, &repositoryResolver{root: r.root, status: status})
+	}
+	return res
+}
+
+type repositoryResolver struct {
+	root   *rootResolver
+	status *maintenance.RepositoryStatus
+}
+
+func (r *repositoryResolver) ID() graphql.ID {
+	return graphql.ID(r.status.ID)
+}
+
+func (r *repositoryResolver) IsTrunk() bool {
+	return r.status.IsTrunk()
+}
+
+func (r *repositoryResolver) View(ctx context.Context) (resolvers.ViewResolver, error) {
+	if r.status.ViewID == nil {
+		return nil, nil
+	}
+	view, err := (*r.root.viewResolver).View(ctx, resolvers.ViewArgs{ID: graphql.ID(*r.status.ViewID)})
+	switch {
+	case err == nil:
+		return view, nil
+	case errors.Is(err, gqlerrors.ErrNotFound):
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+func (r *repositoryResolver) SizeBytes() float64 {
+	return float64(r.status.SizeBytes)
+}
+
+func (r *repositoryResolver) ObjectsCount() float64 {
+	return float64(r.status.ObjectsCount)
+}
+
+func (r *repositoryResolver) PacksCount() int32 {
+	return int32(r.status.PacksCount)
+}
+
+func (r *repositoryResolver) LastMaintenanceAt() *int32 {
+	if r.status.LastMaintenanceAt == nil {
+		return nil
+	}
+	t := int32(r.status.LastMaintenanceAt.Unix())
+	return &t
+}
+
+func (r *repositoryResolver) QuarantinedAt() *int32 {
+	if r.status.QuarantinedAt == nil {
+		return nil
+	}
+	t := int32(r.status.QuarantinedAt.Unix())
+	return &t
+}
+
+func (r *repositoryResolver) FsckError() *string {
+	return r.status.FsckError
+}
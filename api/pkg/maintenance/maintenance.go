@@ -0,0 +1,45 @@
+package maintenance
+
+import "time"
+
+// RepositoryStatus is the storage health of a single trunk or view repository.
+type RepositoryStatus struct {
+	// ID is the codebase ID for trunk repositories, and the view ID for view repositories.
+	ID         string  `db:"id"`
+	CodebaseID string  `db:"codebase_id"`
+	ViewID     *string `db:"view_id"`
+
+	SizeBytes    int64 `db:"size_bytes"`
+	ObjectsCount int64 `db:"objects_count"`
+	PacksCount   int64 `db:"packs_count"`
+
+	LastMaintenanceAt *time.Time `db:"last_maintenance_at"`
+	// QuarantinedAt is set when the repository has failed fsck. Quarantined repositories are
+	// not repacked or garbage collected until fsck passes again.
+	QuarantinedAt *time.Time `db:"quarantined_at"`
+	FsckError     *string    `db:"fsck_error"`
+}
+
+func NewTrunkStatus(codebaseID string) *RepositoryStatus {
+	return &RepositoryStatus{ID: codebaseID, CodebaseID: codebaseID}
+}
+
+func NewViewStatus(codebaseID, viewID string) *RepositoryStatus {
+	return &RepositoryStatus{ID: viewID, CodebaseID: codebaseID, ViewID: &viewID}
+}
+
+func (s *RepositoryStatus) IsTrunk() bool {
+	return s.ViewID == nil
+}
+
+func (s *RepositoryStatus) IsQuarantined() bool {
+	return s.QuarantinedAt != nil
+}
+
+// NeedsMaintenance returns true if maintenance has not been run on the repository within the interval.
+func (s *RepositoryStatus) NeedsMaintenance(now time.Time, interval time.Duration) bool {
+	if s.LastMaintenanceAt == nil {
+		return true
+	}
+	return !s.LastMaintenanceAt.After(now.Add(-interval))
+}
@@ -0,0 +1,35 @@
+package maintenance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNeedsMaintenance(t *testing.T) {
+	now := time.Date(2022, 1, 10, 12, 0, 0, 0, time.UTC)
+	ts := func(d time.Duration) *time.Time {
+		t := now.Add(-d)
+		return &t
+	}
+
+	cases := []struct {
+		name              string
+		lastMaintenanceAt *time.Time
+		expected          bool
+	}{
+		{name: "never", lastMaintenanceAt: nil, expected: true},
+		{name: "recently", lastMaintenanceAt: ts(time.Hour), expected: false},
+		{name: "exactly interval ago", lastMaintenanceAt: ts(24 * time.Hour), expected: true},
+		{name: "long ago", lastMaintenanceAt: ts(48 * time.Hour), expected: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			status := NewTrunkStatus("codebase-id")
+			status.LastMaintenanceAt = tc.lastMaintenanceAt
+			assert.Equal(t, tc.expected, status.NeedsMaintenance(now, 24*time.Hour))
+		})
+	}
+}
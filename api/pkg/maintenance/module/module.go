@@ -0,0 +1,16 @@
+package module
+
+import (
+	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/maintenance/db"
+	"getsturdy.com/api/pkg/maintenance/graphql"
+	"getsturdy.com/api/pkg/maintenance/service"
+	"getsturdy.com/api/pkg/maintenance/worker"
+)
+
+func Module(c *di.Container) {
+	c.Import(db.Module)
+	c.Import(graphql.Module)
+	c.Import(service.Module)
+	c.Import(worker.Module)
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/maintenance"
+	"getsturdy.com/api/pkg/maintenance/db"
+	db_view "getsturdy.com/api/pkg/view/db"
+	"getsturdy.com/api/vcs"
+	"getsturdy.com/api/vcs/executor"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/zap"
+)
+
+var (
+	repositorySizeBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sturdy_repository_size_bytes",
+		Help: "Disk space used by the object database of the repositories of a codebase",
+	}, []string{"codebase_id", "type"})
+
+	repositoryObjects = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sturdy_repository_objects",
+		Help: "Number of git objects in the repositories of a codebase",
+	}, []string{"codebase_id", "type"})
+
+	repositoryPacks = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sturdy_repository_packs",
+		Help: "Number of packfiles in the repositories of a codebase",
+	}, []string{"codebase_id", "type"})
+
+	repositoriesQuarantined = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "sturdy_repositories_quarantined",
+		Help: "Number of repositories of a codebase that have failed fsck",
+	}, []string{"codebase_id"})
+)
+
+// DefaultInterval is how often maintenance is run on each repository.
+const DefaultInterval = 24 * time.Hour
+
+type Service struct {
+	logger           *zap.Logger
+	repo             db.Repository
+	viewRepo         db_view.Repository
+	executorProvider executor.Provider
+}
+
+func New(
+	logger *zap.Logger,
+	repo db.Repository,
+	viewRepo db_view.Repository,
+	executorProvider executor.Provider,
+) *Service {
+	return &Service{
+		logger:           logger.Named("maintenanceService"),
+		repo:             repo,
+		viewRepo:         viewRepo,
+		executorProvider: executorProvider,
+	}
+}
+
+// ListByCodebaseID returns the status of all repositories of the codebase that maintenance has run on.
+func (svc *Service) ListByCodebaseID(ctx context.Context, codebaseID string) ([]*maintenance.RepositoryStatus, error) {
+	statuses, err := svc.repo.ListByCodebaseID(ctx, codebaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statuses: %w", err)
+	}
+	return statuses, nil
+}
+
+// IsQuarantined returns true if the repository with the given id (codebase id for trunk, view id for views)
+// has failed fsck.
+func (svc *Service) IsQuarantined(ctx context.Context, id string) (bool, error) {
+	status, err := svc.repo.Get(ctx, id)
+	switch {
+	case err == nil:
+		return status.IsQuarantined(), nil
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to get status: %w", err)
+	}
+}
+
+// MarkScheduled returns true if the codebase should be scheduled for maintenance now, and false if it has already
+// been scheduled within the interval, for example by another replica.
+func (svc *Service) MarkScheduled(ctx context.Context, codebaseID string, interval time.Duration) (bool, error) {
+	now := time.Now()
+	scheduled, err := svc.repo.MarkScheduled(ctx, codebaseID, now, now.Add(-interval))
+	if err != nil {
+		return false, fmt.Errorf("failed to mark as scheduled: %w", err)
+	}
+	return scheduled, nil
+}
+
+func (svc *Service) Work(ctx context.Context, logger *zap.Logger, codebaseID string) error {
+	return svc.WorkWithInterval(ctx, logger, codebaseID, DefaultInterval)
+}
+
+// WorkWithInterval runs maintenance on trunk and all views of the codebase, skipping repositories that have
+// been maintained within the interval.
+func (svc *Service) WorkWithInterval(ctx context.Context, logger *zap.Logger, codebaseID string, interval time.Duration) error {
+	statuses, err := svc.repo.ListByCodebaseID(ctx, codebaseID)
+	if err != nil {
+		return fmt.Errorf("failed to list statuses: %w", err)
+	}
+	byID := make(map[string]*maintenance.RepositoryStatus, len(statuses))
+	for _, status := range statuses {
+		byID[status.ID] = status
+	}
+
+	trunkStatus, ok := byID[codebaseID]
+	if !ok {
+		trunkStatus = maintenance.NewTrunkStatus(codebaseID)
+	}
+	current := []*maintenance.RepositoryStatus{trunkStatus}
+	if trunkStatus.NeedsMaintenance(time.Now(), interval) {
+		if err := svc.executorProvider.New().GitWrite(func(repo vcs.RepoGitWriter) error {
+			svc.maintain(logger, repo, trunkStatus)
+			return nil
+		}).ExecTrunk(codebaseID, "maintenanceTrunk"); err != nil {
+			logger.Error("failed to run maintenance on trunk", zap.Error(err))
+			// don't exit
+		} else if err := svc.repo.Upsert(ctx, trunkStatus); err != nil {
+			return fmt.Errorf("failed to save trunk status: %w", err)
+		}
+	}
+
+	views, err := svc.viewRepo.ListByCodebase(codebaseID)
+	if err != nil {
+		return fmt.Errorf("failed to list views: %w", err)
+	}
+
+	for _, view := range views {
+		logger := logger.With(zap.String("view_id", view.ID))
+
+		viewStatus, ok := byID[view.ID]
+		if !ok {
+			viewStatus = maintenance.NewViewStatus(codebaseID, view.ID)
+		}
+		current = append(current, viewStatus)
+		if !viewStatus.NeedsMaintenance(time.Now(), interval) {
+			continue
+		}
+
+		if err := svc.executorProvider.New().GitWrite(func(repo vcs.RepoGitWriter) error {
+			svc.maintain(logger, repo, viewStatus)
+			return nil
+		}).ExecView(view.CodebaseID, view.ID, "maintenanceView"); err != nil {
+			// If the view is rebasing, it will be maintained on the next run.
+			if errors.Is(err, executor.ErrIsRebasing) {
+				logger.Warn("failed to run maintenance on view", zap.Error(err))
+			} else {
+				logger.Error("failed to run maintenance on view", zap.Error(err))
+			}
+			continue
+		}
+
+		if err := svc.repo.Upsert(ctx, viewStatus); err != nil {
+			return fmt.Errorf("failed to save view status: %w", err)
+		}
+	}
+
+	reportMetrics(codebaseID, current)
+
+	return nil
+}
+
+func (svc *Service) maintain(logger *zap.Logger, repo vcs.RepoGitWriter, status *maintenance.RepositoryStatus) {
+	// Writing to a corrupted repository can make things worse, quarantined repositories are only
+	// checked again.
+	if !status.IsQuarantined() {
+		if err := repo.GitRepack(); err != nil {
+			logger.Error("failed to repack", zap.Error(err))
+			// don't exit
+		}
+		if err := repo.GitCommitGraphWrite(); err != nil {
+			logger.Error("failed to write commit-graph", zap.Error(err))
+			// don't exit
+		}
+		if err := repo.GitMultiPackIndexWrite(); err != nil {
+			logger.Error("failed to write multi-pack-index", zap.Error(err))
+			// don't exit
+		}
+	}
+
+	now := time.Now()
+	if err := repo.GitFsck(); err != nil {
+		if !status.IsQuarantined() {
+			logger.Error("fsck failed, quarantining repository", zap.Error(err))
+			status.QuarantinedAt = &now
+		}
+		msg := err.Error()
+		status.FsckError = &msg
+	} else {
+		if status.IsQuarantined() {
+			logger.Info("fsck passed, lifting quarantine")
+		}
+		status.QuarantinedAt = nil
+		status.FsckError = nil
+	}
+
+	if counts, err := repo.GitCountObjects(); err != nil {
+		logger.Error("failed to count objects", zap.Error(err))
+	} else {
+		status.SizeBytes = counts.TotalSize()
+		status.ObjectsCount = counts.TotalObjects()
+		status.PacksCount = counts.Packs
+	}
+
+	status.LastMaintenanceAt = &now
+}
+
+func reportMetrics(codebaseID string, statuses []*maintenance.RepositoryStatus) {
+	var quarantined int
+	for _, typ := range []string{"trunk", "view"} {
+		var size, objects, packs int64
+		for _, status := range statuses {
+			if status.IsTrunk() != (typ == "trunk") {
+				continue
+			}
+			size += status.SizeBytes
+			objects += status.ObjectsCount
+			packs += status.PacksCount
+			if status.IsQuarantined() {
+				quarantined++
+			}
+		}
+		repositorySizeBytes.WithLabelValues(codebaseID, typ).Set(float64(size))
+		repositoryObjects.WithLabelValues(codebaseID, typ).Set(float64(objects))
+		repositoryPacks.WithLabelValues(codebaseID, typ).Set(float64(packs))
+	}
+	repositoriesQuarantined.WithLabelValues(codebaseID).Set(float64(quarantined))
+}
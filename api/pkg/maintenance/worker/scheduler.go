@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	service_codebase "getsturdy.com/api/pkg/codebase/service"
+	service_maintenance "getsturdy.com/api/pkg/maintenance/service"
+
+	"go.uber.org/zap"
+)
+
+var (
+	scheduleEvery = time.Hour
+)
+
+// Scheduler periodically enqueues all codebases for maintenance. Every replica runs a scheduler, and each codebase
+// is only enqueued by the first replica that schedules it within the hour. Codebases that have been maintained
+// recently are skipped by the service.
+type Scheduler struct {
+	logger             *zap.Logger
+	codebaseService    *service_codebase.Service
+	maintenanceService *service_maintenance.Service
+	queue              *Queue
+}
+
+func NewScheduler(
+	logger *zap.Logger,
+	codebaseService *service_codebase.Service,
+	maintenanceService *service_maintenance.Service,
+	queue *Queue,
+) *Scheduler {
+	return &Scheduler{
+		logger:             logger.Named("maintenanceScheduler"),
+		codebaseService:    codebaseService,
+		maintenanceService: maintenanceService,
+		queue:              queue,
+	}
+}
+
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.logger.Info("starting")
+
+	ticker := time.NewTicker(scheduleEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.schedule(ctx); err != nil {
+				s.logger.Error("failed to schedule maintenance", zap.Error(err))
+			}
+		case <-ctx.Done():
+			s.logger.Info("stopping")
+			return nil
+		}
+	}
+}
+
+func (s *Scheduler) schedule(ctx context.Context) error {
+	codebases, err := s.codebaseService.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list codebases: %w", err)
+	}
+	for _, cb := range codebases {
+		// the tickers of the replicas are not aligned, so codebases that were scheduled within half the
+		// interval are skipped
+		scheduled, err := s.maintenanceService.MarkScheduled(ctx, cb.ID, scheduleEvery/2)
+		if err != nil {
+			return fmt.Errorf("failed to mark %s as scheduled: %w", cb.ID, err)
+		}
+		if !scheduled {
+			continue
+		}
+		if err := s.queue.Enqueue(ctx, cb.ID); err != nil {
+			return fmt.Errorf("failed to enqueue %s: %w", cb.ID, err)
+		}
+	}
+	return nil
+}
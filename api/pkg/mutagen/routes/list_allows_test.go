@@ -22,6 +22,7 @@ import (
 	"getsturdy.com/api/pkg/codebase/acl"
 	provider_acl "getsturdy.com/api/pkg/codebase/acl/provider"
 	"getsturdy.com/api/pkg/internal/inmemory"
+	db_scim "getsturdy.com/api/pkg/scim/db"
 	"getsturdy.com/api/pkg/users"
 	db_users "getsturdy.com/api/pkg/users/db"
 	service_user "getsturdy.com/api/pkg/users/service"
@@ -43,6 +44,9 @@ func TestListAllows(t *testing.T) {
 		aclRepo,
 		nil,
 		nil,
+		inmemory.NewInMemoryCodebaseRepo(),
+		db_scim.NewGroupsMemory(),
+		inmemory.NewInMemoryOrganizationMemberRepository(),
 	)
 
 	authService := service_auth.New(
@@ -51,6 +55,9 @@ func TestListAllows(t *testing.T) {
 		nil,
 		aclProvider,
 		nil,
+		nil,
+		nil,
+		nil,
 	)
 
 	type listAllowsResponse struct {
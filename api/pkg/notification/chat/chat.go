@@ -0,0 +1,75 @@
+package chat
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"getsturdy.com/api/pkg/outbound"
+)
+
+// Provider is the chat service that an incoming webhook belongs to, it decides the format of the posted messages.
+type Provider string
+
+const (
+	ProviderUndefined  Provider = ""
+	ProviderSlack      Provider = "slack"
+	ProviderMattermost Provider = "mattermost"
+	ProviderTeams      Provider = "teams"
+)
+
+var ValidProvider = map[Provider]bool{
+	ProviderSlack:      true,
+	ProviderMattermost: true,
+	ProviderTeams:      true,
+}
+
+var (
+	ErrInvalidURL      = errors.New("invalid url")
+	ErrInvalidProvider = errors.New("invalid provider")
+	ErrInvalidTarget   = errors.New("exactly one of user id and codebase id must be set")
+)
+
+// Webhook is an incoming webhook of a chat service that notifications are posted to.
+//
+// A webhook belongs to either a user or a codebase. User webhooks receive the notifications of the user, according
+// to the users notification preferences. Codebase webhooks receive all notifications in the codebase, once.
+type Webhook struct {
+	ID         string     `db:"id"`
+	UserID     *string    `db:"user_id"`
+	CodebaseID *string    `db:"codebase_id"`
+	Provider   Provider   `db:"provider"`
+	URL        string     `db:"url"`
+	CreatedBy  string     `db:"created_by"`
+	CreatedAt  time.Time  `db:"created_at"`
+	DeletedAt  *time.Time `db:"deleted_at"`
+}
+
+// Validate returns an error if the webhook can not be saved. Urls of internal addresses are refused here, and
+// hostnames that resolve to them are refused when messages are posted.
+func (w *Webhook) Validate() error {
+	if (w.UserID == nil) == (w.CodebaseID == nil) {
+		return ErrInvalidTarget
+	}
+	if !ValidProvider[w.Provider] {
+		return ErrInvalidProvider
+	}
+	switch err := outbound.ValidateURL(w.URL); {
+	case err == nil:
+	case errors.Is(err, outbound.ErrForbiddenAddress):
+		return fmt.Errorf("%w: %s", ErrInvalidURL, err)
+	default:
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// Host returns the host of the webhook url. The full url contains a secret, and is never returned to the clients.
+func (w *Webhook) Host() string {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+
+	"getsturdy.com/api/pkg/notification"
+	"getsturdy.com/api/pkg/notification/chat"
+)
+
+var _ Repository = &memory{}
+
+type postKey struct {
+	webhookID   string
+	typ         notification.NotificationType
+	referenceID string
+}
+
+type memory struct {
+	mu     sync.Mutex
+	byID   map[string]chat.Webhook
+	posted map[postKey]bool
+}
+
+func NewMemory() *memory {
+	return &memory{
+		byID:   make(map[string]chat.Webhook),
+		posted: make(map[postKey]bool),
+	}
+}
+
+func (m *memory) Create(_ context.Context, webhook *chat.Webhook) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byID[webhook.ID] = *webhook
+	return nil
+}
+
+func (m *memory) Get(_ context.Context, id string) (*chat.Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	webhook, ok := m.byID[id]
+	if !ok || webhook.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	return &webhook, nil
+}
+
+func (m *memory) Update(ctx context.Context, webhook *chat.Webhook) error {
+	return m.Create(ctx, webhook)
+}
+
+func (m *memory) list(match func(*chat.Webhook) bool) []*chat.Webhook {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*chat.Webhook
+	for _, webhook := range m.byID {
+		webhook := webhook
+		if webhook.DeletedAt != nil || !match(&webhook) {
+			continue
+		}
+		res = append(res, &webhook)
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].CreatedAt.Before(res[b].CreatedAt)
+	})
+	return res
+}
+
+func (m *memory) ListByUserID(_ context.Context, userID string) ([]*chat.Webhook, error) {
+	return m.list(func(w *chat.Webhook) bool {
+		return w.UserID != nil && *w.UserID == userID
+	}), nil
+}
+
+func (m *memory) ListByCodebaseID(_ context.Context, codebaseID string) ([]*chat.Webhook, error) {
+	return m.list(func(w *chat.Webhook) bool {
+		return w.CodebaseID != nil && *w.CodebaseID == codebaseID
+	}), nil
+}
+
+func (m *memory) MarkPosted(_ context.Context, webhookID string, typ notification.NotificationType, referenceID string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := postKey{webhookID: webhookID, typ: typ, referenceID: referenceID}
+	if m.posted[key] {
+		return false, nil
+	}
+	m.posted[key] = true
+	return true, nil
+}
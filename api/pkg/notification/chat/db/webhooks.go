@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/notification"
+	"getsturdy.com/api/pkg/notification/chat"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Create(context.Context, *chat.Webhook) error
+	Get(ctx context.Context, id string) (*chat.Webhook, error)
+	Update(context.Context, *chat.Webhook) error
+	// ListByUserID returns the webhooks of the user that are not deleted.
+	ListByUserID(ctx context.Context, userID string) ([]*chat.Webhook, error)
+	// ListByCodebaseID returns the webhooks of the codebase that are not deleted.
+	ListByCodebaseID(ctx context.Context, codebaseID string) ([]*chat.Webhook, error)
+	// MarkPosted records that the referenced notification is posted to the webhook. It returns false if
+	// it has already been posted.
+	MarkPosted(ctx context.Context, webhookID string, typ notification.NotificationType, referenceID string) (bool, error)
+}
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) Repository {
+	return &repo{db: db}
+}
+
+func (r *repo) Create(ctx context.Context, webhook *chat.Webhook) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO chat_webhooks
+			(id, user_id, codebase_id, provider, url, created_by, created_at, deleted_at)
+		VALUES
+			(:id, :user_id, :codebase_id, :provider, :url, :created_by, :created_at, :deleted_at)
+	`, webhook); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) Get(ctx context.Context, id string) (*chat.Webhook, error) {
+	var res chat.Webhook
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, user_id, codebase_id, provider, url, created_by, created_at, deleted_at
+		FROM
+			chat_webhooks
+		WHERE
+			id = $1
+			AND deleted_at IS NULL
+	`, id); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *repo) Update(ctx context.Context, webhook *chat.Webhook) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		UPDATE
+			chat_webhooks
+		SET
+			provider = :provider,
+			url = :url,
+			deleted_at = :deleted_at
+		WHERE
+			id = :id
+	`, webhook); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) ListByUserID(ctx context.Context, userID string) ([]*chat.Webhook, error) {
+	var res []*chat.Webhook
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, user_id, codebase_id, provider, url, created_by, created_at, deleted_at
+		FROM
+			chat_webhooks
+		WHERE
+			user_id = $1
+			AND deleted_at IS NULL
+		ORDER BY
+			created_at
+	`, userID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *repo) ListByCodebaseID(ctx context.Context, codebaseID string) ([]*chat.Webhook, error) {
+	var res []*chat.Webhook
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, user_id, codebase_id, provider, url, created_by, created_at, deleted_at
+		FROM
+			chat_webhooks
+		WHERE
+			codebase_id = $1
+			AND deleted_at IS NULL
+		ORDER BY
+			created_at
+	`, codebaseID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *repo) MarkPosted(ctx context.Context, webhookID string, typ notification.NotificationType, referenceID string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO chat_webhook_posts
+			(webhook_id, notification_type, reference_id, created_at)
+		VALUES
+			($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+	`, webhookID, typ, referenceID, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to insert: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return affected == 1, nil
+}
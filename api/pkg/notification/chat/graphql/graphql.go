@@ -0,0 +1,205 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+
+	"getsturdy.com/api/pkg/accesstokens"
+	"getsturdy.com/api/pkg/auth"
+	service_auth "getsturdy.com/api/pkg/auth/service"
+	service_codebase "getsturdy.com/api/pkg/codebase/service"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/notification/chat"
+	service_chat "getsturdy.com/api/pkg/notification/chat/service"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+var (
+	providerToGraphQL = map[chat.Provider]string{
+		chat.ProviderSlack:      "Slack",
+		chat.ProviderMattermost: "Mattermost",
+		chat.ProviderTeams:      "Teams",
+	}
+	providerFromGraphQL = func() map[string]chat.Provider {
+		res := make(map[string]chat.Provider, len(providerToGraphQL))
+		for provider, name := range providerToGraphQL {
+			res[name] = provider
+		}
+		return res
+	}()
+)
+
+type rootResolver struct {
+	authService     *service_auth.Service
+	codebaseService *service_codebase.Service
+	chatService     *service_chat.Service
+
+	codebaseResolver *resolvers.CodebaseRootResolver
+}
+
+func New(
+	authService *service_auth.Service,
+	codebaseService *service_codebase.Service,
+	chatService *service_chat.Service,
+	codebaseResolver *resolvers.CodebaseRootResolver,
+) resolvers.ChatWebhooksRootResolver {
+	return &rootResolver{
+		authService:      authService,
+		codebaseService:  codebaseService,
+		chatService:      chatService,
+		codebaseResolver: codebaseResolver,
+	}
+}
+
+// canManage returns an error if the user is not allowed to manage the webhook.
+func (r *rootResolver) canManage(ctx context.Context, webhook *chat.Webhook) error {
+	if webhook.CodebaseID != nil {
+		return r.canManageCodebase(ctx, *webhook.CodebaseID)
+	}
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return err
+	}
+	if webhook.UserID == nil || *webhook.UserID != userID {
+		return gqlerrors.ErrForbidden
+	}
+	return auth.RequireScope(ctx, accesstokens.ScopeAdmin)
+}
+
+// canManageCodebase returns an error if the user is not an owner or admin of the codebase.
+func (r *rootResolver) canManageCodebase(ctx context.Context, codebaseID string) error {
+	cb, err := r.codebaseService.GetByID(ctx, codebaseID)
+	if err != nil {
+		return err
+	}
+	return r.authService.CanAdmin(ctx, cb)
+}
+
+func (r *rootResolver) InternalListByUserID(ctx context.Context, userID string) ([]resolvers.ChatWebhookResolver, error) {
+	authUserID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	if authUserID != userID {
+		return nil, gqlerrors.Error(gqlerrors.ErrForbidden)
+	}
+
+	hooks, err := r.chatService.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return r.resolvers(hooks), nil
+}
+
+func (r *rootResolver) InternalListByCodebaseID(ctx context.Context, codebaseID string) ([]resolvers.ChatWebhookResolver, error) {
+	if err := r.canManageCodebase(ctx, codebaseID); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	hooks, err := r.chatService.ListByCodebaseID(ctx, codebaseID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return r.resolvers(hooks), nil
+}
+
+func (r *rootResolver) CreateChatWebhook(ctx context.Context, args resolvers.CreateChatWebhookArgs) (resolvers.ChatWebhookResolver, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	provider, ok := providerFromGraphQL[args.Input.Provider]
+	if !ok {
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "unknown provider")
+	}
+
+	hook := &chat.Webhook{
+		Provider:  provider,
+		URL:       args.Input.URL,
+		CreatedBy: userID,
+	}
+	if args.Input.CodebaseID != nil {
+		codebaseID := string(*args.Input.CodebaseID)
+		hook.CodebaseID = &codebaseID
+	} else {
+		hook.UserID = &userID
+	}
+
+	if err := r.canManage(ctx, hook); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.chatService.Create(ctx, hook); err != nil {
+		return nil, validationError(err)
+	}
+
+	return &webhookResolver{root: r, webhook: hook}, nil
+}
+
+func (r *rootResolver) DeleteChatWebhook(ctx context.Context, args resolvers.DeleteChatWebhookArgs) (resolvers.ChatWebhookResolver, error) {
+	hook, err := r.chatService.Get(ctx, string(args.Input.ID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.canManage(ctx, hook); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.chatService.Delete(ctx, hook); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	return &webhookResolver{root: r, webhook: hook}, nil
+}
+
+func (r *rootResolver) resolvers(hooks []*chat.Webhook) []resolvers.ChatWebhookResolver {
+	res := make([]resolvers.ChatWebhookResolver, 0, len(hooks))
+	for _, hook := range hooks {
+		res = append(res, &webhookResolver{root: r, webhook: hook})
+	}
+	return res
+}
+
+func validationError(err error) error {
+	switch {
+	case errors.Is(err, chat.ErrInvalidURL):
+		return gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "the url must be a valid http or https url")
+	case errors.Is(err, chat.ErrInvalidProvider):
+		return gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "unknown provider")
+	default:
+		return gqlerrors.Error(err)
+	}
+}
+
+type webhookResolver struct {
+	root    *rootResolver
+	webhook *chat.Webhook
+}
+
+func (r *webhookResolver) ID() graphql.ID {
+	return graphql.ID(r.webhook.ID)
+}
+
+func (r *webhookResolver) Provider() string {
+	return providerToGraphQL[r.webhook.Provider]
+}
+
+func (r *webhookResolver) Host() string {
+	return r.webhook.Host()
+}
+
+func (r *webhookResolver) Codebase(ctx context.Context) (resolvers.CodebaseResolver, error) {
+	if r.webhook.CodebaseID == nil {
+		return nil, nil
+	}
+	id := graphql.ID(*r.webhook.CodebaseID)
+	return (*r.root.codebaseResolver).Codebase(ctx, resolvers.CodebaseArgs{ID: &id})
+}
+
+func (r *webhookResolver) CreatedAt() int32 {
+	return int32(r.webhook.CreatedAt.Unix())
+}
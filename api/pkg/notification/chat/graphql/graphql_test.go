@@ -0,0 +1,57 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"getsturdy.com/api/pkg/auth"
+	service_auth "getsturdy.com/api/pkg/auth/service"
+	"getsturdy.com/api/pkg/codebase"
+	service_codebase "getsturdy.com/api/pkg/codebase/service"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/internal/inmemory"
+	"getsturdy.com/api/pkg/organization"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanManageCodebase_roles(t *testing.T) {
+	codebaseRepo := inmemory.NewInMemoryCodebaseRepo()
+	codebaseUserRepo := inmemory.NewInMemoryCodebaseUserRepo()
+	codebaseService := service_codebase.New(codebaseRepo, codebaseUserRepo, nil, nil, nil, nil, nil, nil)
+	root := &rootResolver{
+		authService:     service_auth.New(codebaseService, nil, nil, nil, nil, nil, nil, nil),
+		codebaseService: codebaseService,
+	}
+
+	cb := codebase.Codebase{ID: uuid.NewString()}
+	assert.NoError(t, codebaseRepo.Create(cb))
+
+	for role, allowed := range map[organization.Role]bool{
+		organization.RoleOwner:  true,
+		organization.RoleAdmin:  true,
+		organization.RoleMember: false,
+	} {
+		userID := uuid.NewString()
+		assert.NoError(t, codebaseUserRepo.Create(codebase.CodebaseUser{ID: uuid.NewString(), CodebaseID: cb.ID, UserID: userID, Role: role}))
+		ctx := auth.NewContext(context.Background(), &auth.Subject{ID: userID, Type: auth.SubjectUser})
+
+		if allowed {
+			assert.NoError(t, root.canManageCodebase(ctx, cb.ID), role)
+			continue
+		}
+
+		assert.ErrorIs(t, root.canManageCodebase(ctx, cb.ID), auth.ErrForbidden, role)
+		_, err := root.InternalListByCodebaseID(ctx, cb.ID)
+		assert.ErrorIs(t, err, auth.ErrForbidden, role)
+		codebaseID := graphql.ID(cb.ID)
+		_, err = root.CreateChatWebhook(ctx, resolvers.CreateChatWebhookArgs{Input: resolvers.CreateChatWebhookInput{
+			Provider:   "Slack",
+			URL:        "https://hooks.slack.com/services/x",
+			CodebaseID: &codebaseID,
+		}})
+		assert.ErrorIs(t, err, auth.ErrForbidden, role)
+	}
+}
@@ -0,0 +1,161 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"getsturdy.com/api/pkg/codebase"
+	"getsturdy.com/api/pkg/emails/transactional/templates"
+	"getsturdy.com/api/pkg/review"
+)
+
+// Part is a piece of a message, it's rendered as a link if URL is set.
+type Part struct {
+	Text string
+	URL  string
+}
+
+// Message is a chat message, independent of the provider.
+type Message struct {
+	Parts []Part
+	// Quote is shown below the message, if set
+	Quote string
+}
+
+// Summary returns the message as plain text.
+func (m *Message) Summary() string {
+	var b strings.Builder
+	for _, p := range m.Parts {
+		b.WriteString(p.Text)
+	}
+	return b.String()
+}
+
+func text(s string) Part {
+	return Part{Text: s}
+}
+
+func link(s, url string) Part {
+	return Part{Text: s, URL: url}
+}
+
+// NewMessage renders a message from the data of a notification, see transactional.NotificationContent.
+//
+// The messages are written for a shared channel, the recipient of the notification is not addressed.
+func NewMessage(webURL string, data interface{}) (*Message, error) {
+	webURL = strings.TrimSuffix(webURL, "/")
+	codebaseURL := func(cb *codebase.Codebase) string {
+		return fmt.Sprintf("%s/%s", webURL, cb.GenerateSlug())
+	}
+
+	switch data := data.(type) {
+	case *templates.NotificationCommentTemplateData:
+		msg := &Message{Quote: data.Comment.Message}
+		if data.Parent != nil {
+			msg.Parts = append(msg.Parts, text(fmt.Sprintf("%s replied to %s's comment", data.Author.Name, data.Parent.Author.Name)))
+			data = data.Parent
+		} else {
+			msg.Parts = append(msg.Parts, text(fmt.Sprintf("%s commented", data.Author.Name)))
+		}
+		switch {
+		case data.Change != nil:
+			title := string(data.Change.ID)
+			if data.Change.Title != nil {
+				title = *data.Change.Title
+			}
+			msg.Parts = append(msg.Parts, text(" on "), link(title, fmt.Sprintf("%s/%s", codebaseURL(data.Codebase), data.Change.ID)))
+		case data.Workspace != nil:
+			msg.Parts = append(msg.Parts, text(" on "), link(data.Workspace.NameOrFallback(), fmt.Sprintf("%s/%s", codebaseURL(data.Codebase), data.Workspace.ID)))
+		}
+		msg.Parts = append(msg.Parts, text(" in "), link(data.Codebase.Name, codebaseURL(data.Codebase)))
+		return msg, nil
+	case *templates.NotificationReviewTemplateData:
+		var verb string
+		switch data.Review.Grade {
+		case review.ReviewGradeApprove:
+			verb = "approved"
+		case review.ReviewGradeReject:
+			verb = "has some feedback on"
+		default:
+			verb = "reviewed"
+		}
+		return &Message{Parts: []Part{
+			text(fmt.Sprintf("%s %s ", data.Author.Name, verb)),
+			link(data.Workspace.NameOrFallback(), fmt.Sprintf("%s/%s", codebaseURL(data.Codebase), data.Workspace.ID)),
+			text(" in "),
+			link(data.Codebase.Name, codebaseURL(data.Codebase)),
+		}}, nil
+	case *templates.NotificationRequestedReviewTemplateData:
+		return &Message{Parts: []Part{
+			text(fmt.Sprintf("%s asked %s for feedback on ", data.RequestedBy.Name, data.Reviewer.Name)),
+			link(data.Workspace.NameOrFallback(), fmt.Sprintf("%s/%s", codebaseURL(data.Codebase), data.Workspace.ID)),
+			text(" in "),
+			link(data.Codebase.Name, codebaseURL(data.Codebase)),
+		}}, nil
+	case *templates.NotificationNewSuggestionTemplateData:
+		return &Message{Parts: []Part{
+			text(fmt.Sprintf("%s made a suggestion on ", data.Author.Name)),
+			link(data.Workspace.NameOrFallback(), fmt.Sprintf("%s/%s", codebaseURL(data.Codebase), data.Workspace.ID)),
+			text(" in "),
+			link(data.Codebase.Name, codebaseURL(data.Codebase)),
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported notification data: %T", data)
+	}
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, "*", `\*`, "_", `\_`, "`", "\\`")
+
+// Payload returns the JSON body that posts the message to an incoming webhook of the provider.
+func (m *Message) Payload(provider Provider) ([]byte, error) {
+	switch provider {
+	case ProviderSlack:
+		// https://api.slack.com/reference/surfaces/formatting
+		var b strings.Builder
+		for _, p := range m.Parts {
+			if p.URL == "" {
+				b.WriteString(slackEscaper.Replace(p.Text))
+			} else {
+				fmt.Fprintf(&b, "<%s|%s>", p.URL, slackEscaper.Replace(p.Text))
+			}
+		}
+		quote(&b, slackEscaper.Replace(m.Quote))
+		return json.Marshal(map[string]string{"text": b.String()})
+	case ProviderMattermost, ProviderTeams:
+		var b strings.Builder
+		for _, p := range m.Parts {
+			if p.URL == "" {
+				b.WriteString(markdownEscaper.Replace(p.Text))
+			} else {
+				fmt.Fprintf(&b, "[%s](%s)", markdownEscaper.Replace(p.Text), p.URL)
+			}
+		}
+		quote(&b, markdownEscaper.Replace(m.Quote))
+		if provider == ProviderMattermost {
+			// https://docs.mattermost.com/developer/webhooks-incoming.html
+			return json.Marshal(map[string]string{"text": b.String()})
+		}
+		// https://docs.microsoft.com/en-us/outlook/actionable-messages/message-card-reference
+		return json.Marshal(map[string]string{
+			"@type":    "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary":  m.Summary(),
+			"text":     b.String(),
+		})
+	default:
+		return nil, ErrInvalidProvider
+	}
+}
+
+func quote(b *strings.Builder, s string) {
+	if s == "" {
+		return
+	}
+	for _, line := range strings.Split(s, "\n") {
+		b.WriteString("\n> ")
+		b.WriteString(line)
+	}
+}
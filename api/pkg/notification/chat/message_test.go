@@ -0,0 +1,83 @@
+package chat_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"getsturdy.com/api/pkg/codebase"
+	"getsturdy.com/api/pkg/emails/transactional/templates"
+	"getsturdy.com/api/pkg/notification/chat"
+	"getsturdy.com/api/pkg/review"
+	"getsturdy.com/api/pkg/users"
+	"getsturdy.com/api/pkg/workspaces"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMessage_review(t *testing.T) {
+	workspaceName := "Fix <login>"
+	msg, err := chat.NewMessage("https://example.com/", &templates.NotificationReviewTemplateData{
+		User:      &users.User{Name: "Recipient"},
+		Author:    &users.User{Name: "Alice"},
+		Review:    &review.Review{Grade: review.ReviewGradeApprove},
+		Workspace: &workspaces.Workspace{ID: "ws", Name: &workspaceName},
+		Codebase:  &codebase.Codebase{Name: "sturdy", ShortCodebaseID: "abc"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice approved Fix <login> in sturdy", msg.Summary())
+
+	cases := []struct {
+		provider chat.Provider
+		expected map[string]string
+	}{
+		{
+			provider: chat.ProviderSlack,
+			expected: map[string]string{
+				"text": "Alice approved <https://example.com/sturdy-abc/ws|Fix &lt;login&gt;> in <https://example.com/sturdy-abc|sturdy>",
+			},
+		},
+		{
+			provider: chat.ProviderMattermost,
+			expected: map[string]string{
+				"text": "Alice approved [Fix <login>](https://example.com/sturdy-abc/ws) in [sturdy](https://example.com/sturdy-abc)",
+			},
+		},
+		{
+			provider: chat.ProviderTeams,
+			expected: map[string]string{
+				"@type":    "MessageCard",
+				"@context": "https://schema.org/extensions",
+				"summary":  "Alice approved Fix <login> in sturdy",
+				"text":     "Alice approved [Fix <login>](https://example.com/sturdy-abc/ws) in [sturdy](https://example.com/sturdy-abc)",
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.provider), func(t *testing.T) {
+			payload, err := msg.Payload(tc.provider)
+			require.NoError(t, err)
+			var got map[string]string
+			require.NoError(t, json.Unmarshal(payload, &got))
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+}
+
+func TestPayload_quote(t *testing.T) {
+	msg := &chat.Message{
+		Parts: []chat.Part{{Text: "Alice commented"}},
+		Quote: "looks *good*\nship it",
+	}
+
+	payload, err := msg.Payload(chat.ProviderMattermost)
+	require.NoError(t, err)
+	var got map[string]string
+	require.NoError(t, json.Unmarshal(payload, &got))
+	assert.Equal(t, "Alice commented\n> looks \\*good\\*\n> ship it", got["text"])
+}
+
+func TestPayload_invalidProvider(t *testing.T) {
+	_, err := (&chat.Message{}).Payload("irc")
+	assert.ErrorIs(t, err, chat.ErrInvalidProvider)
+}
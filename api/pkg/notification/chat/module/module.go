@@ -0,0 +1,16 @@
+package module
+
+import (
+	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/notification/chat/db"
+	"getsturdy.com/api/pkg/notification/chat/graphql"
+	"getsturdy.com/api/pkg/notification/chat/service"
+	"getsturdy.com/api/pkg/notification/chat/worker"
+)
+
+func Module(c *di.Container) {
+	c.Import(db.Module)
+	c.Import(graphql.Module)
+	c.Import(service.Module)
+	c.Import(worker.Module)
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"getsturdy.com/api/pkg/emails/transactional"
+	"getsturdy.com/api/pkg/notification"
+	"getsturdy.com/api/pkg/notification/chat"
+	db_chat "getsturdy.com/api/pkg/notification/chat/db"
+	service_notification "getsturdy.com/api/pkg/notification/service"
+	"getsturdy.com/api/pkg/outbound"
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/names"
+	"getsturdy.com/api/pkg/users"
+	db_user "getsturdy.com/api/pkg/users/db"
+	"getsturdy.com/api/pkg/web"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// postTimeout is the max time to wait for a chat service to accept a message
+const postTimeout = 5 * time.Second
+
+// supportedTypes are the notification types that can be rendered as chat messages
+var supportedTypes = map[notification.NotificationType]bool{
+	notification.CommentNotificationType:         true,
+	notification.ReviewNotificationType:          true,
+	notification.RequestedReviewNotificationType: true,
+	notification.NewSuggestionNotificationType:   true,
+}
+
+// QueueEntry is a notification to post to chat webhooks. Entries with a UserID are posted to the webhooks of the user,
+// and entries without to the webhooks of the codebase.
+type QueueEntry struct {
+	NotificationID   string                        `json:"notification_id,omitempty"`
+	UserID           string                        `json:"user_id,omitempty"`
+	CodebaseID       string                        `json:"codebase_id"`
+	NotificationType notification.NotificationType `json:"notification_type"`
+	ReferenceID      string                        `json:"reference_id"`
+}
+
+type Service struct {
+	logger *zap.Logger
+	webURL string
+	client *http.Client
+	queue  queue.Queue
+
+	repo                 db_chat.Repository
+	userRepo             db_user.Repository
+	preferencesService   *service_notification.Preferences
+	transactionalService *transactional.Sender
+}
+
+func New(
+	cfg *web.Configuration,
+	logger *zap.Logger,
+	queue queue.Queue,
+	repo db_chat.Repository,
+	userRepo db_user.Repository,
+	preferencesService *service_notification.Preferences,
+	transactionalService *transactional.Sender,
+) *Service {
+	return &Service{
+		logger: logger.Named("chat"),
+		webURL: cfg.URL,
+		client: newClient(),
+		queue:  queue,
+
+		repo:                 repo,
+		userRepo:             userRepo,
+		preferencesService:   preferencesService,
+		transactionalService: transactionalService,
+	}
+}
+
+func (s *Service) Create(ctx context.Context, webhook *chat.Webhook) error {
+	webhook.ID = uuid.NewString()
+	webhook.CreatedAt = time.Now()
+	if err := webhook.Validate(); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) Delete(ctx context.Context, webhook *chat.Webhook) error {
+	now := time.Now()
+	webhook.DeletedAt = &now
+	if err := s.repo.Update(ctx, webhook); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*chat.Webhook, error) {
+	return s.repo.Get(ctx, id)
+}
+
+func (s *Service) ListByUserID(ctx context.Context, userID string) ([]*chat.Webhook, error) {
+	return s.repo.ListByUserID(ctx, userID)
+}
+
+func (s *Service) ListByCodebaseID(ctx context.Context, codebaseID string) ([]*chat.Webhook, error) {
+	return s.repo.ListByCodebaseID(ctx, codebaseID)
+}
+
+// Enqueue enqueues the notification to be posted to the chat webhooks of its recipient. Notifications of types that
+// can't be rendered as chat messages are ignored.
+func (s *Service) Enqueue(ctx context.Context, notif *notification.Notification) error {
+	return s.enqueue(ctx, &QueueEntry{
+		NotificationID:   notif.ID,
+		UserID:           notif.UserID,
+		CodebaseID:       notif.CodebaseID,
+		NotificationType: notif.NotificationType,
+		ReferenceID:      notif.ReferenceID,
+	})
+}
+
+// EnqueueCodebase enqueues a notification to be posted to the chat webhooks of the codebase, whether or not any
+// member of the codebase is notified. Notifications of types that can't be rendered as chat messages are ignored.
+func (s *Service) EnqueueCodebase(ctx context.Context, codebaseID string, notificationType notification.NotificationType, referenceID string) error {
+	return s.enqueue(ctx, &QueueEntry{
+		CodebaseID:       codebaseID,
+		NotificationType: notificationType,
+		ReferenceID:      referenceID,
+	})
+}
+
+func (s *Service) enqueue(ctx context.Context, entry *QueueEntry) error {
+	if !supportedTypes[entry.NotificationType] {
+		return nil
+	}
+	if err := s.queue.Publish(ctx, names.NotificationChat, entry); err != nil {
+		return fmt.Errorf("failed to enqueue chat notification: %w", err)
+	}
+	return nil
+}
+
+// Post posts the notification of the entry. Notifications to users are posted to the chat webhooks of the user, if
+// the user has the chat channel enabled for the notification type. Notifications to codebases are posted to the chat
+// webhooks of the codebase, unless they have already been posted there. Messages to codebase webhooks are rendered
+// without the recipient, as they are read by all the members of the codebase.
+//
+// Failures to post to individual webhooks are logged, and do not fail the notification.
+func (s *Service) Post(ctx context.Context, entry *QueueEntry) error {
+	if !supportedTypes[entry.NotificationType] {
+		return nil
+	}
+
+	notif := &notification.Notification{
+		ID:               entry.NotificationID,
+		UserID:           entry.UserID,
+		CodebaseID:       entry.CodebaseID,
+		NotificationType: entry.NotificationType,
+		ReferenceID:      entry.ReferenceID,
+	}
+	if entry.UserID == "" {
+		return s.postToCodebase(ctx, notif)
+	}
+	return s.postToUser(ctx, notif)
+}
+
+func (s *Service) postToUser(ctx context.Context, notif *notification.Notification) error {
+	enabled, err := s.preferencesService.IsEnabled(ctx, notif.UserID, notif.NotificationType, notification.ChannelChat)
+	if err != nil {
+		return fmt.Errorf("failed to get preferences: %w", err)
+	}
+	if !enabled {
+		return nil
+	}
+
+	webhooks, err := s.repo.ListByUserID(ctx, notif.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to list user webhooks: %w", err)
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	usr, err := s.userRepo.Get(notif.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	msg, err := s.render(ctx, usr, notif)
+	if err != nil {
+		return err
+	}
+	s.postAll(ctx, notif, msg, webhooks)
+	return nil
+}
+
+func (s *Service) postToCodebase(ctx context.Context, notif *notification.Notification) error {
+	webhooks, err := s.repo.ListByCodebaseID(ctx, notif.CodebaseID)
+	if err != nil {
+		return fmt.Errorf("failed to list codebase webhooks: %w", err)
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	// rendered before the webhooks are marked as posted, so that the entry can be retried if it fails
+	msg, err := s.render(ctx, nil, notif)
+	if err != nil {
+		return err
+	}
+
+	var targets []*chat.Webhook
+	for _, webhook := range webhooks {
+		first, err := s.repo.MarkPosted(ctx, webhook.ID, notif.NotificationType, notif.ReferenceID)
+		if err != nil {
+			return fmt.Errorf("failed to mark notification as posted: %w", err)
+		}
+		if first {
+			targets = append(targets, webhook)
+		}
+	}
+	s.postAll(ctx, notif, msg, targets)
+	return nil
+}
+
+// render renders the notification for the user, or for everyone if usr is nil.
+func (s *Service) render(ctx context.Context, usr *users.User, notif *notification.Notification) (*chat.Message, error) {
+	content, err := s.transactionalService.NotificationContent(ctx, usr, notif)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := chat.NewMessage(s.webURL, content.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render message: %w", err)
+	}
+	return msg, nil
+}
+
+// postAll posts the message to the webhooks.
+func (s *Service) postAll(ctx context.Context, notif *notification.Notification, msg *chat.Message, webhooks []*chat.Webhook) {
+	for _, webhook := range webhooks {
+		if err := s.post(ctx, webhook, msg); err != nil {
+			s.logger.Warn("failed to post notification",
+				zap.String("webhook_id", webhook.ID),
+				zap.String("notification_id", notif.ID),
+				zap.String("reference_id", notif.ReferenceID),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// newClient returns the client that messages are posted with. It can only connect to public addresses, and doesn't
+// follow redirects.
+func newClient() *http.Client {
+	client := outbound.NewClient(postTimeout)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return client
+}
+
+func (s *Service) post(ctx context.Context, webhook *chat.Webhook, msg *chat.Message) error {
+	payload, err := msg.Payload(webhook.Provider)
+	if err != nil {
+		return fmt.Errorf("failed to format message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	switch {
+	case err == nil:
+	case errors.Is(err, outbound.ErrForbiddenAddress):
+		return errors.New("the url resolves to an internal address")
+	default:
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
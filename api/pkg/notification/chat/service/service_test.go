@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/codebase"
+	db_codebase "getsturdy.com/api/pkg/codebase/db"
+	"getsturdy.com/api/pkg/emails/transactional"
+	"getsturdy.com/api/pkg/notification"
+	"getsturdy.com/api/pkg/notification/chat"
+	db_chat "getsturdy.com/api/pkg/notification/chat/db"
+	db_notification "getsturdy.com/api/pkg/notification/db"
+	service_notification "getsturdy.com/api/pkg/notification/service"
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/names"
+	"getsturdy.com/api/pkg/review"
+	db_review "getsturdy.com/api/pkg/review/db"
+	"getsturdy.com/api/pkg/users"
+	db_user "getsturdy.com/api/pkg/users/db"
+	"getsturdy.com/api/pkg/web"
+	"getsturdy.com/api/pkg/workspaces"
+	db_workspaces "getsturdy.com/api/pkg/workspaces/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var _ queue.Queue = &recordingQueue{}
+
+type recordingQueue struct {
+	published []*QueueEntry
+}
+
+func (q *recordingQueue) Publish(_ context.Context, name names.IncompleteQueueName, msg interface{}) error {
+	if name == names.NotificationChat {
+		q.published = append(q.published, msg.(*QueueEntry))
+	}
+	return nil
+}
+
+func (q *recordingQueue) Subscribe(context.Context, names.IncompleteQueueName, chan<- queue.Message) error {
+	return nil
+}
+
+type testReceiver struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	received []map[string]string
+}
+
+func newTestReceiver(t *testing.T) *testReceiver {
+	r := &testReceiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		assert.NoError(t, err)
+		var payload map[string]string
+		assert.NoError(t, json.Unmarshal(body, &payload))
+		r.mu.Lock()
+		r.received = append(r.received, payload)
+		r.mu.Unlock()
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// client returns a client that connects to the receiver, whatever the host of the url is. The receiver is on a
+// loopback address, that the client of the service refuses to connect to.
+func (r *testReceiver) client() *http.Client {
+	addr := r.Listener.Addr().String()
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, addr)
+			},
+		},
+	}
+}
+
+func (r *testReceiver) Received() []map[string]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]map[string]string(nil), r.received...)
+}
+
+type fixture struct {
+	service     *Service
+	queue       *recordingQueue
+	preferences *service_notification.Preferences
+
+	author    *users.User
+	recipient *users.User
+	other     *users.User
+	cb        codebase.Codebase
+	review    review.Review
+}
+
+func setup(t *testing.T) *fixture {
+	userRepo := db_user.NewMemory()
+	codebaseRepo := db_codebase.NewMemory()
+	workspaceRepo := db_workspaces.NewMemory()
+	reviewRepo := db_review.NewMemory()
+
+	f := &fixture{
+		author:    &users.User{ID: uuid.NewString(), Name: "Alice"},
+		recipient: &users.User{ID: uuid.NewString(), Name: "Bob"},
+		other:     &users.User{ID: uuid.NewString(), Name: "Carol"},
+		cb:        codebase.Codebase{ID: uuid.NewString(), Name: "sturdy", ShortCodebaseID: "abc"},
+	}
+	for _, u := range []*users.User{f.author, f.recipient, f.other} {
+		require.NoError(t, userRepo.Create(u))
+	}
+	require.NoError(t, codebaseRepo.Create(f.cb))
+
+	workspaceName := "Fix login"
+	ws := workspaces.Workspace{ID: uuid.NewString(), CodebaseID: f.cb.ID, UserID: f.recipient.ID, Name: &workspaceName}
+	require.NoError(t, workspaceRepo.Create(ws))
+
+	f.review = review.Review{
+		ID:          uuid.NewString(),
+		UserID:      f.author.ID,
+		RequestedBy: &f.recipient.ID,
+		CodebaseID:  f.cb.ID,
+		WorkspaceID: ws.ID,
+		Grade:       review.ReviewGradeApprove,
+		CreatedAt:   time.Now(),
+	}
+	require.NoError(t, reviewRepo.Create(context.Background(), f.review))
+
+	f.preferences = service_notification.NewPreferences(db_notification.NewInMemoryPreferenceRepository())
+	transactionalService := transactional.New(
+		zap.NewNop(),
+		nil,
+		userRepo,
+		nil,
+		nil,
+		codebaseRepo,
+		workspaceRepo,
+		nil,
+		reviewRepo,
+		nil,
+		nil,
+		nil,
+		f.preferences,
+		nil,
+	)
+
+	f.queue = &recordingQueue{}
+	f.service = New(
+		&web.Configuration{URL: "https://example.com"},
+		zap.NewNop(),
+		f.queue,
+		db_chat.NewMemory(),
+		userRepo,
+		f.preferences,
+		transactionalService,
+	)
+	return f
+}
+
+func (f *fixture) notification(recipient *users.User) *notification.Notification {
+	return f.notificationOfType(recipient, notification.ReviewNotificationType)
+}
+
+// entry returns the queue entry of the notification to the recipient, or to the codebase if recipient is nil.
+func (f *fixture) entry(recipient *users.User) *QueueEntry {
+	return f.entryOfType(recipient, notification.ReviewNotificationType)
+}
+
+func (f *fixture) entryOfType(recipient *users.User, notificationType notification.NotificationType) *QueueEntry {
+	if recipient == nil {
+		return &QueueEntry{CodebaseID: f.cb.ID, NotificationType: notificationType, ReferenceID: f.review.ID}
+	}
+	notif := f.notificationOfType(recipient, notificationType)
+	return &QueueEntry{
+		NotificationID:   notif.ID,
+		UserID:           notif.UserID,
+		CodebaseID:       notif.CodebaseID,
+		NotificationType: notif.NotificationType,
+		ReferenceID:      notif.ReferenceID,
+	}
+}
+
+func TestEnqueue(t *testing.T) {
+	ctx := context.Background()
+	f := setup(t)
+
+	notif := f.notification(f.recipient)
+	require.NoError(t, f.service.Enqueue(ctx, notif))
+	require.NoError(t, f.service.EnqueueCodebase(ctx, f.cb.ID, notification.ReviewNotificationType, f.review.ID))
+
+	// notifications that can't be rendered as chat messages are not enqueued
+	require.NoError(t, f.service.Enqueue(ctx, f.notificationOfType(f.recipient, notification.AutoRevertNotificationType)))
+	require.NoError(t, f.service.EnqueueCodebase(ctx, f.cb.ID, notification.AutoRevertNotificationType, f.review.ID))
+
+	assert.Equal(t, []*QueueEntry{
+		{
+			NotificationID:   notif.ID,
+			UserID:           f.recipient.ID,
+			CodebaseID:       f.cb.ID,
+			NotificationType: notification.ReviewNotificationType,
+			ReferenceID:      f.review.ID,
+		},
+		{
+			CodebaseID:       f.cb.ID,
+			NotificationType: notification.ReviewNotificationType,
+			ReferenceID:      f.review.ID,
+		},
+	}, f.queue.published)
+}
+
+func (f *fixture) notificationOfType(recipient *users.User, notificationType notification.NotificationType) *notification.Notification {
+	return &notification.Notification{
+		ID:               uuid.NewString(),
+		CodebaseID:       f.cb.ID,
+		UserID:           recipient.ID,
+		NotificationType: notificationType,
+		ReferenceID:      f.review.ID,
+		CreatedAt:        time.Now(),
+	}
+}
+
+func TestPost_userWebhook(t *testing.T) {
+	ctx := context.Background()
+	f := setup(t)
+	receiver := newTestReceiver(t)
+	f.service.client = receiver.client()
+
+	require.NoError(t, f.service.Create(ctx, &chat.Webhook{
+		UserID:    &f.recipient.ID,
+		Provider:  chat.ProviderSlack,
+		URL:       "https://hooks.example.com/user",
+		CreatedBy: f.recipient.ID,
+	}))
+
+	require.NoError(t, f.service.Post(ctx, f.entry(f.recipient)))
+	if assert.Len(t, receiver.Received(), 1) {
+		assert.Equal(t,
+			"Alice approved <https://example.com/sturdy-abc/"+f.review.WorkspaceID+"|Fix login> in <https://example.com/sturdy-abc|sturdy>",
+			receiver.Received()[0]["text"],
+		)
+	}
+
+	// notifications of other users are not posted
+	require.NoError(t, f.service.Post(ctx, f.entry(f.other)))
+	assert.Len(t, receiver.Received(), 1)
+
+	// disabled in the preferences
+	_, err := f.preferences.Update(ctx, f.recipient.ID, notification.ReviewNotificationType, notification.ChannelChat, false)
+	require.NoError(t, err)
+	require.NoError(t, f.service.Post(ctx, f.entry(f.recipient)))
+	assert.Len(t, receiver.Received(), 1)
+}
+
+func TestPost_codebaseWebhook(t *testing.T) {
+	ctx := context.Background()
+	f := setup(t)
+	receiver := newTestReceiver(t)
+	f.service.client = receiver.client()
+
+	require.NoError(t, f.service.Create(ctx, &chat.Webhook{
+		CodebaseID: &f.cb.ID,
+		Provider:   chat.ProviderMattermost,
+		URL:        "https://hooks.example.com/codebase",
+		CreatedBy:  f.author.ID,
+	}))
+
+	// notifications of users are not posted to the codebase
+	require.NoError(t, f.service.Post(ctx, f.entry(f.recipient)))
+	assert.Empty(t, receiver.Received())
+
+	// the same review is enqueued twice, it's posted to the codebase once
+	require.NoError(t, f.service.Post(ctx, f.entry(nil)))
+	require.NoError(t, f.service.Post(ctx, f.entry(nil)))
+	assert.Len(t, receiver.Received(), 1)
+
+	// the message names the reviewer from the review, not a recipient
+	require.NoError(t, f.service.Post(ctx, f.entryOfType(nil, notification.RequestedReviewNotificationType)))
+	if assert.Len(t, receiver.Received(), 2) {
+		assert.Equal(t,
+			"Bob asked Alice for feedback on [Fix login](https://example.com/sturdy-abc/"+f.review.WorkspaceID+") in [sturdy](https://example.com/sturdy-abc)",
+			receiver.Received()[1]["text"],
+		)
+	}
+
+	// deleted webhooks receive nothing
+	hooks, err := f.service.ListByCodebaseID(ctx, f.cb.ID)
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	require.NoError(t, f.service.Delete(ctx, hooks[0]))
+	hooks, err = f.service.ListByCodebaseID(ctx, f.cb.ID)
+	require.NoError(t, err)
+	assert.Empty(t, hooks)
+}
+
+func TestCreate_validation(t *testing.T) {
+	ctx := context.Background()
+	f := setup(t)
+
+	err := f.service.Create(ctx, &chat.Webhook{UserID: &f.recipient.ID, Provider: chat.ProviderSlack, URL: "ftp://example.com"})
+	assert.ErrorIs(t, err, chat.ErrInvalidURL)
+
+	err = f.service.Create(ctx, &chat.Webhook{UserID: &f.recipient.ID, Provider: chat.ProviderSlack, URL: "http://169.254.169.254/latest/meta-data"})
+	assert.ErrorIs(t, err, chat.ErrInvalidURL)
+
+	err = f.service.Create(ctx, &chat.Webhook{UserID: &f.recipient.ID, Provider: "irc", URL: "https://example.com"})
+	assert.ErrorIs(t, err, chat.ErrInvalidProvider)
+
+	err = f.service.Create(ctx, &chat.Webhook{UserID: &f.recipient.ID, CodebaseID: &f.cb.ID, Provider: chat.ProviderSlack, URL: "https://example.com"})
+	assert.ErrorIs(t, err, chat.ErrInvalidTarget)
+}
+
+func TestPost_internalAddress(t *testing.T) {
+	ctx := context.Background()
+	f := setup(t)
+	receiver := newTestReceiver(t)
+
+	// the url is validated when the webhook is saved, but hostnames can resolve to internal addresses
+	webhook := &chat.Webhook{
+		ID:        uuid.NewString(),
+		UserID:    &f.recipient.ID,
+		Provider:  chat.ProviderSlack,
+		URL:       receiver.URL,
+		CreatedBy: f.recipient.ID,
+	}
+	require.NoError(t, f.service.repo.Create(ctx, webhook))
+
+	require.NoError(t, f.service.Post(ctx, f.entry(f.recipient)))
+	assert.Empty(t, receiver.Received())
+}
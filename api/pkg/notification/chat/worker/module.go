@@ -0,0 +1,7 @@
+package worker
+
+import "getsturdy.com/api/pkg/di"
+
+func Module(c *di.Container) {
+	c.Register(NewQueue)
+}
@@ -0,0 +1,79 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	service_chat "getsturdy.com/api/pkg/notification/chat/service"
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/names"
+
+	"go.uber.org/zap"
+)
+
+// Queue posts the notifications that are enqueued by the chat service, separately from the other channels, so that
+// failures to email a user don't stop notifications from being posted to chats.
+type Queue struct {
+	logger  *zap.Logger
+	queue   queue.Queue
+	service *service_chat.Service
+}
+
+func NewQueue(
+	logger *zap.Logger,
+	queue queue.Queue,
+	service *service_chat.Service,
+) *Queue {
+	return &Queue{
+		logger:  logger.Named("chatNotificationsQueue"),
+		queue:   queue,
+		service: service,
+	}
+}
+
+func (q *Queue) Start(ctx context.Context) error {
+	messages := make(chan queue.Message)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				q.logger.Error("panic in runner", zap.String("panic", fmt.Sprintf("%v", rec)))
+			}
+		}()
+
+		for msg := range messages {
+			t0 := time.Now()
+
+			m := &service_chat.QueueEntry{}
+			if err := msg.As(m); err != nil {
+				q.logger.Error("failed to decode message", zap.Error(err))
+				continue
+			}
+			logger := q.logger.With(
+				zap.String("notification_id", m.NotificationID),
+				zap.String("codebase_id", m.CodebaseID),
+				zap.String("reference_id", m.ReferenceID),
+			)
+
+			if err := q.service.Post(context.Background(), m); err != nil {
+				logger.Error("failed to post notification", zap.Error(err))
+				continue
+			}
+
+			if err := msg.Ack(); err != nil {
+				logger.Error("failed to ack message", zap.Error(err))
+				continue
+			}
+
+			logger.Info("notification posted", zap.Duration("duration", time.Since(t0)))
+		}
+	}()
+
+	q.logger.Info("starting queue", zap.Stringer("queue_name", names.NotificationChat))
+	if err := q.queue.Subscribe(ctx, names.NotificationChat, messages); err != nil {
+		return fmt.Errorf("could not subscribe to queue: %w", err)
+	}
+	q.logger.Info("queue stoped", zap.Stringer("queue_name", names.NotificationChat))
+
+	return nil
+}
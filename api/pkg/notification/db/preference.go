@@ -9,17 +9,22 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-type PreferenceRepository struct {
+type PreferenceRepository interface {
+	Upsert(context.Context, *notification.Preference) error
+	ListByUserID(ctx context.Context, userID string) ([]*notification.Preference, error)
+}
+
+type preferenceRepository struct {
 	db *sqlx.DB
 }
 
-func NewPeferenceRepository(db *sqlx.DB) *PreferenceRepository {
-	return &PreferenceRepository{
+func NewPeferenceRepository(db *sqlx.DB) PreferenceRepository {
+	return &preferenceRepository{
 		db: db,
 	}
 }
 
-func (repo *PreferenceRepository) Upsert(ctx context.Context, preference *notification.Preference) error {
+func (repo *preferenceRepository) Upsert(ctx context.Context, preference *notification.Preference) error {
 	if _, err := repo.db.NamedExecContext(ctx, `INSERT INTO notification_preferences
 		(user_id, type, channel, enabled)
 		VALUES
@@ -32,7 +37,7 @@ func (repo *PreferenceRepository) Upsert(ctx context.Context, preference *notifi
 	return nil
 }
 
-func (repo *PreferenceRepository) ListByUserID(ctx context.Context, userID string) ([]*notification.Preference, error) {
+func (repo *preferenceRepository) ListByUserID(ctx context.Context, userID string) ([]*notification.Preference, error) {
 	var res []*notification.Preference
 	if err := repo.db.SelectContext(ctx, &res, `SELECT user_id, type, channel, enabled
 		FROM notification_preferences
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"getsturdy.com/api/pkg/notification"
+)
+
+var _ PreferenceRepository = &inMemoryPreferences{}
+
+type preferenceKey struct {
+	userID  string
+	typ     notification.NotificationType
+	channel notification.Channel
+}
+
+type inMemoryPreferences struct {
+	mu          sync.Mutex
+	preferences map[preferenceKey]notification.Preference
+}
+
+func NewInMemoryPreferenceRepository() *inMemoryPreferences {
+	return &inMemoryPreferences{preferences: make(map[preferenceKey]notification.Preference)}
+}
+
+func (r *inMemoryPreferences) Upsert(_ context.Context, preference *notification.Preference) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.preferences[preferenceKey{preference.UserID, preference.Type, preference.Channel}] = *preference
+	return nil
+}
+
+func (r *inMemoryPreferences) ListByUserID(_ context.Context, userID string) ([]*notification.Preference, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var res []*notification.Preference
+	for _, p := range r.preferences {
+		if p.UserID != userID {
+			continue
+		}
+		p := p
+		res = append(res, &p)
+	}
+	return res, nil
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/notification"
+	"getsturdy.com/api/pkg/notification/digest"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	GetSettings(ctx context.Context, userID string) (*digest.Settings, error)
+	// UpsertSettings saves the settings. The last_sent_at of existing settings is never moved backwards.
+	UpsertSettings(context.Context, *digest.Settings) error
+	// MarkSent sets last_sent_at of the user to sentAt, if it's still previousSentAt. It returns false if the
+	// settings have been marked by someone else.
+	MarkSent(ctx context.Context, userID string, previousSentAt, sentAt time.Time) (bool, error)
+	// ListScheduledSettings returns the settings of all users that receive digests.
+	ListScheduledSettings(context.Context) ([]*digest.Settings, error)
+
+	// ListPending returns the unarchived notifications of the user that were created after since and until (inclusive),
+	// and have not been included in a digest.
+	ListPending(ctx context.Context, userID string, since, until time.Time) ([]*notification.Notification, error)
+	// Create saves the digest, and marks the notifications as included in it.
+	Create(ctx context.Context, d *digest.Digest, notificationIDs []string) error
+}
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) Repository {
+	return &repo{db: db}
+}
+
+func (r *repo) GetSettings(ctx context.Context, userID string) (*digest.Settings, error) {
+	var res digest.Settings
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			user_id, frequency, updated_at, last_sent_at
+		FROM
+			notification_digest_settings
+		WHERE
+			user_id = $1
+	`, userID); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *repo) UpsertSettings(ctx context.Context, settings *digest.Settings) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO notification_digest_settings
+			(user_id, frequency, updated_at, last_sent_at)
+		VALUES
+			(:user_id, :frequency, :updated_at, :last_sent_at)
+		ON CONFLICT (user_id) DO UPDATE SET
+			frequency = :frequency,
+			updated_at = :updated_at,
+			last_sent_at = GREATEST(notification_digest_settings.last_sent_at, :last_sent_at)
+	`, settings); err != nil {
+		return fmt.Errorf("failed to upsert: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) MarkSent(ctx context.Context, userID string, previousSentAt, sentAt time.Time) (bool, error) {
+	var id string
+	err := r.db.GetContext(ctx, &id, `
+		UPDATE
+			notification_digest_settings
+		SET
+			last_sent_at = $1
+		WHERE
+			user_id = $2
+			AND last_sent_at = $3
+		RETURNING
+			user_id
+	`, sentAt, userID, previousSentAt)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to update: %w", err)
+	}
+}
+
+func (r *repo) ListScheduledSettings(ctx context.Context) ([]*digest.Settings, error) {
+	var res []*digest.Settings
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			user_id, frequency, updated_at, last_sent_at
+		FROM
+			notification_digest_settings
+		WHERE
+			frequency <> $1
+	`, digest.FrequencyImmediate); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *repo) ListPending(ctx context.Context, userID string, since, until time.Time) ([]*notification.Notification, error) {
+	var res []*notification.Notification
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, codebase_id, user_id, type, reference_id, created_at, archived_at, digest_id
+		FROM
+			notifications
+		WHERE
+			user_id = $1
+			AND created_at > $2
+			AND created_at <= $3
+			AND archived_at IS NULL
+			AND digest_id IS NULL
+		ORDER BY
+			created_at
+	`, userID, since, until); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *repo) Create(ctx context.Context, d *digest.Digest, notificationIDs []string) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO notification_digests
+			(id, user_id, created_at)
+		VALUES
+			(:id, :user_id, :created_at)
+	`, d); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+
+	if len(notificationIDs) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(`
+		UPDATE
+			notifications
+		SET
+			digest_id = ?
+		WHERE
+			user_id = ?
+			AND id IN (?)
+	`, d.ID, d.UserID, notificationIDs)
+	if err != nil {
+		return fmt.Errorf("failed to create query: %w", err)
+	}
+	if _, err := r.db.ExecContext(ctx, r.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("failed to update notifications: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"getsturdy.com/api/pkg/notification"
+	"getsturdy.com/api/pkg/notification/digest"
+)
+
+var _ Repository = &memory{}
+
+type memory struct {
+	mu            sync.Mutex
+	settings      map[string]digest.Settings
+	digests       []digest.Digest
+	notifications map[string]*notification.Notification
+}
+
+func NewMemory() *memory {
+	return &memory{
+		settings:      make(map[string]digest.Settings),
+		notifications: make(map[string]*notification.Notification),
+	}
+}
+
+// CreateNotification adds a notification that can be included in digests.
+func (m *memory) CreateNotification(notif *notification.Notification) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *notif
+	m.notifications[notif.ID] = &cp
+}
+
+func (m *memory) GetSettings(_ context.Context, userID string) (*digest.Settings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	settings, ok := m.settings[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &settings, nil
+}
+
+func (m *memory) UpsertSettings(_ context.Context, settings *digest.Settings) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cp := *settings
+	if existing, ok := m.settings[settings.UserID]; ok && existing.LastSentAt.After(cp.LastSentAt) {
+		cp.LastSentAt = existing.LastSentAt
+	}
+	m.settings[settings.UserID] = cp
+	return nil
+}
+
+func (m *memory) MarkSent(_ context.Context, userID string, previousSentAt, sentAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	settings, ok := m.settings[userID]
+	if !ok || !settings.LastSentAt.Equal(previousSentAt) {
+		return false, nil
+	}
+	settings.LastSentAt = sentAt
+	m.settings[userID] = settings
+	return true, nil
+}
+
+func (m *memory) ListScheduledSettings(context.Context) ([]*digest.Settings, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*digest.Settings
+	for _, settings := range m.settings {
+		if settings.Frequency == digest.FrequencyImmediate {
+			continue
+		}
+		cp := settings
+		res = append(res, &cp)
+	}
+	return res, nil
+}
+
+func (m *memory) ListPending(_ context.Context, userID string, since, until time.Time) ([]*notification.Notification, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*notification.Notification
+	for _, notif := range m.notifications {
+		if notif.UserID != userID || !notif.CreatedAt.After(since) || notif.CreatedAt.After(until) || notif.ArchivedAt != nil || notif.DigestID != nil {
+			continue
+		}
+		cp := *notif
+		res = append(res, &cp)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+	return res, nil
+}
+
+func (m *memory) Create(_ context.Context, d *digest.Digest, notificationIDs []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.digests = append(m.digests, *d)
+	for _, id := range notificationIDs {
+		if notif, ok := m.notifications[id]; ok && notif.UserID == d.UserID {
+			notif.DigestID = &d.ID
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package digest
+
+import (
+	"errors"
+	"time"
+)
+
+// Frequency decides how often notification emails are sent to a user.
+type Frequency string
+
+const (
+	FrequencyUndefined Frequency = ""
+	// FrequencyImmediate sends an email for every notification, as it happens.
+	FrequencyImmediate Frequency = "immediate"
+	// FrequencyHourly sends at most one digest email per hour.
+	FrequencyHourly Frequency = "hourly"
+	// FrequencyDaily sends at most one digest email per day.
+	FrequencyDaily Frequency = "daily"
+)
+
+var ErrInvalidFrequency = errors.New("invalid frequency")
+
+var intervals = map[Frequency]time.Duration{
+	FrequencyHourly: time.Hour,
+	FrequencyDaily:  24 * time.Hour,
+}
+
+func (f Frequency) Valid() bool {
+	return f == FrequencyImmediate || intervals[f] > 0
+}
+
+// Settings are the digest settings of a user. Users without settings receive notifications immediately.
+type Settings struct {
+	UserID    string    `db:"user_id"`
+	Frequency Frequency `db:"frequency"`
+	UpdatedAt time.Time `db:"updated_at"`
+	// LastSentAt is the time of the last digest that was sent to the user, or the time that the user started to
+	// receive digests. The next digest includes the notifications that were created after it.
+	LastSentAt time.Time `db:"last_sent_at"`
+}
+
+func DefaultSettings(userID string) *Settings {
+	return &Settings{UserID: userID, Frequency: FrequencyImmediate}
+}
+
+// IsDue returns true if it's time to send the next digest.
+func (s *Settings) IsDue(now time.Time) bool {
+	interval, ok := intervals[s.Frequency]
+	if !ok {
+		return false
+	}
+	return !now.Before(s.LastSentAt.Add(interval))
+}
+
+// Digest is a single digest email sent to a user.
+type Digest struct {
+	ID        string    `db:"id"`
+	UserID    string    `db:"user_id"`
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -0,0 +1,61 @@
+package digest_test
+
+import (
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/notification/digest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettings_IsDue(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		settings digest.Settings
+		expected bool
+	}{
+		{
+			name:     "immediate",
+			settings: digest.Settings{Frequency: digest.FrequencyImmediate, UpdatedAt: now.Add(-48 * time.Hour), LastSentAt: now.Add(-48 * time.Hour)},
+			expected: false,
+		},
+		{
+			name:     "hourly-never-sent",
+			settings: digest.Settings{Frequency: digest.FrequencyHourly, UpdatedAt: now.Add(-time.Hour), LastSentAt: now.Add(-time.Hour)},
+			expected: true,
+		},
+		{
+			name:     "hourly-recently-enabled",
+			settings: digest.Settings{Frequency: digest.FrequencyHourly, UpdatedAt: now.Add(-time.Minute), LastSentAt: now.Add(-time.Minute)},
+			expected: false,
+		},
+		{
+			name:     "hourly-recently-sent",
+			settings: digest.Settings{Frequency: digest.FrequencyHourly, UpdatedAt: now.Add(-48 * time.Hour), LastSentAt: now.Add(-30 * time.Minute)},
+			expected: false,
+		},
+		{
+			name:     "daily-sent-yesterday",
+			settings: digest.Settings{Frequency: digest.FrequencyDaily, UpdatedAt: now.Add(-48 * time.Hour), LastSentAt: now.Add(-25 * time.Hour)},
+			expected: true,
+		},
+		{
+			name:     "daily-sent-today",
+			settings: digest.Settings{Frequency: digest.FrequencyDaily, UpdatedAt: now.Add(-48 * time.Hour), LastSentAt: now.Add(-time.Hour)},
+			expected: false,
+		},
+		{
+			name:     "daily-changed-from-hourly",
+			settings: digest.Settings{Frequency: digest.FrequencyDaily, UpdatedAt: now.Add(-time.Minute), LastSentAt: now.Add(-25 * time.Hour)},
+			expected: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.settings.IsDue(now))
+		})
+	}
+}
@@ -0,0 +1,14 @@
+package module
+
+import (
+	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/notification/digest/db"
+	"getsturdy.com/api/pkg/notification/digest/service"
+	"getsturdy.com/api/pkg/notification/digest/worker"
+)
+
+func Module(c *di.Container) {
+	c.Import(db.Module)
+	c.Import(service.Module)
+	c.Import(worker.Module)
+}
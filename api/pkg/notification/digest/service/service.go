@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/emails/transactional"
+	"getsturdy.com/api/pkg/notification"
+	"getsturdy.com/api/pkg/notification/digest"
+	db_digest "getsturdy.com/api/pkg/notification/digest/db"
+	"getsturdy.com/api/pkg/users"
+	db_user "getsturdy.com/api/pkg/users/db"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	logger *zap.Logger
+
+	repo     db_digest.Repository
+	userRepo db_user.Repository
+
+	transactionalService digestSender
+}
+
+type digestSender interface {
+	SendNotificationDigest(context.Context, *users.User, []*notification.Notification) ([]*notification.Notification, error)
+}
+
+func New(
+	logger *zap.Logger,
+	repo db_digest.Repository,
+	userRepo db_user.Repository,
+	transactionalService *transactional.Sender,
+) *Service {
+	return &Service{
+		logger: logger.Named("digest"),
+
+		repo:     repo,
+		userRepo: userRepo,
+
+		transactionalService: transactionalService,
+	}
+}
+
+// Settings returns the digest settings of the user, or the defaults if the user has none.
+func (s *Service) Settings(ctx context.Context, userID string) (*digest.Settings, error) {
+	settings, err := s.repo.GetSettings(ctx, userID)
+	switch {
+	case err == nil:
+		return settings, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return digest.DefaultSettings(userID), nil
+	default:
+		return nil, fmt.Errorf("failed to get settings: %w", err)
+	}
+}
+
+// IsImmediate returns true if the user wants to receive an email for every notification.
+func (s *Service) IsImmediate(ctx context.Context, userID string) (bool, error) {
+	settings, err := s.Settings(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return settings.Frequency == digest.FrequencyImmediate, nil
+}
+
+func (s *Service) SetFrequency(ctx context.Context, userID string, frequency digest.Frequency) (*digest.Settings, error) {
+	if !frequency.Valid() {
+		return nil, digest.ErrInvalidFrequency
+	}
+
+	settings, err := s.Settings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Frequency == frequency {
+		return settings, nil
+	}
+
+	now := time.Now()
+	if settings.Frequency == digest.FrequencyImmediate {
+		// the notifications until now have been sent immediately
+		settings.LastSentAt = now
+	}
+	settings.Frequency = frequency
+	settings.UpdatedAt = now
+	if err := s.repo.UpsertSettings(ctx, settings); err != nil {
+		return nil, fmt.Errorf("failed to update settings: %w", err)
+	}
+	return settings, nil
+}
+
+// SendDue sends digests to all users that are due for one.
+func (s *Service) SendDue(ctx context.Context, now time.Time) error {
+	scheduled, err := s.repo.ListScheduledSettings(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list settings: %w", err)
+	}
+	for _, settings := range scheduled {
+		if !settings.IsDue(now) {
+			continue
+		}
+		if err := s.send(ctx, settings, now); err != nil {
+			s.logger.Error("failed to send digest", zap.String("user_id", settings.UserID), zap.Error(err))
+			// continue with the next user
+		}
+	}
+	return nil
+}
+
+// send sends a digest with the notifications that were created since the last digest.
+//
+// The digest is marked as sent before the email is sent, so that it's sent only once, even if it's sent from multiple
+// schedulers at the same time. If the email fails to be sent, the mark is reverted, and the digest is sent again
+// later.
+func (s *Service) send(ctx context.Context, settings *digest.Settings, now time.Time) error {
+	// timestamps are stored with microsecond precision
+	now = now.Truncate(time.Microsecond)
+
+	marked, err := s.repo.MarkSent(ctx, settings.UserID, settings.LastSentAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to mark digest as sent: %w", err)
+	}
+	if !marked {
+		// sent by someone else
+		return nil
+	}
+
+	pending, err := s.repo.ListPending(ctx, settings.UserID, settings.LastSentAt, now)
+	if err != nil {
+		return s.unmarkSent(ctx, settings, now, fmt.Errorf("failed to list pending notifications: %w", err))
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	usr, err := s.userRepo.Get(settings.UserID)
+	if err != nil {
+		return s.unmarkSent(ctx, settings, now, fmt.Errorf("failed to get user: %w", err))
+	}
+
+	// the pending notifications that are not included are skipped, they are not sent via email
+	included, err := s.transactionalService.SendNotificationDigest(ctx, usr, pending)
+	if err != nil {
+		return s.unmarkSent(ctx, settings, now, fmt.Errorf("failed to send digest: %w", err))
+	}
+	if len(included) == 0 {
+		return nil
+	}
+
+	d := &digest.Digest{
+		ID:        uuid.NewString(),
+		UserID:    settings.UserID,
+		CreatedAt: now,
+	}
+	ids := make([]string, 0, len(included))
+	for _, notif := range included {
+		ids = append(ids, notif.ID)
+	}
+	if err := s.repo.Create(ctx, d, ids); err != nil {
+		return fmt.Errorf("failed to save digest: %w", err)
+	}
+	return nil
+}
+
+// unmarkSent reverts MarkSent, so that the digest is sent again later.
+func (s *Service) unmarkSent(ctx context.Context, settings *digest.Settings, sentAt time.Time, err error) error {
+	if _, unmarkErr := s.repo.MarkSent(ctx, settings.UserID, sentAt, settings.LastSentAt); unmarkErr != nil {
+		s.logger.Error("failed to unmark digest as sent", zap.String("user_id", settings.UserID), zap.Error(unmarkErr))
+	}
+	return err
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/notification"
+	"getsturdy.com/api/pkg/notification/digest"
+	db_digest "getsturdy.com/api/pkg/notification/digest/db"
+	"getsturdy.com/api/pkg/users"
+	db_user "getsturdy.com/api/pkg/users/db"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// fakeDigestSender records the notifications of the digests that are sent, and includes all of them.
+type fakeDigestSender struct {
+	mu      sync.Mutex
+	digests [][]string
+	err     error
+}
+
+func (f *fakeDigestSender) SendNotificationDigest(_ context.Context, _ *users.User, notifs []*notification.Notification) ([]*notification.Notification, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	ids := make([]string, 0, len(notifs))
+	for _, notif := range notifs {
+		ids = append(ids, notif.ID)
+	}
+	f.digests = append(f.digests, ids)
+	return notifs, nil
+}
+
+func (f *fakeDigestSender) sent() [][]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.digests
+}
+
+func setup(t *testing.T) (*Service, *fakeDigestSender, func(userID string, createdAt time.Time) string) {
+	repo := db_digest.NewMemory()
+	sender := &fakeDigestSender{}
+	service := &Service{
+		logger:               zap.NewNop(),
+		repo:                 repo,
+		userRepo:             db_user.NewMemory(),
+		transactionalService: sender,
+	}
+	notify := func(userID string, createdAt time.Time) string {
+		id := uuid.NewString()
+		repo.CreateNotification(&notification.Notification{ID: id, UserID: userID, CreatedAt: createdAt})
+		return id
+	}
+	return service, sender, notify
+}
+
+func TestSendDue(t *testing.T) {
+	service, sender, notify := setup(t)
+	ctx := context.Background()
+	userID := uuid.NewString()
+
+	sentImmediately := notify(userID, time.Now().Add(-time.Minute))
+	settings, err := service.SetFrequency(ctx, userID, digest.FrequencyHourly)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	enabledAt := settings.LastSentAt
+	first := notify(userID, enabledAt.Add(time.Minute))
+	second := notify(userID, enabledAt.Add(2*time.Minute))
+
+	// not due yet
+	assert.NoError(t, service.SendDue(ctx, enabledAt.Add(30*time.Minute)))
+	assert.Empty(t, sender.sent())
+
+	assert.NoError(t, service.SendDue(ctx, enabledAt.Add(time.Hour)))
+	assert.Equal(t, [][]string{{first, second}}, sender.sent(), "notifications from before digests were enabled are not included")
+	assert.NotContains(t, sender.sent()[0], sentImmediately)
+
+	// sent once
+	assert.NoError(t, service.SendDue(ctx, enabledAt.Add(time.Hour+time.Minute)))
+	assert.Len(t, sender.sent(), 1)
+
+	third := notify(userID, enabledAt.Add(90*time.Minute))
+	assert.NoError(t, service.SendDue(ctx, enabledAt.Add(2*time.Hour)))
+	assert.Equal(t, [][]string{{first, second}, {third}}, sender.sent())
+}
+
+func TestSendDue_concurrent(t *testing.T) {
+	service, sender, notify := setup(t)
+	ctx := context.Background()
+	userID := uuid.NewString()
+
+	settings, err := service.SetFrequency(ctx, userID, digest.FrequencyHourly)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	notify(userID, settings.LastSentAt.Add(time.Minute))
+
+	now := settings.LastSentAt.Add(time.Hour)
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, service.SendDue(ctx, now))
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, sender.sent(), 1)
+}
+
+func TestSendDue_failed(t *testing.T) {
+	service, sender, notify := setup(t)
+	ctx := context.Background()
+	userID := uuid.NewString()
+
+	settings, err := service.SetFrequency(ctx, userID, digest.FrequencyDaily)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	id := notify(userID, settings.LastSentAt.Add(time.Minute))
+
+	sender.err = errors.New("smtp is down")
+	assert.NoError(t, service.SendDue(ctx, settings.LastSentAt.Add(24*time.Hour)))
+	assert.Empty(t, sender.sent())
+
+	stored, err := service.Settings(ctx, userID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, settings.LastSentAt, stored.LastSentAt, "the digest is not marked as sent")
+	}
+
+	// retried
+	sender.err = nil
+	assert.NoError(t, service.SendDue(ctx, settings.LastSentAt.Add(24*time.Hour+5*time.Minute)))
+	assert.Equal(t, [][]string{{id}}, sender.sent())
+}
+
+func TestSetFrequency(t *testing.T) {
+	service, sender, notify := setup(t)
+	ctx := context.Background()
+	userID := uuid.NewString()
+
+	settings, err := service.SetFrequency(ctx, userID, digest.FrequencyHourly)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	enabledAt := settings.LastSentAt
+	id := notify(userID, enabledAt.Add(time.Minute))
+
+	// changing between digest frequencies keeps the pending notifications
+	settings, err = service.SetFrequency(ctx, userID, digest.FrequencyDaily)
+	if assert.NoError(t, err) {
+		assert.Equal(t, enabledAt, settings.LastSentAt)
+	}
+
+	assert.NoError(t, service.SendDue(ctx, enabledAt.Add(time.Hour)))
+	assert.Empty(t, sender.sent(), "daily digests are not due after an hour")
+
+	assert.NoError(t, service.SendDue(ctx, enabledAt.Add(24*time.Hour)))
+	assert.Equal(t, [][]string{{id}}, sender.sent())
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	service_digest "getsturdy.com/api/pkg/notification/digest/service"
+
+	"go.uber.org/zap"
+)
+
+var (
+	scheduleEvery = 5 * time.Minute
+)
+
+// Scheduler periodically sends the notification digests that are due.
+type Scheduler struct {
+	logger  *zap.Logger
+	service *service_digest.Service
+}
+
+func NewScheduler(
+	logger *zap.Logger,
+	service *service_digest.Service,
+) *Scheduler {
+	return &Scheduler{
+		logger:  logger.Named("digestScheduler"),
+		service: service,
+	}
+}
+
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.logger.Info("starting")
+
+	ticker := time.NewTicker(scheduleEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.service.SendDue(ctx, time.Now()); err != nil {
+				s.logger.Error("failed to send digests", zap.Error(err))
+			}
+		case <-ctx.Done():
+			s.logger.Info("stopping")
+			return nil
+		}
+	}
+}
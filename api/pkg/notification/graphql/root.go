@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 
+	"getsturdy.com/api/pkg/accesstokens"
 	"getsturdy.com/api/pkg/auth"
 	service_auth "getsturdy.com/api/pkg/auth/service"
 	db_codebase "getsturdy.com/api/pkg/codebase/db"
@@ -45,6 +46,7 @@ type notificationRootResolver struct {
 	reviewRootResolver                    resolvers.ReviewRootResolver
 	suggestionRootResolver                resolvers.SuggestionRootResolver
 	codebaseGitHubIntegrationRootResolver resolvers.CodebaseGitHubIntegrationRootResolver
+	autoRevertRootResolver                *resolvers.AutoRevertRootResolver
 
 	eventsReader events.EventReader
 	eventSender  events.EventSender
@@ -66,6 +68,7 @@ func NewResolver(
 	reviewRootResolver resolvers.ReviewRootResolver,
 	suggestionRootResolver resolvers.SuggestionRootResolver,
 	codebaseGitHubIntegrationRootResolver resolvers.CodebaseGitHubIntegrationRootResolver,
+	autoRevertRootResolver *resolvers.AutoRevertRootResolver,
 
 	eventsReader events.EventReader,
 	eventSender events.EventSender,
@@ -86,6 +89,7 @@ func NewResolver(
 		reviewRootResolver:                    reviewRootResolver,
 		suggestionRootResolver:                suggestionRootResolver,
 		codebaseGitHubIntegrationRootResolver: codebaseGitHubIntegrationRootResolver,
+		autoRevertRootResolver:                autoRevertRootResolver,
 
 		eventsReader: eventsReader,
 		eventSender:  eventSender,
@@ -158,6 +162,8 @@ func convertChannelType(in resolvers.NotificationChannel) (notification.Channel,
 		return notification.ChannelEmail, nil
 	case resolvers.NotificationChannelWeb:
 		return notification.ChannelWeb, nil
+	case resolvers.NotificationChannelChat:
+		return notification.ChannelChat, nil
 	default:
 		return notification.ChannelUndefined, fmt.Errorf("unknown notification channel: %s", in)
 	}
@@ -175,6 +181,10 @@ func convertNotificationType(in resolvers.NotificationType) (notification.Notifi
 		return notification.RequestedReviewNotificationType, nil
 	case resolvers.NotificationGitHubRepositoryImported:
 		return notification.GitHubRepositoryImported, nil
+	case resolvers.NotificationTypeAutoRevert:
+		return notification.AutoRevertNotificationType, nil
+	case resolvers.NotificationTypeReviewDismissed:
+		return notification.ReviewDismissedNotificationType, nil
 	default:
 		return notification.NotificationTypeUndefined, fmt.Errorf("unknown notification type: %s", in)
 	}
@@ -186,6 +196,10 @@ func (r *notificationRootResolver) UpdateNotificationPreference(ctx context.Cont
 		return nil, err
 	}
 
+	if err := auth.RequireScope(ctx, accesstokens.ScopeAdmin); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
 	typ, err := convertNotificationType(args.Input.Type)
 	if err != nil {
 		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "type", err.Error())
@@ -329,6 +343,8 @@ func (r *notificationPreferenceResolver) Channel() (resolvers.NotificationChanne
 		return resolvers.NotificationChannelEmail, nil
 	case notification.ChannelWeb:
 		return resolvers.NotificationChannelWeb, nil
+	case notification.ChannelChat:
+		return resolvers.NotificationChannelChat, nil
 	default:
 		return resolvers.NotificationChannelUndefined, fmt.Errorf("unkown notification channel")
 	}
@@ -364,6 +380,10 @@ func resolveNotificationType(typ notification.NotificationType) (resolvers.Notif
 		return resolvers.NotificationTypeNewSuggestion, nil
 	case notification.GitHubRepositoryImported:
 		return resolvers.NotificationGitHubRepositoryImported, nil
+	case notification.AutoRevertNotificationType:
+		return resolvers.NotificationTypeAutoRevert, nil
+	case notification.ReviewDismissedNotificationType:
+		return resolvers.NotificationTypeReviewDismissed, nil
 	default:
 		return resolvers.NotificationTypeUndefined, fmt.Errorf("unknown notification type")
 	}
@@ -390,7 +410,7 @@ func (r *notificationResolver) sub(ctx context.Context) (interface{}, error) {
 	switch r.notif.NotificationType {
 	case notification.CommentNotificationType:
 		return r.root.commentResolver.Comment(ctx, resolvers.CommentArgs{ID: graphql.ID(r.notif.ReferenceID)})
-	case notification.ReviewNotificationType:
+	case notification.ReviewNotificationType, notification.ReviewDismissedNotificationType:
 		return r.root.reviewRootResolver.InternalReview(ctx, r.notif.ReferenceID)
 	case notification.RequestedReviewNotificationType:
 		return r.root.reviewRootResolver.InternalReview(ctx, r.notif.ReferenceID)
@@ -398,6 +418,8 @@ func (r *notificationResolver) sub(ctx context.Context) (interface{}, error) {
 		return r.root.suggestionRootResolver.InternalSuggestionByID(ctx, suggestions.ID(r.notif.ReferenceID))
 	case notification.GitHubRepositoryImported:
 		return r.root.codebaseGitHubIntegrationRootResolver.InternalGitHubRepositoryByID(r.notif.ReferenceID)
+	case notification.AutoRevertNotificationType:
+		return (*r.root.autoRevertRootResolver).InternalRevertByID(ctx, r.notif.ReferenceID)
 	default:
 		return resolvers.NotificationTypeUndefined, fmt.Errorf("unknown notification type")
 	}
@@ -441,6 +463,20 @@ func (r *notificationResolver) ToNewSuggestionNotification() (resolvers.NewSugge
 	return &newSuggestionNotificationResolver{r}, true
 }
 
+func (r *notificationResolver) ToAutoRevertNotification() (resolvers.AutoRevertNotificationResolver, bool) {
+	if r.notif.NotificationType != notification.AutoRevertNotificationType {
+		return nil, false
+	}
+	return &autoRevertNotificationResolver{r}, true
+}
+
+func (r *notificationResolver) ToReviewDismissedNotification() (resolvers.ReviewDismissedNotificationResolver, bool) {
+	if r.notif.NotificationType != notification.ReviewDismissedNotificationType {
+		return nil, false
+	}
+	return &reviewDismissedNotificationResolver{r}, true
+}
+
 type commentNotificationResolver struct {
 	*notificationResolver
 }
@@ -495,3 +531,25 @@ func (r *gitHubRepositoryImportedResolver) Repository(ctx context.Context) (reso
 	}
 	return nil, fmt.Errorf("failed to get CodebaseGitHubIntegrationResolver")
 }
+
+type autoRevertNotificationResolver struct {
+	*notificationResolver
+}
+
+func (r *autoRevertNotificationResolver) Revert(ctx context.Context) (resolvers.AutoRevertResolver, error) {
+	if v, ok := r.subItem.(resolvers.AutoRevertResolver); ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("failed to get AutoRevertResolver")
+}
+
+type reviewDismissedNotificationResolver struct {
+	*notificationResolver
+}
+
+func (r *reviewDismissedNotificationResolver) Review(ctx context.Context) (resolvers.ReviewResolver, error) {
+	if v, ok := r.subItem.(resolvers.ReviewResolver); ok {
+		return v, nil
+	}
+	return nil, fmt.Errorf("failed to get ReviewResolver")
+}
@@ -2,6 +2,8 @@ package module
 
 import (
 	"getsturdy.com/api/pkg/di"
+	module_chat "getsturdy.com/api/pkg/notification/chat/module"
+	module_digest "getsturdy.com/api/pkg/notification/digest/module"
 	"getsturdy.com/api/pkg/notification/db"
 	"getsturdy.com/api/pkg/notification/graphql"
 	"getsturdy.com/api/pkg/notification/sender"
@@ -10,6 +12,8 @@ import (
 
 func Module(c *di.Container) {
 	c.Import(db.Module)
+	c.Import(module_chat.Module)
+	c.Import(module_digest.Module)
 	c.Import(graphql.Module)
 	c.Import(sender.Module)
 	c.Import(service.Module)
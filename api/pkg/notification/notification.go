@@ -10,6 +10,8 @@ type Notification struct {
 	ReferenceID      string           `db:"reference_id"`
 	CreatedAt        time.Time        `db:"created_at"`
 	ArchivedAt       *time.Time       `db:"archived_at"`
+	// DigestID is set if the notification has been included in a digest email
+	DigestID *string `db:"digest_id"`
 }
 
 type NotificationType string
@@ -21,4 +23,6 @@ const (
 	RequestedReviewNotificationType NotificationType = "requested_review"
 	NewSuggestionNotificationType   NotificationType = "new_suggesion"
 	GitHubRepositoryImported        NotificationType = "github_repository_imported"
+	AutoRevertNotificationType      NotificationType = "auto_revert"
+	ReviewDismissedNotificationType NotificationType = "review_dismissed"
 )
@@ -6,6 +6,7 @@ const (
 	ChannelUndefined Channel = ""
 	ChannelWeb       Channel = "web"
 	ChannelEmail     Channel = "email"
+	ChannelChat      Channel = "chat"
 )
 
 // Preference is used to determine if user with _UserID_ wants to receive notifications of type _Type_ via _Channel_.
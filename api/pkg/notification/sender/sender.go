@@ -9,6 +9,8 @@ import (
 	db_codebase "getsturdy.com/api/pkg/codebase/db"
 	"getsturdy.com/api/pkg/emails/transactional"
 	"getsturdy.com/api/pkg/notification"
+	service_chat "getsturdy.com/api/pkg/notification/chat/service"
+	service_digest "getsturdy.com/api/pkg/notification/digest/service"
 	db_notification "getsturdy.com/api/pkg/notification/db"
 	db_user "getsturdy.com/api/pkg/users/db"
 	"getsturdy.com/api/pkg/events"
@@ -31,6 +33,9 @@ type realNotificationSender struct {
 
 	eventsSender events.EventSender
 	emailSender  transactional.EmailSender
+	chatService  *service_chat.Service
+
+	digestService *service_digest.Service
 }
 
 func NewNotificationSender(
@@ -42,6 +47,8 @@ func NewNotificationSender(
 
 	eventsSender events.EventSender,
 	emailSender transactional.EmailSender,
+	chatService *service_chat.Service,
+	digestService *service_digest.Service,
 ) NotificationSender {
 	return &realNotificationSender{
 		logger: logger,
@@ -52,15 +59,22 @@ func NewNotificationSender(
 
 		eventsSender: eventsSender,
 		emailSender:  emailSender,
+		chatService:  chatService,
+
+		digestService: digestService,
 	}
 }
 
 func (s *realNotificationSender) Codebase(ctx context.Context, codebaseID string, notificationType notification.NotificationType, referenceID string, senderUserID string) error {
+	s.enqueueCodebaseChat(ctx, codebaseID, notificationType, referenceID)
+
 	// Send to all members of this codebase
 	codebaseUsers, err := s.codebaseUserRepo.GetByCodebase(codebaseID)
 	if err != nil {
 		return err
 	}
+	// members are notified even if notifying another member fails
+	var dispatchErr error
 	for _, codebaseUser := range codebaseUsers {
 		// Don't send to yourself
 		if codebaseUser.UserID == senderUserID {
@@ -80,11 +94,11 @@ func (s *realNotificationSender) Codebase(ctx context.Context, codebaseID string
 		}
 
 		if err := s.dispatch(ctx, &notif); err != nil {
-			return fmt.Errorf("failed to dispatch notification: %w", err)
+			s.logger.Error("failed to dispatch notification", zap.String("user_id", notif.UserID), zap.Error(err))
+			dispatchErr = fmt.Errorf("failed to dispatch notification: %w", err)
 		}
-
 	}
-	return nil
+	return dispatchErr
 }
 
 func (s *realNotificationSender) User(ctx context.Context, userID, codebaseID string, notificationType notification.NotificationType, referenceID string) error {
@@ -101,6 +115,10 @@ func (s *realNotificationSender) User(ctx context.Context, userID, codebaseID st
 		return fmt.Errorf("failed to save notification to the db: %w", err)
 	}
 
+	if codebaseID != "" {
+		s.enqueueCodebaseChat(ctx, codebaseID, notificationType, referenceID)
+	}
+
 	if err := s.dispatch(ctx, &notif); err != nil {
 		return fmt.Errorf("failed to dispatch notification: %w", err)
 	}
@@ -108,19 +126,42 @@ func (s *realNotificationSender) User(ctx context.Context, userID, codebaseID st
 	return nil
 }
 
+// enqueueCodebaseChat enqueues the notification to be posted to the chat webhooks of the codebase. Failures are
+// logged, and do not fail the notification.
+func (s *realNotificationSender) enqueueCodebaseChat(ctx context.Context, codebaseID string, notificationType notification.NotificationType, referenceID string) {
+	if err := s.chatService.EnqueueCodebase(ctx, codebaseID, notificationType, referenceID); err != nil {
+		s.logger.Error("failed to enqueue codebase chat notification", zap.Error(err))
+	}
+}
+
 func (s *realNotificationSender) dispatch(ctx context.Context, notif *notification.Notification) error {
 	s.eventsSender.User(notif.UserID, events.NotificationEvent, notif.ID)
 
+	// chat notifications are posted from their own queue, whether or not the user can be emailed
+	if err := s.chatService.Enqueue(ctx, notif); err != nil {
+		s.logger.Error("failed to enqueue chat notification", zap.Error(err))
+		// do not fail
+	}
+
 	user, err := s.userRepo.Get(notif.UserID)
 	if err != nil {
 		return fmt.Errorf("failed to find user: %w", err)
 	}
 
-	if err := s.emailSender.SendNotification(ctx, user, notif); errors.Is(err, transactional.ErrNotSupported) {
-		s.logger.Warn("email notification not supported", zap.String("type", string(notif.NotificationType)))
-	} else if err != nil {
-		return fmt.Errorf("failed to notify via email: %w", err)
+	// users that receive digests are emailed by the digest scheduler
+	immediate, err := s.digestService.IsImmediate(ctx, user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get digest settings: %w", err)
+	}
+
+	if immediate {
+		if err := s.emailSender.SendNotification(ctx, user, notif); errors.Is(err, transactional.ErrNotSupported) {
+			s.logger.Warn("email notification not supported", zap.String("type", string(notif.NotificationType)))
+		} else if err != nil {
+			return fmt.Errorf("failed to notify via email: %w", err)
+		}
 	}
+
 	return nil
 }
 
@@ -11,10 +11,13 @@ var (
 		notification.ReviewNotificationType:          true,
 		notification.RequestedReviewNotificationType: true,
 		notification.NewSuggestionNotificationType:   true,
+		notification.AutoRevertNotificationType:      true,
+		notification.ReviewDismissedNotificationType: true,
 		notification.GitHubRepositoryImported:        true,
 	}
 	supportedChannels = map[notification.Channel]bool{
 		notification.ChannelEmail: true,
 		notification.ChannelWeb:   true,
+		notification.ChannelChat:  true,
 	}
 )
@@ -11,8 +11,11 @@ var (
 		notification.ReviewNotificationType:          true,
 		notification.RequestedReviewNotificationType: true,
 		notification.NewSuggestionNotificationType:   true,
+		notification.AutoRevertNotificationType:      true,
+		notification.ReviewDismissedNotificationType: true,
 	}
 	supportedChannels = map[notification.Channel]bool{
-		notification.ChannelWeb: true,
+		notification.ChannelWeb:  true,
+		notification.ChannelChat: true,
 	}
 )
@@ -9,11 +9,11 @@ import (
 )
 
 type Preferences struct {
-	preferencesRepo *db_notification.PreferenceRepository
+	preferencesRepo db_notification.PreferenceRepository
 }
 
 func NewPreferences(
-	preferencesRepo *db_notification.PreferenceRepository,
+	preferencesRepo db_notification.PreferenceRepository,
 ) *Preferences {
 	return &Preferences{
 		preferencesRepo: preferencesRepo,
@@ -51,6 +51,7 @@ func (s *Preferences) ListByUserID(ctx context.Context, userID string) ([]*notif
 	existing := map[notification.Channel]map[notification.NotificationType]*notification.Preference{
 		notification.ChannelEmail: {},
 		notification.ChannelWeb:   {},
+		notification.ChannelChat:  {},
 	}
 	for _, p := range pp {
 		existing[p.Channel][p.Type] = p
@@ -83,3 +84,17 @@ func (s *Preferences) ListByUserID(ctx context.Context, userID string) ([]*notif
 
 	return result, nil
 }
+
+// IsEnabled returns true if the user wants to receive notifications of the type via the channel.
+func (s *Preferences) IsEnabled(ctx context.Context, userID string, typ notification.NotificationType, channel notification.Channel) (bool, error) {
+	pp, err := s.ListByUserID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range pp {
+		if p.Type == typ && p.Channel == channel {
+			return p.Enabled, nil
+		}
+	}
+	return false, nil
+}
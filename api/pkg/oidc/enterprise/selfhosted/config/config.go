@@ -0,0 +1,37 @@
+package config
+
+import "strings"
+
+type Configuration struct {
+	Issuer                string   `long:"issuer" description:"URL of the OpenID Connect provider, single sign-on is disabled if empty" env:"ISSUER"`
+	ClientID              string   `long:"client-id" description:"Client ID of Sturdy at the provider" env:"CLIENT_ID"`
+	ClientSecret          string   `long:"client-secret" description:"Client secret of Sturdy at the provider" env:"CLIENT_SECRET"`
+	RedirectURL           string   `long:"redirect-url" description:"URL of the callback route (/v3/auth/oidc/callback) as registered at the provider" env:"REDIRECT_URL"`
+	Scopes                []string `long:"scope" description:"Scope to request in addition to openid" default:"email" default:"profile" env:"SCOPES" env-delim:","`
+	AllowedDomains        []string `long:"allowed-domain" description:"Email domain that is allowed to sign in, all domains are allowed if not set" env:"ALLOWED_DOMAINS" env-delim:","`
+	AllowUnverifiedEmails bool     `long:"allow-unverified-emails" description:"Allow users to sign in with email addresses that the provider has not verified, they can only sign in to new accounts" env:"ALLOW_UNVERIFIED_EMAILS"`
+	OrganizationID        string   `long:"organization-id" description:"ID of the organization that users are added to when they sign in" env:"ORGANIZATION_ID"`
+}
+
+// Enabled returns true if single sign-on is configured.
+func (c *Configuration) Enabled() bool {
+	return c != nil && c.Issuer != ""
+}
+
+// DomainAllowed returns true if users with the email address are allowed to sign in.
+func (c *Configuration) DomainAllowed(email string) bool {
+	if len(c.AllowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	for _, allowed := range c.AllowedDomains {
+		if strings.EqualFold(strings.TrimSpace(allowed), domain) {
+			return true
+		}
+	}
+	return false
+}
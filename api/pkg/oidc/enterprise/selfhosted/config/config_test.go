@@ -0,0 +1,30 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDomainAllowed(t *testing.T) {
+	cases := []struct {
+		name     string
+		domains  []string
+		email    string
+		expected bool
+	}{
+		{name: "no-restriction", email: "user@example.com", expected: true},
+		{name: "allowed", domains: []string{"example.org", "example.com"}, email: "user@example.com", expected: true},
+		{name: "allowed-case-insensitive", domains: []string{"Example.com"}, email: "user@EXAMPLE.COM", expected: true},
+		{name: "not-allowed", domains: []string{"example.com"}, email: "user@example.org", expected: false},
+		{name: "subdomain", domains: []string{"example.com"}, email: "user@evil.example.com", expected: false},
+		{name: "no-domain", domains: []string{"example.com"}, email: "example.com", expected: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Configuration{AllowedDomains: tc.domains}
+			assert.Equal(t, tc.expected, cfg.DomainAllowed(tc.email))
+		})
+	}
+}
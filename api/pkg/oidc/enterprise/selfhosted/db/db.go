@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"getsturdy.com/api/pkg/oidc"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type IdentityRepository interface {
+	Create(context.Context, *oidc.Identity) error
+	Get(ctx context.Context, issuer, subject string) (*oidc.Identity, error)
+}
+
+type identityRepo struct {
+	db *sqlx.DB
+}
+
+func NewIdentities(db *sqlx.DB) IdentityRepository {
+	return &identityRepo{db: db}
+}
+
+func (r *identityRepo) Create(ctx context.Context, identity *oidc.Identity) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO oidc_identities
+			(issuer, subject, user_id, email_verified, created_at)
+		VALUES
+			(:issuer, :subject, :user_id, :email_verified, :created_at)
+	`, identity); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (r *identityRepo) Get(ctx context.Context, issuer, subject string) (*oidc.Identity, error) {
+	var res oidc.Identity
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			issuer, subject, user_id, email_verified, created_at
+		FROM
+			oidc_identities
+		WHERE
+			issuer = $1
+			AND subject = $2
+	`, issuer, subject); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
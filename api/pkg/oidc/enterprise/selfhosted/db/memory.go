@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"getsturdy.com/api/pkg/oidc"
+)
+
+var _ IdentityRepository = &identitiesMemory{}
+
+type identitiesMemory struct {
+	mu         sync.Mutex
+	identities []oidc.Identity
+}
+
+func NewIdentitiesMemory() IdentityRepository {
+	return &identitiesMemory{}
+}
+
+func (m *identitiesMemory) Create(_ context.Context, identity *oidc.Identity) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.identities = append(m.identities, *identity)
+	return nil
+}
+
+func (m *identitiesMemory) Get(_ context.Context, issuer, subject string) (*oidc.Identity, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, identity := range m.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			identity := identity
+			return &identity, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
@@ -0,0 +1,9 @@
+package db
+
+import (
+	"getsturdy.com/api/pkg/di"
+)
+
+func Module(c *di.Container) {
+	c.Register(NewIdentities)
+}
@@ -0,0 +1,12 @@
+package selfhosted
+
+import (
+	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/oidc/enterprise/selfhosted/db"
+	"getsturdy.com/api/pkg/oidc/enterprise/selfhosted/service"
+)
+
+func Module(c *di.Container) {
+	c.Import(db.Module)
+	c.Import(service.Module)
+}
@@ -0,0 +1,284 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"getsturdy.com/api/pkg/oidc/enterprise/selfhosted/config"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/square/go-jose.v2"
+	jose_jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+const (
+	// requestTimeout is the max time to wait for the provider to respond
+	requestTimeout = 10 * time.Second
+	// clockSkew is the allowed difference between the clocks of Sturdy and the provider
+	clockSkew = time.Minute
+)
+
+var (
+	ErrInvalidToken  = errors.New("invalid id token")
+	ErrInvalidNonce  = errors.New("invalid nonce")
+	ErrMissingToken  = errors.New("provider did not return an id token")
+	ErrMissingClaims = errors.New("id token is missing the subject or email")
+)
+
+// Claims are the claims of an id token that Sturdy uses.
+type Claims struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+	Nonce         string `json:"nonce"`
+}
+
+// discovery is the subset of the OpenID Connect discovery document that is used.
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider signs users in with an OpenID Connect provider, using the authorization code flow with PKCE.
+//
+// The provider configuration is discovered on first use, so that Sturdy can start while the provider is unavailable.
+type Provider struct {
+	cfg    *config.Configuration
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      *jose.JSONWebKeySet
+}
+
+func New(cfg *config.Configuration) *Provider {
+	return &Provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// RandomString returns a random url-safe string, suitable for states, nonces and PKCE code verifiers.
+func RandomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to read random bytes: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge returns the S256 PKCE code challenge of the verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL returns the URL of the provider to redirect the user to for signing in.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	oauthCfg, err := p.oauth2Config(ctx)
+	if err != nil {
+		return "", err
+	}
+	return oauthCfg.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("nonce", nonce),
+		oauth2.SetAuthURLParam("code_challenge", CodeChallenge(verifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	), nil
+}
+
+// Exchange exchanges the authorization code for an id token, and returns its claims once the token is verified.
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	oauthCfg, err := p.oauth2Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauthCfg.Exchange(context.WithValue(ctx, oauth2.HTTPClient, p.client), code,
+		oauth2.SetAuthURLParam("code_verifier", verifier),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, ErrMissingToken
+	}
+
+	claims, err := p.verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, ErrInvalidNonce
+	}
+	if claims.Subject == "" || claims.Email == "" {
+		return nil, ErrMissingClaims
+	}
+
+	return claims, nil
+}
+
+func (p *Provider) verify(ctx context.Context, rawIDToken string) (*Claims, error) {
+	token, err := jose_jwt.ParseSigned(rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+	if len(token.Headers) != 1 {
+		return nil, fmt.Errorf("%w: expected one signature", ErrInvalidToken)
+	}
+
+	key, err := p.key(ctx, token.Headers[0].KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		standard jose_jwt.Claims
+		claims   Claims
+	)
+	if err := token.Claims(key, &standard, &claims); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := standard.ValidateWithLeeway(jose_jwt.Expected{
+		Issuer:   d.Issuer,
+		Audience: jose_jwt.Audience{p.cfg.ClientID},
+		Time:     time.Now(),
+	}, clockSkew); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+
+	return &claims, nil
+}
+
+// key returns the signing key of the provider with the given id. The keys are fetched again if the key is not
+// known, in case the provider has rotated its keys.
+func (p *Provider) key(ctx context.Context, keyID string) (*jose.JSONWebKey, error) {
+	p.mu.Lock()
+	keys := p.keys
+	p.mu.Unlock()
+
+	if keys != nil {
+		if key, ok := findKey(keys, keyID); ok {
+			return key, nil
+		}
+	}
+
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys = &jose.JSONWebKeySet{}
+	if err := p.get(ctx, d.JWKSURI, keys); err != nil {
+		return nil, fmt.Errorf("failed to get keys: %w", err)
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok := findKey(keys, keyID); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown key %q", ErrInvalidToken, keyID)
+}
+
+func findKey(keys *jose.JSONWebKeySet, keyID string) (*jose.JSONWebKey, bool) {
+	for _, key := range keys.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		if keyID == "" || key.KeyID == keyID {
+			key := key
+			return &key, true
+		}
+	}
+	return nil, false
+}
+
+func (p *Provider) oauth2Config(ctx context.Context) (*oauth2.Config, error) {
+	d, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Scopes:       append([]string{"openid"}, p.cfg.Scopes...),
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  d.AuthorizationEndpoint,
+			TokenURL: d.TokenEndpoint,
+		},
+	}, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	issuer := strings.TrimSuffix(p.cfg.Issuer, "/")
+	d := &discovery{}
+	if err := p.get(ctx, issuer+"/.well-known/openid-configuration", d); err != nil {
+		return nil, fmt.Errorf("failed to discover provider: %w", err)
+	}
+	if d.Issuer != issuer && d.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("discovered issuer %q does not match the configured issuer %q", d.Issuer, p.cfg.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document of %q is incomplete", p.cfg.Issuer)
+	}
+
+	p.discovery = d
+	return d, nil
+}
+
+func (p *Provider) get(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package provider
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/oidc/enterprise/selfhosted/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	jose_jwt "gopkg.in/square/go-jose.v2/jwt"
+)
+
+// mockProvider is a minimal OpenID Connect provider that issues an id token for a single authorization code.
+type mockProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	code          string
+	codeChallenge string
+	claims        map[string]interface{}
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	m := &mockProvider{t: t, key: key, code: "the-code"}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &m.key.PublicKey, KeyID: "key-1", Algorithm: string(jose.RS256), Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("code") != m.code || CodeChallenge(r.PostForm.Get("code_verifier")) != m.codeChallenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     m.idToken(),
+		})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+
+	return m
+}
+
+func (m *mockProvider) idToken() string {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: m.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "key-1"),
+	)
+	require.NoError(m.t, err)
+	token, err := jose_jwt.Signed(signer).Claims(m.claims).CompactSerialize()
+	require.NoError(m.t, err)
+	return token
+}
+
+func TestProvider(t *testing.T) {
+	now := time.Now()
+
+	cases := []struct {
+		name        string
+		claims      map[string]interface{}
+		verifier    string
+		expectedErr error
+	}{
+		{
+			name:   "valid",
+			claims: map[string]interface{}{},
+		},
+		{
+			name:        "wrong-nonce",
+			claims:      map[string]interface{}{"nonce": "other"},
+			expectedErr: ErrInvalidNonce,
+		},
+		{
+			name:        "wrong-audience",
+			claims:      map[string]interface{}{"aud": "other-client"},
+			expectedErr: ErrInvalidToken,
+		},
+		{
+			name:        "wrong-issuer",
+			claims:      map[string]interface{}{"iss": "https://example.com"},
+			expectedErr: ErrInvalidToken,
+		},
+		{
+			name:        "expired",
+			claims:      map[string]interface{}{"exp": now.Add(-time.Hour).Unix()},
+			expectedErr: ErrInvalidToken,
+		},
+		{
+			name:        "missing-email",
+			claims:      map[string]interface{}{"email": ""},
+			expectedErr: ErrMissingClaims,
+		},
+		{
+			name:     "wrong-verifier",
+			claims:   map[string]interface{}{},
+			verifier: "other-verifier",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			idp := newMockProvider(t)
+			p := New(&config.Configuration{
+				Issuer:      idp.server.URL,
+				ClientID:    "sturdy",
+				RedirectURL: "http://localhost/v3/auth/oidc/callback",
+				Scopes:      []string{"email"},
+			})
+
+			ctx := context.Background()
+			state, nonce, verifier := "the-state", "the-nonce", "the-verifier"
+
+			authURL, err := p.AuthCodeURL(ctx, state, nonce, verifier)
+			require.NoError(t, err)
+			parsed, err := url.Parse(authURL)
+			require.NoError(t, err)
+			assert.Equal(t, "/authorize", parsed.Path)
+			assert.Equal(t, state, parsed.Query().Get("state"))
+			assert.Equal(t, nonce, parsed.Query().Get("nonce"))
+			assert.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+			assert.Equal(t, "openid email", parsed.Query().Get("scope"))
+			idp.codeChallenge = parsed.Query().Get("code_challenge")
+
+			idp.claims = map[string]interface{}{
+				"iss":            idp.server.URL,
+				"sub":            "user-1",
+				"aud":            "sturdy",
+				"exp":            now.Add(time.Hour).Unix(),
+				"iat":            now.Unix(),
+				"nonce":          nonce,
+				"email":          "user@example.com",
+				"email_verified": true,
+				"name":           "User",
+			}
+			for k, v := range tc.claims {
+				idp.claims[k] = v
+			}
+
+			exchangeVerifier := verifier
+			if tc.verifier != "" {
+				exchangeVerifier = tc.verifier
+			}
+
+			claims, err := p.Exchange(ctx, idp.code, exchangeVerifier, nonce)
+			if tc.verifier != "" {
+				assert.Error(t, err)
+				return
+			}
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &Claims{
+				Subject:       "user-1",
+				Email:         "user@example.com",
+				EmailVerified: true,
+				Name:          "User",
+				Nonce:         nonce,
+			}, claims)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package routes
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"getsturdy.com/api/pkg/analytics"
+	service_analytics "getsturdy.com/api/pkg/analytics/service"
+	"getsturdy.com/api/pkg/auth"
+	service_jwt "getsturdy.com/api/pkg/jwt/service"
+	"getsturdy.com/api/pkg/oidc/enterprise/selfhosted/provider"
+	service_oidc "getsturdy.com/api/pkg/oidc/enterprise/selfhosted/service"
+	service_user "getsturdy.com/api/pkg/users/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// stateCookieName is the cookie that holds the state, nonce and code verifier of a sign in
+	stateCookieName = "oidc_state"
+	// signInTimeout is the max time a user has to sign in with the provider
+	signInTimeout = 10 * time.Minute
+)
+
+// Redirect starts a sign in by redirecting the user to the provider.
+func Redirect(logger *zap.Logger, oidcService *service_oidc.Service) func(*gin.Context) {
+	return func(c *gin.Context) {
+		if !oidcService.Enabled() {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+
+		var values [3]string
+		for i := range values {
+			v, err := provider.RandomString()
+			if err != nil {
+				logger.Error("failed to generate state", zap.Error(err))
+				c.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+			values[i] = v
+		}
+		state, nonce, verifier := values[0], values[1], values[2]
+
+		authURL, err := oidcService.AuthCodeURL(c.Request.Context(), state, nonce, verifier)
+		if err != nil {
+			logger.Error("failed to get auth code url", zap.Error(err))
+			c.AbortWithStatus(http.StatusBadGateway)
+			return
+		}
+
+		setStateCookie(c, strings.Join(values[:], "."), int(signInTimeout.Seconds()))
+		c.Redirect(http.StatusFound, authURL)
+	}
+}
+
+// Callback completes a sign in when the provider redirects the user back, and sets the auth cookie of the user.
+func Callback(logger *zap.Logger, oidcService *service_oidc.Service, analyticsService *service_analytics.Service, jwtService *service_jwt.Service) func(*gin.Context) {
+	return func(c *gin.Context) {
+		if !oidcService.Enabled() {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+
+		cookie, err := c.Cookie(stateCookieName)
+		// the state can only be used once
+		setStateCookie(c, "", -1)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Sign in has expired, please try again"})
+			return
+		}
+
+		values := strings.Split(cookie, ".")
+		if len(values) != 3 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Sign in has expired, please try again"})
+			return
+		}
+		state, nonce, verifier := values[0], values[1], values[2]
+
+		if subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid state, please try again"})
+			return
+		}
+
+		if providerErr := c.Query("error"); providerErr != "" {
+			logger.Warn("provider returned an error",
+				zap.String("error", providerErr),
+				zap.String("error_description", c.Query("error_description")),
+			)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Sign in was not successful, please try again"})
+			return
+		}
+
+		usr, err := oidcService.SignIn(c.Request.Context(), c.Query("code"), verifier, nonce)
+		switch {
+		case err == nil:
+		case errors.Is(err, service_oidc.ErrDomainNotAllowed):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Your email domain is not allowed to sign in"})
+			return
+		case errors.Is(err, service_oidc.ErrEmailNotVerified):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Your email address is not verified"})
+			return
+		case errors.Is(err, service_user.ErrExceeded):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Maximum number of users exceeded"})
+			return
+		case errors.Is(err, provider.ErrInvalidToken),
+			errors.Is(err, provider.ErrInvalidNonce),
+			errors.Is(err, provider.ErrMissingToken),
+			errors.Is(err, provider.ErrMissingClaims):
+			logger.Warn("failed to verify identity", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Sign in was not successful, please try again"})
+			return
+		default:
+			logger.Error("failed to sign in", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		if err := auth.SetAuthCookieForUser(c, usr.ID, jwtService); err != nil {
+			logger.Error("failed to set auth cookie", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		ctx := c.Request.Context()
+		analyticsService.IdentifyUser(ctx, usr)
+		analyticsService.Capture(ctx, "logged in", analytics.Property("type", "oidc"))
+
+		c.Redirect(http.StatusFound, oidcService.WebURL())
+	}
+}
+
+func setStateCookie(c *gin.Context, value string, maxAge int) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     stateCookieName,
+		Value:    value,
+		MaxAge:   maxAge,
+		Path:     "/",
+		SameSite: http.SameSiteLaxMode, // Sent when the provider redirects back
+		Secure:   auth.IsSecure(c.Request),
+		HttpOnly: true,
+	})
+}
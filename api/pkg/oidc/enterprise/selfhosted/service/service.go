@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/oidc"
+	"getsturdy.com/api/pkg/oidc/enterprise/selfhosted/config"
+	db_oidc "getsturdy.com/api/pkg/oidc/enterprise/selfhosted/db"
+	"getsturdy.com/api/pkg/oidc/enterprise/selfhosted/provider"
+	"getsturdy.com/api/pkg/organization"
+	service_organization "getsturdy.com/api/pkg/organization/service"
+	"getsturdy.com/api/pkg/users"
+	service_user "getsturdy.com/api/pkg/users/enterprise/selfhosted/service"
+	"getsturdy.com/api/pkg/web"
+
+	"go.uber.org/zap"
+)
+
+var (
+	ErrDisabled           = errors.New("single sign-on is not configured")
+	ErrDomainNotAllowed   = errors.New("email domain is not allowed to sign in")
+	ErrEmailNotVerified   = errors.New("email is not verified by the provider")
+	ErrOrganizationAccess = errors.New("failed to add user to the organization")
+)
+
+type Service struct {
+	logger *zap.Logger
+	cfg    *config.Configuration
+	webCfg *web.Configuration
+
+	provider            *provider.Provider
+	identityRepo        db_oidc.IdentityRepository
+	userService         *service_user.Service
+	organizationService *service_organization.Service
+}
+
+func New(
+	logger *zap.Logger,
+	cfg *config.Configuration,
+	webCfg *web.Configuration,
+	identityRepo db_oidc.IdentityRepository,
+	userService *service_user.Service,
+	organizationService *service_organization.Service,
+) *Service {
+	return &Service{
+		logger: logger.Named("oidc"),
+		cfg:    cfg,
+		webCfg: webCfg,
+
+		provider:            provider.New(cfg),
+		identityRepo:        identityRepo,
+		userService:         userService,
+		organizationService: organizationService,
+	}
+}
+
+func (s *Service) Enabled() bool {
+	return s.cfg.Enabled()
+}
+
+// WebURL is the URL to redirect users to after they have signed in.
+func (s *Service) WebURL() string {
+	return s.webCfg.URL
+}
+
+func (s *Service) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	if !s.Enabled() {
+		return "", ErrDisabled
+	}
+	return s.provider.AuthCodeURL(ctx, state, nonce, verifier)
+}
+
+// SignIn exchanges the authorization code for the identity of the user, and returns the Sturdy user that is linked to
+// it. Users that sign in for the first time are linked to the Sturdy user with the same email, or to a new user if
+// there is none. All users are added to the configured organization.
+//
+// Emails that the provider has not verified (if they are allowed at all) can't be trusted to belong to the user, so
+// they are never used to link to an existing user, only to create new ones.
+func (s *Service) SignIn(ctx context.Context, code, verifier, nonce string) (*users.User, error) {
+	if !s.Enabled() {
+		return nil, ErrDisabled
+	}
+
+	claims, err := s.provider.Exchange(ctx, code, verifier, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	if !claims.EmailVerified && !s.cfg.AllowUnverifiedEmails {
+		return nil, ErrEmailNotVerified
+	}
+	if !s.cfg.DomainAllowed(claims.Email) {
+		return nil, ErrDomainNotAllowed
+	}
+
+	var usr *users.User
+	identity, err := s.identityRepo.Get(ctx, s.cfg.Issuer, claims.Subject)
+	switch {
+	case err == nil:
+		if usr, err = s.userService.GetByID(ctx, identity.UserID); err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		if usr, err = s.link(ctx, claims); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("failed to get identity: %w", err)
+	}
+
+	if err := s.addToOrganization(ctx, usr); err != nil {
+		return nil, err
+	}
+
+	return usr, nil
+}
+
+// link links the identity to the user with the same email if the email is verified, or to a new user.
+func (s *Service) link(ctx context.Context, claims *provider.Claims) (*users.User, error) {
+	usr, err := s.userService.GetByEmail(ctx, claims.Email)
+	switch {
+	case err == nil && claims.EmailVerified:
+	case err == nil:
+		return nil, fmt.Errorf("%w: the email is used by an existing user", ErrEmailNotVerified)
+	case errors.Is(err, sql.ErrNoRows):
+		name := claims.Name
+		if name == "" {
+			name = claims.Email
+		}
+		if usr, err = s.userService.CreateWithVerifiedEmail(ctx, name, claims.Email); err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+		s.logger.Info("created user from identity provider",
+			zap.String("user_id", usr.ID),
+			zap.String("subject", claims.Subject),
+		)
+	default:
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+
+	if err := s.identityRepo.Create(ctx, &oidc.Identity{
+		Issuer:        s.cfg.Issuer,
+		Subject:       claims.Subject,
+		UserID:        usr.ID,
+		EmailVerified: claims.EmailVerified,
+		CreatedAt:     time.Now(),
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create identity: %w", err)
+	}
+
+	return usr, nil
+}
+
+func (s *Service) addToOrganization(ctx context.Context, usr *users.User) error {
+	if s.cfg.OrganizationID == "" {
+		return nil
+	}
+
+	isMember, err := s.organizationService.CanAccess(ctx, usr.ID, s.cfg.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrOrganizationAccess, err)
+	}
+	if isMember {
+		return nil
+	}
+
+	if _, err := s.organizationService.AddMember(ctx, s.cfg.OrganizationID, usr.ID, usr.ID, organization.RoleMember); err != nil {
+		return fmt.Errorf("%w: %s", ErrOrganizationAccess, err)
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package oidc
+
+import "time"
+
+// Identity links a user at an OpenID Connect provider to their Sturdy user.
+type Identity struct {
+	Issuer  string `db:"issuer"`
+	Subject string `db:"subject"`
+	UserID  string `db:"user_id"`
+	// EmailVerified is true if the provider had verified the email of the user when the identity was linked.
+	EmailVerified bool      `db:"email_verified"`
+	CreatedAt     time.Time `db:"created_at"`
+}
@@ -25,6 +25,7 @@ import (
 	module_configuration "getsturdy.com/api/pkg/configuration/module"
 	"getsturdy.com/api/pkg/di"
 	"getsturdy.com/api/pkg/events"
+	"getsturdy.com/api/pkg/gc"
 	service_gc "getsturdy.com/api/pkg/gc/service"
 	module_github "getsturdy.com/api/pkg/github/module"
 	gqldataloader "getsturdy.com/api/pkg/graphql/dataloader"
@@ -471,7 +472,7 @@ func TestCreate(t *testing.T) {
 
 	{
 		// Trigger GC
-		err := gcService.WorkWithOptions(context.Background(), logger, codebaseRes.ID, 0, 0)
+		err := gcService.WorkWithOptions(context.Background(), logger, codebaseRes.ID, 0, &gc.SnapshotRetentionPolicy{})
 		assert.NoError(t, err)
 
 		// make another change (after gc)
@@ -23,12 +23,14 @@ func (d *database) Create(ctx context.Context, token *onetime.Token) error {
 			key,
 			user_id,
 			created_at,
-			clicks
+			clicks,
+			expires_at
 		) VALUES (
 			:key,
 			:user_id,
 			:created_at,
-			:clicks
+			:clicks,
+			:expires_at
 		)
 	`, token); err != nil {
 		return fmt.Errorf("failed to insert: %w", err)
@@ -40,7 +42,7 @@ func (d *database) Update(ctx context.Context, token *onetime.Token) error {
 	if _, err := d.db.NamedExecContext(ctx, `
 		UPDATE onetime_tokens SET
 			clicks = :clicks
-		WHERE key = :key
+		WHERE key = :key AND user_id = :user_id
 	`, token); err != nil {
 		return fmt.Errorf("failed to update: %w", err)
 	}
@@ -54,7 +56,8 @@ func (m *database) Get(ctx context.Context, userID, key string) (*onetime.Token,
 			key,
 			user_id,
 			created_at,
-			clicks
+			clicks,
+			expires_at
 		FROM onetime_tokens
 		WHERE user_id = $1 AND key = $2
 	`, userID, key); err != nil {
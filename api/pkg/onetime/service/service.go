@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"getsturdy.com/api/pkg/onetime"
 	db_onetime "getsturdy.com/api/pkg/onetime/db"
@@ -29,6 +30,21 @@ func (s *Service) CreateToken(ctx context.Context, userID string) (*onetime.Toke
 	return token, nil
 }
 
+// CreateTokenValidUntil creates a token that is valid until expiresAt, for tokens that are shared for longer than
+// ten minutes. Tokens that are not used to sign in as a user should be created for an ID that is not a user ID, so that
+// they can't be used as magic link codes.
+func (s *Service) CreateTokenValidUntil(ctx context.Context, userID string, expiresAt time.Time) (*onetime.Token, error) {
+	token, err := onetime.NewValidUntil(userID, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(ctx, token); err != nil {
+		return nil, fmt.Errorf("failed to create token: %w", err)
+	}
+	return token, nil
+}
+
 var (
 	ErrExpired = fmt.Errorf("token expired")
 	ErrReused  = fmt.Errorf("token reused")
@@ -36,9 +52,15 @@ var (
 )
 
 func (s *Service) Resolve(ctx context.Context, user *users.User, key string) (*onetime.Token, error) {
+	return s.ResolveByUserID(ctx, user.ID, key)
+}
+
+// ResolveByUserID resolves a token by the ID that it was created for, for tokens that are not resolved by the user
+// that they were created for.
+func (s *Service) ResolveByUserID(ctx context.Context, userID, key string) (*onetime.Token, error) {
 	key = strings.ToUpper(key)
 
-	token, err := s.repo.Get(ctx, user.ID, key)
+	token, err := s.repo.Get(ctx, userID, key)
 	switch {
 	case err == nil:
 		if token.IsExpired() {
@@ -1,7 +1,9 @@
 package onetime
 
 import (
+	crypto_rand "crypto/rand"
 	"fmt"
+	"math/big"
 	"math/rand"
 	"time"
 )
@@ -13,6 +15,8 @@ type Token struct {
 	CreatedAt time.Time `db:"created_at"`
 	// Number of times the token has been used.
 	Clicks int `db:"clicks"`
+	// ExpiresAt is when the token expires. Tokens without it expire ten minutes after they are created.
+	ExpiresAt *time.Time `db:"expires_at"`
 }
 
 var letters = []rune("ABCDEFGHIJKLMNOPQRSTUVWXYZ123456789")
@@ -35,9 +39,43 @@ func New(userID string) *Token {
 	}
 }
 
+// validUntilKeyLength is the length of the keys of tokens that are valid for longer than ten minutes, so long that
+// they can't be guessed while the token is valid.
+const validUntilKeyLength = 24
+
+// NewValidUntil returns a token that is valid until expiresAt, with a long and securely random key.
+func NewValidUntil(userID string, expiresAt time.Time) (*Token, error) {
+	key, err := secureRandSeq(validUntilKeyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	return &Token{
+		Key:       key,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+		ExpiresAt: &expiresAt,
+	}, nil
+}
+
+func secureRandSeq(n int) (string, error) {
+	max := big.NewInt(int64(len(letters)))
+	b := make([]rune, n)
+	for i := range b {
+		idx, err := crypto_rand.Int(crypto_rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b[i] = letters[idx.Int64()]
+	}
+	return string(b), nil
+}
+
 const expireAfter = time.Minute * 10
 
 func (t *Token) IsExpired() bool {
+	if t.ExpiresAt != nil {
+		return !time.Now().Before(*t.ExpiresAt)
+	}
 	return time.Since(t.CreatedAt) > expireAfter
 }
 
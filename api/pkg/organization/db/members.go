@@ -27,7 +27,7 @@ func NewMember(db *sqlx.DB) MemberRepository {
 
 func (r *memberRepository) GetByUserIDAndOrganizationID(ctx context.Context, userID, organizationID string) (*organization.Member, error) {
 	var mem organization.Member
-	if err := r.db.GetContext(ctx, &mem, `SELECT id, user_id, organization_id, created_at, created_by, deleted_at, deleted_by
+	if err := r.db.GetContext(ctx, &mem, `SELECT id, user_id, organization_id, created_at, created_by, deleted_at, deleted_by, role
 		FROM organization_members
 		WHERE user_id = $1
 		  AND organization_id = $2
@@ -39,7 +39,7 @@ func (r *memberRepository) GetByUserIDAndOrganizationID(ctx context.Context, use
 
 func (r *memberRepository) ListByOrganizationID(ctx context.Context, id string) ([]*organization.Member, error) {
 	var res []*organization.Member
-	if err := r.db.SelectContext(ctx, &res, `SELECT id, user_id, organization_id, created_at, created_by, deleted_at, deleted_by
+	if err := r.db.SelectContext(ctx, &res, `SELECT id, user_id, organization_id, created_at, created_by, deleted_at, deleted_by, role
 		FROM organization_members
 		WHERE organization_id = $1
 		  AND deleted_at IS NULL`, id); err != nil {
@@ -50,7 +50,7 @@ func (r *memberRepository) ListByOrganizationID(ctx context.Context, id string)
 
 func (r *memberRepository) ListByUserID(ctx context.Context, id string) ([]*organization.Member, error) {
 	var res []*organization.Member
-	if err := r.db.SelectContext(ctx, &res, `SELECT id, user_id, organization_id, created_at, created_by, deleted_at, deleted_by
+	if err := r.db.SelectContext(ctx, &res, `SELECT id, user_id, organization_id, created_at, created_by, deleted_at, deleted_by, role
 		FROM organization_members
 		WHERE user_id = $1
 		  AND deleted_at IS NULL`, id); err != nil {
@@ -60,8 +60,11 @@ func (r *memberRepository) ListByUserID(ctx context.Context, id string) ([]*orga
 }
 
 func (r *memberRepository) Create(ctx context.Context, mem organization.Member) error {
-	if _, err := r.db.NamedExecContext(ctx, `INSERT INTO organization_members (id, user_id, organization_id, created_at, created_by, deleted_at, deleted_by)
-		VALUES (:id, :user_id, :organization_id, :created_at, :created_by, :deleted_at, :deleted_by)`,
+	if mem.Role == "" {
+		mem.Role = organization.RoleMember
+	}
+	if _, err := r.db.NamedExecContext(ctx, `INSERT INTO organization_members (id, user_id, organization_id, created_at, created_by, deleted_at, deleted_by, role)
+		VALUES (:id, :user_id, :organization_id, :created_at, :created_by, :deleted_at, :deleted_by, :role)`,
 		mem); err != nil {
 		return fmt.Errorf("failed to create organization: %w", err)
 	}
@@ -71,7 +74,8 @@ func (r *memberRepository) Create(ctx context.Context, mem organization.Member)
 func (r *memberRepository) Update(ctx context.Context, org *organization.Member) error {
 	if _, err := r.db.NamedExecContext(ctx, `UPDATE organization_members
 		SET deleted_at = :deleted_at,
-		    deleted_by = :deleted_by
+		    deleted_by = :deleted_by,
+		    role = :role
 		WHERE id = :id
 `, org); err != nil {
 		return fmt.Errorf("failed to update organization: %w", err)
@@ -4,11 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/gosimple/slug"
 	"github.com/graph-gophers/graphql-go"
 
+	"getsturdy.com/api/pkg/accesstokens"
 	"getsturdy.com/api/pkg/auth"
 	service_auth "getsturdy.com/api/pkg/auth/service"
 	"getsturdy.com/api/pkg/codebase"
@@ -137,6 +139,10 @@ func (r *organizationRootResolver) Organization(ctx context.Context, args resolv
 }
 
 func (r *organizationRootResolver) CreateOrganization(ctx context.Context, args resolvers.CreateOrganizationArgs) (resolvers.OrganizationResolver, error) {
+	if err := auth.RequireScope(ctx, accesstokens.ScopeAdmin); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
 	org, err := r.service.Create(ctx, args.Input.Name)
 	if err != nil {
 		return nil, gqlerrors.Error(err)
@@ -151,10 +157,22 @@ func (r *organizationRootResolver) AddUserToOrganization(ctx context.Context, ar
 		return nil, gqlerrors.Error(err)
 	}
 
-	if err := r.authService.CanWrite(ctx, org); err != nil {
+	if err := r.authService.CanAdmin(ctx, org); err != nil {
 		return nil, gqlerrors.Error(err)
 	}
 
+	role := organization.RoleMember
+	if args.Input.Role != nil {
+		role = parseRole(*args.Input.Role)
+	}
+
+	// only owners can add other owners
+	if role == organization.RoleOwner {
+		if err := r.ensureOwner(ctx, org.ID); err != nil {
+			return nil, gqlerrors.Error(err)
+		}
+	}
+
 	user, err := r.userService.GetByEmail(ctx, args.Input.Email)
 	if err != nil {
 		return nil, gqlerrors.Error(err)
@@ -165,7 +183,7 @@ func (r *organizationRootResolver) AddUserToOrganization(ctx context.Context, ar
 		return nil, err
 	}
 
-	if _, err := r.service.AddMember(ctx, org.ID, user.ID, addedByUserID); err != nil {
+	if _, err := r.service.AddMember(ctx, org.ID, user.ID, addedByUserID, role); err != nil {
 		return nil, gqlerrors.Error(err)
 	}
 
@@ -178,22 +196,103 @@ func (r *organizationRootResolver) RemoveUserFromOrganization(ctx context.Contex
 		return nil, gqlerrors.Error(err)
 	}
 
-	if err := r.authService.CanWrite(ctx, org); err != nil {
+	if err := r.authService.CanAdmin(ctx, org); err != nil {
 		return nil, gqlerrors.Error(err)
 	}
 
+	member, err := r.service.GetMember(ctx, org.ID, string(args.Input.UserID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	// only owners can remove other owners
+	if member.Role == organization.RoleOwner {
+		if err := r.ensureOwner(ctx, org.ID); err != nil {
+			return nil, gqlerrors.Error(err)
+		}
+	}
+
 	removedByUserID, err := auth.UserID(ctx)
 	if err != nil {
 		return nil, gqlerrors.Error(err)
 	}
 
-	if err := r.service.RemoveMember(ctx, org.ID, string(args.Input.UserID), removedByUserID); err != nil {
+	err = r.service.RemoveMember(ctx, org.ID, member.UserID, removedByUserID)
+	switch {
+	case err == nil:
+	case errors.Is(err, service_organization.ErrLastOwner):
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", err.Error())
+	default:
+		return nil, gqlerrors.Error(err)
+	}
+
+	return &organizationResolver{root: r, org: org}, nil
+}
+
+func (r *organizationRootResolver) UpdateOrganizationMemberRole(ctx context.Context, args resolvers.UpdateOrganizationMemberRoleArgs) (resolvers.OrganizationResolver, error) {
+	org, err := r.service.GetByID(ctx, string(args.Input.OrganizationID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.authService.CanAdmin(ctx, org); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	member, err := r.service.GetMember(ctx, org.ID, string(args.Input.UserID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	// only owners can promote or demote owners
+	role := parseRole(args.Input.Role)
+	if role == organization.RoleOwner || member.Role == organization.RoleOwner {
+		if err := r.ensureOwner(ctx, org.ID); err != nil {
+			return nil, gqlerrors.Error(err)
+		}
+	}
+
+	_, err = r.service.SetMemberRole(ctx, org.ID, member.UserID, role)
+	switch {
+	case err == nil:
+	case errors.Is(err, service_organization.ErrLastOwner):
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", err.Error())
+	default:
 		return nil, gqlerrors.Error(err)
 	}
 
 	return &organizationResolver{root: r, org: org}, nil
 }
 
+// ensureOwner returns an error if the authenticated user is not an owner of the organization.
+func (r *organizationRootResolver) ensureOwner(ctx context.Context, organizationID string) error {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return err
+	}
+	member, err := r.service.GetMember(ctx, organizationID, userID)
+	if err != nil {
+		return err
+	}
+	if member.Role != organization.RoleOwner {
+		return fmt.Errorf("only owners can manage owners: %w", auth.ErrForbidden)
+	}
+	return nil
+}
+
+// parseRole converts a MemberRole enum value to a role.
+func parseRole(role string) organization.Role {
+	return organization.Role(strings.ToLower(role))
+}
+
+// formatRole converts a role to a MemberRole enum value.
+func formatRole(role organization.Role) string {
+	if role == "" {
+		return ""
+	}
+	return strings.ToUpper(string(role[:1])) + string(role[1:])
+}
+
 type organizationResolver struct {
 	root *organizationRootResolver
 	org  *organization.Organization
@@ -234,6 +333,37 @@ func (r *organizationResolver) Members(ctx context.Context) ([]resolvers.AuthorR
 	return res, nil
 }
 
+func (r *organizationResolver) Memberships(ctx context.Context) ([]resolvers.OrganizationMemberResolver, error) {
+	members, err := r.root.service.Members(ctx, r.org.ID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	res := make([]resolvers.OrganizationMemberResolver, 0, len(members))
+	for _, m := range members {
+		res = append(res, &memberResolver{root: r.root, member: m})
+	}
+	return res, nil
+}
+
+func (r *organizationResolver) ViewerRole(ctx context.Context) (*string, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	member, err := r.root.service.GetMember(ctx, r.org.ID, userID)
+	switch {
+	case err == nil:
+		role := formatRole(member.Role)
+		return &role, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil
+	default:
+		return nil, gqlerrors.Error(err)
+	}
+}
+
 func (r *organizationResolver) Codebases(ctx context.Context) ([]resolvers.CodebaseResolver, error) {
 	userID, err := auth.UserID(ctx)
 	if err != nil {
@@ -242,10 +372,11 @@ func (r *organizationResolver) Codebases(ctx context.Context) ([]resolvers.Codeb
 
 	var isMemberOfOrganization bool
 
-	_, err = r.root.service.GetMember(ctx, r.org.ID, userID)
+	member, err := r.root.service.GetMember(ctx, r.org.ID, userID)
 	switch {
 	case err == nil:
-		isMemberOfOrganization = true
+		// guests only see the codebases they've been added to
+		isMemberOfOrganization = member.Role != organization.RoleGuest
 	case errors.Is(err, sql.ErrNoRows):
 		isMemberOfOrganization = false
 	case err != nil:
@@ -286,9 +417,26 @@ func (r *organizationResolver) Licenses(ctx context.Context) ([]resolvers.Licens
 	return r.root.licensesRootResolver.InternalListForOrganizationID(ctx, r.org.ID)
 }
 
+func (r *organizationResolver) RequireTwoFactor() bool {
+	return r.org.RequireTwoFactor
+}
+
 func (r *organizationResolver) Writeable(ctx context.Context) bool {
 	if err := r.root.authService.CanWrite(ctx, r.org); err == nil {
 		return true
 	}
 	return false
 }
+
+type memberResolver struct {
+	root   *organizationRootResolver
+	member *organization.Member
+}
+
+func (r *memberResolver) Author(ctx context.Context) (resolvers.AuthorResolver, error) {
+	return r.root.authorRootResolver.Author(ctx, graphql.ID(r.member.UserID))
+}
+
+func (r *memberResolver) Role() string {
+	return formatRole(r.member.Role)
+}
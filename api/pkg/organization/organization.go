@@ -14,6 +14,9 @@ type Organization struct {
 	CreatedBy string              `db:"created_by"`
 	DeletedAt *time.Time          `db:"deleted_at"`
 	DeletedBy *string             `db:"deleted_by"`
+	// RequireTwoFactor is true if all members must have two-factor authentication enabled to access the
+	// organization.
+	RequireTwoFactor bool `db:"require_two_factor"`
 }
 
 type Member struct {
@@ -24,4 +27,35 @@ type Member struct {
 	CreatedBy      string     `db:"created_by"`
 	DeletedAt      *time.Time `db:"deleted_at"`
 	DeletedBy      *string    `db:"deleted_by"`
+	Role           Role       `db:"role"`
+}
+
+// Role is the role of a member of an organization or a codebase.
+type Role string
+
+const (
+	// RoleOwner can do everything an admin can, and manage the other owners.
+	RoleOwner Role = "owner"
+	// RoleAdmin can manage members, billing and integrations.
+	RoleAdmin Role = "admin"
+	// RoleMember can work on all codebases.
+	RoleMember Role = "member"
+	// RoleGuest can only see the codebases they are explicitly added to.
+	RoleGuest Role = "guest"
+)
+
+var Roles = []Role{RoleOwner, RoleAdmin, RoleMember, RoleGuest}
+
+func (r Role) Valid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManage returns true if the role can manage members, billing and integrations.
+func (r Role) CanManage() bool {
+	return r == RoleOwner || r == RoleAdmin
 }
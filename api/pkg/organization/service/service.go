@@ -17,6 +17,11 @@ import (
 	"github.com/google/uuid"
 )
 
+var (
+	ErrInvalidRole = errors.New("invalid role")
+	ErrLastOwner   = errors.New("organizations must have at least one owner")
+)
+
 type Service struct {
 	organizationRepository       db_organization.Repository
 	organizationMemberRepository db_organization.MemberRepository
@@ -100,8 +105,8 @@ func (svc *Service) Create(ctx context.Context, name string) (*organization.Orga
 		return nil, fmt.Errorf("failed to create organization: %w", err)
 	}
 
-	// add the creator as a member
-	if _, err := svc.AddMember(ctx, org.ID, userID, userID); err != nil {
+	// add the creator as the owner
+	if _, err := svc.AddMember(ctx, org.ID, userID, userID, organization.RoleOwner); err != nil {
 		return nil, fmt.Errorf("failed to invite creator to organization: %w", err)
 	}
 
@@ -111,13 +116,18 @@ func (svc *Service) Create(ctx context.Context, name string) (*organization.Orga
 	return &org, nil
 }
 
-func (svc *Service) AddMember(ctx context.Context, orgID, userID, addedByUserID string) (*organization.Member, error) {
+func (svc *Service) AddMember(ctx context.Context, orgID, userID, addedByUserID string, role organization.Role) (*organization.Member, error) {
+	if !role.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+
 	member := organization.Member{
 		ID:             uuid.NewString(),
 		OrganizationID: orgID,
 		UserID:         userID,
 		CreatedAt:      time.Now(),
 		CreatedBy:      addedByUserID,
+		Role:           role,
 	}
 
 	if err := svc.organizationMemberRepository.Create(ctx, member); err != nil {
@@ -127,17 +137,74 @@ func (svc *Service) AddMember(ctx context.Context, orgID, userID, addedByUserID
 	svc.analyticsServcie.Capture(ctx, "add member to organization",
 		analytics.OrganizationID(orgID),
 		analytics.Property("user_id", userID),
+		analytics.Property("role", role),
 	)
 
 	return &member, nil
 }
 
+// SetMemberRole changes the role of a member of the organization. The last owner of an organization can not be
+// demoted.
+func (svc *Service) SetMemberRole(ctx context.Context, orgID, userID string, role organization.Role) (*organization.Member, error) {
+	if !role.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRole, role)
+	}
+
+	member, err := svc.organizationMemberRepository.GetByUserIDAndOrganizationID(ctx, userID, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get member: %w", err)
+	}
+
+	if member.Role == role {
+		return member, nil
+	}
+
+	if member.Role == organization.RoleOwner {
+		if err := svc.ensureOtherOwner(ctx, orgID, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	member.Role = role
+	if err := svc.organizationMemberRepository.Update(ctx, member); err != nil {
+		return nil, fmt.Errorf("could not update member: %w", err)
+	}
+
+	svc.analyticsServcie.Capture(ctx, "update organization member role",
+		analytics.OrganizationID(orgID),
+		analytics.Property("user_id", userID),
+		analytics.Property("role", role),
+	)
+
+	return member, nil
+}
+
+// ensureOtherOwner returns ErrLastOwner if userID is the only owner of the organization.
+func (svc *Service) ensureOtherOwner(ctx context.Context, orgID, userID string) error {
+	members, err := svc.organizationMemberRepository.ListByOrganizationID(ctx, orgID)
+	if err != nil {
+		return fmt.Errorf("could not list members: %w", err)
+	}
+	for _, m := range members {
+		if m.Role == organization.RoleOwner && m.UserID != userID {
+			return nil
+		}
+	}
+	return ErrLastOwner
+}
+
 func (svc *Service) RemoveMember(ctx context.Context, orgID, userID, deletedByUserID string) error {
 	member, err := svc.organizationMemberRepository.GetByUserIDAndOrganizationID(ctx, userID, orgID)
 	if err != nil {
 		return fmt.Errorf("could not get member: %w", err)
 	}
 
+	if member.Role == organization.RoleOwner {
+		if err := svc.ensureOtherOwner(ctx, orgID, userID); err != nil {
+			return err
+		}
+	}
+
 	t := time.Now()
 	member.DeletedAt = &t
 	member.DeletedBy = &deletedByUserID
@@ -178,6 +245,15 @@ func (svc *Service) GetMemberByUserIDAndOrganizationID(ctx context.Context, user
 	return member, nil
 }
 
+// SetRequireTwoFactor sets if all members of the organization must have two-factor authentication enabled.
+func (svc *Service) SetRequireTwoFactor(ctx context.Context, org *organization.Organization, required bool) error {
+	org.RequireTwoFactor = required
+	if err := svc.organizationRepository.Update(ctx, org); err != nil {
+		return fmt.Errorf("failed to update organization: %w", err)
+	}
+	return nil
+}
+
 // TODO: Only allow calls to GetFirst from self hosted installations
 func (svc *Service) GetFirst(ctx context.Context) (*organization.Organization, error) {
 	org, err := svc.organizationRepository.GetFirst(ctx)
@@ -0,0 +1,92 @@
+// Package outbound is used to make requests to urls that are configured by users, such as webhooks. Requests can only
+// be made to public addresses, so that the urls can't be used to reach the server itself, its internal network or the
+// metadata service of the cloud provider.
+package outbound
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrInvalidURL       = errors.New("invalid url")
+	ErrForbiddenAddress = errors.New("forbidden address")
+)
+
+// sharedAddressSpace is 100.64.0.0/10, which is used for carrier-grade NAT, and by some cloud providers internally.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsPublic returns false for loopback, private, link-local (which includes the metadata services of the cloud
+// providers), unspecified and multicast addresses.
+func IsPublic(ip net.IP) bool {
+	switch {
+	case ip.IsLoopback(),
+		ip.IsPrivate(),
+		ip.IsLinkLocalUnicast(),
+		ip.IsLinkLocalMulticast(),
+		ip.IsInterfaceLocalMulticast(),
+		ip.IsMulticast(),
+		ip.IsUnspecified(),
+		sharedAddressSpace.Contains(ip):
+		return false
+	}
+	return true
+}
+
+// ValidateURL returns an error if the url is not an http or https url, or if its host is an address that is not public.
+// Hostnames are checked when they are resolved, by the clients from NewClient.
+func ValidateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidURL
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	if ip := net.ParseIP(host); ip != nil && !IsPublic(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, ip)
+	}
+	return nil
+}
+
+// NewClient returns a client that can only connect to public addresses. The addresses are checked after the hosts are
+// resolved, right before connecting, so the check can't be bypassed with DNS records that point to internal addresses,
+// or that change between the validation of a url and the request (DNS rebinding).
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: control,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// requests are not made through proxies, as the proxy would be the address that is checked
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+	}
+}
+
+func control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !IsPublic(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package outbound
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPublic(t *testing.T) {
+	for addr, expected := range map[string]bool{
+		"1.1.1.1":          true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.0.0.1":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::":               false,
+		"224.0.0.1":        false,
+		"::ffff:127.0.0.1": false,
+	} {
+		assert.Equal(t, expected, IsPublic(net.ParseIP(addr)), addr)
+	}
+}
+
+func TestValidateURL(t *testing.T) {
+	for rawURL, expected := range map[string]error{
+		"https://example.com/hooks":          nil,
+		"http://1.1.1.1:8080/hooks":          nil,
+		"ftp://example.com":                  ErrInvalidURL,
+		"https://":                           ErrInvalidURL,
+		"http://localhost:3000":              ErrForbiddenAddress,
+		"http://api.localhost":               ErrForbiddenAddress,
+		"http://127.0.0.1/":                  ErrForbiddenAddress,
+		"http://[::1]:3000/":                 ErrForbiddenAddress,
+		"http://169.254.169.254/latest/meta": ErrForbiddenAddress,
+		"http://10.0.0.1/":                   ErrForbiddenAddress,
+	} {
+		err := ValidateURL(rawURL)
+		if expected == nil {
+			assert.NoError(t, err, rawURL)
+		} else {
+			assert.ErrorIs(t, err, expected, rawURL)
+		}
+	}
+}
+
+func TestNewClient_loopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	_, err = NewClient(time.Second).Do(req)
+	if assert.Error(t, err) {
+		assert.True(t, errors.Is(err, ErrForbiddenAddress), err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"time"
 
+	"getsturdy.com/api/pkg/accesstokens"
 	"getsturdy.com/api/pkg/auth"
 	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
 	"getsturdy.com/api/pkg/graphql/resolvers"
@@ -31,6 +32,10 @@ func (p *pkiRootResolver) AddPublicKey(ctx context.Context, args resolvers.AddPu
 		return nil, gqlerrors.Error(err)
 	}
 
+	if err := auth.RequireScope(ctx, accesstokens.ScopeAdmin); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
 	rows, err := p.repo.GetKeyByUserID(userID)
 	switch {
 	// If the user doesn't have any public keys, continue
@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/ssh"
 
+	"getsturdy.com/api/pkg/accesstokens"
 	"getsturdy.com/api/pkg/auth"
 	"getsturdy.com/api/pkg/pki"
 	"getsturdy.com/api/pkg/pki/db"
@@ -34,6 +35,11 @@ func AddPublicKey(repo db.Repo) func(c *gin.Context) {
 			return
 		}
 
+		if err := auth.RequireScope(c.Request.Context(), accesstokens.ScopeAdmin); err != nil {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
 		// If this user already has this key, respond as OK
 		if _, err := repo.GetByPublicKeyAndUserID(req.PublicKey, userID); err == nil {
 			c.JSON(http.StatusOK, gin.H{"status": "ok"})
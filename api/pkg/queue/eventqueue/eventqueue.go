@@ -0,0 +1,114 @@
+package eventqueue
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/events"
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/names"
+
+	"go.uber.org/zap"
+)
+
+// Handler handles the reference of an event, such as the ID of a workspace.
+type Handler func(ctx context.Context, reference string) error
+
+type Entry struct {
+	Reference string `json:"reference"`
+}
+
+// Queue enqueues the references of the events of a type that are sent to any codebase, and passes them to a handler.
+//
+// Messages that fail to be handled are not acked, so that they are retried.
+type Queue struct {
+	logger       *zap.Logger
+	queue        queue.Queue
+	eventsReader events.EventReader
+	name         names.IncompleteQueueName
+	eventType    events.EventType
+	handler      Handler
+}
+
+func New(
+	logger *zap.Logger,
+	queue queue.Queue,
+	eventsReader events.EventReader,
+	name names.IncompleteQueueName,
+	eventType events.EventType,
+	handler Handler,
+) *Queue {
+	return &Queue{
+		logger:       logger,
+		queue:        queue,
+		eventsReader: eventsReader,
+		name:         name,
+		eventType:    eventType,
+		handler:      handler,
+	}
+}
+
+func (q *Queue) Enqueue(ctx context.Context, reference string) error {
+	if err := q.queue.Publish(ctx, q.name, &Entry{
+		Reference: reference,
+	}); err != nil {
+		return fmt.Errorf("could not publish to queue: %w", err)
+	}
+	return nil
+}
+
+// Start subscribes to the events, and handles the messages of the queue until the context is cancelled.
+func (q *Queue) Start(ctx context.Context) error {
+	cancel := q.eventsReader.SubscribeCodebases(func(eventType events.EventType, reference string) error {
+		if eventType != q.eventType {
+			return nil
+		}
+		if err := q.Enqueue(ctx, reference); err != nil {
+			// returning an error would cancel the subscription
+			q.logger.Error("failed to enqueue", zap.String("reference", reference), zap.Error(err))
+		}
+		return nil
+	})
+	defer cancel()
+
+	messages := make(chan queue.Message)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				q.logger.Error("panic in runner", zap.String("panic", fmt.Sprintf("%v", rec)))
+			}
+		}()
+
+		for msg := range messages {
+			t0 := time.Now()
+
+			m := &Entry{}
+			if err := msg.As(m); err != nil {
+				q.logger.Error("failed to decode message", zap.Error(err))
+				continue
+			}
+			logger := q.logger.With(zap.String("reference", m.Reference))
+
+			if err := q.handler(context.Background(), m.Reference); err != nil {
+				logger.Error("failed to handle message", zap.Error(err))
+				continue
+			}
+
+			if err := msg.Ack(); err != nil {
+				logger.Error("failed to ack message", zap.Error(err))
+				continue
+			}
+
+			logger.Info("handled message", zap.Duration("duration", time.Since(t0)))
+		}
+	}()
+
+	q.logger.Info("starting queue", zap.Stringer("queue_name", q.name))
+	if err := q.queue.Subscribe(ctx, q.name, messages); err != nil {
+		return fmt.Errorf("could not subscribe to queue: %w", err)
+	}
+	q.logger.Info("queue stoped", zap.Stringer("queue_name", q.name))
+
+	return nil
+}
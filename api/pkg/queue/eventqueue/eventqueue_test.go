@@ -0,0 +1,122 @@
+package eventqueue_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/events"
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/eventqueue"
+	"getsturdy.com/api/pkg/queue/names"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type message struct {
+	data  []byte
+	acked chan struct{}
+}
+
+func (m *message) As(v interface{}) error {
+	return json.Unmarshal(m.data, v)
+}
+
+func (m *message) Ack() error {
+	close(m.acked)
+	return nil
+}
+
+// fakeQueue delivers the published messages to the subscriber of the queue.
+type fakeQueue struct {
+	messages chan *message
+}
+
+func (q *fakeQueue) Publish(_ context.Context, _ names.IncompleteQueueName, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	q.messages <- &message{data: data, acked: make(chan struct{})}
+	return nil
+}
+
+func (q *fakeQueue) Subscribe(ctx context.Context, _ names.IncompleteQueueName, messages chan<- queue.Message) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg := <-q.messages:
+			messages <- msg
+		}
+	}
+}
+
+func TestQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	eventsReadWriter := events.NewInMemory()
+	fq := &fakeQueue{messages: make(chan *message, 10)}
+
+	var mu sync.Mutex
+	var handled []string
+	handledExcept := func(except string) []string {
+		mu.Lock()
+		defer mu.Unlock()
+		var res []string
+		for _, reference := range handled {
+			if reference != except {
+				res = append(res, reference)
+			}
+		}
+		return res
+	}
+	q := eventqueue.New(zap.NewNop(), fq, eventsReadWriter, names.ReviewStale, events.WorkspaceUpdatedSnapshot, func(_ context.Context, reference string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, reference)
+		if reference == "fails" {
+			return errors.New("failed")
+		}
+		return nil
+	})
+	go func() {
+		assert.NoError(t, q.Start(ctx))
+	}()
+
+	// wait for the queue to subscribe to the events
+	assert.Eventually(t, func() bool {
+		eventsReadWriter.CodebaseEvent("", events.WorkspaceUpdatedSnapshot, "subscribed")
+		return len(handledExcept("")) > 0
+	}, time.Second, 10*time.Millisecond)
+
+	// other events are ignored
+	eventsReadWriter.CodebaseEvent("", events.WorkspaceUpdated, "ignored")
+
+	succeeds := &message{data: []byte(`{"reference":"succeeds"}`), acked: make(chan struct{})}
+	fails := &message{data: []byte(`{"reference":"fails"}`), acked: make(chan struct{})}
+	fq.messages <- succeeds
+	fq.messages <- fails
+
+	select {
+	case <-succeeds.acked:
+	case <-time.After(time.Second):
+		t.Fatal("message that was handled is not acked")
+	}
+
+	assert.Eventually(t, func() bool {
+		return len(handledExcept("subscribed")) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"succeeds", "fails"}, handledExcept("subscribed"))
+
+	select {
+	case <-fails.acked:
+		t.Fatal("message that failed to be handled is acked")
+	default:
+	}
+}
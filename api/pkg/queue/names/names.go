@@ -24,11 +24,20 @@ const (
 	WorkspaceUpdated                  IncompleteQueueName = "workspace_updated"
 	CodebaseUpdated                   IncompleteQueueName = "codebase_updated"
 	CodebaseGarbageCollection         IncompleteQueueName = "codebase_gc"
+	CodebaseMaintenance               IncompleteQueueName = "codebase_maintenance"
 	CodebaseGitHubCloner              IncompleteQueueName = "codebase_githubCloner"
 	CodebaseGitHubPullRequestImporter IncompleteQueueName = "codebase_githubPRimport"
 	GithubWebhooks                    IncompleteQueueName = "github_webhooks"
 	ViewSnapshot                      IncompleteQueueName = "view_snapshot"
 	CITriggerQueue                    IncompleteQueueName = "ci_trigger"
+	StatusAutoRevert                  IncompleteQueueName = "status_autoRevert"
+	WebhooksEvents                    IncompleteQueueName = "webhooks_events"
+	WebhooksDeliveries                IncompleteQueueName = "webhooks_deliveries"
+	ReviewOwners                      IncompleteQueueName = "review_owners"
+	ReviewStale                       IncompleteQueueName = "review_stale"
+	ChangelogMaterialize              IncompleteQueueName = "changelog_materialize"
+	GitHubPushTag                     IncompleteQueueName = "github_pushTag"
+	NotificationChat                  IncompleteQueueName = "notification_chat"
 	longestAllowedName                IncompleteQueueName = "xxxxxXXXXXxxxxxXXXXXxxxx" // To highlight how long a name can be
 )
 
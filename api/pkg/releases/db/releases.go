@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"getsturdy.com/api/pkg/releases"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type ReleaseRepository interface {
+	Create(context.Context, *releases.Release) error
+	Get(ctx context.Context, id string) (*releases.Release, error)
+	GetByTagID(ctx context.Context, tagID string) (*releases.Release, error)
+	ListByCodebaseID(ctx context.Context, codebaseID string) ([]*releases.Release, error)
+	Update(context.Context, *releases.Release) error
+}
+
+type releaseRepo struct {
+	db *sqlx.DB
+}
+
+func NewReleaseRepository(db *sqlx.DB) ReleaseRepository {
+	return &releaseRepo{db: db}
+}
+
+func (r *releaseRepo) Create(ctx context.Context, release *releases.Release) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO releases
+			(id, codebase_id, tag_id, previous_tag_id, title, notes, created_by, created_at, updated_at)
+		VALUES
+			(:id, :codebase_id, :tag_id, :previous_tag_id, :title, :notes, :created_by, :created_at, :updated_at)
+	`, release); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (r *releaseRepo) Get(ctx context.Context, id string) (*releases.Release, error) {
+	var res releases.Release
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, codebase_id, tag_id, previous_tag_id, title, notes, created_by, created_at, updated_at
+		FROM
+			releases
+		WHERE
+			id = $1
+	`, id); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *releaseRepo) GetByTagID(ctx context.Context, tagID string) (*releases.Release, error) {
+	var res releases.Release
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, codebase_id, tag_id, previous_tag_id, title, notes, created_by, created_at, updated_at
+		FROM
+			releases
+		WHERE
+			tag_id = $1
+	`, tagID); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *releaseRepo) ListByCodebaseID(ctx context.Context, codebaseID string) ([]*releases.Release, error) {
+	var res []*releases.Release
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, codebase_id, tag_id, previous_tag_id, title, notes, created_by, created_at, updated_at
+		FROM
+			releases
+		WHERE
+			codebase_id = $1
+		ORDER BY
+			created_at DESC
+	`, codebaseID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *releaseRepo) Update(ctx context.Context, release *releases.Release) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		UPDATE
+			releases
+		SET
+			title = :title,
+			notes = :notes,
+			updated_at = :updated_at
+		WHERE
+			id = :id
+	`, release); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
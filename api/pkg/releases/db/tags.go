@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"getsturdy.com/api/pkg/releases"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TagRepository interface {
+	Create(context.Context, *releases.Tag) error
+	Get(ctx context.Context, id string) (*releases.Tag, error)
+	GetByName(ctx context.Context, codebaseID, name string) (*releases.Tag, error)
+	ListByCodebaseID(ctx context.Context, codebaseID string) ([]*releases.Tag, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type tagRepo struct {
+	db *sqlx.DB
+}
+
+func NewTagRepository(db *sqlx.DB) TagRepository {
+	return &tagRepo{db: db}
+}
+
+func (r *tagRepo) Create(ctx context.Context, tag *releases.Tag) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO tags
+			(id, codebase_id, name, change_id, commit_id, message, created_by, created_at)
+		VALUES
+			(:id, :codebase_id, :name, :change_id, :commit_id, :message, :created_by, :created_at)
+	`, tag); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (r *tagRepo) Get(ctx context.Context, id string) (*releases.Tag, error) {
+	var res releases.Tag
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, codebase_id, name, change_id, commit_id, message, created_by, created_at
+		FROM
+			tags
+		WHERE
+			id = $1
+	`, id); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *tagRepo) GetByName(ctx context.Context, codebaseID, name string) (*releases.Tag, error) {
+	var res releases.Tag
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, codebase_id, name, change_id, commit_id, message, created_by, created_at
+		FROM
+			tags
+		WHERE
+			codebase_id = $1
+			AND name = $2
+	`, codebaseID, name); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *tagRepo) ListByCodebaseID(ctx context.Context, codebaseID string) ([]*releases.Tag, error) {
+	var res []*releases.Tag
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, codebase_id, name, change_id, commit_id, message, created_by, created_at
+		FROM
+			tags
+		WHERE
+			codebase_id = $1
+		ORDER BY
+			created_at DESC
+	`, codebaseID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *tagRepo) Delete(ctx context.Context, id string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		DELETE FROM
+			tags
+		WHERE
+			id = $1
+	`, id); err != nil {
+		return fmt.Errorf("failed to delete: %w", err)
+	}
+	return nil
+}
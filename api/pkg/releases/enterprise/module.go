@@ -0,0 +1,10 @@
+package enterprise
+
+import (
+	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/releases/enterprise/service"
+)
+
+func Module(c *di.Container) {
+	c.Import(service.Module)
+}
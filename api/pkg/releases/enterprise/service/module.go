@@ -0,0 +1,10 @@
+package service
+
+import (
+	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/releases/service"
+)
+
+func Module(c *di.Container) {
+	c.Register(New, new(service.Service))
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"getsturdy.com/api/pkg/change"
+	"getsturdy.com/api/pkg/github"
+	service_github "getsturdy.com/api/pkg/github/enterprise/service"
+	workers_github "getsturdy.com/api/pkg/github/enterprise/workers"
+	"getsturdy.com/api/pkg/releases"
+	service_releases "getsturdy.com/api/pkg/releases/service"
+
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	*service_releases.ReleaseService
+
+	logger          *zap.Logger
+	gitHubService   *service_github.Service
+	gitHubTagsQueue *workers_github.TagsQueue
+}
+
+func New(
+	ossService *service_releases.ReleaseService,
+	logger *zap.Logger,
+	gitHubService *service_github.Service,
+	gitHubTagsQueue *workers_github.TagsQueue,
+) *Service {
+	return &Service{
+		ReleaseService:  ossService,
+		logger:          logger.Named("enterpriseReleaseService"),
+		gitHubService:   gitHubService,
+		gitHubTagsQueue: gitHubTagsQueue,
+	}
+}
+
+// CreateTag creates the tag, and queues a push of it to GitHub if the codebase has an enabled GitHub integration.
+func (s *Service) CreateTag(ctx context.Context, ch *change.Change, name, message, userID string) (*releases.Tag, error) {
+	gitHubRepository, err := s.gitHubService.GetRepositoryByCodebaseID(ctx, ch.CodebaseID)
+	switch {
+	case err == nil, errors.Is(err, sql.ErrNoRows):
+	default:
+		return nil, fmt.Errorf("failed to get gitHubRepository: %w", err)
+	}
+
+	tag, err := s.ReleaseService.CreateTag(ctx, ch, name, message, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if gitHubRepository != nil && gitHubRepository.IntegrationEnabled {
+		if err := s.gitHubTagsQueue.Enqueue(ctx, &github.PushTagEvent{
+			CodebaseID: tag.CodebaseID,
+			TagName:    tag.Name,
+		}); err != nil {
+			// the tag is created in Sturdy, failing here would leave it unusable
+			s.logger.Error("failed to enqueue tag push to github", zap.String("tag_id", tag.ID), zap.Error(err))
+		}
+	}
+
+	return tag, nil
+}
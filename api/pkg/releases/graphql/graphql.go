@@ -0,0 +1,312 @@
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"getsturdy.com/api/pkg/auth"
+	service_auth "getsturdy.com/api/pkg/auth/service"
+	"getsturdy.com/api/pkg/change"
+	service_change "getsturdy.com/api/pkg/change/service"
+	service_codebase "getsturdy.com/api/pkg/codebase/service"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/releases"
+	service_releases "getsturdy.com/api/pkg/releases/service"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type rootResolver struct {
+	authService     *service_auth.Service
+	codebaseService *service_codebase.Service
+	changeService   *service_change.Service
+	releaseService  service_releases.Service
+
+	changeRootResolver resolvers.ChangeRootResolver
+	authorResolver     resolvers.AuthorRootResolver
+}
+
+func New(
+	authService *service_auth.Service,
+	codebaseService *service_codebase.Service,
+	changeService *service_change.Service,
+	releaseService service_releases.Service,
+	changeRootResolver resolvers.ChangeRootResolver,
+	authorResolver resolvers.AuthorRootResolver,
+) resolvers.ReleaseRootResolver {
+	return &rootResolver{
+		authService:        authService,
+		codebaseService:    codebaseService,
+		changeService:      changeService,
+		releaseService:     releaseService,
+		changeRootResolver: changeRootResolver,
+		authorResolver:     authorResolver,
+	}
+}
+
+func (r *rootResolver) InternalTagsByCodebaseID(ctx context.Context, codebaseID string) ([]resolvers.TagResolver, error) {
+	tags, err := r.releaseService.ListTags(ctx, codebaseID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	res := make([]resolvers.TagResolver, 0, len(tags))
+	for _, tag := range tags {
+		res = append(res, &tagResolver{root: r, tag: tag})
+	}
+	return res, nil
+}
+
+func (r *rootResolver) InternalReleasesByCodebaseID(ctx context.Context, codebaseID string) ([]resolvers.ReleaseResolver, error) {
+	rr, err := r.releaseService.ListReleases(ctx, codebaseID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	res := make([]resolvers.ReleaseResolver, 0, len(rr))
+	for _, release := range rr {
+		res = append(res, &releaseResolver{root: r, release: release})
+	}
+	return res, nil
+}
+
+func (r *rootResolver) Release(ctx context.Context, args resolvers.ReleaseArgs) (resolvers.ReleaseResolver, error) {
+	release, err := r.releaseService.GetRelease(ctx, string(args.ID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.canAccessCodebase(ctx, release.CodebaseID, r.authService.CanRead); err != nil {
+		return nil, err
+	}
+
+	return &releaseResolver{root: r, release: release}, nil
+}
+
+func (r *rootResolver) CreateTag(ctx context.Context, args resolvers.CreateTagArgs) (resolvers.TagResolver, error) {
+	ch, err := r.changeService.GetChangeByID(ctx, change.ID(args.Input.ChangeID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.canAccessCodebase(ctx, ch.CodebaseID, r.authService.CanWrite); err != nil {
+		return nil, err
+	}
+
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	var message string
+	if args.Input.Message != nil {
+		message = *args.Input.Message
+	}
+
+	tag, err := r.releaseService.CreateTag(ctx, ch, args.Input.Name, message, userID)
+	switch {
+	case err == nil:
+		return &tagResolver{root: r, tag: tag}, nil
+	case errors.Is(err, releases.ErrInvalidTagName):
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "invalid tag name")
+	case errors.Is(err, releases.ErrTagExists):
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "a tag with this name already exists")
+	default:
+		return nil, gqlerrors.Error(fmt.Errorf("failed to create tag: %w", err))
+	}
+}
+
+func (r *rootResolver) CreateRelease(ctx context.Context, args resolvers.CreateReleaseArgs) (resolvers.ReleaseResolver, error) {
+	tag, err := r.releaseService.GetTag(ctx, string(args.Input.TagID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.canAccessCodebase(ctx, tag.CodebaseID, r.authService.CanWrite); err != nil {
+		return nil, err
+	}
+
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	switch _, err := r.releaseService.GetReleaseByTag(ctx, tag); {
+	case err == nil:
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "a release already exists for this tag")
+	case errors.Is(err, sql.ErrNoRows):
+	default:
+		return nil, gqlerrors.Error(err)
+	}
+
+	var previousTag *releases.Tag
+	if args.Input.PreviousTagID != nil {
+		previousTag, err = r.releaseService.GetTag(ctx, string(*args.Input.PreviousTagID))
+		if err != nil {
+			return nil, gqlerrors.Error(err)
+		}
+		if previousTag.CodebaseID != tag.CodebaseID {
+			return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "tags are not in the same codebase")
+		}
+	}
+
+	title := tag.Name
+	if args.Input.Title != nil && *args.Input.Title != "" {
+		title = *args.Input.Title
+	}
+
+	release, err := r.releaseService.CreateRelease(ctx, tag, previousTag, title, userID)
+	if err != nil {
+		return nil, gqlerrors.Error(fmt.Errorf("failed to create release: %w", err))
+	}
+
+	return &releaseResolver{root: r, release: release}, nil
+}
+
+func (r *rootResolver) UpdateRelease(ctx context.Context, args resolvers.UpdateReleaseArgs) (resolvers.ReleaseResolver, error) {
+	release, err := r.releaseService.GetRelease(ctx, string(args.Input.ID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.canAccessCodebase(ctx, release.CodebaseID, r.authService.CanWrite); err != nil {
+		return nil, err
+	}
+
+	if args.Input.Title != nil {
+		if *args.Input.Title == "" {
+			return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "title can not be empty")
+		}
+		release.Title = *args.Input.Title
+	}
+	if args.Input.Notes != nil {
+		release.Notes = *args.Input.Notes
+	}
+
+	if err := r.releaseService.UpdateRelease(ctx, release); err != nil {
+		return nil, gqlerrors.Error(fmt.Errorf("failed to update release: %w", err))
+	}
+
+	return &releaseResolver{root: r, release: release}, nil
+}
+
+func (r *rootResolver) canAccessCodebase(ctx context.Context, codebaseID string, can func(context.Context, interface{}) error) error {
+	cb, err := r.codebaseService.GetByID(ctx, codebaseID)
+	if err != nil {
+		return gqlerrors.Error(err)
+	}
+	if err := can(ctx, cb); err != nil {
+		return gqlerrors.Error(err)
+	}
+	return nil
+}
+
+func (r *rootResolver) tag(ctx context.Context, id string) (resolvers.TagResolver, error) {
+	tag, err := r.releaseService.GetTag(ctx, id)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return &tagResolver{root: r, tag: tag}, nil
+}
+
+type tagResolver struct {
+	root *rootResolver
+	tag  *releases.Tag
+}
+
+func (r *tagResolver) ID() graphql.ID {
+	return graphql.ID(r.tag.ID)
+}
+
+func (r *tagResolver) Name() string {
+	return r.tag.Name
+}
+
+func (r *tagResolver) Message() string {
+	return r.tag.Message
+}
+
+func (r *tagResolver) Change(ctx context.Context) (resolvers.ChangeResolver, error) {
+	ch, err := r.root.changeService.GetChangeByID(ctx, r.tag.ChangeID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return r.root.changeRootResolver.InternalChange(ch), nil
+}
+
+func (r *tagResolver) Author(ctx context.Context) (resolvers.AuthorResolver, error) {
+	return r.root.authorResolver.Author(ctx, graphql.ID(r.tag.CreatedBy))
+}
+
+func (r *tagResolver) CreatedAt() int32 {
+	return int32(r.tag.CreatedAt.Unix())
+}
+
+func (r *tagResolver) Release(ctx context.Context) (resolvers.ReleaseResolver, error) {
+	release, err := r.root.releaseService.GetReleaseByTag(ctx, r.tag)
+	switch {
+	case err == nil:
+		return &releaseResolver{root: r.root, release: release}, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil
+	default:
+		return nil, gqlerrors.Error(err)
+	}
+}
+
+type releaseResolver struct {
+	root    *rootResolver
+	release *releases.Release
+}
+
+func (r *releaseResolver) ID() graphql.ID {
+	return graphql.ID(r.release.ID)
+}
+
+func (r *releaseResolver) Title() string {
+	return r.release.Title
+}
+
+func (r *releaseResolver) Notes() string {
+	return r.release.Notes
+}
+
+func (r *releaseResolver) Tag(ctx context.Context) (resolvers.TagResolver, error) {
+	return r.root.tag(ctx, r.release.TagID)
+}
+
+func (r *releaseResolver) PreviousTag(ctx context.Context) (resolvers.TagResolver, error) {
+	if r.release.PreviousTagID == nil {
+		return nil, nil
+	}
+	return r.root.tag(ctx, *r.release.PreviousTagID)
+}
+
+func (r *releaseResolver) Changes(ctx context.Context) ([]resolvers.ChangeResolver, error) {
+	changes, err := r.root.releaseService.ListReleaseChanges(ctx, r.release)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	res := make([]resolvers.ChangeResolver, 0, len(changes))
+	for _, ch := range changes {
+		res = append(res, r.root.changeRootResolver.InternalChange(ch))
+	}
+	return res, nil
+}
+
+func (r *releaseResolver) Author(ctx context.Context) (resolvers.AuthorResolver, error) {
+	return r.root.authorResolver.Author(ctx, graphql.ID(r.release.CreatedBy))
+}
+
+func (r *releaseResolver) CreatedAt() int32 {
+	return int32(r.release.CreatedAt.Unix())
+}
+
+func (r *releaseResolver) UpdatedAt() *int32 {
+	if r.release.UpdatedAt == nil {
+		return nil
+	}
+	t := int32(r.release.UpdatedAt.Unix())
+	return &t
+}
@@ -0,0 +1,19 @@
+//go:build enterprise || cloud
+// +build enterprise cloud
+
+package module
+
+import (
+	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/releases/db"
+	"getsturdy.com/api/pkg/releases/enterprise"
+	"getsturdy.com/api/pkg/releases/graphql"
+	"getsturdy.com/api/pkg/releases/service"
+)
+
+func Module(c *di.Container) {
+	c.Register(service.New)
+	c.Import(db.Module)
+	c.Import(enterprise.Module)
+	c.Import(graphql.Module)
+}
@@ -0,0 +1,39 @@
+package releases
+
+import (
+	"strings"
+
+	"getsturdy.com/api/pkg/change"
+	"getsturdy.com/api/pkg/change/message"
+)
+
+// GenerateNotes generates markdown release notes from the titles and descriptions of the changes.
+// Changes are listed in the order they are given.
+func GenerateNotes(changes []*change.Change) string {
+	var b strings.Builder
+	for _, ch := range changes {
+		title := "Untitled"
+		if ch.Title != nil && strings.TrimSpace(*ch.Title) != "" {
+			title = strings.TrimSpace(*ch.Title)
+		}
+		b.WriteString("* ")
+		b.WriteString(title)
+		b.WriteString("\n")
+
+		lines := strings.Split(message.CommitMessage(ch.UpdatedDescription), "\n")
+		for i, line := range lines {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			// the first line of the description is usually the title
+			if i == 0 && line == title {
+				continue
+			}
+			b.WriteString("  ")
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package releases
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"getsturdy.com/api/pkg/change"
+)
+
+var (
+	ErrInvalidTagName = errors.New("invalid tag name")
+	ErrTagExists      = errors.New("tag already exists")
+)
+
+// Tag is an annotated git tag on a change on trunk.
+type Tag struct {
+	ID         string    `db:"id"`
+	CodebaseID string    `db:"codebase_id"`
+	Name       string    `db:"name"`
+	ChangeID   change.ID `db:"change_id"`
+	CommitID   string    `db:"commit_id"`
+	Message    string    `db:"message"`
+	CreatedBy  string    `db:"created_by"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// Release is a tag with release notes. The notes describe the changes between the previous tag and the tag.
+type Release struct {
+	ID            string     `db:"id"`
+	CodebaseID    string     `db:"codebase_id"`
+	TagID         string     `db:"tag_id"`
+	PreviousTagID *string    `db:"previous_tag_id"`
+	Title         string     `db:"title"`
+	Notes         string     `db:"notes"`
+	CreatedBy     string     `db:"created_by"`
+	CreatedAt     time.Time  `db:"created_at"`
+	UpdatedAt     *time.Time `db:"updated_at"`
+}
+
+var invalidTagNameCharacters = regexp.MustCompile(`[\x00-\x20\x7f~^:?*\[\\]`)
+
+// ValidateTagName returns ErrInvalidTagName if name can not be used as a git tag name.
+// See git-check-ref-format(1).
+func ValidateTagName(name string) error {
+	switch {
+	case name == "",
+		len(name) > 100,
+		name == "@",
+		invalidTagNameCharacters.MatchString(name),
+		strings.Contains(name, ".."),
+		strings.Contains(name, "@{"),
+		strings.Contains(name, "//"),
+		strings.HasPrefix(name, "-"),
+		strings.HasPrefix(name, "/"),
+		strings.HasSuffix(name, "/"),
+		strings.HasSuffix(name, "."),
+		strings.HasSuffix(name, ".lock"):
+		return ErrInvalidTagName
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") {
+			return ErrInvalidTagName
+		}
+	}
+	return nil
+}
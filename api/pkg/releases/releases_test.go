@@ -0,0 +1,69 @@
+package releases_test
+
+import (
+	"testing"
+
+	"getsturdy.com/api/pkg/change"
+	"getsturdy.com/api/pkg/releases"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTagName(t *testing.T) {
+	cases := []struct {
+		name  string
+		valid bool
+	}{
+		{name: "v1.0.0", valid: true},
+		{name: "release/2022-02-14", valid: true},
+		{name: "", valid: false},
+		{name: "v1 0", valid: false},
+		{name: "v1..0", valid: false},
+		{name: "-v1", valid: false},
+		{name: "v1/", valid: false},
+		{name: "v1.", valid: false},
+		{name: "v1.lock", valid: false},
+		{name: "release/.hidden", valid: false},
+		{name: "v1@{0}", valid: false},
+		{name: "v1~1", valid: false},
+		{name: "v1:0", valid: false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := releases.ValidateTagName(tc.name)
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, releases.ErrInvalidTagName)
+			}
+		})
+	}
+}
+
+func TestGenerateNotes(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	changes := []*change.Change{
+		{
+			Title:              str("Fix api bug"),
+			UpdatedDescription: "<p>Fix api bug</p><p>Requests with an empty body no longer crash the server.</p>",
+		},
+		{
+			Title:              str("Add web client"),
+			UpdatedDescription: "<p>Add web client</p>",
+		},
+		{
+			UpdatedDescription: "<ul><li><p>one</p></li><li><p>two</p></li></ul>",
+		},
+	}
+
+	expected := "* Fix api bug\n" +
+		"  Requests with an empty body no longer crash the server.\n" +
+		"* Add web client\n" +
+		"* Untitled\n" +
+		"  * one\n" +
+		"  * two\n"
+
+	assert.Equal(t, expected, releases.GenerateNotes(changes))
+}
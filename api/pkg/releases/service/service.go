@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/change"
+	service_change "getsturdy.com/api/pkg/change/service"
+	"getsturdy.com/api/pkg/releases"
+	db_releases "getsturdy.com/api/pkg/releases/db"
+	service_user "getsturdy.com/api/pkg/users/service"
+	"getsturdy.com/api/vcs"
+	"getsturdy.com/api/vcs/executor"
+
+	"github.com/google/uuid"
+	git "github.com/libgit2/git2go/v33"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxReleaseChanges is the max number of changes that are included in the notes of a release
+	maxReleaseChanges = 1000
+	// logBatchSize is the number of commits that are read at a time, when looking for the previous tag
+	logBatchSize = 100
+)
+
+type Service interface {
+	CreateTag(ctx context.Context, ch *change.Change, name, message, userID string) (*releases.Tag, error)
+	GetTag(ctx context.Context, id string) (*releases.Tag, error)
+	ListTags(ctx context.Context, codebaseID string) ([]*releases.Tag, error)
+
+	CreateRelease(ctx context.Context, tag *releases.Tag, previousTag *releases.Tag, title, userID string) (*releases.Release, error)
+	GetRelease(ctx context.Context, id string) (*releases.Release, error)
+	GetReleaseByTag(ctx context.Context, tag *releases.Tag) (*releases.Release, error)
+	ListReleases(ctx context.Context, codebaseID string) ([]*releases.Release, error)
+	UpdateRelease(ctx context.Context, release *releases.Release) error
+	ListReleaseChanges(ctx context.Context, release *releases.Release) ([]*change.Change, error)
+}
+
+type ReleaseService struct {
+	logger           *zap.Logger
+	tagRepo          db_releases.TagRepository
+	releaseRepo      db_releases.ReleaseRepository
+	changeService    *service_change.Service
+	userService      service_user.Service
+	executorProvider executor.Provider
+}
+
+func New(
+	logger *zap.Logger,
+	tagRepo db_releases.TagRepository,
+	releaseRepo db_releases.ReleaseRepository,
+	changeService *service_change.Service,
+	userService service_user.Service,
+	executorProvider executor.Provider,
+) *ReleaseService {
+	return &ReleaseService{
+		logger:           logger.Named("releaseService"),
+		tagRepo:          tagRepo,
+		releaseRepo:      releaseRepo,
+		changeService:    changeService,
+		userService:      userService,
+		executorProvider: executorProvider,
+	}
+}
+
+// CreateTag creates an annotated tag on trunk pointing to the change.
+func (s *ReleaseService) CreateTag(ctx context.Context, ch *change.Change, name, message, userID string) (*releases.Tag, error) {
+	if err := releases.ValidateTagName(name); err != nil {
+		return nil, err
+	}
+	if ch.CommitID == nil {
+		return nil, fmt.Errorf("change %s has no commit", ch.ID)
+	}
+
+	switch _, err := s.tagRepo.GetByName(ctx, ch.CodebaseID, name); {
+	case err == nil:
+		return nil, releases.ErrTagExists
+	case errors.Is(err, sql.ErrNoRows):
+	default:
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	user, err := s.userService.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	now := time.Now()
+	tag := &releases.Tag{
+		ID:         uuid.NewString(),
+		CodebaseID: ch.CodebaseID,
+		Name:       name,
+		ChangeID:   ch.ID,
+		CommitID:   *ch.CommitID,
+		Message:    message,
+		CreatedBy:  userID,
+		CreatedAt:  now,
+	}
+	// the tag is created in the database first, so that it's not created in the repository if the name is taken
+	if err := s.tagRepo.Create(ctx, tag); err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	tagger := git.Signature{
+		Name:  user.Name,
+		Email: user.Email,
+		When:  now,
+	}
+
+	if err := s.executorProvider.New().GitWrite(func(repo vcs.RepoGitWriter) error {
+		_, err := repo.CreateAnnotatedTag(name, *ch.CommitID, message, tagger)
+		return err
+	}).ExecTrunk(ch.CodebaseID, "createTag"); err != nil {
+		if deleteErr := s.tagRepo.Delete(ctx, tag.ID); deleteErr != nil {
+			s.logger.Error("failed to delete tag", zap.String("tag_id", tag.ID), zap.Error(deleteErr))
+		}
+		if errors.Is(err, vcs.ErrTagExists) {
+			return nil, releases.ErrTagExists
+		}
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+func (s *ReleaseService) GetTag(ctx context.Context, id string) (*releases.Tag, error) {
+	return s.tagRepo.Get(ctx, id)
+}
+
+// ListTags returns the tags of the codebase, newest first.
+func (s *ReleaseService) ListTags(ctx context.Context, codebaseID string) ([]*releases.Tag, error) {
+	return s.tagRepo.ListByCodebaseID(ctx, codebaseID)
+}
+
+// CreateRelease creates a release for the tag, with notes generated from the changes since previousTag.
+// If previousTag is nil, the closest tag in the history of the tag is used. If there is no such tag, the notes
+// include all changes up to the tag.
+func (s *ReleaseService) CreateRelease(ctx context.Context, tag *releases.Tag, previousTag *releases.Tag, title, userID string) (*releases.Release, error) {
+	if previousTag == nil {
+		var err error
+		previousTag, err = s.tagBefore(ctx, tag)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	release := &releases.Release{
+		ID:         uuid.NewString(),
+		CodebaseID: tag.CodebaseID,
+		TagID:      tag.ID,
+		Title:      title,
+		CreatedBy:  userID,
+		CreatedAt:  time.Now(),
+	}
+	if previousTag != nil {
+		release.PreviousTagID = &previousTag.ID
+	}
+
+	changes, err := s.listChanges(ctx, tag, previousTag)
+	if err != nil {
+		return nil, err
+	}
+	release.Notes = releases.GenerateNotes(changes)
+
+	if err := s.releaseRepo.Create(ctx, release); err != nil {
+		return nil, fmt.Errorf("failed to create release: %w", err)
+	}
+
+	return release, nil
+}
+
+func (s *ReleaseService) GetRelease(ctx context.Context, id string) (*releases.Release, error) {
+	return s.releaseRepo.Get(ctx, id)
+}
+
+func (s *ReleaseService) GetReleaseByTag(ctx context.Context, tag *releases.Tag) (*releases.Release, error) {
+	return s.releaseRepo.GetByTagID(ctx, tag.ID)
+}
+
+// ListReleases returns the releases of the codebase, newest first.
+func (s *ReleaseService) ListReleases(ctx context.Context, codebaseID string) ([]*releases.Release, error) {
+	return s.releaseRepo.ListByCodebaseID(ctx, codebaseID)
+}
+
+func (s *ReleaseService) UpdateRelease(ctx context.Context, release *releases.Release) error {
+	now := time.Now()
+	release.UpdatedAt = &now
+	return s.releaseRepo.Update(ctx, release)
+}
+
+// ListReleaseChanges returns the changes that are included in the release, newest first.
+func (s *ReleaseService) ListReleaseChanges(ctx context.Context, release *releases.Release) ([]*change.Change, error) {
+	tag, err := s.tagRepo.Get(ctx, release.TagID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	var previousTag *releases.Tag
+	if release.PreviousTagID != nil {
+		previousTag, err = s.tagRepo.Get(ctx, *release.PreviousTagID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get previous tag: %w", err)
+		}
+	}
+
+	return s.listChanges(ctx, tag, previousTag)
+}
+
+func (s *ReleaseService) listChanges(ctx context.Context, tag, previousTag *releases.Tag) ([]*change.Change, error) {
+	var fromCommitID *string
+	if previousTag != nil {
+		fromCommitID = &previousTag.CommitID
+	}
+	changes, err := s.changeService.ListBetween(ctx, tag.CodebaseID, fromCommitID, tag.CommitID, maxReleaseChanges)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list changes: %w", err)
+	}
+	return changes, nil
+}
+
+// tagBefore returns the closest tag on the first parent history of the commit of tag, the same history that the
+// notes are generated from, or nil if there is none. Other tags of the same commit are skipped, and if there are
+// many tags of a commit, the newest is used.
+func (s *ReleaseService) tagBefore(ctx context.Context, tag *releases.Tag) (*releases.Tag, error) {
+	tags, err := s.tagRepo.ListByCodebaseID(ctx, tag.CodebaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	byCommitID := make(map[string]*releases.Tag, len(tags))
+	for _, t := range tags {
+		if t.CommitID == tag.CommitID {
+			continue
+		}
+		// newest first
+		if _, ok := byCommitID[t.CommitID]; !ok {
+			byCommitID[t.CommitID] = t
+		}
+	}
+	if len(byCommitID) == 0 {
+		return nil, nil
+	}
+
+	next := tag.CommitID
+	for next != "" {
+		var commits []*vcs.CommitDetails
+		if err := s.executorProvider.New().GitRead(func(repo vcs.RepoGitReader) error {
+			var err error
+			commits, err = repo.LogFirstParent(next, logBatchSize)
+			return err
+		}).ExecTrunk(tag.CodebaseID, "releasesTagBefore"); err != nil {
+			return nil, fmt.Errorf("failed to read log: %w", err)
+		}
+
+		next = ""
+		for _, c := range commits {
+			if t, ok := byCommitID[c.CommitID]; ok {
+				return t, nil
+			}
+		}
+		if len(commits) > 0 {
+			if last := commits[len(commits)-1]; len(last.Parents) > 0 {
+				next = last.Parents[0]
+			}
+		}
+	}
+	return nil, nil
+}
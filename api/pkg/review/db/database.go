@@ -18,8 +18,8 @@ func NewReviewRepository(db *sqlx.DB) ReviewRepository {
 }
 
 func (r *database) Create(ctx context.Context, rev review.Review) error {
-	_, err := r.db.NamedExecContext(ctx, `INSERT INTO workspace_reviews (id, codebase_id, workspace_id, user_id, grade, created_at, is_replaced, requested_by)
-		VALUES(:id, :codebase_id, :workspace_id, :user_id, :grade, :created_at, :is_replaced, :requested_by)`, rev)
+	_, err := r.db.NamedExecContext(ctx, `INSERT INTO workspace_reviews (id, codebase_id, workspace_id, user_id, grade, created_at, is_replaced, requested_by, snapshot_id, is_stale)
+		VALUES(:id, :codebase_id, :workspace_id, :user_id, :grade, :created_at, :is_replaced, :requested_by, :snapshot_id, :is_stale)`, rev)
 	if err != nil {
 		return fmt.Errorf("failed to insert review: %w", err)
 	}
@@ -31,7 +31,9 @@ func (r *database) Update(ctx context.Context, rev *review.Review) error {
 		SET grade = :grade,
 		    dismissed_at = :dismissed_at,
 		    is_replaced = :is_replaced,
-		    requested_by = :requested_by
+		    requested_by = :requested_by,
+		    snapshot_id = :snapshot_id,
+		    is_stale = :is_stale
 		WHERE id = :id`, rev)
 	if err != nil {
 		return fmt.Errorf("failed to insert review: %w", err)
@@ -41,7 +43,7 @@ func (r *database) Update(ctx context.Context, rev *review.Review) error {
 
 func (r *database) Get(ctx context.Context, id string) (*review.Review, error) {
 	var res review.Review
-	err := r.db.GetContext(ctx, &res, `SELECT id, codebase_id, workspace_id, user_id, grade, created_at, dismissed_at, is_replaced, requested_by
+	err := r.db.GetContext(ctx, &res, `SELECT id, codebase_id, workspace_id, user_id, grade, created_at, dismissed_at, is_replaced, requested_by, snapshot_id, is_stale
 		FROM workspace_reviews
 		WHERE id = $1`, id)
 	if err != nil {
@@ -52,7 +54,7 @@ func (r *database) Get(ctx context.Context, id string) (*review.Review, error) {
 
 func (r *database) GetLatestByUserAndWorkspace(ctx context.Context, userID, workspaceID string) (*review.Review, error) {
 	var res review.Review
-	err := r.db.GetContext(ctx, &res, `SELECT id, codebase_id, workspace_id, user_id, grade, created_at, dismissed_at, is_replaced, requested_by
+	err := r.db.GetContext(ctx, &res, `SELECT id, codebase_id, workspace_id, user_id, grade, created_at, dismissed_at, is_replaced, requested_by, snapshot_id, is_stale
 		FROM workspace_reviews
 		WHERE workspace_id = $1
 	      AND user_id = $2
@@ -65,7 +67,7 @@ func (r *database) GetLatestByUserAndWorkspace(ctx context.Context, userID, work
 
 func (r *database) ListLatestByWorkspace(ctx context.Context, workspaceID string) ([]*review.Review, error) {
 	var res []*review.Review
-	err := r.db.SelectContext(ctx, &res, `SELECT id, codebase_id, workspace_id, user_id, grade, created_at, dismissed_at, is_replaced, requested_by
+	err := r.db.SelectContext(ctx, &res, `SELECT id, codebase_id, workspace_id, user_id, grade, created_at, dismissed_at, is_replaced, requested_by, snapshot_id, is_stale
 		FROM workspace_reviews
 		WHERE workspace_id = $1
 		AND dismissed_at IS NULL
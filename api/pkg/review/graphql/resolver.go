@@ -51,6 +51,10 @@ func (r *reviewResolver) RequestedBy(ctx context.Context) (resolvers.AuthorResol
 	return r.root.authorRootResolver.Author(ctx, graphql.ID(*r.rev.RequestedBy))
 }
 
+func (r *reviewResolver) IsStale() bool {
+	return r.rev.IsStale
+}
+
 func (r *reviewResolver) Workspace(ctx context.Context) (resolvers.WorkspaceResolver, error) {
 	yes := true
 	resolver, err := (*r.root.workspaceRootResolver).Workspace(ctx, resolvers.WorkspaceArgs{
@@ -18,6 +18,8 @@ import (
 	"getsturdy.com/api/pkg/notification/sender"
 	"getsturdy.com/api/pkg/review"
 	db_review "getsturdy.com/api/pkg/review/db"
+	"getsturdy.com/api/pkg/webhooks"
+	sender_webhooks "getsturdy.com/api/pkg/webhooks/sender"
 	"getsturdy.com/api/pkg/workspaces/activity"
 	activity_sender "getsturdy.com/api/pkg/workspaces/activity/sender"
 	db_workspaces "getsturdy.com/api/pkg/workspaces/db"
@@ -41,6 +43,7 @@ type reviewRootResolver struct {
 	eventsReader       events.EventReader
 	notificationSender sender.NotificationSender
 	activitySender     activity_sender.ActivitySender
+	webhooksSender     sender_webhooks.Sender
 
 	analyticsService *service_analytics.Service
 
@@ -60,6 +63,7 @@ func New(
 	eventsReader events.EventReader,
 	notificationSender sender.NotificationSender,
 	activitySender activity_sender.ActivitySender,
+	webhooksSender sender_webhooks.Sender,
 
 	analyticsService *service_analytics.Service,
 
@@ -79,6 +83,7 @@ func New(
 		eventsReader:       eventsReader,
 		notificationSender: notificationSender,
 		activitySender:     activitySender,
+		webhooksSender:     webhooksSender,
 
 		analyticsService: analyticsService,
 
@@ -145,8 +150,9 @@ func (r *reviewRootResolver) CreateOrUpdateReview(ctx context.Context, args reso
 
 	// Mark existing as replaced
 	if existing, err := r.reviewRepo.GetLatestByUserAndWorkspace(ctx, userID, workspaceID); err == nil {
-		// If this review is the same as the existing one, and the review is not dismissed, don't change anything
-		if existing.DismissedAt == nil && existing.Grade == inputGrade {
+		// If this review is the same as the existing one, of the same snapshot, and the review is not dismissed,
+		// don't change anything
+		if existing.DismissedAt == nil && existing.Grade == inputGrade && sameSnapshot(existing.SnapshotID, ws.LatestSnapshotID) {
 			return &reviewResolver{root: r, rev: existing}, nil
 		}
 
@@ -168,6 +174,7 @@ func (r *reviewRootResolver) CreateOrUpdateReview(ctx context.Context, args reso
 		WorkspaceID: workspaceID,
 		Grade:       inputGrade,
 		CreatedAt:   time.Now(),
+		SnapshotID:  ws.LatestSnapshotID,
 	}
 
 	if err := r.reviewRepo.Create(ctx, rev); err != nil {
@@ -194,6 +201,11 @@ func (r *reviewRootResolver) CreateOrUpdateReview(ctx context.Context, args reso
 		// do not fail
 	}
 
+	if err := r.webhooksSender.Codebase(ctx, ws.CodebaseID, webhooks.EventReviewUpdated, rev.ID); err != nil {
+		r.logger.Error("failed to send webhook event", zap.Error(err))
+		// do not fail
+	}
+
 	r.analyticsService.Capture(ctx, "review created",
 		analytics.CodebaseID(ws.CodebaseID),
 		analytics.Property("workspace_id", ws.ID),
@@ -281,6 +293,11 @@ func (r *reviewRootResolver) RequestReview(ctx context.Context, args resolvers.R
 		r.logger.Error("failed to send workspace event", zap.Error(err))
 		// do not fail
 	}
+	if err := r.webhooksSender.Codebase(ctx, ws.CodebaseID, webhooks.EventReviewRequested, rev.ID); err != nil {
+		r.logger.Error("failed to send webhook event", zap.Error(err))
+		// do not fail
+	}
+
 	r.analyticsService.Capture(ctx, "review requested",
 		analytics.CodebaseID(ws.CodebaseID),
 		analytics.Property("workspace_id", ws.ID),
@@ -317,6 +334,11 @@ func (r *reviewRootResolver) DismissReview(ctx context.Context, args resolvers.D
 		// do not fail
 	}
 
+	if err := r.webhooksSender.Codebase(ctx, rev.CodebaseID, webhooks.EventReviewUpdated, rev.ID); err != nil {
+		r.logger.Error("failed to send webhook event", zap.Error(err))
+		// do not fail
+	}
+
 	r.analyticsService.Capture(ctx, "review dismissed",
 		analytics.CodebaseID(rev.CodebaseID),
 		analytics.Property("workspace_id", rev.WorkspaceID),
@@ -332,3 +354,10 @@ func (r *reviewRootResolver) InternalDismissAllInWorkspace(ctx context.Context,
 	}
 	return nil
 }
+
+func sameSnapshot(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
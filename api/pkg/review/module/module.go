@@ -4,9 +4,17 @@ import (
 	"getsturdy.com/api/pkg/di"
 	"getsturdy.com/api/pkg/review/db"
 	"getsturdy.com/api/pkg/review/graphql"
+	module_owners "getsturdy.com/api/pkg/review/owners/module"
+	"getsturdy.com/api/pkg/review/service"
+	db_viewed "getsturdy.com/api/pkg/review/viewed/db"
+	"getsturdy.com/api/pkg/review/worker"
 )
 
 func Module(c *di.Container) {
 	c.Import(db.Module)
 	c.Import(graphql.Module)
+	c.Import(module_owners.Module)
+	c.Import(service.Module)
+	c.Import(worker.Module)
+	c.Import(db_viewed.Module)
 }
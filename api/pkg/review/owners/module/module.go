@@ -0,0 +1,12 @@
+package module
+
+import (
+	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/review/owners/service"
+	"getsturdy.com/api/pkg/review/owners/worker"
+)
+
+func Module(c *di.Container) {
+	c.Import(service.Module)
+	c.Import(worker.Module)
+}
@@ -0,0 +1,63 @@
+package owners
+
+import (
+	"getsturdy.com/api/pkg/codebase/acl"
+	"getsturdy.com/api/pkg/review"
+	"getsturdy.com/api/pkg/users"
+)
+
+// Set is a set of users that own some of the files that are changed in a workspace, see acl.Owner.
+type Set struct {
+	// ID is the id of the owner in the acl policy
+	ID      string
+	UserIDs []string
+	// IsApproved is true if one of the owners has approved the workspace
+	IsApproved bool
+}
+
+// Required returns the owner sets of the changed paths, in the order that they are defined in the policy.
+//
+// The author of the workspace can't approve their own changes, and is never a required owner. Owner sets without
+// any other members of the codebase are skipped, as they can't be approved by anyone.
+func Required(policy acl.Policy, paths []string, members []*users.User, authorID string, reviews []*review.Review) []*Set {
+	touched := make(map[*acl.Owner]bool)
+	for _, path := range paths {
+		for _, owner := range policy.OwnersOf(acl.Identity{Type: acl.Files, ID: path}) {
+			touched[owner] = true
+		}
+	}
+
+	approvedBy := make(map[string]bool, len(reviews))
+	for _, rev := range reviews {
+		if rev.Grade == review.ReviewGradeApprove && rev.DismissedAt == nil && !rev.IsReplaced {
+			approvedBy[rev.UserID] = true
+		}
+	}
+
+	var res []*Set
+	for _, owner := range policy.Owners {
+		if !touched[owner] {
+			continue
+		}
+
+		set := &Set{ID: owner.ID}
+		for _, member := range members {
+			if member.ID == authorID {
+				continue
+			}
+			if !policy.IsOwner(owner, acl.Identity{Type: acl.Users, ID: member.Email}) {
+				continue
+			}
+			set.UserIDs = append(set.UserIDs, member.ID)
+			if approvedBy[member.ID] {
+				set.IsApproved = true
+			}
+		}
+
+		if len(set.UserIDs) == 0 {
+			continue
+		}
+		res = append(res, set)
+	}
+	return res
+}
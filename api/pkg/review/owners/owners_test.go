@@ -0,0 +1,92 @@
+package owners
+
+import (
+	"testing"
+
+	"getsturdy.com/api/pkg/codebase/acl"
+	"getsturdy.com/api/pkg/review"
+	"getsturdy.com/api/pkg/users"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequired(t *testing.T) {
+	policy := acl.Policy{
+		Groups: []*acl.Group{
+			{
+				ID: "frontend",
+				Members: []*acl.Identifier{
+					{Type: acl.Users, Pattern: "alice@example.com"},
+					{Type: acl.Users, Pattern: "bob@example.com"},
+				},
+			},
+		},
+		Owners: []*acl.Owner{
+			{
+				ID:         "web",
+				Principals: []*acl.Identifier{{Type: acl.Groups, Pattern: "frontend"}},
+				Resources:  []*acl.Identifier{{Type: acl.Files, Pattern: "web/*"}},
+			},
+			{
+				ID:         "api",
+				Principals: []*acl.Identifier{{Type: acl.Users, Pattern: "carol@example.com"}},
+				Resources:  []*acl.Identifier{{Type: acl.Files, Pattern: "api/*"}},
+			},
+			{
+				ID:         "docs",
+				Principals: []*acl.Identifier{{Type: acl.Users, Pattern: "alice@example.com"}},
+				Resources:  []*acl.Identifier{{Type: acl.Files, Pattern: "docs/*"}},
+			},
+		},
+	}
+
+	members := []*users.User{
+		{ID: "alice", Email: "alice@example.com"},
+		{ID: "bob", Email: "bob@example.com"},
+		{ID: "carol", Email: "carol@example.com"},
+	}
+
+	cases := []struct {
+		name     string
+		paths    []string
+		reviews  []*review.Review
+		expected []*Set
+	}{
+		{
+			name:     "not-owned",
+			paths:    []string{"README.md"},
+			expected: nil,
+		},
+		{
+			name:  "owned",
+			paths: []string{"api/main.go", "web/index.ts"},
+			expected: []*Set{
+				{ID: "web", UserIDs: []string{"bob"}},
+				{ID: "api", UserIDs: []string{"carol"}},
+			},
+		},
+		{
+			name:  "approved",
+			paths: []string{"api/main.go", "web/index.ts"},
+			reviews: []*review.Review{
+				{UserID: "bob", Grade: review.ReviewGradeApprove},
+				{UserID: "carol", Grade: review.ReviewGradeRequested},
+			},
+			expected: []*Set{
+				{ID: "web", UserIDs: []string{"bob"}, IsApproved: true},
+				{ID: "api", UserIDs: []string{"carol"}},
+			},
+		},
+		{
+			name:     "only-owned-by-author",
+			paths:    []string{"docs/index.md"},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Required(policy, tc.paths, members, "alice", tc.reviews))
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/codebase/acl/provider"
+	db_codebase "getsturdy.com/api/pkg/codebase/db"
+	"getsturdy.com/api/pkg/events"
+	"getsturdy.com/api/pkg/notification"
+	sender_notification "getsturdy.com/api/pkg/notification/sender"
+	"getsturdy.com/api/pkg/review"
+	db_review "getsturdy.com/api/pkg/review/db"
+	"getsturdy.com/api/pkg/review/owners"
+	db_user "getsturdy.com/api/pkg/users/db"
+	"getsturdy.com/api/pkg/webhooks"
+	sender_webhooks "getsturdy.com/api/pkg/webhooks/sender"
+	"getsturdy.com/api/pkg/workspaces"
+	"getsturdy.com/api/pkg/workspaces/activity"
+	sender_workspace_activity "getsturdy.com/api/pkg/workspaces/activity/sender"
+	service_workspace "getsturdy.com/api/pkg/workspaces/service"
+	service_workspace_watchers "getsturdy.com/api/pkg/workspaces/watchers/service"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	logger *zap.Logger
+
+	aclProvider              *provider.Provider
+	codebaseUserRepo         db_codebase.CodebaseUserRepository
+	userRepo                 db_user.Repository
+	reviewRepo               db_review.ReviewRepository
+	workspaceService         service_workspace.Service
+	workspaceWatchersService *service_workspace_watchers.Service
+
+	notificationSender sender_notification.NotificationSender
+	activitySender     sender_workspace_activity.ActivitySender
+	eventsSender       events.EventSender
+	webhooksSender     sender_webhooks.Sender
+}
+
+func New(
+	logger *zap.Logger,
+	aclProvider *provider.Provider,
+	codebaseUserRepo db_codebase.CodebaseUserRepository,
+	userRepo db_user.Repository,
+	reviewRepo db_review.ReviewRepository,
+	workspaceService service_workspace.Service,
+	workspaceWatchersService *service_workspace_watchers.Service,
+	notificationSender sender_notification.NotificationSender,
+	activitySender sender_workspace_activity.ActivitySender,
+	eventsSender events.EventSender,
+	webhooksSender sender_webhooks.Sender,
+) *Service {
+	return &Service{
+		logger: logger.Named("reviewOwners"),
+
+		aclProvider:              aclProvider,
+		codebaseUserRepo:         codebaseUserRepo,
+		userRepo:                 userRepo,
+		reviewRepo:               reviewRepo,
+		workspaceService:         workspaceService,
+		workspaceWatchersService: workspaceWatchersService,
+
+		notificationSender: notificationSender,
+		activitySender:     activitySender,
+		eventsSender:       eventsSender,
+		webhooksSender:     webhooksSender,
+	}
+}
+
+// Required returns the owner sets of the files that are changed in the workspace, see owners.Required.
+func (s *Service) Required(ctx context.Context, ws *workspaces.Workspace) ([]*owners.Set, error) {
+	codebaseACL, err := s.aclProvider.GetByCodebaseID(ctx, ws.CodebaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get acl: %w", err)
+	}
+
+	// most codebases don't have any owners, skip computing the diffs
+	if len(codebaseACL.Policy.Owners) == 0 {
+		return nil, nil
+	}
+
+	diffs, _, err := s.workspaceService.Diffs(ctx, ws.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get diffs: %w", err)
+	}
+	paths := make([]string, 0, len(diffs)*2)
+	for _, diff := range diffs {
+		if diff.OrigName != "/dev/null" {
+			paths = append(paths, diff.OrigName)
+		}
+		if diff.NewName != "/dev/null" && diff.NewName != diff.OrigName {
+			paths = append(paths, diff.NewName)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	codebaseUsers, err := s.codebaseUserRepo.GetByCodebase(ws.CodebaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list codebase members: %w", err)
+	}
+	userIDs := make([]string, 0, len(codebaseUsers))
+	for _, cu := range codebaseUsers {
+		userIDs = append(userIDs, cu.UserID)
+	}
+	members, err := s.userRepo.GetByIDs(ctx, userIDs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	reviews, err := s.reviewRepo.ListLatestByWorkspace(ctx, ws.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reviews: %w", err)
+	}
+
+	return owners.Required(codebaseACL.Policy, paths, members, ws.UserID, reviews), nil
+}
+
+// RequestReviews requests reviews from the owners of the files that are changed in the workspace. Owners that have
+// already been requested, or that have already reviewed the workspace, are not requested again.
+//
+// Requests that are dismissed are not recreated.
+func (s *Service) RequestReviews(ctx context.Context, workspaceID string) error {
+	ws, err := s.workspaceService.GetByID(ctx, workspaceID)
+	if err != nil {
+		return fmt.Errorf("failed to get workspace: %w", err)
+	}
+	if ws.IsArchived() {
+		return nil
+	}
+
+	sets, err := s.Required(ctx, ws)
+	if err != nil {
+		return err
+	}
+
+	requested := make(map[string]bool)
+	for _, set := range sets {
+		for _, userID := range set.UserIDs {
+			if requested[userID] {
+				continue
+			}
+			requested[userID] = true
+			if err := s.request(ctx, ws, userID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Service) request(ctx context.Context, ws *workspaces.Workspace, userID string) error {
+	existing, err := s.reviewRepo.GetLatestByUserAndWorkspace(ctx, userID, ws.ID)
+	switch {
+	case err == nil:
+		// owners that have been requested, or have reviewed, are not requested again. Neither are owners whose
+		// request or review was dismissed, unless the review was dismissed because it's stale.
+		if existing.DismissedAt == nil || !existing.IsStale {
+			return nil
+		}
+		existing.IsReplaced = true
+		if err := s.reviewRepo.Update(ctx, existing); err != nil {
+			return fmt.Errorf("failed to update review: %w", err)
+		}
+	case errors.Is(err, sql.ErrNoRows):
+	default:
+		return fmt.Errorf("failed to get existing review: %w", err)
+	}
+
+	// the review is requested on behalf of the author of the workspace
+	rev := review.Review{
+		ID:          uuid.NewString(),
+		UserID:      userID,
+		CodebaseID:  ws.CodebaseID,
+		WorkspaceID: ws.ID,
+		Grade:       review.ReviewGradeRequested,
+		CreatedAt:   time.Now(),
+		RequestedBy: &ws.UserID,
+	}
+	if err := s.reviewRepo.Create(ctx, rev); err != nil {
+		return fmt.Errorf("failed to create review: %w", err)
+	}
+
+	if _, err := s.workspaceWatchersService.Watch(ctx, userID, ws.ID); err != nil {
+		return fmt.Errorf("failed to watch workspace: %w", err)
+	}
+
+	if err := s.activitySender.Codebase(ctx, ws.CodebaseID, ws.ID, ws.UserID, activity.WorkspaceActivityTypeRequestedReview, rev.ID); err != nil {
+		return fmt.Errorf("failed to create activity: %w", err)
+	}
+
+	if err := s.notificationSender.User(ctx, userID, ws.CodebaseID, notification.RequestedReviewNotificationType, rev.ID); err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+
+	if err := s.eventsSender.Codebase(ws.CodebaseID, events.WorkspaceUpdatedReviews, ws.ID); err != nil {
+		s.logger.Error("failed to send codebase event", zap.Error(err))
+		// do not fail
+	}
+
+	if err := s.eventsSender.Workspace(ws.ID, events.ReviewUpdated, rev.ID); err != nil {
+		s.logger.Error("failed to send workspace event", zap.Error(err))
+		// do not fail
+	}
+
+	if err := s.webhooksSender.Codebase(ctx, ws.CodebaseID, webhooks.EventReviewRequested, rev.ID); err != nil {
+		s.logger.Error("failed to send webhook event", zap.Error(err))
+		// do not fail
+	}
+
+	s.logger.Info("requested review from owner",
+		zap.String("workspace_id", ws.ID),
+		zap.String("user_id", userID),
+	)
+
+	return nil
+}
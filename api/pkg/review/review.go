@@ -12,6 +12,8 @@ type Review struct {
 	DismissedAt *time.Time  `db:"dismissed_at"`
 	IsReplaced  bool        `db:"is_replaced"` // Is false for new reviews.
 	RequestedBy *string     `db:"requested_by"`
+	SnapshotID  *string     `db:"snapshot_id"` // The latest snapshot of the workspace when the review was made, not set for requested reviews.
+	IsStale     bool        `db:"is_stale"`    // The reviewed files have changed since the review was made.
 }
 
 type ReviewGrade string
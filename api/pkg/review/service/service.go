@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/codebase"
+	db_codebase "getsturdy.com/api/pkg/codebase/db"
+	"getsturdy.com/api/pkg/events"
+	"getsturdy.com/api/pkg/notification"
+	sender_notification "getsturdy.com/api/pkg/notification/sender"
+	"getsturdy.com/api/pkg/review"
+	db_review "getsturdy.com/api/pkg/review/db"
+	"getsturdy.com/api/pkg/snapshots"
+	db_snapshots "getsturdy.com/api/pkg/snapshots/db"
+	"getsturdy.com/api/pkg/webhooks"
+	sender_webhooks "getsturdy.com/api/pkg/webhooks/sender"
+	db_workspaces "getsturdy.com/api/pkg/workspaces/db"
+	"getsturdy.com/api/vcs"
+	"getsturdy.com/api/vcs/executor"
+
+	"go.uber.org/zap"
+)
+
+type Service struct {
+	logger *zap.Logger
+
+	reviewRepo       db_review.ReviewRepository
+	workspaceReader  db_workspaces.WorkspaceReader
+	codebaseRepo     db_codebase.CodebaseRepository
+	snapshotsRepo    db_snapshots.Repository
+	executorProvider executor.Provider
+
+	notificationSender sender_notification.NotificationSender
+	eventsSender       events.EventSender
+	webhooksSender     sender_webhooks.Sender
+}
+
+func New(
+	logger *zap.Logger,
+	reviewRepo db_review.ReviewRepository,
+	workspaceReader db_workspaces.WorkspaceReader,
+	codebaseRepo db_codebase.CodebaseRepository,
+	snapshotsRepo db_snapshots.Repository,
+	executorProvider executor.Provider,
+	notificationSender sender_notification.NotificationSender,
+	eventsSender events.EventSender,
+	webhooksSender sender_webhooks.Sender,
+) *Service {
+	return &Service{
+		logger: logger.Named("reviewService"),
+
+		reviewRepo:       reviewRepo,
+		workspaceReader:  workspaceReader,
+		codebaseRepo:     codebaseRepo,
+		snapshotsRepo:    snapshotsRepo,
+		executorProvider: executorProvider,
+
+		notificationSender: notificationSender,
+		eventsSender:       eventsSender,
+		webhooksSender:     webhooksSender,
+	}
+}
+
+// isStale returns true if the files of the workspace that the review covers have changed since the review was made.
+//
+// Reviews without a snapshot (requested reviews, and reviews made before snapshots were recorded) are never stale. If
+// the reviewed snapshot has been garbage collected, the review is stale, as it can't be compared anymore.
+func (s *Service) isStale(ctx context.Context, rev *review.Review, latestSnapshotID *string) (bool, error) {
+	if rev.SnapshotID == nil || latestSnapshotID == nil || *latestSnapshotID == *rev.SnapshotID {
+		return false, nil
+	}
+
+	reviewedSnapshot, err := s.snapshotsRepo.Get(*rev.SnapshotID)
+	if errors.Is(err, db_snapshots.ErrNotFound) {
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get reviewed snapshot: %w", err)
+	}
+
+	latestSnapshot, err := s.snapshotsRepo.Get(*latestSnapshotID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get latest snapshot: %w", err)
+	}
+
+	var staleFiles []string
+	if err := s.executorProvider.New().GitRead(func(repo vcs.RepoGitReader) error {
+		reviewedFiles, err := snapshotFiles(repo, reviewedSnapshot)
+		if err != nil {
+			return fmt.Errorf("failed to get reviewed files: %w", err)
+		}
+		latestFiles, err := snapshotFiles(repo, latestSnapshot)
+		if err != nil {
+			return fmt.Errorf("failed to get latest files: %w", err)
+		}
+		changedFiles, err := diffFiles(repo, reviewedSnapshot.CommitID, latestSnapshot.CommitID)
+		if err != nil {
+			return fmt.Errorf("failed to get changed files: %w", err)
+		}
+		staleFiles = review.StaleFiles(reviewedFiles, latestFiles, changedFiles)
+		return nil
+	}).ExecTrunk(rev.CodebaseID, "reviewIsStale"); err != nil {
+		return false, err
+	}
+
+	return len(staleFiles) > 0, nil
+}
+
+// UpdateStale marks the approvals in the workspace as stale if the reviewed files have changed in the latest snapshot
+// of the workspace. If the codebase is configured to do so, the stale approvals are dismissed, and their reviewers are
+// notified.
+//
+// A review that is stale stays stale until it's replaced by a new review.
+func (s *Service) UpdateStale(ctx context.Context, workspaceID string) error {
+	ws, err := s.workspaceReader.Get(workspaceID)
+	if err != nil {
+		return fmt.Errorf("failed to get workspace: %w", err)
+	}
+	if ws.IsArchived() || ws.LatestSnapshotID == nil {
+		return nil
+	}
+
+	reviews, err := s.reviewRepo.ListLatestByWorkspace(ctx, ws.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list reviews: %w", err)
+	}
+
+	var cb *codebase.Codebase
+	for _, rev := range reviews {
+		if rev.Grade != review.ReviewGradeApprove || rev.IsStale {
+			continue
+		}
+
+		stale, err := s.isStale(ctx, rev, ws.LatestSnapshotID)
+		if err != nil {
+			return fmt.Errorf("failed to check if review is stale: %w", err)
+		}
+		if !stale {
+			continue
+		}
+
+		if cb == nil {
+			if cb, err = s.codebaseRepo.Get(ws.CodebaseID); err != nil {
+				return fmt.Errorf("failed to get codebase: %w", err)
+			}
+		}
+
+		rev.IsStale = true
+		if cb.DismissStaleApprovals {
+			if err := s.dismiss(ctx, rev); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := s.reviewRepo.Update(ctx, rev); err != nil {
+			return fmt.Errorf("failed to update review: %w", err)
+		}
+		s.sendUpdated(ctx, rev)
+	}
+
+	return nil
+}
+
+func (s *Service) dismiss(ctx context.Context, rev *review.Review) error {
+	now := time.Now()
+	rev.DismissedAt = &now
+	if err := s.reviewRepo.Update(ctx, rev); err != nil {
+		return fmt.Errorf("failed to update review: %w", err)
+	}
+
+	// Send notification to the reviewer
+	if err := s.notificationSender.User(ctx, rev.UserID, rev.CodebaseID, notification.ReviewDismissedNotificationType, rev.ID); err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+
+	s.sendUpdated(ctx, rev)
+
+	return nil
+}
+
+func (s *Service) sendUpdated(ctx context.Context, rev *review.Review) {
+	if err := s.eventsSender.Codebase(rev.CodebaseID, events.WorkspaceUpdatedReviews, rev.WorkspaceID); err != nil {
+		s.logger.Error("failed to send codebase event", zap.Error(err))
+		// do not fail
+	}
+
+	if err := s.eventsSender.Workspace(rev.WorkspaceID, events.ReviewUpdated, rev.ID); err != nil {
+		s.logger.Error("failed to send workspace event", zap.Error(err))
+		// do not fail
+	}
+
+	if err := s.webhooksSender.Codebase(ctx, rev.CodebaseID, webhooks.EventReviewUpdated, rev.ID); err != nil {
+		s.logger.Error("failed to send webhook event", zap.Error(err))
+		// do not fail
+	}
+}
+
+// snapshotFiles returns the files that are changed in the snapshot
+func snapshotFiles(repo vcs.RepoGitReader, snapshot *snapshots.Snapshot) ([]string, error) {
+	parents, err := repo.GetCommitParents(snapshot.CommitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit parents: %w", err)
+	}
+	if len(parents) != 1 {
+		return nil, fmt.Errorf("unexpected number of snapshot parents: %d, expected %d", len(parents), 1)
+	}
+	return diffFiles(repo, parents[0], snapshot.CommitID)
+}
+
+// diffFiles returns the old and new paths of the files that differ between the two commits
+func diffFiles(repo vcs.RepoGitReader, firstCommitID, secondCommitID string) ([]string, error) {
+	diff, err := repo.DiffCommits(firstCommitID, secondCommitID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff commits: %w", err)
+	}
+	defer diff.Free()
+
+	count, err := diff.NumDeltas()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count deltas: %w", err)
+	}
+
+	files := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		delta, err := diff.Delta(i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get delta: %w", err)
+		}
+		files = append(files, delta.OldFile.Path)
+		if delta.NewFile.Path != delta.OldFile.Path {
+			files = append(files, delta.NewFile.Path)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,25 @@
+package review
+
+// StaleFiles returns the files that make a review stale.
+//
+// reviewedFiles are the files that the workspace changed when the review was made, latestFiles are the files that the
+// workspace changes now, and changedFiles are the files that differ between the two. A changed file makes the review
+// stale if the reviewer saw it, or if it's part of the workspace now. Files that only changed because the workspace
+// was synced with trunk are not included.
+func StaleFiles(reviewedFiles, latestFiles, changedFiles []string) []string {
+	inWorkspace := make(map[string]bool, len(reviewedFiles)+len(latestFiles))
+	for _, file := range reviewedFiles {
+		inWorkspace[file] = true
+	}
+	for _, file := range latestFiles {
+		inWorkspace[file] = true
+	}
+
+	var stale []string
+	for _, file := range changedFiles {
+		if inWorkspace[file] {
+			stale = append(stale, file)
+		}
+	}
+	return stale
+}
@@ -0,0 +1,56 @@
+package review
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaleFiles(t *testing.T) {
+	cases := []struct {
+		name     string
+		reviewed []string
+		latest   []string
+		changed  []string
+		expected []string
+	}{
+		{
+			name:     "no-changes",
+			reviewed: []string{"a.txt"},
+			latest:   []string{"a.txt"},
+		},
+		{
+			name:     "reviewed-file-changed",
+			reviewed: []string{"a.txt", "b.txt"},
+			latest:   []string{"a.txt", "b.txt"},
+			changed:  []string{"b.txt"},
+			expected: []string{"b.txt"},
+		},
+		{
+			name:     "new-file-in-workspace",
+			reviewed: []string{"a.txt"},
+			latest:   []string{"a.txt", "b.txt"},
+			changed:  []string{"b.txt"},
+			expected: []string{"b.txt"},
+		},
+		{
+			name:     "reviewed-file-reverted",
+			reviewed: []string{"a.txt", "b.txt"},
+			latest:   []string{"a.txt"},
+			changed:  []string{"b.txt"},
+			expected: []string{"b.txt"},
+		},
+		{
+			name:     "synced-with-trunk",
+			reviewed: []string{"a.txt"},
+			latest:   []string{"a.txt"},
+			changed:  []string{"trunk.txt"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, StaleFiles(tc.reviewed, tc.latest, tc.changed))
+		})
+	}
+}
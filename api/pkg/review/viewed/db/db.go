@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"getsturdy.com/api/pkg/review/viewed"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	// Create marks the file as viewed, it's a noop if the file is already marked.
+	Create(context.Context, *viewed.File) error
+	Get(ctx context.Context, userID, workspaceID, path, contentHash string) (*viewed.File, error)
+	Delete(ctx context.Context, userID, workspaceID, path, contentHash string) error
+}
+
+type repo struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) Repository {
+	return &repo{db: db}
+}
+
+func (r *repo) Create(ctx context.Context, file *viewed.File) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO viewed_files
+			(user_id, workspace_id, path, content_hash, viewed_at)
+		VALUES
+			(:user_id, :workspace_id, :path, :content_hash, :viewed_at)
+		ON CONFLICT DO NOTHING
+	`, file); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (r *repo) Get(ctx context.Context, userID, workspaceID, path, contentHash string) (*viewed.File, error) {
+	var res viewed.File
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			user_id, workspace_id, path, content_hash, viewed_at
+		FROM
+			viewed_files
+		WHERE
+			user_id = $1
+			AND workspace_id = $2
+			AND path = $3
+			AND content_hash = $4
+	`, userID, workspaceID, path, contentHash); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *repo) Delete(ctx context.Context, userID, workspaceID, path, contentHash string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		DELETE FROM
+			viewed_files
+		WHERE
+			user_id = $1
+			AND workspace_id = $2
+			AND path = $3
+			AND content_hash = $4
+	`, userID, workspaceID, path, contentHash); err != nil {
+		return fmt.Errorf("failed to delete: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package viewed
+
+import "time"
+
+// File is a file diff that a user has marked as viewed in a workspace. The mark is keyed by the workspace, the path
+// and the content hash of the diff, so that it doesn't apply anymore once the file changes, or to the same diff in
+// other workspaces.
+type File struct {
+	UserID      string    `db:"user_id"`
+	WorkspaceID string    `db:"workspace_id"`
+	Path        string    `db:"path"`
+	ContentHash string    `db:"content_hash"`
+	ViewedAt    time.Time `db:"viewed_at"`
+}
@@ -0,0 +1,33 @@
+package worker
+
+import (
+	"getsturdy.com/api/pkg/events"
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/eventqueue"
+	"getsturdy.com/api/pkg/queue/names"
+	"getsturdy.com/api/pkg/review/service"
+
+	"go.uber.org/zap"
+)
+
+// Queue handles workspaces when they get a new snapshot, so that approvals of the previous contents can be marked as
+// stale.
+type Queue struct {
+	*eventqueue.Queue
+}
+
+func New(
+	logger *zap.Logger,
+	queue queue.Queue,
+	eventsReader events.EventReader,
+	service *service.Service,
+) *Queue {
+	return &Queue{eventqueue.New(
+		logger.Named("reviewStaleQueue"),
+		queue,
+		eventsReader,
+		names.ReviewStale,
+		events.WorkspaceUpdatedSnapshot,
+		service.UpdateStale,
+	)}
+}
@@ -0,0 +1,224 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"getsturdy.com/api/pkg/scim"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+type GroupRepository interface {
+	Create(context.Context, *scim.Group) error
+	// Get returns the group, with its members.
+	Get(ctx context.Context, organizationID, id string) (*scim.Group, error)
+	// ListByOrganizationID returns the groups of the organization, with their members.
+	ListByOrganizationID(ctx context.Context, organizationID string) ([]*scim.Group, error)
+	// Update updates the group and replaces its members.
+	Update(context.Context, *scim.Group) error
+	Delete(ctx context.Context, organizationID, id string) error
+	// DeleteMember removes the user from all groups in the organization.
+	DeleteMember(ctx context.Context, organizationID, userID string) error
+}
+
+type groupRepo struct {
+	db *sqlx.DB
+}
+
+func NewGroups(db *sqlx.DB) GroupRepository {
+	return &groupRepo{db: db}
+}
+
+func (r *groupRepo) Create(ctx context.Context, group *scim.Group) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.NamedExecContext(ctx, `
+		INSERT INTO scim_groups
+			(id, organization_id, display_name, external_id, created_at, updated_at)
+		VALUES
+			(:id, :organization_id, :display_name, :external_id, :created_at, :updated_at)
+	`, group); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+
+	if err := setMembers(ctx, tx, group); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+func (r *groupRepo) Get(ctx context.Context, organizationID, id string) (*scim.Group, error) {
+	var res scim.Group
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, organization_id, display_name, external_id, created_at, updated_at
+		FROM
+			scim_groups
+		WHERE
+			organization_id = $1
+			AND id = $2
+	`, organizationID, id); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	if err := r.loadMembers(ctx, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *groupRepo) ListByOrganizationID(ctx context.Context, organizationID string) ([]*scim.Group, error) {
+	var res []*scim.Group
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, organization_id, display_name, external_id, created_at, updated_at
+		FROM
+			scim_groups
+		WHERE
+			organization_id = $1
+		ORDER BY
+			created_at
+	`, organizationID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	if err := r.loadMembers(ctx, res...); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *groupRepo) Update(ctx context.Context, group *scim.Group) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.NamedExecContext(ctx, `
+		UPDATE
+			scim_groups
+		SET
+			display_name = :display_name,
+			external_id = :external_id,
+			updated_at = :updated_at
+		WHERE
+			id = :id
+	`, group); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM scim_group_members WHERE group_id = $1`, group.ID); err != nil {
+		return fmt.Errorf("failed to delete members: %w", err)
+	}
+
+	if err := setMembers(ctx, tx, group); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+func (r *groupRepo) Delete(ctx context.Context, organizationID, id string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM scim_groups WHERE organization_id = $1 AND id = $2`, organizationID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete: %w", err)
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	} else if affected == 0 {
+		return fmt.Errorf("failed to delete: %w", sql.ErrNoRows)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM scim_group_members WHERE group_id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete members: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit: %w", err)
+	}
+	return nil
+}
+
+func (r *groupRepo) DeleteMember(ctx context.Context, organizationID, userID string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		DELETE FROM
+			scim_group_members
+		WHERE
+			user_id = $1
+			AND group_id IN (SELECT id FROM scim_groups WHERE organization_id = $2)
+	`, userID, organizationID); err != nil {
+		return fmt.Errorf("failed to delete: %w", err)
+	}
+	return nil
+}
+
+func (r *groupRepo) loadMembers(ctx context.Context, groups ...*scim.Group) error {
+	if len(groups) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*scim.Group, len(groups))
+	ids := make([]string, 0, len(groups))
+	for _, group := range groups {
+		group.MemberIDs = []string{}
+		byID[group.ID] = group
+		ids = append(ids, group.ID)
+	}
+
+	var members []struct {
+		GroupID string `db:"group_id"`
+		UserID  string `db:"user_id"`
+	}
+	if err := r.db.SelectContext(ctx, &members, `
+		SELECT
+			group_id, user_id
+		FROM
+			scim_group_members
+		WHERE
+			group_id = ANY($1)
+		ORDER BY
+			user_id
+	`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to select members: %w", err)
+	}
+
+	for _, member := range members {
+		group := byID[member.GroupID]
+		group.MemberIDs = append(group.MemberIDs, member.UserID)
+	}
+	return nil
+}
+
+func setMembers(ctx context.Context, tx *sqlx.Tx, group *scim.Group) error {
+	for _, userID := range group.MemberIDs {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO scim_group_members
+				(group_id, user_id)
+			VALUES
+				($1, $2)
+			ON CONFLICT DO NOTHING
+		`, group.ID, userID); err != nil {
+			return fmt.Errorf("failed to insert member: %w", err)
+		}
+	}
+	return nil
+}
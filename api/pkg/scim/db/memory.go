@@ -0,0 +1,234 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+
+	"getsturdy.com/api/pkg/scim"
+)
+
+var (
+	_ TokenRepository = &tokensMemory{}
+	_ UserRepository  = &usersMemory{}
+	_ GroupRepository = &groupsMemory{}
+)
+
+type tokensMemory struct {
+	mu     sync.Mutex
+	tokens []scim.Token
+}
+
+func NewTokensMemory() TokenRepository {
+	return &tokensMemory{}
+}
+
+func (m *tokensMemory) Create(_ context.Context, token *scim.Token) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.tokens = append(m.tokens, *token)
+	return nil
+}
+
+func (m *tokensMemory) Get(_ context.Context, id string) (*scim.Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, token := range m.tokens {
+		if token.ID == id {
+			token := token
+			return &token, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *tokensMemory) GetBySecretHash(_ context.Context, secretHash []byte) (*scim.Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, token := range m.tokens {
+		if token.SecretHash != nil && bytes.Equal(token.SecretHash, secretHash) {
+			token := token
+			return &token, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *tokensMemory) ListByOrganizationID(_ context.Context, organizationID string) ([]*scim.Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*scim.Token
+	for _, token := range m.tokens {
+		token := token
+		if token.OrganizationID == organizationID && token.RevokedAt == nil {
+			res = append(res, &token)
+		}
+	}
+	return res, nil
+}
+
+func (m *tokensMemory) Update(_ context.Context, token *scim.Token) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, t := range m.tokens {
+		if t.ID == token.ID {
+			m.tokens[i].LastUsedAt = token.LastUsedAt
+			m.tokens[i].RevokedAt = token.RevokedAt
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *tokensMemory) SetSecretHash(_ context.Context, id string, secretHash []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, t := range m.tokens {
+		if t.ID == id {
+			m.tokens[i].SecretHash = secretHash
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+type usersMemory struct {
+	mu    sync.Mutex
+	users []scim.User
+}
+
+func NewUsersMemory() UserRepository {
+	return &usersMemory{}
+}
+
+func (m *usersMemory) Get(_ context.Context, organizationID, userID string) (*scim.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, user := range m.users {
+		if user.OrganizationID == organizationID && user.UserID == userID {
+			user := user
+			return &user, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *usersMemory) ListByOrganizationID(_ context.Context, organizationID string) ([]*scim.User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*scim.User
+	for _, user := range m.users {
+		user := user
+		if user.OrganizationID == organizationID {
+			res = append(res, &user)
+		}
+	}
+	return res, nil
+}
+
+func (m *usersMemory) Upsert(_ context.Context, user *scim.User) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, u := range m.users {
+		if u.OrganizationID == user.OrganizationID && u.UserID == user.UserID {
+			m.users[i] = *user
+			m.users[i].CreatedAccount = u.CreatedAccount
+			return nil
+		}
+	}
+	m.users = append(m.users, *user)
+	return nil
+}
+
+func (m *usersMemory) Delete(_ context.Context, organizationID, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	users := m.users[:0]
+	for _, u := range m.users {
+		if u.OrganizationID != organizationID || u.UserID != userID {
+			users = append(users, u)
+		}
+	}
+	m.users = users
+	return nil
+}
+
+type groupsMemory struct {
+	mu   sync.Mutex
+	byID map[string]scim.Group
+}
+
+func NewGroupsMemory() GroupRepository {
+	return &groupsMemory{byID: make(map[string]scim.Group)}
+}
+
+func (m *groupsMemory) Create(_ context.Context, group *scim.Group) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	g := *group
+	g.MemberIDs = append([]string{}, group.MemberIDs...)
+	m.byID[group.ID] = g
+	return nil
+}
+
+func (m *groupsMemory) Get(_ context.Context, organizationID, id string) (*scim.Group, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	group, ok := m.byID[id]
+	if !ok || group.OrganizationID != organizationID {
+		return nil, sql.ErrNoRows
+	}
+	return &group, nil
+}
+
+func (m *groupsMemory) ListByOrganizationID(_ context.Context, organizationID string) ([]*scim.Group, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*scim.Group
+	for _, group := range m.byID {
+		group := group
+		if group.OrganizationID == organizationID {
+			res = append(res, &group)
+		}
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].CreatedAt.Before(res[b].CreatedAt)
+	})
+	return res, nil
+}
+
+func (m *groupsMemory) Update(ctx context.Context, group *scim.Group) error {
+	return m.Create(ctx, group)
+}
+
+func (m *groupsMemory) Delete(_ context.Context, organizationID, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	group, ok := m.byID[id]
+	if !ok || group.OrganizationID != organizationID {
+		return sql.ErrNoRows
+	}
+	delete(m.byID, id)
+	return nil
+}
+
+func (m *groupsMemory) DeleteMember(_ context.Context, organizationID, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, group := range m.byID {
+		if group.OrganizationID != organizationID {
+			continue
+		}
+		memberIDs := make([]string, 0, len(group.MemberIDs))
+		for _, memberID := range group.MemberIDs {
+			if memberID != userID {
+				memberIDs = append(memberIDs, memberID)
+			}
+		}
+		group.MemberIDs = memberIDs
+		m.byID[id] = group
+	}
+	return nil
+}
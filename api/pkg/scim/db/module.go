@@ -0,0 +1,11 @@
+package db
+
+import (
+	"getsturdy.com/api/pkg/di"
+)
+
+func Module(c *di.Container) {
+	c.Register(NewTokens)
+	c.Register(NewUsers)
+	c.Register(NewGroups)
+}
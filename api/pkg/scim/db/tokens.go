@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"getsturdy.com/api/pkg/scim"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TokenRepository interface {
+	Create(context.Context, *scim.Token) error
+	Get(ctx context.Context, id string) (*scim.Token, error)
+	GetBySecretHash(ctx context.Context, secretHash []byte) (*scim.Token, error)
+	ListByOrganizationID(ctx context.Context, organizationID string) ([]*scim.Token, error)
+	Update(context.Context, *scim.Token) error
+	SetSecretHash(ctx context.Context, id string, secretHash []byte) error
+}
+
+type tokenRepo struct {
+	db *sqlx.DB
+}
+
+func NewTokens(db *sqlx.DB) TokenRepository {
+	return &tokenRepo{db: db}
+}
+
+func (r *tokenRepo) Create(ctx context.Context, token *scim.Token) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO scim_tokens
+			(id, organization_id, name, hash, secret_hash, created_by, created_at, last_used_at, revoked_at)
+		VALUES
+			(:id, :organization_id, :name, :hash, :secret_hash, :created_by, :created_at, :last_used_at, :revoked_at)
+	`, token); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (r *tokenRepo) Get(ctx context.Context, id string) (*scim.Token, error) {
+	var res scim.Token
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, organization_id, name, hash, secret_hash, created_by, created_at, last_used_at, revoked_at
+		FROM
+			scim_tokens
+		WHERE
+			id = $1
+	`, id); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *tokenRepo) GetBySecretHash(ctx context.Context, secretHash []byte) (*scim.Token, error) {
+	var res scim.Token
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, organization_id, name, hash, secret_hash, created_by, created_at, last_used_at, revoked_at
+		FROM
+			scim_tokens
+		WHERE
+			secret_hash = $1
+	`, secretHash); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *tokenRepo) ListByOrganizationID(ctx context.Context, organizationID string) ([]*scim.Token, error) {
+	var res []*scim.Token
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, organization_id, name, hash, secret_hash, created_by, created_at, last_used_at, revoked_at
+		FROM
+			scim_tokens
+		WHERE
+			organization_id = $1
+			AND revoked_at IS NULL
+		ORDER BY
+			created_at
+	`, organizationID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *tokenRepo) Update(ctx context.Context, token *scim.Token) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		UPDATE
+			scim_tokens
+		SET
+			last_used_at = :last_used_at,
+			revoked_at = :revoked_at
+		WHERE
+			id = :id
+	`, token); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (r *tokenRepo) SetSecretHash(ctx context.Context, id string, secretHash []byte) error {
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE
+			scim_tokens
+		SET
+			secret_hash = $2
+		WHERE
+			id = $1
+	`, id, secretHash); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"getsturdy.com/api/pkg/scim"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type UserRepository interface {
+	Get(ctx context.Context, organizationID, userID string) (*scim.User, error)
+	ListByOrganizationID(ctx context.Context, organizationID string) ([]*scim.User, error)
+	// Upsert creates the user, or updates it if it already exists. CreatedAccount is only set when the user is created.
+	Upsert(context.Context, *scim.User) error
+	Delete(ctx context.Context, organizationID, userID string) error
+}
+
+type userRepo struct {
+	db *sqlx.DB
+}
+
+func NewUsers(db *sqlx.DB) UserRepository {
+	return &userRepo{db: db}
+}
+
+func (r *userRepo) Get(ctx context.Context, organizationID, userID string) (*scim.User, error) {
+	var res scim.User
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			organization_id, user_id, external_id, active, created_account, created_at, updated_at
+		FROM
+			scim_users
+		WHERE
+			organization_id = $1
+			AND user_id = $2
+	`, organizationID, userID); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *userRepo) ListByOrganizationID(ctx context.Context, organizationID string) ([]*scim.User, error) {
+	var res []*scim.User
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			organization_id, user_id, external_id, active, created_account, created_at, updated_at
+		FROM
+			scim_users
+		WHERE
+			organization_id = $1
+		ORDER BY
+			created_at
+	`, organizationID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *userRepo) Upsert(ctx context.Context, user *scim.User) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO scim_users
+			(organization_id, user_id, external_id, active, created_account, created_at, updated_at)
+		VALUES
+			(:organization_id, :user_id, :external_id, :active, :created_account, :created_at, :updated_at)
+		ON CONFLICT (organization_id, user_id) DO UPDATE
+		SET
+			external_id = :external_id,
+			active = :active,
+			updated_at = :updated_at
+	`, user); err != nil {
+		return fmt.Errorf("failed to upsert: %w", err)
+	}
+	return nil
+}
+
+func (r *userRepo) Delete(ctx context.Context, organizationID, userID string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		DELETE FROM
+			scim_users
+		WHERE
+			organization_id = $1
+			AND user_id = $2
+	`, organizationID, userID); err != nil {
+		return fmt.Errorf("failed to delete: %w", err)
+	}
+	return nil
+}
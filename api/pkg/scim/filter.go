@@ -0,0 +1,85 @@
+package scim
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidFilter = errors.New("unsupported filter")
+
+// filterRegexp matches the only kind of filter that identity providers use to look up resources: <attribute> eq "<value>"
+var filterRegexp = regexp.MustCompile(`^\s*([A-Za-z][\w.]*)\s+(?i:eq)\s+("(?:[^"\\]|\\.)*")\s*$`)
+
+// Filter is an equality filter on an attribute of a resource.
+type Filter struct {
+	Attribute string
+	Value     string
+}
+
+// ParseFilter parses a SCIM filter. An empty filter returns nil.
+func ParseFilter(s string) (*Filter, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	matches := filterRegexp.FindStringSubmatch(s)
+	if matches == nil {
+		return nil, ErrInvalidFilter
+	}
+	value, err := strconv.Unquote(matches[2])
+	if err != nil {
+		return nil, ErrInvalidFilter
+	}
+	return &Filter{Attribute: matches[1], Value: value}, nil
+}
+
+// MatchesUser returns true if the user matches the filter.
+func (f *Filter) MatchesUser(u *UserResource) bool {
+	if f == nil {
+		return true
+	}
+	switch strings.ToLower(f.Attribute) {
+	case "id":
+		return u.ID == f.Value
+	case "username":
+		return strings.EqualFold(u.UserName, f.Value)
+	case "externalid":
+		return u.ExternalID != nil && *u.ExternalID == f.Value
+	case "emails", "emails.value":
+		for _, email := range u.Emails {
+			if strings.EqualFold(email.Value, f.Value) {
+				return true
+			}
+		}
+		return false
+	case "displayname":
+		return u.DisplayName == f.Value
+	default:
+		return false
+	}
+}
+
+// MatchesGroup returns true if the group matches the filter.
+func (f *Filter) MatchesGroup(g *GroupResource) bool {
+	if f == nil {
+		return true
+	}
+	switch strings.ToLower(f.Attribute) {
+	case "id":
+		return g.ID == f.Value
+	case "displayname":
+		return g.DisplayName == f.Value
+	case "externalid":
+		return g.ExternalID != nil && *g.ExternalID == f.Value
+	case "members", "members.value":
+		for _, member := range g.Members {
+			if member.Value == f.Value {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
@@ -0,0 +1,51 @@
+package scim
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFilter(t *testing.T) {
+	cases := []struct {
+		filter      string
+		expected    *Filter
+		expectedErr error
+	}{
+		{filter: "", expected: nil},
+		{filter: `userName eq "alice@example.com"`, expected: &Filter{Attribute: "userName", Value: "alice@example.com"}},
+		{filter: `displayName EQ "Engineering \"core\""`, expected: &Filter{Attribute: "displayName", Value: `Engineering "core"`}},
+		{filter: `emails.value eq "alice@example.com"`, expected: &Filter{Attribute: "emails.value", Value: "alice@example.com"}},
+		{filter: `userName sw "alice"`, expectedErr: ErrInvalidFilter},
+		{filter: `userName eq "alice" and active eq true`, expectedErr: ErrInvalidFilter},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.filter, func(t *testing.T) {
+			filter, err := ParseFilter(tc.filter)
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, filter)
+		})
+	}
+}
+
+func TestFilter_MatchesUser(t *testing.T) {
+	externalID := "00u1"
+	user := &UserResource{
+		ID:         "user-1",
+		ExternalID: &externalID,
+		UserName:   "Alice@example.com",
+		Emails:     []Email{{Value: "alice@example.com", Primary: true}},
+	}
+
+	assert.True(t, (*Filter)(nil).MatchesUser(user))
+	assert.True(t, (&Filter{Attribute: "userName", Value: "alice@example.com"}).MatchesUser(user))
+	assert.True(t, (&Filter{Attribute: "externalId", Value: "00u1"}).MatchesUser(user))
+	assert.True(t, (&Filter{Attribute: "emails.value", Value: "alice@example.com"}).MatchesUser(user))
+	assert.False(t, (&Filter{Attribute: "userName", Value: "bob@example.com"}).MatchesUser(user))
+	assert.False(t, (&Filter{Attribute: "title", Value: "Engineer"}).MatchesUser(user))
+}
@@ -0,0 +1,124 @@
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"getsturdy.com/api/pkg/auth"
+	service_auth "getsturdy.com/api/pkg/auth/service"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	service_organization "getsturdy.com/api/pkg/organization/service"
+	"getsturdy.com/api/pkg/scim"
+	service_scim "getsturdy.com/api/pkg/scim/service"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type rootResolver struct {
+	authService         *service_auth.Service
+	scimService         *service_scim.Service
+	organizationService *service_organization.Service
+}
+
+func New(
+	authService *service_auth.Service,
+	scimService *service_scim.Service,
+	organizationService *service_organization.Service,
+) resolvers.SCIMRootResolver {
+	return &rootResolver{
+		authService:         authService,
+		scimService:         scimService,
+		organizationService: organizationService,
+	}
+}
+
+func (r *rootResolver) CreateSCIMToken(ctx context.Context, args resolvers.CreateSCIMTokenArgs) (resolvers.SCIMTokenResolver, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	org, err := r.organizationService.GetByID(ctx, string(args.Input.OrganizationID))
+	if err != nil {
+		return nil, gqlerrors.Error(fmt.Errorf("organization not found: %w", err))
+	}
+
+	if err := r.authService.CanAdmin(ctx, org); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	plainTextToken, token, err := r.scimService.CreateToken(ctx, org.ID, args.Input.Name, userID)
+	if err != nil {
+		return nil, gqlerrors.Error(fmt.Errorf("failed to create token: %w", err))
+	}
+
+	return &resolver{token: token, plainTextToken: &plainTextToken}, nil
+}
+
+func (r *rootResolver) RevokeSCIMToken(ctx context.Context, args resolvers.RevokeSCIMTokenArgs) (resolvers.SCIMTokenResolver, error) {
+	token, err := r.scimService.GetToken(ctx, string(args.Input.ID))
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, gqlerrors.Error(gqlerrors.ErrNotFound)
+	default:
+		return nil, gqlerrors.Error(err)
+	}
+
+	org, err := r.organizationService.GetByID(ctx, token.OrganizationID)
+	if err != nil {
+		return nil, gqlerrors.Error(fmt.Errorf("organization not found: %w", err))
+	}
+
+	if err := r.authService.CanAdmin(ctx, org); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if token.RevokedAt == nil {
+		if err := r.scimService.RevokeToken(ctx, token); err != nil {
+			return nil, gqlerrors.Error(err)
+		}
+	}
+
+	return &resolver{token: token}, nil
+}
+
+type resolver struct {
+	plainTextToken *string
+	token          *scim.Token
+}
+
+func (r *resolver) ID() graphql.ID {
+	return graphql.ID(r.token.ID)
+}
+
+func (r *resolver) Name() string {
+	return r.token.Name
+}
+
+func (r *resolver) CreatedAt() int32 {
+	return int32(r.token.CreatedAt.Unix())
+}
+
+func (r *resolver) LastUsedAt() *int32 {
+	if r.token.LastUsedAt == nil {
+		return nil
+	}
+	t := int32(r.token.LastUsedAt.Unix())
+	return &t
+}
+
+func (r *resolver) RevokedAt() *int32 {
+	if r.token.RevokedAt == nil {
+		return nil
+	}
+	t := int32(r.token.RevokedAt.Unix())
+	return &t
+}
+
+func (r *resolver) Token() *string {
+	return r.plainTextToken
+}
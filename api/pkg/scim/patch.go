@@ -0,0 +1,249 @@
+package scim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPatch = errors.New("invalid patch")
+
+type PatchRequest struct {
+	Schemas    []string          `json:"schemas"`
+	Operations []*PatchOperation `json:"Operations"`
+}
+
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+const (
+	opAdd     = "add"
+	opReplace = "replace"
+	opRemove  = "remove"
+)
+
+// memberPathRegexp matches the path that identity providers use to remove a single member: members[value eq "<id>"]
+var memberPathRegexp = regexp.MustCompile(`^(?i:members)\[\s*(?i:value)\s+(?i:eq)\s+("(?:[^"\\]|\\.)*")\s*\]$`)
+
+// ApplyPatch applies the patch operations to the user. Operations on attributes that Sturdy doesn't store are ignored.
+func (u *UserResource) ApplyPatch(ops []*PatchOperation) error {
+	for _, op := range ops {
+		switch strings.ToLower(op.Op) {
+		case opAdd, opReplace:
+			if op.Path == "" {
+				// the value is an object with the attributes to set
+				var values map[string]json.RawMessage
+				if err := json.Unmarshal(op.Value, &values); err != nil {
+					return fmt.Errorf("%w: value must be an object", ErrInvalidPatch)
+				}
+				for path, value := range values {
+					if err := u.set(path, value); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := u.set(op.Path, op.Value); err != nil {
+				return err
+			}
+		case opRemove:
+			switch strings.ToLower(op.Path) {
+			case "externalid":
+				u.ExternalID = nil
+			case "displayname":
+				u.DisplayName = ""
+			case "name", "name.formatted":
+				u.Name = nil
+			}
+		default:
+			return fmt.Errorf("%w: unsupported op %q", ErrInvalidPatch, op.Op)
+		}
+	}
+	return nil
+}
+
+func (u *UserResource) set(path string, value json.RawMessage) error {
+	switch strings.ToLower(path) {
+	case "active":
+		active, err := parseBool(value)
+		if err != nil {
+			return err
+		}
+		u.Active = &active
+	case "username":
+		return unmarshalValue(value, &u.UserName)
+	case "displayname":
+		return unmarshalValue(value, &u.DisplayName)
+	case "externalid":
+		var externalID string
+		if err := unmarshalValue(value, &externalID); err != nil {
+			return err
+		}
+		u.ExternalID = &externalID
+	case "name":
+		return unmarshalValue(value, &u.Name)
+	case "name.formatted", "name.givenname", "name.familyname":
+		if u.Name == nil {
+			u.Name = &Name{}
+		}
+		var s string
+		if err := unmarshalValue(value, &s); err != nil {
+			return err
+		}
+		switch strings.ToLower(path) {
+		case "name.formatted":
+			u.Name.Formatted = s
+		case "name.givenname":
+			u.Name.GivenName = s
+		case "name.familyname":
+			u.Name.FamilyName = s
+		}
+	case "emails":
+		return unmarshalValue(value, &u.Emails)
+	case `emails[type eq "work"].value`, "emails.value":
+		var email string
+		if err := unmarshalValue(value, &email); err != nil {
+			return err
+		}
+		u.Emails = []Email{{Value: email, Type: "work", Primary: true}}
+	}
+	return nil
+}
+
+// ApplyPatch applies the patch operations to the group.
+func (g *GroupResource) ApplyPatch(ops []*PatchOperation) error {
+	for _, op := range ops {
+		switch strings.ToLower(op.Op) {
+		case opAdd, opReplace:
+			if op.Path == "" {
+				var values map[string]json.RawMessage
+				if err := json.Unmarshal(op.Value, &values); err != nil {
+					return fmt.Errorf("%w: value must be an object", ErrInvalidPatch)
+				}
+				for path, value := range values {
+					if err := g.set(strings.ToLower(op.Op), path, value); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := g.set(strings.ToLower(op.Op), op.Path, op.Value); err != nil {
+				return err
+			}
+		case opRemove:
+			if err := g.remove(op); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: unsupported op %q", ErrInvalidPatch, op.Op)
+		}
+	}
+	return nil
+}
+
+func (g *GroupResource) set(op, path string, value json.RawMessage) error {
+	switch strings.ToLower(path) {
+	case "displayname":
+		return unmarshalValue(value, &g.DisplayName)
+	case "externalid":
+		var externalID string
+		if err := unmarshalValue(value, &externalID); err != nil {
+			return err
+		}
+		g.ExternalID = &externalID
+	case "members":
+		var members []Member
+		if err := unmarshalValue(value, &members); err != nil {
+			return err
+		}
+		if op == opReplace {
+			g.Members = nil
+		}
+		for _, member := range members {
+			if !g.hasMember(member.Value) {
+				g.Members = append(g.Members, member)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *GroupResource) remove(op *PatchOperation) error {
+	if strings.EqualFold(op.Path, "members") {
+		if len(op.Value) == 0 {
+			g.Members = nil
+			return nil
+		}
+		var members []Member
+		if err := unmarshalValue(op.Value, &members); err != nil {
+			return err
+		}
+		for _, member := range members {
+			g.removeMember(member.Value)
+		}
+		return nil
+	}
+
+	if matches := memberPathRegexp.FindStringSubmatch(op.Path); matches != nil {
+		id, err := strconv.Unquote(matches[1])
+		if err != nil {
+			return fmt.Errorf("%w: invalid path %q", ErrInvalidPatch, op.Path)
+		}
+		g.removeMember(id)
+		return nil
+	}
+
+	if strings.EqualFold(op.Path, "externalId") {
+		g.ExternalID = nil
+		return nil
+	}
+
+	return fmt.Errorf("%w: unsupported path %q", ErrInvalidPatch, op.Path)
+}
+
+func (g *GroupResource) hasMember(id string) bool {
+	for _, member := range g.Members {
+		if member.Value == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *GroupResource) removeMember(id string) {
+	members := g.Members[:0]
+	for _, member := range g.Members {
+		if member.Value != id {
+			members = append(members, member)
+		}
+	}
+	g.Members = members
+}
+
+func unmarshalValue(value json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(value, v); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+	}
+	return nil
+}
+
+// parseBool parses a boolean value. Some identity providers send booleans as strings.
+func parseBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if b, err := strconv.ParseBool(strings.ToLower(s)); err == nil {
+			return b, nil
+		}
+	}
+	return false, fmt.Errorf("%w: expected a boolean, got %s", ErrInvalidPatch, string(value))
+}
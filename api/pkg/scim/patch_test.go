@@ -0,0 +1,111 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parsePatch(t *testing.T, s string) []*PatchOperation {
+	var req PatchRequest
+	require.NoError(t, json.Unmarshal([]byte(s), &req))
+	return req.Operations
+}
+
+func TestUserResource_ApplyPatch(t *testing.T) {
+	cases := []struct {
+		name     string
+		patch    string
+		expected UserResource
+	}{
+		{
+			name:     "deactivate",
+			patch:    `{"Operations":[{"op":"replace","path":"active","value":false}]}`,
+			expected: UserResource{UserName: "alice@example.com", Active: boolPtr(false)},
+		},
+		{
+			name:     "deactivate-string",
+			patch:    `{"Operations":[{"op":"Replace","path":"active","value":"False"}]}`,
+			expected: UserResource{UserName: "alice@example.com", Active: boolPtr(false)},
+		},
+		{
+			name:     "replace-object",
+			patch:    `{"Operations":[{"op":"replace","value":{"active":true,"displayName":"Alice A","name.givenName":"Alice"}}]}`,
+			expected: UserResource{UserName: "alice@example.com", Active: boolPtr(true), DisplayName: "Alice A", Name: &Name{GivenName: "Alice"}},
+		},
+		{
+			name:  "replace-email",
+			patch: `{"Operations":[{"op":"replace","path":"emails[type eq \"work\"].value","value":"alice@example.org"}]}`,
+			expected: UserResource{
+				UserName: "alice@example.com",
+				Emails:   []Email{{Value: "alice@example.org", Type: "work", Primary: true}},
+			},
+		},
+		{
+			name:     "ignore-unknown",
+			patch:    `{"Operations":[{"op":"add","path":"title","value":"Engineer"}]}`,
+			expected: UserResource{UserName: "alice@example.com"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			user := UserResource{UserName: "alice@example.com"}
+			require.NoError(t, user.ApplyPatch(parsePatch(t, tc.patch)))
+			assert.Equal(t, tc.expected, user)
+		})
+	}
+}
+
+func TestGroupResource_ApplyPatch(t *testing.T) {
+	cases := []struct {
+		name     string
+		patch    string
+		expected []string
+	}{
+		{
+			name:     "add-members",
+			patch:    `{"Operations":[{"op":"add","path":"members","value":[{"value":"b"},{"value":"c"}]}]}`,
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "replace-members",
+			patch:    `{"Operations":[{"op":"replace","path":"members","value":[{"value":"c"}]}]}`,
+			expected: []string{"c"},
+		},
+		{
+			name:     "remove-member-by-path",
+			patch:    `{"Operations":[{"op":"remove","path":"members[value eq \"b\"]"}]}`,
+			expected: []string{"a"},
+		},
+		{
+			name:     "remove-members-by-value",
+			patch:    `{"Operations":[{"op":"remove","path":"members","value":[{"value":"a"}]}]}`,
+			expected: []string{"b"},
+		},
+		{
+			name:     "remove-all-members",
+			patch:    `{"Operations":[{"op":"remove","path":"members"}]}`,
+			expected: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			group := GroupResource{DisplayName: "engineering", Members: []Member{{Value: "a"}, {Value: "b"}}}
+			require.NoError(t, group.ApplyPatch(parsePatch(t, tc.patch)))
+			assert.Equal(t, tc.expected, group.MemberIDs())
+		})
+	}
+
+	t.Run("unsupported-op", func(t *testing.T) {
+		group := GroupResource{}
+		assert.ErrorIs(t, group.ApplyPatch(parsePatch(t, `{"Operations":[{"op":"move","path":"members"}]}`)), ErrInvalidPatch)
+	})
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,331 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"getsturdy.com/api/pkg/scim"
+	service_scim "getsturdy.com/api/pkg/scim/service"
+	service_user "getsturdy.com/api/pkg/users/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	contentType     = "application/scim+json"
+	ginContextToken = "scim.token"
+)
+
+// Register adds the SCIM 2.0 API to the router group.
+func Register(logger *zap.Logger, group *gin.RouterGroup, scimService *service_scim.Service) {
+	logger = logger.Named("scim")
+
+	group.Use(authenticate(logger, scimService))
+
+	group.GET("/Users", listUsers(logger, scimService))
+	group.POST("/Users", createUser(logger, scimService))
+	group.GET("/Users/:id", getUser(logger, scimService))
+	group.PUT("/Users/:id", replaceUser(logger, scimService))
+	group.PATCH("/Users/:id", patchUser(logger, scimService))
+	group.DELETE("/Users/:id", deleteUser(logger, scimService))
+
+	group.GET("/Groups", listGroups(logger, scimService))
+	group.POST("/Groups", createGroup(logger, scimService))
+	group.GET("/Groups/:id", getGroup(logger, scimService))
+	group.PUT("/Groups/:id", replaceGroup(logger, scimService))
+	group.PATCH("/Groups/:id", patchGroup(logger, scimService))
+	group.DELETE("/Groups/:id", deleteGroup(logger, scimService))
+}
+
+func authenticate(logger *zap.Logger, scimService *service_scim.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			abort(c, http.StatusUnauthorized, "", "missing bearer token")
+			return
+		}
+
+		token, err := scimService.Authenticate(c.Request.Context(), strings.TrimPrefix(header, "Bearer "))
+		switch {
+		case err == nil:
+		case errors.Is(err, service_scim.ErrUnauthenticated):
+			abort(c, http.StatusUnauthorized, "", "invalid bearer token")
+			return
+		default:
+			logger.Error("failed to authenticate", zap.Error(err))
+			abort(c, http.StatusInternalServerError, "", "")
+			return
+		}
+
+		c.Set(ginContextToken, token)
+		c.Next()
+	}
+}
+
+func tokenFromContext(c *gin.Context) *scim.Token {
+	return c.MustGet(ginContextToken).(*scim.Token)
+}
+
+func listUsers(logger *zap.Logger, scimService *service_scim.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := scim.ParseFilter(c.Query("filter"))
+		if err != nil {
+			abort(c, http.StatusBadRequest, "invalidFilter", err.Error())
+			return
+		}
+
+		uu, err := scimService.ListUsers(c.Request.Context(), tokenFromContext(c), filter)
+		if err != nil {
+			handleError(c, logger, err)
+			return
+		}
+
+		resources := make([]interface{}, 0, len(uu))
+		for _, u := range uu {
+			resources = append(resources, u)
+		}
+		respondList(c, resources)
+	}
+}
+
+func getUser(logger *zap.Logger, scimService *service_scim.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := scimService.GetUser(c.Request.Context(), tokenFromContext(c), c.Param("id"))
+		if err != nil {
+			handleError(c, logger, err)
+			return
+		}
+		respond(c, http.StatusOK, user)
+	}
+}
+
+func createUser(logger *zap.Logger, scimService *service_scim.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scim.UserResource
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abort(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		user, err := scimService.CreateUser(c.Request.Context(), tokenFromContext(c), &req)
+		if err != nil {
+			handleError(c, logger, err)
+			return
+		}
+		respond(c, http.StatusCreated, user)
+	}
+}
+
+func replaceUser(logger *zap.Logger, scimService *service_scim.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scim.UserResource
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abort(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		user, err := scimService.ReplaceUser(c.Request.Context(), tokenFromContext(c), c.Param("id"), &req)
+		if err != nil {
+			handleError(c, logger, err)
+			return
+		}
+		respond(c, http.StatusOK, user)
+	}
+}
+
+func patchUser(logger *zap.Logger, scimService *service_scim.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scim.PatchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abort(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		ctx := c.Request.Context()
+		token := tokenFromContext(c)
+
+		user, err := scimService.GetUser(ctx, token, c.Param("id"))
+		if err != nil {
+			handleError(c, logger, err)
+			return
+		}
+
+		if err := user.ApplyPatch(req.Operations); err != nil {
+			abort(c, http.StatusBadRequest, "invalidValue", err.Error())
+			return
+		}
+
+		user, err = scimService.ReplaceUser(ctx, token, user.ID, user)
+		if err != nil {
+			handleError(c, logger, err)
+			return
+		}
+		respond(c, http.StatusOK, user)
+	}
+}
+
+func deleteUser(logger *zap.Logger, scimService *service_scim.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := scimService.DeleteUser(c.Request.Context(), tokenFromContext(c), c.Param("id")); err != nil {
+			handleError(c, logger, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func listGroups(logger *zap.Logger, scimService *service_scim.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter, err := scim.ParseFilter(c.Query("filter"))
+		if err != nil {
+			abort(c, http.StatusBadRequest, "invalidFilter", err.Error())
+			return
+		}
+
+		groups, err := scimService.ListGroups(c.Request.Context(), tokenFromContext(c), filter)
+		if err != nil {
+			handleError(c, logger, err)
+			return
+		}
+
+		excludeMembers := strings.Contains(c.Query("excludedAttributes"), "members")
+		resources := make([]interface{}, 0, len(groups))
+		for _, g := range groups {
+			if excludeMembers {
+				g.Members = nil
+			}
+			resources = append(resources, g)
+		}
+		respondList(c, resources)
+	}
+}
+
+func getGroup(logger *zap.Logger, scimService *service_scim.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		group, err := scimService.GetGroup(c.Request.Context(), tokenFromContext(c), c.Param("id"))
+		if err != nil {
+			handleError(c, logger, err)
+			return
+		}
+		respond(c, http.StatusOK, group)
+	}
+}
+
+func createGroup(logger *zap.Logger, scimService *service_scim.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scim.GroupResource
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abort(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		group, err := scimService.CreateGroup(c.Request.Context(), tokenFromContext(c), &req)
+		if err != nil {
+			handleError(c, logger, err)
+			return
+		}
+		respond(c, http.StatusCreated, group)
+	}
+}
+
+func replaceGroup(logger *zap.Logger, scimService *service_scim.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scim.GroupResource
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abort(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		group, err := scimService.ReplaceGroup(c.Request.Context(), tokenFromContext(c), c.Param("id"), &req)
+		if err != nil {
+			handleError(c, logger, err)
+			return
+		}
+		respond(c, http.StatusOK, group)
+	}
+}
+
+func patchGroup(logger *zap.Logger, scimService *service_scim.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req scim.PatchRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			abort(c, http.StatusBadRequest, "invalidSyntax", err.Error())
+			return
+		}
+
+		ctx := c.Request.Context()
+		token := tokenFromContext(c)
+
+		group, err := scimService.GetGroup(ctx, token, c.Param("id"))
+		if err != nil {
+			handleError(c, logger, err)
+			return
+		}
+
+		if err := group.ApplyPatch(req.Operations); err != nil {
+			abort(c, http.StatusBadRequest, "invalidValue", err.Error())
+			return
+		}
+
+		group, err = scimService.ReplaceGroup(ctx, token, group.ID, group)
+		if err != nil {
+			handleError(c, logger, err)
+			return
+		}
+		respond(c, http.StatusOK, group)
+	}
+}
+
+func deleteGroup(logger *zap.Logger, scimService *service_scim.Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := scimService.DeleteGroup(c.Request.Context(), tokenFromContext(c), c.Param("id")); err != nil {
+			handleError(c, logger, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+func respond(c *gin.Context, status int, v interface{}) {
+	c.Header("Content-Type", contentType)
+	c.JSON(status, v)
+}
+
+func respondList(c *gin.Context, resources []interface{}) {
+	respond(c, http.StatusOK, &scim.ListResponse{
+		Schemas:      []string{scim.SchemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+func abort(c *gin.Context, status int, scimType, detail string) {
+	c.Header("Content-Type", contentType)
+	c.AbortWithStatusJSON(status, &scim.ErrorResponse{
+		Schemas:  []string{scim.SchemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+func handleError(c *gin.Context, logger *zap.Logger, err error) {
+	switch {
+	case errors.Is(err, service_scim.ErrNotFound):
+		abort(c, http.StatusNotFound, "", err.Error())
+	case errors.Is(err, service_scim.ErrConflict):
+		abort(c, http.StatusConflict, "uniqueness", err.Error())
+	case errors.Is(err, service_scim.ErrInvalidValue):
+		abort(c, http.StatusBadRequest, "invalidValue", err.Error())
+	case errors.Is(err, service_user.ErrExceeded):
+		abort(c, http.StatusForbidden, "", err.Error())
+	default:
+		logger.Error("scim request failed", zap.Error(err), zap.String("path", c.FullPath()))
+		abort(c, http.StatusInternalServerError, "", "internal error")
+	}
+}
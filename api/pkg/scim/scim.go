@@ -0,0 +1,55 @@
+package scim
+
+import (
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Token authenticates the identity provider of an organization to the SCIM API.
+type Token struct {
+	ID             string `db:"id"`
+	OrganizationID string `db:"organization_id"`
+	Name           string `db:"name"`
+	// Hash is the bcrypt hash of the secret of tokens from before SecretHash was used, it's nil for newer tokens.
+	Hash []byte `db:"hash"`
+	// SecretHash is the SHA-256 of the secret, tokens are looked up by it.
+	SecretHash []byte     `db:"secret_hash"`
+	CreatedBy  string     `db:"created_by"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
+
+// VerifyLegacy verifies the secret of a token that only has a bcrypt hash.
+func (t *Token) VerifyLegacy(secret string) error {
+	return bcrypt.CompareHashAndPassword(t.Hash, []byte(secret))
+}
+
+// User is a user that is provisioned to an organization by its identity provider. Users that are not active are
+// not members of the organization.
+type User struct {
+	OrganizationID string  `db:"organization_id"`
+	UserID         string  `db:"user_id"`
+	ExternalID     *string `db:"external_id"`
+	Active         bool    `db:"active"`
+	// CreatedAccount is true if the Sturdy account of the user was created by the provisioning. The organization can
+	// only change the email and name of accounts that it created.
+	CreatedAccount bool      `db:"created_account"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+}
+
+// Group is a group of users in the identity provider of an organization. Groups can be referred to from the ACL
+// policies of the codebases in the organization as "groups::<DisplayName>".
+type Group struct {
+	ID             string    `db:"id"`
+	OrganizationID string    `db:"organization_id"`
+	DisplayName    string    `db:"display_name"`
+	ExternalID     *string   `db:"external_id"`
+	CreatedAt      time.Time `db:"created_at"`
+	UpdatedAt      time.Time `db:"updated_at"`
+
+	// MemberIDs are the IDs of the users in the group
+	MemberIDs []string `db:"-"`
+}
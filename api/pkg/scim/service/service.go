@@ -0,0 +1,569 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"getsturdy.com/api/pkg/accesstokens"
+	service_accesstokens "getsturdy.com/api/pkg/accesstokens/service"
+	db_codebase "getsturdy.com/api/pkg/codebase/db"
+	"getsturdy.com/api/pkg/organization"
+	service_organization "getsturdy.com/api/pkg/organization/service"
+	"getsturdy.com/api/pkg/scim"
+	db_scim "getsturdy.com/api/pkg/scim/db"
+	service_sessions "getsturdy.com/api/pkg/sessions/service"
+	"getsturdy.com/api/pkg/users"
+	db_user "getsturdy.com/api/pkg/users/db"
+	service_user "getsturdy.com/api/pkg/users/service"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrUnauthenticated = errors.New("invalid token")
+	ErrNotFound        = errors.New("resource not found")
+	ErrConflict        = errors.New("resource already exists")
+	ErrInvalidValue    = errors.New("invalid value")
+)
+
+type Service struct {
+	logger *zap.Logger
+
+	tokenRepo db_scim.TokenRepository
+	userRepo  db_scim.UserRepository
+	groupRepo db_scim.GroupRepository
+	usersRepo db_user.Repository
+
+	codebaseRepo     db_codebase.CodebaseRepository
+	codebaseUserRepo db_codebase.CodebaseUserRepository
+
+	userService         service_user.Service
+	organizationService *service_organization.Service
+	sessionsService     *service_sessions.Service
+	accessTokensService *service_accesstokens.Service
+}
+
+func New(
+	logger *zap.Logger,
+	tokenRepo db_scim.TokenRepository,
+	userRepo db_scim.UserRepository,
+	groupRepo db_scim.GroupRepository,
+	usersRepo db_user.Repository,
+	codebaseRepo db_codebase.CodebaseRepository,
+	codebaseUserRepo db_codebase.CodebaseUserRepository,
+	userService service_user.Service,
+	organizationService *service_organization.Service,
+	sessionsService *service_sessions.Service,
+	accessTokensService *service_accesstokens.Service,
+) *Service {
+	return &Service{
+		logger: logger.Named("scim"),
+
+		tokenRepo: tokenRepo,
+		userRepo:  userRepo,
+		groupRepo: groupRepo,
+		usersRepo: usersRepo,
+
+		codebaseRepo:     codebaseRepo,
+		codebaseUserRepo: codebaseUserRepo,
+
+		userService:         userService,
+		organizationService: organizationService,
+		sessionsService:     sessionsService,
+		accessTokensService: accessTokensService,
+	}
+}
+
+// CreateToken creates a token that the identity provider of the organization can use to provision users and groups.
+// It returns the token in plaintext, which is not stored.
+func (s *Service) CreateToken(ctx context.Context, organizationID, name, createdBy string) (string, *scim.Token, error) {
+	secret := uuid.NewString()
+	token := &scim.Token{
+		ID:             uuid.NewString(),
+		OrganizationID: organizationID,
+		Name:           name,
+		SecretHash:     accesstokens.HashSecret(secret),
+		CreatedBy:      createdBy,
+		CreatedAt:      time.Now(),
+	}
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return "", nil, fmt.Errorf("failed to create token: %w", err)
+	}
+
+	return token.ID + "." + secret, token, nil
+}
+
+func (s *Service) GetToken(ctx context.Context, id string) (*scim.Token, error) {
+	return s.tokenRepo.Get(ctx, id)
+}
+
+func (s *Service) RevokeToken(ctx context.Context, token *scim.Token) error {
+	now := time.Now()
+	token.RevokedAt = &now
+	if err := s.tokenRepo.Update(ctx, token); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// Authenticate returns the token that the plaintext token refers to. Like personal access tokens, tokens are looked up
+// by the SHA-256 of their secret, so that authenticating doesn't need a bcrypt comparison on every request.
+func (s *Service) Authenticate(ctx context.Context, plaintext string) (*scim.Token, error) {
+	parts := strings.SplitN(plaintext, ".", 2)
+	if len(parts) != 2 {
+		return nil, ErrUnauthenticated
+	}
+	id, secret := parts[0], parts[1]
+
+	secretHash := accesstokens.HashSecret(secret)
+	token, err := s.tokenRepo.GetBySecretHash(ctx, secretHash)
+	switch {
+	case err == nil:
+		if subtle.ConstantTimeCompare([]byte(token.ID), []byte(id)) != 1 {
+			return nil, ErrUnauthenticated
+		}
+	case errors.Is(err, sql.ErrNoRows):
+		if token, err = s.authenticateLegacy(ctx, id, secret, secretHash); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	if token.RevokedAt != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	now := time.Now()
+	token.LastUsedAt = &now
+	if err := s.tokenRepo.Update(ctx, token); err != nil {
+		s.logger.Error("failed to update token", zap.Error(err))
+	}
+
+	return token, nil
+}
+
+// authenticateLegacy authenticates tokens that were created before the SHA-256 of the secret was stored, and stores it
+// for them, so that the bcrypt comparison is only needed once per token.
+func (s *Service) authenticateLegacy(ctx context.Context, id, secret string, secretHash []byte) (*scim.Token, error) {
+	token, err := s.tokenRepo.Get(ctx, id)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrUnauthenticated
+	default:
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	if token.SecretHash != nil || token.Hash == nil {
+		return nil, ErrUnauthenticated
+	}
+	if err := token.VerifyLegacy(secret); err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	if err := s.tokenRepo.SetSecretHash(ctx, token.ID, secretHash); err != nil {
+		s.logger.Error("failed to set the secret hash of the token", zap.Error(err))
+	} else {
+		token.SecretHash = secretHash
+	}
+	return token, nil
+}
+
+func (s *Service) ListUsers(ctx context.Context, token *scim.Token, filter *scim.Filter) ([]*scim.UserResource, error) {
+	provisioned, err := s.userRepo.ListByOrganizationID(ctx, token.OrganizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	if len(provisioned) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(provisioned))
+	for _, p := range provisioned {
+		ids = append(ids, p.UserID)
+	}
+	uu, err := s.usersRepo.GetByIDs(ctx, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	byID := make(map[string]*users.User, len(uu))
+	for _, u := range uu {
+		byID[u.ID] = u
+	}
+
+	res := make([]*scim.UserResource, 0, len(provisioned))
+	for _, p := range provisioned {
+		usr, ok := byID[p.UserID]
+		if !ok {
+			continue
+		}
+		resource := userResource(usr, p)
+		if filter.MatchesUser(resource) {
+			res = append(res, resource)
+		}
+	}
+	return res, nil
+}
+
+func (s *Service) GetUser(ctx context.Context, token *scim.Token, id string) (*scim.UserResource, error) {
+	usr, provisioned, err := s.getUser(ctx, token, id)
+	if err != nil {
+		return nil, err
+	}
+	return userResource(usr, provisioned), nil
+}
+
+// CreateUser provisions a user to the organization, and creates a Sturdy account for them. Emails that are already
+// used by an account are a conflict: the account might belong to someone outside of the organization, so it's never
+// linked to the organization by its email alone.
+func (s *Service) CreateUser(ctx context.Context, token *scim.Token, resource *scim.UserResource) (*scim.UserResource, error) {
+	email := resource.Email()
+	if email == "" {
+		return nil, fmt.Errorf("%w: the user must have an email", ErrInvalidValue)
+	}
+
+	usr, err := s.userService.CreateWithVerifiedEmail(ctx, resource.FullName(), email)
+	switch {
+	case err == nil:
+	case errors.Is(err, service_user.ErrExists):
+		return nil, fmt.Errorf("%w: the email is used by another account", ErrConflict)
+	default:
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	now := time.Now()
+	provisioned := &scim.User{
+		OrganizationID: token.OrganizationID,
+		UserID:         usr.ID,
+		ExternalID:     resource.ExternalID,
+		Active:         resource.IsActive(),
+		CreatedAccount: true,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.userRepo.Upsert(ctx, provisioned); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if err := s.syncMembership(ctx, token, provisioned); err != nil {
+		return nil, err
+	}
+
+	return userResource(usr, provisioned), nil
+}
+
+// ReplaceUser updates the user with the values of the resource. Users that are not active are removed from the
+// organization, but they keep their Sturdy account. The email and name are only updated for the accounts that were
+// created by the provisioning of the organization.
+func (s *Service) ReplaceUser(ctx context.Context, token *scim.Token, id string, resource *scim.UserResource) (*scim.UserResource, error) {
+	usr, provisioned, err := s.getUser(ctx, token, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if provisioned.CreatedAccount {
+		if err := s.updateAccount(usr, resource); err != nil {
+			return nil, err
+		}
+	}
+
+	provisioned.ExternalID = resource.ExternalID
+	provisioned.Active = resource.IsActive()
+	provisioned.UpdatedAt = time.Now()
+	if err := s.userRepo.Upsert(ctx, provisioned); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	if err := s.syncMembership(ctx, token, provisioned); err != nil {
+		return nil, err
+	}
+
+	return userResource(usr, provisioned), nil
+}
+
+// updateAccount updates the email and name of the account of a user with the values of the resource.
+func (s *Service) updateAccount(usr *users.User, resource *scim.UserResource) error {
+	if email := resource.Email(); email != "" && !strings.EqualFold(email, usr.Email) {
+		if _, err := s.usersRepo.GetByEmail(email); err == nil {
+			return fmt.Errorf("%w: the email is used by another user", ErrConflict)
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to get user by email: %w", err)
+		}
+		usr.Email = email
+	}
+	if name := resource.FullName(); name != "" {
+		usr.Name = name
+	}
+	if err := s.usersRepo.Update(usr); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// DeleteUser removes the user from the organization and its groups.
+func (s *Service) DeleteUser(ctx context.Context, token *scim.Token, id string) error {
+	_, provisioned, err := s.getUser(ctx, token, id)
+	if err != nil {
+		return err
+	}
+
+	provisioned.Active = false
+	if err := s.syncMembership(ctx, token, provisioned); err != nil {
+		return err
+	}
+	if err := s.groupRepo.DeleteMember(ctx, token.OrganizationID, id); err != nil {
+		return fmt.Errorf("failed to remove user from groups: %w", err)
+	}
+	if err := s.userRepo.Delete(ctx, token.OrganizationID, id); err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) getUser(ctx context.Context, token *scim.Token, id string) (*users.User, *scim.User, error) {
+	provisioned, err := s.userRepo.Get(ctx, token.OrganizationID, id)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil, ErrNotFound
+	default:
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	usr, err := s.usersRepo.Get(id)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, nil, ErrNotFound
+	default:
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return usr, provisioned, nil
+}
+
+// syncMembership makes active users members of the organization, and removes inactive users from it, and from its
+// codebases. Removed users are signed out everywhere, and their personal access tokens are revoked, so that they can't
+// keep using the access that they had.
+func (s *Service) syncMembership(ctx context.Context, token *scim.Token, provisioned *scim.User) error {
+	isMember, err := s.organizationService.CanAccess(ctx, provisioned.UserID, token.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("failed to check membership: %w", err)
+	}
+
+	switch {
+	case provisioned.Active && !isMember:
+		if _, err := s.organizationService.AddMember(ctx, token.OrganizationID, provisioned.UserID, token.CreatedBy, organization.RoleMember); err != nil {
+			return fmt.Errorf("failed to add member: %w", err)
+		}
+	case !provisioned.Active && isMember:
+		err := s.organizationService.RemoveMember(ctx, token.OrganizationID, provisioned.UserID, token.CreatedBy)
+		switch {
+		case err == nil:
+		case errors.Is(err, service_organization.ErrLastOwner):
+			return fmt.Errorf("%w: %s", ErrInvalidValue, err)
+		default:
+			return fmt.Errorf("failed to remove member: %w", err)
+		}
+		if err := s.revokeAccess(ctx, token.OrganizationID, provisioned.UserID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeAccess removes the user from all codebases of the organization, revokes all of their sessions and personal
+// access tokens.
+func (s *Service) revokeAccess(ctx context.Context, organizationID, userID string) error {
+	codebases, err := s.codebaseRepo.ListByOrganization(ctx, organizationID)
+	if err != nil {
+		return fmt.Errorf("failed to list codebases: %w", err)
+	}
+	for _, cb := range codebases {
+		member, err := s.codebaseUserRepo.GetByUserAndCodebase(userID, cb.ID)
+		switch {
+		case err == nil:
+		case errors.Is(err, sql.ErrNoRows):
+			continue
+		default:
+			return fmt.Errorf("failed to get codebase member: %w", err)
+		}
+		if err := s.codebaseUserRepo.DeleteByID(ctx, member.ID); err != nil {
+			return fmt.Errorf("failed to remove user from codebase: %w", err)
+		}
+	}
+
+	if _, err := s.sessionsService.RevokeAll(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	tokens, err := s.accessTokensService.ListByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list access tokens: %w", err)
+	}
+	for _, t := range tokens {
+		if err := s.accessTokensService.Revoke(ctx, t); err != nil {
+			return fmt.Errorf("failed to revoke access token: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) ListGroups(ctx context.Context, token *scim.Token, filter *scim.Filter) ([]*scim.GroupResource, error) {
+	groups, err := s.groupRepo.ListByOrganizationID(ctx, token.OrganizationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+
+	res := make([]*scim.GroupResource, 0, len(groups))
+	for _, group := range groups {
+		resource := groupResource(group)
+		if filter.MatchesGroup(resource) {
+			res = append(res, resource)
+		}
+	}
+	return res, nil
+}
+
+func (s *Service) GetGroup(ctx context.Context, token *scim.Token, id string) (*scim.GroupResource, error) {
+	group, err := s.getGroup(ctx, token, id)
+	if err != nil {
+		return nil, err
+	}
+	return groupResource(group), nil
+}
+
+func (s *Service) CreateGroup(ctx context.Context, token *scim.Token, resource *scim.GroupResource) (*scim.GroupResource, error) {
+	if err := s.validateGroup(ctx, token, "", resource); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	group := &scim.Group{
+		ID:             uuid.NewString(),
+		OrganizationID: token.OrganizationID,
+		DisplayName:    resource.DisplayName,
+		ExternalID:     resource.ExternalID,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		MemberIDs:      resource.MemberIDs(),
+	}
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+	return groupResource(group), nil
+}
+
+func (s *Service) ReplaceGroup(ctx context.Context, token *scim.Token, id string, resource *scim.GroupResource) (*scim.GroupResource, error) {
+	group, err := s.getGroup(ctx, token, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateGroup(ctx, token, id, resource); err != nil {
+		return nil, err
+	}
+
+	group.DisplayName = resource.DisplayName
+	group.ExternalID = resource.ExternalID
+	group.MemberIDs = resource.MemberIDs()
+	group.UpdatedAt = time.Now()
+	if err := s.groupRepo.Update(ctx, group); err != nil {
+		return nil, fmt.Errorf("failed to update group: %w", err)
+	}
+	return groupResource(group), nil
+}
+
+func (s *Service) DeleteGroup(ctx context.Context, token *scim.Token, id string) error {
+	switch err := s.groupRepo.Delete(ctx, token.OrganizationID, id); {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	default:
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+}
+
+func (s *Service) getGroup(ctx context.Context, token *scim.Token, id string) (*scim.Group, error) {
+	group, err := s.groupRepo.Get(ctx, token.OrganizationID, id)
+	switch {
+	case err == nil:
+		return group, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrNotFound
+	default:
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+}
+
+// validateGroup checks that the name of the group is unique in the organization, and that all members are users
+// that are provisioned to the organization.
+func (s *Service) validateGroup(ctx context.Context, token *scim.Token, id string, resource *scim.GroupResource) error {
+	if strings.TrimSpace(resource.DisplayName) == "" {
+		return fmt.Errorf("%w: the group must have a name", ErrInvalidValue)
+	}
+
+	groups, err := s.groupRepo.ListByOrganizationID(ctx, token.OrganizationID)
+	if err != nil {
+		return fmt.Errorf("failed to list groups: %w", err)
+	}
+	for _, group := range groups {
+		if group.ID != id && group.DisplayName == resource.DisplayName {
+			return fmt.Errorf("%w: a group with the same name exists", ErrConflict)
+		}
+	}
+
+	for _, memberID := range resource.MemberIDs() {
+		if _, err := s.userRepo.Get(ctx, token.OrganizationID, memberID); errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: unknown member %q", ErrInvalidValue, memberID)
+		} else if err != nil {
+			return fmt.Errorf("failed to get member: %w", err)
+		}
+	}
+	return nil
+}
+
+func userResource(usr *users.User, provisioned *scim.User) *scim.UserResource {
+	active := provisioned.Active
+	return &scim.UserResource{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          usr.ID,
+		ExternalID:  provisioned.ExternalID,
+		UserName:    usr.Email,
+		Name:        &scim.Name{Formatted: usr.Name},
+		DisplayName: usr.Name,
+		Emails:      []scim.Email{{Value: usr.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      provisioned.CreatedAt,
+			LastModified: provisioned.UpdatedAt,
+		},
+	}
+}
+
+func groupResource(group *scim.Group) *scim.GroupResource {
+	members := make([]scim.Member, 0, len(group.MemberIDs))
+	for _, id := range group.MemberIDs {
+		members = append(members, scim.Member{Value: id})
+	}
+	return &scim.GroupResource{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          group.ID,
+		ExternalID:  group.ExternalID,
+		DisplayName: group.DisplayName,
+		Members:     members,
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Created:      group.CreatedAt,
+			LastModified: group.UpdatedAt,
+		},
+	}
+}
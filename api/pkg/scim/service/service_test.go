@@ -0,0 +1,210 @@
+package service_test
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/accesstokens"
+	db_accesstokens "getsturdy.com/api/pkg/accesstokens/db"
+	service_accesstokens "getsturdy.com/api/pkg/accesstokens/service"
+	"getsturdy.com/api/pkg/analytics/disabled"
+	service_analytics "getsturdy.com/api/pkg/analytics/service"
+	"getsturdy.com/api/pkg/codebase"
+	db_codebase "getsturdy.com/api/pkg/codebase/db"
+	"getsturdy.com/api/pkg/internal/inmemory"
+	service_organization "getsturdy.com/api/pkg/organization/service"
+	"getsturdy.com/api/pkg/scim"
+	db_scim "getsturdy.com/api/pkg/scim/db"
+	service_scim "getsturdy.com/api/pkg/scim/service"
+	db_sessions "getsturdy.com/api/pkg/sessions/db"
+	service_sessions "getsturdy.com/api/pkg/sessions/service"
+	"getsturdy.com/api/pkg/users"
+	db_user "getsturdy.com/api/pkg/users/db"
+	service_user "getsturdy.com/api/pkg/users/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testService struct {
+	*service_scim.Service
+
+	usersRepo           db_user.Repository
+	scimUsersRepo       db_scim.UserRepository
+	codebaseRepo        db_codebase.CodebaseRepository
+	codebaseUserRepo    db_codebase.CodebaseUserRepository
+	sessionsService     *service_sessions.Service
+	accessTokensService *service_accesstokens.Service
+}
+
+func newService(t *testing.T) *testService {
+	t.Helper()
+
+	analyticsService := service_analytics.New(zap.NewNop(), disabled.NewClient(zap.NewNop()))
+	usersRepo := db_user.NewMemory()
+	scimUsersRepo := db_scim.NewUsersMemory()
+	codebaseRepo := inmemory.NewInMemoryCodebaseRepo()
+	codebaseUserRepo := inmemory.NewInMemoryCodebaseUserRepo()
+	organizationService := service_organization.New(
+		inmemory.NewInMemoryOrganizationRepo(),
+		inmemory.NewInMemoryOrganizationMemberRepository(),
+		analyticsService,
+	)
+	sessionsService := service_sessions.New(db_sessions.NewMemory())
+	accessTokensService := service_accesstokens.New(zap.NewNop(), db_accesstokens.NewMemory())
+
+	return &testService{
+		Service: service_scim.New(
+			zap.NewNop(),
+			db_scim.NewTokensMemory(),
+			scimUsersRepo,
+			db_scim.NewGroupsMemory(),
+			usersRepo,
+			codebaseRepo,
+			codebaseUserRepo,
+			service_user.New(zap.NewNop(), usersRepo, analyticsService),
+			organizationService,
+			sessionsService,
+			accessTokensService,
+		),
+		usersRepo:           usersRepo,
+		scimUsersRepo:       scimUsersRepo,
+		codebaseRepo:        codebaseRepo,
+		codebaseUserRepo:    codebaseUserRepo,
+		sessionsService:     sessionsService,
+		accessTokensService: accessTokensService,
+	}
+}
+
+func userResource(email, name string) *scim.UserResource {
+	return &scim.UserResource{
+		UserName:    email,
+		DisplayName: name,
+		Emails:      []scim.Email{{Value: email, Primary: true}},
+	}
+}
+
+func TestCreateUser_existingAccount(t *testing.T) {
+	svc := newService(t)
+	usersRepo := svc.usersRepo
+	ctx := context.Background()
+	token := &scim.Token{OrganizationID: "org-id", CreatedBy: "owner-id"}
+
+	existing := &users.User{ID: "user-id", Name: "Victim", Email: "victim@example.com"}
+	require.NoError(t, usersRepo.Create(existing))
+
+	_, err := svc.CreateUser(ctx, token, userResource("victim@example.com", "Victim"))
+	assert.ErrorIs(t, err, service_scim.ErrConflict)
+
+	_, err = svc.ReplaceUser(ctx, token, existing.ID, userResource("attacker@example.com", "Attacker"))
+	assert.ErrorIs(t, err, service_scim.ErrNotFound)
+
+	stored, err := usersRepo.Get(existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "victim@example.com", stored.Email)
+	assert.Equal(t, "Victim", stored.Name)
+}
+
+func TestReplaceUser_createdAccount(t *testing.T) {
+	svc := newService(t)
+	usersRepo := svc.usersRepo
+	ctx := context.Background()
+	token := &scim.Token{OrganizationID: "org-id", CreatedBy: "owner-id"}
+
+	created, err := svc.CreateUser(ctx, token, userResource("user@example.com", "User"))
+	require.NoError(t, err)
+
+	_, err = svc.ReplaceUser(ctx, token, created.ID, userResource("renamed@example.com", "Renamed"))
+	require.NoError(t, err)
+
+	stored, err := usersRepo.Get(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed@example.com", stored.Email)
+	assert.Equal(t, "Renamed", stored.Name)
+}
+
+func TestReplaceUser_linkedAccount(t *testing.T) {
+	svc := newService(t)
+	usersRepo, scimUsersRepo := svc.usersRepo, svc.scimUsersRepo
+	ctx := context.Background()
+	token := &scim.Token{OrganizationID: "org-id", CreatedBy: "owner-id"}
+
+	// accounts that were linked to the organization before only accounts created by the provisioning were managed
+	existing := &users.User{ID: "user-id", Name: "User", Email: "user@example.com"}
+	require.NoError(t, usersRepo.Create(existing))
+	require.NoError(t, scimUsersRepo.Upsert(ctx, &scim.User{OrganizationID: "org-id", UserID: existing.ID, Active: true}))
+
+	_, err := svc.ReplaceUser(ctx, token, existing.ID, userResource("attacker@example.com", "Attacker"))
+	require.NoError(t, err)
+
+	stored, err := usersRepo.Get(existing.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", stored.Email)
+	assert.Equal(t, "User", stored.Name)
+}
+
+func TestReplaceUser_deactivate(t *testing.T) {
+	svc := newService(t)
+	ctx := context.Background()
+	orgID := "org-id"
+	token := &scim.Token{OrganizationID: orgID, CreatedBy: "owner-id"}
+
+	created, err := svc.CreateUser(ctx, token, userResource("user@example.com", "User"))
+	require.NoError(t, err)
+
+	// a codebase in the organization, and one outside of it
+	require.NoError(t, svc.codebaseRepo.Create(codebase.Codebase{ID: "codebase-id", OrganizationID: &orgID}))
+	require.NoError(t, svc.codebaseUserRepo.Create(codebase.CodebaseUser{ID: "member-id", UserID: created.ID, CodebaseID: "codebase-id"}))
+	otherOrgID := "other-org-id"
+	require.NoError(t, svc.codebaseRepo.Create(codebase.Codebase{ID: "other-codebase-id", OrganizationID: &otherOrgID}))
+	require.NoError(t, svc.codebaseUserRepo.Create(codebase.CodebaseUser{ID: "other-member-id", UserID: created.ID, CodebaseID: "other-codebase-id"}))
+
+	require.NoError(t, svc.sessionsService.Issued(ctx, "session-id", created.ID, time.Now().Add(time.Hour)))
+	_, _, err = svc.accessTokensService.Create(ctx, created.ID, "token", accesstokens.Scopes{accesstokens.ScopeCodebasesRead}, nil)
+	require.NoError(t, err)
+
+	inactive := userResource("user@example.com", "User")
+	inactive.Active = new(bool)
+	_, err = svc.ReplaceUser(ctx, token, created.ID, inactive)
+	require.NoError(t, err)
+
+	_, err = svc.codebaseUserRepo.GetByUserAndCodebase(created.ID, "codebase-id")
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = svc.codebaseUserRepo.GetByUserAndCodebase(created.ID, "other-codebase-id")
+	assert.NoError(t, err, "codebases of other organizations are not touched")
+
+	revoked, err := svc.sessionsService.IsRevoked(ctx, "session-id")
+	require.NoError(t, err)
+	assert.True(t, revoked)
+
+	tokens, err := svc.accessTokensService.ListByUserID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+}
+
+func TestAuthenticate(t *testing.T) {
+	svc := newService(t)
+	ctx := context.Background()
+
+	plaintext, created, err := svc.CreateToken(ctx, "org-id", "idp", "owner-id")
+	require.NoError(t, err)
+	assert.Nil(t, created.Hash, "new tokens are not hashed with bcrypt")
+
+	token, err := svc.Authenticate(ctx, plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, token.ID)
+	assert.NotNil(t, token.LastUsedAt)
+
+	_, err = svc.Authenticate(ctx, created.ID+".wrong-secret")
+	assert.ErrorIs(t, err, service_scim.ErrUnauthenticated)
+	_, err = svc.Authenticate(ctx, "other-id."+strings.SplitN(plaintext, ".", 2)[1])
+	assert.ErrorIs(t, err, service_scim.ErrUnauthenticated, "the id must match the secret")
+
+	require.NoError(t, svc.RevokeToken(ctx, token))
+	_, err = svc.Authenticate(ctx, plaintext)
+	assert.ErrorIs(t, err, service_scim.ErrUnauthenticated)
+}
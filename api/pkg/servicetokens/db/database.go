@@ -3,6 +3,8 @@ package db
 import (
 	"context"
 	"fmt"
+	"time"
+
 	"getsturdy.com/api/pkg/servicetokens"
 
 	"github.com/jmoiron/sqlx"
@@ -23,9 +25,11 @@ func NewDatabase(db *sqlx.DB) Repository {
 func (d *database) Create(ctx context.Context, token *servicetokens.Token) error {
 	if _, err := d.db.NamedExecContext(ctx, `
 		INSERT INTO servicetokens (
-			id, codebase_id, hash, name, created_at, last_used_at
+			id, codebase_id, hash, name, capabilities, allowed_ips, created_by, created_at, expires_at, last_used_at,
+			previous_hash, previous_hash_expires_at
 		) VALUES (
-			:id, :codebase_id, :hash, :name, :created_at, :last_used_at
+			:id, :codebase_id, :hash, :name, :capabilities, :allowed_ips, :created_by, :created_at, :expires_at, :last_used_at,
+			:previous_hash, :previous_hash_expires_at
 		)
 	`, token); err != nil {
 		return fmt.Errorf("failed to insert: %w", err)
@@ -37,7 +41,8 @@ func (d *database) GetByID(ctx context.Context, id string) (*servicetokens.Token
 	token := &servicetokens.Token{}
 	if err := d.db.GetContext(ctx, token, `
 		SELECT
-			id, codebase_id, hash, name, created_at, last_used_at
+			id, codebase_id, hash, name, capabilities, allowed_ips, created_by, created_at, expires_at, last_used_at,
+			previous_hash, previous_hash_expires_at
 		FROM servicetokens
 		WHERE id = $1
 	`, id); err != nil {
@@ -45,3 +50,96 @@ func (d *database) GetByID(ctx context.Context, id string) (*servicetokens.Token
 	}
 	return token, nil
 }
+
+func (d *database) ListByCodebaseID(ctx context.Context, codebaseID string) ([]*servicetokens.Token, error) {
+	var tokens []*servicetokens.Token
+	if err := d.db.SelectContext(ctx, &tokens, `
+		SELECT
+			id, codebase_id, hash, name, capabilities, allowed_ips, created_by, created_at, expires_at, last_used_at,
+			previous_hash, previous_hash_expires_at
+		FROM servicetokens
+		WHERE codebase_id = $1
+		ORDER BY created_at
+	`, codebaseID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return tokens, nil
+}
+
+func (d *database) Update(ctx context.Context, token *servicetokens.Token) error {
+	if _, err := d.db.NamedExecContext(ctx, `
+		UPDATE servicetokens
+		SET
+			hash = :hash,
+			previous_hash = :previous_hash,
+			previous_hash_expires_at = :previous_hash_expires_at
+		WHERE id = :id
+	`, token); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (d *database) SetLastUsedAt(ctx context.Context, id string, lastUsedAt time.Time) error {
+	if _, err := d.db.ExecContext(ctx, `
+		UPDATE servicetokens
+		SET last_used_at = $2
+		WHERE id = $1
+	`, id, lastUsedAt); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (d *database) SetUsage(ctx context.Context, usage *servicetokens.Usage) error {
+	if _, err := d.db.NamedExecContext(ctx, `
+		INSERT INTO servicetoken_usages (
+			token_id, capability, last_used_at
+		) VALUES (
+			:token_id, :capability, :last_used_at
+		)
+		ON CONFLICT (token_id, capability) DO UPDATE SET last_used_at = :last_used_at
+	`, usage); err != nil {
+		return fmt.Errorf("failed to upsert: %w", err)
+	}
+	return nil
+}
+
+func (d *database) ListUsages(ctx context.Context, tokenID string) ([]*servicetokens.Usage, error) {
+	var usages []*servicetokens.Usage
+	if err := d.db.SelectContext(ctx, &usages, `
+		SELECT
+			token_id, capability, last_used_at
+		FROM servicetoken_usages
+		WHERE token_id = $1
+	`, tokenID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return usages, nil
+}
+
+func (d *database) CreateWorkspace(ctx context.Context, workspace *servicetokens.Workspace) error {
+	if _, err := d.db.NamedExecContext(ctx, `
+		INSERT INTO servicetoken_workspaces (
+			token_id, workspace_id, created_at
+		) VALUES (
+			:token_id, :workspace_id, :created_at
+		)
+	`, workspace); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (d *database) GetWorkspace(ctx context.Context, tokenID, workspaceID string) (*servicetokens.Workspace, error) {
+	var workspace servicetokens.Workspace
+	if err := d.db.GetContext(ctx, &workspace, `
+		SELECT
+			token_id, workspace_id, created_at
+		FROM servicetoken_workspaces
+		WHERE token_id = $1 AND workspace_id = $2
+	`, tokenID, workspaceID); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &workspace, nil
+}
@@ -3,6 +3,9 @@ package db
 import (
 	"context"
 	"database/sql"
+	"sort"
+	"sync"
+	"time"
 
 	"getsturdy.com/api/pkg/servicetokens"
 )
@@ -10,34 +13,117 @@ import (
 var _ Repository = &memory{}
 
 type memory struct {
-	byID map[string]*servicetokens.Token
+	mu     sync.Mutex
+	byID   map[string]servicetokens.Token
+	usages map[string]map[servicetokens.Capability]servicetokens.Usage
+	// workspaces are the workspaces created with each token
+	workspaces map[string]map[string]servicetokens.Workspace
 }
 
-func NewMemory() *memory {
+func NewMemory() Repository {
 	return &memory{
-		byID: map[string]*servicetokens.Token{},
+		byID:       map[string]servicetokens.Token{},
+		usages:     map[string]map[servicetokens.Capability]servicetokens.Usage{},
+		workspaces: map[string]map[string]servicetokens.Workspace{},
 	}
 }
 
 func (m *memory) Create(_ context.Context, token *servicetokens.Token) error {
-	m.byID[token.ID] = token
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byID[token.ID] = *token
 	return nil
 }
 
 func (m *memory) Update(_ context.Context, token *servicetokens.Token) error {
-	m.byID[token.ID] = token
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, found := m.byID[token.ID]
+	if !found {
+		return sql.ErrNoRows
+	}
+	stored.Hash = token.Hash
+	stored.PreviousHash = token.PreviousHash
+	stored.PreviousHashExpiresAt = token.PreviousHashExpiresAt
+	m.byID[token.ID] = stored
+	return nil
+}
+
+func (m *memory) SetLastUsedAt(_ context.Context, id string, lastUsedAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token, found := m.byID[id]
+	if !found {
+		return sql.ErrNoRows
+	}
+	token.LastUsedAt = &lastUsedAt
+	m.byID[id] = token
 	return nil
 }
 
 func (m *memory) GetByID(_ context.Context, id string) (*servicetokens.Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	token, found := m.byID[id]
 	if !found {
 		return nil, sql.ErrNoRows
 	}
-	return token, nil
+	return &token, nil
+}
+
+func (m *memory) ListByCodebaseID(_ context.Context, codebaseID string) ([]*servicetokens.Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*servicetokens.Token
+	for _, token := range m.byID {
+		token := token
+		if token.CodebaseID == codebaseID {
+			res = append(res, &token)
+		}
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].CreatedAt.Before(res[b].CreatedAt)
+	})
+	return res, nil
+}
+
+func (m *memory) SetUsage(_ context.Context, usage *servicetokens.Usage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.usages[usage.TokenID] == nil {
+		m.usages[usage.TokenID] = map[servicetokens.Capability]servicetokens.Usage{}
+	}
+	m.usages[usage.TokenID][usage.Capability] = *usage
+	return nil
+}
+
+func (m *memory) ListUsages(_ context.Context, tokenID string) ([]*servicetokens.Usage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*servicetokens.Usage
+	for _, usage := range m.usages[tokenID] {
+		usage := usage
+		res = append(res, &usage)
+	}
+	return res, nil
 }
 
-func (m *memory) DeleteByID(_ context.Context, id string) error {
-	delete(m.byID, id)
+func (m *memory) CreateWorkspace(_ context.Context, workspace *servicetokens.Workspace) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.workspaces[workspace.TokenID] == nil {
+		m.workspaces[workspace.TokenID] = map[string]servicetokens.Workspace{}
+	}
+	m.workspaces[workspace.TokenID][workspace.WorkspaceID] = *workspace
 	return nil
 }
+
+func (m *memory) GetWorkspace(_ context.Context, tokenID, workspaceID string) (*servicetokens.Workspace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	workspace, found := m.workspaces[tokenID][workspaceID]
+	if !found {
+		return nil, sql.ErrNoRows
+	}
+	return &workspace, nil
+}
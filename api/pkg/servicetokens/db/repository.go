@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"getsturdy.com/api/pkg/servicetokens"
 )
@@ -9,4 +10,15 @@ import (
 type Repository interface {
 	Create(context.Context, *servicetokens.Token) error
 	GetByID(context.Context, string) (*servicetokens.Token, error)
+	ListByCodebaseID(ctx context.Context, codebaseID string) ([]*servicetokens.Token, error)
+	// Update updates the secret of the token.
+	Update(context.Context, *servicetokens.Token) error
+	SetLastUsedAt(ctx context.Context, id string, lastUsedAt time.Time) error
+
+	// SetUsage creates or updates when the token was last used for the capability.
+	SetUsage(context.Context, *servicetokens.Usage) error
+	ListUsages(ctx context.Context, tokenID string) ([]*servicetokens.Usage, error)
+
+	CreateWorkspace(context.Context, *servicetokens.Workspace) error
+	GetWorkspace(ctx context.Context, tokenID, workspaceID string) (*servicetokens.Workspace, error)
 }
@@ -2,8 +2,12 @@ package graphql
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 
+	"getsturdy.com/api/pkg/auth"
 	service_auth "getsturdy.com/api/pkg/auth/service"
 	service_codebase "getsturdy.com/api/pkg/codebase/service"
 	gqlerror "getsturdy.com/api/pkg/graphql/errors"
@@ -14,6 +18,26 @@ import (
 	"github.com/graph-gophers/graphql-go"
 )
 
+// capabilities maps the values of the ServiceTokenCapability enum to capabilities.
+var capabilities = map[string]servicetokens.Capability{
+	"FetchCI":         servicetokens.CapabilityFetchCI,
+	"StatusesWrite":   servicetokens.CapabilityStatusesWrite,
+	"ChangesRead":     servicetokens.CapabilityChangesRead,
+	"WorkspacesWrite": servicetokens.CapabilityWorkspacesWrite,
+}
+
+func capabilityName(capability servicetokens.Capability) string {
+	for name, c := range capabilities {
+		if c == capability {
+			return name
+		}
+	}
+	return ""
+}
+
+// defaultGracePeriod is how long the secret from before a rotation is valid, if no grace period is given.
+const defaultGracePeriod = 24 * time.Hour
+
 type rootResolver struct {
 	authService          *service_auth.Service
 	serviceTokensService *service_servicetokens.Service
@@ -32,28 +56,128 @@ func New(
 	}
 }
 
+func (r *rootResolver) InternalListByCodebaseID(ctx context.Context, codebaseID string) ([]resolvers.ServiceTokenResovler, error) {
+	codebase, err := r.codebaseService.GetByID(ctx, codebaseID)
+	if err != nil {
+		return nil, gqlerror.Error(fmt.Errorf("codebase not found: %w", err))
+	}
+
+	if err := r.authService.CanAdmin(ctx, codebase); err != nil {
+		return nil, gqlerror.Error(err)
+	}
+
+	tokens, err := r.serviceTokensService.ListByCodebaseID(ctx, codebaseID)
+	if err != nil {
+		return nil, gqlerror.Error(err)
+	}
+
+	res := make([]resolvers.ServiceTokenResovler, 0, len(tokens))
+	for _, token := range tokens {
+		res = append(res, &resolver{root: r, token: token})
+	}
+	return res, nil
+}
+
 func (r *rootResolver) CreateServiceToken(ctx context.Context, args resolvers.CreateServiceTokenArgs) (resolvers.ServiceTokenResovler, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerror.Error(err)
+	}
+
 	codebase, err := r.codebaseService.GetByShortID(ctx, args.Input.ShortCodebaseID)
 	if err != nil {
 		return nil, gqlerror.Error(fmt.Errorf("codebase not found: %w", err))
 	}
 
-	if err := r.authService.CanWrite(ctx, codebase); err != nil {
+	if err := r.authService.CanAdmin(ctx, codebase); err != nil {
 		return nil, gqlerror.Error(err)
 	}
 
-	plainTextToken, token, err := r.serviceTokensService.Create(ctx, codebase.ID, args.Input.Name)
-	if err != nil {
+	tokenCapabilities := servicetokens.DefaultCapabilities
+	if args.Input.Capabilities != nil {
+		tokenCapabilities = make(servicetokens.Capabilities, 0, len(*args.Input.Capabilities))
+		for _, c := range *args.Input.Capabilities {
+			capability, ok := capabilities[c]
+			if !ok {
+				return nil, gqlerror.Error(gqlerror.ErrBadRequest, "capabilities", fmt.Sprintf("unknown capability %q", c))
+			}
+			tokenCapabilities = append(tokenCapabilities, capability)
+		}
+	}
+
+	var allowedIPs servicetokens.IPAllowList
+	if args.Input.AllowedIPs != nil {
+		allowedIPs = *args.Input.AllowedIPs
+	}
+
+	var expiresAt *time.Time
+	if args.Input.ExpiresAt != nil {
+		t := time.Unix(int64(*args.Input.ExpiresAt), 0)
+		expiresAt = &t
+	}
+
+	plainTextToken, token, err := r.serviceTokensService.Create(ctx, codebase.ID, userID, args.Input.Name, tokenCapabilities, allowedIPs, expiresAt)
+	switch {
+	case err == nil:
+	case errors.Is(err, service_servicetokens.ErrInvalidCapability):
+		return nil, gqlerror.Error(gqlerror.ErrBadRequest, "capabilities", err.Error())
+	case errors.Is(err, service_servicetokens.ErrInvalidAllowedIPs):
+		return nil, gqlerror.Error(gqlerror.ErrBadRequest, "allowedIPs", err.Error())
+	case errors.Is(err, service_servicetokens.ErrInvalidExpiry):
+		return nil, gqlerror.Error(gqlerror.ErrBadRequest, "expiresAt", err.Error())
+	default:
 		return nil, gqlerror.Error(fmt.Errorf("failed to create token: %w", err))
 	}
 
 	return &resolver{
+		root:           r,
+		token:          token,
+		plainTextToken: &plainTextToken,
+	}, nil
+}
+
+func (r *rootResolver) RotateServiceToken(ctx context.Context, args resolvers.RotateServiceTokenArgs) (resolvers.ServiceTokenResovler, error) {
+	token, err := r.serviceTokensService.Get(ctx, string(args.Input.ID))
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, gqlerror.Error(gqlerror.ErrNotFound)
+	default:
+		return nil, gqlerror.Error(err)
+	}
+
+	codebase, err := r.codebaseService.GetByID(ctx, token.CodebaseID)
+	if err != nil {
+		return nil, gqlerror.Error(fmt.Errorf("codebase not found: %w", err))
+	}
+
+	if err := r.authService.CanAdmin(ctx, codebase); err != nil {
+		return nil, gqlerror.Error(err)
+	}
+
+	gracePeriod := defaultGracePeriod
+	if args.Input.GracePeriodSeconds != nil {
+		gracePeriod = time.Duration(*args.Input.GracePeriodSeconds) * time.Second
+	}
+
+	plainTextToken, token, err := r.serviceTokensService.Rotate(ctx, token, gracePeriod)
+	switch {
+	case err == nil:
+	case errors.Is(err, service_servicetokens.ErrInvalidGracePeriod):
+		return nil, gqlerror.Error(gqlerror.ErrBadRequest, "gracePeriodSeconds", err.Error())
+	default:
+		return nil, gqlerror.Error(fmt.Errorf("failed to rotate token: %w", err))
+	}
+
+	return &resolver{
+		root:           r,
 		token:          token,
 		plainTextToken: &plainTextToken,
 	}, nil
 }
 
 type resolver struct {
+	root           *rootResolver
 	plainTextToken *string
 	token          *servicetokens.Token
 }
@@ -66,18 +190,89 @@ func (r *resolver) Name() string {
 	return r.token.Name
 }
 
+func (r *resolver) Capabilities() []string {
+	res := make([]string, 0, len(r.token.Capabilities))
+	for _, capability := range r.token.Capabilities {
+		if name := capabilityName(capability); name != "" {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+func (r *resolver) AllowedIPs() []string {
+	if r.token.AllowedIPs == nil {
+		return []string{}
+	}
+	return r.token.AllowedIPs
+}
+
 func (r *resolver) CreatedAt() int32 {
 	return int32(r.token.CreatedAt.Unix())
 }
 
+func (r *resolver) ExpiresAt() *int32 {
+	return unix(r.token.ExpiresAt)
+}
+
 func (r *resolver) LastUsedAt() *int32 {
-	if r.token.LastUsedAt == nil {
+	return unix(r.token.LastUsedAt)
+}
+
+func (r *resolver) Usage(ctx context.Context) ([]resolvers.ServiceTokenUsageResolver, error) {
+	usages, err := r.root.serviceTokensService.ListUsages(ctx, r.token.ID)
+	if err != nil {
+		return nil, gqlerror.Error(err)
+	}
+
+	lastUsedAt := make(map[servicetokens.Capability]time.Time, len(usages))
+	for _, usage := range usages {
+		lastUsedAt[usage.Capability] = usage.LastUsedAt
+	}
+
+	res := make([]resolvers.ServiceTokenUsageResolver, 0, len(r.token.Capabilities))
+	for _, capability := range r.token.Capabilities {
+		name := capabilityName(capability)
+		if name == "" {
+			continue
+		}
+		usage := &usageResolver{capability: name}
+		if t, ok := lastUsedAt[capability]; ok {
+			usage.lastUsedAt = &t
+		}
+		res = append(res, usage)
+	}
+	return res, nil
+}
+
+func (r *resolver) PreviousTokenExpiresAt() *int32 {
+	if r.token.PreviousHash == nil || r.token.PreviousHashExpiresAt == nil || !time.Now().Before(*r.token.PreviousHashExpiresAt) {
 		return nil
 	}
-	luat := int32(r.token.LastUsedAt.Unix())
-	return &luat
+	return unix(r.token.PreviousHashExpiresAt)
 }
 
 func (r *resolver) Token() *string {
 	return r.plainTextToken
 }
+
+type usageResolver struct {
+	capability string
+	lastUsedAt *time.Time
+}
+
+func (r *usageResolver) Capability() string {
+	return r.capability
+}
+
+func (r *usageResolver) LastUsedAt() *int32 {
+	return unix(r.lastUsedAt)
+}
+
+func unix(t *time.Time) *int32 {
+	if t == nil {
+		return nil
+	}
+	u := int32(t.Unix())
+	return &u
+}
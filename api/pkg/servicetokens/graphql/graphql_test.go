@@ -0,0 +1,72 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"getsturdy.com/api/pkg/auth"
+	service_auth "getsturdy.com/api/pkg/auth/service"
+	"getsturdy.com/api/pkg/codebase"
+	service_codebase "getsturdy.com/api/pkg/codebase/service"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/internal/inmemory"
+	"getsturdy.com/api/pkg/organization"
+	"getsturdy.com/api/pkg/servicetokens"
+	db_servicetokens "getsturdy.com/api/pkg/servicetokens/db"
+	service_servicetokens "getsturdy.com/api/pkg/servicetokens/service"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestServiceTokens_roles(t *testing.T) {
+	codebaseRepo := inmemory.NewInMemoryCodebaseRepo()
+	codebaseUserRepo := inmemory.NewInMemoryCodebaseUserRepo()
+	codebaseService := service_codebase.New(codebaseRepo, codebaseUserRepo, nil, nil, nil, nil, nil, nil)
+	serviceTokensService := service_servicetokens.New(zap.NewNop(), db_servicetokens.NewMemory())
+	root := New(
+		service_auth.New(codebaseService, nil, nil, nil, nil, nil, nil, nil),
+		serviceTokensService,
+		codebaseService,
+	)
+
+	cb := codebase.Codebase{ID: uuid.NewString(), ShortCodebaseID: "short-id"}
+	assert.NoError(t, codebaseRepo.Create(cb))
+
+	_, token, err := serviceTokensService.Create(context.Background(), cb.ID, uuid.NewString(), "deploy", servicetokens.DefaultCapabilities, nil, nil)
+	assert.NoError(t, err)
+
+	for role, allowed := range map[organization.Role]bool{
+		organization.RoleOwner:  true,
+		organization.RoleAdmin:  true,
+		organization.RoleMember: false,
+	} {
+		userID := uuid.NewString()
+		assert.NoError(t, codebaseUserRepo.Create(codebase.CodebaseUser{ID: uuid.NewString(), CodebaseID: cb.ID, UserID: userID, Role: role}))
+		ctx := auth.NewContext(context.Background(), &auth.Subject{ID: userID, Type: auth.SubjectUser})
+
+		_, err = root.CreateServiceToken(ctx, resolvers.CreateServiceTokenArgs{Input: resolvers.CreateServiceTokenInput{
+			Name:            "ci",
+			ShortCodebaseID: string(cb.ShortCodebaseID),
+		}})
+		if allowed {
+			assert.NoError(t, err, role)
+		} else {
+			assert.ErrorIs(t, err, auth.ErrForbidden, role)
+		}
+
+		_, err = root.InternalListByCodebaseID(ctx, cb.ID)
+		if allowed {
+			assert.NoError(t, err, role)
+		} else {
+			assert.ErrorIs(t, err, auth.ErrForbidden, role)
+		}
+
+		if !allowed {
+			_, err = root.RotateServiceToken(ctx, resolvers.RotateServiceTokenArgs{Input: resolvers.RotateServiceTokenInput{ID: graphql.ID(token.ID)}})
+			assert.ErrorIs(t, err, auth.ErrForbidden, role)
+		}
+	}
+}
@@ -2,47 +2,109 @@ package service
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"net"
+	"strings"
+	"sync"
 	"time"
 
+	"getsturdy.com/api/pkg/ip"
 	"getsturdy.com/api/pkg/servicetokens"
 	db_servicetokens "getsturdy.com/api/pkg/servicetokens/db"
 
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
 const (
 	bcryptCost = bcrypt.DefaultCost
+
+	// MaxGracePeriod is how long the old secret of a rotated token can be valid for at most.
+	MaxGracePeriod = 7 * 24 * time.Hour
+	// lastUsedInterval is how often the use of a capability of a token is written to the database.
+	lastUsedInterval = time.Minute
+)
+
+var (
+	ErrUnauthenticated    = errors.New("invalid token")
+	ErrForbidden          = errors.New("forbidden")
+	ErrInvalidCapability  = errors.New("invalid capability")
+	ErrInvalidAllowedIPs  = errors.New("invalid allowed ips")
+	ErrInvalidExpiry      = errors.New("expiry must be in the future")
+	ErrInvalidGracePeriod = errors.New("invalid grace period")
 )
 
 type Service struct {
-	repo db_servicetokens.Repository
+	logger *zap.Logger
+	repo   db_servicetokens.Repository
+
+	lastUsedGuard sync.Mutex
+	lastUsed      map[usageKey]time.Time
+}
+
+type usageKey struct {
+	tokenID    string
+	capability servicetokens.Capability
 }
 
 func New(
+	logger *zap.Logger,
 	repo db_servicetokens.Repository,
 ) *Service {
 	return &Service{
-		repo: repo,
+		logger: logger.Named("servicetokens"),
+		repo:   repo,
+
+		lastUsed: make(map[usageKey]time.Time),
 	}
 }
 
-// Create creates a new service token. It returns the created service token in plaintext (now stored) and the
-// servicetoken in encrypted form (can be recovered from the database).
-func (s *Service) Create(ctx context.Context, codebaseID string, name string) (string, *servicetokens.Token, error) {
-	plainTextToken := uuid.New().String()
-	hashedToken, err := bcrypt.GenerateFromPassword([]byte(plainTextToken), bcryptCost)
+// Create creates a new service token. It returns the secret of the token in plaintext (not stored) and the token,
+// with the secret in hashed form. Tokens without allowed ips can be used from anywhere, and tokens without an expiry
+// are valid forever.
+func (s *Service) Create(
+	ctx context.Context,
+	codebaseID, userID, name string,
+	capabilities servicetokens.Capabilities,
+	allowedIPs servicetokens.IPAllowList,
+	expiresAt *time.Time,
+) (string, *servicetokens.Token, error) {
+	if len(capabilities) == 0 {
+		return "", nil, fmt.Errorf("%w: at least one capability is required", ErrInvalidCapability)
+	}
+	for _, capability := range capabilities {
+		if !capability.Valid() {
+			return "", nil, fmt.Errorf("%w: %q", ErrInvalidCapability, capability)
+		}
+	}
+
+	if err := allowedIPs.Validate(); err != nil {
+		return "", nil, fmt.Errorf("%w: %s", ErrInvalidAllowedIPs, err)
+	}
+
+	now := time.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return "", nil, ErrInvalidExpiry
+	}
+
+	plainTextToken, hashedToken, err := newSecret()
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to generate token has: %w", err)
+		return "", nil, err
 	}
 
 	token := &servicetokens.Token{
-		ID:         uuid.NewString(),
-		CodebaseID: codebaseID,
-		Hash:       hashedToken,
-		Name:       name,
-		CreatedAt:  time.Now(),
+		ID:           uuid.NewString(),
+		CodebaseID:   codebaseID,
+		Hash:         hashedToken,
+		Name:         name,
+		Capabilities: capabilities,
+		AllowedIPs:   allowedIPs,
+		CreatedBy:    &userID,
+		CreatedAt:    now,
+		ExpiresAt:    expiresAt,
 	}
 
 	if err := s.repo.Create(ctx, token); err != nil {
@@ -52,8 +114,157 @@ func (s *Service) Create(ctx context.Context, codebaseID string, name string) (s
 	return plainTextToken, token, nil
 }
 
-// todo: updated last used
+func newSecret() (string, []byte, error) {
+	plainTextToken := uuid.New().String()
+	hashedToken, err := bcrypt.GenerateFromPassword([]byte(plainTextToken), bcryptCost)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to generate token hash: %w", err)
+	}
+	return plainTextToken, hashedToken, nil
+}
+
+// Rotate replaces the secret of the token, and returns the new secret in plaintext. The old secret continues to be
+// valid for the grace period, to give CI the time to start using the new one.
+func (s *Service) Rotate(ctx context.Context, token *servicetokens.Token, gracePeriod time.Duration) (string, *servicetokens.Token, error) {
+	if gracePeriod < 0 || gracePeriod > MaxGracePeriod {
+		return "", nil, fmt.Errorf("%w: must be between 0 and %s", ErrInvalidGracePeriod, MaxGracePeriod)
+	}
+
+	plainTextToken, hashedToken, err := newSecret()
+	if err != nil {
+		return "", nil, err
+	}
+
+	if gracePeriod > 0 {
+		previousHashExpiresAt := time.Now().Add(gracePeriod)
+		token.PreviousHash = token.Hash
+		token.PreviousHashExpiresAt = &previousHashExpiresAt
+	} else {
+		token.PreviousHash = nil
+		token.PreviousHashExpiresAt = nil
+	}
+	token.Hash = hashedToken
+
+	if err := s.repo.Update(ctx, token); err != nil {
+		return "", nil, fmt.Errorf("failed to update: %w", err)
+	}
+
+	return plainTextToken, token, nil
+}
 
 func (s *Service) Get(ctx context.Context, id string) (*servicetokens.Token, error) {
 	return s.repo.GetByID(ctx, id)
 }
+
+func (s *Service) ListByCodebaseID(ctx context.Context, codebaseID string) ([]*servicetokens.Token, error) {
+	return s.repo.ListByCodebaseID(ctx, codebaseID)
+}
+
+// ListUsages returns when the token was last used for each of the capabilities that it has been used for.
+func (s *Service) ListUsages(ctx context.Context, tokenID string) ([]*servicetokens.Usage, error) {
+	return s.repo.ListUsages(ctx, tokenID)
+}
+
+// Authenticate returns the token with the id, if the secret is valid, and the token is used from an allowed ip. The
+// ip is taken from the context.
+func (s *Service) Authenticate(ctx context.Context, id, secret string) (*servicetokens.Token, error) {
+	token, err := s.repo.GetByID(ctx, id)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrUnauthenticated
+	default:
+		return nil, fmt.Errorf("failed to get token: %w", err)
+	}
+
+	now := time.Now()
+	if token.IsExpired(now) {
+		return nil, ErrUnauthenticated
+	}
+	if err := token.Verify(secret, now); err != nil {
+		return nil, ErrUnauthenticated
+	}
+
+	var remoteIP net.IP
+	if addr, ok := ip.FromContext(ctx); ok && addr != nil {
+		remoteIP = *addr
+	}
+	if !token.AllowedIPs.Allows(remoteIP) {
+		return nil, fmt.Errorf("%w: the token can't be used from %s", ErrForbidden, remoteIP)
+	}
+
+	return token, nil
+}
+
+// AuthenticateBearer is like Authenticate, for tokens that are used as bearer tokens, in the form
+// servicetokens.Prefix + id + "_" + secret.
+func (s *Service) AuthenticateBearer(ctx context.Context, plaintext string) (*servicetokens.Token, error) {
+	if !servicetokens.IsServiceToken(plaintext) {
+		return nil, ErrUnauthenticated
+	}
+	parts := strings.SplitN(strings.TrimPrefix(plaintext, servicetokens.Prefix), "_", 2)
+	if len(parts) != 2 {
+		return nil, ErrUnauthenticated
+	}
+	return s.Authenticate(ctx, parts[0], parts[1])
+}
+
+// Authorize returns ErrForbidden if the token doesn't have the capability. Otherwise, the use of the capability is
+// recorded.
+func (s *Service) Authorize(ctx context.Context, token *servicetokens.Token, capability servicetokens.Capability) error {
+	if !token.Capabilities.Has(capability) {
+		return fmt.Errorf("%w: the token is missing the %s capability", ErrForbidden, capability)
+	}
+	if err := s.used(ctx, token.ID, capability); err != nil {
+		s.logger.Error("failed to record token usage", zap.String("token_id", token.ID), zap.Error(err))
+	}
+	return nil
+}
+
+// AddWorkspace records that the workspace was created with the token.
+func (s *Service) AddWorkspace(ctx context.Context, token *servicetokens.Token, workspaceID string) error {
+	if err := s.repo.CreateWorkspace(ctx, &servicetokens.Workspace{
+		TokenID:     token.ID,
+		WorkspaceID: workspaceID,
+		CreatedAt:   time.Now(),
+	}); err != nil {
+		return fmt.Errorf("failed to create workspace: %w", err)
+	}
+	return nil
+}
+
+// AuthorizeWorkspace is like Authorize, but also returns ErrForbidden if the workspace wasn't created with the token.
+func (s *Service) AuthorizeWorkspace(ctx context.Context, token *servicetokens.Token, workspaceID string, capability servicetokens.Capability) error {
+	_, err := s.repo.GetWorkspace(ctx, token.ID, workspaceID)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("%w: the workspace wasn't created with the token", ErrForbidden)
+	default:
+		return fmt.Errorf("failed to get workspace: %w", err)
+	}
+	return s.Authorize(ctx, token, capability)
+}
+
+// used records that the capability of the token has been used. To not write to the database on every request, it is
+// only recorded once every lastUsedInterval.
+func (s *Service) used(ctx context.Context, tokenID string, capability servicetokens.Capability) error {
+	now := time.Now()
+	key := usageKey{tokenID: tokenID, capability: capability}
+
+	s.lastUsedGuard.Lock()
+	if lastUsed, ok := s.lastUsed[key]; ok && now.Sub(lastUsed) < lastUsedInterval {
+		s.lastUsedGuard.Unlock()
+		return nil
+	}
+	s.lastUsed[key] = now
+	s.lastUsedGuard.Unlock()
+
+	if err := s.repo.SetUsage(ctx, &servicetokens.Usage{TokenID: tokenID, Capability: capability, LastUsedAt: now}); err != nil {
+		return fmt.Errorf("failed to set usage: %w", err)
+	}
+	if err := s.repo.SetLastUsedAt(ctx, tokenID, now); err != nil {
+		return fmt.Errorf("failed to set last used at: %w", err)
+	}
+	return nil
+}
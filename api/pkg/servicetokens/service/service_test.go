@@ -0,0 +1,186 @@
+package service_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/ip"
+	"getsturdy.com/api/pkg/servicetokens"
+	db_servicetokens "getsturdy.com/api/pkg/servicetokens/db"
+	"getsturdy.com/api/pkg/servicetokens/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(zap.NewNop(), db_servicetokens.NewMemory())
+
+	secret, token, err := svc.Create(ctx, "codebase-id", "user-id", "ci", servicetokens.DefaultCapabilities, nil, nil)
+	require.NoError(t, err)
+
+	authenticated, err := svc.Authenticate(ctx, token.ID, secret)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "codebase-id", authenticated.CodebaseID)
+	}
+
+	authenticated, err = svc.AuthenticateBearer(ctx, servicetokens.Prefix+token.ID+"_"+secret)
+	if assert.NoError(t, err) {
+		assert.Equal(t, token.ID, authenticated.ID)
+	}
+
+	_, err = svc.Authenticate(ctx, token.ID, secret+"x")
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+	_, err = svc.Authenticate(ctx, "unknown", secret)
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+	_, err = svc.AuthenticateBearer(ctx, token.ID+"_"+secret)
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+}
+
+func TestAuthenticate_expired(t *testing.T) {
+	ctx := context.Background()
+	repo := db_servicetokens.NewMemory()
+	svc := service.New(zap.NewNop(), repo)
+
+	expiresAt := time.Now().Add(time.Hour)
+	secret, token, err := svc.Create(ctx, "codebase-id", "user-id", "ci", servicetokens.DefaultCapabilities, nil, &expiresAt)
+	require.NoError(t, err)
+
+	_, err = svc.Authenticate(ctx, token.ID, secret)
+	assert.NoError(t, err)
+
+	expired := time.Now().Add(-time.Minute)
+	token.ExpiresAt = &expired
+	require.NoError(t, repo.Create(ctx, token))
+
+	_, err = svc.Authenticate(ctx, token.ID, secret)
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+}
+
+func TestAuthenticate_allowedIPs(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(zap.NewNop(), db_servicetokens.NewMemory())
+
+	secret, token, err := svc.Create(ctx, "codebase-id", "user-id", "ci", servicetokens.DefaultCapabilities, servicetokens.IPAllowList{"10.0.0.0/8", "192.168.1.1"}, nil)
+	require.NoError(t, err)
+
+	for addr, allowed := range map[string]bool{
+		"10.1.2.3":    true,
+		"192.168.1.1": true,
+		"192.168.1.2": false,
+		"127.0.0.1":   false,
+	} {
+		_, err := svc.Authenticate(ip.NewContext(ctx, net.ParseIP(addr)), token.ID, secret)
+		if allowed {
+			assert.NoError(t, err, addr)
+		} else {
+			assert.ErrorIs(t, err, service.ErrForbidden, addr)
+		}
+	}
+
+	_, err = svc.Authenticate(ctx, token.ID, secret)
+	assert.ErrorIs(t, err, service.ErrForbidden, "the ip is unknown")
+}
+
+func TestAuthorize(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(zap.NewNop(), db_servicetokens.NewMemory())
+
+	_, token, err := svc.Create(ctx, "codebase-id", "user-id", "ci", servicetokens.Capabilities{servicetokens.CapabilityChangesRead}, nil, nil)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, svc.Authorize(ctx, token, servicetokens.CapabilityFetchCI), service.ErrForbidden)
+	assert.NoError(t, svc.Authorize(ctx, token, servicetokens.CapabilityChangesRead))
+
+	usages, err := svc.ListUsages(ctx, token.ID)
+	require.NoError(t, err)
+	if assert.Len(t, usages, 1) {
+		assert.Equal(t, servicetokens.CapabilityChangesRead, usages[0].Capability)
+	}
+
+	token, err = svc.Get(ctx, token.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, token.LastUsedAt)
+}
+
+func TestAuthorizeWorkspace(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(zap.NewNop(), db_servicetokens.NewMemory())
+
+	_, token, err := svc.Create(ctx, "codebase-id", "user-id", "ci", servicetokens.Capabilities{servicetokens.CapabilityWorkspacesWrite}, nil, nil)
+	require.NoError(t, err)
+	_, otherToken, err := svc.Create(ctx, "codebase-id", "user-id", "ci", servicetokens.Capabilities{servicetokens.CapabilityWorkspacesWrite}, nil, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, svc.AddWorkspace(ctx, token, "workspace-id"))
+
+	assert.NoError(t, svc.AuthorizeWorkspace(ctx, token, "workspace-id", servicetokens.CapabilityWorkspacesWrite))
+	assert.ErrorIs(t, svc.AuthorizeWorkspace(ctx, token, "other-workspace-id", servicetokens.CapabilityWorkspacesWrite), service.ErrForbidden)
+	assert.ErrorIs(t, svc.AuthorizeWorkspace(ctx, otherToken, "workspace-id", servicetokens.CapabilityWorkspacesWrite), service.ErrForbidden)
+	assert.ErrorIs(t, svc.AuthorizeWorkspace(ctx, token, "workspace-id", servicetokens.CapabilityChangesRead), service.ErrForbidden)
+}
+
+func TestRotate(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(zap.NewNop(), db_servicetokens.NewMemory())
+
+	oldSecret, token, err := svc.Create(ctx, "codebase-id", "user-id", "ci", servicetokens.DefaultCapabilities, nil, nil)
+	require.NoError(t, err)
+
+	newSecret, token, err := svc.Rotate(ctx, token, time.Hour)
+	require.NoError(t, err)
+
+	_, err = svc.Authenticate(ctx, token.ID, newSecret)
+	assert.NoError(t, err)
+	_, err = svc.Authenticate(ctx, token.ID, oldSecret)
+	assert.NoError(t, err, "the old secret is valid during the grace period")
+
+	// without a grace period, only the new secret is valid
+	newestSecret, token, err := svc.Rotate(ctx, token, 0)
+	require.NoError(t, err)
+
+	_, err = svc.Authenticate(ctx, token.ID, newestSecret)
+	assert.NoError(t, err)
+	_, err = svc.Authenticate(ctx, token.ID, newSecret)
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+	_, err = svc.Authenticate(ctx, token.ID, oldSecret)
+	assert.ErrorIs(t, err, service.ErrUnauthenticated)
+
+	_, _, err = svc.Rotate(ctx, token, service.MaxGracePeriod+time.Second)
+	assert.ErrorIs(t, err, service.ErrInvalidGracePeriod)
+}
+
+func TestVerify_gracePeriodExpired(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(zap.NewNop(), db_servicetokens.NewMemory())
+
+	oldSecret, token, err := svc.Create(ctx, "codebase-id", "user-id", "ci", servicetokens.DefaultCapabilities, nil, nil)
+	require.NoError(t, err)
+	_, token, err = svc.Rotate(ctx, token, time.Hour)
+	require.NoError(t, err)
+
+	assert.NoError(t, token.Verify(oldSecret, time.Now()))
+	assert.Error(t, token.Verify(oldSecret, time.Now().Add(time.Hour)))
+}
+
+func TestCreate_invalid(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(zap.NewNop(), db_servicetokens.NewMemory())
+
+	_, _, err := svc.Create(ctx, "codebase-id", "user-id", "ci", nil, nil, nil)
+	assert.ErrorIs(t, err, service.ErrInvalidCapability)
+
+	_, _, err = svc.Create(ctx, "codebase-id", "user-id", "ci", servicetokens.Capabilities{"everything"}, nil, nil)
+	assert.ErrorIs(t, err, service.ErrInvalidCapability)
+
+	_, _, err = svc.Create(ctx, "codebase-id", "user-id", "ci", servicetokens.DefaultCapabilities, servicetokens.IPAllowList{"10.0.0.0/33"}, nil)
+	assert.ErrorIs(t, err, service.ErrInvalidAllowedIPs)
+
+	past := time.Now().Add(-time.Hour)
+	_, _, err = svc.Create(ctx, "codebase-id", "user-id", "ci", servicetokens.DefaultCapabilities, nil, &past)
+	assert.ErrorIs(t, err, service.ErrInvalidExpiry)
+}
@@ -1,20 +1,184 @@
 package servicetokens
 
 import (
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// Prefix is the prefix of service tokens that are used as bearer tokens with the API, as Prefix + id + "_" + secret.
+// With git over http, the id is the username and the secret is the password.
+const Prefix = "sturdy_st_"
+
+// IsServiceToken returns true if the plaintext token looks like a service token.
+func IsServiceToken(plaintext string) bool {
+	return strings.HasPrefix(plaintext, Prefix)
+}
+
+type Capability string
+
+const (
+	// CapabilityFetchCI allows fetching the ci repository of the codebase, that has the changes to build.
+	CapabilityFetchCI Capability = "ci:fetch"
+	// CapabilityStatusesWrite allows reporting statuses on the changes of the codebase.
+	CapabilityStatusesWrite Capability = "statuses:write"
+	// CapabilityChangesRead allows reading the changes of the codebase.
+	CapabilityChangesRead Capability = "changes:read"
+	// CapabilityWorkspacesWrite allows creating workspaces in the codebase, that belong to the user that created the
+	// token, and making changes to them.
+	CapabilityWorkspacesWrite Capability = "workspaces:write"
+)
+
+var AllCapabilities = []Capability{CapabilityFetchCI, CapabilityStatusesWrite, CapabilityChangesRead, CapabilityWorkspacesWrite}
+
+// DefaultCapabilities are the capabilities of tokens that are created without any, which is what CI integrations
+// need.
+var DefaultCapabilities = Capabilities{CapabilityFetchCI, CapabilityStatusesWrite}
+
+func (c Capability) Valid() bool {
+	for _, capability := range AllCapabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+type Capabilities []Capability
+
+func (cs Capabilities) Has(capability Capability) bool {
+	for _, c := range cs {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+func (cs *Capabilities) Scan(src interface{}) error {
+	var arr pq.StringArray
+	if err := arr.Scan(src); err != nil {
+		return err
+	}
+	res := make(Capabilities, 0, len(arr))
+	for _, c := range arr {
+		res = append(res, Capability(c))
+	}
+	*cs = res
+	return nil
+}
+
+func (cs Capabilities) Value() (driver.Value, error) {
+	arr := make(pq.StringArray, 0, len(cs))
+	for _, c := range cs {
+		arr = append(arr, string(c))
+	}
+	return arr.Value()
+}
+
+// IPAllowList is a list of IP addresses and CIDR ranges. An empty list allows all addresses. The addresses are matched
+// against the ip of the client, which behind a load balancer is only known if the load balancer is configured as a
+// trusted proxy (--http.trusted-proxy and --git.trusted-proxy).
+type IPAllowList []string
+
+// Validate returns an error if an entry is neither an IP address nor a CIDR range.
+func (l IPAllowList) Validate() error {
+	for _, entry := range l {
+		if _, _, err := net.ParseCIDR(entry); err == nil {
+			continue
+		}
+		if net.ParseIP(entry) == nil {
+			return fmt.Errorf("%q is neither an ip address nor a cidr range", entry)
+		}
+	}
+	return nil
+}
+
+func (l IPAllowList) Allows(ip net.IP) bool {
+	if len(l) == 0 {
+		return true
+	}
+	if ip == nil {
+		return false
+	}
+	for _, entry := range l {
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if network.Contains(ip) {
+				return true
+			}
+		} else if allowed := net.ParseIP(entry); allowed != nil && allowed.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *IPAllowList) Scan(src interface{}) error {
+	var arr pq.StringArray
+	if err := arr.Scan(src); err != nil {
+		return err
+	}
+	*l = IPAllowList(arr)
+	return nil
+}
+
+func (l IPAllowList) Value() (driver.Value, error) {
+	if l == nil {
+		return pq.StringArray{}.Value()
+	}
+	return pq.StringArray(l).Value()
+}
+
+// Token authenticates CI and other automation to a codebase, with what they can do limited to the capabilities of
+// the token.
 type Token struct {
-	ID         string     `db:"id"`
-	CodebaseID string     `db:"codebase_id"`
-	Hash       []byte     `db:"hash"`
-	Name       string     `db:"name"`
+	ID           string       `db:"id"`
+	CodebaseID   string       `db:"codebase_id"`
+	Hash         []byte       `db:"hash"`
+	Name         string       `db:"name"`
+	Capabilities Capabilities `db:"capabilities"`
+	AllowedIPs   IPAllowList  `db:"allowed_ips"`
+	// CreatedBy is the ID of the user that created the token, tokens from before it was recorded don't have it.
+	CreatedBy  *string    `db:"created_by"`
 	CreatedAt  time.Time  `db:"created_at"`
+	ExpiresAt  *time.Time `db:"expires_at"`
 	LastUsedAt *time.Time `db:"last_used_at"`
+	// PreviousHash is the hash of the secret from before the token was rotated, that is valid until
+	// PreviousHashExpiresAt.
+	PreviousHash          []byte     `db:"previous_hash"`
+	PreviousHashExpiresAt *time.Time `db:"previous_hash_expires_at"`
+}
+
+// Verify returns an error if the secret is neither the secret of the token, nor the secret from before the token was
+// rotated while it's still valid.
+func (t *Token) Verify(secret string, now time.Time) error {
+	err := bcrypt.CompareHashAndPassword(t.Hash, []byte(secret))
+	if err == nil || t.PreviousHash == nil || t.PreviousHashExpiresAt == nil || !now.Before(*t.PreviousHashExpiresAt) {
+		return err
+	}
+	return bcrypt.CompareHashAndPassword(t.PreviousHash, []byte(secret))
+}
+
+// IsExpired returns true if the token has an expiry that has passed.
+func (t *Token) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// Usage is when a token was last used for one of its capabilities.
+type Usage struct {
+	TokenID    string     `db:"token_id"`
+	Capability Capability `db:"capability"`
+	LastUsedAt time.Time  `db:"last_used_at"`
 }
 
-func (t *Token) Verify(c string) error {
-	return bcrypt.CompareHashAndPassword(t.Hash, []byte(c))
+// Workspace is a workspace that was created with a token. Tokens can only write to the workspaces that they created.
+type Workspace struct {
+	TokenID     string    `db:"token_id"`
+	WorkspaceID string    `db:"workspace_id"`
+	CreatedAt   time.Time `db:"created_at"`
 }
@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"getsturdy.com/api/pkg/sessions"
+)
+
+var _ Repository = &memory{}
+
+type memory struct {
+	mu   sync.Mutex
+	byID map[string]sessions.Session
+}
+
+func NewMemory() Repository {
+	return &memory{byID: make(map[string]sessions.Session)}
+}
+
+func (m *memory) Create(_ context.Context, session *sessions.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byID[session.ID] = *session
+	return nil
+}
+
+func (m *memory) Get(_ context.Context, id string) (*sessions.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.byID[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &session, nil
+}
+
+func (m *memory) ListByUserID(_ context.Context, userID string) ([]*sessions.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	var res []*sessions.Session
+	for _, session := range m.byID {
+		session := session
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			res = append(res, &session)
+		}
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].LastUsedAt.After(res[b].LastUsedAt)
+	})
+	return res, nil
+}
+
+func (m *memory) ListRevokedIDs(_ context.Context, now time.Time) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []string
+	for _, session := range m.byID {
+		if session.RevokedAt != nil && session.ExpiresAt.After(now) {
+			res = append(res, session.ID)
+		}
+	}
+	return res, nil
+}
+
+func (m *memory) Update(_ context.Context, session *sessions.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	updated := *session
+	updated.TwoFactorVerifiedAt = m.byID[session.ID].TwoFactorVerifiedAt
+	m.byID[session.ID] = updated
+	return nil
+}
+
+func (m *memory) SetTwoFactorVerifiedAt(_ context.Context, id string, at time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.byID[id]
+	if !ok {
+		return nil
+	}
+	session.TwoFactorVerifiedAt = &at
+	m.byID[id] = session
+	return nil
+}
@@ -0,0 +1,125 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/sessions"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+	Create(context.Context, *sessions.Session) error
+	Get(ctx context.Context, id string) (*sessions.Session, error)
+	// ListByUserID returns the sessions of the user that are neither revoked nor expired.
+	ListByUserID(ctx context.Context, userID string) ([]*sessions.Session, error)
+	// ListRevokedIDs returns the IDs of the revoked sessions that are not expired.
+	ListRevokedIDs(ctx context.Context, now time.Time) ([]string, error)
+	// Update updates the session, except for when the second factor was verified, which is only set with
+	// SetTwoFactorVerifiedAt.
+	Update(context.Context, *sessions.Session) error
+	SetTwoFactorVerifiedAt(ctx context.Context, id string, at time.Time) error
+}
+
+type database struct {
+	db *sqlx.DB
+}
+
+func New(db *sqlx.DB) Repository {
+	return &database{db: db}
+}
+
+func (r *database) Create(ctx context.Context, session *sessions.Session) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO sessions
+			(id, user_id, device, ip, created_at, last_used_at, expires_at, revoked_at, two_factor_verified_at)
+		VALUES
+			(:id, :user_id, :device, :ip, :created_at, :last_used_at, :expires_at, :revoked_at, :two_factor_verified_at)
+	`, session); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (r *database) Get(ctx context.Context, id string) (*sessions.Session, error) {
+	var res sessions.Session
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, user_id, device, ip, created_at, last_used_at, expires_at, revoked_at, two_factor_verified_at
+		FROM
+			sessions
+		WHERE
+			id = $1
+	`, id); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *database) ListByUserID(ctx context.Context, userID string) ([]*sessions.Session, error) {
+	var res []*sessions.Session
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, user_id, device, ip, created_at, last_used_at, expires_at, revoked_at, two_factor_verified_at
+		FROM
+			sessions
+		WHERE
+			user_id = $1
+			AND revoked_at IS NULL
+			AND expires_at > NOW()
+		ORDER BY
+			last_used_at DESC
+	`, userID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *database) ListRevokedIDs(ctx context.Context, now time.Time) ([]string, error) {
+	var res []string
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id
+		FROM
+			sessions
+		WHERE
+			revoked_at IS NOT NULL
+			AND expires_at > $1
+	`, now); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *database) Update(ctx context.Context, session *sessions.Session) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		UPDATE
+			sessions
+		SET
+			ip = :ip,
+			last_used_at = :last_used_at,
+			expires_at = :expires_at,
+			revoked_at = :revoked_at
+		WHERE
+			id = :id
+	`, session); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (r *database) SetTwoFactorVerifiedAt(ctx context.Context, id string, at time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE
+			sessions
+		SET
+			two_factor_verified_at = $2
+		WHERE
+			id = $1
+	`, id, at); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package graphql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"getsturdy.com/api/pkg/accesstokens"
+	"getsturdy.com/api/pkg/auth"
+	service_auth "getsturdy.com/api/pkg/auth/service"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/organization"
+	service_organization "getsturdy.com/api/pkg/organization/service"
+	"getsturdy.com/api/pkg/sessions"
+	service_sessions "getsturdy.com/api/pkg/sessions/service"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type rootResolver struct {
+	sessionsService     *service_sessions.Service
+	organizationService *service_organization.Service
+	authService         *service_auth.Service
+}
+
+func New(
+	sessionsService *service_sessions.Service,
+	organizationService *service_organization.Service,
+	authService *service_auth.Service,
+) resolvers.SessionsRootResolver {
+	return &rootResolver{
+		sessionsService:     sessionsService,
+		organizationService: organizationService,
+		authService:         authService,
+	}
+}
+
+// authenticatedUserID returns the ID of the authenticated user. Sessions can only be managed with a session, or
+// with a personal access token that has the admin scope.
+func authenticatedUserID(ctx context.Context) (string, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := auth.RequireScope(ctx, accesstokens.ScopeAdmin); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+func (r *rootResolver) InternalListByUserID(ctx context.Context, userID string) ([]resolvers.SessionResolver, error) {
+	authUserID, err := authenticatedUserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	if authUserID != userID {
+		return nil, gqlerrors.Error(gqlerrors.ErrNotFound)
+	}
+
+	ss, err := r.sessionsService.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return toResolvers(ss), nil
+}
+
+func (r *rootResolver) RevokeSession(ctx context.Context, args resolvers.RevokeSessionArgs) (resolvers.SessionResolver, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	session, err := r.sessionsService.Get(ctx, string(args.Input.ID))
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, gqlerrors.Error(gqlerrors.ErrNotFound)
+	default:
+		return nil, gqlerrors.Error(err)
+	}
+
+	if session.UserID != userID {
+		return nil, gqlerrors.Error(gqlerrors.ErrNotFound)
+	}
+
+	if err := r.sessionsService.Revoke(ctx, session); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	return &resolver{session: session}, nil
+}
+
+// RevokeAllSessions revokes all sessions of the authenticated user. Owners and admins of an organization can also
+// revoke all sessions of its members.
+func (r *rootResolver) RevokeAllSessions(ctx context.Context, args resolvers.RevokeAllSessionsArgs) ([]resolvers.SessionResolver, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if args.Input.UserID != nil && string(*args.Input.UserID) != userID {
+		if args.Input.OrganizationID == nil {
+			return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "organizationID", "required to revoke the sessions of another user")
+		}
+		if err := r.canManageMember(ctx, userID, string(*args.Input.OrganizationID), string(*args.Input.UserID)); err != nil {
+			return nil, gqlerrors.Error(err)
+		}
+		userID = string(*args.Input.UserID)
+	}
+
+	ss, err := r.sessionsService.RevokeAll(ctx, userID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return toResolvers(ss), nil
+}
+
+// canManageMember returns nil if the user can manage the member of the organization. Only owners can manage other
+// owners.
+func (r *rootResolver) canManageMember(ctx context.Context, userID, organizationID, memberUserID string) error {
+	org, err := r.organizationService.GetByID(ctx, organizationID)
+	if err != nil {
+		return fmt.Errorf("organization not found: %w", err)
+	}
+
+	if err := r.authService.CanAdmin(ctx, org); err != nil {
+		return err
+	}
+
+	member, err := r.organizationService.GetMember(ctx, org.ID, memberUserID)
+	if err != nil {
+		return fmt.Errorf("member not found: %w", err)
+	}
+
+	if member.Role == organization.RoleOwner {
+		viewer, err := r.organizationService.GetMember(ctx, org.ID, userID)
+		if err != nil {
+			return err
+		}
+		if viewer.Role != organization.RoleOwner {
+			return fmt.Errorf("only owners can manage owners: %w", auth.ErrForbidden)
+		}
+	}
+
+	return nil
+}
+
+func toResolvers(ss []*sessions.Session) []resolvers.SessionResolver {
+	res := make([]resolvers.SessionResolver, 0, len(ss))
+	for _, session := range ss {
+		res = append(res, &resolver{session: session})
+	}
+	return res
+}
+
+type resolver struct {
+	session *sessions.Session
+}
+
+func (r *resolver) ID() graphql.ID {
+	return graphql.ID(r.session.ID)
+}
+
+func (r *resolver) Device() string {
+	return r.session.Device
+}
+
+func (r *resolver) IP() string {
+	return r.session.IP
+}
+
+func (r *resolver) CreatedAt() int32 {
+	return int32(r.session.CreatedAt.Unix())
+}
+
+func (r *resolver) LastUsedAt() int32 {
+	return int32(r.session.LastUsedAt.Unix())
+}
+
+func (r *resolver) ExpiresAt() int32 {
+	return int32(r.session.ExpiresAt.Unix())
+}
+
+func (r *resolver) RevokedAt() *int32 {
+	if r.session.RevokedAt == nil {
+		return nil
+	}
+	t := int32(r.session.RevokedAt.Unix())
+	return &t
+}
+
+func (r *resolver) Current(ctx context.Context) bool {
+	subject, ok := auth.FromContext(ctx)
+	return ok && subject.SessionID == r.session.ID
+}
@@ -0,0 +1,14 @@
+package module
+
+import (
+	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/sessions/db"
+	"getsturdy.com/api/pkg/sessions/graphql"
+	"getsturdy.com/api/pkg/sessions/service"
+)
+
+func Module(c *di.Container) {
+	c.Import(db.Module)
+	c.Import(graphql.Module)
+	c.Import(service.Module)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// lastUsedList is an in-memory list of when the use of sessions was last written to the database. Entries are only
+// needed until the next write is due, so they are pruned once they are older than the interval, and the list only
+// holds the sessions that have been used recently.
+type lastUsedList struct {
+	interval time.Duration
+
+	mu       sync.Mutex
+	usedAt   map[string]time.Time
+	prunedAt time.Time
+}
+
+func newLastUsedList(interval time.Duration) *lastUsedList {
+	return &lastUsedList{
+		interval: interval,
+		usedAt:   make(map[string]time.Time),
+	}
+}
+
+// shouldWrite returns true if the use of the session at now should be written to the database, and if so, records
+// it as the last write.
+func (l *lastUsedList) shouldWrite(id string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.prunedAt) >= l.interval {
+		l.prune(now)
+	}
+
+	if usedAt, ok := l.usedAt[id]; ok && now.Sub(usedAt) < l.interval {
+		return false
+	}
+	l.usedAt[id] = now
+	return true
+}
+
+func (l *lastUsedList) prune(now time.Time) {
+	for id, usedAt := range l.usedAt {
+		if now.Sub(usedAt) >= l.interval {
+			delete(l.usedAt, id)
+		}
+	}
+	l.prunedAt = now
+}
+
+func (l *lastUsedList) remove(id string) {
+	l.mu.Lock()
+	delete(l.usedAt, id)
+	l.mu.Unlock()
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLastUsedList(t *testing.T) {
+	l := newLastUsedList(time.Minute)
+	now := time.Now()
+
+	assert.True(t, l.shouldWrite("a", now))
+	assert.False(t, l.shouldWrite("a", now.Add(30*time.Second)))
+	assert.True(t, l.shouldWrite("b", now.Add(30*time.Second)))
+	assert.Len(t, l.usedAt, 2)
+
+	// a is pruned once its next write is due
+	assert.False(t, l.shouldWrite("b", now.Add(time.Minute)))
+	assert.Len(t, l.usedAt, 1)
+
+	// b is pruned when another session is used
+	assert.True(t, l.shouldWrite("c", now.Add(2*time.Minute)))
+	assert.Len(t, l.usedAt, 1)
+
+	l.remove("c")
+	assert.Empty(t, l.usedAt)
+}
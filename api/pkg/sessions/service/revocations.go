@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	db_sessions "getsturdy.com/api/pkg/sessions/db"
+)
+
+// revocationList is an in-memory list of the revoked sessions that are not expired. It is reloaded from the
+// database when it's older than its ttl, so that sessions that are revoked by other instances of the API are picked
+// up.
+type revocationList struct {
+	repo db_sessions.Repository
+	ttl  time.Duration
+
+	mu       sync.RWMutex
+	ids      map[string]struct{}
+	loadedAt time.Time
+}
+
+func newRevocationList(repo db_sessions.Repository, ttl time.Duration) *revocationList {
+	return &revocationList{
+		repo: repo,
+		ttl:  ttl,
+		ids:  make(map[string]struct{}),
+	}
+}
+
+func (l *revocationList) contains(ctx context.Context, id string) (bool, error) {
+	l.mu.RLock()
+	stale := time.Since(l.loadedAt) > l.ttl
+	_, found := l.ids[id]
+	l.mu.RUnlock()
+
+	if !stale {
+		return found, nil
+	}
+
+	if err := l.load(ctx); err != nil {
+		return false, err
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	_, found = l.ids[id]
+	return found, nil
+}
+
+func (l *revocationList) load(ctx context.Context) error {
+	now := time.Now()
+	revokedIDs, err := l.repo.ListRevokedIDs(ctx, now)
+	if err != nil {
+		return fmt.Errorf("failed to list revoked sessions: %w", err)
+	}
+
+	ids := make(map[string]struct{}, len(revokedIDs))
+	for _, id := range revokedIDs {
+		ids[id] = struct{}{}
+	}
+
+	l.mu.Lock()
+	l.ids = ids
+	l.loadedAt = now
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *revocationList) add(id string) {
+	l.mu.Lock()
+	l.ids[id] = struct{}{}
+	l.mu.Unlock()
+}
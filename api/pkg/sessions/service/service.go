@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/ip"
+	"getsturdy.com/api/pkg/sessions"
+	db_sessions "getsturdy.com/api/pkg/sessions/db"
+	"getsturdy.com/api/pkg/useragent"
+)
+
+const (
+	// revocationsTTL is how long it takes for a session that is revoked by another instance of the API to be
+	// revoked in this instance.
+	revocationsTTL = 30 * time.Second
+	// lastUsedInterval is how often the last use of a session is written to the database.
+	lastUsedInterval = time.Minute
+)
+
+type Service struct {
+	repo db_sessions.Repository
+
+	revocations *revocationList
+	lastUsed    *lastUsedList
+}
+
+func New(
+	repo db_sessions.Repository,
+) *Service {
+	return &Service{
+		repo: repo,
+
+		revocations: newRevocationList(repo, revocationsTTL),
+		lastUsed:    newLastUsedList(lastUsedInterval),
+	}
+}
+
+// Issued records that an auth token has been issued for the session. If the session already exists, the token is a
+// renewal, and the session is extended.
+func (s *Service) Issued(ctx context.Context, id, userID string, expiresAt time.Time) error {
+	now := time.Now()
+	session, err := s.repo.Get(ctx, id)
+	switch {
+	case err == nil:
+		session.LastUsedAt = now
+		session.ExpiresAt = expiresAt
+		if err := s.repo.Update(ctx, session); err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return s.create(ctx, id, userID, now, expiresAt)
+	default:
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+}
+
+// Used records that the session has been used. To not write to the database on every request, it is only recorded
+// once every lastUsedInterval.
+func (s *Service) Used(ctx context.Context, id, userID string, expiresAt time.Time) error {
+	now := time.Now()
+
+	if !s.lastUsed.shouldWrite(id, now) {
+		return nil
+	}
+
+	session, err := s.repo.Get(ctx, id)
+	switch {
+	case err == nil:
+		session.LastUsedAt = now
+		if addr, ok := ip.FromContext(ctx); ok && addr != nil {
+			session.IP = addr.String()
+		}
+		if err := s.repo.Update(ctx, session); err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		// the token was issued before sessions were recorded
+		return s.create(ctx, id, userID, now, expiresAt)
+	default:
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+}
+
+func (s *Service) create(ctx context.Context, id, userID string, now, expiresAt time.Time) error {
+	session := &sessions.Session{
+		ID:         id,
+		UserID:     userID,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  expiresAt,
+	}
+	if device, ok := useragent.FromContext(ctx); ok {
+		session.Device = device
+	}
+	if addr, ok := ip.FromContext(ctx); ok && addr != nil {
+		session.IP = addr.String()
+	}
+	if err := s.repo.Create(ctx, session); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*sessions.Session, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// ListByUserID returns the sessions of the user that are neither revoked nor expired.
+func (s *Service) ListByUserID(ctx context.Context, userID string) ([]*sessions.Session, error) {
+	return s.repo.ListByUserID(ctx, userID)
+}
+
+// TwoFactorVerified records that the user has entered their second factor in the session.
+func (s *Service) TwoFactorVerified(ctx context.Context, id string) error {
+	if err := s.repo.SetTwoFactorVerifiedAt(ctx, id, time.Now()); err != nil {
+		return fmt.Errorf("failed to set two-factor verified: %w", err)
+	}
+	return nil
+}
+
+// IsTwoFactorVerified returns true if the user has entered their second factor in the session.
+func (s *Service) IsTwoFactorVerified(ctx context.Context, id string) (bool, error) {
+	session, err := s.repo.Get(ctx, id)
+	switch {
+	case err == nil:
+		return session.TwoFactorVerifiedAt != nil, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to get session: %w", err)
+	}
+}
+
+// IsRevoked returns true if the session has been revoked.
+func (s *Service) IsRevoked(ctx context.Context, id string) (bool, error) {
+	return s.revocations.contains(ctx, id)
+}
+
+// Revoke revokes the session, the auth tokens of the session are no longer valid.
+func (s *Service) Revoke(ctx context.Context, session *sessions.Session) error {
+	if session.RevokedAt != nil {
+		return nil
+	}
+	now := time.Now()
+	session.RevokedAt = &now
+	if err := s.repo.Update(ctx, session); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	s.revocations.add(session.ID)
+	s.lastUsed.remove(session.ID)
+	return nil
+}
+
+// RevokeAll revokes all sessions of the user, and returns the revoked sessions.
+func (s *Service) RevokeAll(ctx context.Context, userID string) ([]*sessions.Session, error) {
+	ss, err := s.repo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	for _, session := range ss {
+		if err := s.Revoke(ctx, session); err != nil {
+			return nil, err
+		}
+	}
+	return ss, nil
+}
@@ -0,0 +1,90 @@
+package service_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/ip"
+	db_sessions "getsturdy.com/api/pkg/sessions/db"
+	"getsturdy.com/api/pkg/sessions/service"
+	"getsturdy.com/api/pkg/useragent"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssued(t *testing.T) {
+	ctx := useragent.NewContext(context.Background(), "sturdy-cli")
+	ctx = ip.NewContext(ctx, net.ParseIP("10.0.0.1"))
+	svc := service.New(db_sessions.NewMemory())
+
+	expiresAt := time.Now().Add(time.Hour)
+	assert.NoError(t, svc.Issued(ctx, "session-id", "user-id", expiresAt))
+
+	ss, err := svc.ListByUserID(ctx, "user-id")
+	if assert.NoError(t, err) && assert.Len(t, ss, 1) {
+		assert.Equal(t, "session-id", ss[0].ID)
+		assert.Equal(t, "sturdy-cli", ss[0].Device)
+		assert.Equal(t, "10.0.0.1", ss[0].IP)
+	}
+
+	// renewing the token extends the session
+	renewedExpiresAt := expiresAt.Add(time.Hour)
+	assert.NoError(t, svc.Issued(ctx, "session-id", "user-id", renewedExpiresAt))
+
+	session, err := svc.Get(ctx, "session-id")
+	if assert.NoError(t, err) {
+		assert.Equal(t, renewedExpiresAt, session.ExpiresAt)
+	}
+}
+
+func TestRevokeAll(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(db_sessions.NewMemory())
+
+	expiresAt := time.Now().Add(time.Hour)
+	assert.NoError(t, svc.Issued(ctx, "a", "user-id", expiresAt))
+	assert.NoError(t, svc.Issued(ctx, "b", "user-id", expiresAt))
+	assert.NoError(t, svc.Issued(ctx, "c", "other-user-id", expiresAt))
+
+	revoked, err := svc.RevokeAll(ctx, "user-id")
+	assert.NoError(t, err)
+	assert.Len(t, revoked, 2)
+
+	for id, expected := range map[string]bool{"a": true, "b": true, "c": false} {
+		isRevoked, err := svc.IsRevoked(ctx, id)
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected, isRevoked, id)
+		}
+	}
+
+	ss, err := svc.ListByUserID(ctx, "user-id")
+	assert.NoError(t, err)
+	assert.Empty(t, ss)
+}
+
+func TestTwoFactorVerified(t *testing.T) {
+	ctx := context.Background()
+	svc := service.New(db_sessions.NewMemory())
+
+	expiresAt := time.Now().Add(time.Hour)
+	assert.NoError(t, svc.Issued(ctx, "session-id", "user-id", expiresAt))
+
+	verified, err := svc.IsTwoFactorVerified(ctx, "session-id")
+	assert.NoError(t, err)
+	assert.False(t, verified)
+
+	assert.NoError(t, svc.TwoFactorVerified(ctx, "session-id"))
+
+	// renewing the token keeps the session verified
+	assert.NoError(t, svc.Issued(ctx, "session-id", "user-id", expiresAt.Add(time.Hour)))
+
+	verified, err = svc.IsTwoFactorVerified(ctx, "session-id")
+	assert.NoError(t, err)
+	assert.True(t, verified)
+
+	verified, err = svc.IsTwoFactorVerified(ctx, "other-session-id")
+	assert.NoError(t, err)
+	assert.False(t, verified)
+}
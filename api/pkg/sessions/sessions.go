@@ -0,0 +1,22 @@
+package sessions
+
+import (
+	"time"
+)
+
+// Session is a signed in session of a user. The ID of a session is the ID of its auth token, which stays the same
+// when the token is renewed.
+type Session struct {
+	ID     string `db:"id"`
+	UserID string `db:"user_id"`
+	// Device is the user agent that the session was started from
+	Device string `db:"device"`
+	// IP is the address that the session was last used from
+	IP         string     `db:"ip"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt time.Time  `db:"last_used_at"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+	// TwoFactorVerifiedAt is when the user entered their second factor in the session, if they did
+	TwoFactorVerifiedAt *time.Time `db:"two_factor_verified_at"`
+}
@@ -19,6 +19,7 @@ type Repository interface {
 	Get(string) (*snapshots.Snapshot, error)
 	Update(snapshot *snapshots.Snapshot) error
 	ListUndeletedInCodebase(codebaseID string, threshold time.Time) ([]*snapshots.Snapshot, error)
+	ListLatestUndeletedPerWorkspace(codebaseID string, limit int) ([]*snapshots.Snapshot, error)
 }
 
 type dbrepo struct {
@@ -128,6 +129,40 @@ func (r *dbrepo) ListUndeletedInCodebase(codebaseID string, threshold time.Time)
 	return res, nil
 }
 
+// ListLatestUndeletedPerWorkspace returns up to limit most recent snapshots of each workspace in the codebase.
+func (r *dbrepo) ListLatestUndeletedPerWorkspace(codebaseID string, limit int) ([]*snapshots.Snapshot, error) {
+	var res []*snapshots.Snapshot
+	if err := r.db.Select(&res, `
+		SELECT
+			id,
+			view_id,
+			created_at,
+			new_files,
+			changed_files,
+			deleted_files,
+			previous_snapshot_id,
+			codebase_id,
+			commit_id,
+			workspace_id,
+			action,
+			diffs_count
+		FROM (
+			SELECT
+				*,
+				ROW_NUMBER() OVER (PARTITION BY workspace_id ORDER BY created_at DESC) AS workspace_row
+			FROM
+				snapshots
+			WHERE codebase_id = $1
+			  AND workspace_id IS NOT NULL
+			  AND deleted_at IS NULL
+		) AS ranked
+		WHERE workspace_row <= $2
+		`, codebaseID, limit); err != nil {
+		return nil, fmt.Errorf("failed to query table: %w", err)
+	}
+	return res, nil
+}
+
 func (r *dbrepo) Update(snapshot *snapshots.Snapshot) error {
 	_, err := r.db.NamedExec(`UPDATE snapshots
 		SET deleted_at = :deleted_at
@@ -333,6 +333,8 @@ func (s *snap) Snapshot(codebaseID, workspaceID string, action snapshots.Action,
 			if err := s.workspaceWriter.Update(context.TODO(), ws); err != nil {
 				return nil, fmt.Errorf("failed to update workspace: %w", err)
 			}
+			// workers are notified of the new snapshot, users get the events of the view instead
+			s.eventsSender.Internal(events.WorkspaceUpdatedSnapshot, workspaceID)
 		}
 
 		if isAuthoritativeView {
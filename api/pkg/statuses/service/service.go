@@ -7,25 +7,30 @@ import (
 	"getsturdy.com/api/pkg/events"
 	"getsturdy.com/api/pkg/statuses"
 	db_statuses "getsturdy.com/api/pkg/statuses/db"
+	"getsturdy.com/api/pkg/webhooks"
+	sender_webhooks "getsturdy.com/api/pkg/webhooks/sender"
 
 	"go.uber.org/zap"
 )
 
 type Service struct {
-	logger       *zap.Logger
-	repo         *db_statuses.Repository
-	eventsSender events.EventSender
+	logger         *zap.Logger
+	repo           *db_statuses.Repository
+	eventsSender   events.EventSender
+	webhooksSender sender_webhooks.Sender
 }
 
 func New(
 	logger *zap.Logger,
 	repo *db_statuses.Repository,
 	eventsSender events.EventSender,
+	webhooksSender sender_webhooks.Sender,
 ) *Service {
 	return &Service{
-		logger:       logger,
-		repo:         repo,
-		eventsSender: eventsSender,
+		logger:         logger,
+		repo:           repo,
+		eventsSender:   eventsSender,
+		webhooksSender: webhooksSender,
 	}
 }
 
@@ -41,6 +46,9 @@ func (s *Service) Set(ctx context.Context, status *statuses.Status) error {
 	if err := s.eventsSender.Codebase(status.CodebaseID, events.StatusUpdated, status.ID); err != nil {
 		s.logger.Error("failed to send status updated event", zap.Error(err))
 	}
+	if err := s.webhooksSender.Codebase(ctx, status.CodebaseID, webhooks.EventStatusUpdated, status.ID); err != nil {
+		s.logger.Error("failed to send webhook event", zap.Error(err))
+	}
 	return nil
 }
 
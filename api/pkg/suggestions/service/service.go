@@ -5,10 +5,14 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 	"time"
 
 	"getsturdy.com/api/pkg/analytics"
 	service_analytics "getsturdy.com/api/pkg/analytics/service"
+	"getsturdy.com/api/pkg/comments"
+	"getsturdy.com/api/pkg/comments/live"
 	"getsturdy.com/api/pkg/events"
 	"getsturdy.com/api/pkg/notification"
 	sender_notification "getsturdy.com/api/pkg/notification/sender"
@@ -236,8 +240,84 @@ func (s *Service) ApplyHunks(ctx context.Context, suggestion *suggestions.Sugges
 		}
 	}
 
-	if originalWorkspace.ViewID == nil { // apply patches to the snapshot
-		snapshot, err := s.snapshotter.GetByID(ctx, *originalWorkspace.LatestSnapshotID)
+	if err := s.applyPatches(ctx, originalWorkspace, func(vcs.RepoReader) ([][]byte, error) {
+		return patches, nil
+	}); err != nil {
+		return err
+	}
+
+	suggestion.AppliedHunks = append(suggestion.AppliedHunks, appliedHunks...)
+	if err := s.suggestionRepo.Update(ctx, suggestion); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	s.analyticsService.Capture(ctx, "suggestions-apply",
+		analytics.CodebaseID(suggestion.CodebaseID),
+		analytics.Property("suggestion_id", suggestion.ID),
+		analytics.Property("workspace_id", suggestion.ForWorkspaceID),
+	)
+
+	return nil
+}
+
+// ApplyComment applies the suggestion of a comment to the workspace that it's made in. The commented lines are fuzzily
+// relocated in the latest version of the workspace, comments.ErrSuggestionConflict is returned if they have been
+// changed since the suggestion was made.
+func (s *Service) ApplyComment(ctx context.Context, comment *comments.Comment) error {
+	if !comment.HasSuggestion() || comment.WorkspaceID == nil {
+		return comments.ErrNoSuggestion
+	}
+
+	ws, err := s.workspaceService.GetByID(ctx, *comment.WorkspaceID)
+	if err != nil {
+		return fmt.Errorf("failed to get workspace: %w", err)
+	}
+
+	if err := s.applyPatches(ctx, ws, func(repo vcs.RepoReader) ([][]byte, error) {
+		files := os.DirFS(repo.Path())
+		relocated := *comment
+		if relocated.Context != nil && relocated.ContextStartsAtLine != nil {
+			found, err := live.Relocate(&relocated, files, files)
+			if err != nil {
+				return nil, fmt.Errorf("failed to relocate comment: %w", err)
+			}
+			if !found {
+				return nil, comments.ErrSuggestionConflict
+			}
+		}
+
+		contents, err := fs.ReadFile(files, relocated.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, comments.ErrSuggestionConflict
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+
+		patch, err := relocated.SuggestionPatch(string(contents))
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{patch}, nil
+	}); err != nil {
+		return err
+	}
+
+	s.analyticsService.Capture(ctx, "comment-suggestion-apply",
+		analytics.CodebaseID(comment.CodebaseID),
+		analytics.Property("comment_id", comment.ID),
+		analytics.Property("workspace_id", ws.ID),
+	)
+
+	return nil
+}
+
+// applyPatches applies patches to the workspace, either directly to its view, or to its latest snapshot. The patches
+// are created from the files of the workspace.
+func (s *Service) applyPatches(ctx context.Context, ws *workspaces.Workspace, patchesFunc func(vcs.RepoReader) ([][]byte, error)) error {
+	if ws.ViewID == nil { // apply patches to the snapshot
+		if ws.LatestSnapshotID == nil {
+			return fmt.Errorf("the workspace has no snapshot")
+		}
+		snapshot, err := s.snapshotter.GetByID(ctx, *ws.LatestSnapshotID)
 		if err != nil {
 			return fmt.Errorf("failed to get snapshot: %w", err)
 		}
@@ -245,13 +325,18 @@ func (s *Service) ApplyHunks(ctx context.Context, suggestion *suggestions.Sugges
 		if err := s.executorProvider.New().
 			Write(vcs_view.CheckoutSnapshot(snapshot)).
 			Write(func(repo vcs.RepoWriter) error {
+				patches, err := patchesFunc(repo)
+				if err != nil {
+					return err
+				}
+
 				if err := repo.ApplyPatchesToWorkdir(patches); err != nil {
 					return fmt.Errorf("failed to apply patches: %w", err)
 				}
 
 				if _, err := s.snapshotter.Snapshot(
-					originalWorkspace.CodebaseID,
-					originalWorkspace.ID,
+					ws.CodebaseID,
+					ws.ID,
 					snapshots.ActionSuggestionApply,
 					snapshotter.WithOnView(*repo.ViewID()),
 					snapshotter.WithOnRepo(repo),
@@ -261,27 +346,20 @@ func (s *Service) ApplyHunks(ctx context.Context, suggestion *suggestions.Sugges
 				}
 
 				return nil
-			}).ExecTemporaryView(originalWorkspace.CodebaseID, "applySuggestionDiffs"); err != nil {
+			}).ExecTemporaryView(ws.CodebaseID, "applySuggestionDiffs"); err != nil {
 			return fmt.Errorf("failed to apply patches: %w", err)
 		}
 	} else { // apply to the view
 		if err := s.executorProvider.New().Write(func(repo vcs.RepoWriter) error {
+			patches, err := patchesFunc(repo)
+			if err != nil {
+				return err
+			}
 			return repo.ApplyPatchesToWorkdir(patches)
-		}).ExecView(originalWorkspace.CodebaseID, *originalWorkspace.ViewID, "applySuggestionDiffs"); err != nil {
+		}).ExecView(ws.CodebaseID, *ws.ViewID, "applySuggestionDiffs"); err != nil {
 			return fmt.Errorf("failed to apply patches: %w", err)
 		}
 	}
-
-	suggestion.AppliedHunks = append(suggestion.AppliedHunks, appliedHunks...)
-	if err := s.suggestionRepo.Update(ctx, suggestion); err != nil {
-		return fmt.Errorf("failed to update: %w", err)
-	}
-	s.analyticsService.Capture(ctx, "suggestions-apply",
-		analytics.CodebaseID(suggestion.CodebaseID),
-		analytics.Property("suggestion_id", suggestion.ID),
-		analytics.Property("workspace_id", suggestion.ForWorkspaceID),
-	)
-
 	return nil
 }
 
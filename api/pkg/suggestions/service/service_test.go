@@ -16,6 +16,7 @@ import (
 	"getsturdy.com/api/pkg/events"
 	"getsturdy.com/api/pkg/internal/inmemory"
 	"getsturdy.com/api/pkg/notification/sender"
+	"getsturdy.com/api/pkg/queue"
 	"getsturdy.com/api/pkg/snapshots"
 	db_snapshots "getsturdy.com/api/pkg/snapshots/db"
 	"getsturdy.com/api/pkg/snapshots/snapshotter"
@@ -26,6 +27,7 @@ import (
 	"getsturdy.com/api/pkg/view"
 	db_view "getsturdy.com/api/pkg/view/db"
 	vcs_view "getsturdy.com/api/pkg/view/vcs"
+	sender_webhooks "getsturdy.com/api/pkg/webhooks/sender"
 	"getsturdy.com/api/pkg/workspaces"
 	db_workspaces "getsturdy.com/api/pkg/workspaces/db"
 	service_workspace "getsturdy.com/api/pkg/workspaces/service"
@@ -264,10 +266,10 @@ func newTest(t *testing.T, operations []*operation) *test {
 	eventsSender := events.NewSender(codebaseUserRepo, workspaceDB, events.NewInMemory())
 	changeRepo := inmemory.NewInMemoryChangeRepo()
 
-	changeService := service_change.New(changeRepo, zap.NewNop(), executorProvider)
+	changeService := service_change.New(changeRepo, inmemory.NewInMemoryChangelogMaterializationRepo(), zap.NewNop(), executorProvider, queue.NewNoop())
 	analyticsService := service_analytics.New(zap.NewNop(), disabled.NewClient(zap.NewNop()))
 	gitSnapshotter := snapshotter.NewGitSnapshotter(snapshotsDB, workspaceDB, workspaceDB, viewDB, nil, executorProvider, zap.NewNop())
-	workspaceService := service_workspace.New(zap.NewNop(), analyticsService, workspaceDB, workspaceDB, nil, nil, nil, changeService, nil, executorProvider, nil, nil, gitSnapshotter, nil)
+	workspaceService := service_workspace.New(zap.NewNop(), analyticsService, workspaceDB, workspaceDB, nil, nil, nil, changeService, nil, executorProvider, nil, sender_webhooks.NewNoopSender(), nil, gitSnapshotter, nil)
 	suggestionService := service_suggestions.New(zap.NewNop(), suggestionRepo, workspaceService, executorProvider, gitSnapshotter, analyticsService, sender.NewNoopNotificationSender(), eventsSender)
 	return &test{
 		repoProvider:      repoProvider,
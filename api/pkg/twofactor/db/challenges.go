@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ChallengesRepository records the two-factor challenges that users have signed in with, each challenge can only be
+// used once.
+type ChallengesRepository interface {
+	// Use records that the challenge has been used, and returns false if it has been used before.
+	Use(ctx context.Context, id string, expiresAt time.Time) (bool, error)
+	// DeleteExpired deletes the challenges that have expired, they can't be used again anyway.
+	DeleteExpired(ctx context.Context, now time.Time) error
+}
+
+type challengesDatabase struct {
+	db *sqlx.DB
+}
+
+func NewChallenges(db *sqlx.DB) ChallengesRepository {
+	return &challengesDatabase{db: db}
+}
+
+func (r *challengesDatabase) Use(ctx context.Context, id string, expiresAt time.Time) (bool, error) {
+	var usedID string
+	err := r.db.GetContext(ctx, &usedID, `
+		INSERT INTO two_factor_used_challenges
+			(id, expires_at)
+		VALUES
+			($1, $2)
+		ON CONFLICT (id) DO NOTHING
+		RETURNING
+			id
+	`, id, expiresAt)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to insert: %w", err)
+	}
+}
+
+func (r *challengesDatabase) DeleteExpired(ctx context.Context, now time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `
+		DELETE FROM
+			two_factor_used_challenges
+		WHERE
+			expires_at < $1
+	`, now); err != nil {
+		return fmt.Errorf("failed to delete: %w", err)
+	}
+	return nil
+}
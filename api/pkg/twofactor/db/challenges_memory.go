@@ -0,0 +1,39 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ ChallengesRepository = &challengesMemory{}
+
+type challengesMemory struct {
+	mu        sync.Mutex
+	expiresAt map[string]time.Time
+}
+
+func NewChallengesMemory() ChallengesRepository {
+	return &challengesMemory{expiresAt: make(map[string]time.Time)}
+}
+
+func (m *challengesMemory) Use(_ context.Context, id string, expiresAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.expiresAt[id]; ok {
+		return false, nil
+	}
+	m.expiresAt[id] = expiresAt
+	return true, nil
+}
+
+func (m *challengesMemory) DeleteExpired(_ context.Context, now time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, expiresAt := range m.expiresAt {
+		if expiresAt.Before(now) {
+			delete(m.expiresAt, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package db
+
+import (
+	"getsturdy.com/api/pkg/di"
+)
+
+func Module(c *di.Container) {
+	c.Register(NewTOTP)
+	c.Register(NewRecoveryCodes)
+	c.Register(NewChallenges)
+}
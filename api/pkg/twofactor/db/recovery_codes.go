@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"getsturdy.com/api/pkg/twofactor"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type RecoveryCodesRepository interface {
+	Create(context.Context, *twofactor.RecoveryCode) error
+	// ListUnusedByUserID returns the recovery codes of the user that have not been used.
+	ListUnusedByUserID(ctx context.Context, userID string) ([]*twofactor.RecoveryCode, error)
+	Update(context.Context, *twofactor.RecoveryCode) error
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
+type recoveryCodesDatabase struct {
+	db *sqlx.DB
+}
+
+func NewRecoveryCodes(db *sqlx.DB) RecoveryCodesRepository {
+	return &recoveryCodesDatabase{db: db}
+}
+
+func (r *recoveryCodesDatabase) Create(ctx context.Context, code *twofactor.RecoveryCode) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO two_factor_recovery_codes
+			(id, user_id, hash, created_at, used_at)
+		VALUES
+			(:id, :user_id, :hash, :created_at, :used_at)
+	`, code); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (r *recoveryCodesDatabase) ListUnusedByUserID(ctx context.Context, userID string) ([]*twofactor.RecoveryCode, error) {
+	var res []*twofactor.RecoveryCode
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, user_id, hash, created_at, used_at
+		FROM
+			two_factor_recovery_codes
+		WHERE
+			user_id = $1
+			AND used_at IS NULL
+	`, userID); err != nil {
+		return nil, fmt.Errorf("failed to select: %w", err)
+	}
+	return res, nil
+}
+
+func (r *recoveryCodesDatabase) Update(ctx context.Context, code *twofactor.RecoveryCode) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		UPDATE
+			two_factor_recovery_codes
+		SET
+			used_at = :used_at
+		WHERE
+			id = :id
+	`, code); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (r *recoveryCodesDatabase) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		DELETE FROM
+			two_factor_recovery_codes
+		WHERE
+			user_id = $1
+	`, userID); err != nil {
+		return fmt.Errorf("failed to delete: %w", err)
+	}
+	return nil
+}
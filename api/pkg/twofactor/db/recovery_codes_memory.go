@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"sync"
+
+	"getsturdy.com/api/pkg/twofactor"
+)
+
+var _ RecoveryCodesRepository = &recoveryCodesMemory{}
+
+type recoveryCodesMemory struct {
+	mu   sync.Mutex
+	byID map[string]twofactor.RecoveryCode
+}
+
+func NewRecoveryCodesMemory() RecoveryCodesRepository {
+	return &recoveryCodesMemory{byID: make(map[string]twofactor.RecoveryCode)}
+}
+
+func (m *recoveryCodesMemory) Create(_ context.Context, code *twofactor.RecoveryCode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byID[code.ID] = *code
+	return nil
+}
+
+func (m *recoveryCodesMemory) ListUnusedByUserID(_ context.Context, userID string) ([]*twofactor.RecoveryCode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []*twofactor.RecoveryCode
+	for _, code := range m.byID {
+		code := code
+		if code.UserID == userID && code.UsedAt == nil {
+			res = append(res, &code)
+		}
+	}
+	return res, nil
+}
+
+func (m *recoveryCodesMemory) Update(ctx context.Context, code *twofactor.RecoveryCode) error {
+	return m.Create(ctx, code)
+}
+
+func (m *recoveryCodesMemory) DeleteByUserID(_ context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, code := range m.byID {
+		if code.UserID == userID {
+			delete(m.byID, id)
+		}
+	}
+	return nil
+}
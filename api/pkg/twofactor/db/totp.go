@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/twofactor"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TOTPRepository interface {
+	// Set creates or replaces the authenticator of the user.
+	Set(context.Context, *twofactor.TOTP) error
+	GetByUserID(ctx context.Context, userID string) (*twofactor.TOTP, error)
+	Update(context.Context, *twofactor.TOTP) error
+	DeleteByUserID(ctx context.Context, userID string) error
+	// AddAttempt records an attempt to verify a code of the user, and returns the number of attempts in the current
+	// window, including this one. If the current window started before windowStart, a new window is started at now.
+	AddAttempt(ctx context.Context, userID string, windowStart, now time.Time) (int, error)
+	ResetAttempts(ctx context.Context, userID string) error
+}
+
+type totpDatabase struct {
+	db *sqlx.DB
+}
+
+func NewTOTP(db *sqlx.DB) TOTPRepository {
+	return &totpDatabase{db: db}
+}
+
+func (r *totpDatabase) Set(ctx context.Context, totp *twofactor.TOTP) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO two_factor_totp
+			(user_id, secret, created_at, confirmed_at, last_used_step)
+		VALUES
+			(:user_id, :secret, :created_at, :confirmed_at, :last_used_step)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = :secret,
+			created_at = :created_at,
+			confirmed_at = :confirmed_at,
+			last_used_step = :last_used_step
+	`, totp); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (r *totpDatabase) GetByUserID(ctx context.Context, userID string) (*twofactor.TOTP, error) {
+	var res twofactor.TOTP
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			user_id, secret, created_at, confirmed_at, last_used_step
+		FROM
+			two_factor_totp
+		WHERE
+			user_id = $1
+	`, userID); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *totpDatabase) Update(ctx context.Context, totp *twofactor.TOTP) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		UPDATE
+			two_factor_totp
+		SET
+			confirmed_at = :confirmed_at,
+			last_used_step = :last_used_step
+		WHERE
+			user_id = :user_id
+	`, totp); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (r *totpDatabase) DeleteByUserID(ctx context.Context, userID string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		DELETE FROM
+			two_factor_totp
+		WHERE
+			user_id = $1
+	`, userID); err != nil {
+		return fmt.Errorf("failed to delete: %w", err)
+	}
+	return nil
+}
+
+func (r *totpDatabase) AddAttempt(ctx context.Context, userID string, windowStart, now time.Time) (int, error) {
+	var attempts int
+	if err := r.db.GetContext(ctx, &attempts, `
+		UPDATE
+			two_factor_totp
+		SET
+			attempts = CASE WHEN attempts_since >= $2 THEN attempts + 1 ELSE 1 END,
+			attempts_since = CASE WHEN attempts_since >= $2 THEN attempts_since ELSE $3 END
+		WHERE
+			user_id = $1
+		RETURNING
+			attempts
+	`, userID, windowStart, now); err != nil {
+		return 0, fmt.Errorf("failed to update: %w", err)
+	}
+	return attempts, nil
+}
+
+func (r *totpDatabase) ResetAttempts(ctx context.Context, userID string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		UPDATE
+			two_factor_totp
+		SET
+			attempts = 0,
+			attempts_since = NULL
+		WHERE
+			user_id = $1
+	`, userID); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"getsturdy.com/api/pkg/twofactor"
+)
+
+var _ TOTPRepository = &totpMemory{}
+
+type totpMemory struct {
+	mu       sync.Mutex
+	byUserID map[string]twofactor.TOTP
+	attempts map[string]*attempts
+}
+
+type attempts struct {
+	count int
+	since time.Time
+}
+
+func NewTOTPMemory() TOTPRepository {
+	return &totpMemory{
+		byUserID: make(map[string]twofactor.TOTP),
+		attempts: make(map[string]*attempts),
+	}
+}
+
+func (m *totpMemory) Set(_ context.Context, totp *twofactor.TOTP) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.byUserID[totp.UserID] = *totp
+	return nil
+}
+
+func (m *totpMemory) GetByUserID(_ context.Context, userID string) (*twofactor.TOTP, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	totp, ok := m.byUserID[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &totp, nil
+}
+
+func (m *totpMemory) Update(ctx context.Context, totp *twofactor.TOTP) error {
+	return m.Set(ctx, totp)
+}
+
+func (m *totpMemory) DeleteByUserID(_ context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.byUserID, userID)
+	delete(m.attempts, userID)
+	return nil
+}
+
+func (m *totpMemory) AddAttempt(_ context.Context, userID string, windowStart, now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.byUserID[userID]; !ok {
+		return 0, sql.ErrNoRows
+	}
+	a, ok := m.attempts[userID]
+	if !ok || a.since.Before(windowStart) {
+		a = &attempts{since: now}
+		m.attempts[userID] = a
+	}
+	a.count++
+	return a.count, nil
+}
+
+func (m *totpMemory) ResetAttempts(_ context.Context, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.attempts, userID)
+	return nil
+}
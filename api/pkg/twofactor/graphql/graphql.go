@@ -0,0 +1,201 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"getsturdy.com/api/pkg/accesstokens"
+	"getsturdy.com/api/pkg/auth"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/organization"
+	service_organization "getsturdy.com/api/pkg/organization/service"
+	service_twofactor "getsturdy.com/api/pkg/twofactor/service"
+	service_user "getsturdy.com/api/pkg/users/service"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type rootResolver struct {
+	twoFactorService    *service_twofactor.Service
+	userService         service_user.Service
+	organizationService *service_organization.Service
+
+	userRootResolver         *resolvers.UserRootResolver
+	organizationRootResolver *resolvers.OrganizationRootResolver
+}
+
+func New(
+	twoFactorService *service_twofactor.Service,
+	userService service_user.Service,
+	organizationService *service_organization.Service,
+
+	userRootResolver *resolvers.UserRootResolver,
+	organizationRootResolver *resolvers.OrganizationRootResolver,
+) resolvers.TwoFactorRootResolver {
+	return &rootResolver{
+		twoFactorService:    twoFactorService,
+		userService:         userService,
+		organizationService: organizationService,
+
+		userRootResolver:         userRootResolver,
+		organizationRootResolver: organizationRootResolver,
+	}
+}
+
+// authenticatedUserID returns the ID of the authenticated user. Two-factor authentication can only be managed with a
+// session, or with a personal access token that has the admin scope.
+func authenticatedUserID(ctx context.Context) (string, error) {
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := auth.RequireScope(ctx, accesstokens.ScopeAdmin); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+func (r *rootResolver) InternalIsEnabled(ctx context.Context, userID string) (bool, error) {
+	enabled, err := r.twoFactorService.IsEnabled(ctx, userID)
+	if err != nil {
+		return false, gqlerrors.Error(err)
+	}
+	return enabled, nil
+}
+
+func (r *rootResolver) EnrollTwoFactor(ctx context.Context) (resolvers.TwoFactorEnrollmentResolver, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	user, err := r.userService.GetByID(ctx, userID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	secret, url, err := r.twoFactorService.Enroll(ctx, user)
+	if err != nil {
+		return nil, codeError(err)
+	}
+
+	return &enrollmentResolver{secret: secret, url: url}, nil
+}
+
+func (r *rootResolver) ConfirmTwoFactor(ctx context.Context, args resolvers.ConfirmTwoFactorArgs) (resolvers.TwoFactorRecoveryCodesResolver, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	codes, err := r.twoFactorService.Confirm(ctx, userID, args.Input.Code)
+	if err != nil {
+		return nil, codeError(err)
+	}
+
+	return &recoveryCodesResolver{codes: codes}, nil
+}
+
+func (r *rootResolver) DisableTwoFactor(ctx context.Context, args resolvers.DisableTwoFactorArgs) (resolvers.UserResolver, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.twoFactorService.Disable(ctx, userID, args.Input.Code); err != nil {
+		return nil, codeError(err)
+	}
+
+	return (*r.userRootResolver).InternalUser(ctx, userID)
+}
+
+func (r *rootResolver) RegenerateTwoFactorRecoveryCodes(ctx context.Context, args resolvers.RegenerateTwoFactorRecoveryCodesArgs) (resolvers.TwoFactorRecoveryCodesResolver, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	codes, err := r.twoFactorService.RegenerateRecoveryCodes(ctx, userID, args.Input.Code)
+	if err != nil {
+		return nil, codeError(err)
+	}
+
+	return &recoveryCodesResolver{codes: codes}, nil
+}
+
+// UpdateOrganizationTwoFactorRequirement sets if all members of the organization must have two-factor authentication
+// enabled. Only owners can change it, and they must have it enabled themselves, so they don't lock themselves out.
+func (r *rootResolver) UpdateOrganizationTwoFactorRequirement(ctx context.Context, args resolvers.UpdateOrganizationTwoFactorRequirementArgs) (resolvers.OrganizationResolver, error) {
+	userID, err := authenticatedUserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	org, err := r.organizationService.GetByID(ctx, string(args.Input.OrganizationID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	member, err := r.organizationService.GetMember(ctx, org.ID, userID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	if member.Role != organization.RoleOwner {
+		return nil, gqlerrors.Error(fmt.Errorf("only owners can require two-factor authentication: %w", auth.ErrForbidden))
+	}
+
+	if args.Input.Required {
+		enabled, err := r.twoFactorService.IsEnabled(ctx, userID)
+		if err != nil {
+			return nil, gqlerrors.Error(err)
+		}
+		if !enabled {
+			return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "required", "enable two-factor authentication for your own account first")
+		}
+	}
+
+	if err := r.organizationService.SetRequireTwoFactor(ctx, org, args.Input.Required); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	id := graphql.ID(org.ID)
+	return (*r.organizationRootResolver).Organization(ctx, resolvers.OrganizationArgs{ID: &id})
+}
+
+// codeError converts errors from the two-factor service to graphql errors.
+func codeError(err error) error {
+	switch {
+	case errors.Is(err, service_twofactor.ErrInvalidCode),
+		errors.Is(err, service_twofactor.ErrTooManyAttempts):
+		return gqlerrors.Error(gqlerrors.ErrBadRequest, "code", err.Error())
+	case errors.Is(err, service_twofactor.ErrAlreadyEnabled),
+		errors.Is(err, service_twofactor.ErrNotEnabled),
+		errors.Is(err, service_twofactor.ErrRequired):
+		return gqlerrors.Error(gqlerrors.ErrBadRequest, "message", err.Error())
+	default:
+		return gqlerrors.Error(err)
+	}
+}
+
+type enrollmentResolver struct {
+	secret string
+	url    string
+}
+
+func (r *enrollmentResolver) Secret() string {
+	return r.secret
+}
+
+func (r *enrollmentResolver) URL() string {
+	return r.url
+}
+
+type recoveryCodesResolver struct {
+	codes []string
+}
+
+func (r *recoveryCodesResolver) Codes() []string {
+	return r.codes
+}
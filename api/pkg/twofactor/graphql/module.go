@@ -0,0 +1,9 @@
+package graphql
+
+import (
+	"getsturdy.com/api/pkg/di"
+)
+
+func Module(c *di.Container) {
+	c.Register(New)
+}
@@ -0,0 +1,14 @@
+package module
+
+import (
+	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/twofactor/db"
+	"getsturdy.com/api/pkg/twofactor/graphql"
+	"getsturdy.com/api/pkg/twofactor/service"
+)
+
+func Module(c *di.Container) {
+	c.Import(db.Module)
+	c.Import(graphql.Module)
+	c.Import(service.Module)
+}
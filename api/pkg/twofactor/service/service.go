@@ -0,0 +1,368 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"getsturdy.com/api/pkg/jwt"
+	service_organization "getsturdy.com/api/pkg/organization/service"
+	service_sessions "getsturdy.com/api/pkg/sessions/service"
+	"getsturdy.com/api/pkg/twofactor"
+	db_twofactor "getsturdy.com/api/pkg/twofactor/db"
+	"getsturdy.com/api/pkg/twofactor/totp"
+	"getsturdy.com/api/pkg/users"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrNotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrInvalidCode     = errors.New("invalid code")
+	ErrTooManyAttempts = errors.New("too many attempts, try again later")
+	ErrChallengeUsed   = errors.New("two-factor challenge has already been used")
+	// ErrRequired is returned when a user tries to disable two-factor authentication while being a member of an
+	// organization that requires it.
+	ErrRequired = errors.New("two-factor authentication is required by an organization")
+)
+
+const (
+	issuer = "Sturdy"
+
+	recoveryCodesCount = 10
+
+	// maxAttempts is the number of attempts to verify a code that are allowed per user within attemptsWindow. A
+	// successful attempt starts over the count.
+	maxAttempts    = 5
+	attemptsWindow = 5 * time.Minute
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type Service struct {
+	logger              *zap.Logger
+	totpRepo            db_twofactor.TOTPRepository
+	recoveryCodesRepo   db_twofactor.RecoveryCodesRepository
+	challengesRepo      db_twofactor.ChallengesRepository
+	organizationService *service_organization.Service
+	sessionsService     *service_sessions.Service
+}
+
+func New(
+	logger *zap.Logger,
+	totpRepo db_twofactor.TOTPRepository,
+	recoveryCodesRepo db_twofactor.RecoveryCodesRepository,
+	challengesRepo db_twofactor.ChallengesRepository,
+	organizationService *service_organization.Service,
+	sessionsService *service_sessions.Service,
+) *Service {
+	return &Service{
+		logger:              logger.Named("twofactor"),
+		totpRepo:            totpRepo,
+		recoveryCodesRepo:   recoveryCodesRepo,
+		challengesRepo:      challengesRepo,
+		organizationService: organizationService,
+		sessionsService:     sessionsService,
+	}
+}
+
+// Enroll starts the enrollment of an authenticator app for the user. Two-factor authentication is not enabled
+// until the enrollment is confirmed with a code from the app. It returns the secret, and an otpauth:// URL with the
+// secret that can be shown as a QR code.
+func (s *Service) Enroll(ctx context.Context, user *users.User) (string, string, error) {
+	enabled, err := s.IsEnabled(ctx, user.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if enabled {
+		return "", "", ErrAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	if err := s.totpRepo.Set(ctx, &twofactor.TOTP{
+		UserID:    user.ID,
+		Secret:    secret,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		return "", "", fmt.Errorf("failed to set totp: %w", err)
+	}
+
+	return secret, totp.URL(issuer, user.Email, secret), nil
+}
+
+// Confirm enables two-factor authentication for the user, if the code is valid for the enrolled authenticator.
+// It returns the recovery codes of the user in plaintext, they are not stored.
+func (s *Service) Confirm(ctx context.Context, userID, code string) ([]string, error) {
+	t, err := s.totpRepo.GetByUserID(ctx, userID)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrNotEnabled
+	default:
+		return nil, fmt.Errorf("failed to get totp: %w", err)
+	}
+	if t.IsConfirmed() {
+		return nil, ErrAlreadyEnabled
+	}
+
+	if err := s.verifyTOTP(ctx, t, code); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	t.ConfirmedAt = &now
+	if err := s.totpRepo.Update(ctx, t); err != nil {
+		return nil, fmt.Errorf("failed to update totp: %w", err)
+	}
+
+	return s.generateRecoveryCodes(ctx, userID)
+}
+
+// Disable disables two-factor authentication for the user. The user must provide a valid code to disable it.
+func (s *Service) Disable(ctx context.Context, userID, code string) error {
+	required, err := s.IsRequired(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrRequired
+	}
+
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+
+	if err := s.totpRepo.DeleteByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete totp: %w", err)
+	}
+	if err := s.recoveryCodesRepo.DeleteByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes of the user with new ones. The user must provide a valid code
+// to regenerate them.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	if err := s.recoveryCodesRepo.DeleteByUserID(ctx, userID); err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+	return s.generateRecoveryCodes(ctx, userID)
+}
+
+// IsEnabled returns true if the user has confirmed an authenticator app.
+func (s *Service) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	t, err := s.totpRepo.GetByUserID(ctx, userID)
+	switch {
+	case err == nil:
+		return t.IsConfirmed(), nil
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to get totp: %w", err)
+	}
+}
+
+// IsVerified returns true if the user has enabled two-factor authentication, and has entered their second factor in
+// the session. Users that are not authenticated with a session, like with a personal access token, only need to have
+// it enabled.
+func (s *Service) IsVerified(ctx context.Context, userID, sessionID string) (bool, error) {
+	enabled, err := s.IsEnabled(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !enabled || sessionID == "" {
+		return enabled, nil
+	}
+	verified, err := s.sessionsService.IsTwoFactorVerified(ctx, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to check session: %w", err)
+	}
+	return verified, nil
+}
+
+// IsRequired returns true if the user is a member of an organization that requires two-factor authentication.
+func (s *Service) IsRequired(ctx context.Context, userID string) (bool, error) {
+	orgs, err := s.organizationService.ListByUserID(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	for _, org := range orgs {
+		if org.RequireTwoFactor {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Verify returns nil if code is either a valid code from the user's authenticator app, or one of the user's unused
+// recovery codes. Each code can only be used once.
+func (s *Service) Verify(ctx context.Context, userID, code string) error {
+	t, err := s.totpRepo.GetByUserID(ctx, userID)
+	switch {
+	case err == nil:
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotEnabled
+	default:
+		return fmt.Errorf("failed to get totp: %w", err)
+	}
+	if !t.IsConfirmed() {
+		return ErrNotEnabled
+	}
+
+	if isTOTPCode(code) {
+		return s.verifyTOTP(ctx, t, code)
+	}
+	return s.verifyRecoveryCode(ctx, userID, code)
+}
+
+// VerifyChallenge returns nil if code is valid for the user that is signing in with the two-factor challenge. Each
+// challenge can only be used to sign in once.
+func (s *Service) VerifyChallenge(ctx context.Context, challenge *jwt.Token, code string) error {
+	if challenge.Type != jwt.TokenTypeTwoFactor {
+		return fmt.Errorf("unexpected token type: %s", challenge.Type)
+	}
+
+	if err := s.Verify(ctx, challenge.Subject, code); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := s.challengesRepo.DeleteExpired(ctx, now); err != nil {
+		return fmt.Errorf("failed to delete expired challenges: %w", err)
+	}
+	used, err := s.challengesRepo.Use(ctx, challenge.ID, challenge.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to use challenge: %w", err)
+	}
+	if !used {
+		return ErrChallengeUsed
+	}
+	return nil
+}
+
+func (s *Service) verifyTOTP(ctx context.Context, t *twofactor.TOTP, code string) error {
+	if err := s.attempt(ctx, t.UserID); err != nil {
+		return err
+	}
+
+	step, ok := totp.Validate(t.Secret, code, time.Now())
+	if !ok || step <= t.LastUsedStep {
+		s.logger.Info("failed two-factor attempt", zap.String("user_id", t.UserID))
+		return ErrInvalidCode
+	}
+
+	t.LastUsedStep = step
+	if err := s.totpRepo.Update(ctx, t); err != nil {
+		return fmt.Errorf("failed to update totp: %w", err)
+	}
+	return s.resetAttempts(ctx, t.UserID)
+}
+
+func (s *Service) verifyRecoveryCode(ctx context.Context, userID, code string) error {
+	if err := s.attempt(ctx, userID); err != nil {
+		return err
+	}
+
+	codes, err := s.recoveryCodesRepo.ListUnusedByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to list recovery codes: %w", err)
+	}
+
+	normalized := normalizeRecoveryCode(code)
+	for _, c := range codes {
+		if err := c.Verify(normalized); err != nil {
+			continue
+		}
+		now := time.Now()
+		c.UsedAt = &now
+		if err := s.recoveryCodesRepo.Update(ctx, c); err != nil {
+			return fmt.Errorf("failed to update recovery code: %w", err)
+		}
+		return s.resetAttempts(ctx, userID)
+	}
+
+	s.logger.Info("failed two-factor attempt", zap.String("user_id", userID))
+	return ErrInvalidCode
+}
+
+func (s *Service) generateRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	now := time.Now()
+	res := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		raw := make([]byte, 5)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		// 5 random bytes are 8 base32 characters, formatted as xxxx-xxxx
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash recovery code: %w", err)
+		}
+		if err := s.recoveryCodesRepo.Create(ctx, &twofactor.RecoveryCode{
+			ID:        uuid.NewString(),
+			UserID:    userID,
+			Hash:      hash,
+			CreatedAt: now,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to create recovery code: %w", err)
+		}
+		res = append(res, code[:4]+"-"+code[4:])
+	}
+	return res, nil
+}
+
+// attempt records an attempt to verify a code of the user, and returns ErrTooManyAttempts if the user has made too many
+// attempts recently. Attempts are recorded before the code is checked, so that concurrent attempts are limited too.
+func (s *Service) attempt(ctx context.Context, userID string) error {
+	now := time.Now()
+	attempts, err := s.totpRepo.AddAttempt(ctx, userID, now.Add(-attemptsWindow), now)
+	if err != nil {
+		return fmt.Errorf("failed to add attempt: %w", err)
+	}
+	if attempts > maxAttempts {
+		return ErrTooManyAttempts
+	}
+	return nil
+}
+
+func (s *Service) resetAttempts(ctx context.Context, userID string) error {
+	if err := s.totpRepo.ResetAttempts(ctx, userID); err != nil {
+		return fmt.Errorf("failed to reset attempts: %w", err)
+	}
+	return nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// isTOTPCode returns true if code looks like a code from an authenticator app, rather than a recovery code.
+func isTOTPCode(code string) bool {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != 6 {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,217 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/internal/inmemory"
+	"getsturdy.com/api/pkg/jwt"
+	"getsturdy.com/api/pkg/organization"
+	db_organization "getsturdy.com/api/pkg/organization/db"
+	service_organization "getsturdy.com/api/pkg/organization/service"
+	db_sessions "getsturdy.com/api/pkg/sessions/db"
+	service_sessions "getsturdy.com/api/pkg/sessions/service"
+	db_twofactor "getsturdy.com/api/pkg/twofactor/db"
+	"getsturdy.com/api/pkg/twofactor/service"
+	"getsturdy.com/api/pkg/twofactor/totp"
+	"getsturdy.com/api/pkg/users"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type testService struct {
+	*service.Service
+
+	totpRepo          db_twofactor.TOTPRepository
+	recoveryCodesRepo db_twofactor.RecoveryCodesRepository
+	challengesRepo    db_twofactor.ChallengesRepository
+	organizationRepo  db_organization.Repository
+	memberRepo        db_organization.MemberRepository
+	sessionsService   *service_sessions.Service
+}
+
+func newService() *testService {
+	totpRepo := db_twofactor.NewTOTPMemory()
+	recoveryCodesRepo := db_twofactor.NewRecoveryCodesMemory()
+	challengesRepo := db_twofactor.NewChallengesMemory()
+	organizationRepo := inmemory.NewInMemoryOrganizationRepo()
+	memberRepo := inmemory.NewInMemoryOrganizationMemberRepository()
+	organizationService := service_organization.New(organizationRepo, memberRepo, nil)
+	sessionsService := service_sessions.New(db_sessions.NewMemory())
+	return &testService{
+		Service:           service.New(zap.NewNop(), totpRepo, recoveryCodesRepo, challengesRepo, organizationService, sessionsService),
+		totpRepo:          totpRepo,
+		recoveryCodesRepo: recoveryCodesRepo,
+		challengesRepo:    challengesRepo,
+		organizationRepo:  organizationRepo,
+		memberRepo:        memberRepo,
+		sessionsService:   sessionsService,
+	}
+}
+
+// enable enables two-factor authentication for the user, and returns the secret and the recovery codes.
+func (s *testService) enable(t *testing.T, user *users.User) (string, []string) {
+	ctx := context.Background()
+
+	secret, url, err := s.Enroll(ctx, user)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(url, "otpauth://totp/"))
+
+	enabled, err := s.IsEnabled(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.False(t, enabled, "not enabled until confirmed")
+
+	recoveryCodes, err := s.Confirm(ctx, user.ID, code(t, secret, time.Now()))
+	assert.NoError(t, err)
+	assert.Len(t, recoveryCodes, 10)
+
+	enabled, err = s.IsEnabled(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+
+	return secret, recoveryCodes
+}
+
+func code(t *testing.T, secret string, at time.Time) string {
+	code, err := totp.Code(secret, totp.Step(at))
+	assert.NoError(t, err)
+	return code
+}
+
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	svc := newService()
+	user := &users.User{ID: "user-id", Email: "user@example.com"}
+
+	assert.ErrorIs(t, svc.Verify(ctx, user.ID, "123456"), service.ErrNotEnabled)
+
+	secret, _ := svc.enable(t, user)
+
+	// the code used to confirm can not be used again
+	assert.ErrorIs(t, svc.Verify(ctx, user.ID, code(t, secret, time.Now())), service.ErrInvalidCode)
+
+	next := code(t, secret, time.Now().Add(30*time.Second))
+	assert.NoError(t, svc.Verify(ctx, user.ID, next))
+	assert.ErrorIs(t, svc.Verify(ctx, user.ID, next), service.ErrInvalidCode)
+}
+
+func TestVerify_recoveryCode(t *testing.T) {
+	ctx := context.Background()
+	svc := newService()
+	user := &users.User{ID: "user-id", Email: "user@example.com"}
+
+	_, recoveryCodes := svc.enable(t, user)
+
+	assert.NoError(t, svc.Verify(ctx, user.ID, strings.ToUpper(recoveryCodes[0])))
+	assert.ErrorIs(t, svc.Verify(ctx, user.ID, recoveryCodes[0]), service.ErrInvalidCode, "recovery codes are single use")
+
+	assert.NoError(t, svc.Verify(ctx, user.ID, strings.ReplaceAll(recoveryCodes[1], "-", "")))
+}
+
+func TestVerify_tooManyAttempts(t *testing.T) {
+	ctx := context.Background()
+	svc := newService()
+	user := &users.User{ID: "user-id", Email: "user@example.com"}
+
+	secret, _ := svc.enable(t, user)
+
+	for i := 0; i < 5; i++ {
+		assert.ErrorIs(t, svc.Verify(ctx, user.ID, "000000"), service.ErrInvalidCode)
+	}
+	next := code(t, secret, time.Now().Add(30*time.Second))
+	assert.ErrorIs(t, svc.Verify(ctx, user.ID, next), service.ErrTooManyAttempts)
+
+	// the attempts are shared by all instances of the service
+	other := service.New(zap.NewNop(), svc.totpRepo, svc.recoveryCodesRepo, svc.challengesRepo, nil, svc.sessionsService)
+	assert.ErrorIs(t, other.Verify(ctx, user.ID, next), service.ErrTooManyAttempts)
+}
+
+func TestVerify_successResetsAttempts(t *testing.T) {
+	ctx := context.Background()
+	svc := newService()
+	user := &users.User{ID: "user-id", Email: "user@example.com"}
+
+	secret, _ := svc.enable(t, user)
+
+	for i := 0; i < 4; i++ {
+		assert.ErrorIs(t, svc.Verify(ctx, user.ID, "000000"), service.ErrInvalidCode)
+	}
+	assert.NoError(t, svc.Verify(ctx, user.ID, code(t, secret, time.Now().Add(30*time.Second))))
+	assert.ErrorIs(t, svc.Verify(ctx, user.ID, "000000"), service.ErrInvalidCode)
+}
+
+func TestVerifyChallenge(t *testing.T) {
+	ctx := context.Background()
+	svc := newService()
+	user := &users.User{ID: "user-id", Email: "user@example.com"}
+
+	_, recoveryCodes := svc.enable(t, user)
+
+	challenge := &jwt.Token{ID: "challenge-id", Type: jwt.TokenTypeTwoFactor, Subject: user.ID, ExpiresAt: time.Now().Add(time.Minute)}
+	assert.ErrorIs(t, svc.VerifyChallenge(ctx, challenge, "000000"), service.ErrInvalidCode)
+	assert.NoError(t, svc.VerifyChallenge(ctx, challenge, recoveryCodes[0]), "the challenge can be retried until it's used")
+	assert.ErrorIs(t, svc.VerifyChallenge(ctx, challenge, recoveryCodes[1]), service.ErrChallengeUsed)
+}
+
+func TestIsVerified(t *testing.T) {
+	ctx := context.Background()
+	svc := newService()
+	user := &users.User{ID: "user-id", Email: "user@example.com"}
+
+	assert.NoError(t, svc.sessionsService.Issued(ctx, "session-id", user.ID, time.Now().Add(time.Hour)))
+
+	verified, err := svc.IsVerified(ctx, user.ID, "session-id")
+	assert.NoError(t, err)
+	assert.False(t, verified, "not enabled")
+
+	svc.enable(t, user)
+
+	verified, err = svc.IsVerified(ctx, user.ID, "session-id")
+	assert.NoError(t, err)
+	assert.False(t, verified, "the second factor was not entered in the session")
+
+	verified, err = svc.IsVerified(ctx, user.ID, "")
+	assert.NoError(t, err)
+	assert.True(t, verified, "not authenticated with a session")
+
+	assert.NoError(t, svc.sessionsService.TwoFactorVerified(ctx, "session-id"))
+
+	verified, err = svc.IsVerified(ctx, user.ID, "session-id")
+	assert.NoError(t, err)
+	assert.True(t, verified)
+}
+
+func TestDisable(t *testing.T) {
+	ctx := context.Background()
+	svc := newService()
+	user := &users.User{ID: "user-id", Email: "user@example.com"}
+
+	secret, _ := svc.enable(t, user)
+
+	assert.NoError(t, svc.organizationRepo.Create(ctx, organization.Organization{ID: "org-id", RequireTwoFactor: true}))
+	assert.NoError(t, svc.memberRepo.Create(ctx, organization.Member{ID: "member-id", OrganizationID: "org-id", UserID: user.ID, Role: organization.RoleMember}))
+
+	required, err := svc.IsRequired(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.True(t, required)
+
+	assert.ErrorIs(t, svc.Disable(ctx, user.ID, code(t, secret, time.Now().Add(30*time.Second))), service.ErrRequired)
+}
+
+func TestDisable_notRequired(t *testing.T) {
+	ctx := context.Background()
+	svc := newService()
+	user := &users.User{ID: "user-id", Email: "user@example.com"}
+
+	secret, _ := svc.enable(t, user)
+
+	assert.ErrorIs(t, svc.Disable(ctx, user.ID, "000000"), service.ErrInvalidCode)
+	assert.NoError(t, svc.Disable(ctx, user.ID, code(t, secret, time.Now().Add(30*time.Second))))
+
+	enabled, err := svc.IsEnabled(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+}
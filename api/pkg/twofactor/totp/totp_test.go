@@ -0,0 +1,57 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/twofactor/totp"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The test vectors from RFC 6238, truncated to 6 digits.
+func TestCode(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+
+	for unix, expected := range cases {
+		code, err := totp.Code(secret, totp.Step(time.Unix(unix, 0)))
+		if assert.NoError(t, err) {
+			assert.Equal(t, expected, code, unix)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	code, err := totp.Code(secret, totp.Step(now))
+	assert.NoError(t, err)
+
+	step, ok := totp.Validate(secret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, totp.Step(now), step)
+
+	// codes are accepted for one period before and after
+	_, ok = totp.Validate(secret, code, now.Add(30*time.Second))
+	assert.True(t, ok)
+	_, ok = totp.Validate(secret, code, now.Add(-30*time.Second))
+	assert.True(t, ok)
+
+	_, ok = totp.Validate(secret, code, now.Add(2*time.Minute))
+	assert.False(t, ok)
+
+	_, ok = totp.Validate(secret, "12345", now)
+	assert.False(t, ok)
+}
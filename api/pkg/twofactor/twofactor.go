@@ -0,0 +1,35 @@
+package twofactor
+
+import (
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TOTP is an authenticator app that a user has enrolled as their second factor. It's enabled once the user has
+// confirmed it with a code.
+type TOTP struct {
+	UserID      string     `db:"user_id"`
+	Secret      string     `db:"secret"`
+	CreatedAt   time.Time  `db:"created_at"`
+	ConfirmedAt *time.Time `db:"confirmed_at"`
+	// LastUsedStep is the time step of the last accepted code, codes can not be used more than once
+	LastUsedStep int64 `db:"last_used_step"`
+}
+
+func (t *TOTP) IsConfirmed() bool {
+	return t.ConfirmedAt != nil
+}
+
+// RecoveryCode is a single use code that a user can sign in with instead of a code from their authenticator.
+type RecoveryCode struct {
+	ID        string     `db:"id"`
+	UserID    string     `db:"user_id"`
+	Hash      []byte     `db:"hash"`
+	CreatedAt time.Time  `db:"created_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
+
+func (c *RecoveryCode) Verify(code string) error {
+	return bcrypt.CompareHashAndPassword(c.Hash, []byte(code))
+}
@@ -38,6 +38,18 @@ type FileDiff struct {
 	Hunks []Hunk `json:"hunks"`
 }
 
+// ContentHash returns a hash of the changes to the file, it changes if any of the hunks of the file change.
+func (f FileDiff) ContentHash() string {
+	h := sha256.New()
+	for _, hunk := range f.Hunks {
+		h.Write([]byte(hunk.Patch))
+	}
+	if f.LargeFileInfo != nil {
+		fmt.Fprintf(h, "large:%d", f.LargeFileInfo.Size)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
 type LargeFileInfo struct {
 	Size uint64 `json:"size"`
 }
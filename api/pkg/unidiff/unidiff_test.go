@@ -636,3 +636,12 @@ Binary files a/app/assets/bin/sturdy and b/app/assets/bin/sturdy differ
 		})
 	}
 }
+
+func TestContentHash(t *testing.T) {
+	diff := FileDiff{PreferredName: "a.txt", Hunks: []Hunk{NewHunk("@@ -1 +1 @@\n-a\n+b\n")}}
+	same := FileDiff{PreferredName: "a.txt", Hunks: []Hunk{NewHunk("@@ -1 +1 @@\n-a\n+b\n")}}
+	changed := FileDiff{PreferredName: "a.txt", Hunks: []Hunk{NewHunk("@@ -1 +1 @@\n-a\n+c\n")}}
+
+	assert.Equal(t, diff.ContentHash(), same.ContentHash())
+	assert.NotEqual(t, diff.ContentHash(), changed.ContentHash())
+}
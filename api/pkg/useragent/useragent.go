@@ -0,0 +1,18 @@
+package useragent
+
+import (
+	"context"
+)
+
+type userAgentKeyType struct{}
+
+var userAgentKey = userAgentKeyType{}
+
+func NewContext(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, userAgentKey, userAgent)
+}
+
+func FromContext(ctx context.Context) (string, bool) {
+	s, ok := ctx.Value(userAgentKey).(string)
+	return s, ok
+}
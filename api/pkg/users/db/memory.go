@@ -2,6 +2,8 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"strings"
 
 	"getsturdy.com/api/pkg/users"
 )
@@ -27,6 +29,12 @@ func (f *inMemoryUserRepo) Create(newUser *users.User) error {
 }
 
 func (f *inMemoryUserRepo) Get(id string) (*users.User, error) {
+	for _, u := range f.users {
+		if u.ID == id {
+			cp := *u
+			return &cp, nil
+		}
+	}
 	return &users.User{
 		ID:    id,
 		Name:  "Test Testsson",
@@ -39,11 +47,23 @@ func (f *inMemoryUserRepo) GetByIDs(_ context.Context, ids ...string) ([]*users.
 }
 
 func (f *inMemoryUserRepo) GetByEmail(email string) (*users.User, error) {
-	panic("not implemented")
+	for _, u := range f.users {
+		if strings.EqualFold(u.Email, email) {
+			return u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
 }
 
 func (f *inMemoryUserRepo) Update(u *users.User) error {
-	panic("not implemented")
+	for i, existing := range f.users {
+		if existing.ID == u.ID {
+			cp := *u
+			f.users[i] = &cp
+			return nil
+		}
+	}
+	return sql.ErrNoRows
 }
 
 func (f *inMemoryUserRepo) UpdatePassword(u *users.User) error {
@@ -3,6 +3,7 @@ package graphql
 import (
 	"context"
 
+	"getsturdy.com/api/pkg/accesstokens"
 	"getsturdy.com/api/pkg/auth"
 	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
 	"getsturdy.com/api/pkg/graphql/resolvers"
@@ -31,6 +32,10 @@ func (r *userRootResolver) VerifyEmail(ctx context.Context, args resolvers.Verif
 	if err != nil {
 		return nil, gqlerrors.Error(err)
 	}
+
+	if err := auth.RequireScope(ctx, accesstokens.ScopeAdmin); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
 	user, err := r.userService.VerifyEmail(ctx, userID, args.Input.Token)
 	if err != nil {
 		return nil, gqlerrors.Error(err)
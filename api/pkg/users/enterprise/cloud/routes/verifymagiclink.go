@@ -3,17 +3,23 @@ package routes
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"getsturdy.com/api/pkg/auth"
+	"getsturdy.com/api/pkg/jwt"
 	service_jwt "getsturdy.com/api/pkg/jwt/service"
 	"getsturdy.com/api/pkg/onetime/service"
+	service_twofactor "getsturdy.com/api/pkg/twofactor/service"
 	service_user "getsturdy.com/api/pkg/users/enterprise/cloud/service"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
-func VerifyMagicLink(logger *zap.Logger, userService *service_user.Service, jwtService *service_jwt.Service) gin.HandlerFunc {
+// twoFactorTokenValidFor is how long users have to enter their second factor after signing in with a magic link.
+const twoFactorTokenValidFor = 5 * time.Minute
+
+func VerifyMagicLink(logger *zap.Logger, userService *service_user.Service, jwtService *service_jwt.Service, twoFactorService *service_twofactor.Service) gin.HandlerFunc {
 	type request struct {
 		Code  string `json:"code" binding:"required"`
 		Email string `json:"email" binding:"required"`
@@ -46,6 +52,28 @@ func VerifyMagicLink(logger *zap.Logger, userService *service_user.Service, jwtS
 			return
 		}
 
+		// users with two-factor authentication complete the sign in with their second factor, see
+		// routes.LoginTwoFactor
+		twoFactorEnabled, err := twoFactorService.IsEnabled(c.Request.Context(), user.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
+			logger.Error("failed to check two-factor authentication", zap.Error(err))
+			return
+		}
+		if twoFactorEnabled {
+			token, err := jwtService.IssueToken(c.Request.Context(), user.ID, twoFactorTokenValidFor, jwt.TokenTypeTwoFactor)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "something went wrong"})
+				logger.Error("failed to issue two-factor token", zap.Error(err))
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"two_factor_required": true,
+				"two_factor_token":    token.Token,
+			})
+			return
+		}
+
 		auth.SetAuthCookieForUser(c, user.ID, jwtService)
 	}
 }
@@ -62,3 +62,10 @@ func (s *Service) CreateWithPassword(ctx context.Context, name, password, email
 
 	return usr, nil
 }
+
+func (s *Service) CreateWithVerifiedEmail(ctx context.Context, name, email string) (*users.User, error) {
+	if err := s.ValidateUserCount(ctx); err != nil {
+		return nil, err
+	}
+	return s.Service.CreateWithVerifiedEmail(ctx, name, email)
+}
@@ -5,12 +5,15 @@ import (
 	"database/sql"
 	"errors"
 
+	"getsturdy.com/api/pkg/accesstokens"
 	service_analytics "getsturdy.com/api/pkg/analytics/service"
 	"getsturdy.com/api/pkg/auth"
 	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
 	"getsturdy.com/api/pkg/graphql/resolvers"
 	"getsturdy.com/api/pkg/newsletter"
 	db_newsletter "getsturdy.com/api/pkg/newsletter/db"
+	"getsturdy.com/api/pkg/notification/digest"
+	service_digest "getsturdy.com/api/pkg/notification/digest/service"
 	"getsturdy.com/api/pkg/users"
 	db_user "getsturdy.com/api/pkg/users/db"
 	service_user "getsturdy.com/api/pkg/users/service"
@@ -20,15 +23,32 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	digestFrequencyToGraphQL = map[digest.Frequency]string{
+		digest.FrequencyImmediate: "Immediate",
+		digest.FrequencyHourly:    "Hourly",
+		digest.FrequencyDaily:     "Daily",
+	}
+	digestFrequencyFromGraphQL = map[string]digest.Frequency{
+		"Immediate": digest.FrequencyImmediate,
+		"Hourly":    digest.FrequencyHourly,
+		"Daily":     digest.FrequencyDaily,
+	}
+)
+
 type userRootResolver struct {
 	userRepo                 db_user.Repository
 	notificationSettingsRepo db_newsletter.NotificationSettingsRepository
 
-	userService service_user.Service
+	userService   service_user.Service
+	digestService *service_digest.Service
 
 	viewRootResolver          resolvers.ViewRootResolver
 	notificationRootResolver  resolvers.NotificationRootResolver
 	githubAccountRootResolver resolvers.GitHubAccountRootResolver
+	chatWebhooksRootResolver  resolvers.ChatWebhooksRootResolver
+	sessionsRootResolver      resolvers.SessionsRootResolver
+	twoFactorRootResolver     resolvers.TwoFactorRootResolver
 	analyticsServcie          *service_analytics.Service
 }
 
@@ -37,10 +57,14 @@ func NewResolver(
 	notificationSettingsRepo db_newsletter.NotificationSettingsRepository,
 
 	userService service_user.Service,
+	digestService *service_digest.Service,
 
 	viewRootResolver resolvers.ViewRootResolver,
 	notificationRootResolver resolvers.NotificationRootResolver,
 	githubAccountRootResolver resolvers.GitHubAccountRootResolver,
+	chatWebhooksRootResolver resolvers.ChatWebhooksRootResolver,
+	sessionsRootResolver resolvers.SessionsRootResolver,
+	twoFactorRootResolver resolvers.TwoFactorRootResolver,
 
 	logger *zap.Logger,
 	analyticsServcie *service_analytics.Service,
@@ -49,11 +73,15 @@ func NewResolver(
 		userRepo:                 userRepo,
 		notificationSettingsRepo: notificationSettingsRepo,
 
-		userService: userService,
+		userService:   userService,
+		digestService: digestService,
 
 		viewRootResolver:          viewRootResolver,
 		notificationRootResolver:  notificationRootResolver,
 		githubAccountRootResolver: githubAccountRootResolver,
+		chatWebhooksRootResolver:  chatWebhooksRootResolver,
+		sessionsRootResolver:      sessionsRootResolver,
+		twoFactorRootResolver:     twoFactorRootResolver,
 		analyticsServcie:          analyticsServcie,
 	}, logger)
 }
@@ -72,6 +100,10 @@ func (r *userRootResolver) UpdateUser(ctx context.Context, args resolvers.Update
 		return nil, gqlerrors.Error(err)
 	}
 
+	if err := auth.RequireScope(ctx, accesstokens.ScopeAdmin); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
 	user, err := r.userRepo.Get(userID)
 	if err != nil {
 		return nil, gqlerrors.Error(err)
@@ -107,6 +139,16 @@ func (r *userRootResolver) UpdateUser(ctx context.Context, args resolvers.Update
 		}
 	}
 
+	if args.Input.NotificationsDigestFrequency != nil {
+		frequency, ok := digestFrequencyFromGraphQL[*args.Input.NotificationsDigestFrequency]
+		if !ok {
+			return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "unknown digest frequency")
+		}
+		if _, err := r.digestService.SetFrequency(ctx, user.ID, frequency); err != nil {
+			return nil, gqlerrors.Error(err)
+		}
+	}
+
 	// Update notification settings
 	if args.Input.NotificationsReceiveNewsletter != nil {
 		// Get existing settings
@@ -170,6 +212,18 @@ func (r *userResolver) NotificationPreferences(ctx context.Context) ([]resolvers
 	return r.root.notificationRootResolver.InternalNotificationPreferences(ctx, r.u.ID)
 }
 
+func (r *userResolver) ChatWebhooks(ctx context.Context) ([]resolvers.ChatWebhookResolver, error) {
+	return r.root.chatWebhooksRootResolver.InternalListByUserID(ctx, r.u.ID)
+}
+
+func (r *userResolver) Sessions(ctx context.Context) ([]resolvers.SessionResolver, error) {
+	return r.root.sessionsRootResolver.InternalListByUserID(ctx, r.u.ID)
+}
+
+func (r *userResolver) TwoFactorEnabled(ctx context.Context) (bool, error) {
+	return r.root.twoFactorRootResolver.InternalIsEnabled(ctx, r.u.ID)
+}
+
 func (r *userResolver) NotificationsReceiveNewsletter() (bool, error) {
 	settings, err := r.root.notificationSettingsRepo.GetByUser(r.u.ID)
 	if errors.Is(err, sql.ErrNoRows) {
@@ -181,6 +235,14 @@ func (r *userResolver) NotificationsReceiveNewsletter() (bool, error) {
 	return settings.ReceiveNewsletter, nil
 }
 
+func (r *userResolver) NotificationsDigestFrequency(ctx context.Context) (string, error) {
+	settings, err := r.root.digestService.Settings(ctx, r.u.ID)
+	if err != nil {
+		return "", gqlerrors.Error(err)
+	}
+	return digestFrequencyToGraphQL[settings.Frequency], nil
+}
+
 func (r *userResolver) GitHubAccount(ctx context.Context) (resolvers.GitHubAccountResolver, error) {
 	if account, err := r.root.githubAccountRootResolver.InteralByID(ctx, r.u.ID); errors.Is(err, gqlerrors.ErrNotFound) {
 		return nil, nil
@@ -0,0 +1,52 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"getsturdy.com/api/pkg/accesstokens"
+	"getsturdy.com/api/pkg/analytics/disabled"
+	service_analytics "getsturdy.com/api/pkg/analytics/service"
+	"getsturdy.com/api/pkg/auth"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/users"
+	db_user "getsturdy.com/api/pkg/users/db"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUpdateUser_scopes(t *testing.T) {
+	userRepo := db_user.NewMemory()
+	root := &userRootResolver{
+		userRepo:         userRepo,
+		analyticsServcie: service_analytics.New(zap.NewNop(), disabled.NewClient(zap.NewNop())),
+	}
+
+	user := &users.User{ID: "user-id", Email: "user@example.com"}
+	require.NoError(t, userRepo.Create(user))
+
+	email := "attacker@example.com"
+	args := resolvers.UpdateUserArgs{Input: resolvers.UpdateUserInput{Email: &email}}
+
+	for _, scopes := range []accesstokens.Scopes{
+		{accesstokens.ScopeCodebasesRead},
+		{accesstokens.ScopeWorkspacesWrite},
+		{accesstokens.ScopeStatusesWrite},
+	} {
+		ctx := auth.NewContext(context.Background(), &auth.Subject{ID: user.ID, Type: auth.SubjectUser, Scopes: scopes})
+		_, err := root.UpdateUser(ctx, args)
+		assert.ErrorIs(t, err, auth.ErrForbidden, scopes)
+	}
+
+	stored, err := userRepo.Get(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "user@example.com", stored.Email)
+
+	for _, scopes := range []accesstokens.Scopes{nil, {accesstokens.ScopeAdmin}} {
+		ctx := auth.NewContext(context.Background(), &auth.Subject{ID: user.ID, Type: auth.SubjectUser, Scopes: scopes})
+		_, err := root.UpdateUser(ctx, args)
+		assert.NoError(t, err, scopes)
+	}
+}
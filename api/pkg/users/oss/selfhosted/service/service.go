@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 
+	"getsturdy.com/api/pkg/organization"
 	service_organization "getsturdy.com/api/pkg/organization/service"
 	"getsturdy.com/api/pkg/users"
 	"getsturdy.com/api/pkg/users/service"
@@ -32,20 +33,36 @@ func (s *Service) CreateWithPassword(ctx context.Context, name, password, email
 	if err != nil {
 		return nil, err
 	}
+	if err := s.addToFirstOrganization(ctx, usr); err != nil {
+		return nil, err
+	}
+	return usr, nil
+}
+
+func (s *Service) CreateWithVerifiedEmail(ctx context.Context, name, email string) (*users.User, error) {
+	usr, err := s.UserService.CreateWithVerifiedEmail(ctx, name, email)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.addToFirstOrganization(ctx, usr); err != nil {
+		return nil, err
+	}
+	return usr, nil
+}
 
+func (s *Service) addToFirstOrganization(ctx context.Context, usr *users.User) error {
 	// If this instance has an organization, auto-add this user
 	first, err := s.organizationService.GetFirst(ctx)
 	switch {
 	case err == nil:
 		// add this user
-		if _, err := s.organizationService.AddMember(ctx, first.ID, usr.ID, usr.ID); err != nil {
-			return nil, fmt.Errorf("failed to add member to existing org: %w", err)
+		if _, err := s.organizationService.AddMember(ctx, first.ID, usr.ID, usr.ID, organization.RoleMember); err != nil {
+			return fmt.Errorf("failed to add member to existing org: %w", err)
 		}
 	case errors.Is(err, sql.ErrNoRows):
 	// first org has not been created yet, this user will create it later
 	case err != nil:
-		return nil, fmt.Errorf("failed to check if an organization already exists: %w", err)
+		return fmt.Errorf("failed to check if an organization already exists: %w", err)
 	}
-
-	return usr, nil
+	return nil
 }
@@ -1,13 +1,33 @@
 package routes
 
 import (
-	"getsturdy.com/api/pkg/auth"
 	"net/http"
 
+	"getsturdy.com/api/pkg/auth"
+	service_sessions "getsturdy.com/api/pkg/sessions/service"
+
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
-func AuthDestroy(c *gin.Context) {
-	auth.RemoveAuthCookie(c.Writer)
-	c.Status(http.StatusOK)
+// AuthDestroy signs the user out, and revokes the session if the user is signed in.
+func AuthDestroy(logger *zap.Logger, sessionsService *service_sessions.Service) func(*gin.Context) {
+	return func(c *gin.Context) {
+		if subject, ok := auth.FromContext(c.Request.Context()); ok && subject.SessionID != "" {
+			if err := revokeSession(c, sessionsService, subject.SessionID); err != nil {
+				logger.Error("failed to revoke session", zap.Error(err))
+			}
+		}
+
+		auth.RemoveAuthCookie(c.Writer)
+		c.Status(http.StatusOK)
+	}
+}
+
+func revokeSession(c *gin.Context, sessionsService *service_sessions.Service, id string) error {
+	session, err := sessionsService.Get(c.Request.Context(), id)
+	if err != nil {
+		return err
+	}
+	return sessionsService.Revoke(c.Request.Context(), session)
 }
@@ -1,8 +1,10 @@
 package routes
 
 import (
+	"context"
 	"errors"
 	"log"
+	"getsturdy.com/api/pkg/accesstokens"
 	"getsturdy.com/api/pkg/auth"
 	"getsturdy.com/api/pkg/jwt"
 	"getsturdy.com/api/pkg/users/db"
@@ -10,6 +12,8 @@ import (
 	"time"
 
 	service_jwt "getsturdy.com/api/pkg/jwt/service"
+	service_sessions "getsturdy.com/api/pkg/sessions/service"
+	service_twofactor "getsturdy.com/api/pkg/twofactor/service"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -20,21 +24,61 @@ var (
 	oneMonth = 30 * oneDay
 )
 
-func ClientToken(db db.Repository, jwtService *service_jwt.Service) func(*gin.Context) {
+var errTwoFactorRequired = errors.New("two-factor authentication is required")
+
+// requireTwoFactor returns errTwoFactorRequired if the user is a member of an organization that requires two-factor
+// authentication, and has not entered their second factor in the session.
+func requireTwoFactor(ctx context.Context, twoFactorService *service_twofactor.Service, userID, sessionID string) error {
+	required, err := twoFactorService.IsRequired(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !required {
+		return nil
+	}
+	verified, err := twoFactorService.IsVerified(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return errTwoFactorRequired
+	}
+	return nil
+}
+
+func ClientToken(db db.Repository, jwtService *service_jwt.Service, twoFactorService *service_twofactor.Service, sessionsService *service_sessions.Service) func(*gin.Context) {
 	return func(c *gin.Context) {
-		userID, err := auth.UserID(c.Request.Context())
-		if err != nil {
+		subject, ok := auth.FromContext(c.Request.Context())
+		if !ok || subject.Type != auth.SubjectUser {
 			c.AbortWithStatus(http.StatusUnauthorized)
 			return
 		}
 
-		user, err := db.Get(userID)
+		// client tokens are not limited by scopes
+		if err := auth.RequireScope(c.Request.Context(), accesstokens.ScopeAdmin); err != nil {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		user, err := db.Get(subject.ID)
 		if err != nil {
 			log.Println(err)
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
 			return
 		}
 
+		// users that are required to use two-factor authentication can not get a client token until they have
+		// signed in with it
+		if err := requireTwoFactor(c.Request.Context(), twoFactorService, user.ID, subject.SessionID); err != nil {
+			if errors.Is(err, errTwoFactorRequired) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "two-factor authentication is required, enable it in your account settings and sign in again"})
+				return
+			}
+			log.Println(err)
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
 		token, err := jwtService.IssueToken(c.Request.Context(), user.ID, oneMonth, jwt.TokenTypeAuth)
 		if err != nil {
 			log.Println(err)
@@ -42,6 +86,23 @@ func ClientToken(db db.Repository, jwtService *service_jwt.Service) func(*gin.Co
 			return
 		}
 
+		// the client is signed in with the second factor if the session that it got the token from is
+		if subject.SessionID != "" {
+			verified, err := sessionsService.IsTwoFactorVerified(c.Request.Context(), subject.SessionID)
+			if err != nil {
+				log.Println(err)
+				c.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+			if verified {
+				if err := sessionsService.TwoFactorVerified(c.Request.Context(), token.ID); err != nil {
+					log.Println(err)
+					c.AbortWithStatus(http.StatusInternalServerError)
+					return
+				}
+			}
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"token": token.Token,
 		})
@@ -57,8 +118,7 @@ func RenewToken(logger *zap.Logger, db db.Repository, jwtService *service_jwt.Se
 			return
 		}
 
-		user, err := db.Get(userID)
-		if err != nil {
+		if _, err := db.Get(userID); err != nil {
 			log.Println(err)
 			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "invalid input"})
 			return
@@ -84,7 +144,7 @@ func RenewToken(logger *zap.Logger, db db.Repository, jwtService *service_jwt.Se
 
 		// If expires within 25 days, renew it! (original expire duration is 30 days)
 		if token.ExpiresAt.Before(time.Now().Add(time.Hour * 24 * 25)) {
-			newToken, err := jwtService.IssueToken(c.Request.Context(), user.ID, oneMonth, jwt.TokenTypeAuth)
+			newToken, err := jwtService.RenewToken(c.Request.Context(), token, oneMonth)
 			if err != nil {
 				logger.Error("failed to renew token for user", zap.Error(err))
 				c.AbortWithStatus(http.StatusBadRequest)
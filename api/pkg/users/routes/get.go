@@ -27,7 +27,10 @@ func GetSelf(repo db.Repository, jwtService *service_jwt.Service) func(c *gin.Co
 		}
 		// Refresh the users auth cookie
 		// For requests from a browser, this takes care of all of the auth renewal we need. :-)
-		auth.SetAuthCookieForUser(c, u.ID, jwtService)
+		// The session is renewed rather than replaced, so that it stays verified with two-factor authentication.
+		if err := auth.RenewAuthCookie(c, jwtService); err != nil {
+			log.Println(err)
+		}
 		c.JSON(http.StatusOK, u)
 	}
 }
@@ -1,13 +1,19 @@
 package routes
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+	"time"
 
 	"getsturdy.com/api/pkg/analytics"
 	service_analytics "getsturdy.com/api/pkg/analytics/service"
 	"getsturdy.com/api/pkg/auth"
+	"getsturdy.com/api/pkg/jwt"
 	service_jwt "getsturdy.com/api/pkg/jwt/service"
+	service_sessions "getsturdy.com/api/pkg/sessions/service"
+	service_twofactor "getsturdy.com/api/pkg/twofactor/service"
+	"getsturdy.com/api/pkg/users"
 	"getsturdy.com/api/pkg/users/db"
 
 	"github.com/gin-gonic/gin"
@@ -15,7 +21,10 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-func Login(logger *zap.Logger, repo db.Repository, analyticsService *service_analytics.Service, jwtService *service_jwt.Service) func(c *gin.Context) {
+// twoFactorTokenValidFor is how long users have to enter their second factor after signing in with a password.
+const twoFactorTokenValidFor = 5 * time.Minute
+
+func Login(logger *zap.Logger, repo db.Repository, analyticsService *service_analytics.Service, jwtService *service_jwt.Service, twoFactorService *service_twofactor.Service) func(c *gin.Context) {
 	type request struct {
 		Email    string `json:"email" binding:"required"`
 		Password string `json:"password" binding:"required"`
@@ -48,17 +57,119 @@ func Login(logger *zap.Logger, repo db.Repository, analyticsService *service_ana
 			return
 		}
 
-		if err := auth.SetAuthCookieForUser(c, getUser.ID, jwtService); err != nil {
-			logger.Error("failed to set auth cookie", zap.Error(err))
+		ctx := c.Request.Context()
+
+		// Users with two-factor authentication don't get the auth cookie until they have entered their second
+		// factor, they get a short lived token to present together with the code instead.
+		twoFactorEnabled, err := twoFactorService.IsEnabled(ctx, getUser.ID)
+		if err != nil {
+			logger.Error("failed to check two-factor authentication", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+		if twoFactorEnabled {
+			token, err := jwtService.IssueToken(ctx, getUser.ID, twoFactorTokenValidFor, jwt.TokenTypeTwoFactor)
+			if err != nil {
+				logger.Error("failed to issue two-factor token", zap.Error(err))
+				c.AbortWithStatus(http.StatusInternalServerError)
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{
+				"two_factor_required": true,
+				"two_factor_token":    token.Token,
+			})
+			return
+		}
+
+		token, err := jwtService.IssueToken(ctx, getUser.ID, oneMonth, jwt.TokenTypeAuth)
+		if err != nil {
+			logger.Error("failed to issue auth token", zap.Error(err))
 			c.AbortWithStatus(http.StatusInternalServerError)
 			return
 		}
 
+		passwordLoggedIn(c, getUser, token, analyticsService)
+	}
+}
+
+// LoginTwoFactor completes a password login for users with two-factor authentication, with the token from Login and a
+// code from the user's authenticator app or one of their recovery codes. The session that the user signs in to is
+// marked as verified with two-factor authentication.
+func LoginTwoFactor(logger *zap.Logger, repo db.Repository, analyticsService *service_analytics.Service, jwtService *service_jwt.Service, twoFactorService *service_twofactor.Service, sessionsService *service_sessions.Service) func(c *gin.Context) {
+	type request struct {
+		Token string `json:"token" binding:"required"`
+		Code  string `json:"code" binding:"required"`
+	}
+
+	return func(c *gin.Context) {
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Warn("failed to bind input", zap.Error(err))
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Something went wrong, please check the input and try again"})
+			return
+		}
+
 		ctx := c.Request.Context()
-		analyticsService.IdentifyUser(ctx, getUser)
-		analyticsService.Capture(ctx, "logged in", analytics.Property("type", "password"))
 
-		// Send the user object in the response
-		c.JSON(http.StatusOK, getUser)
+		challenge, err := jwtService.Verify(ctx, req.Token, jwt.TokenTypeTwoFactor)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "The sign in has expired, please sign in again"})
+			return
+		}
+
+		getUser, err := repo.Get(challenge.Subject)
+		if err != nil {
+			logger.Error("failed to get user", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		switch err := twoFactorService.VerifyChallenge(ctx, challenge, req.Code); {
+		case err == nil:
+		case errors.Is(err, service_twofactor.ErrInvalidCode):
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid code, please check the input and try again"})
+			return
+		case errors.Is(err, service_twofactor.ErrTooManyAttempts):
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many attempts, please try again later"})
+			return
+		case errors.Is(err, service_twofactor.ErrChallengeUsed):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "The sign in has expired, please sign in again"})
+			return
+		case errors.Is(err, service_twofactor.ErrNotEnabled):
+			// two-factor authentication was disabled after the user signed in with their password
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled, please sign in again"})
+			return
+		default:
+			logger.Error("failed to verify two-factor code", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		token, err := jwtService.IssueToken(ctx, getUser.ID, oneMonth, jwt.TokenTypeAuth)
+		if err != nil {
+			logger.Error("failed to issue auth token", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		if err := sessionsService.TwoFactorVerified(ctx, token.ID); err != nil {
+			logger.Error("failed to mark session as verified", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		passwordLoggedIn(c, getUser, token, analyticsService)
 	}
 }
+
+// passwordLoggedIn sets the auth cookie for a user that has signed in with a password, and responds with the user.
+func passwordLoggedIn(c *gin.Context, user *users.User, token *jwt.Token, analyticsService *service_analytics.Service) {
+	auth.SetAuthCookie(c, token)
+
+	ctx := c.Request.Context()
+	analyticsService.IdentifyUser(ctx, user)
+	analyticsService.Capture(ctx, "logged in", analytics.Property("type", "password"))
+
+	// Send the user object in the response
+	c.JSON(http.StatusOK, user)
+}
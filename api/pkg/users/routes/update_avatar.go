@@ -7,6 +7,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"getsturdy.com/api/pkg/accesstokens"
 	"getsturdy.com/api/pkg/auth"
 	"getsturdy.com/api/pkg/img"
 
@@ -30,6 +31,11 @@ func UpdateAvatar(
 			return
 		}
 
+		if err := auth.RequireScope(c.Request.Context(), accesstokens.ScopeAdmin); err != nil {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
 		form, err := c.MultipartForm()
 		if err != nil {
 			logger.Warn("failed to parse form", zap.Error(err))
@@ -30,6 +30,7 @@ type UserService struct {
 
 type Service interface {
 	CreateWithPassword(ctx context.Context, name, password, email string) (*users.User, error)
+	CreateWithVerifiedEmail(ctx context.Context, name, email string) (*users.User, error)
 	GetByIDs(ctx context.Context, ids ...string) ([]*users.User, error)
 	GetByID(_ context.Context, id string) (*users.User, error)
 	GetByEmail(_ context.Context, email string) (*users.User, error)
@@ -67,28 +68,41 @@ func (s *UserService) GetFirstUser(ctx context.Context) (*users.User, error) {
 }
 
 func (s *UserService) CreateWithPassword(ctx context.Context, name, password, email string) (*users.User, error) {
-	if _, err := s.userRepo.GetByEmail(email); errors.Is(err, sql.ErrNoRows) {
-		// all good
-	} else if err != nil {
-		return nil, fmt.Errorf("failed to get user by email: %w", err)
-	} else {
-		return nil, ErrExists
-	}
-
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	t := time.Now()
-	newUser := &users.User{
-		ID:           uuid.New().String(),
+	return s.create(ctx, &users.User{
 		Name:         name,
 		Email:        email,
 		PasswordHash: string(hash),
-		CreatedAt:    &t,
+	})
+}
+
+// CreateWithVerifiedEmail creates a user without a password, for users that sign in with an identity provider that
+// has verified their email address.
+func (s *UserService) CreateWithVerifiedEmail(ctx context.Context, name, email string) (*users.User, error) {
+	return s.create(ctx, &users.User{
+		Name:          name,
+		Email:         email,
+		EmailVerified: true,
+	})
+}
+
+func (s *UserService) create(ctx context.Context, newUser *users.User) (*users.User, error) {
+	if _, err := s.userRepo.GetByEmail(newUser.Email); errors.Is(err, sql.ErrNoRows) {
+		// all good
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	} else {
+		return nil, ErrExists
 	}
 
+	t := time.Now()
+	newUser.ID = uuid.New().String()
+	newUser.CreatedAt = &t
+
 	if err := s.userRepo.Create(newUser); err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
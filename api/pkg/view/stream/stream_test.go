@@ -22,6 +22,8 @@ import (
 	"getsturdy.com/api/pkg/db"
 	"getsturdy.com/api/pkg/events"
 	"getsturdy.com/api/pkg/internal/sturdytest"
+	db_organization "getsturdy.com/api/pkg/organization/db"
+	db_scim "getsturdy.com/api/pkg/scim/db"
 	db_suggestion "getsturdy.com/api/pkg/suggestions/db"
 	service_suggestion "getsturdy.com/api/pkg/suggestions/service"
 	"getsturdy.com/api/pkg/users"
@@ -80,7 +82,7 @@ func TestStream(t *testing.T) {
 	codebaseRepo := db_codebase.NewRepo(d)
 	aclRepo := db_acl.NewACLRepository(d)
 	codebaseUserRepo := db_codebase.NewCodebaseUserRepo(d)
-	aclProvider := acl_provider.New(aclRepo, codebaseUserRepo, userRepo)
+	aclProvider := acl_provider.New(aclRepo, codebaseUserRepo, userRepo, codebaseRepo, db_scim.NewGroups(d), db_organization.NewMember(d))
 	userService := service_user.New(zap.NewNop(), userRepo, nil)
 	suggestionsDB := db_suggestion.New(d)
 
@@ -102,10 +104,11 @@ func TestStream(t *testing.T) {
 		nil,
 		nil,
 		nil,
+		nil,
 	)
 
 	codebaseService := service_codebase.New(codebaseRepo, codebaseUserRepo, workspaceService, nil, logger, executorProvider, nil, nil)
-	authService := service_auth.New(codebaseService, userService, nil, aclProvider, nil /*organizationService*/)
+	authService := service_auth.New(codebaseService, userService, nil, aclProvider, nil /*organizationService*/, nil, nil, nil)
 
 	suggestionsService := service_suggestion.New(
 		logger,
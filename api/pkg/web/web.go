@@ -0,0 +1,6 @@
+package web
+
+// Configuration is the configuration of the Sturdy web application, that the api links and redirects users to.
+type Configuration struct {
+	URL string `long:"url" description:"URL of the Sturdy web application, used to link to it from chat messages and to redirect to after signing in" default:"https://getsturdy.com" env:"URL"`
+}
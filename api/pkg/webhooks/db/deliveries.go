@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/webhooks"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type DeliveryRepository interface {
+	Create(context.Context, *webhooks.Delivery) error
+	Get(ctx context.Context, id string) (*webhooks.Delivery, error)
+	Update(context.Context, *webhooks.Delivery) error
+	// ListByWebhookID returns up to limit deliveries of the webhook, newest first.
+	ListByWebhookID(ctx context.Context, webhookID string, limit int) ([]*webhooks.Delivery, error)
+	// ClaimDue clears next_attempt_at of the pending deliveries that are due to be retried at the given time,
+	// and returns their IDs.
+	ClaimDue(ctx context.Context, now time.Time) ([]string, error)
+}
+
+type deliveryRepo struct {
+	db *sqlx.DB
+}
+
+func NewDeliveryRepository(db *sqlx.DB) DeliveryRepository {
+	return &deliveryRepo{db: db}
+}
+
+func (r *deliveryRepo) Create(ctx context.Context, delivery *webhooks.Delivery) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO webhook_deliveries
+			(id, webhook_id, codebase_id, event_id, event_type, payload, status, attempts, response_status_code,
+			 error, duration_ms, redelivery_of, created_at, last_attempt_at, next_attempt_at)
+		VALUES
+			(:id, :webhook_id, :codebase_id, :event_id, :event_type, :payload, :status, :attempts, :response_status_code,
+			 :error, :duration_ms, :redelivery_of, :created_at, :last_attempt_at, :next_attempt_at)
+	`, delivery); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (r *deliveryRepo) Get(ctx context.Context, id string) (*webhooks.Delivery, error) {
+	var res webhooks.Delivery
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, webhook_id, codebase_id, event_id, event_type, payload, status, attempts, response_status_code,
+			error, duration_ms, redelivery_of, created_at, last_attempt_at, next_attempt_at
+		FROM
+			webhook_deliveries
+		WHERE
+			id = $1
+	`, id); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *deliveryRepo) Update(ctx context.Context, delivery *webhooks.Delivery) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		UPDATE
+			webhook_deliveries
+		SET
+			status = :status,
+			attempts = :attempts,
+			response_status_code = :response_status_code,
+			error = :error,
+			duration_ms = :duration_ms,
+			last_attempt_at = :last_attempt_at,
+			next_attempt_at = :next_attempt_at
+		WHERE
+			id = :id
+	`, delivery); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (r *deliveryRepo) ListByWebhookID(ctx context.Context, webhookID string, limit int) ([]*webhooks.Delivery, error) {
+	var res []*webhooks.Delivery
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, webhook_id, codebase_id, event_id, event_type, payload, status, attempts, response_status_code,
+			error, duration_ms, redelivery_of, created_at, last_attempt_at, next_attempt_at
+		FROM
+			webhook_deliveries
+		WHERE
+			webhook_id = $1
+		ORDER BY
+			created_at DESC
+		LIMIT $2
+	`, webhookID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list: %w", err)
+	}
+	return res, nil
+}
+
+func (r *deliveryRepo) ClaimDue(ctx context.Context, now time.Time) ([]string, error) {
+	var ids []string
+	if err := r.db.SelectContext(ctx, &ids, `
+		UPDATE
+			webhook_deliveries
+		SET
+			next_attempt_at = NULL
+		WHERE
+			next_attempt_at <= $1
+			AND status = $2
+		RETURNING
+			id
+	`, now, webhooks.DeliveryStatusPending); err != nil {
+		return nil, fmt.Errorf("failed to claim: %w", err)
+	}
+	return ids, nil
+}
@@ -0,0 +1,117 @@
+package db_test
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/db"
+	"getsturdy.com/api/pkg/internal/sturdytest"
+	"getsturdy.com/api/pkg/webhooks"
+	db_webhooks "getsturdy.com/api/pkg/webhooks/db"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func getDB(t *testing.T) *sqlx.DB {
+	if os.Getenv("E2E_TEST") == "" {
+		t.SkipNow()
+	}
+
+	d, err := db.Setup(
+		sturdytest.PsqlDbSourceForTesting(),
+	)
+	assert.NoError(t, err)
+	return d
+}
+
+func TestDeliveryRepository(t *testing.T) {
+	repo := db_webhooks.NewDeliveryRepository(getDB(t))
+	ctx := context.Background()
+
+	webhookID := uuid.NewString()
+	now := time.Now().Truncate(time.Millisecond)
+	delivery := &webhooks.Delivery{
+		ID:         uuid.NewString(),
+		WebhookID:  webhookID,
+		CodebaseID: uuid.NewString(),
+		EventID:    uuid.NewString(),
+		EventType:  webhooks.EventChangeLanded,
+		Payload:    `{"event":"change.landed"}`,
+		Status:     webhooks.DeliveryStatusPending,
+		CreatedAt:  now,
+	}
+	assert.NoError(t, repo.Create(ctx, delivery))
+
+	statusCode, durationMs := 200, 12
+	delivery.Status = webhooks.DeliveryStatusSucceeded
+	delivery.Attempts = 1
+	delivery.ResponseStatusCode = &statusCode
+	delivery.DurationMs = &durationMs
+	delivery.LastAttemptAt = &now
+	assert.NoError(t, repo.Update(ctx, delivery))
+
+	got, err := repo.Get(ctx, delivery.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, webhooks.DeliveryStatusSucceeded, got.Status)
+		assert.Equal(t, 1, got.Attempts)
+		assert.Equal(t, &statusCode, got.ResponseStatusCode)
+		assert.Equal(t, delivery.Payload, got.Payload)
+	}
+
+	redelivery := &webhooks.Delivery{
+		ID:           uuid.NewString(),
+		WebhookID:    webhookID,
+		CodebaseID:   delivery.CodebaseID,
+		EventID:      delivery.EventID,
+		EventType:    delivery.EventType,
+		Payload:      delivery.Payload,
+		Status:       webhooks.DeliveryStatusPending,
+		RedeliveryOf: &delivery.ID,
+		CreatedAt:    now.Add(time.Second),
+	}
+	assert.NoError(t, repo.Create(ctx, redelivery))
+
+	list, err := repo.ListByWebhookID(ctx, webhookID, 10)
+	if assert.NoError(t, err) && assert.Len(t, list, 2) {
+		assert.Equal(t, redelivery.ID, list[0].ID)
+		assert.Equal(t, delivery.ID, list[1].ID)
+	}
+}
+
+func TestDeliveryRepository_ClaimDue(t *testing.T) {
+	repo := db_webhooks.NewDeliveryRepository(getDB(t))
+	ctx := context.Background()
+
+	now := time.Now()
+	due := now.Add(-time.Minute)
+	delivery := &webhooks.Delivery{
+		ID:            uuid.NewString(),
+		WebhookID:     uuid.NewString(),
+		CodebaseID:    uuid.NewString(),
+		EventID:       uuid.NewString(),
+		EventType:     webhooks.EventChangeLanded,
+		Payload:       "{}",
+		Status:        webhooks.DeliveryStatusPending,
+		CreatedAt:     now,
+		NextAttemptAt: &due,
+	}
+	assert.NoError(t, repo.Create(ctx, delivery))
+
+	ids, err := repo.ClaimDue(ctx, now)
+	assert.NoError(t, err)
+	assert.Contains(t, ids, delivery.ID)
+
+	// claimed deliveries are not claimed again
+	ids, err = repo.ClaimDue(ctx, now)
+	assert.NoError(t, err)
+	assert.NotContains(t, ids, delivery.ID)
+
+	got, err := repo.Get(ctx, delivery.ID)
+	if assert.NoError(t, err) {
+		assert.Nil(t, got.NextAttemptAt)
+	}
+}
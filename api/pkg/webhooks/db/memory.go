@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"getsturdy.com/api/pkg/webhooks"
+)
+
+var _ WebhookRepository = &inMemoryWebhooks{}
+
+type inMemoryWebhooks struct {
+	mu   sync.Mutex
+	byID map[string]webhooks.Webhook
+}
+
+func NewInMemoryWebhookRepository() *inMemoryWebhooks {
+	return &inMemoryWebhooks{byID: make(map[string]webhooks.Webhook)}
+}
+
+func (i *inMemoryWebhooks) Create(_ context.Context, webhook *webhooks.Webhook) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.byID[webhook.ID] = *webhook
+	return nil
+}
+
+func (i *inMemoryWebhooks) Get(_ context.Context, id string) (*webhooks.Webhook, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	webhook, ok := i.byID[id]
+	if !ok || webhook.DeletedAt != nil {
+		return nil, sql.ErrNoRows
+	}
+	return &webhook, nil
+}
+
+func (i *inMemoryWebhooks) Update(ctx context.Context, webhook *webhooks.Webhook) error {
+	return i.Create(ctx, webhook)
+}
+
+func (i *inMemoryWebhooks) ListByCodebaseID(_ context.Context, codebaseID string) ([]*webhooks.Webhook, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var res []*webhooks.Webhook
+	for _, webhook := range i.byID {
+		if webhook.CodebaseID != codebaseID || webhook.DeletedAt != nil {
+			continue
+		}
+		webhook := webhook
+		res = append(res, &webhook)
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].CreatedAt.Before(res[b].CreatedAt)
+	})
+	return res, nil
+}
+
+var _ DeliveryRepository = &inMemoryDeliveries{}
+
+type inMemoryDeliveries struct {
+	mu   sync.Mutex
+	byID map[string]webhooks.Delivery
+}
+
+func NewInMemoryDeliveryRepository() *inMemoryDeliveries {
+	return &inMemoryDeliveries{byID: make(map[string]webhooks.Delivery)}
+}
+
+func (i *inMemoryDeliveries) Create(_ context.Context, delivery *webhooks.Delivery) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.byID[delivery.ID] = *delivery
+	return nil
+}
+
+func (i *inMemoryDeliveries) Get(_ context.Context, id string) (*webhooks.Delivery, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delivery, ok := i.byID[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &delivery, nil
+}
+
+func (i *inMemoryDeliveries) Update(ctx context.Context, delivery *webhooks.Delivery) error {
+	return i.Create(ctx, delivery)
+}
+
+func (i *inMemoryDeliveries) ListByWebhookID(_ context.Context, webhookID string, limit int) ([]*webhooks.Delivery, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var res []*webhooks.Delivery
+	for _, delivery := range i.byID {
+		if delivery.WebhookID != webhookID {
+			continue
+		}
+		delivery := delivery
+		res = append(res, &delivery)
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].CreatedAt.After(res[b].CreatedAt)
+	})
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (i *inMemoryDeliveries) ClaimDue(_ context.Context, now time.Time) ([]string, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var ids []string
+	for id, delivery := range i.byID {
+		if delivery.Status != webhooks.DeliveryStatusPending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		delivery.NextAttemptAt = nil
+		i.byID[id] = delivery
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
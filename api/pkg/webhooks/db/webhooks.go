@@ -0,0 +1,92 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"getsturdy.com/api/pkg/webhooks"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type WebhookRepository interface {
+	Create(context.Context, *webhooks.Webhook) error
+	Get(ctx context.Context, id string) (*webhooks.Webhook, error)
+	Update(context.Context, *webhooks.Webhook) error
+	// ListByCodebaseID returns the webhooks of the codebase that are not deleted.
+	ListByCodebaseID(ctx context.Context, codebaseID string) ([]*webhooks.Webhook, error)
+}
+
+type webhookRepo struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) WebhookRepository {
+	return &webhookRepo{db: db}
+}
+
+func (r *webhookRepo) Create(ctx context.Context, webhook *webhooks.Webhook) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		INSERT INTO webhooks
+			(id, codebase_id, url, secret, events, version, active, created_by, created_at, updated_at, deleted_at)
+		VALUES
+			(:id, :codebase_id, :url, :secret, :events, :version, :active, :created_by, :created_at, :updated_at, :deleted_at)
+	`, webhook); err != nil {
+		return fmt.Errorf("failed to insert: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepo) Get(ctx context.Context, id string) (*webhooks.Webhook, error) {
+	var res webhooks.Webhook
+	if err := r.db.GetContext(ctx, &res, `
+		SELECT
+			id, codebase_id, url, secret, events, version, active, created_by, created_at, updated_at, deleted_at
+		FROM
+			webhooks
+		WHERE
+			id = $1
+			AND deleted_at IS NULL
+	`, id); err != nil {
+		return nil, fmt.Errorf("failed to get: %w", err)
+	}
+	return &res, nil
+}
+
+func (r *webhookRepo) Update(ctx context.Context, webhook *webhooks.Webhook) error {
+	if _, err := r.db.NamedExecContext(ctx, `
+		UPDATE
+			webhooks
+		SET
+			url = :url,
+			secret = :secret,
+			events = :events,
+			version = :version,
+			active = :active,
+			updated_at = :updated_at,
+			deleted_at = :deleted_at
+		WHERE
+			id = :id
+	`, webhook); err != nil {
+		return fmt.Errorf("failed to update: %w", err)
+	}
+	return nil
+}
+
+func (r *webhookRepo) ListByCodebaseID(ctx context.Context, codebaseID string) ([]*webhooks.Webhook, error) {
+	var res []*webhooks.Webhook
+	if err := r.db.SelectContext(ctx, &res, `
+		SELECT
+			id, codebase_id, url, secret, events, version, active, created_by, created_at, updated_at, deleted_at
+		FROM
+			webhooks
+		WHERE
+			codebase_id = $1
+			AND deleted_at IS NULL
+		ORDER BY
+			created_at ASC
+	`, codebaseID); err != nil {
+		return nil, fmt.Errorf("failed to list: %w", err)
+	}
+	return res, nil
+}
@@ -0,0 +1,377 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/auth"
+	service_auth "getsturdy.com/api/pkg/auth/service"
+	service_codebase "getsturdy.com/api/pkg/codebase/service"
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/webhooks"
+	service_webhooks "getsturdy.com/api/pkg/webhooks/service"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/lib/pq"
+)
+
+const (
+	defaultDeliveriesLimit = 20
+	maxDeliveriesLimit     = 100
+)
+
+var (
+	eventToGraphQL = map[webhooks.EventType]string{
+		webhooks.EventChangeLanded:      "ChangeLanded",
+		webhooks.EventCommentCreated:    "CommentCreated",
+		webhooks.EventReviewUpdated:     "ReviewUpdated",
+		webhooks.EventReviewRequested:   "ReviewRequested",
+		webhooks.EventStatusUpdated:     "StatusUpdated",
+		webhooks.EventWorkspaceCreated:  "WorkspaceCreated",
+		webhooks.EventWorkspaceArchived: "WorkspaceArchived",
+	}
+	eventFromGraphQL = func() map[string]webhooks.EventType {
+		res := make(map[string]webhooks.EventType, len(eventToGraphQL))
+		for eventType, name := range eventToGraphQL {
+			res[name] = eventType
+		}
+		return res
+	}()
+
+	deliveryStatusToGraphQL = map[webhooks.DeliveryStatus]string{
+		webhooks.DeliveryStatusPending:   "Pending",
+		webhooks.DeliveryStatusSucceeded: "Succeeded",
+		webhooks.DeliveryStatusFailed:    "Failed",
+	}
+)
+
+type rootResolver struct {
+	authService     *service_auth.Service
+	codebaseService *service_codebase.Service
+	webhooksService *service_webhooks.Service
+
+	codebaseResolver *resolvers.CodebaseRootResolver
+}
+
+func New(
+	authService *service_auth.Service,
+	codebaseService *service_codebase.Service,
+	webhooksService *service_webhooks.Service,
+	codebaseResolver *resolvers.CodebaseRootResolver,
+) resolvers.WebhooksRootResolver {
+	return &rootResolver{
+		authService:      authService,
+		codebaseService:  codebaseService,
+		webhooksService:  webhooksService,
+		codebaseResolver: codebaseResolver,
+	}
+}
+
+// canManage returns an error if the user is not allowed to manage the webhooks of the codebase, only owners and
+// admins of the codebase are.
+func (r *rootResolver) canManage(ctx context.Context, codebaseID string) error {
+	cb, err := r.codebaseService.GetByID(ctx, codebaseID)
+	if err != nil {
+		return err
+	}
+	return r.authService.CanAdmin(ctx, cb)
+}
+
+func (r *rootResolver) InternalListByCodebaseID(ctx context.Context, codebaseID string) ([]resolvers.WebhookResolver, error) {
+	if err := r.canManage(ctx, codebaseID); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	hooks, err := r.webhooksService.ListByCodebaseID(ctx, codebaseID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	res := make([]resolvers.WebhookResolver, 0, len(hooks))
+	for _, hook := range hooks {
+		res = append(res, &webhookResolver{root: r, webhook: hook})
+	}
+	return res, nil
+}
+
+func (r *rootResolver) CreateWebhook(ctx context.Context, args resolvers.CreateWebhookArgs) (resolvers.WebhookResolver, error) {
+	if err := r.canManage(ctx, string(args.Input.CodebaseID)); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	userID, err := auth.UserID(ctx)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	events, err := parseEvents(args.Input.Events)
+	if err != nil {
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", err.Error())
+	}
+
+	hook := &webhooks.Webhook{
+		CodebaseID: string(args.Input.CodebaseID),
+		URL:        args.Input.URL,
+		Secret:     args.Input.Secret,
+		Events:     events,
+		Active:     true,
+		CreatedBy:  userID,
+	}
+	if args.Input.Active != nil {
+		hook.Active = *args.Input.Active
+	}
+
+	if err := r.webhooksService.Create(ctx, hook); err != nil {
+		return nil, validationError(err)
+	}
+
+	return &webhookResolver{root: r, webhook: hook}, nil
+}
+
+func (r *rootResolver) UpdateWebhook(ctx context.Context, args resolvers.UpdateWebhookArgs) (resolvers.WebhookResolver, error) {
+	hook, err := r.webhooksService.Get(ctx, string(args.Input.ID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.canManage(ctx, hook.CodebaseID); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if args.Input.URL != nil {
+		hook.URL = *args.Input.URL
+	}
+	if args.Input.Secret != nil {
+		hook.Secret = *args.Input.Secret
+	}
+	if args.Input.Events != nil {
+		events, err := parseEvents(*args.Input.Events)
+		if err != nil {
+			return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", err.Error())
+		}
+		hook.Events = events
+	}
+	if args.Input.Active != nil {
+		hook.Active = *args.Input.Active
+	}
+
+	if err := r.webhooksService.Update(ctx, hook); err != nil {
+		return nil, validationError(err)
+	}
+
+	return &webhookResolver{root: r, webhook: hook}, nil
+}
+
+func (r *rootResolver) DeleteWebhook(ctx context.Context, args resolvers.DeleteWebhookArgs) (resolvers.WebhookResolver, error) {
+	hook, err := r.webhooksService.Get(ctx, string(args.Input.ID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.canManage(ctx, hook.CodebaseID); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.webhooksService.Delete(ctx, hook); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	return &webhookResolver{root: r, webhook: hook}, nil
+}
+
+func (r *rootResolver) RedeliverWebhookDelivery(ctx context.Context, args resolvers.RedeliverWebhookDeliveryArgs) (resolvers.WebhookDeliveryResolver, error) {
+	delivery, err := r.webhooksService.GetDelivery(ctx, string(args.Input.ID))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	if err := r.canManage(ctx, delivery.CodebaseID); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	redelivery, err := r.webhooksService.Redeliver(ctx, delivery)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	return &deliveryResolver{root: r, delivery: redelivery}, nil
+}
+
+func parseEvents(names []string) (pq.StringArray, error) {
+	events := make(pq.StringArray, 0, len(names))
+	for _, name := range names {
+		eventType, ok := eventFromGraphQL[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown event: %s", name)
+		}
+		events = append(events, string(eventType))
+	}
+	return events, nil
+}
+
+func validationError(err error) error {
+	switch {
+	case errors.Is(err, webhooks.ErrInvalidURL):
+		return gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "the url must be a valid http or https url, that is not an internal address")
+	case errors.Is(err, webhooks.ErrInvalidSecret):
+		return gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "the secret must be at least 8 characters")
+	case errors.Is(err, webhooks.ErrInvalidEvents):
+		return gqlerrors.Error(gqlerrors.ErrBadRequest, "message", "the webhook must subscribe to at least one event, and events must be unique")
+	default:
+		return gqlerrors.Error(err)
+	}
+}
+
+func unixPtr(t *time.Time) *int32 {
+	if t == nil {
+		return nil
+	}
+	v := int32(t.Unix())
+	return &v
+}
+
+type webhookResolver struct {
+	root    *rootResolver
+	webhook *webhooks.Webhook
+}
+
+func (r *webhookResolver) ID() graphql.ID {
+	return graphql.ID(r.webhook.ID)
+}
+
+func (r *webhookResolver) Codebase(ctx context.Context) (resolvers.CodebaseResolver, error) {
+	id := graphql.ID(r.webhook.CodebaseID)
+	return (*r.root.codebaseResolver).Codebase(ctx, resolvers.CodebaseArgs{ID: &id})
+}
+
+func (r *webhookResolver) URL() string {
+	return r.webhook.URL
+}
+
+func (r *webhookResolver) Events() []string {
+	res := make([]string, 0, len(r.webhook.Events))
+	for _, e := range r.webhook.Events {
+		res = append(res, eventToGraphQL[webhooks.EventType(e)])
+	}
+	return res
+}
+
+func (r *webhookResolver) Active() bool {
+	return r.webhook.Active
+}
+
+func (r *webhookResolver) Version() int32 {
+	return int32(r.webhook.Version)
+}
+
+func (r *webhookResolver) CreatedAt() int32 {
+	return int32(r.webhook.CreatedAt.Unix())
+}
+
+func (r *webhookResolver) UpdatedAt() *int32 {
+	return unixPtr(r.webhook.UpdatedAt)
+}
+
+func (r *webhookResolver) Deliveries(ctx context.Context, args resolvers.WebhookDeliveriesArgs) ([]resolvers.WebhookDeliveryResolver, error) {
+	limit := defaultDeliveriesLimit
+	if args.First != nil {
+		limit = int(*args.First)
+	}
+	if limit < 1 || limit > maxDeliveriesLimit {
+		return nil, gqlerrors.Error(gqlerrors.ErrBadRequest, "message", fmt.Sprintf("first must be between 1 and %d", maxDeliveriesLimit))
+	}
+
+	deliveries, err := r.root.webhooksService.ListDeliveries(ctx, r.webhook.ID, limit)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	res := make([]resolvers.WebhookDeliveryResolver, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		res = append(res, &deliveryResolver{root: r.root, delivery: delivery})
+	}
+	return res, nil
+}
+
+type deliveryResolver struct {
+	root     *rootResolver
+	delivery *webhooks.Delivery
+}
+
+func (r *deliveryResolver) ID() graphql.ID {
+	return graphql.ID(r.delivery.ID)
+}
+
+func (r *deliveryResolver) Webhook(ctx context.Context) (resolvers.WebhookResolver, error) {
+	hook, err := r.root.webhooksService.Get(ctx, r.delivery.WebhookID)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return &webhookResolver{root: r.root, webhook: hook}, nil
+}
+
+func (r *deliveryResolver) EventID() graphql.ID {
+	return graphql.ID(r.delivery.EventID)
+}
+
+func (r *deliveryResolver) Event() string {
+	return eventToGraphQL[r.delivery.EventType]
+}
+
+func (r *deliveryResolver) Status() string {
+	return deliveryStatusToGraphQL[r.delivery.Status]
+}
+
+func (r *deliveryResolver) Payload() string {
+	return r.delivery.Payload
+}
+
+func (r *deliveryResolver) Attempts() int32 {
+	return int32(r.delivery.Attempts)
+}
+
+func (r *deliveryResolver) ResponseStatusCode() *int32 {
+	if r.delivery.ResponseStatusCode == nil {
+		return nil
+	}
+	v := int32(*r.delivery.ResponseStatusCode)
+	return &v
+}
+
+func (r *deliveryResolver) Error() *string {
+	return r.delivery.Error
+}
+
+func (r *deliveryResolver) DurationMs() *int32 {
+	if r.delivery.DurationMs == nil {
+		return nil
+	}
+	v := int32(*r.delivery.DurationMs)
+	return &v
+}
+
+func (r *deliveryResolver) RedeliveryOf(ctx context.Context) (resolvers.WebhookDeliveryResolver, error) {
+	if r.delivery.RedeliveryOf == nil {
+		return nil, nil
+	}
+	delivery, err := r.root.webhooksService.GetDelivery(ctx, *r.delivery.RedeliveryOf)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	return &deliveryResolver{root: r.root, delivery: delivery}, nil
+}
+
+func (r *deliveryResolver) CreatedAt() int32 {
+	return int32(r.delivery.CreatedAt.Unix())
+}
+
+func (r *deliveryResolver) LastAttemptAt() *int32 {
+	return unixPtr(r.delivery.LastAttemptAt)
+}
+
+func (r *deliveryResolver) NextAttemptAt() *int32 {
+	return unixPtr(r.delivery.NextAttemptAt)
+}
@@ -0,0 +1,18 @@
+package module
+
+import (
+	"getsturdy.com/api/pkg/di"
+	"getsturdy.com/api/pkg/webhooks/db"
+	"getsturdy.com/api/pkg/webhooks/graphql"
+	"getsturdy.com/api/pkg/webhooks/sender"
+	"getsturdy.com/api/pkg/webhooks/service"
+	"getsturdy.com/api/pkg/webhooks/worker"
+)
+
+func Module(c *di.Container) {
+	c.Import(db.Module)
+	c.Import(graphql.Module)
+	c.Import(sender.Module)
+	c.Import(service.Module)
+	c.Import(worker.Module)
+}
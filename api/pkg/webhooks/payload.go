@@ -0,0 +1,88 @@
+package webhooks
+
+import "time"
+
+// Payload is the body that is sent to webhooks, encoded as JSON.
+//
+// The payload format is versioned with PayloadVersion, and is part of the public API. Fields can be added,
+// but not removed or changed without introducing a new version.
+type Payload struct {
+	// ID is the ID of the event. It's the same for all deliveries of the same event.
+	ID        string    `json:"id"`
+	Type      EventType `json:"type"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Codebase *CodebasePayload `json:"codebase"`
+	Actor    *UserPayload     `json:"actor,omitempty"`
+
+	// Depending on the type of the event, one of these is set
+	Change    *ChangePayload    `json:"change,omitempty"`
+	Comment   *CommentPayload   `json:"comment,omitempty"`
+	Review    *ReviewPayload    `json:"review,omitempty"`
+	Status    *StatusPayload    `json:"status,omitempty"`
+	Workspace *WorkspacePayload `json:"workspace,omitempty"`
+}
+
+type CodebasePayload struct {
+	ID      string `json:"id"`
+	ShortID string `json:"short_id"`
+	Name    string `json:"name"`
+}
+
+type UserPayload struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type ChangePayload struct {
+	ID          string       `json:"id"`
+	CommitID    *string      `json:"commit_id"`
+	Title       *string      `json:"title"`
+	Description string       `json:"description"`
+	Author      *UserPayload `json:"author,omitempty"`
+	CreatedAt   *time.Time   `json:"created_at"`
+}
+
+type CommentPayload struct {
+	ID              string       `json:"id"`
+	Message         string       `json:"message"`
+	Author          *UserPayload `json:"author,omitempty"`
+	WorkspaceID     *string      `json:"workspace_id"`
+	ChangeID        *string      `json:"change_id"`
+	ParentCommentID *string      `json:"parent_comment_id"`
+	Path            string       `json:"path,omitempty"`
+	LineStart       int          `json:"line_start,omitempty"`
+	LineEnd         int          `json:"line_end,omitempty"`
+	LineIsNew       bool         `json:"line_is_new"`
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+type ReviewPayload struct {
+	ID          string       `json:"id"`
+	WorkspaceID string       `json:"workspace_id"`
+	Reviewer    *UserPayload `json:"reviewer,omitempty"`
+	Grade       string       `json:"grade"`
+	RequestedBy *UserPayload `json:"requested_by,omitempty"`
+	IsReplaced  bool         `json:"is_replaced"`
+	DismissedAt *time.Time   `json:"dismissed_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+}
+
+type StatusPayload struct {
+	ID          string    `json:"id"`
+	CommitID    string    `json:"commit_id"`
+	Type        string    `json:"type"`
+	Title       string    `json:"title"`
+	Description *string   `json:"description"`
+	DetailsURL  *string   `json:"details_url"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+type WorkspacePayload struct {
+	ID         string       `json:"id"`
+	Name       *string      `json:"name"`
+	Author     *UserPayload `json:"author,omitempty"`
+	CreatedAt  *time.Time   `json:"created_at"`
+	ArchivedAt *time.Time   `json:"archived_at"`
+}
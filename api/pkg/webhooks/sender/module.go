@@ -0,0 +1,7 @@
+package sender
+
+import "getsturdy.com/api/pkg/di"
+
+func Module(c *di.Container) {
+	c.Register(NewSender)
+}
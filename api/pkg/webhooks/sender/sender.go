@@ -0,0 +1,57 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/auth"
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/names"
+	"getsturdy.com/api/pkg/webhooks"
+
+	"github.com/google/uuid"
+)
+
+type Sender interface {
+	// Codebase sends an event about the referenced object to the webhooks of the codebase. If the context
+	// is authenticated as a user, the user is set as the actor of the event.
+	//
+	// Events are delivered asynchronously.
+	Codebase(ctx context.Context, codebaseID string, eventType webhooks.EventType, referenceID string) error
+}
+
+type realSender struct {
+	queue queue.Queue
+}
+
+func NewSender(queue queue.Queue) Sender {
+	return &realSender{queue: queue}
+}
+
+func (s *realSender) Codebase(ctx context.Context, codebaseID string, eventType webhooks.EventType, referenceID string) error {
+	event := &webhooks.Event{
+		ID:          uuid.NewString(),
+		CodebaseID:  codebaseID,
+		Type:        eventType,
+		ReferenceID: referenceID,
+		CreatedAt:   time.Now(),
+	}
+	if userID, err := auth.UserID(ctx); err == nil {
+		event.ActorID = &userID
+	}
+	if err := s.queue.Publish(ctx, names.WebhooksEvents, event); err != nil {
+		return fmt.Errorf("failed to publish webhook event: %w", err)
+	}
+	return nil
+}
+
+type noopSender struct{}
+
+func NewNoopSender() Sender {
+	return &noopSender{}
+}
+
+func (*noopSender) Codebase(context.Context, string, webhooks.EventType, string) error {
+	return nil
+}
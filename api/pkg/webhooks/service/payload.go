@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"getsturdy.com/api/pkg/change"
+	"getsturdy.com/api/pkg/comments"
+	"getsturdy.com/api/pkg/webhooks"
+)
+
+// buildPayload returns the JSON encoded payload of the event, in the given version.
+func (s *Service) buildPayload(ctx context.Context, event *webhooks.Event, version int) ([]byte, error) {
+	if version != webhooks.PayloadVersion {
+		return nil, fmt.Errorf("unsupported payload version: %d", version)
+	}
+
+	cb, err := s.codebaseRepo.GetAllowArchived(event.CodebaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get codebase: %w", err)
+	}
+
+	payload := &webhooks.Payload{
+		ID:        event.ID,
+		Type:      event.Type,
+		Version:   version,
+		CreatedAt: event.CreatedAt,
+		Codebase: &webhooks.CodebasePayload{
+			ID:      cb.ID,
+			ShortID: string(cb.ShortCodebaseID),
+			Name:    cb.Name,
+		},
+	}
+
+	if event.ActorID != nil {
+		if payload.Actor, err = s.userPayload(*event.ActorID); err != nil {
+			return nil, err
+		}
+	}
+
+	switch event.Type {
+	case webhooks.EventChangeLanded:
+		payload.Change, err = s.changePayload(ctx, change.ID(event.ReferenceID))
+	case webhooks.EventCommentCreated:
+		payload.Comment, err = s.commentPayload(comments.ID(event.ReferenceID))
+	case webhooks.EventReviewUpdated, webhooks.EventReviewRequested:
+		payload.Review, err = s.reviewPayload(ctx, event.ReferenceID)
+	case webhooks.EventStatusUpdated:
+		payload.Status, err = s.statusPayload(ctx, event.ReferenceID)
+	case webhooks.EventWorkspaceCreated, webhooks.EventWorkspaceArchived:
+		payload.Workspace, err = s.workspacePayload(event.ReferenceID)
+	default:
+		return nil, fmt.Errorf("unsupported event type: %s", event.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(payload)
+}
+
+func (s *Service) userPayload(userID string) (*webhooks.UserPayload, error) {
+	user, err := s.userRepo.Get(userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return &webhooks.UserPayload{ID: userID}, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return &webhooks.UserPayload{ID: user.ID, Name: user.Name}, nil
+}
+
+func (s *Service) optionalUserPayload(userID *string) (*webhooks.UserPayload, error) {
+	if userID == nil {
+		return nil, nil
+	}
+	return s.userPayload(*userID)
+}
+
+func (s *Service) changePayload(ctx context.Context, id change.ID) (*webhooks.ChangePayload, error) {
+	ch, err := s.changeRepo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get change: %w", err)
+	}
+	author, err := s.optionalUserPayload(ch.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &webhooks.ChangePayload{
+		ID:          string(ch.ID),
+		CommitID:    ch.CommitID,
+		Title:       ch.Title,
+		Description: ch.UpdatedDescription,
+		Author:      author,
+		CreatedAt:   ch.CreatedAt,
+	}, nil
+}
+
+func (s *Service) commentPayload(id comments.ID) (*webhooks.CommentPayload, error) {
+	comment, err := s.commentsRepo.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	author, err := s.userPayload(comment.UserID)
+	if err != nil {
+		return nil, err
+	}
+	payload := &webhooks.CommentPayload{
+		ID:          string(comment.ID),
+		Message:     comment.Message,
+		Author:      author,
+		WorkspaceID: comment.WorkspaceID,
+		Path:        comment.Path,
+		LineStart:   comment.LineStart,
+		LineEnd:     comment.LineEnd,
+		LineIsNew:   comment.LineIsNew,
+		CreatedAt:   comment.CreatedAt,
+	}
+	if comment.ChangeID != nil {
+		changeID := string(*comment.ChangeID)
+		payload.ChangeID = &changeID
+	}
+	if comment.ParentComment != nil {
+		parentID := string(*comment.ParentComment)
+		payload.ParentCommentID = &parentID
+	}
+	return payload, nil
+}
+
+func (s *Service) reviewPayload(ctx context.Context, id string) (*webhooks.ReviewPayload, error) {
+	rev, err := s.reviewRepo.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+	reviewer, err := s.userPayload(rev.UserID)
+	if err != nil {
+		return nil, err
+	}
+	requestedBy, err := s.optionalUserPayload(rev.RequestedBy)
+	if err != nil {
+		return nil, err
+	}
+	return &webhooks.ReviewPayload{
+		ID:          rev.ID,
+		WorkspaceID: rev.WorkspaceID,
+		Reviewer:    reviewer,
+		Grade:       string(rev.Grade),
+		RequestedBy: requestedBy,
+		IsReplaced:  rev.IsReplaced,
+		DismissedAt: rev.DismissedAt,
+		CreatedAt:   rev.CreatedAt,
+	}, nil
+}
+
+func (s *Service) statusPayload(ctx context.Context, id string) (*webhooks.StatusPayload, error) {
+	status, err := s.statusesService.Get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	return &webhooks.StatusPayload{
+		ID:          status.ID,
+		CommitID:    status.CommitID,
+		Type:        string(status.Type),
+		Title:       status.Title,
+		Description: status.Description,
+		DetailsURL:  status.DetailsURL,
+		Timestamp:   status.Timestamp,
+	}, nil
+}
+
+func (s *Service) workspacePayload(id string) (*webhooks.WorkspacePayload, error) {
+	ws, err := s.workspaceReader.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workspace: %w", err)
+	}
+	author, err := s.userPayload(ws.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &webhooks.WorkspacePayload{
+		ID:         ws.ID,
+		Name:       ws.Name,
+		Author:     author,
+		CreatedAt:  ws.CreatedAt,
+		ArchivedAt: ws.ArchivedAt,
+	}, nil
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	db_change "getsturdy.com/api/pkg/change/db"
+	db_codebase "getsturdy.com/api/pkg/codebase/db"
+	db_comments "getsturdy.com/api/pkg/comments/db"
+	"getsturdy.com/api/pkg/outbound"
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/names"
+	db_review "getsturdy.com/api/pkg/review/db"
+	service_statuses "getsturdy.com/api/pkg/statuses/service"
+	db_user "getsturdy.com/api/pkg/users/db"
+	"getsturdy.com/api/pkg/webhooks"
+	db_webhooks "getsturdy.com/api/pkg/webhooks/db"
+	db_workspaces "getsturdy.com/api/pkg/workspaces/db"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// deliveryTimeout is the max time to wait for a webhook to respond
+	deliveryTimeout = 10 * time.Second
+	// maxResponseBodySize is the max number of bytes of the response body that are read, the body is not saved in the
+	// delivery log, as it could be used to read the responses of any url that the server can reach
+	maxResponseBodySize = 4 * 1024
+	userAgent           = "Sturdy-Webhooks/1"
+)
+
+// DeliveryQueueEntry is published to the deliveries queue for each delivery that should be attempted.
+type DeliveryQueueEntry struct {
+	DeliveryID string `json:"delivery_id"`
+}
+
+type Service struct {
+	logger       *zap.Logger
+	webhookRepo  db_webhooks.WebhookRepository
+	deliveryRepo db_webhooks.DeliveryRepository
+	queue        queue.Queue
+	client       *http.Client
+
+	codebaseRepo    db_codebase.CodebaseRepository
+	userRepo        db_user.Repository
+	changeRepo      db_change.Repository
+	commentsRepo    db_comments.Repository
+	reviewRepo      db_review.ReviewRepository
+	workspaceReader db_workspaces.WorkspaceReader
+	statusesService *service_statuses.Service
+}
+
+func New(
+	logger *zap.Logger,
+	webhookRepo db_webhooks.WebhookRepository,
+	deliveryRepo db_webhooks.DeliveryRepository,
+	queue queue.Queue,
+	codebaseRepo db_codebase.CodebaseRepository,
+	userRepo db_user.Repository,
+	changeRepo db_change.Repository,
+	commentsRepo db_comments.Repository,
+	reviewRepo db_review.ReviewRepository,
+	workspaceReader db_workspaces.WorkspaceReader,
+	statusesService *service_statuses.Service,
+) *Service {
+	return &Service{
+		logger:       logger.Named("webhooks"),
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		queue:        queue,
+		client:       newClient(),
+
+		codebaseRepo:    codebaseRepo,
+		userRepo:        userRepo,
+		changeRepo:      changeRepo,
+		commentsRepo:    commentsRepo,
+		reviewRepo:      reviewRepo,
+		workspaceReader: workspaceReader,
+		statusesService: statusesService,
+	}
+}
+
+func (s *Service) Create(ctx context.Context, webhook *webhooks.Webhook) error {
+	webhook.ID = uuid.NewString()
+	webhook.Version = webhooks.PayloadVersion
+	webhook.CreatedAt = time.Now()
+	if err := webhook.Validate(); err != nil {
+		return err
+	}
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) Update(ctx context.Context, webhook *webhooks.Webhook) error {
+	if err := webhook.Validate(); err != nil {
+		return err
+	}
+	now := time.Now()
+	webhook.UpdatedAt = &now
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) Delete(ctx context.Context, webhook *webhooks.Webhook) error {
+	now := time.Now()
+	webhook.DeletedAt = &now
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*webhooks.Webhook, error) {
+	return s.webhookRepo.Get(ctx, id)
+}
+
+func (s *Service) ListByCodebaseID(ctx context.Context, codebaseID string) ([]*webhooks.Webhook, error) {
+	return s.webhookRepo.ListByCodebaseID(ctx, codebaseID)
+}
+
+func (s *Service) GetDelivery(ctx context.Context, id string) (*webhooks.Delivery, error) {
+	return s.deliveryRepo.Get(ctx, id)
+}
+
+func (s *Service) ListDeliveries(ctx context.Context, webhookID string, limit int) ([]*webhooks.Delivery, error) {
+	return s.deliveryRepo.ListByWebhookID(ctx, webhookID, limit)
+}
+
+// Publish creates and enqueues a delivery of the event to each webhook of the codebase that is subscribed to it.
+func (s *Service) Publish(ctx context.Context, event *webhooks.Event) error {
+	hooks, err := s.webhookRepo.ListByCodebaseID(ctx, event.CodebaseID)
+	if err != nil {
+		return fmt.Errorf("failed to list webhooks: %w", err)
+	}
+
+	payloads := map[int][]byte{}
+	for _, hook := range hooks {
+		if !hook.Subscribes(event.Type) {
+			continue
+		}
+
+		payload, ok := payloads[hook.Version]
+		if !ok {
+			payload, err = s.buildPayload(ctx, event, hook.Version)
+			switch {
+			case errors.Is(err, sql.ErrNoRows):
+				// the referenced object has been deleted since the event was sent, nothing to deliver
+				s.logger.Warn("webhook event references a missing object",
+					zap.String("event_id", event.ID),
+					zap.String("event_type", string(event.Type)),
+					zap.String("reference_id", event.ReferenceID),
+				)
+				return nil
+			case err != nil:
+				return fmt.Errorf("failed to build payload: %w", err)
+			}
+			payloads[hook.Version] = payload
+		}
+
+		delivery := &webhooks.Delivery{
+			ID:         uuid.NewString(),
+			WebhookID:  hook.ID,
+			CodebaseID: hook.CodebaseID,
+			EventID:    event.ID,
+			EventType:  event.Type,
+			Payload:    string(payload),
+			Status:     webhooks.DeliveryStatusPending,
+			CreatedAt:  time.Now(),
+		}
+		if err := s.deliveryRepo.Create(ctx, delivery); err != nil {
+			return fmt.Errorf("failed to create delivery: %w", err)
+		}
+		if err := s.enqueue(ctx, delivery.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Redeliver creates and enqueues a new delivery with the same payload as the given delivery.
+func (s *Service) Redeliver(ctx context.Context, delivery *webhooks.Delivery) (*webhooks.Delivery, error) {
+	if _, err := s.webhookRepo.Get(ctx, delivery.WebhookID); err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	redelivery := &webhooks.Delivery{
+		ID:           uuid.NewString(),
+		WebhookID:    delivery.WebhookID,
+		CodebaseID:   delivery.CodebaseID,
+		EventID:      delivery.EventID,
+		EventType:    delivery.EventType,
+		Payload:      delivery.Payload,
+		Status:       webhooks.DeliveryStatusPending,
+		RedeliveryOf: &delivery.ID,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.deliveryRepo.Create(ctx, redelivery); err != nil {
+		return nil, fmt.Errorf("failed to create delivery: %w", err)
+	}
+	if err := s.enqueue(ctx, redelivery.ID); err != nil {
+		return nil, err
+	}
+	return redelivery, nil
+}
+
+// EnqueueRetries enqueues the failed deliveries that are due to be retried.
+func (s *Service) EnqueueRetries(ctx context.Context) error {
+	ids, err := s.deliveryRepo.ClaimDue(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+	for _, id := range ids {
+		if err := s.enqueue(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Service) enqueue(ctx context.Context, deliveryID string) error {
+	if err := s.queue.Publish(ctx, names.WebhooksDeliveries, &DeliveryQueueEntry{DeliveryID: deliveryID}); err != nil {
+		return fmt.Errorf("failed to enqueue delivery: %w", err)
+	}
+	return nil
+}
+
+// Deliver attempts to send the delivery to its webhook, and saves the result. Failed attempts are
+// scheduled to be retried, until MaxDeliveryAttempts is reached.
+func (s *Service) Deliver(ctx context.Context, deliveryID string) error {
+	delivery, err := s.deliveryRepo.Get(ctx, deliveryID)
+	if err != nil {
+		return fmt.Errorf("failed to get delivery: %w", err)
+	}
+
+	if delivery.Status != webhooks.DeliveryStatusPending || delivery.NextAttemptAt != nil {
+		// already delivered, or waiting to be retried
+		return nil
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+
+	hook, err := s.webhookRepo.Get(ctx, delivery.WebhookID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return s.fail(ctx, delivery, "the webhook has been deleted")
+	case err != nil:
+		return fmt.Errorf("failed to get webhook: %w", err)
+	case !hook.Active:
+		return s.fail(ctx, delivery, "the webhook is inactive")
+	}
+
+	statusCode, attemptErr := s.post(ctx, hook, delivery)
+	durationMs := int(time.Since(now).Milliseconds())
+	delivery.DurationMs = &durationMs
+	delivery.ResponseStatusCode = statusCode
+	delivery.Error = nil
+
+	switch {
+	case attemptErr == nil:
+		delivery.Status = webhooks.DeliveryStatusSucceeded
+	case delivery.Attempts >= webhooks.MaxDeliveryAttempts:
+		delivery.Status = webhooks.DeliveryStatusFailed
+	default:
+		next := now.Add(webhooks.RetryDelay(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+
+	if attemptErr != nil {
+		msg := attemptErr.Error()
+		delivery.Error = &msg
+	}
+
+	if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to update delivery: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) fail(ctx context.Context, delivery *webhooks.Delivery, reason string) error {
+	delivery.Status = webhooks.DeliveryStatusFailed
+	delivery.Error = &reason
+	if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to update delivery: %w", err)
+	}
+	return nil
+}
+
+// newClient returns the client that deliveries are sent with. It can only connect to public addresses, and doesn't
+// follow redirects, the webhook url should be updated instead.
+func newClient() *http.Client {
+	client := outbound.NewClient(deliveryTimeout)
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	return client
+}
+
+func (s *Service) post(ctx context.Context, hook *webhooks.Webhook, delivery *webhooks.Delivery) (*int, error) {
+	payload := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(webhooks.HeaderEvent, string(delivery.EventType))
+	req.Header.Set(webhooks.HeaderDelivery, delivery.ID)
+	req.Header.Set(webhooks.HeaderWebhook, hook.ID)
+	req.Header.Set(webhooks.HeaderSignature, webhooks.Sign(hook.Secret, payload))
+
+	resp, err := s.client.Do(req)
+	switch {
+	case err == nil:
+	case errors.Is(err, outbound.ErrForbiddenAddress):
+		return nil, errors.New("the url resolves to an internal address")
+	default:
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// the body is drained, so that the connection can be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBodySize))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &resp.StatusCode, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return &resp.StatusCode, nil
+}
@@ -0,0 +1,340 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"getsturdy.com/api/pkg/codebase"
+	db_codebase "getsturdy.com/api/pkg/codebase/db"
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/names"
+	"getsturdy.com/api/pkg/review"
+	db_review "getsturdy.com/api/pkg/review/db"
+	db_user "getsturdy.com/api/pkg/users/db"
+	"getsturdy.com/api/pkg/webhooks"
+	db_webhooks "getsturdy.com/api/pkg/webhooks/db"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var _ queue.Queue = &recordingQueue{}
+
+type recordingQueue struct {
+	published []string
+}
+
+func (q *recordingQueue) Publish(_ context.Context, name names.IncompleteQueueName, msg interface{}) error {
+	if name == names.WebhooksDeliveries {
+		q.published = append(q.published, msg.(*DeliveryQueueEntry).DeliveryID)
+	}
+	return nil
+}
+
+func (q *recordingQueue) Subscribe(context.Context, names.IncompleteQueueName, chan<- queue.Message) error {
+	return nil
+}
+
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+type testReceiver struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	statusCode int
+	received   []receivedRequest
+}
+
+func newTestReceiver(t *testing.T) *testReceiver {
+	r := &testReceiver{statusCode: http.StatusOK}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.received = append(r.received, receivedRequest{header: req.Header, body: body})
+		w.WriteHeader(r.statusCode)
+		_, _ = w.Write([]byte("thanks"))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// client returns a client that connects to the receiver, whatever the host of the url is. The receiver is on a
+// loopback address, that the client of the service can't connect to.
+func (r *testReceiver) client() *http.Client {
+	addr := r.Listener.Addr().String()
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, network, addr)
+			},
+		},
+	}
+}
+
+func (r *testReceiver) setStatusCode(code int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statusCode = code
+}
+
+type testEnv struct {
+	service      *Service
+	queue        *recordingQueue
+	deliveryRepo db_webhooks.DeliveryRepository
+	receiver     *testReceiver
+	webhook      *webhooks.Webhook
+	review       *review.Review
+}
+
+func setup(t *testing.T) *testEnv {
+	ctx := context.Background()
+
+	codebaseRepo := db_codebase.NewMemory()
+	require.NoError(t, codebaseRepo.Create(codebase.Codebase{ID: "codebase", ShortCodebaseID: "short", Name: "my-codebase"}))
+
+	reviewRepo := db_review.NewMemory()
+	rev := &review.Review{
+		ID:          "review",
+		UserID:      "reviewer",
+		CodebaseID:  "codebase",
+		WorkspaceID: "workspace",
+		Grade:       review.ReviewGradeApprove,
+		CreatedAt:   time.Now(),
+	}
+	require.NoError(t, reviewRepo.Create(ctx, *rev))
+
+	q := &recordingQueue{}
+	deliveryRepo := db_webhooks.NewInMemoryDeliveryRepository()
+	svc := New(
+		zap.NewNop(),
+		db_webhooks.NewInMemoryWebhookRepository(),
+		deliveryRepo,
+		q,
+		codebaseRepo,
+		db_user.NewMemory(),
+		nil,
+		nil,
+		reviewRepo,
+		nil,
+		nil,
+	)
+
+	receiver := newTestReceiver(t)
+	svc.client = receiver.client()
+
+	webhook := &webhooks.Webhook{
+		CodebaseID: "codebase",
+		URL:        "http://hooks.example.com/sturdy",
+		Secret:     "supersecret",
+		Events:     pq.StringArray{string(webhooks.EventReviewUpdated)},
+		Active:     true,
+		CreatedBy:  "user",
+	}
+	require.NoError(t, svc.Create(ctx, webhook))
+
+	// not subscribed to the event
+	require.NoError(t, svc.Create(ctx, &webhooks.Webhook{
+		CodebaseID: "codebase",
+		URL:        "http://hooks.example.com/sturdy",
+		Secret:     "supersecret",
+		Events:     pq.StringArray{string(webhooks.EventChangeLanded)},
+		Active:     true,
+		CreatedBy:  "user",
+	}))
+
+	// inactive
+	require.NoError(t, svc.Create(ctx, &webhooks.Webhook{
+		CodebaseID: "codebase",
+		URL:        "http://hooks.example.com/sturdy",
+		Secret:     "supersecret",
+		Events:     pq.StringArray{string(webhooks.EventReviewUpdated)},
+		Active:     false,
+		CreatedBy:  "user",
+	}))
+
+	return &testEnv{
+		service:      svc,
+		queue:        q,
+		deliveryRepo: deliveryRepo,
+		receiver:     receiver,
+		webhook:      webhook,
+		review:       rev,
+	}
+}
+
+func (env *testEnv) publish(t *testing.T) *webhooks.Delivery {
+	actorID := "actor"
+	require.NoError(t, env.service.Publish(context.Background(), &webhooks.Event{
+		ID:          "event",
+		CodebaseID:  "codebase",
+		Type:        webhooks.EventReviewUpdated,
+		ReferenceID: env.review.ID,
+		ActorID:     &actorID,
+		CreatedAt:   time.Now(),
+	}))
+	require.Len(t, env.queue.published, 1)
+
+	delivery, err := env.service.GetDelivery(context.Background(), env.queue.published[0])
+	require.NoError(t, err)
+	return delivery
+}
+
+func TestPublishAndDeliver(t *testing.T) {
+	ctx := context.Background()
+	env := setup(t)
+
+	delivery := env.publish(t)
+	assert.Equal(t, env.webhook.ID, delivery.WebhookID)
+	assert.Equal(t, webhooks.DeliveryStatusPending, delivery.Status)
+
+	require.NoError(t, env.service.Deliver(ctx, delivery.ID))
+
+	require.Len(t, env.receiver.received, 1)
+	req := env.receiver.received[0]
+	assert.Equal(t, "application/json", req.header.Get("Content-Type"))
+	assert.Equal(t, string(webhooks.EventReviewUpdated), req.header.Get(webhooks.HeaderEvent))
+	assert.Equal(t, delivery.ID, req.header.Get(webhooks.HeaderDelivery))
+	assert.Equal(t, env.webhook.ID, req.header.Get(webhooks.HeaderWebhook))
+	assert.Equal(t, webhooks.Sign("supersecret", req.body), req.header.Get(webhooks.HeaderSignature))
+
+	var payload webhooks.Payload
+	require.NoError(t, json.Unmarshal(req.body, &payload))
+	assert.Equal(t, "event", payload.ID)
+	assert.Equal(t, webhooks.EventReviewUpdated, payload.Type)
+	assert.Equal(t, webhooks.PayloadVersion, payload.Version)
+	assert.Equal(t, &webhooks.CodebasePayload{ID: "codebase", ShortID: "short", Name: "my-codebase"}, payload.Codebase)
+	assert.Equal(t, "actor", payload.Actor.ID)
+	if assert.NotNil(t, payload.Review) {
+		assert.Equal(t, "review", payload.Review.ID)
+		assert.Equal(t, "Approve", payload.Review.Grade)
+		assert.Equal(t, "reviewer", payload.Review.Reviewer.ID)
+	}
+
+	delivery, err := env.service.GetDelivery(ctx, delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.DeliveryStatusSucceeded, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, *delivery.ResponseStatusCode)
+	assert.Nil(t, delivery.Error)
+
+	// delivering again is a noop
+	require.NoError(t, env.service.Deliver(ctx, delivery.ID))
+	assert.Len(t, env.receiver.received, 1)
+}
+
+func TestDeliver_retries(t *testing.T) {
+	ctx := context.Background()
+	env := setup(t)
+	env.receiver.setStatusCode(http.StatusInternalServerError)
+
+	delivery := env.publish(t)
+	for attempt := 1; attempt <= webhooks.MaxDeliveryAttempts; attempt++ {
+		require.NoError(t, env.service.Deliver(ctx, delivery.ID))
+
+		var err error
+		delivery, err = env.service.GetDelivery(ctx, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, attempt, delivery.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, *delivery.ResponseStatusCode)
+		assert.NotNil(t, delivery.Error)
+
+		if attempt == webhooks.MaxDeliveryAttempts {
+			break
+		}
+
+		assert.Equal(t, webhooks.DeliveryStatusPending, delivery.Status)
+		if assert.NotNil(t, delivery.NextAttemptAt) {
+			assert.WithinDuration(t, *delivery.LastAttemptAt, delivery.NextAttemptAt.Add(-webhooks.RetryDelay(attempt)), time.Second)
+		}
+
+		// not retried before it's due
+		require.NoError(t, env.service.Deliver(ctx, delivery.ID))
+		assert.Len(t, env.receiver.received, attempt)
+
+		// make the retry due
+		past := time.Now().Add(-time.Second)
+		delivery.NextAttemptAt = &past
+		require.NoError(t, env.deliveryRepo.Update(ctx, delivery))
+		env.queue.published = nil
+		require.NoError(t, env.service.EnqueueRetries(ctx))
+		assert.Equal(t, []string{delivery.ID}, env.queue.published)
+	}
+
+	assert.Equal(t, webhooks.DeliveryStatusFailed, delivery.Status)
+	assert.Nil(t, delivery.NextAttemptAt)
+	assert.Len(t, env.receiver.received, webhooks.MaxDeliveryAttempts)
+}
+
+func TestRedeliver(t *testing.T) {
+	ctx := context.Background()
+	env := setup(t)
+	env.receiver.setStatusCode(http.StatusBadRequest)
+
+	delivery := env.publish(t)
+	require.NoError(t, env.service.Deliver(ctx, delivery.ID))
+
+	env.receiver.setStatusCode(http.StatusOK)
+	redelivery, err := env.service.Redeliver(ctx, delivery)
+	require.NoError(t, err)
+	assert.Equal(t, &delivery.ID, redelivery.RedeliveryOf)
+	assert.Equal(t, delivery.Payload, redelivery.Payload)
+	assert.Equal(t, delivery.EventID, redelivery.EventID)
+	assert.Equal(t, []string{delivery.ID, redelivery.ID}, env.queue.published)
+
+	require.NoError(t, env.service.Deliver(ctx, redelivery.ID))
+	redelivery, err = env.service.GetDelivery(ctx, redelivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.DeliveryStatusSucceeded, redelivery.Status)
+
+	require.Len(t, env.receiver.received, 2)
+	assert.Equal(t, env.receiver.received[0].body, env.receiver.received[1].body)
+}
+
+func TestDeliver_deletedWebhook(t *testing.T) {
+	ctx := context.Background()
+	env := setup(t)
+
+	delivery := env.publish(t)
+	require.NoError(t, env.service.Delete(ctx, env.webhook))
+	require.NoError(t, env.service.Deliver(ctx, delivery.ID))
+
+	delivery, err := env.service.GetDelivery(ctx, delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.DeliveryStatusFailed, delivery.Status)
+	assert.Empty(t, env.receiver.received)
+}
+
+func TestDeliver_internalAddress(t *testing.T) {
+	ctx := context.Background()
+	env := setup(t)
+	env.service.client = newClient()
+
+	// the url is validated when the webhook is saved, but hostnames can resolve to internal addresses
+	env.webhook.URL = env.receiver.URL
+	require.NoError(t, env.service.webhookRepo.Update(ctx, env.webhook))
+
+	delivery := env.publish(t)
+	require.NoError(t, env.service.Deliver(ctx, delivery.ID))
+
+	delivery, err := env.service.GetDelivery(ctx, delivery.ID)
+	require.NoError(t, err)
+	assert.Equal(t, webhooks.DeliveryStatusPending, delivery.Status)
+	assert.Nil(t, delivery.ResponseStatusCode)
+	if assert.NotNil(t, delivery.Error) {
+		assert.Equal(t, "the url resolves to an internal address", *delivery.Error)
+	}
+	assert.Empty(t, env.receiver.received)
+}
@@ -0,0 +1,173 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/outbound"
+
+	"github.com/lib/pq"
+)
+
+type EventType string
+
+const (
+	EventChangeLanded      EventType = "change.landed"
+	EventCommentCreated    EventType = "comment.created"
+	EventReviewUpdated     EventType = "review.updated"
+	EventReviewRequested   EventType = "review.requested"
+	EventStatusUpdated     EventType = "status.updated"
+	EventWorkspaceCreated  EventType = "workspace.created"
+	EventWorkspaceArchived EventType = "workspace.archived"
+)
+
+var ValidEventType = map[EventType]bool{
+	EventChangeLanded:      true,
+	EventCommentCreated:    true,
+	EventReviewUpdated:     true,
+	EventReviewRequested:   true,
+	EventStatusUpdated:     true,
+	EventWorkspaceCreated:  true,
+	EventWorkspaceArchived: true,
+}
+
+// Event is something that happened in a codebase, that webhooks can subscribe to.
+type Event struct {
+	ID         string    `json:"id"`
+	CodebaseID string    `json:"codebase_id"`
+	Type       EventType `json:"type"`
+	// ReferenceID is the ID of the object that the event is about, for example the change that was landed.
+	ReferenceID string `json:"reference_id"`
+	// ActorID is the ID of the user that caused the event, if any.
+	ActorID   *string   `json:"actor_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const (
+	// PayloadVersion is the latest version of the payloads, and the version used by new webhooks.
+	PayloadVersion = 1
+)
+
+// Headers sent with each delivery.
+const (
+	HeaderEvent     = "X-Sturdy-Event"
+	HeaderDelivery  = "X-Sturdy-Delivery"
+	HeaderWebhook   = "X-Sturdy-Webhook"
+	HeaderSignature = "X-Sturdy-Signature-256"
+)
+
+var (
+	ErrInvalidURL    = errors.New("invalid url")
+	ErrInvalidEvents = errors.New("invalid events")
+	ErrInvalidSecret = errors.New("invalid secret")
+)
+
+type Webhook struct {
+	ID         string `db:"id"`
+	CodebaseID string `db:"codebase_id"`
+	URL        string `db:"url"`
+	// Secret is used to sign the payloads of the deliveries.
+	Secret string `db:"secret"`
+	// Events are the event types that the webhook is subscribed to.
+	Events    pq.StringArray `db:"events"`
+	Version   int            `db:"version"`
+	Active    bool           `db:"active"`
+	CreatedBy string         `db:"created_by"`
+	CreatedAt time.Time      `db:"created_at"`
+	UpdatedAt *time.Time     `db:"updated_at"`
+	DeletedAt *time.Time     `db:"deleted_at"`
+}
+
+// Subscribes returns true if the webhook should be delivered events of this type.
+func (w *Webhook) Subscribes(eventType EventType) bool {
+	if !w.Active || w.DeletedAt != nil {
+		return false
+	}
+	for _, e := range w.Events {
+		if EventType(e) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error if the webhook can not be saved. The url must not point to an internal address, but as
+// hostnames can resolve to anything, this is also checked when the deliveries are sent.
+func (w *Webhook) Validate() error {
+	switch err := outbound.ValidateURL(w.URL); {
+	case err == nil:
+	case errors.Is(err, outbound.ErrForbiddenAddress):
+		return fmt.Errorf("%w: %s", ErrInvalidURL, err)
+	default:
+		return ErrInvalidURL
+	}
+	if len(w.Secret) < 8 {
+		return ErrInvalidSecret
+	}
+	if len(w.Events) == 0 {
+		return ErrInvalidEvents
+	}
+	seen := make(map[string]bool, len(w.Events))
+	for _, e := range w.Events {
+		if !ValidEventType[EventType(e)] || seen[e] {
+			return ErrInvalidEvents
+		}
+		seen[e] = true
+	}
+	return nil
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryStatusPending   DeliveryStatus = "pending"
+	DeliveryStatusSucceeded DeliveryStatus = "succeeded"
+	DeliveryStatusFailed    DeliveryStatus = "failed"
+)
+
+// MaxDeliveryAttempts is the number of times a delivery is attempted before it's marked as failed.
+const MaxDeliveryAttempts = 5
+
+// Delivery is a payload sent, or to be sent, to a webhook.
+type Delivery struct {
+	ID         string         `db:"id"`
+	WebhookID  string         `db:"webhook_id"`
+	CodebaseID string         `db:"codebase_id"`
+	EventID    string         `db:"event_id"`
+	EventType  EventType      `db:"event_type"`
+	Payload    string         `db:"payload"`
+	Status     DeliveryStatus `db:"status"`
+	Attempts   int            `db:"attempts"`
+
+	// The result of the last attempt
+	ResponseStatusCode *int    `db:"response_status_code"`
+	Error              *string `db:"error"`
+	DurationMs         *int    `db:"duration_ms"`
+
+	// RedeliveryOf is the ID of the delivery that this delivery is a redelivery of.
+	RedeliveryOf  *string    `db:"redelivery_of"`
+	CreatedAt     time.Time  `db:"created_at"`
+	LastAttemptAt *time.Time `db:"last_attempt_at"`
+	// NextAttemptAt is set for pending deliveries that are waiting to be retried.
+	NextAttemptAt *time.Time `db:"next_attempt_at"`
+}
+
+// RetryDelay returns how long to wait before the next attempt, after the given number of failed attempts.
+// The delay is 1 minute after the first attempt, and then grows by 4x for each attempt.
+func RetryDelay(attempts int) time.Duration {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return time.Minute << (2 * (attempts - 1))
+}
+
+// Sign returns the signature of the payload, to be sent in the HeaderSignature header.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,105 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook_Subscribes(t *testing.T) {
+	now := time.Now()
+	cases := []struct {
+		name      string
+		webhook   *Webhook
+		eventType EventType
+		expected  bool
+	}{
+		{
+			name:      "subscribed",
+			webhook:   &Webhook{Active: true, Events: pq.StringArray{string(EventChangeLanded)}},
+			eventType: EventChangeLanded,
+			expected:  true,
+		},
+		{
+			name:      "not subscribed",
+			webhook:   &Webhook{Active: true, Events: pq.StringArray{string(EventChangeLanded)}},
+			eventType: EventCommentCreated,
+		},
+		{
+			name:      "inactive",
+			webhook:   &Webhook{Active: false, Events: pq.StringArray{string(EventChangeLanded)}},
+			eventType: EventChangeLanded,
+		},
+		{
+			name:      "deleted",
+			webhook:   &Webhook{Active: true, DeletedAt: &now, Events: pq.StringArray{string(EventChangeLanded)}},
+			eventType: EventChangeLanded,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.webhook.Subscribes(tc.eventType))
+		})
+	}
+}
+
+func TestWebhook_Validate(t *testing.T) {
+	valid := func() *Webhook {
+		return &Webhook{
+			URL:    "https://example.com/hooks",
+			Secret: "supersecret",
+			Events: pq.StringArray{string(EventChangeLanded), string(EventStatusUpdated)},
+		}
+	}
+
+	assert.NoError(t, valid().Validate())
+
+	w := valid()
+	w.URL = "ftp://example.com"
+	assert.ErrorIs(t, w.Validate(), ErrInvalidURL)
+
+	w = valid()
+	w.URL = "https://"
+	assert.ErrorIs(t, w.Validate(), ErrInvalidURL)
+
+	w = valid()
+	w.URL = "http://169.254.169.254/latest/meta-data"
+	assert.ErrorIs(t, w.Validate(), ErrInvalidURL)
+
+	w = valid()
+	w.URL = "http://localhost:3000/v3/users"
+	assert.ErrorIs(t, w.Validate(), ErrInvalidURL)
+
+	w = valid()
+	w.Secret = "short"
+	assert.ErrorIs(t, w.Validate(), ErrInvalidSecret)
+
+	w = valid()
+	w.Events = nil
+	assert.ErrorIs(t, w.Validate(), ErrInvalidEvents)
+
+	w = valid()
+	w.Events = pq.StringArray{"change.unknown"}
+	assert.ErrorIs(t, w.Validate(), ErrInvalidEvents)
+
+	w = valid()
+	w.Events = pq.StringArray{string(EventChangeLanded), string(EventChangeLanded)}
+	assert.ErrorIs(t, w.Validate(), ErrInvalidEvents)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Minute, RetryDelay(1))
+	assert.Equal(t, 4*time.Minute, RetryDelay(2))
+	assert.Equal(t, 16*time.Minute, RetryDelay(3))
+	assert.Equal(t, 64*time.Minute, RetryDelay(4))
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{"id":"1"}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t,
+		"sha256=6146142a2ce0159e84c0767881e4ec80bc397da62526e7d19f70795eb79460c0",
+		Sign("secret", []byte(`{"id":"1"}`)),
+	)
+}
@@ -0,0 +1,74 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/names"
+	service_webhooks "getsturdy.com/api/pkg/webhooks/service"
+
+	"go.uber.org/zap"
+)
+
+// DeliveriesQueue attempts the deliveries that are enqueued by the service.
+type DeliveriesQueue struct {
+	logger  *zap.Logger
+	queue   queue.Queue
+	service *service_webhooks.Service
+}
+
+func NewDeliveriesQueue(
+	logger *zap.Logger,
+	queue queue.Queue,
+	service *service_webhooks.Service,
+) *DeliveriesQueue {
+	return &DeliveriesQueue{
+		logger:  logger.Named("webhooksDeliveriesQueue"),
+		queue:   queue,
+		service: service,
+	}
+}
+
+func (q *DeliveriesQueue) Start(ctx context.Context) error {
+	messages := make(chan queue.Message)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				q.logger.Error("panic in runner", zap.String("panic", fmt.Sprintf("%v", rec)))
+			}
+		}()
+
+		for msg := range messages {
+			t0 := time.Now()
+
+			m := &service_webhooks.DeliveryQueueEntry{}
+			if err := msg.As(m); err != nil {
+				q.logger.Error("failed to decode message", zap.Error(err))
+				continue
+			}
+			logger := q.logger.With(zap.String("delivery_id", m.DeliveryID))
+
+			if err := q.service.Deliver(context.Background(), m.DeliveryID); err != nil {
+				logger.Error("failed to deliver", zap.Error(err))
+				continue
+			}
+
+			if err := msg.Ack(); err != nil {
+				logger.Error("failed to ack message", zap.Error(err))
+				continue
+			}
+
+			logger.Info("delivery attempted", zap.Duration("duration", time.Since(t0)))
+		}
+	}()
+
+	q.logger.Info("starting queue", zap.Stringer("queue_name", names.WebhooksDeliveries))
+	if err := q.queue.Subscribe(ctx, names.WebhooksDeliveries, messages); err != nil {
+		return fmt.Errorf("could not subscribe to queue: %w", err)
+	}
+	q.logger.Info("queue stoped", zap.Stringer("queue_name", names.WebhooksDeliveries))
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"getsturdy.com/api/pkg/queue"
+	"getsturdy.com/api/pkg/queue/names"
+	"getsturdy.com/api/pkg/webhooks"
+	service_webhooks "getsturdy.com/api/pkg/webhooks/service"
+
+	"go.uber.org/zap"
+)
+
+// EventsQueue consumes the events sent by sender.Sender, and creates deliveries for the webhooks that
+// are subscribed to them.
+type EventsQueue struct {
+	logger  *zap.Logger
+	queue   queue.Queue
+	service *service_webhooks.Service
+}
+
+func NewEventsQueue(
+	logger *zap.Logger,
+	queue queue.Queue,
+	service *service_webhooks.Service,
+) *EventsQueue {
+	return &EventsQueue{
+		logger:  logger.Named("webhooksEventsQueue"),
+		queue:   queue,
+		service: service,
+	}
+}
+
+func (q *EventsQueue) Start(ctx context.Context) error {
+	messages := make(chan queue.Message)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				q.logger.Error("panic in runner", zap.String("panic", fmt.Sprintf("%v", rec)))
+			}
+		}()
+
+		for msg := range messages {
+			t0 := time.Now()
+
+			event := &webhooks.Event{}
+			if err := msg.As(event); err != nil {
+				q.logger.Error("failed to decode message", zap.Error(err))
+				continue
+			}
+			logger := q.logger.With(zap.String("event_id", event.ID), zap.String("event_type", string(event.Type)))
+
+			if err := q.service.Publish(context.Background(), event); err != nil {
+				logger.Error("failed to publish event", zap.Error(err))
+				continue
+			}
+
+			if err := msg.Ack(); err != nil {
+				logger.Error("failed to ack message", zap.Error(err))
+				continue
+			}
+
+			logger.Info("event published", zap.Duration("duration", time.Since(t0)))
+		}
+	}()
+
+	q.logger.Info("starting queue", zap.Stringer("queue_name", names.WebhooksEvents))
+	if err := q.queue.Subscribe(ctx, names.WebhooksEvents, messages); err != nil {
+		return fmt.Errorf("could not subscribe to queue: %w", err)
+	}
+	q.logger.Info("queue stoped", zap.Stringer("queue_name", names.WebhooksEvents))
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	service_webhooks "getsturdy.com/api/pkg/webhooks/service"
+
+	"go.uber.org/zap"
+)
+
+var (
+	retryEvery = 30 * time.Second
+)
+
+// Scheduler periodically enqueues the failed deliveries that are due to be retried.
+type Scheduler struct {
+	logger  *zap.Logger
+	service *service_webhooks.Service
+}
+
+func NewScheduler(
+	logger *zap.Logger,
+	service *service_webhooks.Service,
+) *Scheduler {
+	return &Scheduler{
+		logger:  logger.Named("webhooksScheduler"),
+		service: service,
+	}
+}
+
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.logger.Info("starting")
+
+	ticker := time.NewTicker(retryEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.service.EnqueueRetries(ctx); err != nil {
+				s.logger.Error("failed to enqueue retries", zap.Error(err))
+			}
+		case <-ctx.Done():
+			s.logger.Info("stopping")
+			return nil
+		}
+	}
+}
@@ -15,7 +15,8 @@ import (
 func (r *WorkspaceRootResolver) CreateWorkspace(ctx context.Context, args resolvers.CreateWorkspaceArgs) (resolvers.WorkspaceResolver, error) {
 	codebaseID := string(args.Input.CodebaseID)
 
-	userID, err := auth.UserID(ctx)
+	// workspaces created with a service token belong to the user that created the token
+	userID, err := auth.ActingUserID(ctx)
 	if err != nil {
 		return nil, gqlerrors.Error(err)
 	}
@@ -25,7 +26,7 @@ func (r *WorkspaceRootResolver) CreateWorkspace(ctx context.Context, args resolv
 		return nil, gqlerrors.Error(err)
 	}
 
-	if err := r.authService.CanWrite(ctx, cb); err != nil {
+	if err := r.authService.CanCreateWorkspace(ctx, cb); err != nil {
 		return nil, gqlerrors.Error(err)
 	}
 
@@ -72,5 +73,9 @@ func (r *WorkspaceRootResolver) CreateWorkspace(ctx context.Context, args resolv
 		return nil, err
 	}
 
+	if err := r.authService.WorkspaceCreated(ctx, ws); err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
 	return r.Workspace(ctx, resolvers.WorkspaceArgs{ID: graphql.ID(ws.ID)})
 }
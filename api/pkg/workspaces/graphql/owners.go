@@ -0,0 +1,47 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/graph-gophers/graphql-go"
+
+	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
+	"getsturdy.com/api/pkg/graphql/resolvers"
+	"getsturdy.com/api/pkg/review/owners"
+)
+
+func (r *WorkspaceResolver) RequiredOwners(ctx context.Context) ([]resolvers.OwnerSetResolver, error) {
+	sets, err := r.root.ownersService.Required(ctx, r.w)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+	res := make([]resolvers.OwnerSetResolver, 0, len(sets))
+	for _, set := range sets {
+		if set.IsApproved {
+			continue
+		}
+		res = append(res, &ownerSetResolver{set: set, root: r.root})
+	}
+	return res, nil
+}
+
+type ownerSetResolver struct {
+	set  *owners.Set
+	root *WorkspaceRootResolver
+}
+
+func (r *ownerSetResolver) ID() graphql.ID {
+	return graphql.ID(r.set.ID)
+}
+
+func (r *ownerSetResolver) Owners(ctx context.Context) ([]resolvers.AuthorResolver, error) {
+	res := make([]resolvers.AuthorResolver, 0, len(r.set.UserIDs))
+	for _, userID := range r.set.UserIDs {
+		author, err := r.root.authorResolver.Author(ctx, graphql.ID(userID))
+		if err != nil {
+			return nil, gqlerrors.Error(err)
+		}
+		res = append(res, author)
+	}
+	return res, nil
+}
@@ -140,10 +140,40 @@ func (r *WorkspaceResolver) CommentsCount(ctx context.Context) (int32, error) {
 	return c, nil
 }
 
+func (r *WorkspaceResolver) UnresolvedCommentsCount(ctx context.Context) (int32, error) {
+	c, err := r.root.commentResolver.InternalCountUnresolvedByWorkspaceID(ctx, r.w.ID)
+	if err != nil {
+		return 0, gqlerrors.Error(err)
+	}
+	return c, nil
+}
+
 func (r *WorkspaceResolver) DiffsCount(ctx context.Context) *int32 {
 	return r.w.DiffsCount
 }
 
+func (r *WorkspaceResolver) Diffs(ctx context.Context) ([]resolvers.FileDiffResolver, error) {
+	allower, err := r.root.authService.GetAllower(ctx, r.w)
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	diffs, _, err := r.root.workspaceService.Diffs(ctx, r.w.ID, service_workspace.WithAllower(allower))
+	if err != nil {
+		return nil, gqlerrors.Error(err)
+	}
+
+	res := make([]resolvers.FileDiffResolver, len(diffs))
+	for k := range diffs {
+		res[k] = r.root.fileDiffRootResolver.InternalWorkspaceFileDiff(r.w.ID, &diffs[k])
+	}
+	return res, nil
+}
+
+func (r *WorkspaceResolver) GuestInvites(ctx context.Context) ([]resolvers.GuestInviteResolver, error) {
+	return r.root.guestInvitesRootResolver.InternalListByWorkspaceID(ctx, r.w.ID)
+}
+
 func (r *WorkspaceResolver) Comments() ([]resolvers.TopCommentResolver, error) {
 	comments, err := r.root.commentResolver.InternalWorkspaceComments(r.w)
 	switch {
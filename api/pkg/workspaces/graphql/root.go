@@ -11,6 +11,7 @@ import (
 	"getsturdy.com/api/pkg/events"
 	gqlerrors "getsturdy.com/api/pkg/graphql/errors"
 	"getsturdy.com/api/pkg/graphql/resolvers"
+	service_owners "getsturdy.com/api/pkg/review/owners/service"
 	db_snapshots "getsturdy.com/api/pkg/snapshots/db"
 	"getsturdy.com/api/pkg/snapshots/snapshotter"
 	service_suggestions "getsturdy.com/api/pkg/suggestions/service"
@@ -44,11 +45,14 @@ type WorkspaceRootResolver struct {
 	suggestionRootResolver        resolvers.SuggestionRootResolver
 	statusRootResolver            resolvers.StatusesRootResolver
 	workspaceWatcherRootResolver  resolvers.WorkspaceWatcherRootResolver
+	fileDiffRootResolver          resolvers.FileDiffRootResolver
+	guestInvitesRootResolver      resolvers.GuestInvitesRootResolver
 
 	suggestionsService *service_suggestions.Service
 	workspaceService   service_workspace.Service
 	authService        *service_auth.Service
 	changeService      *service_change.Service
+	ownersService      *service_owners.Service
 
 	logger           *zap.Logger
 	viewEvents       events.EventReadWriter
@@ -77,11 +81,14 @@ func NewResolver(
 	suggestionRootResolver resolvers.SuggestionRootResolver,
 	statusRootResolver resolvers.StatusesRootResolver,
 	workspaceWatcherRootResolver resolvers.WorkspaceWatcherRootResolver,
+	fileDiffRootResolver resolvers.FileDiffRootResolver,
+	guestInvitesRootResolver resolvers.GuestInvitesRootResolver,
 
 	suggestionsService *service_suggestions.Service,
 	workspaceService service_workspace.Service,
 	authService *service_auth.Service,
 	changeService *service_change.Service,
+	ownersService *service_owners.Service,
 
 	logger *zap.Logger,
 	viewEventsWriter events.EventReadWriter,
@@ -109,11 +116,14 @@ func NewResolver(
 		suggestionRootResolver:        suggestionRootResolver,
 		statusRootResolver:            statusRootResolver,
 		workspaceWatcherRootResolver:  workspaceWatcherRootResolver,
+		fileDiffRootResolver:          fileDiffRootResolver,
+		guestInvitesRootResolver:      guestInvitesRootResolver,
 
 		suggestionsService: suggestionsService,
 		workspaceService:   workspaceService,
 		authService:        authService,
 		changeService:      changeService,
+		ownersService:      ownersService,
 
 		logger:           logger.Named("workspaceRootResolver"),
 		viewEvents:       viewEventsWriter,
@@ -25,6 +25,8 @@ import (
 	"getsturdy.com/api/pkg/unidiff/lfs"
 	user_db "getsturdy.com/api/pkg/users/db"
 	vcs_view "getsturdy.com/api/pkg/view/vcs"
+	"getsturdy.com/api/pkg/webhooks"
+	webhooks_sender "getsturdy.com/api/pkg/webhooks/sender"
 	"getsturdy.com/api/pkg/workspaces"
 	"getsturdy.com/api/pkg/workspaces/activity"
 	"getsturdy.com/api/pkg/workspaces/activity/sender"
@@ -78,6 +80,7 @@ type WorkspaceService struct {
 
 	activitySender   sender.ActivitySender
 	eventsSender     events.EventSender
+	webhooksSender   webhooks_sender.Sender
 	snapshotterQueue worker_snapshots.Queue
 	executorProvider executor.Provider
 	snap             snapshotter.Snapshotter
@@ -100,6 +103,7 @@ func New(
 	activitySender sender.ActivitySender,
 	executorProvider executor.Provider,
 	eventsSender events.EventSender,
+	webhooksSender webhooks_sender.Sender,
 	snapshotterQueue worker_snapshots.Queue,
 	snap snapshotter.Snapshotter,
 	buildQueue *workers_ci.BuildQueue,
@@ -120,6 +124,7 @@ func New(
 		activitySender:   activitySender,
 		executorProvider: executorProvider,
 		eventsSender:     eventsSender,
+		webhooksSender:   webhooksSender,
 		snapshotterQueue: snapshotterQueue,
 		snap:             snap,
 		buildQueue:       buildQueue,
@@ -429,6 +434,10 @@ func (s *WorkspaceService) Create(ctx context.Context, req CreateWorkspaceReques
 		analytics.Property("name", ws.Name),
 	)
 
+	if err := s.webhooksSender.Codebase(ctx, ws.CodebaseID, webhooks.EventWorkspaceCreated, ws.ID); err != nil {
+		s.logger.Error("failed to send webhook event", zap.Error(err))
+	}
+
 	return &ws, nil
 }
 
@@ -649,6 +658,10 @@ func (s *WorkspaceService) LandChange(ctx context.Context, ws *workspaces.Worksp
 		s.logger.Error("failed to send workspace event", zap.Error(err))
 	}
 
+	if err := s.webhooksSender.Codebase(ctx, ws.CodebaseID, webhooks.EventChangeLanded, string(change.ID)); err != nil {
+		s.logger.Error("failed to send webhook event", zap.Error(err))
+	}
+
 	if err := s.buildQueue.EnqueueChange(ctx, change); err != nil {
 		s.logger.Error("failed to enqueue change", zap.Error(err))
 	}
@@ -844,6 +857,9 @@ func (s *WorkspaceService) Archive(ctx context.Context, ws *workspaces.Workspace
 	s.analyticsService.Capture(ctx, "workspace archived", analytics.CodebaseID(ws.CodebaseID),
 		analytics.Property("workspace_id", ws.ID),
 	)
+	if err := s.webhooksSender.Codebase(ctx, ws.CodebaseID, webhooks.EventWorkspaceArchived, ws.ID); err != nil {
+		s.logger.Error("failed to send webhook event", zap.Error(err))
+	}
 	return nil
 }
 
@@ -12,6 +12,7 @@ import (
 	"getsturdy.com/api/pkg/queue"
 	"getsturdy.com/api/pkg/snapshots/snapshotter"
 	db_users "getsturdy.com/api/pkg/users/db"
+	sender_webhooks "getsturdy.com/api/pkg/webhooks/sender"
 	db_workspaces "getsturdy.com/api/pkg/workspaces/db"
 	"getsturdy.com/api/vcs"
 	"getsturdy.com/api/vcs/executor"
@@ -59,6 +60,7 @@ func setup(t *testing.T) *testCollaborators {
 		nil, // activitySender
 		executorProvider,
 		eventsSender,
+		sender_webhooks.NewNoopSender(),
 		nil, // snapshotterQueue
 		gitSnapshotter,
 		buildQueue,
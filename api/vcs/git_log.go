@@ -1,6 +1,10 @@
 package vcs
 
 import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
 	"time"
 
 	git "github.com/libgit2/git2go/v33"
@@ -93,3 +97,61 @@ func (repo *repository) log(revwalk *git.RevWalk, limit int) ([]*LogEntry, error
 
 	return out, nil
 }
+
+// LogFirstParent returns up to limit commits, starting with fromCommitID and following the first
+// parent of each commit. This is the history of the branch that the commits were merged into.
+func (repo *repository) LogFirstParent(fromCommitID string, limit int) ([]*CommitDetails, error) {
+	defer getMeterFunc("LogFirstParent")()
+	oid, err := git.NewOid(fromCommitID)
+	if err != nil {
+		return nil, err
+	}
+
+	revwalk, err := repo.r.Walk()
+	if err != nil {
+		return nil, err
+	}
+	defer revwalk.Free()
+
+	revwalk.SimplifyFirstParent()
+	revwalk.Sorting(git.SortTopological)
+
+	if err := revwalk.Push(oid); err != nil {
+		return nil, err
+	}
+
+	var out []*CommitDetails
+	if err := revwalk.Iterate(func(commit *git.Commit) bool {
+		var parents []string
+		for p := uint(0); p < commit.ParentCount(); p++ {
+			parents = append(parents, commit.ParentId(p).String())
+		}
+		out = append(out, &CommitDetails{
+			CommitID: commit.Id().String(),
+			Message:  commit.Message(),
+			Parents:  parents,
+			Author:   commit.Author(),
+		})
+		return len(out) < limit
+	}); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// LogFirstParentPath returns the IDs of the commits on the first parent history of HEAD that modify
+// any file under pathPrefix.
+func (repo *repository) LogFirstParentPath(pathPrefix string) ([]string, error) {
+	defer getMeterFunc("LogFirstParentPath")()
+	cmd := exec.Command("git", "log", "--first-parent", "--format=%H", "HEAD", "--", pathPrefix)
+	errLog := &bytes.Buffer{}
+	outLog := &bytes.Buffer{}
+	cmd.Dir = repo.path
+	cmd.Stderr = errLog
+	cmd.Stdout = outLog
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run git log: %w, %s", err, errLog.String())
+	}
+	return strings.Fields(outLog.String()), nil
+}
@@ -0,0 +1,132 @@
+package vcs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// ObjectCounts is the parsed output of git count-objects -v.
+type ObjectCounts struct {
+	// Count is the number of loose objects.
+	Count int64
+	// Size is the disk space consumed by loose objects, in bytes.
+	Size int64
+	// InPack is the number of objects in packs.
+	InPack int64
+	// Packs is the number of packs.
+	Packs int64
+	// SizePack is the disk space consumed by packs, in bytes.
+	SizePack int64
+	// Garbage is the number of files in the object database that are neither valid objects nor packs.
+	Garbage int64
+	// SizeGarbage is the disk space consumed by garbage files, in bytes.
+	SizeGarbage int64
+}
+
+// TotalObjects returns the number of loose and packed objects.
+func (c *ObjectCounts) TotalObjects() int64 {
+	return c.Count + c.InPack
+}
+
+// TotalSize returns the disk space consumed by the object database, in bytes.
+func (c *ObjectCounts) TotalSize() int64 {
+	return c.Size + c.SizePack + c.SizeGarbage
+}
+
+func (r *repository) runGit(name string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	errLog := &bytes.Buffer{}
+	outLog := &bytes.Buffer{}
+	cmd.Dir = r.path
+	cmd.Stderr = errLog
+	cmd.Stdout = outLog
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w, %s", name, err, errLog.String())
+	}
+	return outLog.Bytes(), nil
+}
+
+// GitRepack repacks all reachable objects into a single pack, and removes redundant packs. Unreachable objects are
+// kept as loose objects, that are pruned by git gc once they are older than gc.pruneExpire, so that objects that
+// are being written to the repository concurrently are not removed.
+func (r *repository) GitRepack() error {
+	if _, err := r.runGit("git-repack", "repack", "-A", "-d", "-l"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GitCommitGraphWrite writes a commit-graph file for all reachable commits.
+func (r *repository) GitCommitGraphWrite() error {
+	if _, err := r.runGit("git-commit-graph", "commit-graph", "write", "--reachable"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GitMultiPackIndexWrite writes a multi-pack-index covering all packs in the repository.
+func (r *repository) GitMultiPackIndexWrite() error {
+	if _, err := r.runGit("git-multi-pack-index", "multi-pack-index", "write"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GitFsck verifies the connectivity and validity of the objects in the repository.
+func (r *repository) GitFsck() error {
+	if _, err := r.runGit("git-fsck", "fsck", "--no-progress", "--no-dangling"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GitCountObjects returns object and disk usage statistics for the repository.
+func (r *repository) GitCountObjects() (*ObjectCounts, error) {
+	out, err := r.runGit("git-count-objects", "count-objects", "-v")
+	if err != nil {
+		return nil, err
+	}
+	return parseCountObjects(out)
+}
+
+func parseCountObjects(out []byte) (*ObjectCounts, error) {
+	counts := &ObjectCounts{}
+	fields := map[string]*int64{
+		"count":        &counts.Count,
+		"size":         &counts.Size,
+		"in-pack":      &counts.InPack,
+		"packs":        &counts.Packs,
+		"size-pack":    &counts.SizePack,
+		"garbage":      &counts.Garbage,
+		"size-garbage": &counts.SizeGarbage,
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		dst, ok := fields[key]
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", key, err)
+		}
+		*dst = n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// count-objects reports sizes in KiB
+	counts.Size *= 1024
+	counts.SizePack *= 1024
+	counts.SizeGarbage *= 1024
+	return counts, nil
+}
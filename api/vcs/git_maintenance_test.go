@@ -0,0 +1,57 @@
+package vcs
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCountObjects(t *testing.T) {
+	out := []byte(`count: 12
+size: 48
+in-pack: 1034
+packs: 2
+size-pack: 512
+prune-packable: 0
+garbage: 1
+size-garbage: 4
+`)
+	counts, err := parseCountObjects(out)
+	assert.NoError(t, err)
+	assert.Equal(t, &ObjectCounts{
+		Count:       12,
+		Size:        48 * 1024,
+		InPack:      1034,
+		Packs:       2,
+		SizePack:    512 * 1024,
+		Garbage:     1,
+		SizeGarbage: 4 * 1024,
+	}, counts)
+	assert.Equal(t, int64(1046), counts.TotalObjects())
+	assert.Equal(t, int64(564*1024), counts.TotalSize())
+}
+
+func TestMaintenance(t *testing.T) {
+	repoPath, err := ioutil.TempDir(os.TempDir(), "sturdy")
+	assert.NoError(t, err)
+
+	repo, err := CreateBareRepoWithRootCommit(repoPath)
+	assert.NoError(t, err)
+
+	_, err = repo.CreateCommitWithFiles([]FileContents{
+		{"README.md", []byte("# Hello World!")},
+	}, "new-branch-name")
+	assert.NoError(t, err)
+
+	assert.NoError(t, repo.GitRepack())
+	assert.NoError(t, repo.GitCommitGraphWrite())
+	assert.NoError(t, repo.GitMultiPackIndexWrite())
+	assert.NoError(t, repo.GitFsck())
+
+	counts, err := repo.GitCountObjects()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), counts.Packs)
+	assert.Equal(t, int64(0), counts.Count)
+}
@@ -0,0 +1,36 @@
+package vcs
+
+import (
+	"errors"
+	"fmt"
+
+	git "github.com/libgit2/git2go/v33"
+)
+
+var ErrTagExists = errors.New("tag already exists")
+
+// CreateAnnotatedTag creates an annotated tag pointing to the commit, and returns the ID of the tag object.
+func (r *repository) CreateAnnotatedTag(name, commitID, message string, tagger git.Signature) (string, error) {
+	defer getMeterFunc("CreateAnnotatedTag")()
+	oid, err := git.NewOid(commitID)
+	if err != nil {
+		return "", fmt.Errorf("invalid commit id: %w", err)
+	}
+
+	commit, err := r.r.LookupCommit(oid)
+	if err != nil {
+		return "", fmt.Errorf("failed to lookup commit: %w", err)
+	}
+	defer commit.Free()
+
+	tagID, err := r.r.Tags.Create(name, commit, &tagger, message)
+	if err != nil {
+		var gitErr *git.GitError
+		if errors.As(err, &gitErr) && gitErr.Code == git.ErrorCodeExists {
+			return "", ErrTagExists
+		}
+		return "", fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return tagID.String(), nil
+}
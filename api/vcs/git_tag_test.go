@@ -0,0 +1,33 @@
+package vcs
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	git "github.com/libgit2/git2go/v33"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAnnotatedTag(t *testing.T) {
+	repoPath, err := ioutil.TempDir(os.TempDir(), "sturdy")
+	assert.NoError(t, err)
+
+	repo, err := CreateBareRepoWithRootCommit(repoPath)
+	assert.NoError(t, err)
+
+	commitID, err := repo.CreateCommitWithFiles([]FileContents{
+		{"README.md", []byte("# Hello World!")},
+	}, "new-branch-name")
+	assert.NoError(t, err)
+
+	tagger := git.Signature{Name: "Sturdy", Email: "support@getsturdy.com", When: time.Now()}
+
+	tagID, err := repo.CreateAnnotatedTag("v1.0.0", commitID, "First release", tagger)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tagID)
+
+	_, err = repo.CreateAnnotatedTag("v1.0.0", commitID, "First release again", tagger)
+	assert.ErrorIs(t, err, ErrTagExists)
+}
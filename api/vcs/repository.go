@@ -38,8 +38,13 @@ type RepoGitReader interface {
 
 	LogHead(limit int) ([]*LogEntry, error)
 	LogBranch(branchName string, limit int) ([]*LogEntry, error)
+	LogFirstParent(fromCommitID string, limit int) ([]*CommitDetails, error)
+	LogFirstParentPath(pathPrefix string) ([]string, error)
 
 	OpenRebase() (*SturdyRebase, error)
+
+	GitFsck() error
+	GitCountObjects() (*ObjectCounts, error)
 }
 
 // RepoGitWriter can read and write to .git
@@ -75,11 +80,16 @@ type RepoGitWriter interface {
 	GitGC() error
 	GitReflogExpire() error
 	GitRemotePrune(remoteName string) error
+	GitRepack() error
+	GitCommitGraphWrite() error
+	GitMultiPackIndexWrite() error
 
 	MergeBranches(ourBranchName, theirBranchName string) (*git.Index, error)
 	MergeBranchInto(branchName, mergeIntoBranchName string) (mergeCommitId string, err error)
 
 	ApplyPatchesToIndex(patches [][]byte) (*git.Oid, error)
+
+	CreateAnnotatedTag(name, commitID, message string, tagger git.Signature) (string, error)
 }
 
 type RepoReaderGitWriter interface {